@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"prreviewer/internal/apierr"
+)
+
+// apiPrefix — версия API, с которой работает клиент.
+const apiPrefix = "/api/v1"
+
+// client — тонкая обертка над HTTP API сервиса.
+type client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// apiError — ошибка, которую вернул сервис в формате apierr.ErrResp.
+type apiError struct {
+	Status    int
+	Code      string
+	Message   string
+	RequestID string
+}
+
+func (e *apiError) Error() string {
+	if e.RequestID == "" {
+		return fmt.Sprintf("%d %s: %s", e.Status, e.Code, e.Message)
+	}
+	return fmt.Sprintf("%d %s: %s (request_id %s)", e.Status, e.Code, e.Message, e.RequestID)
+}
+
+// do отправляет запрос и декодирует тело ответа в out (если out не nil).
+// body сериализуется в JSON; nil — запрос без тела.
+func (c *client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	u := strings.TrimRight(c.baseURL, "/") + apiPrefix + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var e apierr.ErrResp
+		if err := json.NewDecoder(resp.Body).Decode(&e); err != nil || e.Error.Code == "" {
+			return &apiError{Status: resp.StatusCode, Code: http.StatusText(resp.StatusCode), Message: "unexpected response"}
+		}
+		return &apiError{Status: resp.StatusCode, Code: e.Error.Code, Message: e.Error.Message, RequestID: e.Error.RequestID}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,307 @@
+// Command prrevctl — клиент командной строки для HTTP API сервиса назначения
+// ревьюверов: для скриптов эксплуатации и отладки без curl.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"prreviewer/internal/models"
+)
+
+const (
+	defaultURL     = "http://localhost:8080"
+	defaultTimeout = 10 * time.Second
+)
+
+const usage = `Usage: prrevctl [-url URL] [-token TOKEN] <command> [flags]
+
+Commands:
+  team add -f FILE                      create a team from a JSON or YAML file
+  team get -name TEAM                   show a team
+  pr create -id ID -name NAME -author USER [-repo REPO] [-paths a,b]
+            [-labels a,b] [-priority low|normal|high]
+            [-link URL] [-branch BRANCH] [-description TEXT] [-approvals N]
+            [-depends ID,ID]
+  pr get -id ID                         show a PR with its approvals
+  pr merge -id ID [-version N] [-force] merge a PR; -force skips approvals (admin)
+  pr reassign -id ID -old USER [-reason TEXT] [-version N]
+  stats [-format json|table] [-from T] [-to T]
+
+Environment:
+  PRREVCTL_URL    service URL (default http://localhost:8080)
+  PRREVCTL_TOKEN  bearer token for routes that require JWT
+`
+
+// errUsage — неверные аргументы; main выводит справку и завершается с кодом 2.
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	global := flag.NewFlagSet("prrevctl", flag.ExitOnError)
+	global.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	baseURL := global.String("url", envOr("PRREVCTL_URL", defaultURL), "service URL")
+	token := global.String("token", os.Getenv("PRREVCTL_TOKEN"), "bearer token")
+	timeout := global.Duration("timeout", defaultTimeout, "request timeout")
+	_ = global.Parse(os.Args[1:])
+
+	c := &client{baseURL: *baseURL, token: *token, http: &http.Client{Timeout: *timeout}}
+	err := run(context.Background(), c, global.Args(), os.Stdout)
+	switch {
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(os.Stderr, "prrevctl: %v\n\n%s", err, usage)
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "prrevctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func envOr(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+func run(ctx context.Context, c *client, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	switch args[0] {
+	case "team":
+		return runTeam(ctx, c, args[1:], out)
+	case "pr":
+		return runPR(ctx, c, args[1:], out)
+	case "stats":
+		return runStats(ctx, c, args[1:], out)
+	}
+	return fmt.Errorf("%w: unknown command %q", errUsage, args[0])
+}
+
+func runTeam(ctx context.Context, c *client, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: team requires a subcommand", errUsage)
+	}
+	fs := newFlagSet("team " + args[0])
+	switch args[0] {
+	case "add":
+		file := fs.String("f", "", "team file (.json, .yaml or .yml)")
+		if err := parseFlags(fs, args[1:], "f"); err != nil {
+			return err
+		}
+		team, err := readTeamFile(*file)
+		if err != nil {
+			return err
+		}
+		var resp map[string]any
+		if err := c.do(ctx, http.MethodPost, "/team/add", nil, team, &resp); err != nil {
+			return err
+		}
+		return printJSON(out, resp)
+	case "get":
+		name := fs.String("name", "", "team name")
+		if err := parseFlags(fs, args[1:], "name"); err != nil {
+			return err
+		}
+		var resp map[string]any
+		if err := c.do(ctx, http.MethodGet, "/team/get", url.Values{"team_name": {*name}}, nil, &resp); err != nil {
+			return err
+		}
+		return printJSON(out, resp)
+	}
+	return fmt.Errorf("%w: unknown team subcommand %q", errUsage, args[0])
+}
+
+func runPR(ctx context.Context, c *client, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: pr requires a subcommand", errUsage)
+	}
+	fs := newFlagSet("pr " + args[0])
+	id := fs.String("id", "", "pull request id")
+	var (
+		method, path string
+		query        url.Values
+		body         map[string]any
+	)
+	switch args[0] {
+	case "create":
+		name := fs.String("name", "", "pull request name")
+		author := fs.String("author", "", "author user id")
+		repository := fs.String("repo", "", "repository for ownership rules")
+		paths := fs.String("paths", "", "comma-separated changed paths")
+		labels := fs.String("labels", "", "comma-separated PR labels")
+		priority := fs.String("priority", "", "PR priority: low, normal or high")
+		link := fs.String("link", "", "PR URL in GitHub/GitLab")
+		branch := fs.String("branch", "", "source branch")
+		description := fs.String("description", "", "PR description")
+		approvals := fs.Int("approvals", -1, "approvals required to merge, -1 for the team policy")
+		depends := fs.String("depends", "", "comma-separated IDs of PRs to merge or close first")
+		if err := parseFlags(fs, args[1:], "id", "name", "author"); err != nil {
+			return err
+		}
+		method, path = http.MethodPost, "/pullRequest/create"
+		body = map[string]any{"pull_request_id": *id, "pull_request_name": *name, "author_id": *author}
+		if *repository != "" {
+			body["repository"] = *repository
+		}
+		if *paths != "" {
+			body["changed_paths"] = strings.Split(*paths, ",")
+		}
+		if *labels != "" {
+			body["labels"] = strings.Split(*labels, ",")
+		}
+		if *priority != "" {
+			body["priority"] = *priority
+		}
+		if *approvals >= 0 {
+			body["required_approvals"] = *approvals
+		}
+		if *depends != "" {
+			body["depends_on"] = strings.Split(*depends, ",")
+		}
+		for key, value := range map[string]string{"url": *link, "branch": *branch, "description": *description} {
+			if value != "" {
+				body[key] = value
+			}
+		}
+	case "get":
+		if err := parseFlags(fs, args[1:], "id"); err != nil {
+			return err
+		}
+		method, path, query = http.MethodGet, "/pullRequest/get", url.Values{"pull_request_id": {*id}}
+	case "merge", "reassign":
+		version := fs.Int("version", -1, "expected PR version, -1 to skip the check")
+		required := []string{"id"}
+		var old, reason *string
+		var force *bool
+		if args[0] == "reassign" {
+			old = fs.String("old", "", "reviewer to replace")
+			reason = fs.String("reason", "", "why the reviewer is replaced, shown to the new reviewer")
+			required = append(required, "old")
+		} else {
+			force = fs.Bool("force", false, "merge without required approvals (admin token)")
+		}
+		if err := parseFlags(fs, args[1:], required...); err != nil {
+			return err
+		}
+		method, path = http.MethodPost, "/pullRequest/"+args[0]
+		body = map[string]any{"pull_request_id": *id}
+		if old != nil {
+			body["old_user_id"] = *old
+		}
+		if reason != nil && *reason != "" {
+			body["reason"] = *reason
+		}
+		if force != nil && *force {
+			body["force"] = true
+		}
+		if *version >= 0 {
+			body["expected_version"] = *version
+		}
+	default:
+		return fmt.Errorf("%w: unknown pr subcommand %q", errUsage, args[0])
+	}
+
+	var resp map[string]any
+	var reqBody any
+	if body != nil {
+		reqBody = body
+	}
+	if err := c.do(ctx, method, path, query, reqBody, &resp); err != nil {
+		return err
+	}
+	return printJSON(out, resp)
+}
+
+func runStats(ctx context.Context, c *client, args []string, out io.Writer) error {
+	fs := newFlagSet("stats")
+	format := fs.String("format", "json", "output format: json or table")
+	from := fs.String("from", "", "start of period, RFC 3339")
+	to := fs.String("to", "", "end of period, RFC 3339")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *format != "json" && *format != "table" {
+		return fmt.Errorf("%w: unknown format %q", errUsage, *format)
+	}
+
+	query := url.Values{}
+	if *from != "" {
+		query.Set("from", *from)
+	}
+	if *to != "" {
+		query.Set("to", *to)
+	}
+	var stats models.Stats
+	if err := c.do(ctx, http.MethodGet, "/stats", query, nil, &stats); err != nil {
+		return err
+	}
+	if *format == "json" {
+		return printJSON(out, stats)
+	}
+	return printStatsTable(out, stats)
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// parseFlags разбирает флаги подкоманды и проверяет, что обязательные заданы.
+func parseFlags(fs *flag.FlagSet, args []string, required ...string) error {
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %s: %v", errUsage, fs.Name(), err)
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("%w: %s: unexpected argument %q", errUsage, fs.Name(), fs.Arg(0))
+	}
+	for _, name := range required {
+		if fs.Lookup(name).Value.String() == "" {
+			return fmt.Errorf("%w: %s: -%s is required", errUsage, fs.Name(), name)
+		}
+	}
+	return nil
+}
+
+func printJSON(out io.Writer, v any) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func printStatsTable(out io.Writer, s models.Stats) error {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "TEAMS\tUSERS\tPRS\tOPEN\tMERGED\tCLOSED\n")
+	fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%d\t%d\n", s.TotalTeams, s.TotalUsers, s.TotalPRs, s.OpenPRs, s.MergedPRs, s.ClosedPRs)
+	fmt.Fprintln(tw)
+	fmt.Fprintf(tw, "TIME TO MERGE\tAVG\tMEDIAN\tP90\n")
+	fmt.Fprintf(tw, "pr created\t%s\t%s\t%s\n", seconds(s.TimeToMerge.AvgSeconds), seconds(s.TimeToMerge.MedianSeconds), seconds(s.TimeToMerge.P90Seconds))
+	fmt.Fprintf(tw, "reviewer assigned\t%s\t%s\t%s\n", seconds(s.AssignmentToMerge.AvgSeconds), seconds(s.AssignmentToMerge.MedianSeconds), seconds(s.AssignmentToMerge.P90Seconds))
+	fmt.Fprintln(tw)
+	fmt.Fprintf(tw, "USER\tUSERNAME\tASSIGNMENTS\n")
+	for _, a := range s.AssignmentsByUser {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", a.UserID, a.Username, a.Assignments)
+	}
+	if s.AssignmentsByUserTotal > len(s.AssignmentsByUser) {
+		fmt.Fprintf(tw, "... %d more\t\t\n", s.AssignmentsByUserTotal-len(s.AssignmentsByUser))
+	}
+	return tw.Flush()
+}
+
+func seconds(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return time.Duration(*v * float64(time.Second)).Round(time.Second).String()
+}
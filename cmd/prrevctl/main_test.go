@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/models"
+)
+
+func TestReadTeamFileYAML(t *testing.T) {
+	src := `# команда бэкенда
+team_name: backend
+members:
+  - user_id: u1
+    username: "Alice: lead"   # двоеточие внутри кавычек
+    is_active: true
+    review_weight: 2
+    tags: [go, sql]
+  - user_id: '42'
+    username: Bob
+    is_active: false
+    tags:
+      - frontend
+`
+	path := filepath.Join(t.TempDir(), "team.yaml")
+	if err := os.WriteFile(path, []byte(src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readTeamFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := models.Team{TeamName: "backend", Members: []models.TeamMember{
+		{UserID: "u1", Username: "Alice: lead", IsActive: true, ReviewWeight: 2, Tags: []string{"go", "sql"}},
+		{UserID: "42", Username: "Bob", Tags: []string{"frontend"}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ожидалась команда %+v, получили %+v", want, got)
+	}
+}
+
+// TestReadTeamFileYAMLFlowAndAnchors — потоковый синтаксис, якоря и числовые
+// идентификаторы без кавычек.
+func TestReadTeamFileYAMLFlowAndAnchors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "team.yml")
+	src := `team_name: backend
+members: [
+  &alice {user_id: 42, username: Alice, is_active: true, review_weight: 1},
+  {<<: *alice, user_id: u2, username: Bob, review_weight: 3},
+]
+`
+	if err := os.WriteFile(path, []byte(src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readTeamFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := models.Team{TeamName: "backend", Members: []models.TeamMember{
+		{UserID: "42", Username: "Alice", IsActive: true, ReviewWeight: 1},
+		{UserID: "u2", Username: "Bob", IsActive: true, ReviewWeight: 3},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ожидалась команда %+v, получили %+v", want, got)
+	}
+}
+
+func TestReadTeamFileErrors(t *testing.T) {
+	tests := map[string]string{
+		"team.yaml": "team_name: backend\nmembers:\n  - user_id: u1\n    is_active: maybe\n",
+		"bad.yml":   "team_name: backend\n  members: []\n",
+		"team.json": `{"team_name": "backend", "unknown": 1}`,
+	}
+	for name, src := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte(src), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := readTeamFile(path); err == nil {
+				t.Error("ожидалась ошибка разбора")
+			}
+		})
+	}
+}
+
+func TestRunSendsTokenAndReportsAPIError(t *testing.T) {
+	var gotAuth, gotPath string
+	var gotBody map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth, gotPath = r.Header.Get("Authorization"), r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&gotBody)
+		apierr.Write(w, apierr.ErrPRExists)
+	}))
+	defer srv.Close()
+
+	c := &client{baseURL: srv.URL, token: "secret", http: srv.Client()}
+	err := run(context.Background(), c, strings.Fields("pr create -id pr1 -name Fix -author u1"), &bytes.Buffer{})
+
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) || apiErr.Code != "PR_EXISTS" || apiErr.Status != http.StatusConflict {
+		t.Errorf("ожидалась ошибка PR_EXISTS 409, получили %v", err)
+	}
+	if gotPath != "/api/v1/pullRequest/create" {
+		t.Errorf("ожидался запрос к /api/v1/pullRequest/create, получили %q", gotPath)
+	}
+	if gotAuth != "Bearer secret" {
+		t.Errorf("ожидался заголовок Authorization с токеном, получили %q", gotAuth)
+	}
+	if gotBody["pull_request_id"] != "pr1" || gotBody["author_id"] != "u1" {
+		t.Errorf("неожиданное тело запроса: %v", gotBody)
+	}
+}
+
+func TestRunStatsTable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(models.Stats{TotalTeams: 1, TotalPRs: 3, AssignmentsByUser: []models.UserAssignments{
+			{UserID: "u1", Username: "Alice", Assignments: 5},
+		}})
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	c := &client{baseURL: srv.URL, http: srv.Client()}
+	if err := run(context.Background(), c, []string{"stats", "--format", "table"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "u1") || !strings.Contains(out.String(), "Alice") {
+		t.Errorf("в таблице нет назначений пользователя:\n%s", out.String())
+	}
+}
+
+func TestRunUsageErrors(t *testing.T) {
+	c := &client{baseURL: "http://127.0.0.1:0", http: http.DefaultClient}
+	for _, args := range []string{"", "unknown", "pr create -id pr1", "stats -format xml", "pr merge -id pr1 -old u1"} {
+		if err := run(context.Background(), c, strings.Fields(args), &bytes.Buffer{}); !errors.Is(err, errUsage) {
+			t.Errorf("%q: ожидалась ошибка использования, получили %v", args, err)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"prreviewer/internal/models"
+)
+
+// readTeamFile читает описание команды в формате тела POST /team/add: JSON или
+// YAML (по расширению .yaml/.yml).
+func readTeamFile(path string) (models.Team, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return models.Team{}, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return parseTeamYAML(data)
+	default:
+		return decodeTeam(data)
+	}
+}
+
+func decodeTeam(data []byte) (models.Team, error) {
+	var team models.Team
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&team); err != nil {
+		return models.Team{}, fmt.Errorf("decode team: %w", err)
+	}
+	return team, nil
+}
+
+// typedFields — поля команды, значения которых в YAML не строки. Остальные
+// скаляры берутся строками: user_id: 42 — это "42", а не число.
+var typedFields = map[string]bool{
+	"is_active":     true,
+	"review_weight": true,
+}
+
+// parseTeamYAML разбирает описание команды в YAML и декодирует его так же, как
+// JSON, поэтому неизвестные поля тоже отклоняются. Поддерживается весь YAML
+// (потоковые списки и отображения, кавычки, якоря и <<), кроме нескольких
+// документов в одном файле.
+func parseTeamYAML(data []byte) (models.Team, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return models.Team{}, fmt.Errorf("decode team: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return models.Team{}, fmt.Errorf("empty team file")
+	}
+	v, err := yamlValue(doc.Content[0], "")
+	if err != nil {
+		return models.Team{}, err
+	}
+	data, err = json.Marshal(v)
+	if err != nil {
+		return models.Team{}, err
+	}
+	return decodeTeam(data)
+}
+
+// yamlValue переводит узел YAML в значение для encoding/json; key — ключ
+// отображения, под которым лежит узел.
+func yamlValue(n *yaml.Node, key string) (any, error) {
+	switch n.Kind {
+	case yaml.AliasNode:
+		return yamlValue(n.Alias, key)
+	case yaml.SequenceNode:
+		list := make([]any, 0, len(n.Content))
+		for _, item := range n.Content {
+			v, err := yamlValue(item, "")
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case yaml.MappingNode:
+		m := map[string]any{}
+		if err := yamlMerge(m, n); err != nil {
+			return nil, err
+		}
+		return m, nil
+	case yaml.ScalarNode:
+		if n.Tag == "!!null" {
+			return nil, nil
+		}
+		if !typedFields[key] {
+			return n.Value, nil
+		}
+		var v any
+		if err := n.Decode(&v); err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", n.Line, key, err)
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("line %d: unexpected YAML node", n.Line)
+}
+
+// yamlMerge добавляет в m ключи отображения n; ключи из << (слияние с якорем)
+// не перекрывают заданные в самом отображении.
+func yamlMerge(m map[string]any, n *yaml.Node) error {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	var merges []*yaml.Node
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		if k.Tag == "!!merge" {
+			if v.Kind == yaml.SequenceNode {
+				merges = append(merges, v.Content...)
+			} else {
+				merges = append(merges, v)
+			}
+			continue
+		}
+		if _, dup := m[k.Value]; dup {
+			return fmt.Errorf("line %d: duplicate key %q", k.Line, k.Value)
+		}
+		val, err := yamlValue(v, k.Value)
+		if err != nil {
+			return err
+		}
+		m[k.Value] = val
+	}
+	for _, src := range merges {
+		base := map[string]any{}
+		if err := yamlMerge(base, src); err != nil {
+			return err
+		}
+		for k, v := range base {
+			if _, ok := m[k]; !ok {
+				m[k] = v
+			}
+		}
+	}
+	return nil
+}
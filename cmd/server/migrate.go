@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+
+	"prreviewer/migrations"
+)
+
+// Режимы MIGRATIONS_MODE: что делать со схемой при старте HTTP API.
+const (
+	migrationsAuto = "auto" // применить недостающие миграции
+	migrationsSkip = "skip" // не трогать схему
+	migrationsFail = "fail" // не стартовать, если схема отстает или в грязном состоянии
+)
+
+// newMigrate создает migrate.Migrate поверх встроенных в бинарник миграций.
+func newMigrate(dbURL string) (*migrate.Migrate, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("open embedded migrations: %w", err)
+	}
+	return migrate.NewWithSourceInstance("iofs", src, dbURL)
+}
+
+// runMigrations применяет все недостающие миграции; любая ошибка завершает процесс.
+func runMigrations(dbURL string) {
+	slog.Info("running database migrations")
+	m, err := newMigrate(dbURL)
+	if err != nil {
+		fatal("migration init failed", "error", err)
+	}
+	defer m.Close()
+
+	switch err := m.Up(); {
+	case errors.Is(err, migrate.ErrNoChange):
+		slog.Info("no new migrations to apply")
+	case err != nil:
+		fatal("migration up failed", "error", err)
+	default:
+		slog.Info("migrations applied")
+	}
+}
+
+// runMigrateCmd выполняет подкоманду server migrate: up (по умолчанию), down [N]
+// для отката N последних миграций (по умолчанию одной) или version.
+func runMigrateCmd(dbURL string, args []string) {
+	action := "up"
+	if len(args) > 0 {
+		action = args[0]
+	}
+	switch action {
+	case "up":
+		runMigrations(dbURL)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				fatal("invalid number of migrations to roll back", "value", args[1])
+			}
+			steps = n
+		}
+		rollbackMigrations(dbURL, steps)
+	case "version":
+		printMigrationVersion(dbURL)
+	default:
+		fatal("unknown migrate command, expected one of: up, down, version", "command", action)
+	}
+}
+
+// rollbackMigrations откатывает steps последних примененных миграций.
+func rollbackMigrations(dbURL string, steps int) {
+	m, err := newMigrate(dbURL)
+	if err != nil {
+		fatal("migration init failed", "error", err)
+	}
+	defer m.Close()
+
+	slog.Info("rolling back database migrations", "steps", steps)
+	if err := m.Steps(-steps); err != nil {
+		fatal("migration down failed", "error", err)
+	}
+	version, _, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		slog.Info("all migrations rolled back")
+		return
+	}
+	slog.Info("migrations rolled back", "version", version)
+}
+
+// printMigrationVersion выводит в stdout текущую версию схемы и последнюю встроенную миграцию.
+func printMigrationVersion(dbURL string) {
+	m, err := newMigrate(dbURL)
+	if err != nil {
+		fatal("migration init failed", "error", err)
+	}
+	defer m.Close()
+
+	version, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		fatal("read schema version failed", "error", err)
+	}
+	fmt.Printf("version: %d\ndirty: %t\nlatest: %d\n", version, dirty, latestMigration())
+}
+
+// checkMigrations завершает процесс, если схема не соответствует встроенным миграциям.
+func checkMigrations(dbURL string) {
+	m, err := newMigrate(dbURL)
+	if err != nil {
+		fatal("migration init failed", "error", err)
+	}
+	defer m.Close()
+
+	version, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		fatal("read schema version failed", "error", err)
+	}
+	latest := latestMigration()
+	switch {
+	case dirty:
+		fatal("schema is dirty, fix the failed migration first", "version", version)
+	case version < latest:
+		fatal("schema has pending migrations", "version", version, "latest", latest)
+	}
+	slog.Info("schema is up to date", "version", version)
+}
+
+// migrateOnStart обрабатывает схему перед запуском HTTP API согласно MIGRATIONS_MODE;
+// def — режим, если переменная не задана.
+func migrateOnStart(dbURL, def string) {
+	mode := os.Getenv("MIGRATIONS_MODE")
+	if mode == "" {
+		mode = def
+	}
+	switch mode {
+	case migrationsAuto:
+		runMigrations(dbURL)
+	case migrationsSkip:
+	case migrationsFail:
+		checkMigrations(dbURL)
+	default:
+		fatal("invalid MIGRATIONS_MODE, expected one of: auto, skip, fail", "value", mode)
+	}
+}
+
+// latestMigration возвращает номер последней встроенной миграции.
+func latestMigration() uint {
+	latest, err := migrations.Latest()
+	if err != nil {
+		fatal("read embedded migrations failed", "error", err)
+	}
+	return latest
+}
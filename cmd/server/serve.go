@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"prreviewer/internal/app"
+)
+
+// shutdownTimeout — сколько ждать завершения текущих запросов при остановке.
+const shutdownTimeout = 10 * time.Second
+
+// runServe запускает HTTP API без применения миграций до получения SIGINT/SIGTERM.
+func runServe(cfg app.Config) {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	slog.Info("starting application initialization")
+	a, err := app.New(ctx, cfg)
+	if err != nil {
+		fatal("application initialization failed", "error", err)
+	}
+	if err := a.Start(); err != nil {
+		fatal("server failed to start", "error", err)
+	}
+
+	select {
+	case <-ctx.Done():
+	case err := <-a.Err():
+		fatal("server failed", "error", err)
+	}
+
+	slog.Info("server stopping")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := a.Stop(shutdownCtx); err != nil {
+		slog.Error("server shutdown failed", "error", err)
+	}
+	slog.Info("server stopped")
+}
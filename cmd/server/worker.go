@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os/signal"
+	"syscall"
+
+	"prreviewer/internal/app"
+	"prreviewer/internal/repo"
+	"prreviewer/internal/worker"
+)
+
+// workerLeaderLock — имя advisory-блокировки лидера фоновых задач.
+const workerLeaderLock = "prreviewer:worker"
+
+// runWorker запускает фоновые задачи до получения SIGINT/SIGTERM.
+func runWorker(cfg app.Config) {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	db, err := app.Connect(ctx, cfg)
+	if err != nil {
+		fatal("failed to connect to database", "error", err)
+	}
+	defer db.Close()
+
+	// Несколько экземпляров воркера выполняют задачи по очереди: по расписанию
+	// работает только держатель блокировки, остальные ждут в резерве.
+	runner, err := app.NewJobs(ctx, db, cfg, worker.WithElector(repo.NewLeaderLock(db, workerLeaderLock)))
+	if err != nil {
+		fatal("worker initialization failed", "error", err)
+	}
+
+	slog.Info("worker started", "jobs", runner.Len())
+	runner.Run(ctx)
+	slog.Info("worker stopped")
+}
@@ -0,0 +1,180 @@
+package integration_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"prreviewer/internal/app"
+	"prreviewer/internal/logging"
+	"prreviewer/migrations"
+)
+
+const (
+	postgresImage = "postgres:15-alpine"
+	// harnessMaxConns — размер пула, как у test_app в docker-compose.test.yml:
+	// тесты гоняют параллельные запросы.
+	harnessMaxConns = 50
+	postgresTimeout = time.Minute
+)
+
+// errNoDocker — ни TEST_DATABASE_URL, ни docker: поднять БД для тестов нечем.
+var errNoDocker = errors.New("docker not found and TEST_DATABASE_URL is not set")
+
+// harness — сервис, запущенный в процессе тестов поверх отдельной БД.
+type harness struct {
+	URL string
+
+	server    *httptest.Server
+	app       *app.App
+	container string // ID контейнера Postgres; пустой — БД из TEST_DATABASE_URL
+}
+
+// startHarness поднимает Postgres в контейнере docker (или берет БД из
+// TEST_DATABASE_URL), применяет миграции и запускает сервис в процессе с теми
+// же настройками, что test_app в docker-compose.test.yml.
+func startHarness(ctx context.Context) (_ *harness, err error) {
+	h := &harness{}
+	defer func() {
+		if err != nil {
+			h.Close()
+		}
+	}()
+
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		if dbURL, err = h.startPostgres(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if err := waitForPostgres(ctx, dbURL); err != nil {
+		return nil, err
+	}
+	if err := migrateUp(dbURL); err != nil {
+		return nil, err
+	}
+
+	slog.SetDefault(logging.New(os.Stderr, slog.LevelWarn))
+	cfg := app.DefaultConfig()
+	cfg.DatabaseURL = dbURL
+	if cfg.Pool, err = pgxpool.ParseConfig(dbURL); err != nil {
+		return nil, err
+	}
+	cfg.Pool.MaxConns = harnessMaxConns
+	cfg.AssignmentSeed = true
+	if h.app, err = app.New(ctx, cfg); err != nil {
+		return nil, err
+	}
+	h.server = httptest.NewServer(h.app.Handler)
+	h.URL = h.server.URL
+	return h, nil
+}
+
+// startPostgres запускает контейнер Postgres на свободном порту localhost
+// и возвращает URL базы.
+func (h *harness) startPostgres(ctx context.Context) (string, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return "", errNoDocker
+	}
+	id, err := docker(ctx, "run", "-d", "--rm",
+		"-e", "POSTGRES_USER=test",
+		"-e", "POSTGRES_PASSWORD=test",
+		"-e", "POSTGRES_DB=prreviewer_test",
+		"-p", "127.0.0.1::5432",
+		postgresImage)
+	if err != nil {
+		return "", err
+	}
+	h.container = id
+
+	addr, err := docker(ctx, "port", id, "5432/tcp")
+	if err != nil {
+		return "", err
+	}
+	// docker port печатает адрес на каждое семейство, берем первый.
+	addr, _, _ = strings.Cut(addr, "\n")
+	return fmt.Sprintf("postgres://test:test@%s/prreviewer_test?sslmode=disable", addr), nil
+}
+
+// docker выполняет команду docker и возвращает ее вывод без пробелов по краям.
+func docker(ctx context.Context, args ...string) (string, error) {
+	out, err := exec.CommandContext(ctx, "docker", args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("docker %s: %w: %s", args[0], err, exitErr.Stderr)
+		}
+		return "", fmt.Errorf("docker %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// waitForPostgres ждет, пока БД начнет принимать соединения. Во время
+// инициализации контейнер слушает только Unix-сокет, поэтому успешное
+// подключение по TCP означает, что база готова.
+func waitForPostgres(ctx context.Context, dbURL string) error {
+	ctx, cancel := context.WithTimeout(ctx, postgresTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		conn, err := pgx.Connect(ctx, dbURL)
+		if err == nil {
+			err = conn.Ping(ctx)
+			_ = conn.Close(ctx)
+			if err == nil {
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("postgres is not ready: %w", err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// migrateUp применяет встроенные миграции.
+func migrateUp(dbURL string) error {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return err
+	}
+	m, err := migrate.NewWithSourceInstance("iofs", src, dbURL)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migrate up: %w", err)
+	}
+	return nil
+}
+
+// Close останавливает сервис и удаляет контейнер.
+func (h *harness) Close() {
+	if h.server != nil {
+		h.server.Close()
+	}
+	if h.app != nil {
+		_ = h.app.Stop(context.Background())
+	}
+	if h.container != "" {
+		if _, err := docker(context.Background(), "rm", "-f", "-v", h.container); err != nil {
+			slog.Error("failed to remove postgres container", "container", h.container, "error", err)
+		}
+	}
+}
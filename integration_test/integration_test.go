@@ -1,44 +1,82 @@
 package integration_test
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
 
 const (
 	pathHealth         = "/health"
+	pathHealthLive     = "/health/live"
+	pathHealthReady    = "/health/ready"
 	pathTeamAdd        = "/team/add"
+	pathTeamRename     = "/team/rename"
 	pathTeamGet        = "/team/get"
+	pathTeamImport     = "/team/import"
+	pathTeamList       = "/team/list"
 	pathTeamDeactivate = "/team/deactivate"
 	pathUserActive     = "/users/setIsActive"
 	pathUserReviews    = "/users/getReview"
+	pathUserDelete     = "/users/delete"
+	pathUserGet        = "/users/get"
+	pathUserList       = "/users/list"
+	pathUserVacation   = "/users/setVacation"
+	pathUserSnooze     = "/users/snooze"
+	pathUserTags       = "/users/setTags"
+	pathUserNotify     = "/users/setNotifications"
+	pathUserIdentity   = "/users/setIdentity"
+	pathUserIdentities = "/users/identities"
+	pathUserByIdentity = "/users/byIdentity"
+	pathSCIMUsers      = "/scim/v2/Users"
+	pathDirectorySync  = "/directory/sync"
+	pathExport         = "/export/assignments"
+	pathImportGitHub   = "/import/github"
 	pathPRCreate       = "/pullRequest/create"
 	pathPRMerge        = "/pullRequest/merge"
 	pathPRReassign     = "/pullRequest/reassign"
+	pathPRAssign       = "/pullRequest/assign"
+	pathPRUnassign     = "/pullRequest/unassign"
+	pathPRAddReviewer  = "/pullRequest/addReviewer"
+	pathPRApprove      = "/pullRequest/approve"
+	pathPRDecline      = "/pullRequest/decline"
+	pathPRClose        = "/pullRequest/close"
+	pathPRSetStatus    = "/pullRequest/setStatus"
+	pathPRStale        = "/pullRequest/stale"
+	pathPRBlocked      = "/pullRequest/blocked"
+	pathPRGet          = "/pullRequest/get"
+	pathPRArchived     = "/pullRequest/archived"
+	pathTeamPolicy     = "/team/policy"
 	pathStats          = "/stats"
+	pathTeamStats      = "/stats/team"
+	pathSLAStats       = "/stats/sla"
+	pathAudit          = "/audit"
+	pathOwnership      = "/ownership/rules"
+	pathOpenAPI        = "/openapi.json"
 )
 
+// assignmentSeed фиксирует выбор ревьюверов (сервис запущен с DEV_ASSIGNMENT_SEED=true).
+const assignmentSeed = "42"
+
 var (
 	baseURL string
 	client  *http.Client
 )
 
-func Init() {
-	baseURL = os.Getenv("TEST_BASE_URL")
-	if baseURL == "" {
-		baseURL = "http://localhost:8081"
-	}
-	client = &http.Client{Timeout: 5 * time.Second}
-}
-
 func waitForService(ctx context.Context) error {
 	ticker := time.NewTicker(500 * time.Millisecond)
 	defer ticker.Stop()
@@ -62,17 +100,43 @@ func waitForService(ctx context.Context) error {
 	}
 }
 
+// TestMain запускает тесты против сервиса из TEST_BASE_URL, а без него —
+// против сервиса в процессе поверх Postgres в docker (см. startHarness).
+// Без docker и TEST_DATABASE_URL прогон падает, а с -short интеграционные
+// тесты пропускаются.
 func TestMain(m *testing.M) {
-	Init()
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	os.Exit(runTests(m))
+}
 
-	if err := waitForService(ctx); err != nil {
-		cancel()
-		log.Fatalf("сервис не готов: %v", err)
+func runTests(m *testing.M) int {
+	client = &http.Client{Timeout: 5 * time.Second}
+	baseURL = os.Getenv("TEST_BASE_URL")
+	if baseURL == "" {
+		flag.Parse()
+		h, err := startHarness(context.Background())
+		if errors.Is(err, errNoDocker) {
+			if testing.Short() {
+				log.Printf("интеграционные тесты пропущены (-short): %v", err)
+				return 0
+			}
+			log.Printf("интеграционные тесты не запущены: %v; задайте TEST_DATABASE_URL или TEST_BASE_URL либо запустите с -short", err)
+			return 1
+		}
+		if err != nil {
+			log.Printf("не удалось поднять окружение тестов: %v", err)
+			return 1
+		}
+		defer h.Close()
+		baseURL = h.URL
 	}
 
-	cancel()
-	os.Exit(m.Run())
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := waitForService(ctx); err != nil {
+		log.Printf("сервис не готов: %v", err)
+		return 1
+	}
+	return m.Run()
 }
 
 // Вспомогательные функции.
@@ -90,6 +154,7 @@ func doRequest(ctx context.Context, method, path string, body interface{}) (*htt
 		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Assignment-Seed", assignmentSeed)
 	return client.Do(req)
 }
 
@@ -112,6 +177,7 @@ func post(ctx context.Context, path, body string) (*http.Response, error) {
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Assignment-Seed", assignmentSeed)
 
 	return client.Do(req)
 }
@@ -122,6 +188,7 @@ func get(ctx context.Context, path string) (*http.Response, error) {
 	if err != nil {
 		return nil, err
 	}
+	req.Header.Set("X-Assignment-Seed", assignmentSeed)
 
 	return client.Do(req)
 }
@@ -139,6 +206,39 @@ func TestHealthCheck(t *testing.T) {
 	}
 }
 
+func TestHealthProbes(t *testing.T) {
+	resp, err := doRequest(context.Background(), http.MethodGet, pathHealthLive, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("live: ожидался 200, получили %d", resp.StatusCode)
+	}
+
+	resp2, err := doRequest(context.Background(), http.MethodGet, pathHealthReady, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp2)
+	if resp2.StatusCode != http.StatusOK {
+		t.Errorf("ready: ожидался 200, получили %d", resp2.StatusCode)
+	}
+	var report struct {
+		Status     string `json:"status"`
+		Components map[string]struct {
+			Status  string `json:"status"`
+			Version *uint  `json:"version"`
+		} `json:"components"`
+	}
+	if err := json.NewDecoder(resp2.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Components["database"].Status != "up" || report.Components["migrations"].Version == nil {
+		t.Errorf("ожидались доступная БД и версия схемы, получили %+v", report)
+	}
+}
+
 func TestTeamAdd(t *testing.T) {
 	ctx := context.Background()
 	payload := map[string]interface{}{
@@ -366,6 +466,53 @@ func TestPRMergeNotFound(t *testing.T) {
 	}
 }
 
+func TestPRMergeVersionConflict(t *testing.T) {
+	ctx := context.Background()
+	prID := fmt.Sprintf("pr_version_%d", time.Now().UnixNano())
+
+	resp1, _ := post(ctx, pathPRCreate,
+		fmt.Sprintf(
+			`{"pull_request_id":"%s","pull_request_name":"Version PR","author_id":"user1"}`,
+			prID,
+		),
+	)
+	closeResp(resp1)
+
+	resp, err := post(ctx, pathPRMerge,
+		fmt.Sprintf(`{"pull_request_id":"%s","expected_version":100}`, prID),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp)
+
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("ожидался 409 VERSION_CONFLICT, получили %d", resp.StatusCode)
+	}
+
+	resp2, err := post(ctx, pathPRMerge,
+		fmt.Sprintf(`{"pull_request_id":"%s","expected_version":1}`, prID),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp2)
+
+	if resp2.StatusCode != http.StatusOK {
+		t.Errorf("ожидался 200, получили %d", resp2.StatusCode)
+	}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp2.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	pr := result["pr"].(map[string]interface{})
+	if pr["version"] != float64(2) {
+		t.Errorf("ожидалась версия 2 после merge, получили %v", pr["version"])
+	}
+}
+
 func TestPRReassign(t *testing.T) {
 	ctx := context.Background()
 	prID := fmt.Sprintf("pr_reassign_%d", time.Now().UnixNano())
@@ -384,7 +531,7 @@ func TestPRReassign(t *testing.T) {
 	reviewers := pr["assigned_reviewers"].([]interface{})
 
 	if len(reviewers) == 0 {
-		t.Skip("ревьюеры отсутствуют — пропускаем тест")
+		t.Fatal("ревьюеры не назначены")
 	}
 
 	oldReviewer := reviewers[0].(string)
@@ -402,6 +549,78 @@ func TestPRReassign(t *testing.T) {
 	}
 }
 
+func TestPRReassignConcurrent(t *testing.T) {
+	ctx := context.Background()
+	prID := fmt.Sprintf("pr_reassign_concurrent_%d", time.Now().UnixNano())
+
+	resp, _ := post(ctx, pathPRCreate,
+		fmt.Sprintf(`{"pull_request_id":"%s","pull_request_name":"Concurrent PR","author_id":"user1"}`, prID),
+	)
+	var created struct {
+		PR struct {
+			AssignedReviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	closeResp(resp)
+	if len(created.PR.AssignedReviewers) == 0 {
+		t.Fatal("ревьюеры не назначены")
+	}
+	oldReviewer := created.PR.AssignedReviewers[0]
+
+	const workers = 5
+	codes := make([]int, workers)
+	var wg sync.WaitGroup
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := post(ctx, pathPRReassign,
+				fmt.Sprintf(`{"pull_request_id":"%s","old_user_id":"%s"}`, prID, oldReviewer),
+			)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			closeResp(resp)
+			codes[i] = resp.StatusCode
+		}()
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, code := range codes {
+		switch code {
+		case http.StatusOK:
+			succeeded++
+		case http.StatusConflict:
+		default:
+			t.Errorf("ожидались 200 или 409, получили %d", code)
+		}
+	}
+	if succeeded > 1 {
+		t.Errorf("один ревьювер не может быть заменен дважды, успешных замен: %d", succeeded)
+	}
+
+	resp2, err := get(ctx, pathPRGet+"?pull_request_id="+prID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp2)
+	var result struct {
+		PR struct {
+			AssignedReviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	if err := json.NewDecoder(resp2.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.PR.AssignedReviewers) != len(created.PR.AssignedReviewers) {
+		t.Errorf("число ревьюверов не должно меняться, было %v, стало %v",
+			created.PR.AssignedReviewers, result.PR.AssignedReviewers)
+	}
+}
+
 func TestPRReassignMerged(t *testing.T) {
 	ctx := context.Background()
 	prID := fmt.Sprintf("pr_reassign_merged_%d", time.Now().UnixNano())
@@ -420,7 +639,7 @@ func TestPRReassignMerged(t *testing.T) {
 	reviewers := pr["assigned_reviewers"].([]interface{})
 
 	if len(reviewers) == 0 {
-		t.Skip("ревьюеры отсутствуют — пропускаем тест")
+		t.Fatal("ревьюеры не назначены")
 	}
 
 	resp1, _ := post(ctx, pathPRMerge, fmt.Sprintf(`{"pull_request_id":"%s"}`, prID))
@@ -439,141 +658,2401 @@ func TestPRReassignMerged(t *testing.T) {
 	}
 }
 
-func TestPRReassignNotAssigned(t *testing.T) {
+func TestPRClose(t *testing.T) {
 	ctx := context.Background()
-	prID := fmt.Sprintf("pr_reassign_na_%d", time.Now().UnixNano())
+	prID := fmt.Sprintf("pr_close_%d", time.Now().UnixNano())
 
-	resp1, _ := post(ctx, pathPRCreate,
+	resp, _ := post(ctx, pathPRCreate,
 		fmt.Sprintf(
-			`{"pull_request_id":"%s","pull_request_name":"NA PR","author_id":"user5"}`,
+			`{"pull_request_id":"%s","pull_request_name":"Closed PR","author_id":"user1"}`,
 			prID,
 		),
 	)
-	closeResp(resp1)
+	var created map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&created)
+	closeResp(resp)
 
-	resp, err := post(ctx, pathPRReassign,
-		fmt.Sprintf(`{"pull_request_id":"%s","old_user_id":"user1"}`, prID),
-	)
+	reviewers := created["pr"].(map[string]interface{})["assigned_reviewers"].([]interface{})
+
+	resp1, err := post(ctx, pathPRClose, fmt.Sprintf(`{"pull_request_id":"%s"}`, prID))
 	if err != nil {
 		t.Fatal(err)
 	}
-	defer closeResp(resp)
+	var result map[string]interface{}
+	_ = json.NewDecoder(resp1.Body).Decode(&result)
+	closeResp(resp1)
 
-	if resp.StatusCode != http.StatusConflict {
-		t.Errorf("ожидался 409 NOT_ASSIGNED, получили %d", resp.StatusCode)
+	if resp1.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp1.StatusCode)
+	}
+	pr := result["pr"].(map[string]interface{})
+	if pr["status"] != "CLOSED" {
+		t.Errorf("ожидался статус CLOSED, получили %v", pr["status"])
+	}
+	if len(pr["assigned_reviewers"].([]interface{})) != 0 {
+		t.Errorf("ревьюверы должны быть сняты с закрытого PR")
+	}
+
+	resp2, _ := post(ctx, pathPRMerge, fmt.Sprintf(`{"pull_request_id":"%s"}`, prID))
+	closeResp(resp2)
+	if resp2.StatusCode != http.StatusConflict {
+		t.Errorf("ожидался 409 PR_CLOSED при merge, получили %d", resp2.StatusCode)
+	}
+
+	if len(reviewers) > 0 {
+		resp3, _ := post(ctx, pathPRReassign,
+			fmt.Sprintf(`{"pull_request_id":"%s","old_user_id":"%s"}`, prID, reviewers[0].(string)),
+		)
+		closeResp(resp3)
+		if resp3.StatusCode != http.StatusConflict {
+			t.Errorf("ожидался 409 PR_CLOSED при переназначении, получили %d", resp3.StatusCode)
+		}
 	}
 }
 
-func TestUsersGetReview(t *testing.T) {
+func TestPRSetStatus(t *testing.T) {
 	ctx := context.Background()
-	prID := fmt.Sprintf("pr_getreview_%d", time.Now().UnixNano())
+	prID := fmt.Sprintf("pr_status_%d", time.Now().UnixNano())
 
-	resp, _ := post(ctx, pathPRCreate,
-		fmt.Sprintf(
-			`{"pull_request_id":"%s","pull_request_name":"GetReview PR","author_id":"user1"}`,
-			prID,
-		),
-	)
-	var result map[string]interface{}
-	_ = json.NewDecoder(resp.Body).Decode(&result)
+	resp, err := post(ctx, pathPRCreate,
+		fmt.Sprintf(`{"pull_request_id":"%s","pull_request_name":"Status PR","author_id":"user1"}`, prID))
+	if err != nil {
+		t.Fatal(err)
+	}
 	closeResp(resp)
 
-	pr := result["pr"].(map[string]interface{})
-	reviewers := pr["assigned_reviewers"].([]interface{})
-
-	if len(reviewers) == 0 {
-		t.Skip("ревьюеры отсутствуют — пропускаем тест")
+	resp1, err := post(ctx, pathPRSetStatus,
+		fmt.Sprintf(`{"pull_request_id":"%s","status":"CHANGES_REQUESTED"}`, prID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result map[string]interface{}
+	_ = json.NewDecoder(resp1.Body).Decode(&result)
+	closeResp(resp1)
+	if resp1.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp1.StatusCode)
+	}
+	if status := result["pr"].(map[string]interface{})["status"]; status != "CHANGES_REQUESTED" {
+		t.Errorf("ожидался статус CHANGES_REQUESTED, получили %v", status)
 	}
 
-	reviewer := reviewers[0].(string)
+	resp2, _ := post(ctx, pathPRSetStatus, fmt.Sprintf(`{"pull_request_id":"%s","status":"MERGED"}`, prID))
+	closeResp(resp2)
+	if resp2.StatusCode != http.StatusBadRequest {
+		t.Errorf("MERGED задается только merge: ожидался 400, получили %d", resp2.StatusCode)
+	}
 
-	resp2, err := get(ctx, pathUserReviews+"?user_id="+reviewer)
+	resp3, err := get(ctx, pathStats)
 	if err != nil {
 		t.Fatal(err)
 	}
-	defer closeResp(resp2)
+	var stats struct {
+		PRsByStatus map[string]int `json:"prs_by_status"`
+	}
+	_ = json.NewDecoder(resp3.Body).Decode(&stats)
+	closeResp(resp3)
+	if stats.PRsByStatus["CHANGES_REQUESTED"] < 1 || len(stats.PRsByStatus) != 5 {
+		t.Errorf("prs_by_status должен содержать все состояния и учесть PR, получили %v", stats.PRsByStatus)
+	}
 
-	if resp2.StatusCode != http.StatusOK {
-		t.Errorf("ожидался 200, получили %d", resp2.StatusCode)
+	resp4, _ := post(ctx, pathPRMerge, fmt.Sprintf(`{"pull_request_id":"%s"}`, prID))
+	closeResp(resp4)
+	if resp4.StatusCode != http.StatusOK {
+		t.Fatalf("merge из CHANGES_REQUESTED: ожидался 200, получили %d", resp4.StatusCode)
 	}
 
-	var result2 map[string]interface{}
-	if err := json.NewDecoder(resp2.Body).Decode(&result2); err != nil {
-		t.Fatal(err)
+	resp5, _ := post(ctx, pathPRSetStatus, fmt.Sprintf(`{"pull_request_id":"%s","status":"OPEN"}`, prID))
+	closeResp(resp5)
+	if resp5.StatusCode != http.StatusConflict {
+		t.Errorf("смерженный PR: ожидался 409 PR_MERGED, получили %d", resp5.StatusCode)
 	}
+}
 
-	prs := result2["pull_requests"].([]interface{})
-	found := false
+func TestPRGet(t *testing.T) {
+	ctx := context.Background()
+	prID := fmt.Sprintf("pr_get_%d", time.Now().UnixNano())
 
-	for _, p := range prs {
-		if p.(map[string]interface{})["pull_request_id"] == prID {
-			found = true
-			break
-		}
+	resp, _ := post(ctx, pathPRCreate,
+		fmt.Sprintf(`{"pull_request_id":"%s","pull_request_name":"Get PR","author_id":"user1"}`, prID),
+	)
+	closeResp(resp)
+
+	resp1, err := get(ctx, pathPRGet+"?pull_request_id="+prID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp1)
+	if resp1.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp1.StatusCode)
+	}
+	var result struct {
+		PR struct {
+			ID                string   `json:"pull_request_id"`
+			AssignedReviewers []string `json:"assigned_reviewers"`
+			CreatedAt         *string  `json:"createdAt"`
+			Approval          *struct {
+				Satisfied bool `json:"satisfied"`
+			} `json:"approval"`
+			History []struct {
+				EventType string `json:"event_type"`
+				NewUserID string `json:"new_user_id"`
+			} `json:"history"`
+		} `json:"pr"`
+	}
+	if err := json.NewDecoder(resp1.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.PR.ID != prID || result.PR.CreatedAt == nil || result.PR.Approval == nil {
+		t.Errorf("ожидался PR %s со временем создания и статусом одобрений, получили %+v", prID, result.PR)
+	}
+	if len(result.PR.History) != len(result.PR.AssignedReviewers) {
+		t.Errorf("история должна содержать по событию ASSIGNED на ревьювера, получили %+v", result.PR.History)
 	}
 
-	if !found {
-		t.Errorf("PR должен присутствовать в списке ревью пользователя")
+	resp2, _ := get(ctx, pathPRGet+"?pull_request_id=missing_"+prID)
+	closeResp(resp2)
+	if resp2.StatusCode != http.StatusNotFound {
+		t.Errorf("ожидался 404 для неизвестного PR, получили %d", resp2.StatusCode)
+	}
+
+	resp3, _ := get(ctx, pathPRGet)
+	closeResp(resp3)
+	if resp3.StatusCode != http.StatusBadRequest {
+		t.Errorf("ожидался 400 без pull_request_id, получили %d", resp3.StatusCode)
 	}
 }
 
-func TestStats(t *testing.T) {
-	resp, err := get(context.Background(), pathStats)
+func TestPRTimeline(t *testing.T) {
+	ctx := context.Background()
+	prID := fmt.Sprintf("pr_timeline_%d", time.Now().UnixNano())
+
+	resp, _ := post(ctx, pathPRCreate,
+		fmt.Sprintf(`{"pull_request_id":"%s","pull_request_name":"Timeline PR","author_id":"user1"}`, prID),
+	)
+	closeResp(resp)
+	resp, _ = post(ctx, pathPRMerge, fmt.Sprintf(`{"pull_request_id":"%s"}`, prID))
+	closeResp(resp)
+
+	resp1, err := get(ctx, "/pullRequest/"+prID+"/timeline")
 	if err != nil {
 		t.Fatal(err)
 	}
-	defer closeResp(resp)
-
-	if resp.StatusCode != http.StatusOK {
-		t.Errorf("ожидался 200, получили %d", resp.StatusCode)
+	defer closeResp(resp1)
+	if resp1.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp1.StatusCode)
 	}
-
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	var result struct {
+		Status   string `json:"status"`
+		Timeline []struct {
+			Type string `json:"type"`
+			At   string `json:"at"`
+		} `json:"timeline"`
+	}
+	if err := json.NewDecoder(resp1.Body).Decode(&result); err != nil {
 		t.Fatal(err)
 	}
+	if n := len(result.Timeline); n < 2 || result.Timeline[0].Type != "CREATED" || result.Timeline[n-1].Type != "MERGED" {
+		t.Errorf("таймлайн должен начинаться с CREATED и заканчиваться MERGED, получили %+v", result.Timeline)
+	}
 
-	if result["total_teams"] == nil {
-		t.Errorf("нет поля total_teams в ответе")
+	resp2, _ := get(ctx, "/pullRequest/missing_"+prID+"/timeline")
+	closeResp(resp2)
+	if resp2.StatusCode != http.StatusNotFound {
+		t.Errorf("ожидался 404 для неизвестного PR, получили %d", resp2.StatusCode)
 	}
 }
 
-func TestTeamDeactivate(t *testing.T) {
+func TestPRArchived(t *testing.T) {
 	ctx := context.Background()
+	prID := fmt.Sprintf("pr_archived_%d", time.Now().UnixNano())
 
-	teamName := fmt.Sprintf("deact_team_%d", time.Now().UnixNano())
-	ts := time.Now().UnixNano()
-
-	teamBody := fmt.Sprintf(
-		`{"team_name":"%s","members":[
-			{"user_id":"deact_u1_%d","username":"D1","is_active":true},
-			{"user_id":"deact_u2_%d","username":"D2","is_active":true},
-			{"user_id":"deact_u3_%d","username":"D3","is_active":true}
-		]}`,
-		teamName, ts, ts+1, ts+2,
+	resp, _ := post(ctx, pathPRCreate,
+		fmt.Sprintf(`{"pull_request_id":"%s","pull_request_name":"Archived PR","author_id":"user1"}`, prID),
 	)
+	closeResp(resp)
 
-	resp1, _ := post(ctx, pathTeamAdd, teamBody)
+	// Свежий PR еще не в архиве.
+	resp1, _ := get(ctx, pathPRArchived+"?pull_request_id="+prID)
 	closeResp(resp1)
+	if resp1.StatusCode != http.StatusNotFound {
+		t.Errorf("ожидался 404 для PR не из архива, получили %d", resp1.StatusCode)
+	}
 
-	resp, err := post(ctx, pathTeamDeactivate, fmt.Sprintf(`{"team_name":"%s"}`, teamName))
+	resp2, _ := get(ctx, pathPRArchived)
+	closeResp(resp2)
+	if resp2.StatusCode != http.StatusBadRequest {
+		t.Errorf("ожидался 400 без pull_request_id, получили %d", resp2.StatusCode)
+	}
+}
+
+func TestPRReassignNotAssigned(t *testing.T) {
+	ctx := context.Background()
+	prID := fmt.Sprintf("pr_reassign_na_%d", time.Now().UnixNano())
+
+	resp1, _ := post(ctx, pathPRCreate,
+		fmt.Sprintf(
+			`{"pull_request_id":"%s","pull_request_name":"NA PR","author_id":"user5"}`,
+			prID,
+		),
+	)
+	closeResp(resp1)
+
+	resp, err := post(ctx, pathPRReassign,
+		fmt.Sprintf(`{"pull_request_id":"%s","old_user_id":"user1"}`, prID),
+	)
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer closeResp(resp)
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		t.Errorf("ожидался 200, получили %d: %s", resp.StatusCode, string(body))
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("ожидался 409 NOT_ASSIGNED, получили %d", resp.StatusCode)
 	}
+}
 
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+func TestPRAssign(t *testing.T) {
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+	author := fmt.Sprintf("assign_author_%d", suffix)
+	members := []map[string]interface{}{{"user_id": author, "username": "Author", "is_active": true}}
+	for i := 1; i <= 3; i++ {
+		members = append(members, map[string]interface{}{
+			"user_id": fmt.Sprintf("assign_m%d_%d", i, suffix), "username": fmt.Sprintf("M%d", i), "is_active": true,
+		})
+	}
+	resp, err := doRequest(ctx, http.MethodPost, pathTeamAdd, map[string]interface{}{
+		"team_name": fmt.Sprintf("assign_team_%d", suffix), "members": members,
+	})
+	if err != nil {
 		t.Fatal(err)
 	}
+	closeResp(resp)
 
-	deactivated := result["deactivated_users"].([]interface{})
-	if len(deactivated) == 0 {
-		t.Errorf("должны быть деактивированные пользователи")
+	prID := fmt.Sprintf("pr_assign_%d", suffix)
+	resp1, err := post(ctx, pathPRCreate,
+		fmt.Sprintf(`{"pull_request_id":"%s","pull_request_name":"Assign PR","author_id":"%s"}`, prID, author),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var created struct {
+		PR struct {
+			AssignedReviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	err = json.NewDecoder(resp1.Body).Decode(&created)
+	closeResp(resp1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var extra string
+	for _, m := range members[1:] {
+		if id := m["user_id"].(string); !slices.Contains(created.PR.AssignedReviewers, id) {
+			extra = id
+		}
+	}
+	if extra == "" {
+		t.Fatalf("ожидался свободный участник команды, назначены %v", created.PR.AssignedReviewers)
+	}
+
+	body := fmt.Sprintf(`{"pull_request_id":"%s","user_id":"%s"}`, prID, extra)
+	resp2, err := post(ctx, pathPRAssign, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var assigned struct {
+		PR struct {
+			AssignedReviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	err = json.NewDecoder(resp2.Body).Decode(&assigned)
+	closeResp(resp2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp2.StatusCode != http.StatusOK || !slices.Contains(assigned.PR.AssignedReviewers, extra) {
+		t.Errorf("ожидался 200 с %s среди ревьюверов, получили %d %v", extra, resp2.StatusCode, assigned.PR.AssignedReviewers)
+	}
+
+	for _, user := range []string{extra, author, "user1"} {
+		resp3, _ := post(ctx, pathPRAssign, fmt.Sprintf(`{"pull_request_id":"%s","user_id":"%s"}`, prID, user))
+		closeResp(resp3)
+		if resp3.StatusCode != http.StatusConflict {
+			t.Errorf("%s: ожидался 409, получили %d", user, resp3.StatusCode)
+		}
+	}
+}
+
+func TestPRUnassign(t *testing.T) {
+	ctx := context.Background()
+	prID := fmt.Sprintf("pr_unassign_%d", time.Now().UnixNano())
+
+	resp, err := post(ctx, pathPRCreate,
+		fmt.Sprintf(`{"pull_request_id":"%s","pull_request_name":"Unassign PR","author_id":"user1"}`, prID),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var created struct {
+		PR struct {
+			AssignedReviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	closeResp(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created.PR.AssignedReviewers) == 0 {
+		t.Fatal("ревьюеры не назначены")
+	}
+	reviewer := created.PR.AssignedReviewers[0]
+
+	body := fmt.Sprintf(`{"pull_request_id":"%s","user_id":"%s","reason":"not needed"}`, prID, reviewer)
+	resp1, err := post(ctx, pathPRUnassign, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		PR struct {
+			AssignedReviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	err = json.NewDecoder(resp1.Body).Decode(&result)
+	closeResp(resp1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp1.StatusCode != http.StatusOK || slices.Contains(result.PR.AssignedReviewers, reviewer) ||
+		len(result.PR.AssignedReviewers) != len(created.PR.AssignedReviewers)-1 {
+		t.Errorf("ожидался 200 без %s и без замены, получили %d %v", reviewer, resp1.StatusCode, result.PR.AssignedReviewers)
+	}
+
+	resp2, _ := post(ctx, pathPRUnassign, body)
+	closeResp(resp2)
+	if resp2.StatusCode != http.StatusConflict {
+		t.Errorf("повторное снятие: ожидался 409 NOT_ASSIGNED, получили %d", resp2.StatusCode)
+	}
+}
+
+func TestUsersGetReview(t *testing.T) {
+	ctx := context.Background()
+	prID := fmt.Sprintf("pr_getreview_%d", time.Now().UnixNano())
+
+	resp, _ := post(ctx, pathPRCreate,
+		fmt.Sprintf(
+			`{"pull_request_id":"%s","pull_request_name":"GetReview PR","author_id":"user1"}`,
+			prID,
+		),
+	)
+	var result map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+	closeResp(resp)
+
+	pr := result["pr"].(map[string]interface{})
+	reviewers := pr["assigned_reviewers"].([]interface{})
+
+	if len(reviewers) == 0 {
+		t.Fatal("ревьюеры не назначены")
+	}
+
+	reviewer := reviewers[0].(string)
+
+	resp2, err := get(ctx, pathUserReviews+"?user_id="+reviewer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp2)
+
+	if resp2.StatusCode != http.StatusOK {
+		t.Errorf("ожидался 200, получили %d", resp2.StatusCode)
+	}
+
+	var result2 map[string]interface{}
+	if err := json.NewDecoder(resp2.Body).Decode(&result2); err != nil {
+		t.Fatal(err)
+	}
+
+	prs := result2["pull_requests"].([]interface{})
+	found := false
+
+	for _, p := range prs {
+		if p.(map[string]interface{})["pull_request_id"] == prID {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		t.Errorf("PR должен присутствовать в списке ревью пользователя")
+	}
+}
+
+func TestStats(t *testing.T) {
+	resp, err := get(context.Background(), pathStats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("ожидался 200, получили %d", resp.StatusCode)
+	}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	if result["total_teams"] == nil {
+		t.Errorf("нет поля total_teams в ответе")
+	}
+}
+
+func TestTeamStats(t *testing.T) {
+	ctx := context.Background()
+	teamName := fmt.Sprintf("team_stats_%d", time.Now().UnixNano())
+	resp, err := doRequest(ctx, http.MethodPost, pathTeamAdd, map[string]interface{}{
+		"team_name": teamName,
+		"members": []map[string]interface{}{
+			{"user_id": teamName + "_author", "username": "Author", "is_active": true},
+			{"user_id": teamName + "_rev", "username": "Reviewer", "is_active": true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp)
+
+	resp1, _ := post(ctx, pathPRCreate, fmt.Sprintf(
+		`{"pull_request_id":"%s_pr","pull_request_name":"Stats PR","author_id":"%s_author"}`, teamName, teamName))
+	closeResp(resp1)
+
+	resp2, err := get(ctx, pathTeamStats+"?team_name="+teamName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp2)
+	if resp2.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp2.StatusCode)
+	}
+	var result struct {
+		OpenPRs             int `json:"open_prs"`
+		Assignments         int `json:"assignments"`
+		AssignmentsByMember []struct {
+			UserID string `json:"user_id"`
+			Open   int    `json:"open_assignments"`
+		} `json:"assignments_by_member"`
+	}
+	if err := json.NewDecoder(resp2.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.OpenPRs != 1 || result.Assignments != 1 || len(result.AssignmentsByMember) != 2 {
+		t.Errorf("ожидались 1 открытый PR, 1 назначение и 2 участника, получили %+v", result)
+	}
+	if len(result.AssignmentsByMember) > 0 && result.AssignmentsByMember[0].UserID != teamName+"_rev" {
+		t.Errorf("первым должен идти ревьювер с назначением, получили %+v", result.AssignmentsByMember)
+	}
+
+	resp3, _ := get(ctx, pathTeamStats+"?team_name=missing_"+teamName)
+	closeResp(resp3)
+	if resp3.StatusCode != http.StatusNotFound {
+		t.Errorf("ожидался 404 для неизвестной команды, получили %d", resp3.StatusCode)
+	}
+}
+
+func TestSLAStats(t *testing.T) {
+	ctx := context.Background()
+	teamName := fmt.Sprintf("team_sla_%d", time.Now().UnixNano())
+	author, reviewer := teamName+"_author", teamName+"_rev"
+	resp, err := doRequest(ctx, http.MethodPost, pathTeamAdd, map[string]interface{}{
+		"team_name": teamName,
+		"members": []map[string]interface{}{
+			{"user_id": author, "username": "Author", "is_active": true},
+			{"user_id": reviewer, "username": "Reviewer", "is_active": true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp)
+
+	prID := teamName + "_pr"
+	resp2, err := post(ctx, pathPRCreate,
+		fmt.Sprintf(`{"pull_request_id":"%s","pull_request_name":"SLA","author_id":"%s"}`, prID, author))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp2)
+	resp3, err := post(ctx, pathPRApprove, fmt.Sprintf(`{"pull_request_id":"%s","user_id":"%s"}`, prID, reviewer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp3)
+
+	resp4, err := get(ctx, pathSLAStats+"?team_name="+teamName+"&within=1h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp4)
+	if resp4.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp4.StatusCode)
+	}
+	var stats struct {
+		WindowSeconds int64 `json:"window_seconds"`
+		Users         []struct {
+			UserID            string   `json:"user_id"`
+			Due               int      `json:"due_reviews"`
+			WithinSLA         int      `json:"within_sla"`
+			CompliancePercent *float64 `json:"compliance_percent"`
+		} `json:"users"`
+	}
+	if err := json.NewDecoder(resp4.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.WindowSeconds != 3600 {
+		t.Errorf("ожидалось окно 3600 секунд, получили %d", stats.WindowSeconds)
+	}
+	if len(stats.Users) != 1 || stats.Users[0].UserID != reviewer || stats.Users[0].WithinSLA != 1 ||
+		stats.Users[0].CompliancePercent == nil || *stats.Users[0].CompliancePercent != 100 {
+		t.Errorf("ожидалось одобрение ревьювера в пределах SLA, получили %+v", stats.Users)
+	}
+
+	resp5, err := get(ctx, pathSLAStats+"?within=soon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp5)
+	if resp5.StatusCode != http.StatusBadRequest {
+		t.Errorf("ожидался 400 для некорректного within, получили %d", resp5.StatusCode)
+	}
+}
+
+func TestTeamDeactivate(t *testing.T) {
+	ctx := context.Background()
+
+	teamName := fmt.Sprintf("deact_team_%d", time.Now().UnixNano())
+	ts := time.Now().UnixNano()
+
+	teamBody := fmt.Sprintf(
+		`{"team_name":"%s","members":[
+			{"user_id":"deact_u1_%d","username":"D1","is_active":true},
+			{"user_id":"deact_u2_%d","username":"D2","is_active":true},
+			{"user_id":"deact_u3_%d","username":"D3","is_active":true}
+		]}`,
+		teamName, ts, ts+1, ts+2,
+	)
+
+	resp1, _ := post(ctx, pathTeamAdd, teamBody)
+	closeResp(resp1)
+
+	resp, err := post(ctx, pathTeamDeactivate, fmt.Sprintf(`{"team_name":"%s"}`, teamName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		t.Errorf("ожидался 200, получили %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	deactivated := result["deactivated_users"].([]interface{})
+	if len(deactivated) == 0 {
+		t.Errorf("должны быть деактивированные пользователи")
+	}
+}
+
+func TestTeamDeactivateDryRun(t *testing.T) {
+	ctx := context.Background()
+	ts := time.Now().UnixNano()
+	teamName := fmt.Sprintf("dry_team_%d", ts)
+	author := fmt.Sprintf("dry_author_%d", ts)
+
+	resp1, _ := post(ctx, pathTeamAdd, fmt.Sprintf(
+		`{"team_name":"%s","members":[
+			{"user_id":"%s","username":"A","is_active":true},
+			{"user_id":"dry_rev_%d","username":"R","is_active":true}
+		]}`,
+		teamName, author, ts,
+	))
+	closeResp(resp1)
+	resp2, _ := post(ctx, pathPRCreate, fmt.Sprintf(
+		`{"pull_request_id":"pr_dry_%d","pull_request_name":"Dry run","author_id":"%s"}`, ts, author,
+	))
+	closeResp(resp2)
+
+	resp, err := post(ctx, pathTeamDeactivate, fmt.Sprintf(`{"team_name":"%s","dry_run":true}`, teamName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp)
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("ожидался 200, получили %d: %s", resp.StatusCode, string(body))
+	}
+	var result struct {
+		DeactivatedUsers []string            `json:"deactivated_users"`
+		Reassignments    []map[string]string `json:"reassignments"`
+		DryRun           bool                `json:"dry_run"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if !result.DryRun || len(result.DeactivatedUsers) != 2 || len(result.Reassignments) != 1 {
+		t.Errorf("ожидался прогноз: 2 пользователя и 1 переназначение, получили %+v", result)
+	}
+
+	resp3, err := get(ctx, pathTeamGet+"?team_name="+teamName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp3)
+	var team struct {
+		Members []struct {
+			IsActive bool `json:"is_active"`
+		} `json:"members"`
+	}
+	if err := json.NewDecoder(resp3.Body).Decode(&team); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range team.Members {
+		if !m.IsActive {
+			t.Errorf("dry_run не должен деактивировать участников: %+v", team.Members)
+			break
+		}
+	}
+}
+
+func TestPRApproveRequiredForMerge(t *testing.T) {
+	ctx := context.Background()
+	ts := time.Now().UnixNano()
+	teamName := fmt.Sprintf("approve_team_%d", ts)
+	author := fmt.Sprintf("appr_author_%d", ts)
+	reviewer := fmt.Sprintf("appr_rev_%d", ts)
+	prID := fmt.Sprintf("pr_approve_%d", ts)
+
+	resp1, _ := post(ctx, pathTeamAdd, fmt.Sprintf(
+		`{"team_name":"%s","members":[
+			{"user_id":"%s","username":"A","is_active":true},
+			{"user_id":"%s","username":"R","is_active":true}
+		]}`,
+		teamName, author, reviewer,
+	))
+	closeResp(resp1)
+
+	resp2, err := post(ctx, pathTeamPolicy, fmt.Sprintf(`{"team_name":"%s","require_approvals":true}`, teamName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp2)
+	if resp2.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200 при сохранении политики, получили %d", resp2.StatusCode)
+	}
+
+	resp3, _ := post(ctx, pathPRCreate, fmt.Sprintf(
+		`{"pull_request_id":"%s","pull_request_name":"Approve PR","author_id":"%s"}`,
+		prID, author,
+	))
+	closeResp(resp3)
+
+	resp4, err := post(ctx, pathPRMerge, fmt.Sprintf(`{"pull_request_id":"%s"}`, prID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp4)
+	if resp4.StatusCode != http.StatusConflict {
+		t.Errorf("ожидался 409 NOT_APPROVED, получили %d", resp4.StatusCode)
+	}
+
+	resp5, err := post(ctx, pathPRApprove, fmt.Sprintf(`{"pull_request_id":"%s","user_id":"%s"}`, prID, author))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp5)
+	if resp5.StatusCode != http.StatusConflict {
+		t.Errorf("ожидался 409 NOT_ASSIGNED для автора, получили %d", resp5.StatusCode)
+	}
+
+	resp6, err := post(ctx, pathPRApprove, fmt.Sprintf(`{"pull_request_id":"%s","user_id":"%s"}`, prID, reviewer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp6)
+	if resp6.StatusCode != http.StatusOK {
+		t.Errorf("ожидался 200 при одобрении, получили %d", resp6.StatusCode)
+	}
+
+	resp7, err := post(ctx, pathPRMerge, fmt.Sprintf(`{"pull_request_id":"%s"}`, prID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp7)
+	if resp7.StatusCode != http.StatusOK {
+		t.Errorf("ожидался 200 после одобрения, получили %d", resp7.StatusCode)
+	}
+}
+
+func TestPRRequiredApprovalsForceMerge(t *testing.T) {
+	ctx := context.Background()
+	ts := time.Now().UnixNano()
+	teamName := fmt.Sprintf("threshold_team_%d", ts)
+	author := fmt.Sprintf("thr_author_%d", ts)
+	reviewer := fmt.Sprintf("thr_rev_%d", ts)
+	prID := fmt.Sprintf("pr_threshold_%d", ts)
+
+	resp1, _ := post(ctx, pathTeamAdd, fmt.Sprintf(
+		`{"team_name":"%s","members":[
+			{"user_id":"%s","username":"A","is_active":true},
+			{"user_id":"%s","username":"R","is_active":true}
+		]}`,
+		teamName, author, reviewer,
+	))
+	closeResp(resp1)
+
+	resp2, err := post(ctx, pathPRCreate, fmt.Sprintf(
+		`{"pull_request_id":"%s","pull_request_name":"Threshold PR","author_id":"%s","required_approvals":1}`,
+		prID, author,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp2)
+	if resp2.StatusCode != http.StatusCreated {
+		t.Fatalf("ожидался 201, получили %d", resp2.StatusCode)
+	}
+
+	resp3, err := post(ctx, pathPRMerge, fmt.Sprintf(`{"pull_request_id":"%s"}`, prID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		Error struct {
+			Code             string   `json:"code"`
+			MissingApprovers []string `json:"missing_approvers"`
+		} `json:"error"`
+	}
+	_ = json.NewDecoder(resp3.Body).Decode(&result)
+	closeResp(resp3)
+	if resp3.StatusCode != http.StatusConflict || result.Error.Code != "NOT_APPROVED" {
+		t.Fatalf("ожидался 409 NOT_APPROVED, получили %d %s", resp3.StatusCode, result.Error.Code)
+	}
+	if len(result.Error.MissingApprovers) != 1 || result.Error.MissingApprovers[0] != reviewer {
+		t.Errorf("ожидался недостающий ревьювер %s, получили %v", reviewer, result.Error.MissingApprovers)
+	}
+
+	resp4, err := post(ctx, pathPRMerge, fmt.Sprintf(`{"pull_request_id":"%s","force":true}`, prID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp4)
+	if resp4.StatusCode != http.StatusOK {
+		t.Errorf("ожидался 200 при принудительном merge, получили %d", resp4.StatusCode)
+	}
+}
+
+func TestPRDependencies(t *testing.T) {
+	ctx := context.Background()
+	ts := time.Now().UnixNano()
+	baseID := fmt.Sprintf("pr_dep_base_%d", ts)
+	prID := fmt.Sprintf("pr_dep_%d", ts)
+
+	resp1, err := post(ctx, pathPRCreate, fmt.Sprintf(
+		`{"pull_request_id":"%s","pull_request_name":"Base PR","author_id":"user1"}`, baseID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp1)
+
+	resp2, err := post(ctx, pathPRCreate, fmt.Sprintf(
+		`{"pull_request_id":"%s","pull_request_name":"Dependent PR","author_id":"user1","depends_on":["%s"]}`,
+		prID, baseID,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp2)
+	if resp2.StatusCode != http.StatusCreated {
+		t.Fatalf("ожидался 201, получили %d", resp2.StatusCode)
+	}
+
+	resp3, err := post(ctx, pathPRMerge, fmt.Sprintf(`{"pull_request_id":"%s"}`, prID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mergeResult struct {
+		Error struct {
+			Code      string   `json:"code"`
+			BlockedBy []string `json:"blocked_by"`
+		} `json:"error"`
+	}
+	_ = json.NewDecoder(resp3.Body).Decode(&mergeResult)
+	closeResp(resp3)
+	if resp3.StatusCode != http.StatusConflict || mergeResult.Error.Code != "BLOCKED" {
+		t.Fatalf("ожидался 409 BLOCKED, получили %d %s", resp3.StatusCode, mergeResult.Error.Code)
+	}
+	if len(mergeResult.Error.BlockedBy) != 1 || mergeResult.Error.BlockedBy[0] != baseID {
+		t.Errorf("ожидалась блокирующая зависимость %s, получили %v", baseID, mergeResult.Error.BlockedBy)
+	}
+
+	resp4, err := get(ctx, pathPRBlocked+"?limit=1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var blocked struct {
+		PullRequests []struct {
+			ID string `json:"pull_request_id"`
+		} `json:"pull_requests"`
+	}
+	_ = json.NewDecoder(resp4.Body).Decode(&blocked)
+	closeResp(resp4)
+	found := false
+	for _, pr := range blocked.PullRequests {
+		found = found || pr.ID == prID
+	}
+	if !found {
+		t.Errorf("ожидался %s среди заблокированных", prID)
+	}
+
+	resp5, err := post(ctx, pathPRClose, fmt.Sprintf(`{"pull_request_id":"%s"}`, baseID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp5)
+
+	resp6, err := post(ctx, pathPRMerge, fmt.Sprintf(`{"pull_request_id":"%s"}`, prID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp6)
+	if resp6.StatusCode != http.StatusOK {
+		t.Errorf("ожидался 200 после закрытия зависимости, получили %d", resp6.StatusCode)
+	}
+}
+
+func TestPRDecline(t *testing.T) {
+	ctx := context.Background()
+	prID := fmt.Sprintf("pr_decline_%d", time.Now().UnixNano())
+
+	resp, _ := post(ctx, pathPRCreate,
+		fmt.Sprintf(
+			`{"pull_request_id":"%s","pull_request_name":"Decline PR","author_id":"user1"}`,
+			prID,
+		),
+	)
+	var result map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+	closeResp(resp)
+
+	pr := result["pr"].(map[string]interface{})
+	reviewers := pr["assigned_reviewers"].([]interface{})
+
+	if len(reviewers) == 0 {
+		t.Fatal("ревьюеры не назначены")
+	}
+
+	declined := reviewers[0].(string)
+
+	resp2, err := post(ctx, pathPRDecline,
+		fmt.Sprintf(`{"pull_request_id":"%s","user_id":"%s","reason":"on call"}`, prID, declined),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp2)
+
+	if resp2.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp2.StatusCode)
+	}
+
+	var result2 map[string]interface{}
+	if err := json.NewDecoder(resp2.Body).Decode(&result2); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range result2["pr"].(map[string]interface{})["assigned_reviewers"].([]interface{}) {
+		if r == declined {
+			t.Errorf("отказавшийся ревьювер не должен оставаться на PR")
+		}
+	}
+}
+
+func TestUsersDelete(t *testing.T) {
+	ctx := context.Background()
+	ts := time.Now().UnixNano()
+	teamName := fmt.Sprintf("delete_team_%d", ts)
+	author := fmt.Sprintf("del_author_%d", ts)
+	leaver := fmt.Sprintf("del_leaver_%d", ts)
+	prID := fmt.Sprintf("pr_delete_%d", ts)
+
+	resp1, _ := post(ctx, pathTeamAdd, fmt.Sprintf(
+		`{"team_name":"%s","members":[
+			{"user_id":"%s","username":"A","is_active":true},
+			{"user_id":"%s","username":"L","is_active":true},
+			{"user_id":"del_stayer_%d","username":"S","is_active":true}
+		]}`,
+		teamName, author, leaver, ts,
+	))
+	closeResp(resp1)
+
+	resp2, _ := post(ctx, pathPRCreate, fmt.Sprintf(
+		`{"pull_request_id":"%s","pull_request_name":"Delete PR","author_id":"%s"}`,
+		prID, author,
+	))
+	closeResp(resp2)
+
+	resp, err := post(ctx, pathUserDelete, fmt.Sprintf(`{"user_id":"%s"}`, leaver))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp.StatusCode)
+	}
+
+	resp3, err := get(ctx, pathUserReviews+"?user_id="+leaver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp3)
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp3.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if prs := result["pull_requests"].([]interface{}); len(prs) != 0 {
+		t.Errorf("у удаленного пользователя не должно остаться ревью, получили %d", len(prs))
+	}
+
+	resp4, err := post(ctx, pathUserDelete, fmt.Sprintf(`{"user_id":"%s"}`, leaver))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp4)
+	if resp4.StatusCode != http.StatusNotFound {
+		t.Errorf("ожидался 404 при повторном удалении, получили %d", resp4.StatusCode)
+	}
+}
+
+func TestPagination(t *testing.T) {
+	ctx := context.Background()
+
+	resp, err := get(ctx, pathStats+"?limit=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp.StatusCode)
+	}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	if users := result["assignments_by_user"].([]interface{}); len(users) > 1 {
+		t.Errorf("ожидалось не больше 1 записи, получили %d", len(users))
+	}
+	if result["assignments_by_user_total"] == nil {
+		t.Errorf("нет поля assignments_by_user_total в ответе")
+	}
+
+	resp2, err := get(ctx, pathUserReviews+"?user_id=user2&limit=-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp2)
+
+	if resp2.StatusCode != http.StatusBadRequest {
+		t.Errorf("ожидался 400 для некорректного limit, получили %d", resp2.StatusCode)
+	}
+}
+
+func TestUsersGetReviewStatusFilter(t *testing.T) {
+	ctx := context.Background()
+	prID := fmt.Sprintf("pr_filter_%d", time.Now().UnixNano())
+
+	resp, _ := post(ctx, pathPRCreate,
+		fmt.Sprintf(
+			`{"pull_request_id":"%s","pull_request_name":"Filter PR","author_id":"user5"}`,
+			prID,
+		),
+	)
+	var result map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+	closeResp(resp)
+
+	reviewers := result["pr"].(map[string]interface{})["assigned_reviewers"].([]interface{})
+	if len(reviewers) == 0 {
+		t.Fatal("ревьюеры не назначены")
+	}
+
+	resp1, _ := post(ctx, pathPRMerge, fmt.Sprintf(`{"pull_request_id":"%s"}`, prID))
+	closeResp(resp1)
+
+	resp2, err := get(ctx, pathUserReviews+"?status=OPEN&team_name=team2&user_id="+reviewers[0].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp2)
+
+	var result2 map[string]interface{}
+	if err := json.NewDecoder(resp2.Body).Decode(&result2); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range result2["pull_requests"].([]interface{}) {
+		pr := p.(map[string]interface{})
+		if pr["pull_request_id"] == prID || pr["status"] != "OPEN" {
+			t.Errorf("фильтр status=OPEN вернул неподходящий PR %v", pr["pull_request_id"])
+		}
+	}
+
+	resp3, err := get(ctx, pathUserReviews+"?status=UNKNOWN&user_id=user1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp3)
+
+	if resp3.StatusCode != http.StatusBadRequest {
+		t.Errorf("ожидался 400 для неизвестного статуса, получили %d", resp3.StatusCode)
+	}
+}
+
+func TestTeamImport(t *testing.T) {
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("import_team_%d", suffix)
+
+	payload := map[string]interface{}{
+		"teams": []map[string]interface{}{
+			{
+				"team_name": teamName,
+				"members": []map[string]interface{}{
+					{"user_id": fmt.Sprintf("import_u1_%d", suffix), "username": "Import1", "is_active": true},
+				},
+			},
+			{"team_name": ""},
+		},
+	}
+
+	resp, err := doRequest(ctx, http.MethodPost, pathTeamImport, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Created int `json:"created"`
+		Failed  int `json:"failed"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Created != 1 || result.Failed != 1 {
+		t.Errorf("ожидались created=1 и failed=1, получили %+v", result)
+	}
+
+	resp2, err := get(ctx, pathTeamGet+"?team_name="+teamName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp2)
+	if resp2.StatusCode != http.StatusOK {
+		t.Errorf("импортированная команда должна находиться, получили %d", resp2.StatusCode)
+	}
+}
+
+func TestTeamList(t *testing.T) {
+	ctx := context.Background()
+	prefix := fmt.Sprintf("list_team_%d_", time.Now().UnixNano())
+
+	for _, name := range []string{prefix + "a", prefix + "b"} {
+		payload := map[string]interface{}{
+			"team_name": name,
+			"members": []map[string]interface{}{
+				{"user_id": name + "_u1", "username": "Active", "is_active": true},
+				{"user_id": name + "_u2", "username": "Inactive", "is_active": false},
+			},
+		}
+		resp, err := doRequest(ctx, http.MethodPost, pathTeamAdd, payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		closeResp(resp)
+	}
+
+	resp, err := get(ctx, pathTeamList+"?name_prefix="+prefix+"&limit=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Teams []struct {
+			TeamName      string `json:"team_name"`
+			MemberCount   int    `json:"member_count"`
+			ActiveMembers int    `json:"active_members"`
+		} `json:"teams"`
+		Total int `json:"total"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Total != 2 || len(result.Teams) != 1 {
+		t.Fatalf("ожидались total=2 и одна команда на странице, получили %+v", result)
+	}
+	if result.Teams[0].TeamName != prefix+"a" || result.Teams[0].MemberCount != 2 || result.Teams[0].ActiveMembers != 1 {
+		t.Errorf("неверные счетчики команды: %+v", result.Teams[0])
+	}
+}
+
+func TestUsersList(t *testing.T) {
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("users_list_team_%d", suffix)
+
+	payload := map[string]interface{}{
+		"team_name": teamName,
+		"members": []map[string]interface{}{
+			{"user_id": fmt.Sprintf("ul_a_%d", suffix), "username": "Alice Searchable", "is_active": true},
+			{"user_id": fmt.Sprintf("ul_b_%d", suffix), "username": "Bob Searchable", "is_active": false},
+			{"user_id": fmt.Sprintf("ul_c_%d", suffix), "username": "Carol", "is_active": true},
+		},
+	}
+	resp, err := doRequest(ctx, http.MethodPost, pathTeamAdd, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp)
+
+	resp2, err := get(ctx, pathUserList+"?team_name="+teamName+"&is_active=true&q=searchable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp2)
+
+	if resp2.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp2.StatusCode)
+	}
+
+	var result struct {
+		Users []struct {
+			UserID string `json:"user_id"`
+		} `json:"users"`
+		Total int `json:"total"`
+	}
+	if err := json.NewDecoder(resp2.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 1 || len(result.Users) != 1 || result.Users[0].UserID != fmt.Sprintf("ul_a_%d", suffix) {
+		t.Errorf("ожидался только активный Alice, получили %+v", result)
+	}
+}
+
+func TestAuditTrail(t *testing.T) {
+	ctx := context.Background()
+	teamName := fmt.Sprintf("audit_team_%d", time.Now().UnixNano())
+
+	payload := map[string]interface{}{
+		"team_name": teamName,
+		"members": []map[string]interface{}{
+			{"user_id": teamName + "_u1", "username": "Audited", "is_active": true},
+		},
+	}
+	resp, err := doRequest(ctx, http.MethodPost, pathTeamAdd, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp)
+
+	resp2, err := post(ctx, pathUserActive, fmt.Sprintf(`{"user_id":"%s_u1","is_active":false}`, teamName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp2)
+
+	resp3, err := get(ctx, pathAudit+"?team_name="+teamName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp3)
+
+	if resp3.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp3.StatusCode)
+	}
+
+	var result struct {
+		Entries []struct {
+			Action string `json:"action"`
+		} `json:"entries"`
+		Total int `json:"total"`
+	}
+	if err := json.NewDecoder(resp3.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 2 || result.Entries[0].Action != "user.deactivated" || result.Entries[1].Action != "team.created" {
+		t.Errorf("ожидались user.deactivated и team.created, получили %+v", result)
+	}
+}
+
+func TestUsersSetVacationExcludesFromAssignment(t *testing.T) {
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("vacation_team_%d", suffix)
+	author := fmt.Sprintf("vac_author_%d", suffix)
+	away := fmt.Sprintf("vac_away_%d", suffix)
+	present := fmt.Sprintf("vac_present_%d", suffix)
+
+	payload := map[string]interface{}{
+		"team_name": teamName,
+		"members": []map[string]interface{}{
+			{"user_id": author, "username": "Author", "is_active": true},
+			{"user_id": away, "username": "Away", "is_active": true},
+			{"user_id": present, "username": "Present", "is_active": true},
+		},
+	}
+	resp, err := doRequest(ctx, http.MethodPost, pathTeamAdd, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp)
+
+	start := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	end := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	resp2, err := post(ctx, pathUserVacation,
+		fmt.Sprintf(`{"user_id":"%s","start":"%s","end":"%s"}`, away, start, end),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp2)
+	if resp2.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp2.StatusCode)
+	}
+
+	resp3, err := post(ctx, pathPRCreate,
+		fmt.Sprintf(`{"pull_request_id":"pr_vac_%d","pull_request_name":"Vacation","author_id":"%s"}`, suffix, author),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp3)
+
+	var result struct {
+		PR struct {
+			AssignedReviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	if err := json.NewDecoder(resp3.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.PR.AssignedReviewers) != 1 || result.PR.AssignedReviewers[0] != present {
+		t.Errorf("ожидался только %s, получили %v", present, result.PR.AssignedReviewers)
+	}
+}
+
+func TestUsersSnoozeExcludesFromAssignment(t *testing.T) {
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("snooze_team_%d", suffix)
+	author := fmt.Sprintf("snz_author_%d", suffix)
+	snoozed := fmt.Sprintf("snz_snoozed_%d", suffix)
+	present := fmt.Sprintf("snz_present_%d", suffix)
+
+	payload := map[string]interface{}{
+		"team_name": teamName,
+		"members": []map[string]interface{}{
+			{"user_id": author, "username": "Author", "is_active": true},
+			{"user_id": snoozed, "username": "Snoozed", "is_active": true},
+			{"user_id": present, "username": "Present", "is_active": true},
+		},
+	}
+	resp, err := doRequest(ctx, http.MethodPost, pathTeamAdd, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp)
+
+	resp2, err := post(ctx, pathUserSnooze, fmt.Sprintf(`{"user_id":"%s","hours":4}`, snoozed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp2)
+	if resp2.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp2.StatusCode)
+	}
+	var snooze struct {
+		SnoozedUntil *time.Time `json:"snoozed_until"`
+	}
+	if err := json.NewDecoder(resp2.Body).Decode(&snooze); err != nil {
+		t.Fatal(err)
+	}
+	if snooze.SnoozedUntil == nil || snooze.SnoozedUntil.Before(time.Now().Add(3*time.Hour)) {
+		t.Errorf("ожидалась пауза примерно на 4 часа, получили %v", snooze.SnoozedUntil)
+	}
+
+	resp3, err := post(ctx, pathPRCreate,
+		fmt.Sprintf(`{"pull_request_id":"pr_snz_%d","pull_request_name":"Snooze","author_id":"%s"}`, suffix, author),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp3)
+
+	var result struct {
+		PR struct {
+			AssignedReviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	if err := json.NewDecoder(resp3.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.PR.AssignedReviewers) != 1 || result.PR.AssignedReviewers[0] != present {
+		t.Errorf("ожидался только %s, получили %v", present, result.PR.AssignedReviewers)
+	}
+
+	resp4, err := post(ctx, pathUserSnooze, fmt.Sprintf(`{"user_id":"%s","hours":500}`, snoozed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp4)
+	if resp4.StatusCode != http.StatusBadRequest {
+		t.Errorf("ожидался 400 для паузы дольше недели, получили %d", resp4.StatusCode)
+	}
+}
+
+func TestTeamPolicyAssignment(t *testing.T) {
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("policy_team_%d", suffix)
+	author := fmt.Sprintf("pol_author_%d", suffix)
+	manager := fmt.Sprintf("pol_manager_%d", suffix)
+	member := fmt.Sprintf("pol_member_%d", suffix)
+
+	payload := map[string]interface{}{
+		"team_name": teamName,
+		"members": []map[string]interface{}{
+			{"user_id": manager, "username": "Manager", "is_active": true},
+			{"user_id": author, "username": "Author", "is_active": true, "manager_id": manager},
+			{"user_id": member, "username": "Member", "is_active": true},
+		},
+	}
+	resp, err := doRequest(ctx, http.MethodPost, pathTeamAdd, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp)
+
+	resp2, err := post(ctx, pathTeamPolicy,
+		fmt.Sprintf(`{"team_name":"%s","reviewer_count":1,"assignment_strategy":"least_loaded","require_manager":true}`, teamName),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp2)
+	if resp2.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp2.StatusCode)
+	}
+
+	resp3, err := post(ctx, pathPRCreate,
+		fmt.Sprintf(`{"pull_request_id":"pr_pol_%d","pull_request_name":"Policy","author_id":"%s"}`, suffix, author),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp3)
+
+	var result struct {
+		PR struct {
+			AssignedReviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	if err := json.NewDecoder(resp3.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.PR.AssignedReviewers) != 1 || result.PR.AssignedReviewers[0] != manager {
+		t.Errorf("ожидался только менеджер %s, получили %v", manager, result.PR.AssignedReviewers)
+	}
+
+	resp4, err := post(ctx, pathTeamPolicy,
+		fmt.Sprintf(`{"team_name":"%s","assignment_strategy":"lottery"}`, teamName),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp4)
+	if resp4.StatusCode != http.StatusBadRequest {
+		t.Errorf("ожидался 400 для неизвестной стратегии, получили %d", resp4.StatusCode)
+	}
+}
+
+func TestTeamPolicyMandatoryReviewer(t *testing.T) {
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("mandatory_team_%d", suffix)
+	author := fmt.Sprintf("mand_author_%d", suffix)
+	lead := fmt.Sprintf("mand_lead_%d", suffix)
+
+	payload := map[string]interface{}{
+		"team_name": teamName,
+		"members": []map[string]interface{}{
+			{"user_id": author, "username": "Author", "is_active": true},
+			{"user_id": lead, "username": "Lead", "is_active": true},
+			{"user_id": fmt.Sprintf("mand_m1_%d", suffix), "username": "M1", "is_active": true},
+			{"user_id": fmt.Sprintf("mand_m2_%d", suffix), "username": "M2", "is_active": true},
+		},
+	}
+	resp, err := doRequest(ctx, http.MethodPost, pathTeamAdd, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp)
+
+	resp2, err := post(ctx, pathTeamPolicy,
+		fmt.Sprintf(`{"team_name":"%s","reviewer_count":1,"mandatory_reviewer":"%s"}`, teamName, lead),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp2)
+	if resp2.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp2.StatusCode)
+	}
+
+	resp3, err := post(ctx, pathPRCreate,
+		fmt.Sprintf(`{"pull_request_id":"pr_mand_%d","pull_request_name":"Mandatory","author_id":"%s"}`, suffix, author),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp3)
+
+	var result struct {
+		PR struct {
+			AssignedReviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	if err := json.NewDecoder(resp3.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.PR.AssignedReviewers) != 2 || !slices.Contains(result.PR.AssignedReviewers, lead) {
+		t.Errorf("ожидались %s и один ревьювер по reviewer_count, получили %v", lead, result.PR.AssignedReviewers)
+	}
+
+	resp4, err := post(ctx, pathTeamPolicy,
+		fmt.Sprintf(`{"team_name":"%s","mandatory_reviewer":"missing_%d"}`, teamName, suffix),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp4)
+	if resp4.StatusCode != http.StatusBadRequest {
+		t.Errorf("ожидался 400 для неизвестного обязательного ревьювера, получили %d", resp4.StatusCode)
+	}
+}
+
+func TestPRLabelsAndPriority(t *testing.T) {
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("priority_team_%d", suffix)
+	author := fmt.Sprintf("prio_author_%d", suffix)
+	reviewer := fmt.Sprintf("prio_reviewer_%d", suffix)
+
+	payload := map[string]interface{}{
+		"team_name": teamName,
+		"members": []map[string]interface{}{
+			{"user_id": author, "username": "Author", "is_active": true},
+			{"user_id": reviewer, "username": "Reviewer", "is_active": true},
+		},
+	}
+	resp, err := doRequest(ctx, http.MethodPost, pathTeamAdd, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp)
+
+	resp2, err := post(ctx, pathPRCreate, fmt.Sprintf(
+		`{"pull_request_id":"pr_prio_%d","pull_request_name":"Hotfix","author_id":"%s","priority":"high","labels":["Hotfix","hotfix"]}`,
+		suffix, author))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp2)
+	if resp2.StatusCode != http.StatusCreated {
+		t.Fatalf("ожидался 201, получили %d", resp2.StatusCode)
+	}
+	var created struct {
+		PR struct {
+			Labels   []string `json:"labels"`
+			Priority string   `json:"priority"`
+		} `json:"pr"`
+	}
+	if err := json.NewDecoder(resp2.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	if created.PR.Priority != "high" || !slices.Equal(created.PR.Labels, []string{"hotfix"}) {
+		t.Errorf("ожидались priority=high и labels=[hotfix], получили %+v", created.PR)
+	}
+
+	resp3, err := get(ctx, pathUserReviews+"?user_id="+reviewer+"&label=hotfix&priority=high")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp3)
+	var reviews struct {
+		PullRequests []struct {
+			ID       string `json:"pull_request_id"`
+			Priority string `json:"priority"`
+		} `json:"pull_requests"`
+	}
+	if err := json.NewDecoder(resp3.Body).Decode(&reviews); err != nil {
+		t.Fatal(err)
+	}
+	if len(reviews.PullRequests) != 1 || reviews.PullRequests[0].Priority != "high" {
+		t.Errorf("ожидался один PR по фильтру label и priority, получили %+v", reviews.PullRequests)
+	}
+
+	resp4, err := get(ctx, pathUserReviews+"?user_id="+reviewer+"&priority=urgent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp4)
+	if resp4.StatusCode != http.StatusBadRequest {
+		t.Errorf("ожидался 400 для неизвестного priority, получили %d", resp4.StatusCode)
+	}
+
+	resp5, err := post(ctx, pathPRCreate, fmt.Sprintf(
+		`{"pull_request_id":"pr_prio_bad_%d","pull_request_name":"Bad","author_id":"%s","priority":"urgent"}`,
+		suffix, author))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp5)
+	if resp5.StatusCode != http.StatusBadRequest {
+		t.Errorf("ожидался 400 для неизвестного priority при создании, получили %d", resp5.StatusCode)
+	}
+}
+
+func TestPRMetadata(t *testing.T) {
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("meta_team_%d", suffix)
+	author := fmt.Sprintf("meta_author_%d", suffix)
+	prID := fmt.Sprintf("pr_meta_%d", suffix)
+
+	payload := map[string]interface{}{
+		"team_name": teamName,
+		"members": []map[string]interface{}{
+			{"user_id": author, "username": "Author", "is_active": true},
+		},
+	}
+	resp, err := doRequest(ctx, http.MethodPost, pathTeamAdd, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp)
+
+	resp2, err := post(ctx, pathPRCreate, fmt.Sprintf(
+		`{"pull_request_id":"%s","pull_request_name":"Feature","author_id":"%s",`+
+			`"url":"https://github.com/acme/api/pull/7","branch":"feature/x","description":"Новая ручка"}`,
+		prID, author))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp2)
+	if resp2.StatusCode != http.StatusCreated {
+		t.Fatalf("ожидался 201, получили %d", resp2.StatusCode)
+	}
+
+	resp3, err := get(ctx, pathPRGet+"?pull_request_id="+prID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp3)
+	var got struct {
+		PR struct {
+			URL         string `json:"url"`
+			Branch      string `json:"branch"`
+			Description string `json:"description"`
+		} `json:"pr"`
+	}
+	if err := json.NewDecoder(resp3.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.PR.URL != "https://github.com/acme/api/pull/7" || got.PR.Branch != "feature/x" || got.PR.Description != "Новая ручка" {
+		t.Errorf("метаданные PR должны сохраниться, получили %+v", got.PR)
+	}
+
+	resp4, err := post(ctx, pathPRCreate, fmt.Sprintf(
+		`{"pull_request_id":"%s_bad","pull_request_name":"Bad","author_id":"%s","url":"github.com/acme/api/pull/8"}`,
+		prID, author))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp4)
+	if resp4.StatusCode != http.StatusBadRequest {
+		t.Errorf("ожидался 400 для относительной ссылки, получили %d", resp4.StatusCode)
+	}
+}
+
+func TestOwnershipRoutesToCodeOwners(t *testing.T) {
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+	author := fmt.Sprintf("own_author_%d", suffix)
+	owner := fmt.Sprintf("own_owner_%d", suffix)
+	repository := fmt.Sprintf("acme/repo_%d", suffix)
+
+	for _, team := range []map[string]interface{}{
+		{
+			"team_name": fmt.Sprintf("own_dev_%d", suffix),
+			"members":   []map[string]interface{}{{"user_id": author, "username": "Author", "is_active": true}},
+		},
+		{
+			"team_name": fmt.Sprintf("own_dba_%d", suffix),
+			"members":   []map[string]interface{}{{"user_id": owner, "username": "Owner", "is_active": true}},
+		},
+	} {
+		resp, err := doRequest(ctx, http.MethodPost, pathTeamAdd, team)
+		if err != nil {
+			t.Fatal(err)
+		}
+		closeResp(resp)
+	}
+
+	resp, err := post(ctx, pathOwnership,
+		fmt.Sprintf(`{"repository":"%s","rules":[{"pattern":"migrations/","user_id":"%s"}]}`, repository, owner),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp.StatusCode)
+	}
+
+	resp2, err := post(ctx, pathPRCreate, fmt.Sprintf(
+		`{"pull_request_id":"pr_own_%d","pull_request_name":"Owners","author_id":"%s",`+
+			`"repository":"%s","changed_paths":["migrations/001_init.up.sql"]}`,
+		suffix, author, repository,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp2)
+
+	var result struct {
+		PR struct {
+			AssignedReviewers []string `json:"assigned_reviewers"`
+			ChangedPaths      []string `json:"changed_paths"`
+		} `json:"pr"`
+	}
+	if err := json.NewDecoder(resp2.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.PR.AssignedReviewers) != 1 || result.PR.AssignedReviewers[0] != owner {
+		t.Errorf("ожидался владелец %s, получили %v", owner, result.PR.AssignedReviewers)
+	}
+	if len(result.PR.ChangedPaths) != 1 {
+		t.Errorf("changed_paths должны сохраниться, получили %v", result.PR.ChangedPaths)
+	}
+}
+
+func TestRequiredTagsPreferTaggedReviewer(t *testing.T) {
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+	author := fmt.Sprintf("tag_author_%d", suffix)
+	expert := fmt.Sprintf("tag_expert_%d", suffix)
+
+	members := []map[string]interface{}{
+		{"user_id": author, "username": "Author", "is_active": true},
+		{"user_id": expert, "username": "Expert", "is_active": true},
+	}
+	for i := 0; i < 3; i++ {
+		members = append(members, map[string]interface{}{
+			"user_id": fmt.Sprintf("tag_generic_%d_%d", i, suffix), "username": "Generic", "is_active": true,
+		})
+	}
+	resp, err := doRequest(ctx, http.MethodPost, pathTeamAdd, map[string]interface{}{
+		"team_name": fmt.Sprintf("tags_team_%d", suffix),
+		"members":   members,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp)
+
+	resp2, err := post(ctx, pathUserTags, fmt.Sprintf(`{"user_id":"%s","tags":["DB","go"]}`, expert))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp2)
+	if resp2.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp2.StatusCode)
+	}
+
+	resp3, err := post(ctx, pathPRCreate, fmt.Sprintf(
+		`{"pull_request_id":"pr_tag_%d","pull_request_name":"Tags","author_id":"%s","required_tags":["db"]}`,
+		suffix, author,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp3)
+
+	var result struct {
+		PR struct {
+			AssignedReviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	if err := json.NewDecoder(resp3.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.PR.AssignedReviewers) != 2 || !slices.Contains(result.PR.AssignedReviewers, expert) {
+		t.Errorf("ожидался %s среди двух ревьюверов, получили %v", expert, result.PR.AssignedReviewers)
+	}
+}
+
+func TestPRStale(t *testing.T) {
+	ctx := context.Background()
+
+	resp, err := get(ctx, pathPRStale+"?older_than=0s&limit=5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp.StatusCode)
+	}
+	var result struct {
+		PullRequests []struct {
+			ID string `json:"pull_request_id"`
+		} `json:"pull_requests"`
+		Total int `json:"total"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.PullRequests) > 5 || result.Total < len(result.PullRequests) {
+		t.Errorf("некорректная страница: %d элементов, total=%d", len(result.PullRequests), result.Total)
+	}
+
+	resp2, err := get(ctx, pathPRStale+"?older_than=week")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp2)
+	if resp2.StatusCode != http.StatusBadRequest {
+		t.Errorf("ожидался 400 для некорректного older_than, получили %d", resp2.StatusCode)
+	}
+}
+
+func TestUsersSetNotifications(t *testing.T) {
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+	userID := fmt.Sprintf("notify_user_%d", suffix)
+
+	resp, err := doRequest(ctx, http.MethodPost, pathTeamAdd, map[string]interface{}{
+		"team_name": fmt.Sprintf("notify_team_%d", suffix),
+		"members": []map[string]interface{}{
+			{"user_id": userID, "username": "Notify", "is_active": true, "email": "notify@example.com"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("ожидался 201, получили %d", resp.StatusCode)
+	}
+
+	resp2, err := post(ctx, pathUserNotify, fmt.Sprintf(`{"user_id":"%s","email_opt_out":true}`, userID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp2)
+	if resp2.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp2.StatusCode)
+	}
+	var result struct {
+		Preferences struct {
+			EmailOptOut bool `json:"email_opt_out"`
+		} `json:"preferences"`
+	}
+	if err := json.NewDecoder(resp2.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if !result.Preferences.EmailOptOut {
+		t.Error("ожидался email_opt_out=true")
+	}
+
+	resp3, err := post(ctx, pathUserNotify, `{"user_id":"no_such_user","email_opt_out":true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp3)
+	if resp3.StatusCode != http.StatusNotFound {
+		t.Errorf("ожидался 404, получили %d", resp3.StatusCode)
+	}
+}
+
+func TestOpenAPISpecAndValidation(t *testing.T) {
+	ctx := context.Background()
+
+	resp, err := get(ctx, pathOpenAPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp.StatusCode)
+	}
+	var spec struct {
+		OpenAPI string                     `json:"openapi"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&spec); err != nil {
+		t.Fatal(err)
+	}
+	if spec.OpenAPI == "" || spec.Paths[pathPRCreate] == nil {
+		t.Errorf("в документе должен быть описан %s", pathPRCreate)
+	}
+
+	resp2, err := post(ctx, pathPRCreate, `{"pull_request_id":1,"author_id":"u1"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp2)
+	if resp2.StatusCode != http.StatusBadRequest {
+		t.Fatalf("ожидался 400 для тела вне схемы, получили %d", resp2.StatusCode)
+	}
+	var result struct {
+		Error struct {
+			Code   string            `json:"code"`
+			Fields map[string]string `json:"fields"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp2.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Error.Code != "VALIDATION" || len(result.Error.Fields) != 2 {
+		t.Errorf("ожидались нарушения pull_request_name и pull_request_id, получили %+v", result.Error)
+	}
+}
+
+func TestRequestValidation(t *testing.T) {
+	ctx := context.Background()
+
+	resp, err := post(ctx, pathUserActive, `{"user_id":"  ","is_active":true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("ожидался 400 для пустого user_id, получили %d", resp.StatusCode)
+	}
+	var result struct {
+		Error struct {
+			Code   string            `json:"code"`
+			Fields map[string]string `json:"fields"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := result.Error.Fields["user_id"]; result.Error.Code != "VALIDATION" || !ok {
+		t.Errorf("ожидалась ошибка VALIDATION по user_id, получили %+v", result.Error)
+	}
+
+	longID := strings.Repeat("a", 256)
+	resp2, err := post(ctx, pathPRCreate,
+		fmt.Sprintf(`{"pull_request_id":"%s","pull_request_name":"n","author_id":"u"}`, longID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp2)
+	if resp2.StatusCode != http.StatusBadRequest {
+		t.Errorf("ожидался 400 для слишком длинного pull_request_id, получили %d", resp2.StatusCode)
+	}
+}
+
+func TestPRAddReviewer(t *testing.T) {
+	ctx := context.Background()
+	prID := fmt.Sprintf("pr_add_reviewer_%d", time.Now().UnixNano())
+
+	resp, err := post(ctx, pathPRCreate,
+		fmt.Sprintf(`{"pull_request_id":"%s","pull_request_name":"Top-up PR","author_id":"user1"}`, prID),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var created struct {
+		PR struct {
+			AssignedReviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	closeResp(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created.PR.AssignedReviewers) == 0 {
+		t.Fatal("ревьюеры не назначены")
+	}
+
+	resp1, err := post(ctx, pathPRUnassign,
+		fmt.Sprintf(`{"pull_request_id":"%s","user_id":"%s"}`, prID, created.PR.AssignedReviewers[0]),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp1)
+
+	resp2, err := post(ctx, pathPRAddReviewer, fmt.Sprintf(`{"pull_request_id":"%s"}`, prID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		PR struct {
+			AssignedReviewers []string `json:"assigned_reviewers"`
+		} `json:"pr"`
+		Added []string `json:"added"`
+	}
+	err = json.NewDecoder(resp2.Body).Decode(&result)
+	closeResp(resp2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp2.StatusCode != http.StatusOK || len(result.Added) != 1 ||
+		len(result.PR.AssignedReviewers) != len(created.PR.AssignedReviewers) {
+		t.Errorf("ожидался добор одного ревьювера, получили %d %v (добавлены %v)",
+			resp2.StatusCode, result.PR.AssignedReviewers, result.Added)
+	}
+
+	resp3, err := post(ctx, pathPRAddReviewer, `{"pull_request_id":"no_such_pr"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp3)
+	if resp3.StatusCode != http.StatusNotFound {
+		t.Errorf("неизвестный PR: ожидался 404, получили %d", resp3.StatusCode)
+	}
+}
+
+func TestTeamAddRebalance(t *testing.T) {
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("team_rebalance_%d", suffix)
+	author := fmt.Sprintf("rb_author_%d", suffix)
+	newcomer := fmt.Sprintf("rb_new_%d", suffix)
+	prID := fmt.Sprintf("pr_rebalance_%d", suffix)
+
+	resp, err := doRequest(ctx, http.MethodPost, pathTeamAdd, map[string]interface{}{
+		"team_name": teamName,
+		"members": []map[string]interface{}{
+			{"user_id": author, "username": "Author", "is_active": true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp)
+	resp1, err := post(ctx, pathPRCreate,
+		fmt.Sprintf(`{"pull_request_id":"%s","pull_request_name":"Rebalance PR","author_id":"%s"}`, prID, author),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp1)
+
+	resp2, err := doRequest(ctx, http.MethodPost, pathTeamAdd, map[string]interface{}{
+		"team_name": teamName,
+		"rebalance": true,
+		"members": []map[string]interface{}{
+			{"user_id": newcomer, "username": "Newcomer", "is_active": true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		Rebalanced []struct {
+			PRID   string `json:"pull_request_id"`
+			UserID string `json:"user_id"`
+		} `json:"rebalanced"`
+	}
+	err = json.NewDecoder(resp2.Body).Decode(&result)
+	closeResp(resp2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp2.StatusCode != http.StatusOK || len(result.Rebalanced) != 1 ||
+		result.Rebalanced[0].PRID != prID || result.Rebalanced[0].UserID != newcomer {
+		t.Errorf("ожидалось назначение %s на %s, получили %d %+v", newcomer, prID, resp2.StatusCode, result.Rebalanced)
+	}
+}
+
+func TestUserGet(t *testing.T) {
+	ctx := context.Background()
+	resp, err := get(ctx, pathUserGet+"?user_id=user1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		User struct {
+			UserID      string `json:"user_id"`
+			OpenReviews *int   `json:"open_reviews"`
+		} `json:"user"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	closeResp(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || result.User.UserID != "user1" || result.User.OpenReviews == nil {
+		t.Errorf("ожидался профиль user1 с нагрузкой, получили %d %+v", resp.StatusCode, result.User)
+	}
+
+	resp2, err := get(ctx, pathUserGet+"?user_id=no_such_user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp2)
+	if resp2.StatusCode != http.StatusNotFound {
+		t.Errorf("неизвестный пользователь: ожидался 404, получили %d", resp2.StatusCode)
+	}
+}
+
+func TestTeamRename(t *testing.T) {
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+	oldName := fmt.Sprintf("team_rename_%d", suffix)
+	newName := fmt.Sprintf("team_renamed_%d", suffix)
+
+	resp, err := doRequest(ctx, http.MethodPost, pathTeamAdd, map[string]interface{}{
+		"team_name": oldName,
+		"members": []map[string]interface{}{
+			{"user_id": fmt.Sprintf("rn_user_%d", suffix), "username": "Renamed", "is_active": true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp)
+
+	body := fmt.Sprintf(`{"team_name":"%s","new_team_name":"%s"}`, oldName, newName)
+	resp1, err := post(ctx, pathTeamRename, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp1)
+	if resp1.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp1.StatusCode)
+	}
+
+	resp2, err := get(ctx, pathTeamGet+"?team_name="+newName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var team struct {
+		Members []struct {
+			UserID string `json:"user_id"`
+		} `json:"members"`
+	}
+	err = json.NewDecoder(resp2.Body).Decode(&team)
+	closeResp(resp2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp2.StatusCode != http.StatusOK || len(team.Members) != 1 {
+		t.Errorf("участники должны перейти к новому имени, получили %d %+v", resp2.StatusCode, team.Members)
+	}
+
+	resp3, err := post(ctx, pathTeamRename, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp3)
+	if resp3.StatusCode != http.StatusNotFound {
+		t.Errorf("повторное переименование: ожидался 404, получили %d", resp3.StatusCode)
+	}
+}
+
+func TestUserIdentities(t *testing.T) {
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("identity_team_%d", suffix)
+	alice := fmt.Sprintf("identity_alice_%d", suffix)
+	bob := fmt.Sprintf("identity_bob_%d", suffix)
+	login := fmt.Sprintf("alice-%d", suffix)
+
+	payload := map[string]interface{}{
+		"team_name": teamName,
+		"members": []map[string]interface{}{
+			{"user_id": alice, "username": "Alice", "is_active": true},
+			{"user_id": bob, "username": "Bob", "is_active": true},
+		},
+	}
+	resp, err := doRequest(ctx, http.MethodPost, pathTeamAdd, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp)
+
+	resp2, err := post(ctx, pathUserIdentity, fmt.Sprintf(
+		`{"user_id":"%s","provider":"github","external_id":"@%s"}`, alice, strings.ToUpper(login)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp2)
+	if resp2.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp2.StatusCode)
+	}
+
+	resp3, err := get(ctx, pathUserByIdentity+"?provider=github&external_id="+login)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp3)
+	var found struct {
+		User struct {
+			UserID string `json:"user_id"`
+		} `json:"user"`
+	}
+	if err := json.NewDecoder(resp3.Body).Decode(&found); err != nil {
+		t.Fatal(err)
+	}
+	if found.User.UserID != alice {
+		t.Errorf("ожидался %s по логину GitHub, получили %q", alice, found.User.UserID)
+	}
+
+	resp4, err := get(ctx, pathUserIdentities+"?user_id="+alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp4)
+	var list struct {
+		Identities []struct {
+			Provider   string `json:"provider"`
+			ExternalID string `json:"external_id"`
+		} `json:"identities"`
+	}
+	if err := json.NewDecoder(resp4.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Identities) != 1 || list.Identities[0].ExternalID != login {
+		t.Errorf("ожидалась одна учетная запись %s, получили %+v", login, list.Identities)
+	}
+
+	resp5, err := post(ctx, pathUserIdentity, fmt.Sprintf(
+		`{"user_id":"%s","provider":"github","external_id":"%s"}`, bob, login))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp5)
+	if resp5.StatusCode != http.StatusConflict {
+		t.Errorf("ожидался 409 для занятого логина, получили %d", resp5.StatusCode)
+	}
+
+	resp6, err := post(ctx, pathUserIdentity, fmt.Sprintf(
+		`{"user_id":"%s","provider":"bitbucket","external_id":"bob"}`, bob))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp6)
+	if resp6.StatusCode != http.StatusBadRequest {
+		t.Errorf("ожидался 400 для неизвестной системы, получили %d", resp6.StatusCode)
+	}
+}
+
+func TestSCIMUsers(t *testing.T) {
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("scim_team_%d", suffix)
+	userID := fmt.Sprintf("scim_user_%d", suffix)
+
+	payload := map[string]interface{}{
+		"schemas":     []string{"urn:ietf:params:scim:schemas:core:2.0:User"},
+		"userName":    userID,
+		"displayName": "SCIM User",
+		"active":      true,
+		"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User": map[string]string{"department": teamName},
+	}
+	resp, err := doRequest(ctx, http.MethodPost, pathSCIMUsers, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("ожидался 201, получили %d", resp.StatusCode)
+	}
+
+	resp2, err := doRequest(ctx, http.MethodPost, pathSCIMUsers, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp2)
+	if resp2.StatusCode != http.StatusConflict {
+		t.Errorf("повторное создание: ожидался 409, получили %d", resp2.StatusCode)
+	}
+
+	patch := map[string]interface{}{
+		"schemas":    []string{"urn:ietf:params:scim:api:messages:2.0:PatchOp"},
+		"Operations": []map[string]interface{}{{"op": "replace", "path": "active", "value": "False"}},
+	}
+	resp3, err := doRequest(ctx, http.MethodPatch, pathSCIMUsers+"/"+userID, patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp3)
+	if resp3.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp3.StatusCode)
+	}
+	var user struct {
+		ID     string `json:"id"`
+		Active bool   `json:"active"`
+	}
+	if err := json.NewDecoder(resp3.Body).Decode(&user); err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != userID || user.Active {
+		t.Errorf("ожидался отключенный %s, получили %+v", userID, user)
+	}
+
+	resp4, err := get(ctx, pathSCIMUsers+"?filter="+url.QueryEscape(fmt.Sprintf(`userName eq "%s"`, userID)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp4)
+	var list struct {
+		TotalResults int `json:"totalResults"`
+	}
+	if err := json.NewDecoder(resp4.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if list.TotalResults != 1 {
+		t.Errorf("фильтр по userName: ожидался 1 ресурс, получили %d", list.TotalResults)
+	}
+
+	resp5, err := doRequest(ctx, http.MethodDelete, pathSCIMUsers+"/"+userID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp5)
+	if resp5.StatusCode != http.StatusNoContent {
+		t.Errorf("ожидался 204, получили %d", resp5.StatusCode)
+	}
+}
+
+// Тестовый сервис запущен без LDAP_URL.
+func TestDirectorySyncNotConfigured(t *testing.T) {
+	resp, err := post(context.Background(), pathDirectorySync, `{"dry_run":true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("ожидался 404, получили %d", resp.StatusCode)
+	}
+}
+
+func TestExportAssignments(t *testing.T) {
+	ctx := context.Background()
+	suffix := time.Now().UnixNano()
+	teamName := fmt.Sprintf("export_team_%d", suffix)
+	author := fmt.Sprintf("export_author_%d", suffix)
+	reviewer := fmt.Sprintf("export_reviewer_%d", suffix)
+	prID := fmt.Sprintf("export_pr_%d", suffix)
+	from := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+
+	payload := map[string]interface{}{
+		"team_name": teamName,
+		"members": []map[string]interface{}{
+			{"user_id": author, "username": "Author", "is_active": true},
+			{"user_id": reviewer, "username": "Reviewer", "is_active": true},
+		},
+	}
+	resp, err := doRequest(ctx, http.MethodPost, pathTeamAdd, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp)
+	resp2, err := post(ctx, pathPRCreate, fmt.Sprintf(
+		`{"pull_request_id":"%s","pull_request_name":"Export","author_id":"%s"}`, prID, author))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp2)
+
+	resp3, err := get(ctx, pathExport+"?from="+from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeResp(resp3)
+	if resp3.StatusCode != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d", resp3.StatusCode)
+	}
+	if ct := resp3.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("ожидался application/x-ndjson, получили %q", ct)
+	}
+
+	found := false
+	scanner := bufio.NewScanner(resp3.Body)
+	for scanner.Scan() {
+		var ev struct {
+			PRID      string `json:"pull_request_id"`
+			EventType string `json:"event_type"`
+			NewUserID string `json:"new_user_id"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			t.Fatalf("строка не JSON: %q: %v", scanner.Text(), err)
+		}
+		if ev.PRID == prID && ev.EventType == "ASSIGNED" && ev.NewUserID == reviewer {
+			found = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !found {
+		t.Errorf("в выгрузке нет назначения %s на %s", reviewer, prID)
+	}
+
+	resp4, err := get(ctx, pathExport+"?from="+from+"&to="+from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp4)
+	if resp4.StatusCode != http.StatusBadRequest {
+		t.Errorf("пустой диапазон: ожидался 400, получили %d", resp4.StatusCode)
+	}
+}
+
+func TestImportGitHubInvalidRepository(t *testing.T) {
+	resp, err := post(context.Background(), pathImportGitHub, `{"repository":"not-a-repo","token":"x"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeResp(resp)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("ожидался 400, получили %d", resp.StatusCode)
 	}
 }
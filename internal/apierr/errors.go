@@ -3,25 +3,58 @@ package apierr
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 type ErrResp struct {
 	Error struct {
 		Code    string `json:"code"`
 		Message string `json:"message"`
+		// RequestID — X-Request-Id запроса; по нему ответ находится в логах сервиса.
+		RequestID string `json:"request_id,omitempty"`
+		// Details — дополнительные сведения об ошибке, например лимит или срок повтора.
+		Details map[string]any `json:"details,omitempty"`
+		// Fields — нарушения по полям запроса для ответов VALIDATION.
+		Fields map[string]string `json:"fields,omitempty"`
+		// MissingApprovers — ревьюверы, еще не одобрившие PR, для ответов NOT_APPROVED.
+		MissingApprovers []string `json:"missing_approvers,omitempty"`
+		// BlockedBy — открытые зависимости PR для ответов BLOCKED.
+		BlockedBy []string `json:"blocked_by,omitempty"`
 	} `json:"error"`
 }
 
 var (
-	ErrTeamExists     = &AppError{400, "TEAM_EXISTS", "team_name already exists"}
-	ErrPRExists       = &AppError{409, "PR_EXISTS", "PR id already exists"}
-	ErrPRMerged       = &AppError{409, "PR_MERGED", "cannot reassign on merged PR"}
-	ErrNotAssigned    = &AppError{409, "NOT_ASSIGNED", "reviewer is not assigned to this PR"}
-	ErrNoCandidate    = &AppError{409, "NO_CANDIDATE", "no active replacement candidate in team"}
-	ErrTeamNotFound   = &AppError{404, "NOT_FOUND", "team not found"}
-	ErrUserNotFound   = &AppError{404, "NOT_FOUND", "user not found"}
-	ErrPRNotFound     = &AppError{404, "NOT_FOUND", "PR not found"}
-	ErrAuthorNotFound = &AppError{404, "NOT_FOUND", "author not found"}
+	ErrTeamExists          = &AppError{400, "TEAM_EXISTS", "team_name already exists"}
+	ErrPRExists            = &AppError{409, "PR_EXISTS", "PR id already exists"}
+	ErrPRMerged            = &AppError{409, "PR_MERGED", "cannot reassign on merged PR"}
+	ErrPRClosed            = &AppError{409, "PR_CLOSED", "cannot modify closed PR"}
+	ErrNotAssigned         = &AppError{409, "NOT_ASSIGNED", "reviewer is not assigned to this PR"}
+	ErrAlreadyAssigned     = &AppError{409, "ALREADY_ASSIGNED", "user is already assigned to this PR"}
+	ErrIneligibleReviewer  = &AppError{409, "INELIGIBLE_REVIEWER", "user cannot review this PR"}
+	ErrNoCandidate         = &AppError{409, "NO_CANDIDATE", "no active replacement candidate in team"}
+	ErrTeamNotFound        = &AppError{404, "NOT_FOUND", "team not found"}
+	ErrUserNotFound        = &AppError{404, "NOT_FOUND", "user not found"}
+	ErrPRNotFound          = &AppError{404, "NOT_FOUND", "PR not found"}
+	ErrAuthorNotFound      = &AppError{404, "NOT_FOUND", "author not found"}
+	ErrVersionConflict     = &AppError{409, "VERSION_CONFLICT", "PR was modified concurrently, reload and retry"}
+	ErrConflictRetry       = &AppError{409, "CONFLICT_RETRY", "PR was modified by a concurrent request, retry"}
+	ErrReviewerConflict    = &AppError{409, "REVIEWER_CONFLICT", "reviewer assignment violates integrity constraints"}
+	ErrAuthorIsReviewer    = &AppError{409, "AUTHOR_IS_REVIEWER", "author cannot be assigned as reviewer"}
+	ErrNotApproved         = &AppError{409, "NOT_APPROVED", "PR does not have enough approvals"}
+	ErrBlocked             = &AppError{409, "BLOCKED", "PR is blocked by open dependencies"}
+	ErrIdentityTaken       = &AppError{409, "IDENTITY_TAKEN", "identity is linked to another user"}
+	ErrDirectoryGroupEmpty = &AppError{409, "DIRECTORY_GROUP_EMPTY", "directory group is empty, retry with force to deactivate its team"}
+	ErrUnauthorized        = &AppError{401, "UNAUTHORIZED", "missing or invalid bearer token"}
+	ErrForbidden           = &AppError{403, "FORBIDDEN", "token does not grant access to this team"}
+	ErrRateLimited         = &AppError{429, "RATE_LIMITED", "too many requests, retry later"}
+	ErrBodyTooLarge        = &AppError{413, "PAYLOAD_TOO_LARGE", "request body exceeds size limit"}
+	ErrTimeout             = &AppError{504, "TIMEOUT", "operation timed out"}
+	ErrDuplicate           = &AppError{409, "DUPLICATE", "resource already exists"}
+	ErrUnknownReference    = &AppError{409, "UNKNOWN_REFERENCE", "referenced resource does not exist"}
+	ErrReadOnly            = &AppError{503, "READ_ONLY", "service is in read-only mode, retry later"}
+	ErrOverloaded          = &AppError{503, "OVERLOADED", "database is saturated, retry later"}
+	ErrUnsupportedVersion  = &AppError{404, "UNSUPPORTED_API_VERSION", "API version is not supported"}
 )
 
 type AppError struct {
@@ -33,16 +66,62 @@ type AppError struct {
 func (e *AppError) Error() string { return e.Message }
 
 func JSON(w http.ResponseWriter, status int, code, msg string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
+	encode(w, status, newResp(code, msg))
+}
+
+func Write(w http.ResponseWriter, e *AppError) {
+	JSON(w, e.Status, e.Code, e.Message)
+}
+
+// WriteDetails отвечает ошибкой e с дополнительными сведениями details.
+func WriteDetails(w http.ResponseWriter, e *AppError, details map[string]any) {
+	resp := newResp(e.Code, e.Message)
+	resp.Error.Details = details
+	encode(w, e.Status, resp)
+}
+
+// Validation отвечает 400 VALIDATION с сообщениями по полям запроса.
+func Validation(w http.ResponseWriter, fields map[string]string) {
+	resp := newResp("VALIDATION", "некорректные поля запроса")
+	resp.Error.Fields = fields
+	encode(w, http.StatusBadRequest, resp)
+}
+
+// NotApproved отвечает 409 NOT_APPROVED со списком ревьюверов, чьих одобрений
+// не хватает для merge.
+func NotApproved(w http.ResponseWriter, msg string, missing []string) {
+	resp := newResp(ErrNotApproved.Code, msg)
+	resp.Error.MissingApprovers = missing
+	encode(w, ErrNotApproved.Status, resp)
+}
+
+// Blocked отвечает 409 BLOCKED со списком открытых PR, от которых зависит
+// сливаемый PR.
+func Blocked(w http.ResponseWriter, msg string, blockedBy []string) {
+	resp := newResp(ErrBlocked.Code, msg)
+	resp.Error.BlockedBy = blockedBy
+	encode(w, ErrBlocked.Status, resp)
+}
+
+func newResp(code, msg string) ErrResp {
 	e := ErrResp{}
 	e.Error.Code = code
 	e.Error.Message = msg
+	return e
+}
+
+// encode пишет ответ об ошибке с request_id из заголовка X-Request-Id ответа:
+// mw.RequestLogger выставляет его до обработчиков и пишет тот же id в лог.
+// Для ответов, обернутых WithProblemFormat, тело — в формате RFC 7807.
+func encode(w http.ResponseWriter, status int, e ErrResp) {
+	e.Error.RequestID = w.Header().Get(middleware.RequestIDHeader)
+	if wantsProblem(w) {
+		encodeProblem(w, status, e)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(e); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
-
-func Write(w http.ResponseWriter, e *AppError) {
-	JSON(w, e.Status, e.Code, e.Message)
-}
@@ -0,0 +1,106 @@
+package apierr
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ProblemContentType — тип тела ошибки по RFC 7807.
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix образует type проблемы из кода ошибки: urn:prreviewer:error:PR_EXISTS.
+const problemTypePrefix = "urn:prreviewer:error:"
+
+// Problem — ошибка в формате RFC 7807. Кроме стандартных полей содержит
+// расширения с теми же данными, что и ErrResp.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail"`
+
+	Code             string            `json:"code"`
+	RequestID        string            `json:"request_id,omitempty"`
+	Fields           map[string]string `json:"fields,omitempty"`
+	MissingApprovers []string          `json:"missing_approvers,omitempty"`
+	BlockedBy        []string          `json:"blocked_by,omitempty"`
+	Details          map[string]any    `json:"details,omitempty"`
+}
+
+func newProblem(status int, e ErrResp) Problem {
+	return Problem{
+		Type:             problemTypePrefix + e.Error.Code,
+		Title:            http.StatusText(status),
+		Status:           status,
+		Detail:           e.Error.Message,
+		Code:             e.Error.Code,
+		RequestID:        e.Error.RequestID,
+		Fields:           e.Error.Fields,
+		MissingApprovers: e.Error.MissingApprovers,
+		BlockedBy:        e.Error.BlockedBy,
+		Details:          e.Error.Details,
+	}
+}
+
+// AcceptsProblem сообщает, просит ли клиент ошибки в формате
+// application/problem+json (заголовок Accept с ненулевым q).
+func AcceptsProblem(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, item := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(item))
+			if err != nil || mediaType != ProblemContentType {
+				continue
+			}
+			if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q <= 0 {
+				continue
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// problemWriter помечает ответ, ошибки которого пишутся в формате RFC 7807.
+type problemWriter struct {
+	http.ResponseWriter
+}
+
+// WithProblemFormat оборачивает w так, что Write, JSON и остальные функции
+// пакета отвечают application/problem+json. Обертки поверх w должны отдавать
+// исходный writer через Unwrap, как это делает http.ResponseController.
+func WithProblemFormat(w http.ResponseWriter) http.ResponseWriter {
+	return &problemWriter{ResponseWriter: w}
+}
+
+func (w *problemWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *problemWriter) Flush() {
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// wantsProblem ищет problemWriter в цепочке оберток w.
+func wantsProblem(w http.ResponseWriter) bool {
+	for {
+		if _, ok := w.(*problemWriter); ok {
+			return true
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return false
+		}
+		w = u.Unwrap()
+	}
+}
+
+func encodeProblem(w http.ResponseWriter, status int, e ErrResp) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(newProblem(status, e)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
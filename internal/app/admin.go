@@ -0,0 +1,118 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"log/slog"
+	"net/http"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/health"
+	"prreviewer/internal/mw"
+	"prreviewer/internal/repo"
+	"prreviewer/internal/worker"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// newAdminRouter собирает роутер служебного порта: метрики, pprof, медленные
+// запросы, статистику таблиц БД, health, ручной запуск задач воркера и режим
+// только для чтения. Бизнес-API на этот порт не попадает, но тела запросов
+// ограничены теми же limits.
+func newAdminRouter(
+	ready *health.Checker,
+	jobs *worker.Runner,
+	readOnly *mw.ReadOnlySwitch,
+	slow *mw.SlowLog,
+	repository *repo.Repository,
+	limits mw.BodyLimits,
+) http.Handler {
+	router := chi.NewRouter()
+	router.Use(middleware.Recoverer)
+	router.Use(mw.LimitBody(limits))
+
+	routeHealth(router, ready)
+	router.Handle("/metrics", expvar.Handler())
+	router.Get("/debug/slow", slow.ServeHTTP)
+	router.Mount("/debug", middleware.Profiler())
+	router.Post("/admin/jobs/run", runJob(jobs, readOnly))
+	router.Get("/admin/readonly", readOnlyState(readOnly))
+	router.Post("/admin/readonly", setReadOnly(readOnly))
+	router.Get("/admin/dbstats", dbStats(repository))
+
+	return router
+}
+
+// runJob выполняет задачу воркера ?job=<имя> один раз, не дожидаясь ее периода.
+// Ответ отправляется после завершения задачи. В режиме только для чтения задачи
+// не запускаются: они пишут в базу через тот же сервис, что и API.
+func runJob(jobs *worker.Runner, readOnly *mw.ReadOnlySwitch) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("job")
+		if readOnly.Enabled() {
+			slog.Warn("job run rejected in read-only mode", "job", name)
+			mw.WriteReadOnly(w)
+			return
+		}
+		err := jobs.RunOnce(r.Context(), name)
+		switch {
+		case errors.Is(err, worker.ErrUnknownJob):
+			apierr.JSON(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+		case err != nil:
+			apierr.JSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]string{"job": name, "status": "done"})
+		}
+	}
+}
+
+// dbStats отдает счетчики pg_stat_user_tables и pg_stat_user_indexes, чтобы
+// проверить, что запросы идут по индексам: seq_tup_read таблицы не растет,
+// а idx_scan нужных индексов растет.
+func dbStats(repository *repo.Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats, err := repository.DBStats(r.Context())
+		if err != nil {
+			apierr.JSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(stats)
+	}
+}
+
+// routeHealth регистрирует пробы: /health/live для liveness, /health/ready для
+// readiness. /health оставлен как синоним /health/live.
+func routeHealth(router chi.Router, ready *health.Checker) {
+	router.Get("/health", health.Live)
+	router.Get("/health/live", health.Live)
+	router.Get("/health/ready", ready.ServeHTTP)
+}
+
+// readOnlyState возвращает текущий режим: {"read_only": true|false}.
+func readOnlyState(readOnly *mw.ReadOnlySwitch) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]bool{"read_only": readOnly.Enabled()})
+	}
+}
+
+// setReadOnly включает или выключает режим только для чтения: {"enabled": true}.
+// Режим действует только на этот экземпляр сервиса.
+func setReadOnly(readOnly *mw.ReadOnlySwitch) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Enabled *bool `json:"enabled"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Enabled == nil {
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", `ожидается {"enabled": true|false}`)
+			return
+		}
+		readOnly.Set(*req.Enabled)
+		slog.Warn("read-only mode changed", "enabled", *req.Enabled)
+		readOnlyState(readOnly)(w, r)
+	}
+}
@@ -0,0 +1,313 @@
+// Package app собирает сервис из настроек: пул БД → репозиторий → сервис →
+// обработчики → роутеры API и служебного порта. Точки входа (server, тесты,
+// другие бинарники) получают готовое приложение через New и управляют им
+// через Start/Stop, не повторяя сборку.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"prreviewer/internal/auth"
+	"prreviewer/internal/handlers"
+	"prreviewer/internal/health"
+	"prreviewer/internal/mw"
+	"prreviewer/internal/repo"
+	"prreviewer/internal/service"
+	"prreviewer/internal/worker"
+	"prreviewer/migrations"
+)
+
+const (
+	serverReadTimeout  = 10 * time.Second
+	serverWriteTimeout = 10 * time.Second
+	serverIdleTimeout  = 60 * time.Second
+
+	// requestTimeoutMargin — запас таймаута запроса сверх самого долгого срока
+	// операции сервиса, чтобы раньше срабатывал срок сервиса с ответом 504 TIMEOUT.
+	requestTimeoutMargin = time.Second
+)
+
+// App — собранный сервис. Поля заполняет New; Handler и Admin можно
+// обслуживать своим сервером (например, httptest) без Start.
+type App struct {
+	DB         *pgxpool.Pool
+	Repository *repo.Repository
+	Service    *service.Service
+	// Handler — бизнес-API с цепочкой middleware.
+	Handler http.Handler
+	// Admin — служебный роутер: метрики, pprof, пробы, ручной запуск задач.
+	Admin http.Handler
+	// Jobs — фоновые задачи для ручного запуска через Admin; по расписанию
+	// их выполняет отдельный воркер (NewJobs).
+	Jobs *worker.Runner
+
+	cfg          Config
+	ownDB        bool
+	writeTimeout time.Duration
+	protocols    *http.Protocols
+	cancel       context.CancelFunc // фоновые горутины, например LISTEN сбросов кэша
+	servers      []*http.Server
+	errs         chan error
+}
+
+type options struct {
+	db          *pgxpool.Pool
+	serviceOpts []service.Option
+}
+
+type Option func(*options)
+
+// WithPool использует готовый пул вместо подключения по Config; Stop его
+// не закрывает.
+func WithPool(db *pgxpool.Pool) Option {
+	return func(o *options) { o.db = db }
+}
+
+// WithServiceOptions дополняет опции сервиса, собранные из Config, например
+// своим notifier или источником случайности в тестах.
+func WithServiceOptions(opts ...service.Option) Option {
+	return func(o *options) { o.serviceOpts = append(o.serviceOpts, opts...) }
+}
+
+// New собирает приложение по cfg. Сетевые порты открывает Start; до него
+// приложение только держит пул соединений.
+func New(ctx context.Context, cfg Config, opts ...Option) (*App, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	bgCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	a := &App{cfg: cfg, DB: o.db, cancel: cancel, errs: make(chan error, 3)}
+	if err := a.build(ctx, bgCtx, o); err != nil {
+		cancel()
+		if a.ownDB && a.DB != nil {
+			a.DB.Close()
+		}
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *App) build(ctx, bgCtx context.Context, o options) error {
+	cfg := a.cfg
+	if a.DB == nil {
+		db, err := Connect(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		a.DB, a.ownDB = db, true
+	}
+
+	var err error
+	if a.Repository, err = newRepository(ctx, a.DB, cfg); err != nil {
+		return err
+	}
+	svcOpts, err := serviceOptions(a.Repository, cfg)
+	if err != nil {
+		return err
+	}
+	r, err := withCache(bgCtx, withRetry(a.Repository, cfg.Retry), a.DB, cfg.Cache)
+	if err != nil {
+		return err
+	}
+	svcOpts = append(svcOpts, service.WithTimeouts(cfg.Timeouts))
+	a.Service = service.New(r, append(svcOpts, o.serviceOpts...)...)
+
+	latest, err := migrations.Latest()
+	if err != nil {
+		return fmt.Errorf("read embedded migrations: %w", err)
+	}
+	ready := health.NewChecker(a.DB, cfg.ReadinessTimeout, latest)
+
+	spec, err := handlers.NewOpenAPI()
+	if err != nil {
+		return fmt.Errorf("build OpenAPI document: %w", err)
+	}
+	compressor, err := newCompressor(cfg.Compression)
+	if err != nil {
+		return err
+	}
+	if cfg.AssignmentSeed {
+		slog.Warn("deterministic reviewer assignment enabled, do not use in production", "header", mw.AssignmentSeedHeader)
+	}
+	if cfg.ReadOnly {
+		slog.Warn("read-only mode enabled, mutating requests are rejected")
+	}
+
+	backpressure := cfg.Backpressure
+	backpressure.Stats = func() (int32, int32) {
+		s := a.DB.Stat()
+		return s.AcquiredConns(), s.MaxConns()
+	}
+	readOnly := mw.NewReadOnlySwitch(cfg.ReadOnly)
+	slow := mw.NewSlowLog(mw.DefaultSlowLogSize)
+
+	requestTimeout := cfg.Timeouts.Max() + requestTimeoutMargin
+	a.writeTimeout = max(serverWriteTimeout, requestTimeout+requestTimeoutMargin)
+	a.Handler = newRouter(routerDeps{
+		handler:        handlers.New(a.Service),
+		ready:          ready,
+		spec:           spec,
+		verifier:       authVerifier(cfg.JWTSecret),
+		readOnly:       readOnly,
+		slow:           slow,
+		slowThresholds: cfg.SlowRequests,
+		rateLimiter:    rateLimiter(cfg.RateLimit),
+		compressor:     compressor,
+		backpressure:   backpressure,
+		bodyLimits:     cfg.BodyLimits,
+		deprecations:   cfg.Deprecations,
+		legacy:         mw.DeprecationPolicy{Sunset: cfg.LegacySunset},
+		assignmentSeed: cfg.AssignmentSeed,
+		requestTimeout: requestTimeout,
+	})
+
+	// Задачи регистрируются только для ручного запуска через /admin/jobs/run;
+	// по расписанию их выполняет воркер. Ручной запуск идет через сервис API.
+	a.Jobs = newJobs(a.Service, cfg)
+	a.Admin = newAdminRouter(ready, a.Jobs, readOnly, slow, a.Repository, cfg.BodyLimits)
+
+	a.protocols = new(http.Protocols)
+	a.protocols.SetHTTP1(true)
+	a.protocols.SetHTTP2(true)
+	a.protocols.SetUnencryptedHTTP2(cfg.H2C)
+	return nil
+}
+
+// authVerifier включает проверку JWT для управления командами, если задан секрет.
+func authVerifier(secret string) *auth.Verifier {
+	if secret == "" {
+		slog.Warn("JWT_SECRET not set, team management endpoints are unauthenticated")
+		return nil
+	}
+	return auth.NewVerifier([]byte(secret))
+}
+
+// rateLimiter создает лимит запросов; без RPS лимит выключен.
+func rateLimiter(cfg RateLimitConfig) *mw.RateLimiter {
+	if cfg.RPS <= 0 {
+		return nil
+	}
+	slog.Info("rate limiting enabled", "rps", cfg.RPS, "burst", cfg.Burst)
+	return mw.NewRateLimiter(cfg.RPS, cfg.Burst)
+}
+
+// newCompressor создает сжатие ответов; nil выключает его.
+func newCompressor(cfg *mw.CompressConfig) (*mw.Compressor, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	c, err := mw.NewCompressor(*cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid compression config: %w", err)
+	}
+	names := make([]string, len(cfg.Encoders))
+	for i, enc := range cfg.Encoders {
+		names[i] = enc.Name
+	}
+	slog.Info("response compression enabled", "encodings", names, "level", cfg.Level, "min_size", cfg.MinSize)
+	return c, nil
+}
+
+// Start открывает порты API, служебного порта и Unix-сокета (если они заданы)
+// и обслуживает их в фоне. Порты открываются до запуска серверов: если один из
+// них не открылся, уже открытые закрываются и ошибка возвращается сразу, а
+// ошибка обслуживания приходит через Err.
+func (a *App) Start() error {
+	apiLn, err := net.Listen("tcp", ":"+a.cfg.Port)
+	if err != nil {
+		return fmt.Errorf("listen on port %s: %w", a.cfg.Port, err)
+	}
+
+	var adminLn net.Listener
+	if a.cfg.AdminAddr != AdminAddrDisabled {
+		adminLn, err = net.Listen("tcp", a.cfg.AdminAddr)
+		if err != nil {
+			apiLn.Close()
+			return fmt.Errorf("admin listen on %s: %w", a.cfg.AdminAddr, err)
+		}
+	}
+
+	var sockLn net.Listener
+	if path := a.cfg.Socket.Path; path != "" {
+		sockLn, err = listenUnix(path, a.cfg.Socket.Mode)
+		if err != nil {
+			apiLn.Close()
+			if adminLn != nil {
+				adminLn.Close()
+			}
+			return fmt.Errorf("listen on unix socket %s: %w", path, err)
+		}
+	}
+
+	api := a.newServer(a.Handler, a.writeTimeout)
+	api.ErrorLog = slog.NewLogLogger(slog.Default().Handler(), slog.LevelError)
+	slog.Info("server starting", "port", a.cfg.Port, "tls", a.cfg.TLS.enabled())
+	a.run(api, func() error { return serve(api, apiLn, a.cfg.TLS) })
+
+	if adminLn == nil {
+		slog.Info("admin listener disabled")
+	} else {
+		admin := a.newServer(a.Admin, serverWriteTimeout)
+		slog.Info("admin server starting", "addr", a.cfg.AdminAddr)
+		a.run(admin, func() error { return admin.Serve(adminLn) })
+	}
+
+	if sockLn != nil {
+		sock := a.newServer(a.Handler, a.writeTimeout)
+		slog.Info("server listening on unix socket", "path", a.cfg.Socket.Path)
+		a.run(sock, func() error { return sock.Serve(sockLn) })
+	}
+	return nil
+}
+
+func (a *App) newServer(h http.Handler, writeTimeout time.Duration) *http.Server {
+	return &http.Server{
+		Handler:      h,
+		ReadTimeout:  serverReadTimeout,
+		WriteTimeout: writeTimeout,
+		IdleTimeout:  serverIdleTimeout,
+		Protocols:    a.protocols,
+	}
+}
+
+// run обслуживает srv в фоне; ошибка, кроме штатной остановки, уходит в Err.
+func (a *App) run(srv *http.Server, serve func() error) {
+	a.servers = append(a.servers, srv)
+	go func() {
+		if err := serve(); !errors.Is(err, http.ErrServerClosed) {
+			a.errs <- err
+		}
+	}()
+}
+
+// Err сообщает об ошибке сервера, запущенного Start; после нее приложение
+// нужно остановить.
+func (a *App) Err() <-chan error {
+	return a.errs
+}
+
+// Stop дожидается завершения текущих запросов, пока не истек ctx, и
+// освобождает ресурсы приложения; пул из WithPool остается открытым.
+func (a *App) Stop(ctx context.Context) error {
+	var errs []error
+	for _, srv := range a.servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	a.cancel()
+	if a.ownDB {
+		a.DB.Close()
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,197 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"prreviewer/internal/handlers"
+	"prreviewer/internal/health"
+	"prreviewer/internal/mw"
+	"prreviewer/internal/openapi"
+	"prreviewer/internal/repo/memory"
+	"prreviewer/internal/service"
+)
+
+var update = flag.Bool("update", false, "перезаписать ответы в testdata/contract по текущему поведению")
+
+// exchange — записанная пара запрос/ответ контрактного сценария.
+type exchange struct {
+	Request struct {
+		Method string          `json:"method"`
+		Path   string          `json:"path"`
+		Body   json.RawMessage `json:"body,omitempty"`
+	} `json:"request"`
+	Response struct {
+		Status int             `json:"status"`
+		Body   json.RawMessage `json:"body,omitempty"`
+	} `json:"response"`
+}
+
+// TestContract проигрывает записанные сценарии testdata/contract/*.json через
+// роутер API поверх репозитория в памяти. Каждый ответ — и полученный, и
+// записанный — проверяется по OpenAPI-документу, а структура полученного
+// ответа (ключи и типы, без значений) сравнивается с записанной. Так
+// переименование поля модели ломает тест, даже если схема документа
+// поменялась вместе с моделью. После намеренного изменения API записи
+// обновляются: go test ./internal/app -run TestContract -update.
+func TestContract(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "contract", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("нет сценариев в testdata/contract")
+	}
+	spec, err := handlers.NewOpenAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range files {
+		t.Run(strings.TrimSuffix(filepath.Base(file), ".json"), func(t *testing.T) {
+			raw, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var exchanges []exchange
+			if err := json.Unmarshal(raw, &exchanges); err != nil {
+				t.Fatal(err)
+			}
+
+			router := contractRouter(spec)
+			for i := range exchanges {
+				replay(t, router, spec, &exchanges[i])
+			}
+
+			if *update {
+				out, err := json.MarshalIndent(exchanges, "", "  ")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(file, append(out, '\n'), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// contractRouter собирает роутер API, как New, но поверх репозитория в
+// памяти и без проверки JWT; выбор ревьюверов фиксируется заголовком.
+func contractRouter(spec *openapi.Document) http.Handler {
+	cfg := DefaultConfig()
+	return newRouter(routerDeps{
+		handler:        handlers.New(service.New(memory.New())),
+		ready:          health.NewChecker(nil, time.Second, 0),
+		spec:           spec,
+		readOnly:       mw.NewReadOnlySwitch(false),
+		slow:           mw.NewSlowLog(mw.DefaultSlowLogSize),
+		slowThresholds: cfg.SlowRequests,
+		bodyLimits:     cfg.BodyLimits,
+		assignmentSeed: true,
+		requestTimeout: time.Minute,
+	})
+}
+
+// replay выполняет запрос ex и сверяет ответ с записью и документом; с -update
+// запись заменяется полученным ответом, если тот отличается кодом или структурой.
+func replay(t *testing.T, router http.Handler, spec *openapi.Document, ex *exchange) {
+	t.Helper()
+	name := ex.Request.Method + " " + ex.Request.Path
+
+	rctx := chi.NewRouteContext()
+	req := httptest.NewRequest(ex.Request.Method, ex.Request.Path, bytes.NewReader(ex.Request.Body))
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(mw.AssignmentSeedHeader, "42")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	route := rctx.RoutePattern()
+	if route == "" {
+		// Запрос отклонен до маршрутизации, например проверкой по документу.
+		route = req.URL.Path
+	}
+
+	got := bytes.TrimSpace(rec.Body.Bytes())
+	for _, e := range spec.ValidateResponse(ex.Request.Method, route, rec.Code, got) {
+		t.Errorf("%s: ответ %d не соответствует документу: %s: %s\n%s", name, rec.Code, e.Field, e.Message, got)
+	}
+
+	if *update {
+		// Совпадающую по коду и структуре запись не трогаем, чтобы в diff
+		// попадали только изменения API, а не новые время и request_id.
+		if rec.Code == ex.Response.Status && reflect.DeepEqual(shapeOf(t, ex.Response.Body), shapeOf(t, got)) {
+			return
+		}
+		ex.Response.Status = rec.Code
+		ex.Response.Body = nil
+		if len(got) > 0 {
+			ex.Response.Body = json.RawMessage(got)
+		}
+		return
+	}
+
+	if rec.Code != ex.Response.Status {
+		t.Errorf("%s: ожидался код %d, получили %d: %s", name, ex.Response.Status, rec.Code, got)
+		return
+	}
+	for _, e := range spec.ValidateResponse(ex.Request.Method, route, ex.Response.Status, ex.Response.Body) {
+		t.Errorf("%s: записанный ответ не соответствует документу: %s: %s", name, e.Field, e.Message)
+	}
+	want, have := shapeOf(t, ex.Response.Body), shapeOf(t, got)
+	if !reflect.DeepEqual(want, have) {
+		w, _ := json.Marshal(want)
+		h, _ := json.Marshal(have)
+		t.Errorf("%s: структура ответа изменилась\nзаписано: %s\nполучено: %s", name, w, h)
+	}
+}
+
+// shapeOf описывает структуру JSON-тела без значений: ключи объектов и типы
+// значений; у массива — структура первого элемента.
+func shapeOf(t *testing.T, body []byte) any {
+	t.Helper()
+	if len(body) == 0 {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		t.Fatalf("тело не JSON: %v", err)
+	}
+	return shape(v)
+}
+
+func shape(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, x := range v {
+			m[k] = shape(x)
+		}
+		return m
+	case []any:
+		if len(v) == 0 {
+			return []any{}
+		}
+		return []any{shape(v[0])}
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
@@ -0,0 +1,150 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"prreviewer/internal/cache"
+	"prreviewer/internal/ldap"
+	"prreviewer/internal/mw"
+	"prreviewer/internal/notify"
+	"prreviewer/internal/repo"
+	"prreviewer/internal/repo/cached"
+	"prreviewer/internal/repo/retrying"
+	"prreviewer/internal/service"
+	"prreviewer/internal/vcs"
+)
+
+// Connect открывает пул соединений к основной БД по cfg.Pool или, если он не
+// задан, по cfg.DatabaseURL. Запросы пула попадают в тайминги медленных
+// запросов (mw.QueryTracer).
+func Connect(ctx context.Context, cfg Config) (*pgxpool.Pool, error) {
+	slog.Info("connecting to database")
+	poolCfg := cfg.Pool
+	if poolCfg == nil {
+		var err error
+		if poolCfg, err = pgxpool.ParseConfig(cfg.DatabaseURL); err != nil {
+			return nil, fmt.Errorf("invalid DATABASE_URL: %w", err)
+		}
+	}
+	db, err := openPool(ctx, poolCfg)
+	if err != nil {
+		return nil, fmt.Errorf("connect to database: %w", err)
+	}
+	slog.Info("database connection established",
+		"max_conns", poolCfg.MaxConns,
+		"min_conns", poolCfg.MinConns,
+		"max_conn_lifetime", poolCfg.MaxConnLifetime,
+		"health_check_period", poolCfg.HealthCheckPeriod,
+	)
+	return db, nil
+}
+
+func openPool(ctx context.Context, cfg *pgxpool.Config) (*pgxpool.Pool, error) {
+	cfg = cfg.Copy()
+	cfg.ConnConfig.Tracer = mw.QueryTracer{}
+	return pgxpool.NewWithConfig(ctx, cfg)
+}
+
+// prepareStatements готовит горячие запросы на новом соединении. Ошибка
+// подготовки (например, схема еще не мигрирована) не закрывает соединение:
+// неподготовленные запросы выполняются в обычном режиме.
+func prepareStatements(ctx context.Context, conn *pgx.Conn) error {
+	if err := repo.PrepareStatements(ctx, conn); err != nil {
+		slog.Warn("hot statements not prepared", "error", err)
+	}
+	return nil
+}
+
+// newRepository создает репозиторий основной БД. Пул реплики подключается
+// лениво: недоступная при старте реплика не мешает запуску, чтения уходят
+// в основную БД. С кэшем в памяти записи команд и пользователей рассылают
+// сброс кэша через NOTIFY в своей транзакции — и из API, и из воркера.
+func newRepository(ctx context.Context, db *pgxpool.Pool, cfg Config) (*repo.Repository, error) {
+	opts := []repo.Option{
+		repo.WithDeactivationIsolation(cfg.DeactivationIsolation),
+		repo.WithDeactivationRetries(cfg.DeactivationRetries),
+	}
+	if cfg.Replica != nil {
+		replica, err := openPool(ctx, cfg.Replica)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DATABASE_REPLICA_URL: %w", err)
+		}
+		opts = append(opts, repo.WithReplica(replica))
+	}
+	if cfg.Cache.Kind == cache.KindMemory && cfg.Cache.Invalidation {
+		opts = append(opts, repo.WithInvalidation(repo.NewInvalidationBus(db)))
+	}
+	return repo.New(db, opts...), nil
+}
+
+// withRetry оборачивает репозиторий повторами при временных сбоях БД.
+func withRetry(r service.Repository, p retrying.Policy) service.Repository {
+	if p.Attempts <= 1 {
+		return r
+	}
+	return retrying.New(r, p)
+}
+
+// withCache оборачивает репозиторий кэшем команд и статистики, если он задан.
+// Кэш в памяти сбрасывается по сообщениям LISTEN/NOTIFY основной БД о записях
+// любого процесса, пока не отменен ctx. Кэш в Redis общий и рассылки не требует.
+func withCache(ctx context.Context, r service.Repository, db *pgxpool.Pool, cfg CacheConfig) (service.Repository, error) {
+	c, err := cache.New(cfg.Kind, cache.Config{RedisURL: cfg.RedisURL})
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE: %w", err)
+	}
+	if c == nil {
+		return r, nil
+	}
+	if cfg.Kind != cache.KindMemory || !cfg.Invalidation {
+		return cached.New(r, c, cfg.TTL), nil
+	}
+
+	cr := cached.New(r, c, cfg.TTL)
+	go repo.NewInvalidationBus(db).Listen(ctx, cr.Drop)
+	return cr, nil
+}
+
+// serviceOptions собирает опции сервиса из настроек: клиенты VCS для auto-merge,
+// каналы уведомлений, каталог LDAP и сроки фоновых задач.
+func serviceOptions(r *repo.Repository, cfg Config) ([]service.Option, error) {
+	opts := []service.Option{
+		service.WithStaleAfter(cfg.StaleAfter),
+		service.WithArchiveAfter(cfg.ArchiveAfter),
+		service.WithAutoReassignAfter(cfg.AutoReassignAfter),
+	}
+	if cfg.VCS.GitHubToken != "" {
+		opts = append(opts, service.WithMerger(vcs.ProviderGitHub, vcs.NewGitHub(cfg.VCS.GitHubURL, cfg.VCS.GitHubToken)))
+	}
+	if cfg.VCS.GitHubURL != "" {
+		opts = append(opts, service.WithGitHubURL(cfg.VCS.GitHubURL))
+	}
+	if cfg.VCS.GitLabToken != "" {
+		opts = append(opts, service.WithMerger(vcs.ProviderGitLab, vcs.NewGitLab(cfg.VCS.GitLabURL, cfg.VCS.GitLabToken)))
+	}
+
+	notifier, err := notify.New(cfg.Notifier.Kinds, notify.Config{
+		URL:        cfg.Notifier.URL,
+		SMTP:       cfg.Notifier.SMTP,
+		Recipients: r,
+		Identities: r,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid NOTIFIER: %w", err)
+	}
+	opts = append(opts, service.WithNotifier(notifier))
+
+	if cfg.LDAP != nil {
+		dir, err := ldap.New(*cfg.LDAP)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LDAP config: %w", err)
+		}
+		opts = append(opts, service.WithDirectory(dir))
+	}
+	return opts, nil
+}
@@ -0,0 +1,411 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"prreviewer/internal/ldap"
+	"prreviewer/internal/mw"
+	"prreviewer/internal/notify"
+	"prreviewer/internal/repo"
+)
+
+// env читает переменные окружения и запоминает первую ошибку разбора, чтобы
+// ConfigFromEnv проверяла ее один раз в конце.
+type env struct {
+	getenv func(string) string
+	err    error
+}
+
+func (e *env) get(name string) string {
+	return e.getenv(name)
+}
+
+// fail запоминает ошибку разбора переменной name, если ошибки еще не было.
+func (e *env) fail(name string, err error) {
+	if e.err == nil {
+		e.err = fmt.Errorf("invalid %s: %w", name, err)
+	}
+}
+
+// failValue — fail для значения, не прошедшего проверку.
+func (e *env) failValue(name, v string) {
+	e.fail(name, fmt.Errorf("bad value %q", v))
+}
+
+// duration читает положительную длительность; пустая переменная — def.
+func (e *env) duration(name string, def time.Duration) time.Duration {
+	v := e.get(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		e.failValue(name, v)
+		return def
+	}
+	return d
+}
+
+// int32 читает целое не меньше minValue; пустая переменная — def.
+func (e *env) int32(name string, def, minValue int32) int32 {
+	v := e.get(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.ParseInt(v, 10, 32)
+	if err != nil || int32(n) < minValue {
+		e.failValue(name, v)
+		return def
+	}
+	return int32(n)
+}
+
+// int читает целое не меньше minValue; пустая переменная — def.
+func (e *env) int(name string, def, minValue int) int {
+	v := e.get(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < minValue {
+		e.failValue(name, v)
+		return def
+	}
+	return n
+}
+
+// bool читает логическое значение; пустая переменная — def.
+func (e *env) bool(name string, def bool) bool {
+	v := e.get(name)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		e.failValue(name, v)
+		return def
+	}
+	return b
+}
+
+// ConfigFromEnv собирает Config из переменных окружения поверх DefaultConfig;
+// getenv обычно os.Getenv. Ошибка называет первую переменную с неверным
+// значением.
+func ConfigFromEnv(getenv func(string) string) (Config, error) {
+	e := &env{getenv: getenv}
+	cfg := DefaultConfig()
+
+	if v := e.get("APP_PORT"); v != "" {
+		cfg.Port = v
+	}
+	if v := e.get("ADMIN_ADDR"); v != "" {
+		cfg.AdminAddr = v
+	}
+	cfg.Socket = e.socket(cfg.Socket)
+	cfg.TLS = e.tls(cfg.TLS)
+	cfg.H2C = e.bool("APP_H2C", cfg.H2C)
+
+	if v := e.get("DATABASE_URL"); v != "" {
+		cfg.DatabaseURL = v
+	}
+	cfg.Pool = e.pool("DATABASE_URL", cfg.DatabaseURL)
+	if v := e.get("DATABASE_REPLICA_URL"); v != "" {
+		cfg.Replica = e.pool("DATABASE_REPLICA_URL", v)
+	}
+	isoLevel, err := repo.ParseIsoLevel(e.get("DEACTIVATION_ISOLATION"))
+	if err != nil {
+		e.fail("DEACTIVATION_ISOLATION", err)
+	}
+	cfg.DeactivationIsolation = isoLevel
+	cfg.DeactivationRetries = e.int("DEACTIVATION_RETRIES", cfg.DeactivationRetries, 0)
+	cfg.Retry.Attempts = e.int("DB_RETRY_ATTEMPTS", cfg.Retry.Attempts, 1)
+	cfg.Retry.BaseDelay = e.duration("DB_RETRY_BASE_DELAY", cfg.Retry.BaseDelay)
+	cfg.Retry.MaxDelay = e.duration("DB_RETRY_MAX_DELAY", cfg.Retry.MaxDelay)
+	cfg.Cache = CacheConfig{
+		Kind:         e.get("CACHE"),
+		RedisURL:     e.get("CACHE_REDIS_URL"),
+		TTL:          e.duration("CACHE_TTL", cfg.Cache.TTL),
+		Invalidation: e.get("CACHE_INVALIDATION") != "off",
+	}
+
+	cfg.Timeouts.Read = e.duration("OP_TIMEOUT_READ", cfg.Timeouts.Read)
+	cfg.Timeouts.Write = e.duration("OP_TIMEOUT_WRITE", cfg.Timeouts.Write)
+	cfg.Timeouts.Bulk = e.duration("OP_TIMEOUT_BULK", cfg.Timeouts.Bulk)
+	cfg.ReadinessTimeout = e.duration("READINESS_TIMEOUT", cfg.ReadinessTimeout)
+	cfg.JWTSecret = e.get("JWT_SECRET")
+	cfg.RateLimit = e.rateLimit()
+	cfg.BodyLimits = e.bodyLimits(cfg.BodyLimits)
+	cfg.Backpressure = e.backpressure(cfg.Backpressure)
+	cfg.SlowRequests = e.slowThresholds(cfg.SlowRequests)
+	cfg.Compression = e.compression(cfg.Compression)
+	deprecations, err := mw.ParseDeprecations(e.get("DEPRECATED_ROUTES"))
+	if err != nil {
+		e.fail("DEPRECATED_ROUTES", err)
+	}
+	cfg.Deprecations = deprecations
+	if v := e.get("LEGACY_ROUTES_SUNSET"); v != "" {
+		sunset, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			e.failValue("LEGACY_ROUTES_SUNSET", v)
+		}
+		cfg.LegacySunset = sunset
+	}
+	cfg.AssignmentSeed = e.bool("DEV_ASSIGNMENT_SEED", cfg.AssignmentSeed)
+	cfg.ReadOnly = e.bool("READ_ONLY", cfg.ReadOnly)
+
+	cfg.VCS = VCSConfig{
+		GitHubToken: e.get("VCS_GITHUB_TOKEN"),
+		GitHubURL:   e.get("VCS_GITHUB_URL"),
+		GitLabToken: e.get("VCS_GITLAB_TOKEN"),
+		GitLabURL:   e.get("VCS_GITLAB_URL"),
+	}
+	cfg.Notifier = NotifierConfig{
+		Kinds: e.get("NOTIFIER"),
+		URL:   e.get("NOTIFIER_URL"),
+		SMTP: notify.SMTPConfig{
+			Host:         e.get("SMTP_HOST"),
+			Port:         e.get("SMTP_PORT"),
+			Username:     e.get("SMTP_USERNAME"),
+			Password:     e.get("SMTP_PASSWORD"),
+			From:         e.get("SMTP_FROM"),
+			TemplatesDir: e.get("SMTP_TEMPLATES_DIR"),
+		},
+	}
+	cfg.LDAP = e.ldap()
+	cfg.StaleAfter = e.duration("STALE_PR_AGE", cfg.StaleAfter)
+	cfg.ArchiveAfter = e.duration("PR_ARCHIVE_AGE", cfg.ArchiveAfter)
+	cfg.AutoReassignAfter = e.duration("AUTO_REASSIGN_AFTER", cfg.AutoReassignAfter)
+	cfg.Jobs = JobIntervals{
+		StaleReminders: e.duration("STALE_PR_CHECK_INTERVAL", cfg.Jobs.StaleReminders),
+		Outbox:         e.duration("OUTBOX_RELAY_INTERVAL", cfg.Jobs.Outbox),
+		PRArchive:      e.duration("PR_ARCHIVE_INTERVAL", cfg.Jobs.PRArchive),
+		AutoReassign:   e.duration("AUTO_REASSIGN_INTERVAL", cfg.Jobs.AutoReassign),
+		LDAPSync:       e.duration("LDAP_SYNC_INTERVAL", cfg.Jobs.LDAPSync),
+		Digest:         e.duration("DIGEST_INTERVAL", cfg.Jobs.Digest),
+	}
+
+	return cfg, e.err
+}
+
+// socket читает APP_SOCKET и APP_SOCKET_MODE (восьмеричные права файла сокета).
+func (e *env) socket(def SocketConfig) SocketConfig {
+	s := def
+	s.Path = e.get("APP_SOCKET")
+	if v := e.get("APP_SOCKET_MODE"); v != "" {
+		m, err := strconv.ParseUint(v, 8, 32)
+		if err != nil {
+			e.fail("APP_SOCKET_MODE", err)
+		}
+		s.Mode = fs.FileMode(m)
+	}
+	return s
+}
+
+// tls читает TLS_CERT_FILE/TLS_KEY_FILE и TLS_AUTOCERT_*.
+func (e *env) tls(def TLSConfig) TLSConfig {
+	s := def
+	s.CertFile = e.get("TLS_CERT_FILE")
+	s.KeyFile = e.get("TLS_KEY_FILE")
+	s.AutocertEmail = e.get("TLS_AUTOCERT_EMAIL")
+	if v := e.get("TLS_AUTOCERT_CACHE_DIR"); v != "" {
+		s.AutocertCache = v
+	}
+	if domains := e.get("TLS_AUTOCERT_DOMAINS"); domains != "" {
+		for _, d := range strings.Split(domains, ",") {
+			if d = strings.TrimSpace(d); d != "" {
+				s.AutocertDomains = append(s.AutocertDomains, d)
+			}
+		}
+	}
+
+	if (s.CertFile == "") != (s.KeyFile == "") {
+		e.fail("TLS configuration", errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together"))
+	}
+	if s.CertFile != "" && len(s.AutocertDomains) > 0 {
+		e.fail("TLS configuration", errors.New("TLS_CERT_FILE and TLS_AUTOCERT_DOMAINS are mutually exclusive"))
+	}
+	return s
+}
+
+// pool разбирает URL из переменной name и применяет настройки пула из
+// DB_MAX_CONNS, DB_MIN_CONNS, DB_MAX_CONN_LIFETIME и DB_HEALTH_CHECK_PERIOD.
+// Незаданная переменная оставляет значение из параметров pool_* в URL или
+// значение pgx по умолчанию. Режим выполнения запросов и подготовку горячих
+// запросов задает statements.
+func (e *env) pool(name, dbURL string) *pgxpool.Config {
+	cfg, err := pgxpool.ParseConfig(dbURL)
+	if err != nil {
+		e.fail(name, err)
+		return nil
+	}
+
+	cfg.MaxConns = e.int32("DB_MAX_CONNS", cfg.MaxConns, 1)
+	cfg.MinConns = e.int32("DB_MIN_CONNS", cfg.MinConns, 0)
+	if cfg.MinConns > cfg.MaxConns {
+		e.fail("DB_MIN_CONNS", fmt.Errorf("%d exceeds DB_MAX_CONNS %d", cfg.MinConns, cfg.MaxConns))
+	}
+	cfg.MaxConnLifetime = e.duration("DB_MAX_CONN_LIFETIME", cfg.MaxConnLifetime)
+	cfg.HealthCheckPeriod = e.duration("DB_HEALTH_CHECK_PERIOD", cfg.HealthCheckPeriod)
+	e.statements(cfg)
+	return cfg
+}
+
+// statements применяет DB_QUERY_EXEC_MODE, DB_STATEMENT_CACHE_CAPACITY
+// и DB_PREPARE_STATEMENTS. Для PgBouncer в режиме transaction нужны
+// DB_QUERY_EXEC_MODE=exec или simple_protocol и DB_PREPARE_STATEMENTS=false.
+func (e *env) statements(cfg *pgxpool.Config) {
+	if v := e.get("DB_QUERY_EXEC_MODE"); v != "" {
+		mode, err := repo.ParseQueryExecMode(v)
+		if err != nil {
+			e.fail("DB_QUERY_EXEC_MODE", err)
+		}
+		cfg.ConnConfig.DefaultQueryExecMode = mode
+	}
+	if v := e.get("DB_STATEMENT_CACHE_CAPACITY"); v != "" {
+		n := e.int("DB_STATEMENT_CACHE_CAPACITY", 0, 0)
+		cfg.ConnConfig.StatementCacheCapacity = n
+		cfg.ConnConfig.DescriptionCacheCapacity = n
+	}
+	if e.bool("DB_PREPARE_STATEMENTS", true) {
+		cfg.AfterConnect = prepareStatements
+	}
+}
+
+// rateLimit читает RATE_LIMIT_RPS/RATE_LIMIT_BURST; без RATE_LIMIT_RPS лимит выключен.
+func (e *env) rateLimit() RateLimitConfig {
+	v := e.get("RATE_LIMIT_RPS")
+	if v == "" {
+		return RateLimitConfig{}
+	}
+	rps, err := strconv.ParseFloat(v, 64)
+	if err != nil || rps <= 0 {
+		e.failValue("RATE_LIMIT_RPS", v)
+		return RateLimitConfig{}
+	}
+	return RateLimitConfig{RPS: rps, Burst: e.int("RATE_LIMIT_BURST", int(math.Ceil(rps)), 1)}
+}
+
+// bodyLimits читает MAX_BODY_SIZE и переопределения MAX_BODY_SIZE_ROUTES поверх
+// встроенного лимита /team/import.
+func (e *env) bodyLimits(def mw.BodyLimits) mw.BodyLimits {
+	limits := mw.BodyLimits{Default: def.Default, Routes: maps.Clone(def.Routes)}
+	if v := e.get("MAX_BODY_SIZE"); v != "" {
+		n, err := mw.ParseSize(v)
+		if err != nil {
+			e.fail("MAX_BODY_SIZE", err)
+		}
+		limits.Default = n
+	}
+	routes, err := mw.ParseBodyLimits(e.get("MAX_BODY_SIZE_ROUTES"))
+	if err != nil {
+		e.fail("MAX_BODY_SIZE_ROUTES", err)
+	}
+	maps.Copy(limits.Routes, routes)
+	return limits
+}
+
+// backpressure читает BACKPRESSURE_THRESHOLD, BACKPRESSURE_RETRY_AFTER и
+// BACKPRESSURE_ROUTES.
+func (e *env) backpressure(def mw.Backpressure) mw.Backpressure {
+	b := def
+	b.RetryAfter = e.duration("BACKPRESSURE_RETRY_AFTER", b.RetryAfter)
+	if v := e.get("BACKPRESSURE_THRESHOLD"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || f > 1 {
+			e.failValue("BACKPRESSURE_THRESHOLD", v)
+		}
+		b.Threshold = f
+	}
+	if v := e.get("BACKPRESSURE_ROUTES"); v != "" {
+		routes, err := mw.ParseShedRoutes(v)
+		if err != nil {
+			e.fail("BACKPRESSURE_ROUTES", err)
+		}
+		b.Routes = routes
+	}
+	return b
+}
+
+// slowThresholds читает SLOW_REQUEST_THRESHOLD и SLOW_REQUEST_ROUTES.
+func (e *env) slowThresholds(def mw.SlowThresholds) mw.SlowThresholds {
+	t := def
+	if v := e.get("SLOW_REQUEST_THRESHOLD"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			e.failValue("SLOW_REQUEST_THRESHOLD", v)
+		}
+		t.Default = d
+	}
+	routes, err := mw.ParseSlowThresholds(e.get("SLOW_REQUEST_ROUTES"))
+	if err != nil {
+		e.fail("SLOW_REQUEST_ROUTES", err)
+	}
+	t.Routes = routes
+	return t
+}
+
+// compression читает COMPRESSION и COMPRESSION_*; COMPRESSION=off выключает сжатие.
+func (e *env) compression(def *mw.CompressConfig) *mw.CompressConfig {
+	v := e.get("COMPRESSION")
+	if v == "off" {
+		return nil
+	}
+	cfg := *def
+	if v != "" {
+		cfg.Encoders = nil
+		for name := range strings.SplitSeq(v, ",") {
+			enc, ok := mw.Encoders[strings.TrimSpace(name)]
+			if !ok {
+				e.fail("COMPRESSION", fmt.Errorf("unsupported encoding %q, supported: br, gzip, deflate", name))
+				continue
+			}
+			cfg.Encoders = append(cfg.Encoders, enc)
+		}
+	}
+
+	if v := e.get("COMPRESSION_LEVEL"); v != "" {
+		level, err := strconv.Atoi(v)
+		if err != nil || level < 1 || level > 9 {
+			e.failValue("COMPRESSION_LEVEL", v)
+		}
+		cfg.Level = level
+	}
+	cfg.MinSize = e.int("COMPRESSION_MIN_SIZE", cfg.MinSize, 0)
+	if v := e.get("COMPRESSION_TYPES"); v != "" {
+		cfg.ContentTypes = nil
+		for t := range strings.SplitSeq(v, ",") {
+			cfg.ContentTypes = append(cfg.ContentTypes, strings.TrimSpace(t))
+		}
+	}
+	return &cfg
+}
+
+// ldap собирает настройки каталога из LDAP_* переменных; без LDAP_URL — nil.
+func (e *env) ldap() *ldap.Config {
+	url := e.get("LDAP_URL")
+	if url == "" {
+		return nil
+	}
+	groups, err := ldap.ParseGroups(e.get("LDAP_GROUP_TEAMS"))
+	if err != nil {
+		e.fail("LDAP_GROUP_TEAMS", err)
+	}
+	return &ldap.Config{
+		URL:          url,
+		StartTLS:     e.bool("LDAP_START_TLS", false),
+		BindDN:       e.get("LDAP_BIND_DN"),
+		BindPassword: e.get("LDAP_BIND_PASSWORD"),
+		BaseDN:       e.get("LDAP_BASE_DN"),
+		UserAttr:     e.get("LDAP_USER_ATTR"),
+		Groups:       groups,
+	}
+}
@@ -0,0 +1,103 @@
+package app_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"prreviewer/internal/app"
+)
+
+func getenv(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+func TestConfigFromEnvDefaults(t *testing.T) {
+	cfg, err := app.ConfigFromEnv(getenv(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	def := app.DefaultConfig()
+	if cfg.Port != def.Port || cfg.AdminAddr != def.AdminAddr || cfg.DatabaseURL != def.DatabaseURL {
+		t.Errorf("адреса должны быть по умолчанию, получили %q %q %q", cfg.Port, cfg.AdminAddr, cfg.DatabaseURL)
+	}
+	if cfg.Pool == nil || cfg.Pool.AfterConnect == nil {
+		t.Error("пул должен быть разобран из DATABASE_URL с подготовкой горячих запросов")
+	}
+	if cfg.Replica != nil || cfg.LDAP != nil || cfg.RateLimit.RPS != 0 {
+		t.Error("реплика, LDAP и лимит запросов без переменных должны быть выключены")
+	}
+	if cfg.Compression == nil || cfg.Compression.Encoders[0].Name != "gzip" {
+		t.Error("по умолчанию ответы сжимаются gzip")
+	}
+	if !cfg.Cache.Invalidation {
+		t.Error("рассылка сбросов кэша по умолчанию включена")
+	}
+}
+
+func TestConfigFromEnvOverrides(t *testing.T) {
+	cfg, err := app.ConfigFromEnv(getenv(map[string]string{
+		"APP_PORT":              "9000",
+		"DB_MAX_CONNS":          "7",
+		"DB_PREPARE_STATEMENTS": "false",
+		"OP_TIMEOUT_WRITE":      "3s",
+		"RATE_LIMIT_RPS":        "2.5",
+		"COMPRESSION":           "off",
+		"CACHE":                 "memory",
+		"CACHE_INVALIDATION":    "off",
+		"LDAP_URL":              "ldap://localhost:389",
+		"LDAP_GROUP_TEAMS":      "backend=cn=backend,dc=example,dc=com",
+		"STALE_PR_AGE":          "24h",
+		"LEGACY_ROUTES_SUNSET":  "2027-06-01",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != "9000" {
+		t.Errorf("порт 9000, получили %q", cfg.Port)
+	}
+	if cfg.Pool.MaxConns != 7 || cfg.Pool.AfterConnect != nil {
+		t.Errorf("ожидался пул на 7 соединений без подготовки запросов, получили %d", cfg.Pool.MaxConns)
+	}
+	if cfg.Timeouts.Write != 3*time.Second {
+		t.Errorf("срок записи 3s, получили %v", cfg.Timeouts.Write)
+	}
+	if cfg.RateLimit.RPS != 2.5 || cfg.RateLimit.Burst != 3 {
+		t.Errorf("лимит 2.5 rps с burst 3, получили %+v", cfg.RateLimit)
+	}
+	if cfg.Compression != nil {
+		t.Error("COMPRESSION=off выключает сжатие")
+	}
+	if cfg.Cache.Kind != "memory" || cfg.Cache.Invalidation {
+		t.Errorf("ожидался кэш в памяти без рассылки, получили %+v", cfg.Cache)
+	}
+	if cfg.LDAP == nil || cfg.LDAP.URL != "ldap://localhost:389" {
+		t.Error("LDAP_URL включает каталог")
+	}
+	if cfg.StaleAfter != 24*time.Hour {
+		t.Errorf("порог зависания 24h, получили %v", cfg.StaleAfter)
+	}
+	if !cfg.LegacySunset.Equal(time.Date(2027, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("дата отключения маршрутов без версии 2027-06-01, получили %v", cfg.LegacySunset)
+	}
+}
+
+func TestConfigFromEnvInvalid(t *testing.T) {
+	tests := map[string]map[string]string{
+		"DB_MAX_CONNS":         {"DB_MAX_CONNS": "0"},
+		"OP_TIMEOUT_READ":      {"OP_TIMEOUT_READ": "soon"},
+		"READ_ONLY":            {"READ_ONLY": "maybe"},
+		"COMPRESSION":          {"COMPRESSION": "zstd"},
+		"TLS configuration":    {"TLS_CERT_FILE": "cert.pem"},
+		"DB_QUERY_EXEC_MODE":   {"DB_QUERY_EXEC_MODE": "fast"},
+		"LEGACY_ROUTES_SUNSET": {"LEGACY_ROUTES_SUNSET": "next year"},
+	}
+	for name, vars := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := app.ConfigFromEnv(getenv(vars))
+			if err == nil || !strings.Contains(err.Error(), name) {
+				t.Errorf("ошибка должна называть %s, получили %v", name, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"prreviewer/internal/logging"
+	"prreviewer/internal/service"
+	"prreviewer/internal/worker"
+)
+
+// NewJobs создает воркер с фоновыми задачами сервиса поверх пула db; opts
+// передаются worker.New. Задачи читают через тот же кэш репозитория, что и
+// API, и сбрасывают его своими записями; кэш живет, пока не отменен ctx.
+func NewJobs(ctx context.Context, db *pgxpool.Pool, cfg Config, opts ...worker.Option) (*worker.Runner, error) {
+	repository, err := newRepository(ctx, db, cfg)
+	if err != nil {
+		return nil, err
+	}
+	r, err := withCache(ctx, withRetry(repository, cfg.Retry), db, cfg.Cache)
+	if err != nil {
+		return nil, err
+	}
+	svcOpts, err := serviceOptions(repository, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newJobs(service.New(r, svcOpts...), cfg, opts...), nil
+}
+
+// newJobs регистрирует фоновые задачи сервиса svc.
+func newJobs(svc *service.Service, cfg Config, opts ...worker.Option) *worker.Runner {
+	runner := worker.New(opts...)
+
+	runner.Add("vacations", vacationSweepInterval, func(ctx context.Context) error {
+		users, err := svc.ExpireVacations(ctx)
+		if err != nil {
+			return err
+		}
+		if len(users) > 0 {
+			logging.FromContext(ctx).Info("vacations ended, users eligible for review again", "users", users)
+		}
+		return nil
+	})
+
+	runner.Add("stale_reminders", cfg.Jobs.StaleReminders, func(ctx context.Context) error {
+		n, err := svc.RemindStalePRs(ctx)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			logging.FromContext(ctx).Info("stale PR reminders sent", "count", n)
+		}
+		return nil
+	})
+
+	runner.Add("outbox", cfg.Jobs.Outbox, func(ctx context.Context) error {
+		n, err := svc.RelayOutbox(ctx)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			logging.FromContext(ctx).Debug("outbox events published", "count", n)
+		}
+		return nil
+	})
+
+	runner.Add("pr_archive", cfg.Jobs.PRArchive, func(ctx context.Context) error {
+		n, err := svc.ArchiveMergedPRs(ctx)
+		if n > 0 {
+			logging.FromContext(ctx).Info("merged PRs archived", "count", n)
+		}
+		return err
+	})
+
+	runner.Add("auto_reassign", cfg.Jobs.AutoReassign, func(ctx context.Context) error {
+		n, err := svc.ReassignOverdueReviews(ctx)
+		if n > 0 {
+			logging.FromContext(ctx).Info("overdue reviewers reassigned", "count", n)
+		}
+		return err
+	})
+
+	runner.Add("digest", cfg.Jobs.Digest, func(ctx context.Context) error {
+		n, err := svc.SendDigests(ctx)
+		if n > 0 {
+			logging.FromContext(ctx).Info("review digests sent", "count", n)
+		}
+		return err
+	})
+
+	if cfg.LDAP != nil {
+		runner.Add("ldap_sync", cfg.Jobs.LDAPSync, func(ctx context.Context) error {
+			report, err := svc.SyncDirectory(ctx, false, false)
+			if err != nil {
+				return err
+			}
+			if len(report.Changes) > 0 {
+				logging.FromContext(ctx).Info("teams synced with directory",
+					"changes", len(report.Changes), "reassigned_reviews", report.ReassignedReviews)
+			}
+			return nil
+		})
+	}
+	return runner
+}
@@ -0,0 +1,160 @@
+package app
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"prreviewer/internal/auth"
+	"prreviewer/internal/handlers"
+	"prreviewer/internal/health"
+	"prreviewer/internal/mw"
+	"prreviewer/internal/openapi"
+	"prreviewer/internal/ui"
+)
+
+// routerDeps — зависимости роутера API, собранные New.
+type routerDeps struct {
+	handler        *handlers.Handler
+	ready          *health.Checker
+	spec           *openapi.Document
+	verifier       *auth.Verifier
+	readOnly       *mw.ReadOnlySwitch
+	slow           *mw.SlowLog
+	slowThresholds mw.SlowThresholds
+	rateLimiter    *mw.RateLimiter
+	compressor     *mw.Compressor
+	backpressure   mw.Backpressure
+	bodyLimits     mw.BodyLimits
+	deprecations   mw.Deprecations
+	legacy         mw.DeprecationPolicy
+	assignmentSeed bool
+	requestTimeout time.Duration
+}
+
+// newRouter собирает роутер бизнес-API с цепочкой middleware.
+func newRouter(d routerDeps) http.Handler {
+	h, verifier := d.handler, d.verifier
+
+	router := chi.NewRouter()
+	router.Use(middleware.RequestID)
+	router.Use(mw.RequestLogger(slog.Default()))
+	router.Use(mw.SlowRequests(d.slow, d.slowThresholds))
+	router.Use(mw.ProblemJSON)
+	router.Use(middleware.Recoverer)
+	router.Use(mw.Compress(d.compressor))
+	router.Use(mw.RateLimit(d.rateLimiter, verifier))
+	router.Use(mw.ShedLoad(d.backpressure))
+	router.Use(mw.ReadOnly(d.readOnly))
+	router.Use(mw.LimitBody(d.bodyLimits))
+	router.Use(mw.Timeout(d.requestTimeout, "GET /export/assignments"))
+	router.Use(mw.Deprecation(d.deprecations))
+	router.Use(mw.AssignmentSeed(d.assignmentSeed))
+	router.Use(mw.ValidateRequests(d.spec))
+
+	routeHealth(router, d.ready)
+	router.Get("/openapi.json", d.spec.ServeHTTP)
+	router.Get("/docs", openapi.DocsHandler("/openapi.json"))
+	router.Handle("/swagger", http.RedirectHandler("/docs", http.StatusMovedPermanently))
+	router.Handle("/ui", http.RedirectHandler("/ui/", http.StatusMovedPermanently))
+	router.Handle("/ui/*", ui.Handler("/ui/"))
+
+	for _, v := range handlers.APIVersions {
+		router.Route(v.Prefix(), func(r chi.Router) {
+			r.Use(handlers.WithVersion(v))
+			routeAPI(r, h, verifier)
+		})
+	}
+	router.HandleFunc("/api/*", handlers.UnsupportedVersion)
+
+	// Маршруты без префикса версии и /v2/pullRequest/... остались от клиентов,
+	// появившихся до /api/vN; они отвечают как v1 и v2 соответственно, но с
+	// заголовками Deprecation и ссылкой на версионированный путь.
+	router.Group(func(r chi.Router) {
+		r.Use(mw.DeprecatedAlias(d.legacy, handlers.APIv1.Prefix()))
+		routeAPI(r, h, verifier)
+	})
+	router.Route("/v2", func(r chi.Router) {
+		r.Use(handlers.WithVersion(handlers.APIv2))
+		r.Use(mw.DeprecatedAlias(d.legacy, "/api"))
+		r.Post("/pullRequest/create", h.PRCreate)
+		r.Get("/pullRequest/get", h.PRGet)
+		r.Get("/pullRequest/archived", h.PRArchived)
+		r.With(mw.AuthenticateOptional(verifier)).Post("/pullRequest/merge", h.PRMerge)
+		r.Post("/pullRequest/close", h.PRClose)
+		r.Post("/pullRequest/setStatus", h.PRSetStatus)
+		r.Post("/pullRequest/reassign", h.PRReassign)
+		r.Post("/pullRequest/addReviewer", h.PRAddReviewer)
+		r.Post("/pullRequest/decline", h.PRDecline)
+		r.Group(func(r chi.Router) {
+			r.Use(mw.Authenticate(verifier))
+			r.Post("/pullRequest/approve", h.PRApprove)
+			r.Post("/pullRequest/assign", h.PRAssign)
+			r.Post("/pullRequest/unassign", h.PRUnassign)
+		})
+	})
+
+	return router
+}
+
+// routeAPI регистрирует маршруты бизнес-API; newRouter вызывает ее для
+// каждой версии и для устаревших путей без префикса.
+func routeAPI(r chi.Router, h *handlers.Handler, verifier *auth.Verifier) {
+	r.Get("/team/get", h.TeamGet)
+	r.Get("/team/list", h.TeamList)
+	r.Group(func(r chi.Router) {
+		r.Use(mw.Authenticate(verifier))
+		r.Post("/team/add", h.TeamAdd)
+		r.Post("/team/import", h.TeamImport)
+		r.Post("/team/deactivate", h.TeamDeactivate)
+		r.Post("/team/rename", h.TeamRename)
+		r.Post("/team/autoMerge", h.TeamAutoMerge)
+		r.Post("/team/policy", h.TeamPolicy)
+		r.Get("/audit", h.Audit)
+		r.Get("/export/assignments", h.ExportAssignments)
+		r.Post("/import/github", h.ImportGitHub)
+		r.Post("/ownership/rules", h.OwnershipSetRules)
+		r.Post("/pullRequest/assign", h.PRAssign)
+		r.Post("/pullRequest/unassign", h.PRUnassign)
+		r.Post("/pullRequest/approve", h.PRApprove)
+		r.Post("/directory/sync", h.DirectorySync)
+		r.Get("/scim/v2/Users", h.SCIMListUsers)
+		r.Post("/scim/v2/Users", h.SCIMCreateUser)
+		r.Get("/scim/v2/Users/{id}", h.SCIMGetUser)
+		r.Put("/scim/v2/Users/{id}", h.SCIMReplaceUser)
+		r.Patch("/scim/v2/Users/{id}", h.SCIMPatchUser)
+		r.Delete("/scim/v2/Users/{id}", h.SCIMDeleteUser)
+		r.Post("/users/setIsActive", h.UsersSetIsActive)
+		r.Post("/users/delete", h.UsersDelete)
+		r.Post("/users/setVacation", h.UsersSetVacation)
+		r.Post("/users/snooze", h.UsersSnooze)
+		r.Post("/users/setTags", h.UsersSetTags)
+		r.Post("/users/setNotifications", h.UsersSetNotifications)
+		r.Post("/users/setIdentity", h.UsersSetIdentity)
+	})
+	r.Get("/users/getReview", h.UsersGetReview)
+	r.Get("/users/get", h.UsersGet)
+	r.Get("/users/list", h.UsersList)
+	r.Get("/users/digestPreview", h.UsersDigestPreview)
+	r.Get("/users/identities", h.UsersIdentities)
+	r.Get("/users/byIdentity", h.UsersByIdentity)
+	r.Post("/pullRequest/create", h.PRCreate)
+	r.Get("/pullRequest/get", h.PRGet)
+	r.Get("/pullRequest/archived", h.PRArchived)
+	r.Get("/pullRequest/{id}/timeline", h.PRTimeline)
+	r.With(mw.AuthenticateOptional(verifier)).Post("/pullRequest/merge", h.PRMerge)
+	r.Post("/pullRequest/close", h.PRClose)
+	r.Post("/pullRequest/setStatus", h.PRSetStatus)
+	r.Post("/pullRequest/reassign", h.PRReassign)
+	r.Post("/pullRequest/addReviewer", h.PRAddReviewer)
+	r.Post("/pullRequest/decline", h.PRDecline)
+	r.Get("/pullRequest/stale", h.PRStale)
+	r.Get("/pullRequest/blocked", h.PRBlocked)
+	r.Get("/ownership/rules", h.OwnershipGetRules)
+	r.Get("/stats", h.Stats)
+	r.Get("/stats/team", h.TeamStats)
+	r.Get("/stats/sla", h.SLAStats)
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/handlers"
+)
+
+func TestRouterAPIVersions(t *testing.T) {
+	spec, err := handlers.NewOpenAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := contractRouter(spec)
+
+	tests := []struct {
+		path       string
+		version    string
+		deprecated bool
+		successor  string
+	}{
+		{"/api/v1/team/get?team_name=backend", "v1", false, ""},
+		{"/api/v2/team/get?team_name=backend", "v2", false, ""},
+		{"/team/get?team_name=backend", "", true, `</api/v1/team/get>; rel="successor-version"`},
+		{"/v2/pullRequest/get?pull_request_id=pr-1", "v2", true, `</api/v2/pullRequest/get>; rel="successor-version"`},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s: ожидался 404 от обработчика, получили %d: %s", tt.path, rec.Code, rec.Body)
+		}
+		if got := rec.Header().Get(handlers.APIVersionHeader); got != tt.version {
+			t.Errorf("GET %s: ожидалась версия %q, получили %q", tt.path, tt.version, got)
+		}
+		if got := rec.Header().Get("Deprecation") != ""; got != tt.deprecated {
+			t.Errorf("GET %s: Deprecation выставлен: %v, ожидалось %v", tt.path, got, tt.deprecated)
+		}
+		if got := rec.Header().Get("Link"); got != tt.successor {
+			t.Errorf("GET %s: ожидалась ссылка %q, получили %q", tt.path, tt.successor, got)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v3/team/get?team_name=backend", nil))
+	var resp apierr.ErrResp
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusNotFound || resp.Error.Code != "UNSUPPORTED_API_VERSION" {
+		t.Errorf("неизвестная версия: ожидался 404 UNSUPPORTED_API_VERSION, получили %d %s", rec.Code, resp.Error.Code)
+	}
+	if supported, _ := resp.Error.Details["supported"].([]any); len(supported) != len(handlers.APIVersions) {
+		t.Errorf("в ответе должны быть перечислены поддерживаемые версии: %v", resp.Error.Details)
+	}
+}
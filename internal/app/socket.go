@@ -0,0 +1,26 @@
+package app
+
+import (
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+)
+
+// listenUnix открывает Unix-сокет (для sidecar-прокси) с правами mode.
+// Оставшийся от прошлого запуска файл сокета удаляется.
+func listenUnix(path string, mode fs.FileMode) (net.Listener, error) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		_ = ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
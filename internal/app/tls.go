@@ -0,0 +1,47 @@
+package app
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// baseTLSConfig — современные настройки: TLS 1.2+, только AEAD-шифры с ECDHE.
+func baseTLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:       tls.VersionTLS12,
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256},
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+	}
+}
+
+// serve обслуживает ln по HTTP или HTTPS в зависимости от настроек TLS.
+func serve(srv *http.Server, ln net.Listener, s TLSConfig) error {
+	if !s.enabled() {
+		return srv.Serve(ln)
+	}
+
+	cfg := baseTLSConfig()
+	if len(s.AutocertDomains) > 0 {
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(s.AutocertDomains...),
+			Cache:      autocert.DirCache(s.AutocertCache),
+			Email:      s.AutocertEmail,
+		}
+		cfg.GetCertificate = m.GetCertificate
+		cfg.NextProtos = append(cfg.NextProtos, "acme-tls/1")
+	}
+	srv.TLSConfig = cfg
+
+	return srv.ServeTLS(ln, s.CertFile, s.KeyFile)
+}
@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// Claims — полезная нагрузка токена. team_name может быть строкой или списком
+// команд, которыми владеет тимлид; admin разрешает управлять любой командой.
+type Claims struct {
+	Subject   string
+	Teams     []string
+	Admin     bool
+	ExpiresAt time.Time
+}
+
+type rawClaims struct {
+	Subject   string          `json:"sub"`
+	TeamName  json.RawMessage `json:"team_name"`
+	Admin     bool            `json:"admin"`
+	ExpiresAt *int64          `json:"exp"`
+	NotBefore *int64          `json:"nbf"`
+}
+
+// Verifier проверяет JWT, подписанные HS256 общим секретом.
+type Verifier struct {
+	secret []byte
+	now    func() time.Time
+}
+
+func NewVerifier(secret []byte) *Verifier {
+	return &Verifier{secret: secret, now: time.Now}
+}
+
+// Verify проверяет подпись, exp/nbf и возвращает claims токена.
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if header.Alg != "HS256" {
+		return nil, fmt.Errorf("%w: unsupported alg %q", ErrInvalidToken, header.Alg)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	mac := hmac.New(sha256.New, v.secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	var raw rawClaims
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return nil, err
+	}
+
+	now := v.now()
+	if raw.ExpiresAt == nil {
+		return nil, fmt.Errorf("%w: exp is required", ErrInvalidToken)
+	}
+	exp := time.Unix(*raw.ExpiresAt, 0)
+	if !now.Before(exp) {
+		return nil, ErrTokenExpired
+	}
+	if raw.NotBefore != nil && now.Before(time.Unix(*raw.NotBefore, 0)) {
+		return nil, fmt.Errorf("%w: token not valid yet", ErrInvalidToken)
+	}
+
+	teams, err := parseTeams(raw.TeamName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Claims{
+		Subject:   raw.Subject,
+		Teams:     teams,
+		Admin:     raw.Admin,
+		ExpiresAt: exp,
+	}, nil
+}
+
+// Sign выпускает HS256-токен; используется в тестах и утилитах.
+func (v *Verifier) Sign(c Claims) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+	payload := map[string]any{"exp": c.ExpiresAt.Unix()}
+	if c.Subject != "" {
+		payload["sub"] = c.Subject
+	}
+	if len(c.Teams) > 0 {
+		payload["team_name"] = c.Teams
+	}
+	if c.Admin {
+		payload["admin"] = true
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(body)
+	mac := hmac.New(sha256.New, v.secret)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// CanManageTeam сообщает, может ли владелец токена управлять командой.
+func (c *Claims) CanManageTeam(team string) bool {
+	return c.Admin || slices.Contains(c.Teams, team)
+}
+
+type ctxKey struct{}
+
+func NewContext(ctx context.Context, c *Claims) context.Context {
+	return context.WithValue(ctx, ctxKey{}, c)
+}
+
+// FromContext возвращает claims запроса; ok=false, если аутентификация отключена.
+func FromContext(ctx context.Context) (*Claims, bool) {
+	c, ok := ctx.Value(ctxKey{}).(*Claims)
+	return c, ok
+}
+
+// AllowsTeam проверяет доступ к команде. Без claims в контексте (JWT_SECRET не
+// задан) ограничений нет.
+func AllowsTeam(ctx context.Context, team string) bool {
+	c, ok := FromContext(ctx)
+	return !ok || c.CanManageTeam(team)
+}
+
+func decodeSegment(seg string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+func parseTeams(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var one string
+	if err := json.Unmarshal(raw, &one); err == nil {
+		return []string{one}, nil
+	}
+	var many []string
+	if err := json.Unmarshal(raw, &many); err != nil {
+		return nil, fmt.Errorf("%w: team_name must be a string or a list", ErrInvalidToken)
+	}
+	return many, nil
+}
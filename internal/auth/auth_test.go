@@ -0,0 +1,60 @@
+package auth_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"prreviewer/internal/auth"
+)
+
+func TestVerifyRoundTrip(t *testing.T) {
+	v := auth.NewVerifier([]byte("secret"))
+	token, err := v.Sign(auth.Claims{
+		Subject:   "lead",
+		Teams:     []string{"backend"},
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := v.Verify(token)
+	if err != nil {
+		t.Fatalf("ожидался валидный токен, получили %v", err)
+	}
+	if !c.CanManageTeam("backend") || c.CanManageTeam("frontend") {
+		t.Errorf("неверная область команд: %+v", c)
+	}
+}
+
+func TestVerifyRejectsForeignSignature(t *testing.T) {
+	token, _ := auth.NewVerifier([]byte("other")).Sign(auth.Claims{ExpiresAt: time.Now().Add(time.Hour)})
+
+	_, err := auth.NewVerifier([]byte("secret")).Verify(token)
+	if !errors.Is(err, auth.ErrInvalidToken) {
+		t.Fatalf("ожидалась ErrInvalidToken, получили %v", err)
+	}
+}
+
+func TestVerifyRejectsExpired(t *testing.T) {
+	v := auth.NewVerifier([]byte("secret"))
+	token, _ := v.Sign(auth.Claims{ExpiresAt: time.Now().Add(-time.Minute)})
+
+	if _, err := v.Verify(token); !errors.Is(err, auth.ErrTokenExpired) {
+		t.Fatalf("ожидалась ErrTokenExpired, получили %v", err)
+	}
+}
+
+func TestVerifyRejectsNoneAlg(t *testing.T) {
+	v := auth.NewVerifier([]byte("secret"))
+	token, _ := v.Sign(auth.Claims{Admin: true, ExpiresAt: time.Now().Add(time.Hour)})
+	parts := strings.Split(token, ".")
+	parts[0] = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+
+	if _, err := v.Verify(strings.Join(parts[:2], ".") + "."); !errors.Is(err, auth.ErrInvalidToken) {
+		t.Fatalf("ожидалась ErrInvalidToken, получили %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+// Package cache — кэш сериализованных ответов с TTL: в памяти процесса или в Redis
+// для нескольких экземпляров сервиса.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	KindMemory = "memory"
+	KindRedis  = "redis"
+)
+
+// Cache хранит значения по ключу до истечения TTL.
+type Cache interface {
+	// Get возвращает значение и false, если ключа нет или он истек.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// DeletePrefix удаляет все ключи, начинающиеся с prefix.
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+// Config — настройки бэкендов для New.
+type Config struct {
+	RedisURL string
+}
+
+// New создает кэш вида kind: memory или redis. Пустой kind — кэш выключен (nil).
+func New(kind string, cfg Config) (Cache, error) {
+	switch strings.TrimSpace(kind) {
+	case "":
+		return nil, nil
+	case KindMemory:
+		return NewMemory(), nil
+	case KindRedis:
+		if cfg.RedisURL == "" {
+			return nil, fmt.Errorf("cache %s requires url", KindRedis)
+		}
+		return NewRedis(cfg.RedisURL)
+	default:
+		return nil, fmt.Errorf("unknown cache %q", kind)
+	}
+}
+
+// maxMemoryEntries — при превышении Set вычищает истекшие записи.
+const maxMemoryEntries = 10000
+
+type entry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// Memory — кэш в памяти процесса.
+type Memory struct {
+	mu      sync.Mutex
+	entries map[string]entry
+	now     func() time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{entries: map[string]entry{}, now: time.Now}
+}
+
+func (m *Memory) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !m.now().Before(e.expiresAt) {
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	return e.value, true, nil
+}
+
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if len(m.entries) >= maxMemoryEntries {
+		for k, e := range m.entries {
+			if !now.Before(e.expiresAt) {
+				delete(m.entries, k)
+			}
+		}
+	}
+	m.entries[key] = entry{value: value, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (m *Memory) DeletePrefix(_ context.Context, prefix string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for k := range m.entries {
+		if strings.HasPrefix(k, prefix) {
+			delete(m.entries, k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryExpiresAndDeletesByPrefix(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+
+	_ = m.Set(ctx, "team:a", []byte("1"), time.Minute)
+	_ = m.Set(ctx, "team:b", []byte("2"), time.Hour)
+	_ = m.Set(ctx, "stats:100:0", []byte("3"), time.Hour)
+
+	now = now.Add(2 * time.Minute)
+	if _, ok, _ := m.Get(ctx, "team:a"); ok {
+		t.Errorf("запись с истекшим TTL не должна возвращаться")
+	}
+	if v, ok, _ := m.Get(ctx, "team:b"); !ok || string(v) != "2" {
+		t.Errorf("ожидалось значение 2, получили %q %v", v, ok)
+	}
+
+	_ = m.DeletePrefix(ctx, "team:")
+	if _, ok, _ := m.Get(ctx, "team:b"); ok {
+		t.Errorf("ключи с префиксом team: должны быть удалены")
+	}
+	if _, ok, _ := m.Get(ctx, "stats:100:0"); !ok {
+		t.Errorf("ключи с другим префиксом не должны удаляться")
+	}
+}
+
+func TestNew(t *testing.T) {
+	if c, err := New("", Config{}); c != nil || err != nil {
+		t.Errorf("пустой вид должен выключать кэш, получили %v, %v", c, err)
+	}
+	if _, err := New(KindRedis, Config{}); err == nil {
+		t.Errorf("redis без url должен быть ошибкой")
+	}
+	if _, err := New("memcached", Config{}); err == nil {
+		t.Errorf("неизвестный вид должен быть ошибкой")
+	}
+}
+
+// fakeRedis понимает GET, SET, SCAN (без курсора) и DEL.
+type fakeRedis struct {
+	mu   sync.Mutex
+	data map[string]string
+	ttl  map[string]string
+}
+
+func startFakeRedis(t *testing.T) (*fakeRedis, string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	f := &fakeRedis{data: map[string]string{}, ttl: map[string]string{}}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f, "redis://" + ln.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	r := bufio.NewReader(conn)
+	for {
+		reply, err := readReply(r)
+		if err != nil {
+			return
+		}
+		var args []string
+		for _, a := range reply.([]any) {
+			args = append(args, string(a.([]byte)))
+		}
+
+		f.mu.Lock()
+		var out string
+		switch args[0] {
+		case "GET":
+			if v, ok := f.data[args[1]]; ok {
+				out = bulk(v)
+			} else {
+				out = "$-1\r\n"
+			}
+		case "SET":
+			f.data[args[1]] = args[2]
+			f.ttl[args[1]] = args[4]
+			out = "+OK\r\n"
+		case "SCAN":
+			prefix := strings.TrimSuffix(strings.ReplaceAll(args[3], `\`, ""), "*")
+			var keys []string
+			for k := range f.data {
+				if strings.HasPrefix(k, prefix) {
+					keys = append(keys, bulk(k))
+				}
+			}
+			out = "*2\r\n" + bulk("0") + "*" + strconv.Itoa(len(keys)) + "\r\n" + strings.Join(keys, "")
+		case "DEL":
+			for _, k := range args[1:] {
+				delete(f.data, k)
+			}
+			out = ":" + strconv.Itoa(len(args)-1) + "\r\n"
+		default:
+			out = "-ERR unknown command\r\n"
+		}
+		f.mu.Unlock()
+
+		if _, err := conn.Write([]byte(out)); err != nil {
+			return
+		}
+	}
+}
+
+func bulk(s string) string { return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n" }
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+	f, url := startFakeRedis(t)
+	c, err := NewRedis(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok, err := c.Get(ctx, "team:a"); ok || err != nil {
+		t.Fatalf("отсутствующий ключ: ожидалось (false, nil), получили (%v, %v)", ok, err)
+	}
+	if err := c.Set(ctx, "team:a", []byte(`{"team_name":"a"}`), 30*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(ctx, "stats:1:0", []byte("{}"), time.Second); err != nil {
+		t.Fatal(err)
+	}
+	f.mu.Lock()
+	ttl := f.ttl["prreviewer:team:a"]
+	f.mu.Unlock()
+	if ttl != "30000" {
+		t.Errorf("TTL должен передаваться в миллисекундах, получили %q", ttl)
+	}
+
+	v, ok, err := c.Get(ctx, "team:a")
+	if err != nil || !ok || string(v) != `{"team_name":"a"}` {
+		t.Errorf("ожидалось сохраненное значение, получили %q %v %v", v, ok, err)
+	}
+
+	if err := c.DeletePrefix(ctx, "team:"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := c.Get(ctx, "team:a"); ok {
+		t.Errorf("ключ team:a должен быть удален")
+	}
+	if _, ok, _ := c.Get(ctx, "stats:1:0"); !ok {
+		t.Errorf("ключ stats:1:0 не должен удаляться")
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/service"
+)
+
+type approvePRRequest struct {
+	ID     string `json:"pull_request_id" validate:"required,max=255"`
+	UserID string `json:"user_id" validate:"required,max=255"`
+}
+
+func (h *Handler) PRApprove(w http.ResponseWriter, r *http.Request) {
+	var req approvePRRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "некорректный JSON")
+		return
+	}
+	if !validRequest(w, r, &req) {
+		return
+	}
+
+	if !requireSubject(w, r, req.UserID) {
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "pr_id", req.ID, "user_id", req.UserID)
+	pr, err := h.svc.ApprovePullRequest(ctx, req.ID, req.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPRNotFound):
+			logger.Warn("PR not found")
+			apierr.Write(w, apierr.ErrPRNotFound)
+		case errors.Is(err, service.ErrPRMerged):
+			logger.Warn("PR already merged")
+			apierr.Write(w, apierr.ErrPRMerged)
+		case errors.Is(err, service.ErrPRClosed):
+			logger.Warn("PR closed")
+			apierr.Write(w, apierr.ErrPRClosed)
+		case errors.Is(err, service.ErrNotAssigned):
+			logger.Warn("user not assigned to PR")
+			apierr.Write(w, apierr.ErrNotAssigned)
+		case errors.Is(err, service.ErrConflictRetry):
+			logger.Warn("concurrent modification, retry")
+			apierr.Write(w, apierr.ErrConflictRetry)
+		default:
+			internalError(w, logger, "failed to approve PR", err, err.Error())
+		}
+		return
+	}
+
+	logger.Info("PR approved", "status", pr.Status)
+	respond(w, http.StatusOK, map[string]any{"pr": prView(r, pr)})
+}
+
+func (h *Handler) TeamPolicy(w http.ResponseWriter, r *http.Request) {
+	var req models.TeamPolicy
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "некорректный JSON")
+		return
+	}
+	if !validRequest(w, r, &req) {
+		return
+	}
+
+	if !authorizeTeam(w, r, req.TeamName) {
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "team_name", req.TeamName)
+	policy, err := h.svc.SetTeamPolicy(ctx, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrTeamNotFound):
+			logger.Warn("team not found")
+			apierr.Write(w, apierr.ErrTeamNotFound)
+		case errors.Is(err, service.ErrInvalidPolicy):
+			logger.Warn("invalid policy", "error", err)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		default:
+			internalError(w, logger, "failed to save policy", err, err.Error())
+		}
+		return
+	}
+
+	logger.Info("policy saved",
+		"require_approvals", policy.RequireApprovals,
+		"assignment_strategy", policy.AssignmentStrategy)
+	respond(w, http.StatusOK, map[string]*models.TeamPolicy{"policy": policy})
+}
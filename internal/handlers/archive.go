@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/service"
+)
+
+func (h *Handler) PRArchived(w http.ResponseWriter, r *http.Request) {
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		logging.FromContext(r.Context()).Warn("pull_request_id parameter missing")
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "параметр pull_request_id обязателен")
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "pr_id", prID)
+	pr, err := h.svc.GetArchivedPR(ctx, prID)
+	if err != nil {
+		if errors.Is(err, service.ErrPRNotFound) {
+			logger.Warn("archived PR not found")
+			apierr.Write(w, apierr.ErrPRNotFound)
+			return
+		}
+		internalError(w, logger, "failed to get archived PR", err, "не удалось получить PR из архива")
+		return
+	}
+
+	if versionOf(r) >= APIv2 {
+		respond(w, http.StatusOK, map[string]*models.ArchivedPRV2{"pr": pr.V2()})
+		return
+	}
+	respond(w, http.StatusOK, map[string]*models.ArchivedPR{"pr": pr})
+}
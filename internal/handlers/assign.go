@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/service"
+)
+
+type assignPRRequest struct {
+	ID              string `json:"pull_request_id" validate:"required,max=255"`
+	UserID          string `json:"user_id" validate:"required,max=255"`
+	Reason          string `json:"reason" validate:"max=1000"`
+	ExpectedVersion *int   `json:"expected_version"`
+}
+
+// PRAssign добавляет ревьювера, выбранного администратором.
+func (h *Handler) PRAssign(w http.ResponseWriter, r *http.Request) {
+	var req assignPRRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "некорректный JSON")
+		return
+	}
+	if !validRequest(w, r, &req) {
+		return
+	}
+
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "pr_id", req.ID, "user_id", req.UserID)
+	pr, err := h.svc.AssignReviewer(ctx, req.ID, req.UserID, req.Reason, req.ExpectedVersion)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPRNotFound):
+			logger.Warn("PR not found")
+			apierr.Write(w, apierr.ErrPRNotFound)
+		case errors.Is(err, service.ErrUserNotFound):
+			logger.Warn("user not found")
+			apierr.Write(w, apierr.ErrUserNotFound)
+		case errors.Is(err, service.ErrPRMerged):
+			logger.Warn("PR already merged")
+			apierr.Write(w, apierr.ErrPRMerged)
+		case errors.Is(err, service.ErrPRClosed):
+			logger.Warn("PR closed")
+			apierr.Write(w, apierr.ErrPRClosed)
+		case errors.Is(err, service.ErrAlreadyAssigned):
+			logger.Warn("user already assigned to PR")
+			apierr.Write(w, apierr.ErrAlreadyAssigned)
+		case errors.Is(err, service.ErrIneligibleReviewer):
+			logger.Warn("ineligible reviewer", "error", err)
+			apierr.JSON(w, apierr.ErrIneligibleReviewer.Status, apierr.ErrIneligibleReviewer.Code, err.Error())
+		case errors.Is(err, service.ErrVersionConflict):
+			logger.Warn("version conflict")
+			apierr.Write(w, apierr.ErrVersionConflict)
+		case errors.Is(err, service.ErrConflictRetry):
+			logger.Warn("concurrent modification, retry")
+			apierr.Write(w, apierr.ErrConflictRetry)
+		case errors.Is(err, service.ErrAuthorIsReviewer):
+			logger.Warn("author assigned as reviewer", "error", err)
+			apierr.Write(w, apierr.ErrAuthorIsReviewer)
+		case errors.Is(err, service.ErrReviewerConflict):
+			logger.Warn("reviewer conflict", "error", err)
+			apierr.Write(w, apierr.ErrReviewerConflict)
+		default:
+			internalError(w, logger, "failed to assign reviewer", err, err.Error())
+		}
+		return
+	}
+
+	logger.Info("reviewer assigned by admin")
+	respond(w, http.StatusOK, map[string]interface{}{"pr": prView(r, pr)})
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/service"
+)
+
+func (h *Handler) Audit(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	page, err := parsePage(r)
+	if err != nil {
+		logger.Warn("invalid pagination", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	from, to, err := parseTimeRange(r)
+	if err != nil {
+		logger.Warn("invalid time filter", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	q := r.URL.Query()
+	filter := models.AuditFilter{
+		UserID:   q.Get("user_id"),
+		TeamName: q.Get("team_name"),
+		PRID:     q.Get("pull_request_id"),
+		Action:   q.Get("event_type"),
+		From:     from,
+		To:       to,
+	}
+
+	entries, total, err := h.svc.ListAudit(r.Context(), filter, page)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAuditFilter) {
+			logger.Warn("invalid audit filter", "error", err)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+		internalError(w, logger, "failed to list audit entries", err, err.Error())
+		return
+	}
+
+	respond(w, http.StatusOK, map[string]interface{}{
+		"entries": entries,
+		"total":   total,
+		"limit":   page.Limit,
+		"offset":  page.Offset,
+	})
+}
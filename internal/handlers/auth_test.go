@@ -0,0 +1,108 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"prreviewer/internal/auth"
+	"prreviewer/internal/handlers"
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo/memory"
+	"prreviewer/internal/service"
+)
+
+// leadRequest — запрос с токеном тимлида команды backend.
+func leadRequest(path, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req.WithContext(auth.NewContext(req.Context(), &auth.Claims{Subject: "lead", Teams: []string{"backend"}}))
+}
+
+func TestLeadCannotManageOtherTeamsUsers(t *testing.T) {
+	svc := service.New(memory.New())
+	ctx := context.Background()
+	for _, team := range []models.Team{
+		{TeamName: "backend", Members: []models.TeamMember{{UserID: "b1", Username: "B1", IsActive: true}}},
+		{TeamName: "frontend", Members: []models.TeamMember{{UserID: "f1", Username: "F1", IsActive: true}}},
+	} {
+		if err := svc.CreateTeam(ctx, team); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := handlers.New(svc)
+
+	for _, tc := range []struct {
+		name    string
+		handler http.HandlerFunc
+		path    string
+		body    string
+		want    int
+	}{
+		{"своя команда", h.UsersSetIsActive, "/users/setIsActive", `{"user_id":"b1","is_active":false}`, http.StatusOK},
+		{"чужая команда", h.UsersSetIsActive, "/users/setIsActive", `{"user_id":"f1","is_active":false}`, http.StatusForbidden},
+		{"удаление из чужой команды", h.UsersDelete, "/users/delete", `{"user_id":"f1"}`, http.StatusForbidden},
+		{"импорт чужого участника", h.TeamImport, "/team/import",
+			`{"teams":[{"team_name":"backend","members":[{"user_id":"f1","username":"F1","is_active":true}]}]}`, http.StatusForbidden},
+		{"rebalance с чужим участником", h.TeamAdd, "/team/add",
+			`{"team_name":"backend","rebalance":true,"members":[{"user_id":"f1","username":"F1","is_active":true}]}`, http.StatusForbidden},
+	} {
+		rec := httptest.NewRecorder()
+		tc.handler(rec, leadRequest(tc.path, tc.body))
+		if rec.Code != tc.want {
+			t.Errorf("%s: ожидался %d, получили %d %s", tc.name, tc.want, rec.Code, rec.Body)
+		}
+	}
+
+	profile, err := svc.GetUserProfile(ctx, "f1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.User.TeamName != "frontend" || !profile.User.IsActive {
+		t.Errorf("пользователь чужой команды не должен меняться: %+v", profile.User)
+	}
+}
+
+func TestApproveRequiresTokenSubject(t *testing.T) {
+	svc := service.New(memory.New())
+	ctx := context.Background()
+	team := models.Team{TeamName: "backend", Members: []models.TeamMember{
+		{UserID: "b1", Username: "B1", IsActive: true},
+		{UserID: "b2", Username: "B2", IsActive: true},
+	}}
+	if err := svc.CreateTeam(ctx, team); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.CreatePullRequest(ctx, models.PR{ID: "pr1", Name: "PR", AuthorID: "b1"}); err != nil {
+		t.Fatal(err)
+	}
+	h := handlers.New(svc)
+
+	for _, tc := range []struct {
+		name   string
+		claims *auth.Claims
+		want   int
+	}{
+		{"чужой токен", &auth.Claims{Subject: "lead", Teams: []string{"backend"}}, http.StatusForbidden},
+		{"токен ревьювера", &auth.Claims{Subject: "b2"}, http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/pullRequest/approve", strings.NewReader(`{"pull_request_id":"pr1","user_id":"b2"}`))
+		req = req.WithContext(auth.NewContext(req.Context(), tc.claims))
+		rec := httptest.NewRecorder()
+		h.PRApprove(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s: ожидался %d, получили %d %s", tc.name, tc.want, rec.Code, rec.Body)
+		}
+		if tc.want == http.StatusForbidden {
+			pr, err := svc.GetPullRequest(ctx, "pr1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(pr.ApprovedBy) != 0 {
+				t.Errorf("%s: одобрение не должно сохраниться: %v", tc.name, pr.ApprovedBy)
+			}
+		}
+	}
+}
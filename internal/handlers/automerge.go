@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/service"
+)
+
+func (h *Handler) TeamAutoMerge(w http.ResponseWriter, r *http.Request) {
+	var req models.TeamAutoMerge
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "некорректный JSON")
+		return
+	}
+	if !validRequest(w, r, &req) {
+		return
+	}
+
+	if !authorizeTeam(w, r, req.TeamName) {
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "team_name", req.TeamName)
+	cfg, err := h.svc.SetTeamAutoMerge(ctx, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrTeamNotFound):
+			logger.Warn("team not found")
+			apierr.Write(w, apierr.ErrTeamNotFound)
+		case errors.Is(err, service.ErrInvalidAutoMerge):
+			logger.Warn("invalid auto-merge config", "error", err)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		default:
+			internalError(w, logger, "failed to save auto-merge config", err, err.Error())
+		}
+		return
+	}
+
+	logger.Info("auto-merge config saved", "enabled", cfg.Enabled)
+	respond(w, http.StatusOK, map[string]*models.TeamAutoMerge{"auto_merge": cfg})
+}
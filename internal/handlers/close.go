@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/service"
+)
+
+type closePRRequest struct {
+	ID              string `json:"pull_request_id" validate:"required,max=255"`
+	ExpectedVersion *int   `json:"expected_version"`
+}
+
+func (h *Handler) PRClose(w http.ResponseWriter, r *http.Request) {
+	var req closePRRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "некорректный JSON")
+		return
+	}
+	if !validRequest(w, r, &req) {
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "pr_id", req.ID)
+	pr, err := h.svc.ClosePullRequest(ctx, req.ID, req.ExpectedVersion)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPRNotFound):
+			logger.Warn("PR not found")
+			apierr.Write(w, apierr.ErrPRNotFound)
+		case errors.Is(err, service.ErrPRMerged):
+			logger.Warn("PR already merged")
+			apierr.Write(w, apierr.ErrPRMerged)
+		case errors.Is(err, service.ErrVersionConflict):
+			logger.Warn("version conflict")
+			apierr.Write(w, apierr.ErrVersionConflict)
+		case errors.Is(err, service.ErrConflictRetry):
+			logger.Warn("concurrent modification, retry")
+			apierr.Write(w, apierr.ErrConflictRetry)
+		default:
+			internalError(w, logger, "failed to close PR", err, err.Error())
+		}
+		return
+	}
+
+	logger.Info("PR closed")
+	respond(w, http.StatusOK, map[string]any{"pr": prView(r, pr)})
+}
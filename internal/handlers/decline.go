@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/service"
+)
+
+type declinePRRequest struct {
+	ID     string `json:"pull_request_id" validate:"required,max=255"`
+	UserID string `json:"user_id" validate:"required,max=255"`
+	Reason string `json:"reason" validate:"max=1000"`
+}
+
+func (h *Handler) PRDecline(w http.ResponseWriter, r *http.Request) {
+	var req declinePRRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "некорректный JSON")
+		return
+	}
+	if !validRequest(w, r, &req) {
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "pr_id", req.ID, "user_id", req.UserID)
+	pr, newReviewerID, err := h.svc.DeclineReview(ctx, req.ID, req.UserID, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPRNotFound):
+			logger.Warn("PR not found")
+			apierr.Write(w, apierr.ErrPRNotFound)
+		case errors.Is(err, service.ErrUserNotFound):
+			logger.Warn("user not found")
+			apierr.Write(w, apierr.ErrUserNotFound)
+		case errors.Is(err, service.ErrPRMerged):
+			logger.Warn("PR already merged")
+			apierr.Write(w, apierr.ErrPRMerged)
+		case errors.Is(err, service.ErrPRClosed):
+			logger.Warn("PR closed")
+			apierr.Write(w, apierr.ErrPRClosed)
+		case errors.Is(err, service.ErrNotAssigned):
+			logger.Warn("user not assigned to PR")
+			apierr.Write(w, apierr.ErrNotAssigned)
+		case errors.Is(err, service.ErrAuthorIsReviewer):
+			logger.Warn("author assigned as reviewer", "error", err)
+			apierr.Write(w, apierr.ErrAuthorIsReviewer)
+		case errors.Is(err, service.ErrReviewerConflict):
+			logger.Warn("reviewer conflict", "error", err)
+			apierr.Write(w, apierr.ErrReviewerConflict)
+		case errors.Is(err, service.ErrConflictRetry):
+			logger.Warn("concurrent modification, retry")
+			apierr.Write(w, apierr.ErrConflictRetry)
+		default:
+			internalError(w, logger, "failed to decline review", err, err.Error())
+		}
+		return
+	}
+
+	logger.Info("review declined", "new_user_id", newReviewerID)
+	respond(w, http.StatusOK, map[string]interface{}{
+		"pr":          prView(r, pr),
+		"replaced_by": newReviewerID,
+	})
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/service"
+)
+
+type directorySyncRequest struct {
+	DryRun bool `json:"dry_run"`
+	// Force отключает участников команд, группы которых пришли из каталога пустыми.
+	Force bool `json:"force"`
+}
+
+func (h *Handler) DirectorySync(w http.ResponseWriter, r *http.Request) {
+	var req directorySyncRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "некорректный JSON")
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "dry_run", req.DryRun, "force", req.Force)
+	report, err := h.svc.SyncDirectory(ctx, req.DryRun, req.Force)
+	if err != nil {
+		if errors.Is(err, service.ErrDirectoryNotConfigured) {
+			logger.Warn("directory sync is not configured")
+			apierr.JSON(w, http.StatusNotFound, "NOT_FOUND", "синхронизация с каталогом не настроена")
+			return
+		}
+		if errors.Is(err, service.ErrDirectoryGroupEmpty) {
+			logger.Warn("directory sync aborted", "error", err)
+			apierr.Write(w, apierr.ErrDirectoryGroupEmpty)
+			return
+		}
+		internalError(w, logger, "failed to sync directory", err, "не удалось синхронизировать команды с каталогом")
+		return
+	}
+
+	logger.Info("directory synced", "changes", len(report.Changes), "reassigned_reviews", report.ReassignedReviews)
+	respond(w, http.StatusOK, report)
+}
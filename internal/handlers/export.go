@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/service"
+)
+
+// exportChunkTimeout — сколько выгрузка ждет, пока клиент примет очередную
+// пачку событий; медленнее — соединение обрывается.
+const exportChunkTimeout = 30 * time.Second
+
+func (h *Handler) ExportAssignments(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	from, to, err := parseTimeRange(r)
+	if err != nil {
+		logger.Warn("invalid time filter", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+	started := false
+	start := func() {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		started = true
+	}
+
+	exported := 0
+	err = h.svc.ExportAssignmentEvents(r.Context(), from, to, func(events []models.AssignmentEvent) error {
+		if err := rc.SetWriteDeadline(time.Now().Add(exportChunkTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		if !started {
+			start()
+		}
+		for _, ev := range events {
+			if err := enc.Encode(ev); err != nil {
+				return err
+			}
+		}
+		exported += len(events)
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		if started {
+			// Заголовки уже отправлены: соединение рвется без завершающего
+			// chunk, чтобы клиент не принял неполную выгрузку за целую.
+			logger.Error("assignment export interrupted", "exported", exported, "error", err)
+			panic(http.ErrAbortHandler)
+		}
+		if errors.Is(err, service.ErrInvalidExportRange) {
+			logger.Warn("invalid export range", "error", err)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+		internalError(w, logger, "failed to export assignment events", err, "не удалось выгрузить события назначения")
+		return
+	}
+	if !started {
+		start()
+	}
+	logger.Info("assignment events exported", "exported", exported)
+}
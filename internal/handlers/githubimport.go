@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/service"
+	"prreviewer/internal/vcs"
+)
+
+type importGitHubRequest struct {
+	Repository    string `json:"repository" validate:"required,max=255"`
+	Token         string `json:"token" validate:"required,max=1024"`
+	IncludeDrafts bool   `json:"include_drafts"`
+}
+
+type importGitHubResponse struct {
+	Repository string                  `json:"repository"`
+	Created    int                     `json:"created"`
+	Results    []models.PRImportResult `json:"results"`
+}
+
+func (h *Handler) ImportGitHub(w http.ResponseWriter, r *http.Request) {
+	var req importGitHubRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "некорректный JSON")
+		return
+	}
+	if !validRequest(w, r, &req) {
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "repository", req.Repository)
+	results, err := h.svc.ImportGitHubPRs(ctx, req.Repository, req.Token, req.IncludeDrafts)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidGitHubImport):
+			logger.Warn("invalid github import", "error", err)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		case errors.Is(err, vcs.ErrRequestFailed):
+			logger.Warn("github request failed", "error", err)
+			apierr.JSON(w, http.StatusBadGateway, "UPSTREAM_ERROR", err.Error())
+		default:
+			internalError(w, logger, "failed to import github PRs", err, "не удалось импортировать PR из GitHub")
+		}
+		return
+	}
+
+	resp := importGitHubResponse{Repository: req.Repository, Results: results}
+	for _, res := range results {
+		if res.Status == models.ImportCreated {
+			resp.Created++
+		}
+	}
+	logger.Info("github PRs imported", "open_prs", len(results), "created", resp.Created)
+	respond(w, http.StatusOK, resp)
+}
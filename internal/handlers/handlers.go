@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"log/slog"
 	"net/http"
 
-	"log"
 	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
 	"prreviewer/internal/models"
 	"prreviewer/internal/service"
 )
@@ -24,182 +26,418 @@ func respond(w http.ResponseWriter, code int, data interface{}) {
 	w.WriteHeader(code)
 	if data != nil {
 		if err := json.NewEncoder(w).Encode(data); err != nil {
-			log.Printf("respond: failed to encode response: %v", err)
+			slog.Error("failed to encode response", "error", err)
 			http.Error(w, "internal json error", http.StatusInternalServerError)
 		}
 	}
 }
 
+// internalError отвечает на ошибку сервиса, которую ручка не разбирает сама:
+// нарушения ограничений БД — 409 DUPLICATE или UNKNOWN_REFERENCE, конфликт
+// транзакций — 409 CONFLICT_RETRY, истекший срок операции — 504 TIMEOUT,
+// остальное логируется как ошибка и получает 500 INTERNAL_ERROR с message.
+func internalError(w http.ResponseWriter, logger *slog.Logger, msg string, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrDuplicate):
+		logger.Warn(msg, "error", err)
+		apierr.Write(w, apierr.ErrDuplicate)
+	case errors.Is(err, service.ErrForeignKey):
+		logger.Warn(msg, "error", err)
+		apierr.Write(w, apierr.ErrUnknownReference)
+	case errors.Is(err, service.ErrConflict):
+		logger.Warn(msg, "error", err)
+		apierr.Write(w, apierr.ErrConflictRetry)
+	case errors.Is(err, context.DeadlineExceeded):
+		logger.Error(msg, "error", err)
+		apierr.Write(w, apierr.ErrTimeout)
+	default:
+		logger.Error(msg, "error", err)
+		apierr.JSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", message)
+	}
+}
+
+// teamAddRequest — команда для /team/add. Rebalance разрешает добавлять
+// участников в существующую команду и назначает новичков на ее открытые PR.
+type teamAddRequest struct {
+	models.Team
+	Rebalance bool `json:"rebalance,omitempty"`
+}
+
 func (h *Handler) TeamAdd(w http.ResponseWriter, r *http.Request) {
-	var team models.Team
-	if err := json.NewDecoder(r.Body).Decode(&team); err != nil {
-		log.Printf("TeamAdd: failed to decode request body: %v", err)
+	var req teamAddRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
 		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "некорректный JSON")
 		return
 	}
+	if !validRequest(w, r, &req) {
+		return
+	}
+	team := req.Team
 
-	if err := h.svc.CreateTeam(r.Context(), team); err != nil {
+	if !authorizeTeam(w, r, team.TeamName) || !h.authorizeMembers(w, r, []models.Team{team}) {
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "team_name", team.TeamName)
+	if req.Rebalance {
+		h.teamAddRebalance(ctx, w, logger, team)
+		return
+	}
+	if err := h.svc.CreateTeam(ctx, team); err != nil {
 		if errors.Is(err, service.ErrTeamExists) {
-			log.Printf("TeamAdd: team already exists: %s", team.TeamName)
+			logger.Warn("team already exists")
 			apierr.Write(w, apierr.ErrTeamExists)
 			return
 		}
-		log.Printf("TeamAdd: failed to create team %s: %v", team.TeamName, err)
-		apierr.JSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", "ошибка при создании команды")
+		if errors.Is(err, service.ErrInvalidWeight) ||
+			errors.Is(err, service.ErrInvalidTag) ||
+			errors.Is(err, service.ErrInvalidEmail) {
+			logger.Warn("invalid team member", "error", err)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+		internalError(w, logger, "failed to create team", err, "ошибка при создании команды")
 		return
 	}
 
-	log.Printf("TeamAdd: team created successfully: %s", team.TeamName)
+	logger.Info("team created", "members", len(team.Members))
 	respond(w, http.StatusCreated, map[string]models.Team{"team": team})
 }
 
+func (h *Handler) teamAddRebalance(ctx context.Context, w http.ResponseWriter, logger *slog.Logger, team models.Team) {
+	created, assignments, err := h.svc.AddTeamMembers(ctx, team)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidWeight) ||
+			errors.Is(err, service.ErrInvalidTag) ||
+			errors.Is(err, service.ErrInvalidEmail) {
+			logger.Warn("invalid team member", "error", err)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+		internalError(w, logger, "failed to add team members", err, "ошибка при добавлении участников команды")
+		return
+	}
+
+	logger.Info("team members added", "members", len(team.Members), "created", created,
+		"rebalanced", len(assignments))
+	code := http.StatusOK
+	if created {
+		code = http.StatusCreated
+	}
+	respond(w, code, map[string]interface{}{
+		"team":       team,
+		"rebalanced": assignments,
+	})
+}
+
 func (h *Handler) TeamGet(w http.ResponseWriter, r *http.Request) {
 	teamName := r.URL.Query().Get("team_name")
 	if teamName == "" {
-		log.Println("TeamGet: team_name parameter missing")
+		logging.FromContext(r.Context()).Warn("team_name parameter missing")
 		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "параметр team_name обязателен")
 		return
 	}
 
-	team, err := h.svc.GetTeam(r.Context(), teamName)
+	ctx, logger := logging.With(r.Context(), "team_name", teamName)
+	team, err := h.svc.GetTeam(ctx, teamName)
 	if err != nil {
 		if errors.Is(err, service.ErrTeamNotFound) {
-			log.Printf("TeamGet: team not found: %s", teamName)
+			logger.Warn("team not found")
 			apierr.Write(w, apierr.ErrTeamNotFound)
 			return
 		}
-		log.Printf("TeamGet: failed to get team %s: %v", teamName, err)
-		apierr.JSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", "не удалось получить команду")
+		internalError(w, logger, "failed to get team", err, "не удалось получить команду")
 		return
 	}
 
 	respond(w, http.StatusOK, team)
 }
 
-func (h *Handler) UsersSetIsActive(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		UserID   string `json:"user_id"`
-		IsActive bool   `json:"is_active"`
+func (h *Handler) TeamList(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePage(r)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("invalid pagination", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	prefix := r.URL.Query().Get("name_prefix")
+	teams, total, err := h.svc.ListTeams(r.Context(), prefix, page)
+	if err != nil {
+		internalError(w, logging.FromContext(r.Context()), "failed to list teams", err, err.Error())
+		return
 	}
+
+	respond(w, http.StatusOK, map[string]interface{}{
+		"teams":  teams,
+		"total":  total,
+		"limit":  page.Limit,
+		"offset": page.Offset,
+	})
+}
+
+type setUserActiveRequest struct {
+	UserID   string `json:"user_id" validate:"required,max=255"`
+	IsActive bool   `json:"is_active" validate:"required"`
+}
+
+func (h *Handler) UsersSetIsActive(w http.ResponseWriter, r *http.Request) {
+	var req setUserActiveRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("UsersSetIsActive: failed to decode request body: %v", err)
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
 		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "некорректный JSON")
 		return
 	}
+	if !validRequest(w, r, &req) {
+		return
+	}
+	if !h.authorizeUser(w, r, req.UserID) {
+		return
+	}
 
-	user, err := h.svc.SetUserActive(r.Context(), req.UserID, req.IsActive)
+	ctx, logger := logging.With(r.Context(), "user_id", req.UserID)
+	user, err := h.svc.SetUserActive(ctx, req.UserID, req.IsActive)
 	if err != nil {
 		if errors.Is(err, service.ErrUserNotFound) {
-			log.Printf("UsersSetIsActive: user not found: %s", req.UserID)
+			logger.Warn("user not found")
 			apierr.Write(w, apierr.ErrUserNotFound)
 			return
 		}
-		log.Printf("UsersSetIsActive: failed to update user %s: %v", req.UserID, err)
-		apierr.JSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", "ошибка обновления статуса")
+		internalError(w, logger, "failed to update user", err, "ошибка обновления статуса")
 		return
 	}
 
-	log.Printf("UsersSetIsActive: user %s status updated to active=%v", req.UserID, req.IsActive)
+	logger.Info("user status updated", "is_active", req.IsActive)
 	respond(w, http.StatusOK, map[string]*models.User{"user": user})
 }
 
+type createPRRequest struct {
+	ID           string   `json:"pull_request_id" validate:"required,max=255"`
+	Name         string   `json:"pull_request_name" validate:"required,max=255"`
+	AuthorID     string   `json:"author_id" validate:"required,max=255"`
+	Repository   string   `json:"repository" validate:"max=255"`
+	ChangedPaths []string `json:"changed_paths"`
+	RequiredTags []string `json:"required_tags"`
+	Labels       []string `json:"labels"`
+	Priority     string   `json:"priority" validate:"oneof=low normal high"`
+	URL          string   `json:"url" validate:"max=2048"`
+	Branch       string   `json:"branch" validate:"max=255"`
+	Description  string   `json:"description" validate:"max=10000"`
+	// RequiredApprovals — порог одобрений для merge; только повышает порог политики команды.
+	RequiredApprovals *int `json:"required_approvals" validate:"min=0"`
+	// DependsOn — PR, которые нужно смержить или закрыть раньше этого.
+	DependsOn []string `json:"depends_on"`
+}
+
 func (h *Handler) PRCreate(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		ID       string `json:"pull_request_id"`
-		Name     string `json:"pull_request_name"`
-		AuthorID string `json:"author_id"`
-	}
+	var req createPRRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("PRCreate: failed to decode request body: %v", err)
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
 		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "некорректный JSON")
 		return
 	}
+	if !validRequest(w, r, &req) {
+		return
+	}
 
-	pr, err := h.svc.CreatePullRequest(r.Context(), req.ID, req.Name, req.AuthorID)
+	ctx, logger := logging.With(r.Context(), "pr_id", req.ID, "user_id", req.AuthorID)
+	pr, err := h.svc.CreatePullRequest(ctx, models.PR{
+		ID:                req.ID,
+		Name:              req.Name,
+		AuthorID:          req.AuthorID,
+		Repository:        req.Repository,
+		ChangedPaths:      req.ChangedPaths,
+		RequiredTags:      req.RequiredTags,
+		Labels:            req.Labels,
+		Priority:          req.Priority,
+		URL:               req.URL,
+		Branch:            req.Branch,
+		Description:       req.Description,
+		RequiredApprovals: req.RequiredApprovals,
+		DependsOn:         req.DependsOn,
+	})
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrAuthorNotFound):
-			log.Printf("PRCreate: author not found: %s", req.AuthorID)
+			logger.Warn("author not found")
 			apierr.Write(w, apierr.ErrAuthorNotFound)
 		case errors.Is(err, service.ErrPRExists):
-			log.Printf("PRCreate: PR already exists: %s", req.ID)
+			logger.Warn("PR already exists")
 			apierr.Write(w, apierr.ErrPRExists)
+		case errors.Is(err, service.ErrAuthorIsReviewer):
+			logger.Warn("author assigned as reviewer", "error", err)
+			apierr.Write(w, apierr.ErrAuthorIsReviewer)
+		case errors.Is(err, service.ErrReviewerConflict):
+			logger.Warn("reviewer conflict", "error", err)
+			apierr.Write(w, apierr.ErrReviewerConflict)
+		case errors.Is(err, service.ErrInvalidTag):
+			logger.Warn("invalid required tag", "error", err)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		case errors.Is(err, service.ErrInvalidLabel), errors.Is(err, service.ErrInvalidPriority):
+			logger.Warn("invalid label or priority", "error", err)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		case errors.Is(err, service.ErrInvalidURL):
+			logger.Warn("invalid PR url", "error", err)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		case errors.Is(err, service.ErrInvalidDependency):
+			logger.Warn("invalid PR dependency", "error", err)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
 		default:
-			log.Printf("PRCreate: failed to create PR %s: %v", req.ID, err)
-			apierr.JSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			internalError(w, logger, "failed to create PR", err, err.Error())
 		}
 		return
 	}
 
-	log.Printf("PRCreate: PR created successfully: %s", req.ID)
-	respond(w, http.StatusCreated, map[string]*models.PR{"pr": pr})
+	logger.Info("PR created", "reviewers", pr.AssignedReviewers)
+	respond(w, http.StatusCreated, map[string]any{"pr": prView(r, pr)})
 }
 
-func (h *Handler) PRMerge(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		ID string `json:"pull_request_id"`
+func (h *Handler) PRGet(w http.ResponseWriter, r *http.Request) {
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		logging.FromContext(r.Context()).Warn("pull_request_id parameter missing")
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "параметр pull_request_id обязателен")
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "pr_id", prID)
+	pr, err := h.svc.GetPullRequest(ctx, prID)
+	if err != nil {
+		if errors.Is(err, service.ErrPRNotFound) {
+			logger.Warn("PR not found")
+			apierr.Write(w, apierr.ErrPRNotFound)
+			return
+		}
+		internalError(w, logger, "failed to get PR", err, "не удалось получить PR")
+		return
+	}
+
+	if versionOf(r) >= APIv2 {
+		respond(w, http.StatusOK, map[string]*models.PRDetailsV2{"pr": pr.V2()})
+		return
 	}
+	respond(w, http.StatusOK, map[string]*models.PRDetails{"pr": pr})
+}
+
+type mergePRRequest struct {
+	ID              string `json:"pull_request_id" validate:"required,max=255"`
+	ExpectedVersion *int   `json:"expected_version"`
+	// Force сливает PR без нужного числа одобрений; только для администратора.
+	Force bool `json:"force"`
+}
+
+func (h *Handler) PRMerge(w http.ResponseWriter, r *http.Request) {
+	var req mergePRRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("PRMerge: failed to decode request body: %v", err)
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
 		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "некорректный JSON")
 		return
 	}
+	if !validRequest(w, r, &req) {
+		return
+	}
+	if req.Force && !requireAdmin(w, r) {
+		return
+	}
 
-	pr, err := h.svc.MergePullRequest(r.Context(), req.ID)
+	ctx, logger := logging.With(r.Context(), "pr_id", req.ID)
+	pr, err := h.svc.MergePullRequest(ctx, req.ID, req.ExpectedVersion, req.Force)
 	if err != nil {
-		if errors.Is(err, service.ErrPRNotFound) {
-			log.Printf("PRMerge: PR not found: %s", req.ID)
+		var (
+			notApproved *service.NotApprovedError
+			blocked     *service.BlockedError
+		)
+		switch {
+		case errors.Is(err, service.ErrPRNotFound):
+			logger.Warn("PR not found")
 			apierr.Write(w, apierr.ErrPRNotFound)
-			return
+		case errors.Is(err, service.ErrPRClosed):
+			logger.Warn("PR closed")
+			apierr.Write(w, apierr.ErrPRClosed)
+		case errors.Is(err, service.ErrVersionConflict):
+			logger.Warn("version conflict")
+			apierr.Write(w, apierr.ErrVersionConflict)
+		case errors.Is(err, service.ErrConflictRetry):
+			logger.Warn("concurrent modification, retry")
+			apierr.Write(w, apierr.ErrConflictRetry)
+		case errors.As(err, &notApproved):
+			logger.Warn("PR not approved", "error", err, "missing_approvers", notApproved.Missing)
+			apierr.NotApproved(w, err.Error(), notApproved.Missing)
+		case errors.As(err, &blocked):
+			logger.Warn("PR blocked by dependencies", "blocked_by", blocked.BlockedBy)
+			apierr.Blocked(w, err.Error(), blocked.BlockedBy)
+		default:
+			internalError(w, logger, "failed to merge PR", err, err.Error())
 		}
-		log.Printf("PRMerge: failed to merge PR %s: %v", req.ID, err)
-		apierr.JSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 		return
 	}
 
-	log.Printf("PRMerge: PR merged successfully: %s", req.ID)
-	respond(w, http.StatusOK, map[string]*models.PR{"pr": pr})
+	logger.Info("PR merged", "force", req.Force)
+	respond(w, http.StatusOK, map[string]any{"pr": prView(r, pr)})
+}
+
+type reassignPRRequest struct {
+	ID              string `json:"pull_request_id" validate:"required,max=255"`
+	OldUserID       string `json:"old_user_id" validate:"required,max=255"`
+	Reason          string `json:"reason" validate:"max=1000"`
+	ExpectedVersion *int   `json:"expected_version"`
 }
 
 func (h *Handler) PRReassign(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		ID        string `json:"pull_request_id"`
-		OldUserID string `json:"old_user_id"`
-	}
+	var req reassignPRRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("PRReassign: failed to decode request body: %v", err)
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
 		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "некорректный JSON")
 		return
 	}
+	if !validRequest(w, r, &req) {
+		return
+	}
 
-	pr, newReviewerID, err := h.svc.ReassignReviewer(r.Context(), req.ID, req.OldUserID)
+	ctx, logger := logging.With(r.Context(), "pr_id", req.ID, "user_id", req.OldUserID)
+	pr, newReviewerID, err := h.svc.ReassignReviewer(ctx, req.ID, req.OldUserID, req.Reason, req.ExpectedVersion)
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrPRNotFound):
-			log.Printf("PRReassign: PR not found: %s", req.ID)
+			logger.Warn("PR not found")
 			apierr.Write(w, apierr.ErrPRNotFound)
 		case errors.Is(err, service.ErrUserNotFound):
-			log.Printf("PRReassign: user not found: %s", req.OldUserID)
+			logger.Warn("user not found")
 			apierr.Write(w, apierr.ErrUserNotFound)
 		case errors.Is(err, service.ErrPRMerged):
-			log.Printf("PRReassign: PR already merged: %s", req.ID)
+			logger.Warn("PR already merged")
 			apierr.Write(w, apierr.ErrPRMerged)
+		case errors.Is(err, service.ErrPRClosed):
+			logger.Warn("PR closed")
+			apierr.Write(w, apierr.ErrPRClosed)
 		case errors.Is(err, service.ErrNotAssigned):
-			log.Printf("PRReassign: user %s not assigned to PR %s", req.OldUserID, req.ID)
+			logger.Warn("user not assigned to PR")
 			apierr.Write(w, apierr.ErrNotAssigned)
 		case errors.Is(err, service.ErrNoCandidate):
-			log.Printf("PRReassign: no replacement candidate for PR %s", req.ID)
+			logger.Warn("no replacement candidate")
 			apierr.Write(w, apierr.ErrNoCandidate)
+		case errors.Is(err, service.ErrVersionConflict):
+			logger.Warn("version conflict")
+			apierr.Write(w, apierr.ErrVersionConflict)
+		case errors.Is(err, service.ErrConflictRetry):
+			logger.Warn("concurrent modification, retry")
+			apierr.Write(w, apierr.ErrConflictRetry)
+		case errors.Is(err, service.ErrAuthorIsReviewer):
+			logger.Warn("author assigned as reviewer", "error", err)
+			apierr.Write(w, apierr.ErrAuthorIsReviewer)
+		case errors.Is(err, service.ErrReviewerConflict):
+			logger.Warn("reviewer conflict", "error", err)
+			apierr.Write(w, apierr.ErrReviewerConflict)
 		default:
-			log.Printf("PRReassign: failed to reassign PR %s: %v", req.ID, err)
-			apierr.JSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+			internalError(w, logger, "failed to reassign reviewer", err, err.Error())
 		}
 		return
 	}
 
-	log.Printf("PRReassign: reviewer reassigned for PR %s: %s -> %s", req.ID, req.OldUserID, newReviewerID)
+	logger.Info("reviewer reassigned", "new_user_id", newReviewerID)
 	respond(w, http.StatusOK, map[string]interface{}{
-		"pr":          pr,
+		"pr":          prView(r, pr),
 		"replaced_by": newReviewerID,
 	})
 }
@@ -207,65 +445,162 @@ func (h *Handler) PRReassign(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) UsersGetReview(w http.ResponseWriter, r *http.Request) {
 	uid := r.URL.Query().Get("user_id")
 	if uid == "" {
-		log.Println("UsersGetReview: user_id parameter missing")
+		logging.FromContext(r.Context()).Warn("user_id parameter missing")
 		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "user_id обязателен")
 		return
 	}
 
-	_, prs, err := h.svc.GetUserReviews(r.Context(), uid)
+	ctx, logger := logging.With(r.Context(), "user_id", uid)
+	page, err := parsePage(r)
 	if err != nil {
-		log.Printf("UsersGetReview: failed to get reviews for user %s: %v", uid, err)
-		apierr.JSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		logger.Warn("invalid pagination", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	filter := models.ReviewFilter{
+		Status:   models.PRStatus(r.URL.Query().Get("status")),
+		TeamName: r.URL.Query().Get("team_name"),
+		Label:    r.URL.Query().Get("label"),
+		Priority: r.URL.Query().Get("priority"),
+	}
+
+	prs, total, err := h.svc.GetUserReviews(ctx, uid, filter, page)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidStatus) {
+			logger.Warn("invalid status filter", "status", filter.Status)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "status должен быть OPEN, IN_REVIEW, CHANGES_REQUESTED, MERGED или CLOSED")
+			return
+		}
+		if errors.Is(err, service.ErrInvalidPriority) {
+			logger.Warn("invalid priority filter", "priority", filter.Priority)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "priority должен быть low, normal или high")
+			return
+		}
+		internalError(w, logger, "failed to get reviews", err, err.Error())
 		return
 	}
 
 	respond(w, http.StatusOK, map[string]interface{}{
 		"user_id":       uid,
 		"pull_requests": prs,
+		"total":         total,
+		"limit":         page.Limit,
+		"offset":        page.Offset,
 	})
 }
 
 func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
-	stats, err := h.svc.GetStats(r.Context())
+	page, err := parsePage(r)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("invalid pagination", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	from, to, err := parseTimeRange(r)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("invalid time filter", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	stats, err := h.svc.GetStats(r.Context(), models.StatsFilter{From: from, To: to}, page)
 	if err != nil {
-		log.Printf("Stats: failed to get stats: %v", err)
-		apierr.JSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		if errors.Is(err, service.ErrInvalidStatsRange) {
+			logging.FromContext(r.Context()).Warn("invalid stats range", "error", err)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+		internalError(w, logging.FromContext(r.Context()), "failed to get stats", err, err.Error())
 		return
 	}
 
 	respond(w, http.StatusOK, stats)
 }
 
-func (h *Handler) TeamDeactivate(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		TeamName string `json:"team_name"`
+func (h *Handler) TeamStats(w http.ResponseWriter, r *http.Request) {
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		logging.FromContext(r.Context()).Warn("team_name parameter missing")
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "параметр team_name обязателен")
+		return
 	}
+
+	ctx, logger := logging.With(r.Context(), "team_name", teamName)
+	stats, err := h.svc.GetTeamStats(ctx, teamName)
+	if err != nil {
+		if errors.Is(err, service.ErrTeamNotFound) {
+			logger.Warn("team not found")
+			apierr.Write(w, apierr.ErrTeamNotFound)
+			return
+		}
+		internalError(w, logger, "failed to get team stats", err, err.Error())
+		return
+	}
+
+	respond(w, http.StatusOK, stats)
+}
+
+type deactivateTeamRequest struct {
+	TeamName string `json:"team_name" validate:"required,max=255"`
+	// DryRun — только рассчитать деактивацию и переназначения, не сохраняя их.
+	DryRun bool `json:"dry_run"`
+}
+
+func (h *Handler) TeamDeactivate(w http.ResponseWriter, r *http.Request) {
+	var req deactivateTeamRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("TeamDeactivate: failed to decode request body: %v", err)
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
 		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request body")
 		return
 	}
+	if !validRequest(w, r, &req) {
+		return
+	}
+
+	if !authorizeTeam(w, r, req.TeamName) {
+		return
+	}
 
-	deactivated, reassignments, err := h.svc.DeactivateTeam(r.Context(), req.TeamName)
+	ctx, logger := logging.With(r.Context(), "team_name", req.TeamName)
+	deactivate := h.svc.DeactivateTeam
+	if req.DryRun {
+		deactivate = h.svc.PreviewTeamDeactivation
+	}
+	deactivated, reassignments, err := deactivate(ctx, req.TeamName)
 	if err != nil {
 		if errors.Is(err, service.ErrTeamNotFound) {
-			log.Printf("TeamDeactivate: team not found: %s", req.TeamName)
+			logger.Warn("team not found")
 			apierr.Write(w, apierr.ErrTeamNotFound)
 			return
 		}
-		log.Printf("TeamDeactivate: failed to deactivate team %s: %v", req.TeamName, err)
-		apierr.JSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		if errors.Is(err, service.ErrAuthorIsReviewer) {
+			logger.Warn("author assigned as reviewer", "error", err)
+			apierr.Write(w, apierr.ErrAuthorIsReviewer)
+			return
+		}
+		if errors.Is(err, service.ErrReviewerConflict) {
+			logger.Warn("reviewer conflict", "error", err)
+			apierr.Write(w, apierr.ErrReviewerConflict)
+			return
+		}
+		internalError(w, logger, "failed to deactivate team", err, err.Error())
 		return
 	}
 
-	log.Printf(
-		"TeamDeactivate: team %s deactivated, users: %d, reassignments: %d",
-		req.TeamName,
-		len(deactivated),
-		len(reassignments),
+	msg := "team deactivated"
+	if req.DryRun {
+		msg = "dry run, team would be deactivated"
+	}
+	logger.Info(msg,
+		"users", len(deactivated),
+		"reassignments", len(reassignments),
+		"dry_run", req.DryRun,
 	)
 	respond(w, http.StatusOK, map[string]interface{}{
 		"deactivated_users": deactivated,
 		"reassignments":     reassignments,
+		"dry_run":           req.DryRun,
 	})
 }
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/service"
+)
+
+func (h *Handler) UsersSetIdentity(w http.ResponseWriter, r *http.Request) {
+	var req models.UserIdentity
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "некорректный JSON")
+		return
+	}
+	if !validRequest(w, r, &req) {
+		return
+	}
+	if !h.authorizeUser(w, r, req.UserID) {
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "user_id", req.UserID, "provider", req.Provider)
+	identities, err := h.svc.SetUserIdentity(ctx, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			logger.Warn("user not found")
+			apierr.Write(w, apierr.ErrUserNotFound)
+		case errors.Is(err, service.ErrIdentityTaken):
+			logger.Warn("identity taken", "error", err)
+			apierr.Write(w, apierr.ErrIdentityTaken)
+		case errors.Is(err, service.ErrUnknownIdentityProvider):
+			logger.Warn("unknown identity provider", "error", err)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		default:
+			internalError(w, logger, "failed to set identity", err, err.Error())
+		}
+		return
+	}
+
+	logger.Info("identity updated", "external_id", req.ExternalID)
+	respond(w, http.StatusOK, map[string]interface{}{"user_id": req.UserID, "identities": identities})
+}
+
+func (h *Handler) UsersIdentities(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		logging.FromContext(r.Context()).Warn("user_id parameter missing")
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "параметр user_id обязателен")
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "user_id", userID)
+	identities, err := h.svc.GetUserIdentities(ctx, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			logger.Warn("user not found")
+			apierr.Write(w, apierr.ErrUserNotFound)
+			return
+		}
+		internalError(w, logger, "failed to get identities", err, "не удалось получить учетные записи")
+		return
+	}
+
+	respond(w, http.StatusOK, map[string]interface{}{"user_id": userID, "identities": identities})
+}
+
+func (h *Handler) UsersByIdentity(w http.ResponseWriter, r *http.Request) {
+	provider, externalID := r.URL.Query().Get("provider"), r.URL.Query().Get("external_id")
+	if provider == "" || externalID == "" {
+		logging.FromContext(r.Context()).Warn("provider or external_id parameter missing")
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "параметры provider и external_id обязательны")
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "provider", provider, "external_id", externalID)
+	user, err := h.svc.GetUserByIdentity(ctx, provider, externalID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			logger.Warn("user not found")
+			apierr.Write(w, apierr.ErrUserNotFound)
+		case errors.Is(err, service.ErrUnknownIdentityProvider):
+			logger.Warn("unknown identity provider", "error", err)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		default:
+			internalError(w, logger, "failed to find user by identity", err, "не удалось найти пользователя")
+		}
+		return
+	}
+
+	respond(w, http.StatusOK, map[string]*models.User{"user": user})
+}
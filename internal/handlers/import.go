@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/service"
+	"prreviewer/internal/validate"
+)
+
+// MaxImportBody — предел размера тела /team/import по умолчанию; остальные
+// маршруты ограничены mw.DefaultMaxBodySize.
+const MaxImportBody = 10 << 20
+
+// maxImportTeams — наибольшее число команд в одном импорте; совпадает с max
+// в теге importTeamsRequest.Teams.
+const maxImportTeams = 1000
+
+// TeamImport принимает JSON {"teams": [...]} или CSV с колонками
+// team_name,user_id,username,is_active[,review_weight] (text/csv или multipart-поле file).
+func (h *Handler) TeamImport(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+
+	teams, err := decodeImport(r)
+	if err != nil {
+		logger.Warn("failed to decode import", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+	if len(teams) > maxImportTeams {
+		logger.Warn("too many teams in import", "teams", len(teams))
+		apierr.Validation(w, validate.Errors{"teams": fmt.Sprintf("не больше %d элементов", maxImportTeams)})
+		return
+	}
+
+	for _, t := range teams {
+		if !authorizeTeam(w, r, t.TeamName) {
+			return
+		}
+	}
+	if !h.authorizeMembers(w, r, teams) {
+		return
+	}
+
+	results, err := h.svc.ImportTeams(r.Context(), teams)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidImport) {
+			logger.Warn("invalid import", "error", err)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+		internalError(w, logger, "failed to import teams", err, err.Error())
+		return
+	}
+
+	counts := map[string]int{}
+	for _, res := range results {
+		counts[res.Status]++
+	}
+	logger.Info("teams imported",
+		"created", counts[models.ImportCreated],
+		"updated", counts[models.ImportUpdated],
+		"failed", counts[models.ImportFailed],
+	)
+	respond(w, http.StatusOK, map[string]interface{}{
+		"results": results,
+		"created": counts[models.ImportCreated],
+		"updated": counts[models.ImportUpdated],
+		"failed":  counts[models.ImportFailed],
+	})
+}
+
+// importTeamsRequest описывает JSON-вариант импорта для схемы OpenAPI. Поля команд
+// не проверяются validRequest: некорректная команда отклоняется сервисом
+// по отдельности и попадает в отчет как failed. Число команд, в том числе из
+// CSV, ограничивает maxImportTeams.
+type importTeamsRequest struct {
+	Teams []models.Team `json:"teams" validate:"required,max=1000"`
+}
+
+func decodeImport(r *http.Request) ([]models.Team, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		return parseTeamsCSV(r.Body)
+	case "multipart/form-data":
+		f, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, fmt.Errorf("поле file обязательно: %w", err)
+		}
+		defer f.Close()
+		return parseTeamsCSV(f)
+	}
+
+	var req importTeamsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.New("некорректный JSON")
+	}
+	return req.Teams, nil
+}
+
+// parseTeamsCSV группирует строки по team_name в порядке первого появления.
+// Пустой is_active означает активного пользователя.
+func parseTeamsCSV(src io.Reader) ([]models.Team, error) {
+	rd := csv.NewReader(src)
+	rd.TrimLeadingSpace = true
+
+	header, err := rd.Read()
+	if err != nil {
+		return nil, fmt.Errorf("некорректный CSV: %w", err)
+	}
+	col := map[string]int{}
+	for i, name := range header {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"team_name", "user_id"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("в CSV нет колонки %s", name)
+		}
+	}
+	field := func(rec []string, name string) string {
+		if i, ok := col[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+
+	var teams []models.Team
+	index := map[string]int{}
+	for line := 2; ; line++ {
+		rec, err := rd.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("некорректный CSV: %w", err)
+		}
+
+		active := true
+		if v := field(rec, "is_active"); v != "" {
+			active, err = strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("строка %d: некорректный is_active %q", line, v)
+			}
+		}
+
+		weight := 0
+		if v := field(rec, "review_weight"); v != "" {
+			weight, err = strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("строка %d: некорректный review_weight %q", line, v)
+			}
+		}
+
+		var tags []string
+		if v := field(rec, "tags"); v != "" {
+			tags = strings.Split(v, ";")
+		}
+
+		name := field(rec, "team_name")
+		i, ok := index[name]
+		if !ok {
+			i = len(teams)
+			index[name] = i
+			teams = append(teams, models.Team{TeamName: name, Members: []models.TeamMember{}})
+		}
+		teams[i].Members = append(teams[i].Members, models.TeamMember{
+			UserID:       field(rec, "user_id"),
+			Username:     field(rec, "username"),
+			IsActive:     active,
+			ReviewWeight: weight,
+			Tags:         tags,
+			Email:        field(rec, "email"),
+		})
+	}
+	return teams, nil
+}
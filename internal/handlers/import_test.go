@@ -0,0 +1,48 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"prreviewer/internal/handlers"
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo/memory"
+	"prreviewer/internal/service"
+)
+
+func importTeams(t *testing.T, h *handlers.Handler, n int) *httptest.ResponseRecorder {
+	t.Helper()
+	teams := make([]models.Team, n)
+	for i := range teams {
+		teams[i] = models.Team{TeamName: fmt.Sprintf("team%d", i), Members: []models.TeamMember{
+			{UserID: fmt.Sprintf("u%d", i), Username: "User", IsActive: true},
+		}}
+	}
+	body, err := json.Marshal(map[string]any{"teams": teams})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/team/import", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.TeamImport(rec, req)
+	return rec
+}
+
+func TestTeamImportLimit(t *testing.T) {
+	h := handlers.New(service.New(memory.New()))
+
+	rec := importTeams(t, h, 1001)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"VALIDATION"`) {
+		t.Errorf("1001 команда: ожидался 400 VALIDATION, получили %d %s", rec.Code, rec.Body)
+	}
+
+	if rec := importTeams(t, h, 1000); rec.Code != http.StatusOK {
+		t.Errorf("1000 команд: ожидался 200, получили %d %s", rec.Code, rec.Body)
+	}
+}
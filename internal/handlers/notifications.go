@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/service"
+)
+
+func (h *Handler) UsersSetNotifications(w http.ResponseWriter, r *http.Request) {
+	var req models.NotificationPreferences
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "некорректный JSON")
+		return
+	}
+	if !validRequest(w, r, &req) {
+		return
+	}
+	if !h.authorizeUser(w, r, req.UserID) {
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "user_id", req.UserID)
+	prefs, err := h.svc.SetNotificationPreferences(ctx, req)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			logger.Warn("user not found")
+			apierr.Write(w, apierr.ErrUserNotFound)
+			return
+		}
+		if errors.Is(err, service.ErrInvalidDigestTime) || errors.Is(err, service.ErrInvalidTimezone) {
+			logger.Warn("invalid digest schedule", "error", err)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+		internalError(w, logger, "failed to save notification preferences", err, err.Error())
+		return
+	}
+
+	logger.Info("notification preferences saved",
+		"email_opt_out", prefs.EmailOptOut, "digest_time", *prefs.DigestTime, "timezone", *prefs.Timezone)
+	respond(w, http.StatusOK, map[string]*models.NotificationPreferences{"preferences": prefs})
+}
+
+// UsersDigestPreview показывает дайджест пользователя, который ушел бы сейчас,
+// без отправки; для отладки расписания и содержимого.
+func (h *Handler) UsersDigestPreview(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		logging.FromContext(r.Context()).Warn("user_id parameter missing")
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "параметр user_id обязателен")
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "user_id", userID)
+	digest, err := h.svc.DigestPreview(ctx, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			logger.Warn("user not found")
+			apierr.Write(w, apierr.ErrUserNotFound)
+			return
+		}
+		internalError(w, logger, "failed to build digest", err, "не удалось собрать дайджест")
+		return
+	}
+
+	respond(w, http.StatusOK, map[string]*models.Digest{"digest": digest})
+}
@@ -0,0 +1,565 @@
+package handlers
+
+import (
+	"net/http"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/health"
+	"prreviewer/internal/models"
+	"prreviewer/internal/openapi"
+)
+
+// Описания ответов для схемы; обработчики собирают те же поля через map.
+type (
+	pullRequestResponse struct {
+		PR models.PR `json:"pr"`
+	}
+	reviewerReplacedResponse struct {
+		PR         models.PR `json:"pr"`
+		ReplacedBy string    `json:"replaced_by"`
+	}
+	userResponse struct {
+		User models.User `json:"user"`
+	}
+	identitiesResponse struct {
+		UserID     string                `json:"user_id"`
+		Identities []models.UserIdentity `json:"identities"`
+	}
+	pageFields struct {
+		Total  int `json:"total"`
+		Limit  int `json:"limit"`
+		Offset int `json:"offset"`
+	}
+	reassignmentsField struct {
+		Reassignments []map[string]string `json:"reassignments"`
+	}
+	reviewersAddedResponse struct {
+		PR    models.PR `json:"pr"`
+		Added []string  `json:"added"`
+	}
+	ownershipRulesResponse struct {
+		Repository string                 `json:"repository"`
+		Rules      []models.OwnershipRule `json:"rules"`
+	}
+
+	pullRequestV2Response struct {
+		PR models.PRV2 `json:"pr"`
+	}
+	reviewerReplacedV2Response struct {
+		PR         models.PRV2 `json:"pr"`
+		ReplacedBy string      `json:"replaced_by"`
+	}
+	reviewersAddedV2Response struct {
+		PR    models.PRV2 `json:"pr"`
+		Added []string    `json:"added"`
+	}
+)
+
+// v2Responses — ответы операций, которые в API v2 возвращают модели PR v2;
+// остальные операции v2 отвечают как в v1.
+var v2Responses = map[string]map[int]any{
+	"POST /pullRequest/create": {http.StatusCreated: pullRequestV2Response{}},
+	"GET /pullRequest/get": {http.StatusOK: struct {
+		PR models.PRDetailsV2 `json:"pr"`
+	}{}},
+	"GET /pullRequest/archived": {http.StatusOK: struct {
+		PR models.ArchivedPRV2 `json:"pr"`
+	}{}},
+	"POST /pullRequest/merge":       {http.StatusOK: pullRequestV2Response{}},
+	"POST /pullRequest/close":       {http.StatusOK: pullRequestV2Response{}},
+	"POST /pullRequest/setStatus":   {http.StatusOK: pullRequestV2Response{}},
+	"POST /pullRequest/reassign":    {http.StatusOK: reviewerReplacedV2Response{}},
+	"POST /pullRequest/assign":      {http.StatusOK: pullRequestV2Response{}},
+	"POST /pullRequest/unassign":    {http.StatusOK: pullRequestV2Response{}},
+	"POST /pullRequest/addReviewer": {http.StatusOK: reviewersAddedV2Response{}},
+	"POST /pullRequest/approve":     {http.StatusOK: pullRequestV2Response{}},
+	"POST /pullRequest/decline":     {http.StatusOK: reviewerReplacedV2Response{}},
+}
+
+var pageParams = []openapi.Param{
+	{Name: "limit", Description: "Размер страницы"},
+	{Name: "offset", Description: "Смещение"},
+}
+
+// Operations описывает маршруты API для генерации OpenAPI-документа и проверки
+// тел запросов: каждую операцию под префиксом каждой версии (/api/v1, /api/v2),
+// устаревшие псевдонимы без префикса и пробы.
+func Operations() []openapi.Operation {
+	ops := []openapi.Operation{
+		{
+			Method: http.MethodPost, Path: "/team/add", Tag: "Teams", Auth: true,
+			Summary: "Создать команду с участниками или, с rebalance, дополнить существующую",
+			Request: teamAddRequest{},
+			Responses: map[int]any{
+				http.StatusCreated: struct {
+					Team models.Team `json:"team"`
+				}{},
+				http.StatusOK: struct {
+					Team       models.Team                  `json:"team"`
+					Rebalanced []models.RebalanceAssignment `json:"rebalanced"`
+				}{},
+			},
+		},
+		{
+			Method: http.MethodGet, Path: "/team/get", Tag: "Teams",
+			Summary:   "Получить команду с участниками",
+			Query:     []openapi.Param{{Name: "team_name", Required: true}},
+			Responses: map[int]any{http.StatusOK: models.Team{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/team/list", Tag: "Teams",
+			Summary: "Список команд",
+			Query:   append([]openapi.Param{{Name: "name_prefix", Description: "Префикс имени команды"}}, pageParams...),
+			Responses: map[int]any{http.StatusOK: struct {
+				Teams []models.TeamSummary `json:"teams"`
+				pageFields
+			}{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/team/import", Tag: "Teams", Auth: true,
+			Summary: "Импорт команд из JSON (также принимает text/csv и multipart-поле file)",
+			Request: importTeamsRequest{},
+			Responses: map[int]any{http.StatusOK: struct {
+				Results []models.TeamImportResult `json:"results"`
+				Created int                       `json:"created"`
+				Updated int                       `json:"updated"`
+				Failed  int                       `json:"failed"`
+			}{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/team/deactivate", Tag: "Teams", Auth: true,
+			Summary: "Деактивировать команду и переназначить открытые ревью (dry_run — только прогноз)",
+			Request: deactivateTeamRequest{},
+			Responses: map[int]any{http.StatusOK: struct {
+				DeactivatedUsers []string `json:"deactivated_users"`
+				reassignmentsField
+				DryRun bool `json:"dry_run"`
+			}{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/team/rename", Tag: "Teams", Auth: true,
+			Summary: "Переименовать команду (только администратор)",
+			Request: renameTeamRequest{},
+			Responses: map[int]any{http.StatusOK: struct {
+				Team models.Team `json:"team"`
+			}{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/team/autoMerge", Tag: "Teams", Auth: true,
+			Summary: "Настроить auto-merge команды",
+			Request: models.TeamAutoMerge{},
+			Responses: map[int]any{http.StatusOK: struct {
+				AutoMerge models.TeamAutoMerge `json:"auto_merge"`
+			}{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/team/policy", Tag: "Teams", Auth: true,
+			Summary: "Настроить политику назначения команды",
+			Request: models.TeamPolicy{},
+			Responses: map[int]any{http.StatusOK: struct {
+				Policy models.TeamPolicy `json:"policy"`
+			}{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/audit", Tag: "Audit", Auth: true,
+			Summary: "Журнал аудита (только администратор)",
+			Query: append([]openapi.Param{
+				{Name: "user_id"},
+				{Name: "team_name"},
+				{Name: "pull_request_id"},
+				{Name: "event_type"},
+				{Name: "from", Description: "RFC 3339"},
+				{Name: "to", Description: "RFC 3339"},
+			}, pageParams...),
+			Responses: map[int]any{http.StatusOK: struct {
+				Entries []models.AuditEntry `json:"entries"`
+				pageFields
+			}{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/import/github", Tag: "PullRequests", Auth: true,
+			Summary:   "Импортировать открытые PR репозитория GitHub и назначить ревьюверов (только администратор)",
+			Request:   importGitHubRequest{},
+			Responses: map[int]any{http.StatusOK: importGitHubResponse{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/export/assignments", Tag: "Audit", Auth: true,
+			Summary: "Выгрузка событий назначения в NDJSON, по одному событию в строке (только администратор)",
+			Query: []openapi.Param{
+				{Name: "from", Description: "RFC 3339, включительно"},
+				{Name: "to", Description: "RFC 3339, не включая"},
+			},
+			Responses: map[int]any{http.StatusOK: models.AssignmentEvent{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/ownership/rules", Tag: "Ownership", Auth: true,
+			Summary:   "Заменить правила владения кодом репозитория (только администратор)",
+			Request:   setOwnershipRulesRequest{},
+			Responses: map[int]any{http.StatusOK: ownershipRulesResponse{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/ownership/rules", Tag: "Ownership",
+			Summary:   "Правила владения кодом репозитория",
+			Query:     []openapi.Param{{Name: "repository", Required: true}},
+			Responses: map[int]any{http.StatusOK: ownershipRulesResponse{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/users/setIsActive", Tag: "Users", Auth: true,
+			Summary:   "Изменить активность пользователя",
+			Request:   setUserActiveRequest{},
+			Responses: map[int]any{http.StatusOK: userResponse{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/users/getReview", Tag: "Users",
+			Summary: "PR, где пользователь назначен ревьювером",
+			Query: append([]openapi.Param{
+				{Name: "user_id", Required: true},
+				{Name: "status", Description: "OPEN, IN_REVIEW, CHANGES_REQUESTED, MERGED или CLOSED"},
+				{Name: "team_name"},
+				{Name: "label", Description: "Метка PR"},
+				{Name: "priority", Description: "low, normal или high"},
+			}, pageParams...),
+			Responses: map[int]any{http.StatusOK: struct {
+				UserID       string           `json:"user_id"`
+				PullRequests []models.PRShort `json:"pull_requests"`
+				pageFields
+			}{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/users/get", Tag: "Users",
+			Summary: "Профиль пользователя с нагрузкой на ревью и доступностью",
+			Query:   []openapi.Param{{Name: "user_id", Required: true}},
+			Responses: map[int]any{http.StatusOK: struct {
+				User models.UserProfile `json:"user"`
+			}{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/users/list", Tag: "Users",
+			Summary: "Поиск пользователей",
+			Query: append([]openapi.Param{
+				{Name: "team_name"},
+				{Name: "is_active", Description: "true или false"},
+				{Name: "q", Description: "Подстрока user_id или username"},
+			}, pageParams...),
+			Responses: map[int]any{http.StatusOK: struct {
+				Users []models.User `json:"users"`
+				pageFields
+			}{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/users/delete", Tag: "Users", Auth: true,
+			Summary: "Удалить пользователя и переназначить его ревью",
+			Request: deleteUserRequest{},
+			Responses: map[int]any{http.StatusOK: struct {
+				UserID string `json:"user_id"`
+				reassignmentsField
+				ArchivedAssignments int64 `json:"archived_assignments"`
+			}{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/users/setVacation", Tag: "Users", Auth: true,
+			Summary: "Задать отпуск пользователя",
+			Request: setVacationRequest{},
+			Responses: map[int]any{http.StatusOK: struct {
+				Vacation models.Vacation `json:"vacation"`
+			}{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/users/snooze", Tag: "Users", Auth: true,
+			Summary:   "Приостановить новые назначения пользователю на несколько часов",
+			Request:   snoozeRequest{},
+			Responses: map[int]any{http.StatusOK: snoozeResponse{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/users/setTags", Tag: "Users", Auth: true,
+			Summary:   "Задать теги навыков пользователя",
+			Request:   setTagsRequest{},
+			Responses: map[int]any{http.StatusOK: userResponse{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/users/setNotifications", Tag: "Users", Auth: true,
+			Summary: "Настроить email-уведомления и ежедневный дайджест пользователя",
+			Request: models.NotificationPreferences{},
+			Responses: map[int]any{http.StatusOK: struct {
+				Preferences models.NotificationPreferences `json:"preferences"`
+			}{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/users/digestPreview", Tag: "Users",
+			Summary: "Дайджест пользователя на текущий момент без отправки",
+			Query:   []openapi.Param{{Name: "user_id", Required: true}},
+			Responses: map[int]any{http.StatusOK: struct {
+				Digest models.Digest `json:"digest"`
+			}{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/users/setIdentity", Tag: "Users", Auth: true,
+			Summary:   "Привязать пользователя к учетной записи GitHub, GitLab или Slack",
+			Request:   models.UserIdentity{},
+			Responses: map[int]any{http.StatusOK: identitiesResponse{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/users/identities", Tag: "Users",
+			Summary:   "Учетные записи пользователя во внешних системах",
+			Query:     []openapi.Param{{Name: "user_id", Required: true}},
+			Responses: map[int]any{http.StatusOK: identitiesResponse{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/users/byIdentity", Tag: "Users",
+			Summary: "Найти пользователя по учетной записи во внешней системе",
+			Query: []openapi.Param{
+				{Name: "provider", Required: true, Description: "github, gitlab или slack"},
+				{Name: "external_id", Required: true, Description: "Логин GitHub/GitLab или member ID в Slack"},
+			},
+			Responses: map[int]any{http.StatusOK: userResponse{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/pullRequest/create", Tag: "PullRequests",
+			Summary:   "Создать PR и назначить ревьюверов",
+			Request:   createPRRequest{},
+			Responses: map[int]any{http.StatusCreated: pullRequestResponse{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/pullRequest/merge", Tag: "PullRequests",
+			Summary:   "Смержить PR (идемпотентно), когда зависимости смержены или закрыты; force без одобрений — только администратор",
+			Request:   mergePRRequest{},
+			Responses: map[int]any{http.StatusOK: pullRequestResponse{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/pullRequest/get", Tag: "PullRequests",
+			Summary: "Получить PR со статусом одобрений и историей назначений",
+			Query:   []openapi.Param{{Name: "pull_request_id", Required: true}},
+			Responses: map[int]any{http.StatusOK: struct {
+				PR models.PRDetails `json:"pr"`
+			}{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/pullRequest/archived", Tag: "PullRequests",
+			Summary: "Получить PR из архива смерженных",
+			Query:   []openapi.Param{{Name: "pull_request_id", Required: true}},
+			Responses: map[int]any{http.StatusOK: struct {
+				PR models.ArchivedPR `json:"pr"`
+			}{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/pullRequest/{id}/timeline", Tag: "PullRequests",
+			Summary:    "Жизненный цикл PR по порядку: создание, назначения, одобрения, merge",
+			PathParams: []openapi.Param{{Name: "id", Description: "pull_request_id"}},
+			Responses:  map[int]any{http.StatusOK: models.PRTimeline{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/pullRequest/close", Tag: "PullRequests",
+			Summary:   "Закрыть PR без слияния",
+			Request:   closePRRequest{},
+			Responses: map[int]any{http.StatusOK: pullRequestResponse{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/pullRequest/setStatus", Tag: "PullRequests",
+			Summary:   "Перевести открытый PR в OPEN, IN_REVIEW или CHANGES_REQUESTED",
+			Request:   setPRStatusRequest{},
+			Responses: map[int]any{http.StatusOK: pullRequestResponse{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/pullRequest/reassign", Tag: "PullRequests",
+			Summary:   "Переназначить ревьювера",
+			Request:   reassignPRRequest{},
+			Responses: map[int]any{http.StatusOK: reviewerReplacedResponse{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/pullRequest/assign", Tag: "PullRequests", Auth: true,
+			Summary:   "Назначить выбранного ревьювера (только администратор)",
+			Request:   assignPRRequest{},
+			Responses: map[int]any{http.StatusOK: pullRequestResponse{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/pullRequest/unassign", Tag: "PullRequests", Auth: true,
+			Summary:   "Снять ревьювера без замены (только администратор)",
+			Request:   unassignPRRequest{},
+			Responses: map[int]any{http.StatusOK: pullRequestResponse{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/pullRequest/addReviewer", Tag: "PullRequests",
+			Summary:   "Добрать ревьюверов до reviewer_count политики команды",
+			Request:   addReviewerRequest{},
+			Responses: map[int]any{http.StatusOK: reviewersAddedResponse{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/pullRequest/approve", Tag: "PullRequests", Auth: true,
+			Summary:   "Одобрить PR ревьювером",
+			Request:   approvePRRequest{},
+			Responses: map[int]any{http.StatusOK: pullRequestResponse{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/pullRequest/decline", Tag: "PullRequests",
+			Summary:   "Отказаться от ревью",
+			Request:   declinePRRequest{},
+			Responses: map[int]any{http.StatusOK: reviewerReplacedResponse{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/pullRequest/stale", Tag: "PullRequests",
+			Summary: "Зависшие PR без одобрений",
+			Query: append([]openapi.Param{
+				{Name: "older_than", Description: "Порог возраста, например 48h"},
+			}, pageParams...),
+			Responses: map[int]any{http.StatusOK: struct {
+				PullRequests []models.StalePR `json:"pull_requests"`
+				pageFields
+			}{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/pullRequest/blocked", Tag: "PullRequests",
+			Summary: "Открытые PR, merge которых ждет открытых зависимостей",
+			Query:   pageParams,
+			Responses: map[int]any{http.StatusOK: struct {
+				PullRequests []models.BlockedPR `json:"pull_requests"`
+				pageFields
+			}{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/stats", Tag: "Stats",
+			Summary: "Статистика назначений",
+			Query: append([]openapi.Param{
+				{Name: "from", Description: "RFC 3339, начало периода включительно"},
+				{Name: "to", Description: "RFC 3339, конец периода не включительно"},
+			}, pageParams...),
+			Responses: map[int]any{http.StatusOK: models.Stats{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/stats/team", Tag: "Stats",
+			Summary:   "Нагрузка на ревью в команде",
+			Query:     []openapi.Param{{Name: "team_name", Required: true}},
+			Responses: map[int]any{http.StatusOK: models.TeamStats{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/stats/sla", Tag: "Stats",
+			Summary: "Соблюдение SLA реакции ревьюверов",
+			Query: []openapi.Param{
+				{Name: "within", Description: "Окно реакции, по умолчанию 24h"},
+				{Name: "team_name", Description: "Команда ревьюверов"},
+			},
+			Responses: map[int]any{http.StatusOK: models.SLAStats{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/directory/sync", Tag: "Users", Auth: true,
+			Summary:   "Синхронизировать команды с группами LDAP/AD (dry_run — только отчет, только администратор)",
+			Request:   directorySyncRequest{},
+			Responses: map[int]any{http.StatusOK: models.DirectorySyncReport{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/scim/v2/Users", Tag: "SCIM", Auth: true,
+			Summary: "Пользователи в формате SCIM 2.0 (только администратор)",
+			Query: []openapi.Param{
+				{Name: "filter", Description: `Только userName eq "..."`},
+				{Name: "startIndex", Description: "Номер первого ресурса, с 1"},
+				{Name: "count", Description: "Размер страницы"},
+			},
+			Responses: map[int]any{http.StatusOK: scimListResponse{}},
+		},
+		{
+			Method: http.MethodPost, Path: "/scim/v2/Users", Tag: "SCIM", Auth: true,
+			Summary:     "Завести пользователя из каталога; команда — department расширения enterprise",
+			ContentType: scimContentType,
+			Request:     scimUser{},
+			Responses:   map[int]any{http.StatusCreated: scimUser{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/scim/v2/Users/{id}", Tag: "SCIM", Auth: true,
+			Summary:    "Пользователь в формате SCIM 2.0",
+			PathParams: []openapi.Param{{Name: "id", Description: "user_id"}},
+			Responses:  map[int]any{http.StatusOK: scimUser{}},
+		},
+		{
+			Method: http.MethodPut, Path: "/scim/v2/Users/{id}", Tag: "SCIM", Auth: true,
+			Summary:     "Заменить данные пользователя; active=false переназначает его открытые ревью",
+			PathParams:  []openapi.Param{{Name: "id", Description: "user_id"}},
+			ContentType: scimContentType,
+			Request:     scimUser{},
+			Responses:   map[int]any{http.StatusOK: scimUser{}},
+		},
+		{
+			Method: http.MethodPatch, Path: "/scim/v2/Users/{id}", Tag: "SCIM", Auth: true,
+			Summary:     "Изменить active, displayName, emails или department пользователя",
+			PathParams:  []openapi.Param{{Name: "id", Description: "user_id"}},
+			ContentType: scimContentType,
+			Request:     scimPatchRequest{},
+			Responses:   map[int]any{http.StatusOK: scimUser{}},
+		},
+		{
+			Method: http.MethodDelete, Path: "/scim/v2/Users/{id}", Tag: "SCIM", Auth: true,
+			Summary:    "Удалить пользователя и переназначить его ревью",
+			PathParams: []openapi.Param{{Name: "id", Description: "user_id"}},
+			Responses:  map[int]any{http.StatusNoContent: nil},
+		},
+	}
+
+	var out []openapi.Operation
+	for _, v := range APIVersions {
+		out = append(out, versionOperations(v, ops)...)
+	}
+	out = append(out, legacyOperations(ops)...)
+	return append(out, healthOperations()...)
+}
+
+// versionOperations повторяет операции ops под префиксом версии v с моделями
+// ответов этой версии.
+func versionOperations(v APIVersion, ops []openapi.Operation) []openapi.Operation {
+	out := make([]openapi.Operation, 0, len(ops))
+	for _, op := range ops {
+		if responses, ok := v2Responses[op.Method+" "+op.Path]; ok && v >= APIv2 {
+			op.Responses = responses
+		}
+		op.Path = v.Prefix() + op.Path
+		out = append(out, op)
+	}
+	return out
+}
+
+// legacyOperations — устаревшие псевдонимы для клиентов, появившихся до
+// /api/vN: операции v1 без префикса и операции PR v2 под префиксом /v2.
+func legacyOperations(ops []openapi.Operation) []openapi.Operation {
+	var out []openapi.Operation
+	for _, op := range ops {
+		op.Deprecated = true
+		out = append(out, op)
+		if responses, ok := v2Responses[op.Method+" "+op.Path]; ok {
+			op.Path = "/v2" + op.Path
+			op.Responses = responses
+			out = append(out, op)
+		}
+	}
+	return out
+}
+
+// healthOperations — проверки доступности и пробы; они не версионируются.
+func healthOperations() []openapi.Operation {
+	return []openapi.Operation{
+		{
+			Method: http.MethodGet, Path: "/health", Tag: "Health",
+			Summary:   "Проверка доступности",
+			Responses: map[int]any{http.StatusOK: map[string]string{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/health/live", Tag: "Health",
+			Summary:   "Проба живости процесса",
+			Responses: map[int]any{http.StatusOK: map[string]string{}},
+		},
+		{
+			Method: http.MethodGet, Path: "/health/ready", Tag: "Health",
+			Summary: "Проба готовности: пул БД и версия схемы",
+			Responses: map[int]any{
+				http.StatusOK:                 health.Report{},
+				http.StatusServiceUnavailable: health.Report{},
+			},
+		},
+	}
+}
+
+// NewOpenAPI генерирует OpenAPI-документ API сервиса.
+func NewOpenAPI() (*openapi.Document, error) {
+	return openapi.New(
+		openapi.Info{Title: "PR Reviewer Assignment Service", Version: "1.0.0"},
+		Operations(),
+		openapi.WithErrorResponse(apierr.ErrResp{}),
+		openapi.WithProblemResponse(apierr.Problem{}),
+	)
+}
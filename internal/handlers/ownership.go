@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/service"
+)
+
+type setOwnershipRulesRequest struct {
+	Repository string                 `json:"repository" validate:"required,max=255"`
+	Rules      []models.OwnershipRule `json:"rules" validate:"max=1000"`
+}
+
+func (h *Handler) OwnershipSetRules(w http.ResponseWriter, r *http.Request) {
+	var req setOwnershipRulesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "некорректный JSON")
+		return
+	}
+	if !validRequest(w, r, &req) {
+		return
+	}
+
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "repository", req.Repository)
+	rules, err := h.svc.SetOwnershipRules(ctx, req.Repository, req.Rules)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidOwnershipRule) {
+			logger.Warn("invalid ownership rules", "error", err)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+		internalError(w, logger, "failed to save ownership rules", err, err.Error())
+		return
+	}
+
+	logger.Info("ownership rules saved", "rules", len(rules))
+	respond(w, http.StatusOK, map[string]interface{}{
+		"repository": req.Repository,
+		"rules":      rules,
+	})
+}
+
+func (h *Handler) OwnershipGetRules(w http.ResponseWriter, r *http.Request) {
+	repository := r.URL.Query().Get("repository")
+	if repository == "" {
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "параметр repository обязателен")
+		return
+	}
+
+	rules, err := h.svc.ListOwnershipRules(r.Context(), repository)
+	if err != nil {
+		internalError(w, logging.FromContext(r.Context()).With("repository", repository), "failed to list ownership rules", err, err.Error())
+		return
+	}
+
+	respond(w, http.StatusOK, map[string]interface{}{
+		"repository": repository,
+		"rules":      rules,
+	})
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"prreviewer/internal/models"
+)
+
+// parsePage читает limit/offset из query. limit по умолчанию — models.DefaultPageLimit,
+// не больше models.MaxPageLimit.
+func parsePage(r *http.Request) (models.Page, error) {
+	page := models.Page{Limit: models.DefaultPageLimit}
+
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return page, errors.New("limit должен быть положительным числом")
+		}
+		page.Limit = min(limit, models.MaxPageLimit)
+	}
+
+	if v := r.URL.Query().Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return page, errors.New("offset должен быть неотрицательным числом")
+		}
+		page.Offset = offset
+	}
+
+	return page, nil
+}
+
+// parseTimeRange читает необязательные границы from/to в формате RFC 3339.
+func parseTimeRange(r *http.Request) (from, to *time.Time, err error) {
+	for name, target := range map[string]**time.Time{"from": &from, "to": &to} {
+		v := r.URL.Query().Get(name)
+		if v == "" {
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, nil, errors.New(name + " должен быть в формате RFC 3339")
+		}
+		*target = &ts
+	}
+	return from, to, nil
+}
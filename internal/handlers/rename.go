@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/service"
+)
+
+type renameTeamRequest struct {
+	TeamName    string `json:"team_name" validate:"required,max=255"`
+	NewTeamName string `json:"new_team_name" validate:"required,max=255"`
+}
+
+// TeamRename переименовывает команду. Токен тимлида привязан к имени команды,
+// поэтому переименование доступно только администратору.
+func (h *Handler) TeamRename(w http.ResponseWriter, r *http.Request) {
+	var req renameTeamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "некорректный JSON")
+		return
+	}
+	if !validRequest(w, r, &req) {
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "team_name", req.TeamName, "new_team_name", req.NewTeamName)
+	if err := h.svc.RenameTeam(ctx, req.TeamName, req.NewTeamName); err != nil {
+		switch {
+		case errors.Is(err, service.ErrTeamNotFound):
+			logger.Warn("team not found")
+			apierr.Write(w, apierr.ErrTeamNotFound)
+		case errors.Is(err, service.ErrTeamExists):
+			logger.Warn("new team name already taken")
+			apierr.Write(w, apierr.ErrTeamExists)
+		default:
+			internalError(w, logger, "failed to rename team", err, "не удалось переименовать команду")
+		}
+		return
+	}
+
+	logger.Info("team renamed")
+	team, err := h.svc.GetTeam(ctx, req.NewTeamName)
+	if err != nil {
+		internalError(w, logger, "failed to get renamed team", err, "не удалось получить команду")
+		return
+	}
+	respond(w, http.StatusOK, map[string]*models.Team{"team": team})
+}
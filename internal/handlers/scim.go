@@ -0,0 +1,440 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/service"
+)
+
+// Ручки /scim/v2/Users реализуют подмножество SCIM 2.0 (RFC 7643, RFC 7644),
+// достаточное для провижининга из Okta и Azure AD: id и userName ресурса
+// совпадают с user_id, команда — department расширения enterprise.
+
+const (
+	scimUserSchema       = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimEnterpriseSchema = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+	scimListSchema       = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimErrorSchema      = "urn:ietf:params:scim:api:messages:2.0:Error"
+	scimContentType      = "application/scim+json"
+)
+
+// scimFilter — единственный поддерживаемый фильтр списка: userName eq "...".
+var scimFilter = regexp.MustCompile(`(?i)^\s*userName\s+eq\s+"([^"]*)"\s*$`)
+
+type scimUser struct {
+	Schemas     []string        `json:"schemas"`
+	ID          string          `json:"id,omitempty"`
+	UserName    string          `json:"userName" validate:"required,max=255"`
+	DisplayName string          `json:"displayName,omitempty"`
+	Name        *scimName       `json:"name,omitempty"`
+	Emails      []scimEmail     `json:"emails,omitempty"`
+	Active      *bool           `json:"active,omitempty"`
+	Enterprise  *scimEnterprise `json:"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User,omitempty"`
+	Meta        *scimMeta       `json:"meta,omitempty"`
+}
+
+type scimName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type scimEmail struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type scimEnterprise struct {
+	Department string `json:"department,omitempty"`
+}
+
+type scimMeta struct {
+	ResourceType string `json:"resourceType"`
+	Location     string `json:"location"`
+}
+
+type scimListResponse struct {
+	Schemas      []string   `json:"schemas"`
+	TotalResults int        `json:"totalResults"`
+	StartIndex   int        `json:"startIndex"`
+	ItemsPerPage int        `json:"itemsPerPage"`
+	Resources    []scimUser `json:"Resources"`
+}
+
+type scimErrorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+}
+
+type scimPatchRequest struct {
+	Schemas    []string      `json:"schemas"`
+	Operations []scimPatchOp `json:"Operations"`
+}
+
+type scimPatchOp struct {
+	Op    string `json:"op"`
+	Path  string `json:"path,omitempty"`
+	Value any    `json:"value"`
+}
+
+func newSCIMUser(u *models.User) scimUser {
+	active := u.IsActive
+	s := scimUser{
+		Schemas:     []string{scimUserSchema, scimEnterpriseSchema},
+		ID:          u.UserID,
+		UserName:    u.UserID,
+		DisplayName: u.Username,
+		Active:      &active,
+		Enterprise:  &scimEnterprise{Department: u.TeamName},
+		Meta:        &scimMeta{ResourceType: "User", Location: "/scim/v2/Users/" + url.PathEscape(u.UserID)},
+	}
+	if u.Email != "" {
+		s.Emails = []scimEmail{{Value: u.Email, Type: "work", Primary: true}}
+	}
+	return s
+}
+
+// member переводит ресурс в участника команды и название команды; active по
+// умолчанию true, имя — первое заданное из displayName, name и userName.
+func (u scimUser) member() (string, models.TeamMember) {
+	m := models.TeamMember{UserID: u.UserName, Username: u.DisplayName, IsActive: u.Active == nil || *u.Active}
+	if m.Username == "" && u.Name != nil {
+		m.Username = u.Name.Formatted
+		if m.Username == "" {
+			m.Username = strings.TrimSpace(u.Name.GivenName + " " + u.Name.FamilyName)
+		}
+	}
+	if m.Username == "" {
+		m.Username = u.UserName
+	}
+	for _, e := range u.Emails {
+		if m.Email == "" || e.Primary {
+			m.Email = e.Value
+		}
+	}
+	team := ""
+	if u.Enterprise != nil {
+		team = u.Enterprise.Department
+	}
+	return team, m
+}
+
+func scimRespond(w http.ResponseWriter, code int, data any) {
+	w.Header().Set("Content-Type", scimContentType)
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		slog.Error("failed to encode response", "error", err)
+	}
+}
+
+// scimError отвечает ошибкой в формате SCIM; scimType может быть пустым.
+func scimError(w http.ResponseWriter, code int, scimType, detail string) {
+	scimRespond(w, code, scimErrorResponse{
+		Schemas:  []string{scimErrorSchema},
+		Status:   strconv.Itoa(code),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
+
+func (h *Handler) SCIMListUsers(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	logger := logging.FromContext(r.Context())
+	query := r.URL.Query()
+	start, count := 1, models.DefaultPageLimit
+	if v := query.Get("startIndex"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			scimError(w, http.StatusBadRequest, "invalidValue", "startIndex должен быть числом")
+			return
+		}
+		start = max(n, 1)
+	}
+	if v := query.Get("count"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			scimError(w, http.StatusBadRequest, "invalidValue", "count должен быть числом")
+			return
+		}
+		count = min(max(n, 0), models.MaxPageLimit)
+	}
+
+	resp := scimListResponse{Schemas: []string{scimListSchema}, StartIndex: start, Resources: []scimUser{}}
+	if filter := query.Get("filter"); filter != "" {
+		match := scimFilter.FindStringSubmatch(filter)
+		if match == nil {
+			logger.Warn("unsupported SCIM filter", "filter", filter)
+			scimError(w, http.StatusBadRequest, "invalidFilter", `поддерживается только фильтр userName eq "..."`)
+			return
+		}
+		profile, err := h.svc.GetUserProfile(r.Context(), match[1])
+		if err != nil && !errors.Is(err, service.ErrUserNotFound) {
+			logger.Error("failed to get user", "error", err)
+			scimError(w, http.StatusInternalServerError, "", "не удалось получить пользователя")
+			return
+		}
+		if profile != nil {
+			resp.TotalResults = 1
+			if start == 1 && count > 0 {
+				resp.Resources = append(resp.Resources, newSCIMUser(&profile.User))
+			}
+		}
+	} else {
+		users, total, err := h.svc.ListUsers(r.Context(), models.UserFilter{}, models.Page{Limit: count, Offset: start - 1})
+		if err != nil {
+			logger.Error("failed to list users", "error", err)
+			scimError(w, http.StatusInternalServerError, "", "не удалось получить пользователей")
+			return
+		}
+		for i := range users {
+			resp.Resources = append(resp.Resources, newSCIMUser(&users[i]))
+		}
+		resp.TotalResults = total
+	}
+	resp.ItemsPerPage = len(resp.Resources)
+	scimRespond(w, http.StatusOK, resp)
+}
+
+func (h *Handler) SCIMGetUser(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	ctx, logger := logging.With(r.Context(), "user_id", chi.URLParam(r, "id"))
+	profile, err := h.svc.GetUserProfile(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		scimServiceError(w, logger, "failed to get user", err)
+		return
+	}
+	scimRespond(w, http.StatusOK, newSCIMUser(&profile.User))
+}
+
+func (h *Handler) SCIMCreateUser(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	var req scimUser
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
+		scimError(w, http.StatusBadRequest, "invalidSyntax", "некорректный JSON")
+		return
+	}
+	if req.UserName == "" || len(req.UserName) > 255 {
+		scimError(w, http.StatusBadRequest, "invalidValue", "userName обязателен, не длиннее 255 символов")
+		return
+	}
+
+	team, member := req.member()
+	ctx, logger := logging.With(r.Context(), "user_id", member.UserID, "team_name", team)
+	user, err := h.svc.CreateDirectoryUser(ctx, team, member)
+	if err != nil {
+		scimServiceError(w, logger, "failed to provision user", err)
+		return
+	}
+
+	logger.Info("user provisioned", "is_active", user.IsActive)
+	scimRespond(w, http.StatusCreated, newSCIMUser(user))
+}
+
+func (h *Handler) SCIMReplaceUser(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id := chi.URLParam(r, "id")
+	var req scimUser
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
+		scimError(w, http.StatusBadRequest, "invalidSyntax", "некорректный JSON")
+		return
+	}
+	if req.UserName != "" && req.UserName != id {
+		scimError(w, http.StatusBadRequest, "mutability", "userName нельзя изменить")
+		return
+	}
+	req.UserName = id
+
+	team, member := req.member()
+	ctx, logger := logging.With(r.Context(), "user_id", id)
+	if req.Active == nil {
+		// PUT без active не меняет статус: иначе каталог, не передающий
+		// атрибут, включал бы отключенных пользователей.
+		profile, err := h.svc.GetUserProfile(ctx, id)
+		if err != nil {
+			scimServiceError(w, logger, "failed to get user", err)
+			return
+		}
+		member.IsActive = profile.User.IsActive
+	}
+	user, err := h.svc.UpdateDirectoryUser(ctx, team, member)
+	if err != nil {
+		scimServiceError(w, logger, "failed to update user", err)
+		return
+	}
+
+	logger.Info("user updated by directory", "is_active", user.IsActive)
+	scimRespond(w, http.StatusOK, newSCIMUser(user))
+}
+
+// SCIMPatchUser применяет операции add и replace к active, displayName, emails
+// и department; остальные атрибуты пропускаются.
+func (h *Handler) SCIMPatchUser(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	id := chi.URLParam(r, "id")
+	var req scimPatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
+		scimError(w, http.StatusBadRequest, "invalidSyntax", "некорректный JSON")
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "user_id", id)
+	profile, err := h.svc.GetUserProfile(ctx, id)
+	if err != nil {
+		scimServiceError(w, logger, "failed to get user", err)
+		return
+	}
+	team, member := newSCIMUser(&profile.User).member()
+	for _, op := range req.Operations {
+		if err := op.apply(&member, &team); err != nil {
+			logger.Warn("invalid SCIM patch", "error", err)
+			scimError(w, http.StatusBadRequest, "invalidValue", err.Error())
+			return
+		}
+	}
+
+	user, err := h.svc.UpdateDirectoryUser(ctx, team, member)
+	if err != nil {
+		scimServiceError(w, logger, "failed to update user", err)
+		return
+	}
+
+	logger.Info("user updated by directory", "is_active", user.IsActive)
+	scimRespond(w, http.StatusOK, newSCIMUser(user))
+}
+
+func (h *Handler) SCIMDeleteUser(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	ctx, logger := logging.With(r.Context(), "user_id", chi.URLParam(r, "id"))
+	result, err := h.svc.DeleteUser(ctx, chi.URLParam(r, "id"))
+	if err != nil {
+		scimServiceError(w, logger, "failed to delete user", err)
+		return
+	}
+
+	logger.Info("user deleted by directory", "reassignments", len(result.Reassignments))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func scimServiceError(w http.ResponseWriter, logger *slog.Logger, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		logger.Warn("user not found")
+		scimError(w, http.StatusNotFound, "", "user not found")
+	case errors.Is(err, service.ErrUserExists):
+		logger.Warn("user already exists")
+		scimError(w, http.StatusConflict, "uniqueness", "user already exists")
+	case errors.Is(err, service.ErrTeamRequired), errors.Is(err, service.ErrInvalidEmail),
+		errors.Is(err, service.ErrInvalidWeight):
+		logger.Warn(msg, "error", err)
+		scimError(w, http.StatusBadRequest, "invalidValue", err.Error())
+	default:
+		logger.Error(msg, "error", err)
+		scimError(w, http.StatusInternalServerError, "", "internal error")
+	}
+}
+
+// apply применяет операцию PATCH к участнику и названию команды.
+func (op scimPatchOp) apply(m *models.TeamMember, team *string) error {
+	switch strings.ToLower(op.Op) {
+	case "add", "replace":
+	default:
+		return fmt.Errorf("операция %q не поддерживается", op.Op)
+	}
+	if op.Path != "" {
+		return setSCIMAttr(m, team, op.Path, op.Value)
+	}
+	values, ok := op.Value.(map[string]any)
+	if !ok {
+		return errors.New("value операции без path должен быть объектом")
+	}
+	for path, v := range values {
+		if err := setSCIMAttr(m, team, path, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setSCIMAttr(m *models.TeamMember, team *string, path string, v any) error {
+	lower := strings.ToLower(path)
+	switch {
+	case lower == "active":
+		// Azure AD присылает active строкой "True"/"False".
+		switch b := v.(type) {
+		case bool:
+			m.IsActive = b
+		case string:
+			parsed, err := strconv.ParseBool(b)
+			if err != nil {
+				return fmt.Errorf("active: %q не является boolean", b)
+			}
+			m.IsActive = parsed
+		default:
+			return errors.New("active должен быть boolean")
+		}
+	case lower == "displayname":
+		s, ok := v.(string)
+		if !ok {
+			return errors.New("displayName должен быть строкой")
+		}
+		m.Username = s
+	case strings.HasPrefix(lower, "emails"):
+		switch e := v.(type) {
+		case string:
+			m.Email = e
+		case []any:
+			for _, item := range e {
+				if obj, ok := item.(map[string]any); ok {
+					if s, ok := obj["value"].(string); ok && (obj["primary"] == true || m.Email == "") {
+						m.Email = s
+					}
+				}
+			}
+		default:
+			return errors.New("emails: ожидалась строка или список")
+		}
+	case lower == strings.ToLower(scimEnterpriseSchema)+":department":
+		s, ok := v.(string)
+		if !ok {
+			return errors.New("department должен быть строкой")
+		}
+		*team = s
+	case lower == strings.ToLower(scimEnterpriseSchema):
+		if obj, ok := v.(map[string]any); ok {
+			if s, ok := obj["department"].(string); ok {
+				*team = s
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"prreviewer/internal/handlers"
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo/memory"
+	"prreviewer/internal/service"
+)
+
+func TestSCIMReplaceUserKeepsActive(t *testing.T) {
+	svc := service.New(memory.New())
+	ctx := context.Background()
+	err := svc.CreateTeam(ctx, models.Team{TeamName: "backend", Members: []models.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: false},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handlers.New(svc)
+
+	body := `{"schemas":["urn:ietf:params:scim:schemas:core:2.0:User"],"userName":"u1","displayName":"Alice B"}`
+	req := httptest.NewRequest(http.MethodPut, "/scim/v2/Users/u1", strings.NewReader(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "u1")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	rec := httptest.NewRecorder()
+	h.SCIMReplaceUser(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ожидался 200, получили %d %s", rec.Code, rec.Body)
+	}
+
+	profile, err := svc.GetUserProfile(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.User.IsActive || profile.User.Username != "Alice B" {
+		t.Errorf("PUT без active не должен включать пользователя: %+v", profile.User)
+	}
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/service"
+)
+
+func (h *Handler) SLAStats(w http.ResponseWriter, r *http.Request) {
+	filter := models.SLAFilter{TeamName: r.URL.Query().Get("team_name")}
+	ctx, logger := logging.With(r.Context(), "team_name", filter.TeamName)
+	if v := r.URL.Query().Get("within"); v != "" {
+		var err error
+		filter.Window, err = time.ParseDuration(v)
+		if err != nil {
+			logger.Warn("invalid within", "value", v)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "within должен быть длительностью, например 24h")
+			return
+		}
+	}
+
+	stats, err := h.svc.GetSLAStats(ctx, filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidSLAWindow):
+			logger.Warn("invalid SLA window", "error", err)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		case errors.Is(err, service.ErrTeamNotFound):
+			logger.Warn("team not found")
+			apierr.Write(w, apierr.ErrTeamNotFound)
+		default:
+			internalError(w, logger, "failed to get SLA stats", err, err.Error())
+		}
+		return
+	}
+
+	respond(w, http.StatusOK, stats)
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/service"
+)
+
+func (h *Handler) PRStale(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+	page, err := parsePage(r)
+	if err != nil {
+		logger.Warn("invalid pagination", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	var olderThan time.Duration
+	if v := r.URL.Query().Get("older_than"); v != "" {
+		olderThan, err = time.ParseDuration(v)
+		if err != nil {
+			logger.Warn("invalid older_than", "value", v)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "older_than должен быть длительностью, например 48h")
+			return
+		}
+	}
+
+	prs, total, err := h.svc.ListStalePRs(r.Context(), olderThan, page)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidStaleAge) {
+			logger.Warn("invalid stale age", "error", err)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+			return
+		}
+		internalError(w, logger, "failed to list stale PRs", err, err.Error())
+		return
+	}
+
+	respond(w, http.StatusOK, map[string]interface{}{
+		"pull_requests": prs,
+		"total":         total,
+		"limit":         page.Limit,
+		"offset":        page.Offset,
+	})
+}
+
+func (h *Handler) PRBlocked(w http.ResponseWriter, r *http.Request) {
+	logger := logging.FromContext(r.Context())
+	page, err := parsePage(r)
+	if err != nil {
+		logger.Warn("invalid pagination", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	prs, total, err := h.svc.ListBlockedPRs(r.Context(), page)
+	if err != nil {
+		internalError(w, logger, "failed to list blocked PRs", err, err.Error())
+		return
+	}
+
+	respond(w, http.StatusOK, map[string]interface{}{
+		"pull_requests": prs,
+		"total":         total,
+		"limit":         page.Limit,
+		"offset":        page.Offset,
+	})
+}
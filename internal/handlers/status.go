@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/service"
+)
+
+type setPRStatusRequest struct {
+	ID              string          `json:"pull_request_id" validate:"required,max=255"`
+	Status          models.PRStatus `json:"status" validate:"required,oneof=OPEN IN_REVIEW CHANGES_REQUESTED"`
+	ExpectedVersion *int            `json:"expected_version"`
+}
+
+func (h *Handler) PRSetStatus(w http.ResponseWriter, r *http.Request) {
+	var req setPRStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "некорректный JSON")
+		return
+	}
+	if !validRequest(w, r, &req) {
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "pr_id", req.ID, "status", req.Status)
+	pr, err := h.svc.SetPullRequestStatus(ctx, req.ID, req.Status, req.ExpectedVersion)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPRNotFound):
+			logger.Warn("PR not found")
+			apierr.Write(w, apierr.ErrPRNotFound)
+		case errors.Is(err, service.ErrPRMerged):
+			logger.Warn("PR already merged")
+			apierr.Write(w, apierr.ErrPRMerged)
+		case errors.Is(err, service.ErrPRClosed):
+			logger.Warn("PR closed")
+			apierr.Write(w, apierr.ErrPRClosed)
+		case errors.Is(err, service.ErrVersionConflict):
+			logger.Warn("version conflict")
+			apierr.Write(w, apierr.ErrVersionConflict)
+		case errors.Is(err, service.ErrConflictRetry):
+			logger.Warn("concurrent modification, retry")
+			apierr.Write(w, apierr.ErrConflictRetry)
+		default:
+			internalError(w, logger, "failed to set PR status", err, err.Error())
+		}
+		return
+	}
+
+	logger.Info("PR status changed")
+	respond(w, http.StatusOK, map[string]any{"pr": prView(r, pr)})
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/service"
+)
+
+type setTagsRequest struct {
+	UserID string   `json:"user_id" validate:"required,max=255"`
+	Tags   []string `json:"tags"`
+}
+
+func (h *Handler) UsersSetTags(w http.ResponseWriter, r *http.Request) {
+	var req setTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "некорректный JSON")
+		return
+	}
+	if !validRequest(w, r, &req) {
+		return
+	}
+	if !h.authorizeUser(w, r, req.UserID) {
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "user_id", req.UserID)
+	user, err := h.svc.SetUserTags(ctx, req.UserID, req.Tags)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			logger.Warn("user not found")
+			apierr.Write(w, apierr.ErrUserNotFound)
+		case errors.Is(err, service.ErrInvalidTag):
+			logger.Warn("invalid tag", "error", err)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		default:
+			internalError(w, logger, "failed to set tags", err, err.Error())
+		}
+		return
+	}
+
+	logger.Info("tags updated", "tags", user.Tags)
+	respond(w, http.StatusOK, map[string]*models.User{"user": user})
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/service"
+)
+
+func (h *Handler) PRTimeline(w http.ResponseWriter, r *http.Request) {
+	prID := chi.URLParam(r, "id")
+	ctx, logger := logging.With(r.Context(), "pr_id", prID)
+	timeline, err := h.svc.GetPRTimeline(ctx, prID)
+	if err != nil {
+		if errors.Is(err, service.ErrPRNotFound) {
+			logger.Warn("PR not found")
+			apierr.Write(w, apierr.ErrPRNotFound)
+			return
+		}
+		internalError(w, logger, "failed to get PR timeline", err, "не удалось получить таймлайн PR")
+		return
+	}
+
+	respond(w, http.StatusOK, timeline)
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/service"
+)
+
+type addReviewerRequest struct {
+	ID string `json:"pull_request_id" validate:"required,max=255"`
+}
+
+// PRAddReviewer добирает ревьюверов PR до reviewer_count политики команды.
+func (h *Handler) PRAddReviewer(w http.ResponseWriter, r *http.Request) {
+	var req addReviewerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "некорректный JSON")
+		return
+	}
+	if !validRequest(w, r, &req) {
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "pr_id", req.ID)
+	pr, added, err := h.svc.TopUpReviewers(ctx, req.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPRNotFound):
+			logger.Warn("PR not found")
+			apierr.Write(w, apierr.ErrPRNotFound)
+		case errors.Is(err, service.ErrAuthorNotFound):
+			logger.Warn("author not found")
+			apierr.Write(w, apierr.ErrAuthorNotFound)
+		case errors.Is(err, service.ErrPRMerged):
+			logger.Warn("PR already merged")
+			apierr.Write(w, apierr.ErrPRMerged)
+		case errors.Is(err, service.ErrPRClosed):
+			logger.Warn("PR closed")
+			apierr.Write(w, apierr.ErrPRClosed)
+		case errors.Is(err, service.ErrConflictRetry):
+			logger.Warn("concurrent modification, retry")
+			apierr.Write(w, apierr.ErrConflictRetry)
+		case errors.Is(err, service.ErrAuthorIsReviewer):
+			logger.Warn("author assigned as reviewer", "error", err)
+			apierr.Write(w, apierr.ErrAuthorIsReviewer)
+		case errors.Is(err, service.ErrReviewerConflict):
+			logger.Warn("reviewer conflict", "error", err)
+			apierr.Write(w, apierr.ErrReviewerConflict)
+		default:
+			internalError(w, logger, "failed to top up reviewers", err, err.Error())
+		}
+		return
+	}
+
+	logger.Info("reviewers topped up", "added", added)
+	respond(w, http.StatusOK, map[string]interface{}{
+		"pr":    prView(r, pr),
+		"added": added,
+	})
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/service"
+)
+
+type deleteUserRequest struct {
+	UserID string `json:"user_id" validate:"required,max=255"`
+}
+
+func (h *Handler) UsersDelete(w http.ResponseWriter, r *http.Request) {
+	var req deleteUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "некорректный JSON")
+		return
+	}
+	if !validRequest(w, r, &req) {
+		return
+	}
+	if !h.authorizeUser(w, r, req.UserID) {
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "user_id", req.UserID)
+	result, err := h.svc.DeleteUser(ctx, req.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			logger.Warn("user not found")
+			apierr.Write(w, apierr.ErrUserNotFound)
+		case errors.Is(err, service.ErrReviewerConflict):
+			logger.Warn("reviewer conflict", "error", err)
+			apierr.Write(w, apierr.ErrReviewerConflict)
+		default:
+			internalError(w, logger, "failed to delete user", err, err.Error())
+		}
+		return
+	}
+
+	logger.Info("user deleted",
+		"reassignments", len(result.Reassignments),
+		"archived", result.ArchivedAssignments,
+	)
+	respond(w, http.StatusOK, map[string]interface{}{
+		"user_id":              req.UserID,
+		"reassignments":        result.Reassignments,
+		"archived_assignments": result.ArchivedAssignments,
+	})
+}
+
+func (h *Handler) UsersGet(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		logging.FromContext(r.Context()).Warn("user_id parameter missing")
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "параметр user_id обязателен")
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "user_id", userID)
+	profile, err := h.svc.GetUserProfile(ctx, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			logger.Warn("user not found")
+			apierr.Write(w, apierr.ErrUserNotFound)
+			return
+		}
+		internalError(w, logger, "failed to get user", err, "не удалось получить пользователя")
+		return
+	}
+
+	respond(w, http.StatusOK, map[string]*models.UserProfile{"user": profile})
+}
+
+func (h *Handler) UsersList(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePage(r)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("invalid pagination", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	q := r.URL.Query()
+	filter := models.UserFilter{
+		TeamName: q.Get("team_name"),
+		Query:    q.Get("q"),
+	}
+	if v := q.Get("is_active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			logging.FromContext(r.Context()).Warn("invalid is_active filter", "value", v)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "is_active должен быть true или false")
+			return
+		}
+		filter.IsActive = &active
+	}
+
+	users, total, err := h.svc.ListUsers(r.Context(), filter, page)
+	if err != nil {
+		internalError(w, logging.FromContext(r.Context()), "failed to list users", err, err.Error())
+		return
+	}
+
+	respond(w, http.StatusOK, map[string]interface{}{
+		"users":  users,
+		"total":  total,
+		"limit":  page.Limit,
+		"offset": page.Offset,
+	})
+}
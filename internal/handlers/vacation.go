@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/service"
+)
+
+type setVacationRequest struct {
+	UserID string `json:"user_id" validate:"required,max=255"`
+	Start  string `json:"start"`
+	End    string `json:"end"`
+	Reason string `json:"reason" validate:"max=1000"`
+}
+
+func (h *Handler) UsersSetVacation(w http.ResponseWriter, r *http.Request) {
+	var req setVacationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "некорректный JSON")
+		return
+	}
+	if !validRequest(w, r, &req) {
+		return
+	}
+	if !h.authorizeUser(w, r, req.UserID) {
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "user_id", req.UserID)
+	start, err := parseVacationBound(req.Start, false)
+	if err != nil {
+		logger.Warn("invalid vacation start", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+	end, err := parseVacationBound(req.End, true)
+	if err != nil {
+		logger.Warn("invalid vacation end", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	vacation, err := h.svc.SetVacation(ctx, models.Vacation{
+		UserID:   req.UserID,
+		StartsAt: start,
+		EndsAt:   end,
+		Reason:   req.Reason,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			logger.Warn("user not found")
+			apierr.Write(w, apierr.ErrUserNotFound)
+		case errors.Is(err, service.ErrInvalidVacation):
+			logger.Warn("invalid vacation", "error", err)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		default:
+			internalError(w, logger, "failed to set vacation", err, err.Error())
+		}
+		return
+	}
+
+	logger.Info("vacation set", "starts_at", vacation.StartsAt, "ends_at", vacation.EndsAt)
+	respond(w, http.StatusOK, map[string]*models.Vacation{"vacation": vacation})
+}
+
+// parseVacationBound принимает RFC 3339 или дату YYYY-MM-DD (UTC). Дата окончания
+// включительная: отпуск до 2025-01-10 длится до конца этого дня.
+func parseVacationBound(v string, end bool) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, errors.New("start и end обязательны")
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("некорректная дата %q: нужен YYYY-MM-DD или RFC 3339", v)
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+type snoozeRequest struct {
+	UserID string `json:"user_id" validate:"required,max=255"`
+	// Hours — на сколько часов приостановить назначения; 0 снимает паузу.
+	Hours *int `json:"hours" validate:"required,min=0,max=168"`
+}
+
+type snoozeResponse struct {
+	UserID       string     `json:"user_id"`
+	SnoozedUntil *time.Time `json:"snoozed_until"`
+}
+
+func (h *Handler) UsersSnooze(w http.ResponseWriter, r *http.Request) {
+	var req snoozeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to decode request body", "error", err)
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "некорректный JSON")
+		return
+	}
+	if !validRequest(w, r, &req) {
+		return
+	}
+	if !h.authorizeUser(w, r, req.UserID) {
+		return
+	}
+
+	ctx, logger := logging.With(r.Context(), "user_id", req.UserID)
+	until, err := h.svc.SnoozeUser(ctx, req.UserID, time.Duration(*req.Hours)*time.Hour)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			logger.Warn("user not found")
+			apierr.Write(w, apierr.ErrUserNotFound)
+		case errors.Is(err, service.ErrInvalidSnooze):
+			logger.Warn("invalid snooze", "error", err)
+			apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		default:
+			internalError(w, logger, "failed to snooze user", err, err.Error())
+		}
+		return
+	}
+
+	logger.Info("user snoozed", "snoozed_until", until)
+	respond(w, http.StatusOK, snoozeResponse{UserID: req.UserID, SnoozedUntil: until})
+}
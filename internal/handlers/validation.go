@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/validate"
+)
+
+// init проверяет теги validate всех запросов API: ошибка в теге роняет сервис
+// при старте, а не запрос, который первым дойдет до validRequest.
+func init() {
+	for _, op := range Operations() {
+		if op.Request == nil {
+			continue
+		}
+		if err := validate.Check(op.Request); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// validRequest проверяет тело запроса по тегам validate; при нарушениях
+// отвечает 400 VALIDATION с сообщениями по полям и возвращает false.
+func validRequest(w http.ResponseWriter, r *http.Request, req any) bool {
+	fields := validate.Struct(req)
+	if fields == nil {
+		return true
+	}
+	logging.FromContext(r.Context()).Warn("invalid request", "fields", fields)
+	apierr.Validation(w, fields)
+	return false
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/models"
+)
+
+// APIVersion — версия API, под префиксом которой (/api/v1, /api/v2) пришел
+// запрос. От нее зависят модели ответов; несовместимые изменения (переименование
+// полей, новые коды ошибок) выходят только в новой версии.
+type APIVersion int
+
+const (
+	APIv1 APIVersion = 1
+	// APIv2 отвечает моделью PR v2 (models.PRV2).
+	APIv2 APIVersion = 2
+)
+
+// APIVersions — поддерживаемые версии по возрастанию.
+var APIVersions = []APIVersion{APIv1, APIv2}
+
+// APIVersionHeader — заголовок ответа с версией API, которой он сформирован.
+const APIVersionHeader = "API-Version"
+
+// Prefix возвращает префикс маршрутов версии: /api/v1.
+func (v APIVersion) Prefix() string {
+	return "/api/" + v.String()
+}
+
+func (v APIVersion) String() string {
+	return "v" + strconv.Itoa(int(v))
+}
+
+type versionKey struct{}
+
+// WithVersion — middleware группы маршрутов версии v: обработчики отвечают
+// моделями этой версии, ответ получает заголовок API-Version.
+func WithVersion(v APIVersion) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(APIVersionHeader, v.String())
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), versionKey{}, v)))
+		})
+	}
+}
+
+// versionOf возвращает версию API запроса; маршруты без префикса — v1.
+func versionOf(r *http.Request) APIVersion {
+	if v, ok := r.Context().Value(versionKey{}).(APIVersion); ok {
+		return v
+	}
+	return APIv1
+}
+
+// UnsupportedVersion отвечает на запросы под /api/ с неизвестной версией
+// ошибкой 404 UNSUPPORTED_API_VERSION со списком поддерживаемых.
+func UnsupportedVersion(w http.ResponseWriter, _ *http.Request) {
+	supported := make([]string, len(APIVersions))
+	for i, v := range APIVersions {
+		supported[i] = v.Prefix()
+	}
+	apierr.WriteDetails(w, apierr.ErrUnsupportedVersion, map[string]any{"supported": supported})
+}
+
+// prView возвращает PR в представлении версии API запроса.
+func prView(r *http.Request, pr *models.PR) any {
+	if versionOf(r) >= APIv2 {
+		return pr.V2()
+	}
+	return pr
+}
@@ -0,0 +1,130 @@
+// Package health — пробы живости и готовности сервиса для Kubernetes. Живость не
+// зависит от внешних систем; готовность проверяет пул БД и версию схемы.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"prreviewer/internal/logging"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+
+	statusOK          = "ok"
+	statusUnavailable = "unavailable"
+)
+
+// DB — то, что нужно проверкам от пула соединений (*pgxpool.Pool).
+type DB interface {
+	Ping(ctx context.Context) error
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Component — состояние одной зависимости.
+type Component struct {
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	LatencyMS *int64 `json:"latency_ms,omitempty"`
+	Version   *uint  `json:"version,omitempty"`
+	Latest    *uint  `json:"latest,omitempty"`
+	Dirty     *bool  `json:"dirty,omitempty"`
+}
+
+// Report — ответ пробы готовности.
+type Report struct {
+	Status     string               `json:"status"`
+	Components map[string]Component `json:"components"`
+}
+
+// Checker проверяет готовность сервиса принимать запросы.
+type Checker struct {
+	db      DB
+	timeout time.Duration
+	latest  uint
+}
+
+// NewChecker создает проверку с ограничением времени timeout на все запросы к БД.
+// latest — номер последней миграции, которую ожидает код; 0 — не сравнивать.
+func NewChecker(db DB, timeout time.Duration, latest uint) *Checker {
+	return &Checker{db: db, timeout: timeout, latest: latest}
+}
+
+// Ready опрашивает БД и таблицу миграций. Сервис готов, если БД отвечает,
+// а схема не в грязном состоянии и не отстает от кода.
+func (c *Checker) Ready(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	report := Report{Status: statusOK, Components: map[string]Component{
+		"database":   c.database(ctx),
+		"migrations": c.migrations(ctx),
+	}}
+	for _, comp := range report.Components {
+		if comp.Status != StatusUp {
+			report.Status = statusUnavailable
+		}
+	}
+	return report
+}
+
+func (c *Checker) database(ctx context.Context) Component {
+	start := time.Now()
+	if err := c.db.Ping(ctx); err != nil {
+		logging.FromContext(ctx).Warn("readiness check: database ping failed", "error", err)
+		return Component{Status: StatusDown, Error: "database unreachable"}
+	}
+	latency := time.Since(start).Milliseconds()
+	return Component{Status: StatusUp, LatencyMS: &latency}
+}
+
+// migrations читает состояние из schema_migrations, которую ведет golang-migrate.
+func (c *Checker) migrations(ctx context.Context) Component {
+	var version uint
+	var dirty bool
+	err := c.db.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err != nil {
+		logging.FromContext(ctx).Warn("readiness check: failed to read schema_migrations", "error", err)
+		return Component{Status: StatusDown, Error: "migration state unavailable"}
+	}
+
+	comp := Component{Status: StatusUp, Version: &version, Dirty: &dirty}
+	switch {
+	case dirty:
+		comp.Status, comp.Error = StatusDown, "last migration failed, schema is dirty"
+	case c.latest != 0 && version < c.latest:
+		comp.Status, comp.Error = StatusDown, "pending migrations"
+	}
+	if c.latest != 0 {
+		comp.Latest = &c.latest
+	}
+	return comp
+}
+
+// Live — проба живости: процесс отвечает на запросы.
+func Live(w http.ResponseWriter, _ *http.Request) {
+	write(w, http.StatusOK, map[string]string{"status": statusOK})
+}
+
+// ServeHTTP — проба готовности: 200, если все компоненты в порядке, иначе 503.
+func (c *Checker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := c.Ready(r.Context())
+	code := http.StatusOK
+	if report.Status != statusOK {
+		code = http.StatusServiceUnavailable
+	}
+	write(w, code, report)
+}
+
+func write(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
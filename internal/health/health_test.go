@@ -0,0 +1,87 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type fakeRow struct {
+	version uint
+	dirty   bool
+	err     error
+}
+
+func (r fakeRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	*dest[0].(*uint) = r.version
+	*dest[1].(*bool) = r.dirty
+	return nil
+}
+
+type fakeDB struct {
+	pingErr error
+	row     fakeRow
+}
+
+func (db fakeDB) Ping(context.Context) error { return db.pingErr }
+
+func (db fakeDB) QueryRow(context.Context, string, ...any) pgx.Row { return db.row }
+
+func TestReady(t *testing.T) {
+	down := errors.New("connection refused")
+	tests := []struct {
+		name       string
+		db         fakeDB
+		wantCode   int
+		wantStatus map[string]string
+	}{
+		{"all up", fakeDB{row: fakeRow{version: 22}}, http.StatusOK,
+			map[string]string{"database": StatusUp, "migrations": StatusUp}},
+		{"database down", fakeDB{pingErr: down, row: fakeRow{err: down}}, http.StatusServiceUnavailable,
+			map[string]string{"database": StatusDown, "migrations": StatusDown}},
+		{"dirty schema", fakeDB{row: fakeRow{version: 22, dirty: true}}, http.StatusServiceUnavailable,
+			map[string]string{"database": StatusUp, "migrations": StatusDown}},
+		{"pending migrations", fakeDB{row: fakeRow{version: 21}}, http.StatusServiceUnavailable,
+			map[string]string{"database": StatusUp, "migrations": StatusDown}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChecker(tt.db, time.Second, 22)
+			rec := httptest.NewRecorder()
+			c.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("ожидался код %d, получили %d", tt.wantCode, rec.Code)
+			}
+			var report Report
+			if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+				t.Fatal(err)
+			}
+			for name, want := range tt.wantStatus {
+				if got := report.Components[name].Status; got != want {
+					t.Errorf("%s: ожидался статус %s, получили %s (%+v)", name, want, got, report.Components[name])
+				}
+				if strings.Contains(report.Components[name].Error, down.Error()) {
+					t.Errorf("%s: текст ошибки БД не должен попадать в ответ: %+v", name, report.Components[name])
+				}
+			}
+		})
+	}
+}
+
+func TestReadyWithoutKnownLatest(t *testing.T) {
+	c := NewChecker(fakeDB{row: fakeRow{version: 3}}, time.Second, 0)
+	if report := c.Ready(context.Background()); report.Status != statusOK {
+		t.Errorf("без известной последней миграции версия не сравнивается, получили %+v", report)
+	}
+}
@@ -0,0 +1,258 @@
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	goldap "github.com/go-ldap/ldap/v3"
+
+	"prreviewer/internal/models"
+)
+
+const (
+	defaultUserAttr = "uid"
+	defaultTimeout  = 30 * time.Second
+
+	// pageSize — размер страницы поиска (Simple Paged Results, RFC 2696): AD
+	// по умолчанию отдает не больше 1000 записей на запрос.
+	pageSize = 500
+	// maxReferralHops — глубина обхода ссылок на другие серверы каталога.
+	maxReferralHops = 3
+
+	// uacAccountDisable — флаг ACCOUNTDISABLE в userAccountControl Active Directory.
+	uacAccountDisable = 0x2
+)
+
+var (
+	ErrInvalidConfig = errors.New("invalid LDAP config")
+	ErrLDAP          = errors.New("ldap request failed")
+)
+
+// GroupMapping сопоставляет группу каталога команде сервиса.
+type GroupMapping struct {
+	TeamName string
+	GroupDN  string
+}
+
+// ParseGroups разбирает соответствие групп командам вида
+// "backend=cn=backend,ou=groups,dc=example,dc=com;frontend=cn=web,ou=groups,dc=example,dc=com".
+func ParseGroups(s string) ([]GroupMapping, error) {
+	var groups []GroupMapping
+	for _, item := range strings.Split(s, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		team, dn, ok := strings.Cut(item, "=")
+		team, dn = strings.TrimSpace(team), strings.TrimSpace(dn)
+		if !ok || team == "" || dn == "" {
+			return nil, fmt.Errorf("%w: group mapping %q, want team=group_dn", ErrInvalidConfig, item)
+		}
+		groups = append(groups, GroupMapping{TeamName: team, GroupDN: dn})
+	}
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("%w: no group mappings", ErrInvalidConfig)
+	}
+	return groups, nil
+}
+
+type Config struct {
+	// URL — ldap://host:389 или ldaps://host:636.
+	URL string
+	// StartTLS переводит соединение ldap:// в TLS до bind.
+	StartTLS     bool
+	BindDN       string
+	BindPassword string
+	// BaseDN — поддерево, в котором ищутся пользователи групп.
+	BaseDN string
+	// UserAttr — атрибут с user_id: uid (OpenLDAP) или sAMAccountName (AD).
+	UserAttr string
+	Groups   []GroupMapping
+	Timeout  time.Duration
+}
+
+// Directory читает участников групп LDAP/AD фильтром memberOf (в OpenLDAP
+// нужен оверлей memberof). Поиск идет постранично, ссылки (referrals) на
+// другие серверы обходятся с теми же учетными данными.
+type Directory struct {
+	cfg Config
+}
+
+func New(cfg Config) (*Directory, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "ldap" && u.Scheme != "ldaps") || u.Hostname() == "" {
+		return nil, fmt.Errorf("%w: url %q, want ldap:// or ldaps://", ErrInvalidConfig, cfg.URL)
+	}
+	if cfg.StartTLS && u.Scheme == "ldaps" {
+		return nil, fmt.Errorf("%w: StartTLS is not used with ldaps://", ErrInvalidConfig)
+	}
+	if cfg.BaseDN == "" {
+		return nil, fmt.Errorf("%w: base DN is required", ErrInvalidConfig)
+	}
+	if len(cfg.Groups) == 0 {
+		return nil, fmt.Errorf("%w: no group mappings", ErrInvalidConfig)
+	}
+	if cfg.UserAttr == "" {
+		cfg.UserAttr = defaultUserAttr
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	return &Directory{cfg: cfg}, nil
+}
+
+// TeamMembers реализует service.Directory: участники каждой группы — активные
+// участники ее команды, в порядке групп в конфигурации. Отключенные учетные
+// записи AD и записи без UserAttr пропускаются.
+func (d *Directory) TeamMembers(ctx context.Context) ([]models.Team, error) {
+	c, closeConn, err := d.connect(ctx, d.cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer closeConn()
+
+	teams := make([]models.Team, 0, len(d.cfg.Groups))
+	for _, g := range d.cfg.Groups {
+		entries, err := d.search(ctx, c, d.cfg.BaseDN, g.GroupDN, maxReferralHops)
+		if err != nil {
+			return nil, fmt.Errorf("group %s: %w", g.GroupDN, err)
+		}
+
+		members := make([]models.TeamMember, 0, len(entries))
+		seen := make(map[string]bool, len(entries))
+		for _, e := range entries {
+			uid := first(e, d.cfg.UserAttr)
+			if uid == "" || seen[uid] || disabled(e) {
+				continue
+			}
+			seen[uid] = true
+			name := first(e, "displayName")
+			if name == "" {
+				name = first(e, "cn")
+			}
+			if name == "" {
+				name = uid
+			}
+			members = append(members, models.TeamMember{
+				UserID:   uid,
+				Username: name,
+				Email:    first(e, "mail"),
+				IsActive: true,
+			})
+		}
+		teams = append(teams, models.Team{TeamName: g.TeamName, Members: members})
+	}
+	_ = c.Unbind()
+	return teams, nil
+}
+
+// search постранично ищет в поддереве baseDN участников группы groupDN и
+// обходит ссылки на другие серверы, пока не исчерпана глубина hops.
+func (d *Directory) search(ctx context.Context, c *goldap.Conn, baseDN, groupDN string, hops int) ([]*goldap.Entry, error) {
+	req := goldap.NewSearchRequest(
+		baseDN, goldap.ScopeWholeSubtree, goldap.NeverDerefAliases, 0, 0, false,
+		"(memberOf="+goldap.EscapeFilter(groupDN)+")",
+		[]string{d.cfg.UserAttr, "displayName", "cn", "mail", "userAccountControl"},
+		nil,
+	)
+	res, err := c.SearchWithPaging(req, pageSize)
+	if err != nil {
+		return nil, ldapError("search", err)
+	}
+
+	entries := res.Entries
+	if len(res.Referrals) > 0 && hops == 0 {
+		return nil, fmt.Errorf("%w: search: too many referral hops", ErrLDAP)
+	}
+	for _, ref := range res.Referrals {
+		more, err := d.followReferral(ctx, ref, baseDN, groupDN, hops-1)
+		if err != nil {
+			return nil, fmt.Errorf("referral %s: %w", ref, err)
+		}
+		entries = append(entries, more...)
+	}
+	return entries, nil
+}
+
+// followReferral выполняет поиск на сервере из ссылки вида
+// ldap://host:port/dn?...; без DN в ссылке ищет в прежнем baseDN.
+func (d *Directory) followReferral(ctx context.Context, ref, baseDN, groupDN string, hops int) ([]*goldap.Entry, error) {
+	u, err := url.Parse(ref)
+	if err != nil || (u.Scheme != "ldap" && u.Scheme != "ldaps") || u.Hostname() == "" {
+		return nil, fmt.Errorf("%w: unsupported referral", ErrLDAP)
+	}
+	if dn := strings.TrimPrefix(u.Path, "/"); dn != "" {
+		baseDN = dn
+	}
+	c, closeConn, err := d.connect(ctx, u.Scheme+"://"+u.Host)
+	if err != nil {
+		return nil, err
+	}
+	defer closeConn()
+
+	entries, err := d.search(ctx, c, baseDN, groupDN, hops)
+	if err != nil {
+		return nil, err
+	}
+	_ = c.Unbind()
+	return entries, nil
+}
+
+// connect открывает соединение с сервером rawURL, включает StartTLS, если он
+// задан, и выполняет simple bind. Соединение закрывается при отмене ctx или
+// вызове возвращенной функции.
+func (d *Directory) connect(ctx context.Context, rawURL string) (*goldap.Conn, func(), error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: url %q", ErrInvalidConfig, rawURL)
+	}
+	tlsConfig := &tls.Config{ServerName: u.Hostname()}
+	c, err := goldap.DialURL(rawURL,
+		goldap.DialWithDialer(&net.Dialer{Timeout: d.cfg.Timeout}),
+		goldap.DialWithTLSConfig(tlsConfig),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: dial %s: %w", ErrLDAP, u.Host, err)
+	}
+	c.SetTimeout(d.cfg.Timeout)
+	stop := context.AfterFunc(ctx, func() { _ = c.Close() })
+	closeConn := func() {
+		stop()
+		_ = c.Close()
+	}
+
+	if d.cfg.StartTLS && u.Scheme == "ldap" {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			closeConn()
+			return nil, nil, ldapError("StartTLS", err)
+		}
+	}
+	if d.cfg.BindDN != "" {
+		if err := c.Bind(d.cfg.BindDN, d.cfg.BindPassword); err != nil {
+			closeConn()
+			return nil, nil, ldapError("bind", err)
+		}
+	}
+	return c, closeConn, nil
+}
+
+// ldapError оборачивает ошибку клиента LDAP в ErrLDAP.
+func ldapError(operation string, err error) error {
+	return fmt.Errorf("%w: %s: %w", ErrLDAP, operation, err)
+}
+
+func first(e *goldap.Entry, attr string) string {
+	return strings.TrimSpace(e.GetEqualFoldAttributeValue(attr))
+}
+
+func disabled(e *goldap.Entry) bool {
+	uac, err := strconv.Atoi(first(e, "userAccountControl"))
+	return err == nil && uac&uacAccountDisable != 0
+}
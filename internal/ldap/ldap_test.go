@@ -0,0 +1,221 @@
+package ldap
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	goldap "github.com/go-ldap/ldap/v3"
+)
+
+const testGroupDN = "cn=backend,ou=groups,dc=example,dc=com"
+
+// fakeServer отвечает на bind с паролем secret и на поиск по memberOf группы
+// testGroupDN двумя страницами: alice, затем отключенная учетная запись AD bob
+// и, если задан referral, ссылка на другой сервер. Пустой referral — сервер
+// без ссылок, отдающий только carol.
+func fakeServer(t *testing.T, referral string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveFake(c, referral)
+		}
+	}()
+	return "ldap://" + ln.Addr().String()
+}
+
+func serveFake(c net.Conn, referral string) {
+	defer c.Close()
+	for {
+		msg, err := ber.ReadPacket(c)
+		if err != nil || len(msg.Children) < 2 {
+			return
+		}
+		id, _ := msg.Children[0].Value.(int64)
+		reply := func(op *ber.Packet, controls ...goldap.Control) {
+			_, _ = c.Write(envelope(id, op, controls...).Bytes())
+		}
+
+		op := msg.Children[1]
+		switch op.Tag {
+		case goldap.ApplicationBindRequest:
+			code := int64(goldap.LDAPResultSuccess)
+			if op.Children[2].Data.String() != "secret" {
+				code = goldap.LDAPResultInvalidCredentials
+			}
+			reply(result(goldap.ApplicationBindResponse, code))
+		case goldap.ApplicationSearchRequest:
+			filter := op.Children[6]
+			if filter.Children[1].Data.String() != testGroupDN {
+				reply(result(goldap.ApplicationSearchResultDone, goldap.LDAPResultSuccess))
+				continue
+			}
+			if referral == "" {
+				reply(fakeEntry("uid=carol", map[string]string{"uid": "carol", "cn": "Carol"}))
+				reply(result(goldap.ApplicationSearchResultDone, goldap.LDAPResultSuccess))
+				continue
+			}
+
+			paging := goldap.NewControlPaging(0)
+			if len(msg.Children) > 2 {
+				for _, child := range msg.Children[2].Children {
+					if ctrl, err := goldap.DecodeControl(child); err == nil {
+						if p, ok := ctrl.(*goldap.ControlPaging); ok {
+							paging.Cookie = p.Cookie
+						}
+					}
+				}
+			}
+			if len(paging.Cookie) == 0 {
+				reply(fakeEntry("uid=alice", map[string]string{"uid": "alice", "displayName": "Alice", "mail": "alice@example.com"}))
+				paging.SetCookie([]byte("page2"))
+				reply(result(goldap.ApplicationSearchResultDone, goldap.LDAPResultSuccess), paging)
+				continue
+			}
+			reply(fakeEntry("uid=bob", map[string]string{"uid": "bob", "cn": "Bob", "userAccountControl": "514"}))
+			ref := ber.Encode(ber.ClassApplication, ber.TypeConstructed, goldap.ApplicationSearchResultReference, nil, "Reference")
+			ref.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, referral, "URI"))
+			reply(ref)
+			paging.SetCookie(nil)
+			reply(result(goldap.ApplicationSearchResultDone, goldap.LDAPResultSuccess), paging)
+		default:
+			return
+		}
+	}
+}
+
+func envelope(id int64, op *ber.Packet, controls ...goldap.Control) *ber.Packet {
+	p := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "MessageID"))
+	p.AppendChild(op)
+	if len(controls) > 0 {
+		list := ber.Encode(ber.ClassContext, ber.TypeConstructed, 0, nil, "Controls")
+		for _, c := range controls {
+			list.AppendChild(c.Encode())
+		}
+		p.AppendChild(list)
+	}
+	return p
+}
+
+func result(tag ber.Tag, code int64) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, "resultCode"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "diagnosticMessage"))
+	return op
+}
+
+func fakeEntry(dn string, attrs map[string]string) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, goldap.ApplicationSearchResultEntry, nil, "Entry")
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, dn, "DN"))
+	list := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+	for name, value := range attrs {
+		attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+		vals := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		vals.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+		attr.AppendChild(vals)
+		list.AppendChild(attr)
+	}
+	op.AppendChild(list)
+	return op
+}
+
+func TestTeamMembers(t *testing.T) {
+	dir, err := New(Config{
+		URL:          fakeServer(t, fakeServer(t, "")),
+		BindDN:       "cn=sync,dc=example,dc=com",
+		BindPassword: "secret",
+		BaseDN:       "dc=example,dc=com",
+		Groups: []GroupMapping{
+			{TeamName: "backend", GroupDN: testGroupDN},
+			{TeamName: "frontend", GroupDN: "cn=web,ou=groups,dc=example,dc=com"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	teams, err := dir.TeamMembers(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(teams) != 2 || teams[0].TeamName != "backend" || teams[1].TeamName != "frontend" {
+		t.Fatalf("ожидались команды в порядке групп, получили %+v", teams)
+	}
+	if len(teams[0].Members) != 2 {
+		t.Fatalf("ожидались alice со страницы 1 и carol по ссылке, отключенный bob пропускается, получили %+v",
+			teams[0].Members)
+	}
+	m := teams[0].Members[0]
+	if m.UserID != "alice" || m.Username != "Alice" || m.Email != "alice@example.com" || !m.IsActive {
+		t.Errorf("неожиданный участник: %+v", m)
+	}
+	if m := teams[0].Members[1]; m.UserID != "carol" || m.Username != "Carol" {
+		t.Errorf("ожидался carol с сервера из ссылки, получили %+v", m)
+	}
+	if len(teams[1].Members) != 0 {
+		t.Errorf("пустая группа дает команду без участников, получили %+v", teams[1].Members)
+	}
+}
+
+func TestTeamMembersInvalidCredentials(t *testing.T) {
+	dir, err := New(Config{
+		URL:          fakeServer(t, ""),
+		BindDN:       "cn=sync,dc=example,dc=com",
+		BindPassword: "wrong",
+		BaseDN:       "dc=example,dc=com",
+		Groups:       []GroupMapping{{TeamName: "backend", GroupDN: testGroupDN}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dir.TeamMembers(context.Background()); !errors.Is(err, ErrLDAP) {
+		t.Errorf("ожидалась ErrLDAP, получили %v", err)
+	}
+}
+
+func TestNewRejectsStartTLSWithLDAPS(t *testing.T) {
+	_, err := New(Config{
+		URL:      "ldaps://ldap.example.com",
+		StartTLS: true,
+		BaseDN:   "dc=example,dc=com",
+		Groups:   []GroupMapping{{TeamName: "backend", GroupDN: testGroupDN}},
+	})
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("ожидалась ErrInvalidConfig, получили %v", err)
+	}
+}
+
+func TestParseGroups(t *testing.T) {
+	groups, err := ParseGroups("backend=cn=backend,ou=groups,dc=example,dc=com; frontend = cn=web,dc=example,dc=com;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []GroupMapping{
+		{TeamName: "backend", GroupDN: "cn=backend,ou=groups,dc=example,dc=com"},
+		{TeamName: "frontend", GroupDN: "cn=web,dc=example,dc=com"},
+	}
+	if len(groups) != len(want) || groups[0] != want[0] || groups[1] != want[1] {
+		t.Errorf("ожидалось %+v, получили %+v", want, groups)
+	}
+
+	for _, s := range []string{"", "backend", "=cn=x"} {
+		if _, err := ParseGroups(s); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("%q: ожидалась ErrInvalidConfig, получили %v", s, err)
+		}
+	}
+}
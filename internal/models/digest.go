@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Digest — ежедневная сводка ревьювера: ревью в работе, назначения с прошлой
+// сводки и ревью, срок первой реакции по SLA которых истечет до следующей.
+type Digest struct {
+	UserID      string    `json:"user_id"`
+	GeneratedAt time.Time `json:"generated_at"`
+	// Since — начало окна новых назначений: время прошлой сводки или сутки назад.
+	Since          time.Time    `json:"since"`
+	OpenReviews    []DigestItem `json:"open_reviews"`
+	NewlyAssigned  []DigestItem `json:"newly_assigned"`
+	SLAApproaching []DigestItem `json:"sla_approaching"`
+	// NextDeliveryAt — ближайшая отправка; nil, если дайджест не настроен.
+	NextDeliveryAt *time.Time `json:"next_delivery_at,omitempty"`
+}
+
+// Empty сообщает, что у пользователя нет ревью в работе и сводку не отправляют.
+func (d *Digest) Empty() bool {
+	return len(d.OpenReviews) == 0
+}
+
+// DigestItem — ревью в дайджесте.
+type DigestItem struct {
+	PRID       string    `json:"pull_request_id"`
+	PRName     string    `json:"pull_request_name"`
+	AuthorID   string    `json:"author_id"`
+	Priority   string    `json:"priority,omitempty"`
+	URL        string    `json:"url,omitempty"`
+	AssignedAt time.Time `json:"assigned_at"`
+	// SLADueAt — срок первой реакции; nil, если ревьювер уже отреагировал.
+	SLADueAt *time.Time `json:"sla_due_at,omitempty"`
+}
+
+// ReviewAssignment — назначение пользователя ревьювером открытого PR, который
+// он еще не одобрил.
+type ReviewAssignment struct {
+	PRID          string
+	PRName        string
+	AuthorID      string
+	Priority      string
+	URL           string
+	AssignedAt    time.Time
+	FirstActionAt *time.Time
+}
+
+// DigestSubscription — настройка дайджеста пользователя.
+type DigestSubscription struct {
+	UserID     string
+	DigestTime string // "HH:MM"
+	Timezone   string
+	LastSentAt *time.Time
+}
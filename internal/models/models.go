@@ -1,48 +1,417 @@
 package models
 
+import (
+	"slices"
+	"time"
+)
+
 type Team struct {
-	TeamName string       `json:"team_name"`
+	TeamName string       `json:"team_name" validate:"required,max=255"`
 	Members  []TeamMember `json:"members"`
 }
 
 type TeamMember struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
+	UserID   string `json:"user_id" validate:"required,max=255"`
+	Username string `json:"username" validate:"max=255"`
 	IsActive bool   `json:"is_active"`
+	// ReviewWeight — относительная частота назначения ревьюером; 0 при записи —
+	// оставить текущий вес (1 для нового пользователя).
+	ReviewWeight int    `json:"review_weight"`
+	ManagerID    string `json:"manager_id,omitempty"`
+	Email        string `json:"email,omitempty" validate:"max=320"`
+	// Tags — навыки пользователя ("go", "db"); nil при записи — оставить текущие.
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Vacation — окно, в которое пользователь не назначается ревьювером.
+type Vacation struct {
+	ID       int64     `json:"id"`
+	UserID   string    `json:"user_id"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Reason   string    `json:"reason,omitempty"`
+}
+
+// Candidate — активный участник команды, которого можно назначить ревьюером.
+type Candidate struct {
+	UserID         string
+	TeamName       string
+	Weight         int
+	Tags           []string
+	OpenReviews    int       // открытые PR, где он уже ревьювер; high считается за HighPriorityLoad
+	LastAssignedAt time.Time // нулевое, если ни разу не назначался
+}
+
+type TeamSummary struct {
+	TeamName      string `json:"team_name"`
+	MemberCount   int    `json:"member_count"`
+	ActiveMembers int    `json:"active_members"`
+	OpenPRs       int    `json:"open_prs"`
+}
+
+// Итог импорта одной команды через /team/import или PR через /import/github.
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportSkipped = "skipped"
+	ImportFailed  = "failed"
+)
+
+type TeamImportResult struct {
+	TeamName string `json:"team_name"`
+	Status   string `json:"status"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// RebalanceAssignment — новый участник команды, назначенный ревьювером на
+// открытый PR при /team/add с rebalance.
+type RebalanceAssignment struct {
+	PRID   string `json:"pull_request_id"`
+	UserID string `json:"user_id"`
 }
 
 type User struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
+	UserID       string   `json:"user_id"`
+	Username     string   `json:"username"`
+	TeamName     string   `json:"team_name"`
+	IsActive     bool     `json:"is_active"`
+	ReviewWeight int      `json:"review_weight"`
+	ManagerID    string   `json:"manager_id,omitempty"`
+	Email        string   `json:"email,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+}
+
+// UserProfile — пользователь с текущей нагрузкой и доступностью для GET /users/get.
+type UserProfile struct {
+	User
+	OpenReviews    int `json:"open_reviews"`
+	ApprovalsGiven int `json:"approvals_given"`
+	// AvgResponseSeconds — среднее время от назначения до первой реакции
+	// ревьювера; nil, если он еще ни разу не реагировал.
+	AvgResponseSeconds *float64 `json:"avg_response_seconds"`
+	OnVacation         bool     `json:"on_vacation"`
+	// Vacation — текущий или ближайший отпуск.
+	Vacation     *Vacation  `json:"vacation,omitempty"`
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty"`
+}
+
+// Внешние системы, с учетными записями которых связываются пользователи.
+const (
+	IdentityGitHub = "github"
+	IdentityGitLab = "gitlab"
+	IdentitySlack  = "slack"
+)
+
+// UserIdentity связывает пользователя с логином GitHub/GitLab или member ID
+// в Slack; у пользователя не больше одной учетной записи в каждой системе.
+type UserIdentity struct {
+	UserID     string `json:"user_id" validate:"required,max=255"`
+	Provider   string `json:"provider" validate:"required,oneof=github gitlab slack"`
+	ExternalID string `json:"external_id" validate:"max=255"`
+}
+
+// Изменения пользователя при синхронизации команд с каталогом LDAP/AD.
+const (
+	DirectoryUserCreated     = "created"
+	DirectoryUserMoved       = "moved"
+	DirectoryUserActivated   = "activated"
+	DirectoryUserUpdated     = "updated"
+	DirectoryUserDeactivated = "deactivated"
+)
+
+type DirectorySyncChange struct {
 	TeamName string `json:"team_name"`
-	IsActive bool   `json:"is_active"`
+	UserID   string `json:"user_id"`
+	Action   string `json:"action"`
+}
+
+// DirectorySyncReport — итог синхронизации с каталогом; при DryRun изменения
+// только рассчитаны.
+type DirectorySyncReport struct {
+	DryRun            bool                  `json:"dry_run"`
+	Changes           []DirectorySyncChange `json:"changes"`
+	ReassignedReviews int                   `json:"reassigned_reviews"`
+}
+
+// Recipient — адресат письма.
+type Recipient struct {
+	UserID string
+	Name   string
+	Email  string
+}
+
+// NotificationPreferences — настройки уведомлений пользователя.
+type NotificationPreferences struct {
+	UserID      string `json:"user_id" validate:"required,max=255"`
+	EmailOptOut bool   `json:"email_opt_out"`
+	// DigestTime — местное время ежедневного дайджеста "HH:MM"; пустое — без
+	// дайджеста, nil — оставить как есть.
+	DigestTime *string `json:"digest_time,omitempty" validate:"max=5"`
+	// Timezone — часовой пояс IANA для DigestTime, например Europe/Moscow; пустой
+	// — UTC, nil — оставить как есть.
+	Timezone *string `json:"timezone,omitempty" validate:"max=64"`
+}
+
+// PRStatus — состояние PR. OPEN, IN_REVIEW и CHANGES_REQUESTED — открытые
+// состояния: ревьюверов можно назначать и менять, PR можно смержить или
+// закрыть. MERGED и CLOSED — конечные.
+type PRStatus string
+
+const (
+	StatusOpen             PRStatus = "OPEN"
+	StatusInReview         PRStatus = "IN_REVIEW"
+	StatusChangesRequested PRStatus = "CHANGES_REQUESTED"
+	StatusMerged           PRStatus = "MERGED"
+	StatusClosed           PRStatus = "CLOSED"
+)
+
+// PRStatuses — все состояния PR в порядке жизненного цикла.
+var PRStatuses = []PRStatus{StatusOpen, StatusInReview, StatusChangesRequested, StatusMerged, StatusClosed}
+
+// Valid сообщает, что s — известное состояние.
+func (s PRStatus) Valid() bool {
+	return slices.Contains(PRStatuses, s)
+}
+
+// IsOpen сообщает, что PR в состоянии s еще не смержен и не закрыт.
+func (s PRStatus) IsOpen() bool {
+	return s == StatusOpen || s == StatusInReview || s == StatusChangesRequested
+}
+
+// StatusCounts возвращает счетчики PR по всем состояниям: отсутствующие в
+// counts состояния получают ноль.
+func StatusCounts(counts map[PRStatus]int) map[PRStatus]int {
+	out := make(map[PRStatus]int, len(PRStatuses))
+	for _, s := range PRStatuses {
+		out[s] = counts[s]
+	}
+	return out
 }
 
 type PR struct {
 	ID                string   `json:"pull_request_id"`
 	Name              string   `json:"pull_request_name"`
 	AuthorID          string   `json:"author_id"`
-	Status            string   `json:"status"`
+	Status            PRStatus `json:"status"`
 	AssignedReviewers []string `json:"assigned_reviewers"`
 	CreatedAt         *string  `json:"createdAt,omitempty"`
 	MergedAt          *string  `json:"mergedAt,omitempty"`
+	ClosedAt          *string  `json:"closedAt,omitempty"`
+	Version           int      `json:"version"`
+	ApprovedBy        []string `json:"approved_by"`
+	Repository        string   `json:"repository,omitempty"`
+	ChangedPaths      []string `json:"changed_paths,omitempty"`
+	RequiredTags      []string `json:"required_tags,omitempty"`
+	Labels            []string `json:"labels,omitempty"`
+	Priority          string   `json:"priority,omitempty"`
+	// RequiredApprovals — порог одобрений для merge этого PR; действует, если он
+	// выше порога политики команды автора; nil — по политике.
+	RequiredApprovals *int `json:"required_approvals,omitempty"`
+	// DependsOn — PR, которые нужно смержить или закрыть до merge этого.
+	DependsOn []string `json:"depends_on,omitempty"`
+	// URL, Branch и Description — ссылка на PR в GitHub/GitLab и его
+	// метаданные; сервис их только хранит и отдает.
+	URL         string `json:"url,omitempty"`
+	Branch      string `json:"branch,omitempty"`
+	Description string `json:"description,omitempty"`
+	// AssignmentReasons — почему выбран каждый новый ревьювер; заполняется
+	// только в ответах создания PR и замены ревьювера.
+	AssignmentReasons map[string]string `json:"assignment_reasons,omitempty"`
+}
+
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+)
+
+// HighPriorityLoad — сколько открытых ревью стоит PR с приоритетом high при
+// выборе наименее загруженного ревьювера.
+const HighPriorityLoad = 2
+
+// PRImportResult — итог импорта одного открытого PR из GitHub.
+type PRImportResult struct {
+	PRID              string   `json:"pull_request_id"`
+	Number            int      `json:"number"`
+	Status            string   `json:"status"`
+	Reason            string   `json:"reason,omitempty"`
+	AssignedReviewers []string `json:"assigned_reviewers,omitempty"`
+}
+
+// ArchivedPR — смерженный PR, перенесенный задачей архивации в pull_requests_archive.
+type ArchivedPR struct {
+	PR
+	ArchivedAt string `json:"archivedAt"`
+}
+
+// OwnershipRule закрепляет файлы репозитория, подходящие под glob-шаблон,
+// за пользователем или командой (ровно одно из UserID/TeamName).
+type OwnershipRule struct {
+	ID         int64  `json:"id,omitempty"`
+	Repository string `json:"repository" validate:"max=255"`
+	Pattern    string `json:"pattern" validate:"required,max=500"`
+	UserID     string `json:"user_id,omitempty"`
+	TeamName   string `json:"team_name,omitempty"`
+}
+
+// StalePR — открытый PR без одобрений, ожидающий ревью дольше порога.
+type StalePR struct {
+	ID                string     `json:"pull_request_id"`
+	Name              string     `json:"pull_request_name"`
+	AuthorID          string     `json:"author_id"`
+	URL               string     `json:"url,omitempty"`
+	AssignedReviewers []string   `json:"assigned_reviewers"`
+	CreatedAt         time.Time  `json:"created_at"`
+	RemindedAt        *time.Time `json:"reminded_at,omitempty"`
+}
+
+// BlockedPR — открытый PR, merge которого ждет открытых зависимостей BlockedBy.
+type BlockedPR struct {
+	ID        string   `json:"pull_request_id"`
+	Name      string   `json:"pull_request_name"`
+	AuthorID  string   `json:"author_id"`
+	Status    PRStatus `json:"status"`
+	URL       string   `json:"url,omitempty"`
+	BlockedBy []string `json:"blocked_by"`
+}
+
+// StaleFilter — PR созданы до CreatedBefore; RemindedBefore != nil исключает PR,
+// о которых уже напомнили позже этого момента. Для PR с приоритетом high
+// вместо них действуют HighCreatedBefore и HighRemindedBefore.
+type StaleFilter struct {
+	CreatedBefore      time.Time
+	RemindedBefore     *time.Time
+	HighCreatedBefore  time.Time
+	HighRemindedBefore *time.Time
+}
+
+// OverdueAssignment — назначение, на которое ревьювер не отреагировал вовремя.
+type OverdueAssignment struct {
+	PRID       string
+	UserID     string
+	AssignedAt time.Time
 }
 
 type PRShort struct {
-	ID       string `json:"pull_request_id"`
-	Name     string `json:"pull_request_name"`
-	AuthorID string `json:"author_id"`
-	Status   string `json:"status"`
+	ID       string   `json:"pull_request_id"`
+	Name     string   `json:"pull_request_name"`
+	AuthorID string   `json:"author_id"`
+	Status   PRStatus `json:"status"`
+	Priority string   `json:"priority,omitempty"`
+	Labels   []string `json:"labels,omitempty"`
+	URL      string   `json:"url,omitempty"`
 }
 
 type Stats struct {
-	TotalTeams        int               `json:"total_teams"`
-	TotalUsers        int               `json:"total_users"`
-	TotalPRs          int               `json:"total_prs"`
+	TotalTeams int `json:"total_teams"`
+	TotalUsers int `json:"total_users"`
+	TotalPRs   int `json:"total_prs"`
+	// OpenPRs — PR во всех открытых состояниях, PRsByStatus — по каждому
+	// состоянию, включая нулевые.
 	OpenPRs           int               `json:"open_prs"`
 	MergedPRs         int               `json:"merged_prs"`
+	ClosedPRs         int               `json:"closed_prs"`
+	PRsByStatus       map[PRStatus]int  `json:"prs_by_status"`
 	AssignmentsByUser []UserAssignments `json:"assignments_by_user"`
 	ReviewersByPR     []PRReviewerCount `json:"reviewers_by_pr"`
+	// Полное число строк в списках до применения limit/offset.
+	AssignmentsByUserTotal int `json:"assignments_by_user_total"`
+	ReviewersByPRTotal     int `json:"reviewers_by_pr_total"`
+	// TimeToMerge — от создания PR до merge, AssignmentToMerge — от назначения
+	// ревьювера до merge по каждому ревьюверу смерженных PR.
+	TimeToMerge       DurationStats `json:"time_to_merge"`
+	AssignmentToMerge DurationStats `json:"assignment_to_merge"`
+}
+
+// DurationStats — распределение длительностей в секундах; nil, если данных нет.
+type DurationStats struct {
+	AvgSeconds    *float64 `json:"avg_seconds"`
+	MedianSeconds *float64 `json:"median_seconds"`
+	P90Seconds    *float64 `json:"p90_seconds"`
+}
+
+// TeamStats — нагрузка на ревью в команде для GET /stats/team. PR команды —
+// PR, авторы которых сейчас в ней состоят.
+type TeamStats struct {
+	TeamName    string           `json:"team_name"`
+	OpenPRs     int              `json:"open_prs"`
+	MergedPRs   int              `json:"merged_prs"`
+	PRsByStatus map[PRStatus]int `json:"prs_by_status"`
+	// AvgTimeToMergeSeconds — среднее время от создания до merge; nil, если смерженных PR нет.
+	AvgTimeToMergeSeconds *float64                `json:"avg_time_to_merge_seconds"`
+	AssignmentsByMember   []TeamMemberAssignments `json:"assignments_by_member"`
+	// Assignments — первичные назначения на PR команды, Reassignments — замены
+	// и отказы; ReassignmentRate = Reassignments / Assignments.
+	Assignments      int     `json:"assignments"`
+	Reassignments    int     `json:"reassignments"`
+	ReassignmentRate float64 `json:"reassignment_rate"`
+}
+
+// SLAFilter — параметры GET /stats/sla: окно реакции ревьювера и, если задана,
+// команда ревьюверов.
+type SLAFilter struct {
+	Window   time.Duration
+	TeamName string
+}
+
+// SLAStats — соблюдение SLA реакции на ревью: доля назначений, по которым
+// ревьювер одобрил PR или отказался от ревью не позже чем через окно.
+type SLAStats struct {
+	WindowSeconds int64           `json:"window_seconds"`
+	Overall       SLACompliance   `json:"overall"`
+	Teams         []SLACompliance `json:"teams"`
+	Users         []SLACompliance `json:"users"`
+}
+
+// SLACompliance — SLA команды или пользователя. Due — назначения, по которым
+// ревьювер уже отреагировал или окно истекло; Responded — отреагировавшие из них.
+type SLACompliance struct {
+	TeamName  string `json:"team_name,omitempty"`
+	UserID    string `json:"user_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	Due       int    `json:"due_reviews"`
+	Responded int    `json:"responded"`
+	WithinSLA int    `json:"within_sla"`
+	// CompliancePercent — WithinSLA / Due в процентах; nil, если Due = 0.
+	CompliancePercent  *float64 `json:"compliance_percent"`
+	AvgResponseSeconds *float64 `json:"avg_response_seconds"`
+}
+
+// DBStats — статистика использования таблиц и индексов из pg_stat_user_tables
+// и pg_stat_user_indexes для GET /admin/dbstats.
+type DBStats struct {
+	Tables  []TableStats `json:"tables"`
+	Indexes []IndexStats `json:"indexes"`
+}
+
+// TableStats — счетчики чтения таблицы. Много последовательно прочитанных
+// строк большой таблицы (SeqTupRead) указывает на недостающий индекс.
+type TableStats struct {
+	Table      string `json:"table"`
+	SeqScan    int64  `json:"seq_scan"`
+	SeqTupRead int64  `json:"seq_tup_read"`
+	IdxScan    int64  `json:"idx_scan"`
+	LiveTuples int64  `json:"live_tuples"`
+	DeadTuples int64  `json:"dead_tuples"`
+}
+
+// IndexStats — использование индекса; Scans = 0 после нагрузки — индекс не нужен
+// планировщику.
+type IndexStats struct {
+	Table      string `json:"table"`
+	Index      string `json:"index"`
+	Scans      int64  `json:"scans"`
+	TuplesRead int64  `json:"tuples_read"`
+	SizeBytes  int64  `json:"size_bytes"`
+}
+
+type TeamMemberAssignments struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	IsActive bool   `json:"is_active"`
+	// Total — все текущие назначения участника, Open — на открытых PR.
+	Total int `json:"total_assignments"`
+	Open  int `json:"open_assignments"`
 }
 
 type UserAssignments struct {
@@ -56,3 +425,234 @@ type PRReviewerCount struct {
 	PRName        string `json:"pull_request_name"`
 	ReviewerCount int    `json:"reviewer_count"`
 }
+
+type TeamAutoMerge struct {
+	TeamName          string `json:"team_name" validate:"required,max=255"`
+	Enabled           bool   `json:"enabled"`
+	Provider          string `json:"provider"`
+	Repository        string `json:"repository"`
+	RequiredApprovals int    `json:"required_approvals"`
+}
+
+type TeamPolicy struct {
+	TeamName         string `json:"team_name" validate:"required,max=255"`
+	RequireApprovals bool   `json:"require_approvals"`
+	// RequiredApprovals — сколько одобрений нужно для merge; nil — все назначенные ревьюверы.
+	RequiredApprovals *int `json:"required_approvals,omitempty"`
+
+	// Настройки назначения при создании PR; nil/пустое при записи — оставить текущее.
+	ReviewerCount      *int   `json:"reviewer_count,omitempty"`
+	AssignmentStrategy string `json:"assignment_strategy,omitempty"`
+	CrossTeamFallback  *bool  `json:"cross_team_fallback,omitempty"`
+	RequireManager     *bool  `json:"require_manager,omitempty"`
+	// MandatoryReviewer — участник команды, которого назначают на каждый PR сверх
+	// reviewer_count; пустая строка снимает его.
+	MandatoryReviewer *string `json:"mandatory_reviewer,omitempty"`
+	// AutoReassign — заменять ревьюверов команды, не отреагировавших на
+	// назначение дольше AUTO_REASSIGN_AFTER.
+	AutoReassign *bool `json:"auto_reassign,omitempty"`
+}
+
+// Стратегии выбора ревьюверов.
+const (
+	StrategyRandom      = "random"
+	StrategyRoundRobin  = "round_robin"
+	StrategyLeastLoaded = "least_loaded"
+)
+
+// DefaultReviewerCount — число ревьюверов без политики команды.
+const DefaultReviewerCount = 2
+
+const (
+	EventAssigned   = "ASSIGNED"
+	EventReassigned = "REASSIGNED"
+	EventDeclined   = "DECLINED"
+	EventReleased   = "RELEASED"
+	EventUnassigned = "UNASSIGNED"
+)
+
+// AssignmentEvent — запись истории назначений ревьюверов PR.
+type AssignmentEvent struct {
+	ID        int64  `json:"id"`
+	PRID      string `json:"pull_request_id"`
+	EventType string `json:"event_type"`
+	UserID    string `json:"user_id,omitempty"`
+	NewUserID string `json:"new_user_id,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// EventCursor — позиция выгрузки событий назначения: (created_at, id)
+// последнего выданного события. Курсор не зависит от того, осталась ли эта
+// строка в таблице.
+type EventCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// Шаги таймлайна PR сверх событий назначения (EventAssigned и другие).
+const (
+	TimelineCreated  = "CREATED"
+	TimelineApproved = "APPROVED"
+	TimelineMerged   = "MERGED"
+	TimelineClosed   = "CLOSED"
+)
+
+// TimelineEntry — шаг жизненного цикла PR. Actor — кто выполнил действие,
+// пусто для автоматических назначений и анонимных запросов.
+type TimelineEntry struct {
+	Type      string `json:"type"`
+	Actor     string `json:"actor,omitempty"`
+	UserID    string `json:"user_id,omitempty"`
+	NewUserID string `json:"new_user_id,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	At        string `json:"at"`
+}
+
+// PRTimeline — жизненный цикл PR по порядку для GET /pullRequest/{id}/timeline.
+type PRTimeline struct {
+	PRID     string          `json:"pull_request_id"`
+	Status   PRStatus        `json:"status"`
+	Archived bool            `json:"archived"`
+	Timeline []TimelineEntry `json:"timeline"`
+}
+
+// ApprovalStatus — выполнение политики одобрений команды автора PR.
+// Без политики или с выключенным require_approvals Required = false.
+type ApprovalStatus struct {
+	Required      bool `json:"required"`
+	RequiredCount int  `json:"required_count"`
+	Approved      int  `json:"approved"`
+	Satisfied     bool `json:"satisfied"`
+}
+
+// PRDetails — PR со статусом одобрений и историей назначений для GET /pullRequest/get.
+type PRDetails struct {
+	PR
+	Approval ApprovalStatus    `json:"approval"`
+	History  []AssignmentEvent `json:"history"`
+}
+
+// Типы доменных событий, которые репозиторий пишет в outbox.
+const (
+	DomainPRCreated        = "pr.created"
+	DomainReviewerAssigned = "pr.reviewer_assigned"
+	DomainPRMerged         = "pr.merged"
+	DomainPRClosed         = "pr.closed"
+	DomainPRStatusChanged  = "pr.status_changed"
+	DomainTeamCreated      = "team.created"
+	DomainTeamDeactivated  = "team.deactivated"
+	DomainTeamRenamed      = "team.renamed"
+	DomainUserDeleted      = "user.deleted"
+)
+
+// DomainEvent — запись outbox, публикуемая релеем во внешние каналы.
+type DomainEvent struct {
+	ID       int64  `json:"-"`
+	Type     string `json:"type"`
+	PRID     string `json:"pull_request_id,omitempty"`
+	TeamName string `json:"team_name,omitempty"`
+	UserID   string `json:"user_id,omitempty"`
+	// Recipients — пользователи, которым адресовано событие.
+	Recipients []string       `json:"recipients,omitempty"`
+	Details    map[string]any `json:"details,omitempty"`
+	CreatedAt  time.Time      `json:"-"`
+	Attempts   int            `json:"-"`
+}
+
+// Действия журнала аудита.
+const (
+	AuditTeamCreated         = "team.created"
+	AuditTeamImported        = "team.imported"
+	AuditTeamUpdated         = "team.updated"
+	AuditTeamRenamed         = "team.renamed"
+	AuditTeamDeactivated     = "team.deactivated"
+	AuditTeamPolicyChanged   = "team.policy_changed"
+	AuditAutoMergeChanged    = "team.auto_merge_changed"
+	AuditUserActivated       = "user.activated"
+	AuditUserDeactivated     = "user.deactivated"
+	AuditUserProvisioned     = "user.provisioned"
+	AuditDirectorySynced     = "directory.synced"
+	AuditGitHubImported      = "github.imported"
+	AuditUserDeleted         = "user.deleted"
+	AuditVacationSet         = "user.vacation_set"
+	AuditVacationEnded       = "user.vacation_ended"
+	AuditUserSnoozed         = "user.snoozed"
+	AuditUserTagsChanged     = "user.tags_changed"
+	AuditUserIdentityChanged = "user.identity_changed"
+	AuditNotificationsSet    = "user.notifications_changed"
+	AuditOwnershipChanged    = "ownership.rules_changed"
+	AuditPRCreated           = "pr.created"
+	AuditPRApproved          = "pr.approved"
+	AuditPRMerged            = "pr.merged"
+	AuditPRClosed            = "pr.closed"
+	AuditPRStatusChanged     = "pr.status_changed"
+	AuditReviewerAssigned    = "pr.reviewer_assigned"
+	AuditReviewerReassigned  = "pr.reviewer_reassigned"
+	AuditReviewerUnassigned  = "pr.reviewer_unassigned"
+	AuditReviewDeclined      = "pr.review_declined"
+)
+
+// AuditEntry — запись append-only журнала изменений состояния.
+type AuditEntry struct {
+	ID        int64          `json:"id"`
+	Action    string         `json:"action"`
+	Actor     string         `json:"actor,omitempty"`
+	TeamName  string         `json:"team_name,omitempty"`
+	UserID    string         `json:"user_id,omitempty"`
+	PRID      string         `json:"pull_request_id,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+	CreatedAt string         `json:"created_at"`
+}
+
+// StatsFilter ограничивает статистику полуинтервалом [From, To): счетчики и
+// рейтинги — по времени создания PR, длительности до merge — по времени merge.
+type StatsFilter struct {
+	From *time.Time
+	To   *time.Time
+}
+
+// AuditFilter — фильтры /audit; пустое поле или nil — без фильтра.
+type AuditFilter struct {
+	UserID   string
+	TeamName string
+	PRID     string
+	Action   string
+	From     *time.Time
+	To       *time.Time
+}
+
+// ReviewerChange описывает замену ревьювера на PR и событие, которое нужно записать.
+type ReviewerChange struct {
+	PRID          string
+	OldReviewerID string
+	NewReviewerID string
+	EventType     string
+	Reason        string
+}
+
+const (
+	DefaultPageLimit = 100
+	MaxPageLimit     = 1000
+)
+
+// Page — параметры постраничной выдачи списков.
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// UserFilter — фильтры /users/list; пустое поле или nil — без фильтра.
+type UserFilter struct {
+	TeamName string
+	IsActive *bool
+	Query    string // подстрока username без учета регистра
+}
+
+// ReviewFilter — фильтры списка ревью пользователя; пустое поле — без фильтра.
+type ReviewFilter struct {
+	Status   PRStatus
+	TeamName string
+	Label    string
+	Priority string
+}
@@ -0,0 +1,97 @@
+package models
+
+import "time"
+
+// PRV2 — PR в ответах API v2 (маршруты с префиксом /v2): все поля в
+// snake_case, моменты времени — RFC 3339. В v1 (PR) имена createdAt/mergedAt/
+// closedAt сохранены для существующих клиентов.
+type PRV2 struct {
+	ID                string            `json:"pull_request_id"`
+	Name              string            `json:"pull_request_name"`
+	AuthorID          string            `json:"author_id"`
+	Status            PRStatus          `json:"status"`
+	AssignedReviewers []string          `json:"assigned_reviewers"`
+	CreatedAt         *time.Time        `json:"created_at,omitempty"`
+	MergedAt          *time.Time        `json:"merged_at,omitempty"`
+	ClosedAt          *time.Time        `json:"closed_at,omitempty"`
+	Version           int               `json:"version"`
+	ApprovedBy        []string          `json:"approved_by"`
+	Repository        string            `json:"repository,omitempty"`
+	ChangedPaths      []string          `json:"changed_paths,omitempty"`
+	RequiredTags      []string          `json:"required_tags,omitempty"`
+	Labels            []string          `json:"labels,omitempty"`
+	Priority          string            `json:"priority,omitempty"`
+	RequiredApprovals *int              `json:"required_approvals,omitempty"`
+	DependsOn         []string          `json:"depends_on,omitempty"`
+	URL               string            `json:"url,omitempty"`
+	Branch            string            `json:"branch,omitempty"`
+	Description       string            `json:"description,omitempty"`
+	AssignmentReasons map[string]string `json:"assignment_reasons,omitempty"`
+}
+
+// PRDetailsV2 — PRDetails в ответе GET /v2/pullRequest/get.
+type PRDetailsV2 struct {
+	PRV2
+	Approval ApprovalStatus    `json:"approval"`
+	History  []AssignmentEvent `json:"history"`
+}
+
+// ArchivedPRV2 — ArchivedPR в ответе GET /v2/pullRequest/archived.
+type ArchivedPRV2 struct {
+	PRV2
+	ArchivedAt time.Time `json:"archived_at"`
+}
+
+// V2 переводит PR в представление API v2.
+func (pr *PR) V2() *PRV2 {
+	return &PRV2{
+		ID:                pr.ID,
+		Name:              pr.Name,
+		AuthorID:          pr.AuthorID,
+		Status:            pr.Status,
+		AssignedReviewers: pr.AssignedReviewers,
+		CreatedAt:         parseTime(pr.CreatedAt),
+		MergedAt:          parseTime(pr.MergedAt),
+		ClosedAt:          parseTime(pr.ClosedAt),
+		Version:           pr.Version,
+		ApprovedBy:        pr.ApprovedBy,
+		Repository:        pr.Repository,
+		ChangedPaths:      pr.ChangedPaths,
+		RequiredTags:      pr.RequiredTags,
+		Labels:            pr.Labels,
+		Priority:          pr.Priority,
+		RequiredApprovals: pr.RequiredApprovals,
+		DependsOn:         pr.DependsOn,
+		URL:               pr.URL,
+		Branch:            pr.Branch,
+		Description:       pr.Description,
+		AssignmentReasons: pr.AssignmentReasons,
+	}
+}
+
+// V2 переводит PRDetails в представление API v2.
+func (pr *PRDetails) V2() *PRDetailsV2 {
+	return &PRDetailsV2{PRV2: *pr.PR.V2(), Approval: pr.Approval, History: pr.History}
+}
+
+// V2 переводит ArchivedPR в представление API v2.
+func (pr *ArchivedPR) V2() *ArchivedPRV2 {
+	out := &ArchivedPRV2{PRV2: *pr.PR.V2()}
+	if t := parseTime(&pr.ArchivedAt); t != nil {
+		out.ArchivedAt = *t
+	}
+	return out
+}
+
+// parseTime разбирает момент в формате RFC 3339, в котором репозиторий
+// заполняет поля PR; nil и нераспознанная строка дают nil.
+func parseTime(s *string) *time.Time {
+	if s == nil {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, *s)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
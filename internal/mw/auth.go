@@ -0,0 +1,66 @@
+package mw
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/auth"
+	"prreviewer/internal/logging"
+)
+
+// Authenticate требует заголовок Authorization: Bearer <JWT> и кладет claims
+// токена в контекст. С nil-верификатором пропускает запросы без проверки.
+func Authenticate(v *auth.Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if v == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" {
+				apierr.Write(w, apierr.ErrUnauthorized)
+				return
+			}
+			serveAuthenticated(w, r, next, v, token)
+		})
+	}
+}
+
+// AuthenticateOptional проверяет токен, только если он передан. Запрос без
+// токена получает пустые claims: проверки прав администратора ему отказывают,
+// а аудит пишет его действия без исполнителя. С nil-верификатором, как и
+// Authenticate, пропускает запросы без проверки.
+func AuthenticateOptional(v *auth.Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if v == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" {
+				next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), &auth.Claims{})))
+				return
+			}
+			serveAuthenticated(w, r, next, v, token)
+		})
+	}
+}
+
+func serveAuthenticated(w http.ResponseWriter, r *http.Request, next http.Handler, v *auth.Verifier, token string) {
+	claims, err := v.Verify(strings.TrimSpace(token))
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("token rejected", "error", err)
+		if errors.Is(err, auth.ErrTokenExpired) {
+			apierr.JSON(w, apierr.ErrUnauthorized.Status, apierr.ErrUnauthorized.Code, "token expired")
+			return
+		}
+		apierr.Write(w, apierr.ErrUnauthorized)
+		return
+	}
+
+	ctx := auth.NewContext(r.Context(), claims)
+	ctx, _ = logging.With(ctx, "subject", claims.Subject)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
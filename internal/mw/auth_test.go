@@ -0,0 +1,45 @@
+package mw_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"prreviewer/internal/auth"
+	"prreviewer/internal/mw"
+)
+
+func TestAuthenticateOptional(t *testing.T) {
+	v := auth.NewVerifier([]byte("secret"))
+	var claims *auth.Claims
+	h := mw.AuthenticateOptional(v)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ = auth.FromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+	do := func(token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/pullRequest/merge", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := do(""); code != http.StatusOK || claims == nil || claims.Admin || claims.Subject != "" {
+		t.Errorf("запрос без токена: ожидались 200 и пустые claims, получили %d %+v", code, claims)
+	}
+
+	token, err := v.Sign(auth.Claims{Subject: "lead", Admin: true, ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := do(token); code != http.StatusOK || claims == nil || !claims.Admin || claims.Subject != "lead" {
+		t.Errorf("запрос с токеном: ожидались 200 и claims токена, получили %d %+v", code, claims)
+	}
+
+	if code := do("invalid"); code != http.StatusUnauthorized {
+		t.Errorf("неверный токен: ожидался 401, получили %d", code)
+	}
+}
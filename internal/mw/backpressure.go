@@ -0,0 +1,80 @@
+package mw
+
+import (
+	"expvar"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+)
+
+// DefaultShedRoutes — маршруты низкого приоритета, которые первыми
+// отклоняются при насыщенном пуле: тяжелая статистика.
+var DefaultShedRoutes = []string{"GET /stats", "GET /stats/team", "GET /stats/sla"}
+
+var shedRequests = expvar.NewMap("backpressure_rejected")
+
+// PoolStats сообщает число занятых соединений пула и его размер.
+type PoolStats func() (acquired, size int32)
+
+// Backpressure — настройки сброса нагрузки по занятости пула соединений с БД.
+type Backpressure struct {
+	Stats PoolStats
+	// Threshold — доля занятых соединений (0, 1], начиная с которой пул
+	// считается насыщенным.
+	Threshold float64
+	// RetryAfter — значение заголовка Retry-After в отказах.
+	RetryAfter time.Duration
+	// Routes — маршруты низкого приоритета по ключу "METHOD /path" без префикса версии.
+	Routes map[string]bool
+}
+
+func (b Backpressure) saturated() bool {
+	acquired, size := b.Stats()
+	return size > 0 && float64(acquired) >= math.Ceil(b.Threshold*float64(size))
+}
+
+// ShedLoad отвечает 503 OVERLOADED с Retry-After на запросы к маршрутам
+// низкого приоритета, пока пул соединений насыщен: такие запросы не встают
+// в очередь за соединением и не отнимают его у остальных. Без Stats,
+// маршрутов или с нулевым порогом ничего не ограничивает.
+func ShedLoad(b Backpressure) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if b.Stats == nil || len(b.Routes) == 0 || b.Threshold <= 0 {
+			return next
+		}
+		retry := max(int(math.Ceil(b.RetryAfter.Seconds())), 1)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := routeKey(r)
+			if !b.Routes[key] || !b.saturated() {
+				next.ServeHTTP(w, r)
+				return
+			}
+			shedRequests.Add(key, 1)
+			logging.FromContext(r.Context()).Warn("request shed, database pool saturated", "retry_after", retry)
+			w.Header().Set("Retry-After", strconv.Itoa(retry))
+			apierr.WriteDetails(w, apierr.ErrOverloaded, map[string]any{"retry_after": retry})
+		})
+	}
+}
+
+// ParseShedRoutes разбирает список маршрутов вида "GET /stats;GET /export/assignments".
+func ParseShedRoutes(s string) (map[string]bool, error) {
+	result := map[string]bool{}
+	for _, entry := range strings.Split(s, ";") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 || !strings.HasPrefix(fields[1], "/") {
+			return nil, fmt.Errorf("invalid entry %q, expected \"METHOD /path\"", strings.TrimSpace(entry))
+		}
+		result[strings.ToUpper(fields[0])+" "+fields[1]] = true
+	}
+	return result, nil
+}
@@ -0,0 +1,67 @@
+package mw_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/mw"
+)
+
+func TestShedLoad(t *testing.T) {
+	var acquired int32
+	routes, err := mw.ParseShedRoutes("get /stats; GET /stats/team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := mw.ShedLoad(mw.Backpressure{
+		Stats:      func() (int32, int32) { return acquired, 10 },
+		Threshold:  0.8,
+		RetryAfter: 1500 * time.Millisecond,
+		Routes:     routes,
+	})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	acquired = 7
+	if rec := do(http.MethodGet, "/stats"); rec.Code != http.StatusOK {
+		t.Fatalf("пул не насыщен, ожидался 200, получили %d", rec.Code)
+	}
+
+	acquired = 8
+	rec := do(http.MethodGet, "/stats")
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "2" {
+		t.Fatalf("ожидался 503 с Retry-After: 2, получили %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	var resp apierr.ErrResp
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error.Code != "OVERLOADED" || resp.Error.Details["retry_after"] != float64(2) {
+		t.Errorf("неверное тело ответа: %+v", resp.Error)
+	}
+
+	if rec := do(http.MethodGet, "/pullRequest/get"); rec.Code != http.StatusOK {
+		t.Errorf("маршрут обычного приоритета не отклоняется, получили %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/stats"); rec.Code != http.StatusOK {
+		t.Errorf("маршрут сравнивается вместе с методом, получили %d", rec.Code)
+	}
+}
+
+func TestParseShedRoutes(t *testing.T) {
+	for _, s := range []string{"/stats", "GET stats", "GET /stats extra"} {
+		if _, err := mw.ParseShedRoutes(s); err == nil {
+			t.Errorf("%q: ожидалась ошибка", s)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package mw
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+)
+
+// DefaultMaxBodySize — предел тела запроса для маршрутов без своего лимита.
+const DefaultMaxBodySize = 1 << 20
+
+// BodyLimits — предельные размеры тел запросов в байтах: Default для всех
+// маршрутов и переопределения по ключу "METHOD /path" без префикса версии.
+type BodyLimits struct {
+	Default int64
+	Routes  map[string]int64
+}
+
+func (l BodyLimits) limit(r *http.Request) int64 {
+	if n, ok := l.Routes[routeKey(r)]; ok {
+		return n
+	}
+	return l.Default
+}
+
+// LimitBody отвечает 413 PAYLOAD_TOO_LARGE на тела больше лимита маршрута.
+// Тело с известным Content-Length отклоняется сразу; иначе чтение обрывается
+// на лимите, и ответ обработчика (обычно 400 о некорректном теле) заменяется
+// на 413. Лимит 0 не ограничивает маршрут.
+func LimitBody(limits BodyLimits) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := limits.limit(r)
+			if limit <= 0 || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if r.ContentLength > limit {
+				rejectBody(w, r, limit)
+				return
+			}
+
+			body := &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, limit)}
+			r.Body = body
+			next.ServeHTTP(&bodyLimitWriter{ResponseWriter: w, r: r, body: body, limit: limit}, r)
+		})
+	}
+}
+
+func rejectBody(w http.ResponseWriter, r *http.Request, limit int64) {
+	logging.FromContext(r.Context()).Warn("request body too large",
+		"limit", limit, "content_length", r.ContentLength)
+	apierr.WriteDetails(w, apierr.ErrBodyTooLarge, map[string]any{"limit_bytes": limit})
+}
+
+// limitedBody запоминает, что чтение уперлось в лимит.
+type limitedBody struct {
+	io.ReadCloser
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		b.exceeded = true
+	}
+	return n, err
+}
+
+// bodyLimitWriter подменяет ответ обработчика на 413, если тело было обрезано.
+type bodyLimitWriter struct {
+	http.ResponseWriter
+	r     *http.Request
+	body  *limitedBody
+	limit int64
+
+	wroteHeader bool
+	rejected    bool
+}
+
+func (w *bodyLimitWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	if code >= http.StatusOK {
+		w.wroteHeader = true
+	}
+	if w.body.exceeded && code >= http.StatusBadRequest {
+		w.rejected = true
+		rejectBody(w.ResponseWriter, w.r, w.limit)
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *bodyLimitWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.rejected {
+		return len(p), nil
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *bodyLimitWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// ParseBodyLimits разбирает переопределения вида
+// "POST /team/import=10MB;POST /team/add=256KB". Размер — число байт
+// с необязательным суффиксом KB или MB (по 1024).
+func ParseBodyLimits(s string) (map[string]int64, error) {
+	result := map[string]int64{}
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, size, ok := strings.Cut(entry, "=")
+		fields := strings.Fields(route)
+		if !ok || len(fields) != 2 {
+			return nil, fmt.Errorf("invalid entry %q, expected \"METHOD /path=size\"", entry)
+		}
+		n, err := ParseSize(size)
+		if err != nil {
+			return nil, fmt.Errorf("invalid size for %q: %w", route, err)
+		}
+		result[strings.ToUpper(fields[0])+" "+fields[1]] = n
+	}
+	return result, nil
+}
+
+// ParseSize разбирает размер в байтах с необязательным суффиксом KB или MB.
+func ParseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	mult := int64(1)
+	if v, ok := strings.CutSuffix(s, "KB"); ok {
+		s, mult = v, 1<<10
+	} else if v, ok := strings.CutSuffix(s, "MB"); ok {
+		s, mult = v, 1<<20
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("size must be a non-negative number of bytes, KB or MB")
+	}
+	return n * mult, nil
+}
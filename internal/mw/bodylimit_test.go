@@ -0,0 +1,94 @@
+package mw_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/mw"
+)
+
+// decodeHandler ведет себя как обработчики: при ошибке разбора тела — 400.
+var decodeHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	var v any
+	if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+		apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "некорректный JSON")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+})
+
+func TestLimitBody(t *testing.T) {
+	h := mw.LimitBody(mw.BodyLimits{
+		Default: 32,
+		Routes:  map[string]int64{"POST /team/import": 1024, "POST /unlimited": 0},
+	})(decodeHandler)
+
+	large := `{"team_name":"` + strings.Repeat("a", 100) + `"}`
+	tests := []struct {
+		name          string
+		path          string
+		body          string
+		contentLength bool
+		want          int
+	}{
+		{"within default", "/team/add", `{"team_name":"a"}`, true, http.StatusOK},
+		{"content-length over default", "/team/add", large, true, http.StatusRequestEntityTooLarge},
+		{"chunked over default", "/team/add", large, false, http.StatusRequestEntityTooLarge},
+		{"route override", "/team/import", large, true, http.StatusOK},
+		{"route override in API version", "/api/v2/team/import", large, true, http.StatusOK},
+		{"not a version prefix", "/api/vx/team/import", large, true, http.StatusRequestEntityTooLarge},
+		{"unlimited route", "/unlimited", large, false, http.StatusOK},
+		{"bad JSON within limit", "/team/add", `{`, false, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader = strings.NewReader(tt.body)
+			if !tt.contentLength {
+				body = io.MultiReader(body) // скрывает длину, как chunked-запрос
+			}
+			req := httptest.NewRequest(http.MethodPost, tt.path, body)
+			if !tt.contentLength {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("ожидался статус %d, получили %d: %s", tt.want, rec.Code, rec.Body)
+			}
+			if tt.want == http.StatusRequestEntityTooLarge {
+				var resp apierr.ErrResp
+				if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+					t.Fatal(err)
+				}
+				if resp.Error.Code != "PAYLOAD_TOO_LARGE" || resp.Error.Details["limit_bytes"] == nil {
+					t.Errorf("ожидался код PAYLOAD_TOO_LARGE с limit_bytes, получили %+v", resp.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestParseBodyLimits(t *testing.T) {
+	got, err := mw.ParseBodyLimits("post /team/import=10MB; POST /team/add=256kb;GET /stats=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int64{"POST /team/import": 10 << 20, "POST /team/add": 256 << 10, "GET /stats": 0}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: ожидался лимит %d, получили %d", k, v, got[k])
+		}
+	}
+
+	for _, s := range []string{"/team/add=1KB", "POST /team/add", "POST /team/add=-1", "POST /team/add=1GB"} {
+		if _, err := mw.ParseBodyLimits(s); err == nil {
+			t.Errorf("%q: ожидалась ошибка", s)
+		}
+	}
+}
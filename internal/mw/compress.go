@@ -0,0 +1,258 @@
+package mw
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// DefaultCompressMinSize — ответы короче не сжимаются: заголовки и кадр gzip
+// съедают выигрыш.
+const DefaultCompressMinSize = 1024
+
+// DefaultCompressTypes — типы содержимого, которые сжимаются по умолчанию.
+var DefaultCompressTypes = []string{"application/json", "text/html", "text/plain"}
+
+// EncoderWriter — потоковый кодировщик, который можно переиспользовать через Reset.
+type EncoderWriter interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// Encoder — алгоритм сжатия для Content-Encoding.
+type Encoder struct {
+	Name string
+	New  func(level int) (EncoderWriter, error)
+}
+
+// Encoders — встроенные алгоритмы сжатия. Уровни br (0–11) шире, чем у gzip,
+// но общий диапазон 1–9 дает сопоставимый выигрыш и цену.
+var Encoders = map[string]Encoder{
+	"br": {Name: "br", New: func(level int) (EncoderWriter, error) {
+		if level < 0 {
+			level = brotli.DefaultCompression
+		}
+		return brotli.NewWriterLevel(io.Discard, level), nil
+	}},
+	"gzip": {Name: "gzip", New: func(level int) (EncoderWriter, error) {
+		return gzip.NewWriterLevel(io.Discard, level)
+	}},
+	"deflate": {Name: "deflate", New: func(level int) (EncoderWriter, error) {
+		return zlib.NewWriterLevel(io.Discard, level)
+	}},
+}
+
+// CompressConfig — настройки сжатия ответов.
+type CompressConfig struct {
+	Encoders     []Encoder // в порядке предпочтения сервера
+	Level        int       // уровень сжатия; 0 — уровень кодировщика по умолчанию
+	MinSize      int       // минимальный размер сжимаемого ответа в байтах
+	ContentTypes []string  // media type без параметров, "text/*" — любой подтип
+}
+
+// Compressor сжимает ответы алгоритмом, который клиент принимает в Accept-Encoding.
+type Compressor struct {
+	cfg   CompressConfig
+	pools map[string]*sync.Pool
+}
+
+// NewCompressor проверяет, что каждый кодировщик создается с уровнем cfg.Level.
+func NewCompressor(cfg CompressConfig) (*Compressor, error) {
+	if len(cfg.Encoders) == 0 {
+		return nil, fmt.Errorf("no encoders configured")
+	}
+	if cfg.Level == 0 {
+		cfg.Level = -1 // DefaultCompression у gzip и zlib
+	}
+	c := &Compressor{cfg: cfg, pools: make(map[string]*sync.Pool, len(cfg.Encoders))}
+	for _, enc := range cfg.Encoders {
+		if _, err := enc.New(cfg.Level); err != nil {
+			return nil, fmt.Errorf("%s: %w", enc.Name, err)
+		}
+		c.pools[enc.Name] = &sync.Pool{New: func() any {
+			w, _ := enc.New(cfg.Level)
+			return w
+		}}
+	}
+	return c, nil
+}
+
+// Compress сжимает ответы подходящего типа не короче MinSize. Решение
+// принимается по первым MinSize байтам тела, поэтому короткие ответы уходят
+// как есть. nil Compressor отключает сжатие.
+func Compress(c *Compressor) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if c == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			enc, ok := c.negotiate(r.Header.Get("Accept-Encoding"))
+			if !ok || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Без defer: при панике заголовки еще не отправлены, и ответ 500
+			// пишет middleware.Recoverer.
+			cw := &compressWriter{ResponseWriter: w, c: c, enc: enc, status: http.StatusOK}
+			next.ServeHTTP(cw, r)
+			cw.close()
+		})
+	}
+}
+
+// negotiate выбирает первый по предпочтению сервера кодировщик с ненулевым q.
+func (c *Compressor) negotiate(header string) (Encoder, bool) {
+	accepted := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		accepted[strings.ToLower(name)] = q
+	}
+	for _, enc := range c.cfg.Encoders {
+		q, ok := accepted[enc.Name]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+		if ok && q > 0 {
+			return enc, true
+		}
+	}
+	return Encoder{}, false
+}
+
+func (c *Compressor) compressible(h http.Header) bool {
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	for _, t := range c.cfg.ContentTypes {
+		if t == mediaType || strings.HasSuffix(t, "/*") && strings.HasPrefix(mediaType, t[:len(t)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// compressWriter копит начало тела, пока не станет ясно, сжимать ли ответ.
+type compressWriter struct {
+	http.ResponseWriter
+	c      *Compressor
+	enc    Encoder
+	status int
+	buf    []byte
+
+	decided bool
+	ew      EncoderWriter // не nil, если ответ сжимается
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided {
+		return
+	}
+	if code < http.StatusOK {
+		w.ResponseWriter.WriteHeader(code) // информационный ответ, основной еще впереди
+		return
+	}
+	w.status = code
+	if code == http.StatusNoContent || code == http.StatusNotModified {
+		_ = w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		if !w.c.compressible(w.Header()) {
+			if err := w.decide(false); err != nil {
+				return 0, err
+			}
+		} else {
+			w.buf = append(w.buf, p...)
+			if len(w.buf) < w.c.cfg.MinSize {
+				return len(p), nil
+			}
+			if err := w.decide(true); err != nil {
+				return 0, err
+			}
+			return len(p), nil
+		}
+	}
+	if w.ew != nil {
+		return w.ew.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush отправляет накопленное: потоковый ответ подходящего типа сжимается
+// независимо от размера.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(w.c.compressible(w.Header()))
+	}
+	if w.ew != nil {
+		_ = w.ew.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide отправляет заголовки и накопленное тело, сжатое или как есть.
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+	if compress {
+		h := w.Header()
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", w.enc.Name)
+		w.ew = w.c.pools[w.enc.Name].Get().(EncoderWriter)
+		w.ew.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(w.buf) == 0 {
+		return nil
+	}
+	buf := w.buf
+	w.buf = nil
+	if w.ew != nil {
+		_, err := w.ew.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+func (w *compressWriter) close() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if w.ew != nil {
+		_ = w.ew.Close()
+		w.ew.Reset(io.Discard)
+		w.c.pools[w.enc.Name].Put(w.ew)
+	}
+}
@@ -0,0 +1,134 @@
+package mw_test
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+
+	"prreviewer/internal/mw"
+)
+
+func newCompressHandler(t *testing.T, contentType, body string) http.Handler {
+	t.Helper()
+	c, err := mw.NewCompressor(mw.CompressConfig{
+		Encoders:     []mw.Encoder{mw.Encoders["gzip"], mw.Encoders["deflate"]},
+		MinSize:      64,
+		ContentTypes: []string{"application/json", "text/*"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mw.Compress(c)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		// Тело пишется частями, как encoding/json с большим ответом.
+		for chunk := range strings.SplitSeq(body, " ") {
+			_, _ = io.WriteString(w, chunk+" ")
+		}
+	}))
+}
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat(`{"user_id":"u1"} `, 20)
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		acceptEncoding string
+		wantEncoding   string
+	}{
+		{"gzip", "application/json; charset=utf-8", large, "gzip, deflate, br", "gzip"},
+		{"server preference over client order", "application/json", large, "deflate, gzip", "gzip"},
+		{"q=0 disables gzip", "application/json", large, "gzip;q=0, deflate", "deflate"},
+		{"wildcard", "text/plain", large, "*", "gzip"},
+		{"small body", "application/json", `{"ok":true}`, "gzip", ""},
+		{"content type not allowed", "image/png", large, "gzip", ""},
+		{"no accept-encoding", "application/json", large, "", ""},
+		{"only unsupported", "application/json", large, "br", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+			newCompressHandler(t, tt.contentType, tt.body).ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("ожидался Content-Encoding %q, получили %q", tt.wantEncoding, got)
+			}
+			if rec.Header().Get("Vary") != "Accept-Encoding" {
+				t.Errorf("ожидался Vary: Accept-Encoding, получили %q", rec.Header().Get("Vary"))
+			}
+			var body io.Reader = rec.Body
+			if tt.wantEncoding == "gzip" {
+				zr, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = zr
+			}
+			if tt.wantEncoding == "deflate" {
+				return
+			}
+			got, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := tt.body + " "; string(got) != want {
+				t.Errorf("тело после распаковки не совпадает: %q", got)
+			}
+		})
+	}
+}
+
+func TestCompressBrotli(t *testing.T) {
+	c, err := mw.NewCompressor(mw.CompressConfig{
+		Encoders:     []mw.Encoder{mw.Encoders["br"], mw.Encoders["gzip"]},
+		MinSize:      64,
+		ContentTypes: []string{"application/json"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := strings.Repeat(`{"user_id":"u1"}`, 20)
+	h := mw.Compress(c)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, body)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	req.Header.Set("Accept-Encoding", "gzip, br")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Content-Encoding"); got != "br" {
+		t.Fatalf("ожидался Content-Encoding br, получили %q", got)
+	}
+	got, err := io.ReadAll(brotli.NewReader(rec.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != body {
+		t.Errorf("тело после распаковки не совпадает: %q", got)
+	}
+}
+
+func TestCompressDisabled(t *testing.T) {
+	h := mw.Compress(nil)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, strings.Repeat("x", 4096))
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Header().Get("Content-Encoding") != "" || rec.Header().Get("Vary") != "" {
+		t.Errorf("без Compressor ответ не должен меняться: %v", rec.Header())
+	}
+}
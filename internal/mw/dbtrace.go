@@ -0,0 +1,90 @@
+package mw
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type dbTimingsKey struct{}
+
+type queryStartKey struct{}
+
+// queryStart — начало запроса к БД, переданное из TraceQueryStart в TraceQueryEnd.
+type queryStart struct {
+	sql string
+	at  time.Time
+}
+
+// dbTimings собирает время запросов к БД одного HTTP-запроса. Запросы
+// сервиса могут выполняться параллельно, поэтому доступ под мьютексом.
+type dbTimings struct {
+	mu      sync.Mutex
+	queries int
+	total   time.Duration
+	top     []QueryTiming
+}
+
+func withDBTimings(ctx context.Context, t *dbTimings) context.Context {
+	return context.WithValue(ctx, dbTimingsKey{}, t)
+}
+
+func (t *dbTimings) record(sql string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.queries++
+	t.total += d
+	q := QueryTiming{SQL: compactSQL(sql), DurationMS: millis(d)}
+	if len(t.top) < slowTopQueries {
+		t.top = append(t.top, q)
+	} else if q.DurationMS > t.top[len(t.top)-1].DurationMS {
+		t.top[len(t.top)-1] = q
+	} else {
+		return
+	}
+	slices.SortStableFunc(t.top, func(a, b QueryTiming) int { return cmp.Compare(b.DurationMS, a.DurationMS) })
+}
+
+// summary возвращает число запросов, их суммарное время и самые долгие из них.
+func (t *dbTimings) summary() (int, float64, []QueryTiming) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.queries, millis(t.total), slices.Clone(t.top)
+}
+
+// compactSQL схлопывает пробелы и переводы строк и обрезает длинный текст.
+func compactSQL(sql string) string {
+	s := strings.Join(strings.Fields(sql), " ")
+	if len(s) > maxQueryText {
+		s = s[:maxQueryText] + "…"
+	}
+	return s
+}
+
+// QueryTracer — pgx.QueryTracer, записывающий время запросов к БД в контекст
+// HTTP-запроса для SlowRequests. Вне HTTP-запроса ничего не делает.
+type QueryTracer struct{}
+
+func (QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if ctx.Value(dbTimingsKey{}) == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, queryStartKey{}, queryStart{sql: data.SQL, at: time.Now()})
+}
+
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryEndData) {
+	t, ok := ctx.Value(dbTimingsKey{}).(*dbTimings)
+	if !ok {
+		return
+	}
+	start, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	t.record(start.sql, time.Since(start.at))
+}
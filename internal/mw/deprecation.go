@@ -0,0 +1,160 @@
+package mw
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"prreviewer/internal/logging"
+)
+
+const dateLayout = "2006-01-02"
+
+var deprecatedHits = expvar.NewMap("deprecated_route_hits")
+
+// DeprecationPolicy описывает выводимый из эксплуатации маршрут.
+type DeprecationPolicy struct {
+	Since     time.Time // дата объявления устаревшим (заголовок Deprecation)
+	Sunset    time.Time // дата отключения (заголовок Sunset)
+	Successor string    // ссылка на замену (Link rel="successor-version")
+}
+
+// Deprecations — политики по ключу "METHOD /path" без префикса версии.
+type Deprecations map[string]DeprecationPolicy
+
+// merge дополняет политику p незаданными в ней полями политики other.
+func (p DeprecationPolicy) merge(other DeprecationPolicy) DeprecationPolicy {
+	if p.Since.IsZero() {
+		p.Since = other.Since
+	}
+	if p.Sunset.IsZero() {
+		p.Sunset = other.Sunset
+	}
+	if p.Successor == "" {
+		p.Successor = other.Successor
+	}
+	return p
+}
+
+type deprecationKey struct{}
+
+// Deprecation выставляет заголовки Deprecation/Sunset/Link и логирует обращения
+// к устаревшим маршрутам, чтобы отследить оставшихся потребителей. Маршрут
+// ищется по routeKey, то есть во всех версиях API.
+func Deprecation(policies Deprecations) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(policies) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if p, ok := policies[routeKey(r)]; ok {
+				markDeprecated(w, r, p)
+				r = r.WithContext(context.WithValue(r.Context(), deprecationKey{}, p))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Deprecated — вариант для навешивания на отдельный маршрут через chi With.
+// Если маршрут уже отмечен Deprecation, политики объединяются (заданные в
+// Deprecation поля главнее), а обращение повторно не логируется.
+func Deprecated(p DeprecationPolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			deprecate(w, r, p)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// DeprecatedAlias — Deprecated для группы маршрутов-псевдонимов: ссылка на
+// замену строится по пути запроса с префиксом successorPrefix, например
+// /team/add -> /api/v1/team/add. Successor из p не используется.
+func DeprecatedAlias(p DeprecationPolicy, successorPrefix string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p := p
+			p.Successor = successorPrefix + r.URL.Path
+			deprecate(w, r, p)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// deprecate отмечает запрос политикой p маршрута или, если Deprecation уже
+// отметил его, переписывает заголовки объединенной политикой.
+func deprecate(w http.ResponseWriter, r *http.Request, p DeprecationPolicy) {
+	if route, ok := r.Context().Value(deprecationKey{}).(DeprecationPolicy); ok {
+		writeDeprecationHeaders(w.Header(), route.merge(p))
+		return
+	}
+	markDeprecated(w, r, p)
+}
+
+func markDeprecated(w http.ResponseWriter, r *http.Request, p DeprecationPolicy) {
+	writeDeprecationHeaders(w.Header(), p)
+	deprecatedHits.Add(routeKey(r), 1)
+	logging.FromContext(r.Context()).Warn("deprecated route called",
+		"remote_addr", r.RemoteAddr,
+		"user_agent", r.UserAgent(),
+	)
+}
+
+func writeDeprecationHeaders(h http.Header, p DeprecationPolicy) {
+	if p.Since.IsZero() {
+		h.Set("Deprecation", "true")
+	} else {
+		h.Set("Deprecation", "@"+strconv.FormatInt(p.Since.Unix(), 10))
+	}
+	if !p.Sunset.IsZero() {
+		h.Set("Sunset", p.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if p.Successor != "" {
+		h.Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", p.Successor))
+	}
+}
+
+// ParseDeprecations разбирает конфигурацию вида
+// "POST /team/deactivate|2027-01-01|/api/v2/team/deactivate;GET /stats|2027-03-01".
+// Второе поле — дата Sunset, третье (необязательное) — ссылка на замену.
+// Префикс версии в пути отбрасывается, как в routeKey.
+func ParseDeprecations(s string) (Deprecations, error) {
+	result := Deprecations{}
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, "|")
+		route := strings.TrimSpace(parts[0])
+		if len(strings.Fields(route)) != 2 {
+			return nil, fmt.Errorf("invalid route %q, expected \"METHOD /path\"", route)
+		}
+
+		var p DeprecationPolicy
+		if len(parts) > 1 && strings.TrimSpace(parts[1]) != "" {
+			sunset, err := time.Parse(dateLayout, strings.TrimSpace(parts[1]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid sunset date for %q: %w", route, err)
+			}
+			p.Sunset = sunset
+		}
+		if len(parts) > 2 {
+			p.Successor = strings.TrimSpace(parts[2])
+		}
+		if len(parts) > 3 {
+			return nil, errors.New("too many fields in deprecation entry " + strconv.Quote(entry))
+		}
+
+		method, path := strings.Fields(route)[0], strings.Fields(route)[1]
+		result[strings.ToUpper(method)+" "+unversioned(path)] = p
+	}
+	return result, nil
+}
@@ -0,0 +1,105 @@
+package mw_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"prreviewer/internal/mw"
+)
+
+func TestParseDeprecations(t *testing.T) {
+	d, err := mw.ParseDeprecations("post /team/deactivate|2027-01-01|/api/v2/team/deactivate; GET /stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, ok := d["POST /team/deactivate"]
+	if !ok {
+		t.Fatalf("ожидалась политика для POST /team/deactivate, получили %v", d)
+	}
+	if p.Sunset.Format("2006-01-02") != "2027-01-01" || p.Successor != "/api/v2/team/deactivate" {
+		t.Errorf("неверно разобрана политика: %+v", p)
+	}
+	if _, ok := d["GET /stats"]; !ok {
+		t.Errorf("ожидалась политика для GET /stats")
+	}
+
+	if _, err := mw.ParseDeprecations("/stats|2027-01-01"); err == nil {
+		t.Errorf("ожидалась ошибка для маршрута без метода")
+	}
+}
+
+func TestDeprecationHeaders(t *testing.T) {
+	d, err := mw.ParseDeprecations("GET /stats|2027-01-01|/api/v2/stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := mw.Deprecation(d)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats", nil))
+
+	if rec.Header().Get("Deprecation") == "" {
+		t.Errorf("нет заголовка Deprecation")
+	}
+	if rec.Header().Get("Sunset") != "Fri, 01 Jan 2027 00:00:00 GMT" {
+		t.Errorf("неверный Sunset: %q", rec.Header().Get("Sunset"))
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/team/get", nil))
+	if rec.Header().Get("Deprecation") != "" {
+		t.Errorf("заголовок Deprecation не должен выставляться для актуальных маршрутов")
+	}
+}
+
+func TestDeprecatedAliasLinksVersionedPath(t *testing.T) {
+	h := mw.DeprecatedAlias(mw.DeprecationPolicy{}, "/api/v1")(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/team/get?team_name=backend", nil))
+
+	if rec.Header().Get("Deprecation") != "true" {
+		t.Errorf("ожидался Deprecation: true, получили %q", rec.Header().Get("Deprecation"))
+	}
+	if rec.Header().Get("Sunset") != "" {
+		t.Errorf("без даты отключения Sunset не выставляется, получили %q", rec.Header().Get("Sunset"))
+	}
+	if link := rec.Header().Get("Link"); link != `</api/v1/team/get>; rel="successor-version"` {
+		t.Errorf("неверная ссылка на замену: %q", link)
+	}
+}
+
+// TestDeprecationMergesWithAlias — маршрут из DEPRECATED_ROUTES находится во
+// всех версиях API, а на пути-псевдониме политики объединяются без повторных
+// заголовков.
+func TestDeprecationMergesWithAlias(t *testing.T) {
+	d, err := mw.ParseDeprecations("GET /stats|2027-01-01|/api/v2/stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	mw.Deprecation(d)(ok).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/stats", nil))
+	if rec.Header().Get("Sunset") != "Fri, 01 Jan 2027 00:00:00 GMT" {
+		t.Errorf("версионированный путь должен получить политику маршрута, Sunset %q", rec.Header().Get("Sunset"))
+	}
+
+	h := mw.Deprecation(d)(mw.DeprecatedAlias(mw.DeprecationPolicy{}, "/api/v1")(ok))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	if links := rec.Header().Values("Link"); len(links) != 1 || links[0] != `</api/v2/stats>; rel="successor-version"` {
+		t.Errorf("ожидалась одна ссылка из политики маршрута, получили %q", links)
+	}
+	if rec.Header().Get("Sunset") != "Fri, 01 Jan 2027 00:00:00 GMT" {
+		t.Errorf("Sunset политики маршрута должен сохраниться, получили %q", rec.Header().Get("Sunset"))
+	}
+}
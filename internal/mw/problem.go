@@ -0,0 +1,21 @@
+package mw
+
+import (
+	"net/http"
+
+	"prreviewer/internal/apierr"
+)
+
+// ProblemJSON переключает ответы об ошибках на application/problem+json
+// (RFC 7807), если клиент просит его в Accept; без него остается обычный
+// формат {"error": {...}}. Ставится раньше middleware, которые сами отвечают
+// ошибками.
+func ProblemJSON(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+		if apierr.AcceptsProblem(r) {
+			w = apierr.WithProblemFormat(w)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,67 @@
+package mw_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/mw"
+)
+
+func TestProblemJSON(t *testing.T) {
+	// Ошибку пишет обработчик за оберткой chi, как за RequestLogger и Compress.
+	h := mw.ProblemJSON(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		ww.Header().Set(middleware.RequestIDHeader, "req-1")
+		apierr.NotApproved(ww, "not enough approvals", []string{"u2"})
+	}))
+
+	tests := []struct {
+		accept      string
+		wantProblem bool
+	}{
+		{"", false},
+		{"application/json", false},
+		{"application/problem+json", true},
+		{"application/json;q=0.5, application/problem+json", true},
+		{"application/problem+json;q=0", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/pullRequest/merge", nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusConflict {
+			t.Fatalf("%q: ожидался 409, получили %d", tt.accept, rec.Code)
+		}
+		if !tt.wantProblem {
+			var resp apierr.ErrResp
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Header().Get("Content-Type") != "application/json" || resp.Error.Code != "NOT_APPROVED" {
+				t.Errorf("%q: ожидался обычный формат ошибки, получили %s %+v", tt.accept, rec.Header().Get("Content-Type"), resp)
+			}
+			continue
+		}
+
+		var p apierr.Problem
+		if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Header().Get("Content-Type") != apierr.ProblemContentType {
+			t.Errorf("%q: ожидался %s, получили %q", tt.accept, apierr.ProblemContentType, rec.Header().Get("Content-Type"))
+		}
+		if p.Type != "urn:prreviewer:error:NOT_APPROVED" || p.Title != "Conflict" || p.Status != http.StatusConflict ||
+			p.Detail != "not enough approvals" || p.RequestID != "req-1" || len(p.MissingApprovers) != 1 {
+			t.Errorf("%q: неожиданная проблема %+v", tt.accept, p)
+		}
+	}
+}
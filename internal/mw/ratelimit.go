@@ -0,0 +1,127 @@
+package mw
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/auth"
+	"prreviewer/internal/logging"
+)
+
+const (
+	// idleBucketTTL — через сколько неиспользуемый bucket клиента удаляется.
+	idleBucketTTL = 10 * time.Minute
+	// maxBuckets — число клиентов, после которого bucket'ы чистятся, не
+	// дожидаясь idleBucketTTL.
+	maxBuckets = 10000
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter — token bucket на клиента: rps токенов в секунду, не больше burst.
+type RateLimiter struct {
+	rps   float64
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		rps:     rps,
+		burst:   float64(max(burst, 1)),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow списывает токен клиента key. Если токенов нет, возвращает время
+// до появления следующего.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rps)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / l.rps * float64(time.Second))
+	return false, wait
+}
+
+// sweep удаляет bucket'ы клиентов, простаивающих дольше idleBucketTTL или
+// успевших восполнить burst: такой bucket не отличается от нового. Обычно
+// чистка идет раз в idleBucketTTL, а при maxBuckets клиентов — не чаще раза в секунду.
+func (l *RateLimiter) sweep(now time.Time) {
+	since := now.Sub(l.lastSweep)
+	if since < idleBucketTTL && (len(l.buckets) < maxBuckets || since < time.Second) {
+		return
+	}
+	refill := time.Duration(l.burst / l.rps * float64(time.Second))
+	for k, b := range l.buckets {
+		if idle := now.Sub(b.last); idle > idleBucketTTL || idle >= refill {
+			delete(l.buckets, k)
+		}
+	}
+	l.lastSweep = now
+}
+
+// RateLimit отвечает 429 с Retry-After, когда клиент исчерпал бюджет. Клиент
+// определяется по subject проверенного JWT, иначе по IP: непроверенные
+// заголовки не дают нового бюджета. С nil-лимитером ничего не ограничивает.
+func RateLimit(l *RateLimiter, v *auth.Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if l == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := clientKey(r, v)
+			ok, wait := l.Allow(key)
+			if !ok {
+				retry := int(math.Ceil(wait.Seconds()))
+				logging.FromContext(r.Context()).Warn("rate limit exceeded", "client", key, "retry_after", retry)
+				w.Header().Set("Retry-After", strconv.Itoa(retry))
+				apierr.WriteDetails(w, apierr.ErrRateLimited, map[string]any{"retry_after": retry})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func clientKey(r *http.Request, v *auth.Verifier) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && v != nil {
+		if claims, err := v.Verify(strings.TrimSpace(token)); err == nil && claims.Subject != "" {
+			return "sub:" + claims.Subject
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
@@ -0,0 +1,57 @@
+package mw_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"prreviewer/internal/auth"
+	"prreviewer/internal/mw"
+)
+
+func TestRateLimitPerClient(t *testing.T) {
+	v := auth.NewVerifier([]byte("secret"))
+	h := mw.RateLimit(mw.NewRateLimiter(1, 2), v)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	token, err := v.Sign(auth.Claims{Subject: "team-bot", ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	do := func(remote, bearer string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/pullRequest/create", nil)
+		req.RemoteAddr = remote
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < 2; i++ {
+		if rec := do("10.0.0.1:1000", ""); rec.Code != http.StatusOK {
+			t.Fatalf("запрос %d в пределах burst: ожидался 200, получили %d", i, rec.Code)
+		}
+	}
+
+	rec := do("10.0.0.1:2000", "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("ожидался 429, получили %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "1" {
+		t.Errorf("ожидался Retry-After: 1, получили %q", rec.Header().Get("Retry-After"))
+	}
+
+	if rec := do("10.0.0.2:1000", ""); rec.Code != http.StatusOK {
+		t.Errorf("другой IP имеет свой бюджет: ожидался 200, получили %d", rec.Code)
+	}
+	if rec := do("10.0.0.1:1000", "forged"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("непроверенный токен не дает нового бюджета: ожидался 429, получили %d", rec.Code)
+	}
+	if rec := do("10.0.0.1:1000", token); rec.Code != http.StatusOK {
+		t.Errorf("subject JWT имеет свой бюджет: ожидался 200, получили %d", rec.Code)
+	}
+}
@@ -0,0 +1,65 @@
+package mw
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+)
+
+// ReadOnlyRetryAfter — значение заголовка Retry-After в отказах режима только
+// для чтения: режим включают на минуты, поэтому клиентам незачем ретраить чаще.
+const ReadOnlyRetryAfter = 30 * time.Second
+
+// ReadOnlySwitch — переключатель режима только для чтения, безопасный для
+// одновременного использования.
+type ReadOnlySwitch struct {
+	on atomic.Bool
+}
+
+func NewReadOnlySwitch(on bool) *ReadOnlySwitch {
+	s := &ReadOnlySwitch{}
+	s.on.Store(on)
+	return s
+}
+
+func (s *ReadOnlySwitch) Set(on bool) {
+	s.on.Store(on)
+}
+
+func (s *ReadOnlySwitch) Enabled() bool {
+	return s.on.Load()
+}
+
+// ReadOnly отвечает 503 READ_ONLY с Retry-After на изменяющие запросы (все
+// методы, кроме GET, HEAD и OPTIONS), пока режим включен; чтение продолжает
+// работать.
+func ReadOnly(s *ReadOnlySwitch) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !s.Enabled() || safeMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			logging.FromContext(r.Context()).Warn("write rejected in read-only mode")
+			WriteReadOnly(w)
+		})
+	}
+}
+
+// WriteReadOnly отвечает 503 READ_ONLY с Retry-After.
+func WriteReadOnly(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(ReadOnlyRetryAfter.Seconds())))
+	apierr.Write(w, apierr.ErrReadOnly)
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package mw_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"prreviewer/internal/mw"
+)
+
+func TestReadOnly(t *testing.T) {
+	s := mw.NewReadOnlySwitch(false)
+	h := mw.ReadOnly(s)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	do := func(method string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, "/pullRequest/create", nil))
+		return rec
+	}
+
+	if rec := do(http.MethodPost); rec.Code != http.StatusOK {
+		t.Errorf("выключенный режим не должен мешать записи, получили %d", rec.Code)
+	}
+
+	s.Set(true)
+	rec := do(http.MethodPost)
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "READ_ONLY") {
+		t.Errorf("ожидался 503 READ_ONLY, получили %d %s", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Retry-After") != "30" {
+		t.Errorf("ожидался Retry-After: 30, получили %q", rec.Header().Get("Retry-After"))
+	}
+	if rec := do(http.MethodGet); rec.Code != http.StatusOK {
+		t.Errorf("чтение в режиме только для чтения должно работать, получили %d", rec.Code)
+	}
+
+	s.Set(false)
+	if rec := do(http.MethodPost); rec.Code != http.StatusOK {
+		t.Errorf("после выключения режима запись должна работать, получили %d", rec.Code)
+	}
+}
@@ -0,0 +1,40 @@
+package mw
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"prreviewer/internal/logging"
+)
+
+// RequestLogger кладет в контекст логгер с request_id и маршрутом и пишет
+// JSON-строку о каждом завершенном запросе. Ставится после middleware.RequestID.
+func RequestLogger(base *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			reqID := middleware.GetReqID(r.Context())
+			if reqID != "" {
+				w.Header().Set(middleware.RequestIDHeader, reqID)
+			}
+
+			l := base.With(
+				"request_id", reqID,
+				"method", r.Method,
+				"route", r.URL.Path,
+			)
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(logging.NewContext(r.Context(), l)))
+
+			l.Info("request completed",
+				"status", ww.Status(),
+				"bytes", ww.BytesWritten(),
+				"duration_ms", time.Since(start).Milliseconds(),
+				"remote_addr", r.RemoteAddr,
+			)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package mw_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/mw"
+)
+
+func TestRequestLoggerPropagatesRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	base := logging.New(&buf, slog.LevelInfo)
+
+	h := middleware.RequestID(mw.RequestLogger(base)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, l := logging.With(r.Context(), "pr_id", "pr-1")
+		l.Info("handled")
+		w.WriteHeader(http.StatusCreated)
+	})))
+
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/create", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-42")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(middleware.RequestIDHeader); got != "req-42" {
+		t.Errorf("ожидался заголовок %s=req-42, получили %q", middleware.RequestIDHeader, got)
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("ожидалось 2 строки лога, получили %d: %s", len(lines), buf.String())
+	}
+
+	var handled, completed map[string]interface{}
+	if err := json.Unmarshal(lines[0], &handled); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(lines[1], &completed); err != nil {
+		t.Fatal(err)
+	}
+
+	if handled["request_id"] != "req-42" || handled["pr_id"] != "pr-1" || handled["route"] != "/pullRequest/create" {
+		t.Errorf("неверные поля строки обработчика: %v", handled)
+	}
+	if completed["request_id"] != "req-42" || completed["status"] != float64(http.StatusCreated) {
+		t.Errorf("неверные поля итоговой строки: %v", completed)
+	}
+}
+
+func TestRequestIDInErrorResponse(t *testing.T) {
+	var buf bytes.Buffer
+	base := logging.New(&buf, slog.LevelInfo)
+
+	h := middleware.RequestID(mw.RequestLogger(base)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		apierr.Write(w, apierr.ErrPRNotFound)
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/pullRequest/get", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-7")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var resp apierr.ErrResp
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error.Code != "NOT_FOUND" || resp.Error.RequestID != "req-7" {
+		t.Errorf("ожидалась ошибка NOT_FOUND с request_id req-7, получили %+v", resp.Error)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"request_id":"req-7"`)) {
+		t.Errorf("тот же request_id должен попасть в лог: %s", buf.String())
+	}
+}
@@ -0,0 +1,29 @@
+package mw
+
+import (
+	"net/http"
+	"strings"
+)
+
+// routeKey — ключ "METHOD /path" запроса в настройках middleware (лимиты тела,
+// сброс нагрузки, потоковые маршруты). Префикс версии /api/vN отбрасывается:
+// настройка действует на маршрут во всех версиях API и на путь без префикса.
+func routeKey(r *http.Request) string {
+	return r.Method + " " + unversioned(r.URL.Path)
+}
+
+// unversioned возвращает путь без префикса версии: /api/v1/team/add -> /team/add.
+func unversioned(path string) string {
+	rest, ok := strings.CutPrefix(path, "/api/v")
+	if !ok {
+		return path
+	}
+	i := 0
+	for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+		i++
+	}
+	if i == 0 || i == len(rest) || rest[i] != '/' {
+		return path
+	}
+	return rest[i:]
+}
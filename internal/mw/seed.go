@@ -0,0 +1,38 @@
+package mw
+
+import (
+	"net/http"
+	"strconv"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/pkg"
+)
+
+// AssignmentSeedHeader — заголовок с зерном случайного выбора ревьюверов.
+const AssignmentSeedHeader = "X-Assignment-Seed"
+
+// AssignmentSeed делает выбор ревьюверов запроса воспроизводимым: зерно из
+// заголовка X-Assignment-Seed передается сервису через контекст. Предназначен
+// для тестовых окружений; при enabled=false заголовок игнорируется.
+func AssignmentSeed(enabled bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !enabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			v := r.Header.Get(AssignmentSeedHeader)
+			if v == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			seed, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				logging.FromContext(r.Context()).Warn("invalid assignment seed", "value", v)
+				apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", AssignmentSeedHeader+" должен быть целым числом")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(pkg.WithSeed(r.Context(), seed)))
+		})
+	}
+}
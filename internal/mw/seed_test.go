@@ -0,0 +1,42 @@
+package mw_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"prreviewer/internal/mw"
+	"prreviewer/internal/pkg"
+)
+
+func TestAssignmentSeed(t *testing.T) {
+	do := func(enabled bool, seed string) (*httptest.ResponseRecorder, *int64) {
+		var got *int64
+		h := mw.AssignmentSeed(enabled)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if seed, ok := pkg.SeedFromContext(r.Context()); ok {
+				got = &seed
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		req := httptest.NewRequest(http.MethodPost, "/pullRequest/create", nil)
+		if seed != "" {
+			req.Header.Set(mw.AssignmentSeedHeader, seed)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec, got
+	}
+
+	if rec, got := do(true, "42"); rec.Code != http.StatusOK || got == nil || *got != 42 {
+		t.Errorf("ожидалось зерно 42 в контексте, получили %v (код %d)", got, rec.Code)
+	}
+	if _, got := do(true, ""); got != nil {
+		t.Errorf("без заголовка зерно не задается, получили %d", *got)
+	}
+	if rec, _ := do(true, "abc"); rec.Code != http.StatusBadRequest {
+		t.Errorf("некорректное зерно: ожидался 400, получили %d", rec.Code)
+	}
+	if rec, got := do(false, "42"); rec.Code != http.StatusOK || got != nil {
+		t.Errorf("выключенный режим должен игнорировать заголовок, получили %v (код %d)", got, rec.Code)
+	}
+}
@@ -0,0 +1,257 @@
+package mw
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"prreviewer/internal/logging"
+)
+
+const (
+	// DefaultSlowThreshold — порог медленного запроса для маршрутов без своего.
+	DefaultSlowThreshold = time.Second
+	// DefaultSlowLogSize — сколько последних медленных запросов хранит SlowLog.
+	DefaultSlowLogSize = 100
+	// slowTopQueries — сколько самых долгих запросов к БД попадает в запись.
+	slowTopQueries = 5
+	// maxQueryText обрезает текст SQL в записи медленного запроса.
+	maxQueryText = 300
+)
+
+// latencyBuckets — верхние границы корзин гистограммы задержек, мс.
+var latencyBuckets = [...]int64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+var routeLatency = expvar.NewMap("route_latency_ms")
+
+// histogram — гистограмма задержек маршрута в формате expvar.Var.
+// Счетчики корзин накопительные, как le-корзины Prometheus.
+type histogram struct {
+	counts [len(latencyBuckets) + 1]atomic.Int64 // последняя — +Inf
+	count  atomic.Int64
+	sumMS  atomic.Int64
+}
+
+func (h *histogram) observe(d time.Duration) {
+	ms := d.Milliseconds()
+	i := 0
+	for i < len(latencyBuckets) && ms > latencyBuckets[i] {
+		i++
+	}
+	h.counts[i].Add(1)
+	h.count.Add(1)
+	h.sumMS.Add(ms)
+}
+
+func (h *histogram) String() string {
+	type bucket struct {
+		LE    string `json:"le"`
+		Count int64  `json:"count"`
+	}
+	buckets := make([]bucket, 0, len(h.counts))
+	var total int64
+	for i := range h.counts {
+		total += h.counts[i].Load()
+		le := "+Inf"
+		if i < len(latencyBuckets) {
+			le = strconv.FormatInt(latencyBuckets[i], 10)
+		}
+		buckets = append(buckets, bucket{LE: le, Count: total})
+	}
+	b, _ := json.Marshal(struct {
+		Count   int64    `json:"count"`
+		SumMS   int64    `json:"sum_ms"`
+		Buckets []bucket `json:"buckets"`
+	}{h.count.Load(), h.sumMS.Load(), buckets})
+	return string(b)
+}
+
+var histogramsMu sync.Mutex
+
+// routeHistogram возвращает гистограмму маршрута, создавая ее при первом обращении.
+func routeHistogram(key string) *histogram {
+	if h, ok := routeLatency.Get(key).(*histogram); ok {
+		return h
+	}
+	histogramsMu.Lock()
+	defer histogramsMu.Unlock()
+	if h, ok := routeLatency.Get(key).(*histogram); ok {
+		return h
+	}
+	h := &histogram{}
+	routeLatency.Set(key, h)
+	return h
+}
+
+// SlowThresholds — пороги медленных запросов: Default для всех маршрутов
+// и переопределения по ключу "METHOD /pattern" (шаблон маршрута chi без
+// префикса версии, например "GET /pullRequest/{id}/timeline"). Порог 0 не
+// логирует маршрут.
+type SlowThresholds struct {
+	Default time.Duration
+	Routes  map[string]time.Duration
+}
+
+func (t SlowThresholds) threshold(route string) time.Duration {
+	if d, ok := t.Routes[route]; ok {
+		return d
+	}
+	return t.Default
+}
+
+// QueryTiming — время одного запроса к БД.
+type QueryTiming struct {
+	SQL        string  `json:"sql"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// SlowRequest — запись о запросе, превысившем порог своего маршрута.
+type SlowRequest struct {
+	At          time.Time     `json:"at"`
+	RequestID   string        `json:"request_id,omitempty"`
+	Method      string        `json:"method"`
+	Path        string        `json:"path"`
+	Route       string        `json:"route"`
+	Status      int           `json:"status"`
+	DurationMS  float64       `json:"duration_ms"`
+	ThresholdMS float64       `json:"threshold_ms"`
+	DBQueries   int           `json:"db_queries"`
+	DBTimeMS    float64       `json:"db_time_ms"`
+	TopQueries  []QueryTiming `json:"top_queries"`
+}
+
+// SlowLog хранит последние медленные запросы в кольцевом буфере.
+type SlowLog struct {
+	mu      sync.Mutex
+	entries []SlowRequest
+	next    int
+	full    bool
+}
+
+// NewSlowLog создает журнал на size записей; size <= 0 — DefaultSlowLogSize.
+func NewSlowLog(size int) *SlowLog {
+	if size <= 0 {
+		size = DefaultSlowLogSize
+	}
+	return &SlowLog{entries: make([]SlowRequest, size)}
+}
+
+func (l *SlowLog) add(e SlowRequest) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries[l.next] = e
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// Recent возвращает сохраненные записи, новые первыми.
+func (l *SlowLog) Recent() []SlowRequest {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := l.next
+	if l.full {
+		n = len(l.entries)
+	}
+	out := make([]SlowRequest, 0, n)
+	for i := 1; i <= n; i++ {
+		out = append(out, l.entries[(l.next-i+len(l.entries))%len(l.entries)])
+	}
+	return out
+}
+
+// ServeHTTP отдает {"slow_requests": [...]} — последние медленные запросы.
+func (l *SlowLog) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string][]SlowRequest{"slow_requests": l.Recent()})
+}
+
+// SlowRequests пишет задержку каждого запроса в гистограмму его маршрута
+// (expvar route_latency_ms) и логирует запросы дольше порога маршрута вместе
+// с разбивкой времени по запросам к БД, сохраняя их в slow. Время БД
+// собирает QueryTracer пула pgx. Запросы вне маршрутов роутера не учитываются.
+func SlowRequests(slow *SlowLog, thresholds SlowThresholds) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			db := &dbTimings{}
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(withDBTimings(r.Context(), db)))
+			elapsed := time.Since(start)
+
+			rctx := chi.RouteContext(r.Context())
+			if rctx == nil || rctx.RoutePattern() == "" {
+				return
+			}
+			route := r.Method + " " + rctx.RoutePattern()
+			routeHistogram(route).observe(elapsed)
+
+			limit := thresholds.threshold(r.Method + " " + unversioned(rctx.RoutePattern()))
+			if limit <= 0 || elapsed < limit {
+				return
+			}
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			e := SlowRequest{
+				At:          start.UTC(),
+				RequestID:   middleware.GetReqID(r.Context()),
+				Method:      r.Method,
+				Path:        r.URL.Path,
+				Route:       rctx.RoutePattern(),
+				Status:      status,
+				DurationMS:  millis(elapsed),
+				ThresholdMS: millis(limit),
+			}
+			e.DBQueries, e.DBTimeMS, e.TopQueries = db.summary()
+			slow.add(e)
+			logging.FromContext(r.Context()).Warn("slow request",
+				"status", e.Status,
+				"duration_ms", e.DurationMS,
+				"threshold_ms", e.ThresholdMS,
+				"db_queries", e.DBQueries,
+				"db_time_ms", e.DBTimeMS,
+				"top_queries", e.TopQueries,
+			)
+		})
+	}
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// ParseSlowThresholds разбирает переопределения вида
+// "GET /export/assignments=30s;POST /team/import=5s;GET /stats=0".
+func ParseSlowThresholds(s string) (map[string]time.Duration, error) {
+	result := map[string]time.Duration{}
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, value, ok := strings.Cut(entry, "=")
+		fields := strings.Fields(route)
+		if !ok || len(fields) != 2 {
+			return nil, fmt.Errorf("invalid entry %q, expected \"METHOD /path=duration\"", entry)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid duration for %q: %q", route, value)
+		}
+		result[strings.ToUpper(fields[0])+" "+fields[1]] = d
+	}
+	return result, nil
+}
@@ -0,0 +1,118 @@
+package mw_test
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"prreviewer/internal/mw"
+)
+
+// fakeQuery имитирует запрос к БД длительностью d через QueryTracer.
+func fakeQuery(ctx context.Context, sql string, d time.Duration) {
+	var tracer mw.QueryTracer
+	ctx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: sql})
+	time.Sleep(d)
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+}
+
+func TestSlowRequests(t *testing.T) {
+	slow := mw.NewSlowLog(2)
+	router := chi.NewRouter()
+	router.Use(mw.SlowRequests(slow, mw.SlowThresholds{
+		Default: 10 * time.Millisecond,
+		Routes:  map[string]time.Duration{"GET /stats": 0},
+	}))
+	router.Get("/pullRequest/{id}/timeline", func(w http.ResponseWriter, r *http.Request) {
+		fakeQuery(r.Context(), "SELECT *\n\tFROM pull_requests WHERE id = $1", 15*time.Millisecond)
+		fakeQuery(r.Context(), "SELECT 1", 0)
+		w.WriteHeader(http.StatusAccepted)
+	})
+	router.Get("/stats", func(http.ResponseWriter, *http.Request) {
+		time.Sleep(15 * time.Millisecond)
+	})
+	router.Get("/fast", func(http.ResponseWriter, *http.Request) {})
+
+	for _, path := range []string{"/pullRequest/pr-1/timeline", "/stats", "/fast"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	entries := slow.Recent()
+	if len(entries) != 1 {
+		t.Fatalf("ожидался один медленный запрос, получили %+v", entries)
+	}
+	e := entries[0]
+	if e.Route != "/pullRequest/{id}/timeline" || e.Path != "/pullRequest/pr-1/timeline" || e.Status != http.StatusAccepted {
+		t.Errorf("неверная запись: %+v", e)
+	}
+	if e.DBQueries != 2 || e.DBTimeMS < 15 || len(e.TopQueries) != 2 {
+		t.Fatalf("неверная разбивка времени БД: %+v", e)
+	}
+	if e.TopQueries[0].SQL != "SELECT * FROM pull_requests WHERE id = $1" {
+		t.Errorf("первым ожидался самый долгий запрос со схлопнутыми пробелами, получили %q", e.TopQueries[0].SQL)
+	}
+
+	h, ok := expvar.Get("route_latency_ms").(*expvar.Map).Get("GET /pullRequest/{id}/timeline").(expvar.Var)
+	if !ok {
+		t.Fatal("ожидалась гистограмма маршрута в expvar")
+	}
+	var hist struct {
+		Count   int64 `json:"count"`
+		Buckets []struct {
+			LE    string `json:"le"`
+			Count int64  `json:"count"`
+		} `json:"buckets"`
+	}
+	if err := json.Unmarshal([]byte(h.String()), &hist); err != nil {
+		t.Fatal(err)
+	}
+	if hist.Count != 1 || hist.Buckets[len(hist.Buckets)-1].LE != "+Inf" || hist.Buckets[len(hist.Buckets)-1].Count != 1 {
+		t.Errorf("неверная гистограмма: %s", h.String())
+	}
+}
+
+func TestSlowLogRing(t *testing.T) {
+	slow := mw.NewSlowLog(2)
+	router := chi.NewRouter()
+	router.Use(mw.SlowRequests(slow, mw.SlowThresholds{Default: time.Nanosecond}))
+	router.Get("/{n}", func(http.ResponseWriter, *http.Request) { time.Sleep(time.Millisecond) })
+	for _, path := range []string{"/1", "/2", "/3"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	slow.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/slow", nil))
+	var resp struct {
+		SlowRequests []mw.SlowRequest `json:"slow_requests"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.SlowRequests) != 2 || resp.SlowRequests[0].Path != "/3" || resp.SlowRequests[1].Path != "/2" {
+		t.Errorf("ожидались два последних запроса, новые первыми, получили %+v", resp.SlowRequests)
+	}
+}
+
+func TestParseSlowThresholds(t *testing.T) {
+	routes, err := mw.ParseSlowThresholds("get /export/assignments=30s; GET /stats=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if routes["GET /export/assignments"] != 30*time.Second || routes["GET /stats"] != 0 || len(routes) != 2 {
+		t.Errorf("неверно разобраны пороги: %v", routes)
+	}
+
+	for _, s := range []string{"/stats=1s", "GET /stats=fast", "GET /stats=-1s"} {
+		if _, err := mw.ParseSlowThresholds(s); err == nil || !strings.Contains(err.Error(), "invalid") {
+			t.Errorf("%q: ожидалась ошибка, получили %v", s, err)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package mw
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Timeout ограничивает обработку запроса сроком d, как middleware.Timeout chi,
+// кроме потоковых маршрутов streaming ("METHOD /path" без префикса версии): их
+// длительность зависит от объема выгрузки, и обработчик сам продлевает дедлайн
+// записи по частям.
+func Timeout(d time.Duration, streaming ...string) func(http.Handler) http.Handler {
+	skip := make(map[string]bool, len(streaming))
+	for _, route := range streaming {
+		skip[route] = true
+	}
+	return func(next http.Handler) http.Handler {
+		limited := middleware.Timeout(d)(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if skip[routeKey(r)] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			limited.ServeHTTP(w, r)
+		})
+	}
+}
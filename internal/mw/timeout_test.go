@@ -0,0 +1,29 @@
+package mw_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"prreviewer/internal/mw"
+)
+
+func TestTimeoutSkipsStreamingRoutes(t *testing.T) {
+	deadlines := map[string]bool{}
+	h := mw.Timeout(time.Minute, "GET /export/assignments")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := r.Context().Deadline()
+		deadlines[r.URL.Path] = ok
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, path := range []string{"/export/assignments", "/stats"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if deadlines["/export/assignments"] {
+		t.Errorf("у потокового маршрута не должно быть дедлайна")
+	}
+	if !deadlines["/stats"] {
+		t.Errorf("обычный маршрут получает дедлайн запроса")
+	}
+}
@@ -0,0 +1,56 @@
+package mw
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/openapi"
+)
+
+// ValidateRequests отклоняет JSON-тела, не соответствующие схеме операции
+// из документа OpenAPI, ответом 400 VALIDATION с сообщениями по полям.
+// Тела других типов (CSV-импорт) и нераспознанный JSON пропускаются к обработчику.
+func ValidateRequests(doc *openapi.Document) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body == nil || !doc.HasRequestSchema(r.Method, r.URL.Path) || !isJSON(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				logging.FromContext(r.Context()).Warn("failed to read request body", "error", err)
+				apierr.JSON(w, http.StatusBadRequest, "BAD_REQUEST", "не удалось прочитать тело запроса")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			if violations := doc.Validate(r.Method, r.URL.Path, body); len(violations) > 0 {
+				fields := make(map[string]string, len(violations))
+				for _, v := range violations {
+					if _, ok := fields[v.Field]; !ok {
+						fields[v.Field] = v.Message
+					}
+				}
+				logging.FromContext(r.Context()).Warn("request violates schema", "fields", fields)
+				apierr.Validation(w, fields)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isJSON(r *http.Request) bool {
+	ct := r.Header.Get("Content-Type")
+	if ct == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(ct)
+	return err == nil && mediaType == "application/json"
+}
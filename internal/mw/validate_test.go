@@ -0,0 +1,61 @@
+package mw_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"prreviewer/internal/apierr"
+	"prreviewer/internal/mw"
+	"prreviewer/internal/openapi"
+)
+
+func TestValidateRequests(t *testing.T) {
+	type request struct {
+		UserID string `json:"user_id" validate:"required"`
+	}
+	doc, err := openapi.New(openapi.Info{Title: "test", Version: "1"}, []openapi.Operation{
+		{Method: http.MethodPost, Path: "/users/delete", Request: request{}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got string
+	h := mw.ValidateRequests(doc)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = string(b)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users/delete", strings.NewReader(`{"user_id":1}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("ожидался 400, получили %d", rec.Code)
+	}
+	var resp apierr.ErrResp
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := resp.Error.Fields["user_id"]; resp.Error.Code != "VALIDATION" || !ok {
+		t.Errorf("ожидалась ошибка VALIDATION по user_id, получили %+v", resp.Error)
+	}
+
+	body := `{"user_id":"u1"}`
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users/delete", strings.NewReader(body)))
+	if rec.Code != http.StatusOK || got != body {
+		t.Errorf("корректное тело должно дойти до обработчика без изменений, получили %d %q", rec.Code, got)
+	}
+
+	csvReq := httptest.NewRequest(http.MethodPost, "/users/delete", strings.NewReader("user_id\n1"))
+	csvReq.Header.Set("Content-Type", "text/csv")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, csvReq)
+	if rec.Code != http.StatusOK {
+		t.Errorf("не-JSON тело не должно проверяться, получили %d", rec.Code)
+	}
+}
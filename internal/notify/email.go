@@ -0,0 +1,293 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+)
+
+const defaultSMTPPort = "587"
+
+// SMTPConfig — параметры почтового сервера.
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	// TemplatesDir — каталог с файлами <type>.subject.tmpl и <type>.body.tmpl,
+	// переопределяющими шаблоны по умолчанию.
+	TemplatesDir string
+}
+
+// RecipientLookup возвращает адреса пользователей, не отказавшихся от писем.
+type RecipientLookup interface {
+	EmailRecipients(ctx context.Context, userIDs []string) ([]models.Recipient, error)
+}
+
+// SendFunc отправляет готовое письмо.
+type SendFunc func(ctx context.Context, cfg SMTPConfig, to string, msg []byte) error
+
+// reasonTexts — русский текст причин назначения, которые сервис пишет кодом;
+// причины с параметром ищутся по префиксу в reasonPrefixes.
+var reasonTexts = map[string]string{
+	"team deactivated":         "команда ревьювера отключена",
+	"user deleted":             "прежний ревьювер удален",
+	"top-up to reviewer_count": "добор до нужного числа ревьюверов",
+	"assigned by admin":        "назначение администратором",
+	"unassigned by admin":      "снятие администратором",
+}
+
+var reasonPrefixes = [][2]string{
+	{"no response within ", "нет реакции за "},
+	{"new member of team ", "новый участник команды "},
+}
+
+// reasonText переводит код причины в текст письма; причину, заданную
+// человеком (reason в /pullRequest/reassign и отказе), возвращает как есть.
+func reasonText(code string) string {
+	if text, ok := reasonTexts[code]; ok {
+		return text
+	}
+	for _, p := range reasonPrefixes {
+		if rest, ok := strings.CutPrefix(code, p[0]); ok {
+			return p[1] + rest
+		}
+	}
+	return code
+}
+
+// templateFuncs доступны во встроенных и переопределенных шаблонах.
+var templateFuncs = template.FuncMap{"reason": reasonText}
+
+type emailTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+// Шаблоны по умолчанию; данные шаблона — emailData.
+var defaultTemplates = map[string][2]string{
+	EventReviewerAssigned: {
+		`Вас назначили ревьювером: {{.Event.PRName}}`,
+		`Здравствуйте, {{.Recipient.Name}}!
+
+Вас назначили ревьювером PR «{{.Event.PRName}}» ({{.Event.PRID}}) автора {{.Event.AuthorID}}.
+{{- with .Event.Details.old_user_id}} Вы заменяете ревьювера {{.}}.{{end}}
+{{- with .Event.Message}} Причина: {{reason .}}.{{end}}
+{{with .Event.PRURL}}
+Ссылка: {{.}}
+{{end}}`,
+	},
+	EventPRMerged: {
+		`PR смержен: {{.Event.PRName}}`,
+		`Здравствуйте, {{.Recipient.Name}}!
+
+Ваш PR «{{.Event.PRName}}» ({{.Event.PRID}}) смержен.
+{{with .Event.PRURL}}
+Ссылка: {{.}}
+{{end}}`,
+	},
+	EventStalePR: {
+		`PR ждет ревью: {{.Event.PRName}}`,
+		`Здравствуйте, {{.Recipient.Name}}!
+
+PR «{{.Event.PRName}}» ({{.Event.PRID}}) автора {{.Event.AuthorID}} все еще ждет вашего ревью. {{.Event.Message}}.
+{{with .Event.PRURL}}
+Ссылка: {{.}}
+{{end}}`,
+	},
+	EventDigest: {
+		`Дайджест ревью`,
+		`Здравствуйте, {{.Recipient.Name}}!
+
+{{.Event.Message}}
+`,
+	},
+}
+
+type emailData struct {
+	Event     Event
+	Recipient models.Recipient
+}
+
+// Email рассылает письма пользователям из Event.Recipients.
+type Email struct {
+	cfg       SMTPConfig
+	lookup    RecipientLookup
+	templates map[string]emailTemplate
+	send      SendFunc
+}
+
+type EmailOption func(*Email)
+
+// WithSendFunc подменяет отправку письма (например, в тестах).
+func WithSendFunc(f SendFunc) EmailOption {
+	return func(e *Email) { e.send = f }
+}
+
+func NewEmail(cfg SMTPConfig, lookup RecipientLookup, opts ...EmailOption) (*Email, error) {
+	if cfg.Host == "" || cfg.From == "" {
+		return nil, errors.New("email notifier requires SMTP host and from address")
+	}
+	if cfg.Port == "" {
+		cfg.Port = defaultSMTPPort
+	}
+
+	templates, err := loadTemplates(cfg.TemplatesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Email{cfg: cfg, lookup: lookup, templates: templates, send: sendMail}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e, nil
+}
+
+// Notify отправляет письмо каждому получателю события. События без шаблона
+// и пользователи без адреса или с отказом от писем пропускаются.
+func (e *Email) Notify(ctx context.Context, ev Event) error {
+	tmpl, ok := e.templates[ev.Type]
+	if !ok || len(ev.Recipients) == 0 {
+		return nil
+	}
+
+	recipients, err := e.lookup.EmailRecipients(ctx, ev.Recipients)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, rcpt := range recipients {
+		msg, err := render(e.cfg.From, rcpt, tmpl, emailData{Event: ev, Recipient: rcpt})
+		if err != nil {
+			return err
+		}
+		if err := e.send(ctx, e.cfg, rcpt.Email, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s: %v", ErrDeliveryFailed, rcpt.UserID, err))
+			continue
+		}
+		logging.FromContext(ctx).Debug("email sent", "type", ev.Type, "user_id", rcpt.UserID)
+	}
+	return errors.Join(errs...)
+}
+
+func loadTemplates(dir string) (map[string]emailTemplate, error) {
+	templates := make(map[string]emailTemplate, len(defaultTemplates))
+	for eventType, def := range defaultTemplates {
+		subject, body := def[0], def[1]
+		if dir != "" {
+			var err error
+			if subject, err = readTemplate(dir, eventType+".subject.tmpl", subject); err != nil {
+				return nil, err
+			}
+			if body, err = readTemplate(dir, eventType+".body.tmpl", body); err != nil {
+				return nil, err
+			}
+		}
+
+		var t emailTemplate
+		var err error
+		if t.subject, err = template.New(eventType + ".subject").Funcs(templateFuncs).Parse(subject); err != nil {
+			return nil, err
+		}
+		if t.body, err = template.New(eventType + ".body").Funcs(templateFuncs).Parse(body); err != nil {
+			return nil, err
+		}
+		templates[eventType] = t
+	}
+	return templates, nil
+}
+
+// readTemplate читает файл шаблона; если файла нет, возвращает def.
+func readTemplate(dir, name, def string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return def, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func render(from string, rcpt models.Recipient, tmpl emailTemplate, data emailData) ([]byte, error) {
+	var subject, body bytes.Buffer
+	if err := tmpl.subject.Execute(&subject, data); err != nil {
+		return nil, err
+	}
+	if err := tmpl.body.Execute(&body, data); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", rcpt.Email)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", strings.TrimSpace(subject.String())))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body.String(), "\n", "\r\n"))
+	return msg.Bytes(), nil
+}
+
+// sendMail отправляет письмо через SMTP с STARTTLS, если сервер его поддерживает.
+func sendMail(ctx context.Context, cfg SMTPConfig, to string, msg []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", net.JoinHostPort(cfg.Host, cfg.Port))
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer func() { _ = c.Close() }()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: cfg.Host, MinVersion: tls.VersionTLS12}); err != nil {
+			return err
+		}
+	}
+	if cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(cfg.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
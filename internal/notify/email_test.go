@@ -0,0 +1,134 @@
+package notify_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"prreviewer/internal/models"
+	"prreviewer/internal/notify"
+)
+
+// stubLookup возвращает адреса только для известных пользователей, как репозиторий
+// для пользователей без отказа от писем.
+type stubLookup map[string]string
+
+func (l stubLookup) EmailRecipients(_ context.Context, userIDs []string) ([]models.Recipient, error) {
+	var result []models.Recipient
+	for _, id := range userIDs {
+		if email, ok := l[id]; ok {
+			result = append(result, models.Recipient{UserID: id, Name: id, Email: email})
+		}
+	}
+	return result, nil
+}
+
+type sentMail struct {
+	to  string
+	msg string
+}
+
+func newTestEmail(t *testing.T, cfg notify.SMTPConfig, sent *[]sentMail) *notify.Email {
+	t.Helper()
+	cfg.Host, cfg.From = "smtp.example.com", "bot@example.com"
+	e, err := notify.NewEmail(cfg, stubLookup{"rev1": "rev1@example.com"},
+		notify.WithSendFunc(func(_ context.Context, _ notify.SMTPConfig, to string, msg []byte) error {
+			*sent = append(*sent, sentMail{to: to, msg: string(msg)})
+			return nil
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestEmailSendsToRecipientsWithAddress(t *testing.T) {
+	var sent []sentMail
+	e := newTestEmail(t, notify.SMTPConfig{}, &sent)
+
+	err := e.Notify(context.Background(), notify.Event{
+		Type:       notify.EventReviewerAssigned,
+		PRID:       "pr-1",
+		PRName:     "Fix",
+		AuthorID:   "author",
+		Recipients: []string{"rev1", "opted_out"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sent) != 1 || sent[0].to != "rev1@example.com" {
+		t.Fatalf("ожидалось одно письмо rev1, получили %+v", sent)
+	}
+	if !strings.Contains(sent[0].msg, "Subject: =?utf-8?q?") || !strings.Contains(sent[0].msg, "pr-1") {
+		t.Errorf("неожиданное письмо:\n%s", sent[0].msg)
+	}
+}
+
+func TestEmailReviewerReplacedReason(t *testing.T) {
+	var sent []sentMail
+	e := newTestEmail(t, notify.SMTPConfig{}, &sent)
+
+	err := e.Notify(context.Background(), notify.Event{
+		Type:       notify.EventReviewerAssigned,
+		PRID:       "pr-1",
+		PRName:     "Fix",
+		AuthorID:   "author",
+		Message:    "в отпуске",
+		Recipients: []string{"rev1"},
+		Details:    map[string]any{"old_user_id": "rev0", "reason": "в отпуске"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sent) != 1 || !strings.Contains(sent[0].msg, "Вы заменяете ревьювера rev0. Причина: в отпуске.") {
+		t.Errorf("письмо должно называть замененного ревьювера и причину:\n%+v", sent)
+	}
+}
+
+func TestEmailReasonCodes(t *testing.T) {
+	for code, want := range map[string]string{
+		"team deactivated":           "Причина: команда ревьювера отключена.",
+		"no response within 48h0m0s": "Причина: нет реакции за 48h0m0s.",
+		"new member of team backend": "Причина: новый участник команды backend.",
+		"нужен эксперт по БД":        "Причина: нужен эксперт по БД.",
+	} {
+		var sent []sentMail
+		e := newTestEmail(t, notify.SMTPConfig{}, &sent)
+		err := e.Notify(context.Background(), notify.Event{
+			Type:       notify.EventReviewerAssigned,
+			PRID:       "pr-1",
+			PRName:     "Fix",
+			Message:    code,
+			Recipients: []string{"rev1"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sent) != 1 || !strings.Contains(sent[0].msg, want) {
+			t.Errorf("%q: ожидалось %q в письме:\n%+v", code, want, sent)
+		}
+	}
+}
+
+func TestEmailTemplatesOverride(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, notify.EventPRMerged+".body.tmpl"), []byte("merged {{.Event.PRID}}"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sent []sentMail
+	e := newTestEmail(t, notify.SMTPConfig{TemplatesDir: dir}, &sent)
+
+	err = e.Notify(context.Background(), notify.Event{Type: notify.EventPRMerged, PRID: "pr-7", Recipients: []string{"rev1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 || !strings.HasSuffix(sent[0].msg, "\r\n\r\nmerged pr-7") {
+		t.Errorf("ожидалось тело из шаблона каталога, получили %+v", sent)
+	}
+}
@@ -0,0 +1,239 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+)
+
+const (
+	KindLog     = "log"
+	KindWebhook = "webhook"
+	KindSlack   = "slack"
+	KindEmail   = "email"
+
+	requestTimeout = 10 * time.Second
+)
+
+// Типы событий.
+const (
+	EventStalePR          = "pr.stale"
+	EventReviewerAssigned = "pr.reviewer_assigned"
+	EventPRMerged         = "pr.merged"
+	// EventDigest — ежедневная сводка ревьювера; текст сводки — в Message,
+	// сама сводка (models.Digest) — в Details["digest"].
+	EventDigest = "user.digest"
+)
+
+var ErrDeliveryFailed = errors.New("notification delivery failed")
+
+// Event — уведомление о PR для внешнего канала.
+type Event struct {
+	Type      string    `json:"type"`
+	PRID      string    `json:"pull_request_id"`
+	PRName    string    `json:"pull_request_name"`
+	PRURL     string    `json:"pull_request_url,omitempty"`
+	AuthorID  string    `json:"author_id"`
+	Reviewers []string  `json:"reviewers"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+	// Recipients — пользователи, которым адресовано событие (для персональных каналов).
+	Recipients []string       `json:"recipients,omitempty"`
+	TeamName   string         `json:"team_name,omitempty"`
+	UserID     string         `json:"user_id,omitempty"`
+	Details    map[string]any `json:"details,omitempty"`
+}
+
+// Notifier доставляет события в канал уведомлений.
+type Notifier interface {
+	Notify(ctx context.Context, e Event) error
+}
+
+// Log пишет события в лог сервиса.
+type Log struct{}
+
+func (Log) Notify(ctx context.Context, e Event) error {
+	logging.FromContext(ctx).Info("notification",
+		"type", e.Type,
+		"pr_id", e.PRID,
+		"reviewers", e.Reviewers,
+		"message", e.Message)
+	return nil
+}
+
+// Webhook отправляет событие POST-запросом с JSON-телом.
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhook(url string) *Webhook {
+	return &Webhook{url: url, client: &http.Client{Timeout: requestTimeout}}
+}
+
+func (w *Webhook) Notify(ctx context.Context, e Event) error {
+	return postJSON(ctx, w.client, w.url, e)
+}
+
+// IdentityLookup возвращает учетные записи пользователей во внешней системе;
+// пользователи без привязки в ответ не попадают.
+type IdentityLookup interface {
+	ExternalIDs(ctx context.Context, provider string, userIDs []string) (map[string]string, error)
+}
+
+// Slack отправляет событие в incoming webhook Slack.
+type Slack struct {
+	url        string
+	client     *http.Client
+	identities IdentityLookup
+}
+
+type SlackOption func(*Slack)
+
+// WithSlackMentions упоминает ревьюверов с привязанным Slack member ID как @user.
+func WithSlackMentions(lookup IdentityLookup) SlackOption {
+	return func(s *Slack) { s.identities = lookup }
+}
+
+func NewSlack(url string, opts ...SlackOption) *Slack {
+	s := &Slack{url: url, client: &http.Client{Timeout: requestTimeout}}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *Slack) Notify(ctx context.Context, e Event) error {
+	if e.Type == EventDigest {
+		// Incoming webhook пишет в общий канал, а дайджест — личная сводка:
+		// он уходит только в персональные каналы (email).
+		return nil
+	}
+	name := e.PRName
+	if e.PRURL != "" {
+		name = fmt.Sprintf("<%s|%s>", e.PRURL, e.PRName)
+	}
+	text := fmt.Sprintf("*%s* (%s): %s", name, e.PRID, e.Message)
+	if len(e.Reviewers) > 0 {
+		text += "\nРевьюверы: " + strings.Join(s.mentions(ctx, e.Reviewers), ", ")
+	}
+	return postJSON(ctx, s.client, s.url, map[string]string{"text": text})
+}
+
+// mentions заменяет user_id на упоминания Slack; без привязки или при ошибке
+// поиска остается user_id.
+func (s *Slack) mentions(ctx context.Context, userIDs []string) []string {
+	if s.identities == nil {
+		return userIDs
+	}
+	ids, err := s.identities.ExternalIDs(ctx, models.IdentitySlack, userIDs)
+	if err != nil {
+		logging.FromContext(ctx).Warn("failed to look up slack identities", "error", err)
+		return userIDs
+	}
+	result := make([]string, len(userIDs))
+	for i, uid := range userIDs {
+		result[i] = uid
+		if id, ok := ids[uid]; ok {
+			result[i] = "<@" + id + ">"
+		}
+	}
+	return result
+}
+
+// Multi рассылает событие во все каналы и объединяет их ошибки.
+type Multi []Notifier
+
+func (m Multi) Notify(ctx context.Context, e Event) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Config — настройки каналов для New.
+type Config struct {
+	URL        string // webhook и slack
+	SMTP       SMTPConfig
+	Recipients RecipientLookup
+	Identities IdentityLookup // упоминания в Slack; nil — без упоминаний
+}
+
+// New создает notifier по списку видов через запятую: log, webhook, slack, email.
+func New(kinds string, cfg Config) (Notifier, error) {
+	if strings.TrimSpace(kinds) == "" {
+		return Log{}, nil
+	}
+
+	var multi Multi
+	for _, kind := range strings.Split(kinds, ",") {
+		n, err := newNotifier(strings.TrimSpace(kind), cfg)
+		if err != nil {
+			return nil, err
+		}
+		multi = append(multi, n)
+	}
+	if len(multi) == 1 {
+		return multi[0], nil
+	}
+	return multi, nil
+}
+
+func newNotifier(kind string, cfg Config) (Notifier, error) {
+	switch kind {
+	case KindLog:
+		return Log{}, nil
+	case KindWebhook, KindSlack:
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("notifier %s requires url", kind)
+		}
+		if kind == KindSlack {
+			var opts []SlackOption
+			if cfg.Identities != nil {
+				opts = append(opts, WithSlackMentions(cfg.Identities))
+			}
+			return NewSlack(cfg.URL, opts...), nil
+		}
+		return NewWebhook(cfg.URL), nil
+	case KindEmail:
+		return NewEmail(cfg.SMTP, cfg.Recipients)
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", kind)
+	}
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w: %s: %d", ErrDeliveryFailed, req.URL.Host, resp.StatusCode)
+	}
+	return nil
+}
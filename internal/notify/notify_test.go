@@ -0,0 +1,112 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"prreviewer/internal/notify"
+)
+
+func TestSlackPostsText(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	n, err := notify.New(notify.KindSlack, notify.Config{URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = n.Notify(context.Background(), notify.Event{
+		PRID: "pr-1", PRName: "Fix", PRURL: "https://git.example.com/pr/1",
+		Message: "ждет ревью", Reviewers: []string{"u1", "u2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got["text"], "<https://git.example.com/pr/1|Fix>") ||
+		!strings.Contains(got["text"], "pr-1") || !strings.Contains(got["text"], "u1, u2") {
+		t.Errorf("неожиданный текст: %q", got["text"])
+	}
+}
+
+func TestSlackSkipsPersonalDigest(t *testing.T) {
+	posted := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted = true
+	}))
+	defer srv.Close()
+
+	n, err := notify.New(notify.KindSlack, notify.Config{URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = n.Notify(context.Background(), notify.Event{
+		Type: notify.EventDigest, UserID: "u1", Message: "Ревью в работе: 2",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if posted {
+		t.Error("личный дайджест не публикуется в общий канал Slack")
+	}
+}
+
+type slackIDs map[string]string
+
+func (m slackIDs) ExternalIDs(_ context.Context, provider string, userIDs []string) (map[string]string, error) {
+	ids := map[string]string{}
+	for _, uid := range userIDs {
+		if id, ok := m[uid]; ok && provider == "slack" {
+			ids[uid] = id
+		}
+	}
+	return ids, nil
+}
+
+func TestSlackMentionsLinkedReviewers(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	n, err := notify.New(notify.KindSlack, notify.Config{URL: srv.URL, Identities: slackIDs{"u1": "U0123"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = n.Notify(context.Background(), notify.Event{PRID: "pr-1", PRName: "Fix", Reviewers: []string{"u1", "u2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got["text"], "Ревьюверы: <@U0123>, u2") {
+		t.Errorf("привязанный ревьювер упоминается, остальные — по user_id: %q", got["text"])
+	}
+}
+
+func TestWebhookReportsHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	err := notify.NewWebhook(srv.URL).Notify(context.Background(), notify.Event{PRID: "pr-1"})
+	if !errors.Is(err, notify.ErrDeliveryFailed) {
+		t.Fatalf("ожидалась ErrDeliveryFailed, получили %v", err)
+	}
+}
+
+func TestNewRequiresURL(t *testing.T) {
+	if _, err := notify.New(notify.KindWebhook, notify.Config{}); err == nil {
+		t.Error("webhook без url должен давать ошибку")
+	}
+	if _, err := notify.New("log,pager", notify.Config{URL: "http://x"}); err == nil {
+		t.Error("неизвестный notifier должен давать ошибку")
+	}
+}
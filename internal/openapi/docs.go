@@ -0,0 +1,32 @@
+package openapi
+
+import (
+	"html/template"
+	"net/http"
+)
+
+var docsPage = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+  <meta charset="utf-8">
+  <title>PR Reviewer API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: {{.}}, dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>
+`))
+
+// DocsHandler отдает страницу Swagger UI для документа по адресу specURL.
+// Статика Swagger UI загружается браузером с CDN.
+func DocsHandler(specURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = docsPage.Execute(w, specURL)
+	}
+}
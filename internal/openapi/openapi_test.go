@@ -0,0 +1,194 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"prreviewer/internal/openapi"
+)
+
+type member struct {
+	UserID   string `json:"user_id" validate:"required"`
+	IsActive bool   `json:"is_active"`
+}
+
+type createRequest struct {
+	Name    string   `json:"name" validate:"required,max=10"`
+	Limit   *int     `json:"limit,omitempty"`
+	Members []member `json:"members"`
+	Ignored string   `json:"-"`
+}
+
+func newDocument(t *testing.T) *openapi.Document {
+	t.Helper()
+	doc, err := openapi.New(openapi.Info{Title: "test", Version: "1"}, []openapi.Operation{
+		{Method: http.MethodPost, Path: "/create", Auth: true, Request: createRequest{}},
+		{Method: http.MethodGet, Path: "/list", Deprecated: true, Query: []openapi.Param{{Name: "q"}}},
+		{Method: http.MethodGet, Path: "/items/{id}/history", PathParams: []openapi.Param{{Name: "id"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestDocumentDescribesPathParams(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newDocument(t).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Parameters []struct {
+				Name     string `json:"name"`
+				In       string `json:"in"`
+				Required bool   `json:"required"`
+			} `json:"parameters"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	params := doc.Paths["/items/{id}/history"]["get"].Parameters
+	if len(params) != 1 || params[0].Name != "id" || params[0].In != "path" || !params[0].Required {
+		t.Errorf("ожидался обязательный параметр пути id, получили %+v", params)
+	}
+}
+
+func TestDocumentMarksDeprecated(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newDocument(t).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Deprecated bool `json:"deprecated"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if !doc.Paths["/list"]["get"].Deprecated || doc.Paths["/create"]["post"].Deprecated {
+		t.Errorf("deprecated должен быть только у /list: %+v", doc.Paths)
+	}
+}
+
+func TestDocumentDescribesRequestSchema(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newDocument(t).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	var doc struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]struct {
+					Type      string `json:"type"`
+					Nullable  bool   `json:"nullable"`
+					MaxLength *int   `json:"maxLength"`
+				} `json:"properties"`
+				Required []string `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := doc.Paths["/create"]["post"]; !ok {
+		t.Errorf("ожидалась операция POST /create, получили %v", doc.Paths)
+	}
+	req, ok := doc.Components.Schemas["CreateRequest"]
+	if !ok {
+		t.Fatalf("ожидалась схема CreateRequest, получили %v", doc.Components.Schemas)
+	}
+	if len(req.Required) != 1 || req.Required[0] != "name" {
+		t.Errorf("обязательным должно быть только name, получили %v", req.Required)
+	}
+	if p := req.Properties["name"]; p.MaxLength == nil || *p.MaxLength != 10 {
+		t.Errorf("ограничение max должно стать maxLength, получили %+v", p)
+	}
+	if p := req.Properties["limit"]; p.Type != "integer" || !p.Nullable {
+		t.Errorf("limit должен быть nullable integer, получили %+v", p)
+	}
+	if _, ok := req.Properties["Ignored"]; ok {
+		t.Errorf("поле с json:\"-\" не должно попадать в схему")
+	}
+	if _, ok := doc.Components.Schemas["Member"]; !ok {
+		t.Errorf("вложенная структура должна попасть в components")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	doc := newDocument(t)
+	tests := []struct {
+		name   string
+		body   string
+		fields []string
+	}{
+		{"корректное тело", `{"name":"x","limit":5,"members":[{"user_id":"u1"}]}`, nil},
+		{"нет обязательного поля", `{"limit":5}`, []string{"name"}},
+		{"неверные типы", `{"name":1,"limit":1.5}`, []string{"limit", "name"}},
+		{"вложенное поле", `{"name":"x","members":[{"is_active":"yes"}]}`, []string{"members[0].user_id", "members[0].is_active"}},
+		{"не объект", `[]`, []string{"body"}},
+		{"не JSON — решает обработчик", `{`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := doc.Validate(http.MethodPost, "/create", []byte(tt.body))
+			if len(errs) != len(tt.fields) {
+				t.Fatalf("ожидались ошибки в %v, получили %+v", tt.fields, errs)
+			}
+			for i, f := range tt.fields {
+				if errs[i].Field != f {
+					t.Errorf("ошибка %d: ожидалось поле %s, получили %s", i, f, errs[i].Field)
+				}
+			}
+		})
+	}
+
+	if errs := doc.Validate(http.MethodGet, "/list", []byte(`{"name":1}`)); errs != nil {
+		t.Errorf("у операции без тела проверок быть не должно, получили %+v", errs)
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	type errorBody struct {
+		Code string `json:"code"`
+	}
+	doc, err := openapi.New(openapi.Info{Title: "test", Version: "1"}, []openapi.Operation{
+		{Method: http.MethodGet, Path: "/items/{id}", Responses: map[int]any{http.StatusOK: createRequest{}}},
+		{Method: http.MethodDelete, Path: "/items/{id}", Responses: map[int]any{http.StatusNoContent: nil}},
+	}, openapi.WithErrorResponse(errorBody{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		status int
+		body   string
+		fields []string
+	}{
+		{"корректный ответ", http.MethodGet, http.StatusOK, `{"name":"x","members":[{"user_id":"u1","is_active":true}]}`, nil},
+		{"переименованное поле", http.MethodGet, http.StatusOK, `{"name":"x","members":[{"userId":"u1"}]}`, []string{"members[0].user_id", "members[0].userId"}},
+		{"неверный тип", http.MethodGet, http.StatusOK, `{"name":"x","limit":"5"}`, []string{"limit"}},
+		{"ошибка по схеме default", http.MethodGet, http.StatusNotFound, `{"code":"NOT_FOUND"}`, nil},
+		{"неописанный код", http.MethodGet, http.StatusCreated, `{}`, []string{"status"}},
+		{"ответ без тела", http.MethodDelete, http.StatusNoContent, ``, nil},
+		{"тело у ответа без тела", http.MethodDelete, http.StatusNoContent, `{}`, []string{"body"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := doc.ValidateResponse(tt.method, "/items/{id}", tt.status, []byte(tt.body))
+			if len(errs) != len(tt.fields) {
+				t.Fatalf("ожидались ошибки в %v, получили %+v", tt.fields, errs)
+			}
+			for i, f := range tt.fields {
+				if errs[i].Field != f {
+					t.Errorf("ошибка %d: ожидалось поле %s, получили %s", i, f, errs[i].Field)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"prreviewer/internal/validate"
+)
+
+// Schema — подмножество Schema Object OpenAPI 3.0, достаточное для моделей сервиса.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+const refPrefix = "#/components/schemas/"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// generator строит схемы по Go-типам; именованные структуры выносятся в components.
+type generator struct {
+	schemas map[string]*Schema
+}
+
+func (g *generator) schemaOf(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Pointer:
+		s := g.schemaOf(t.Elem())
+		if s.Ref != "" {
+			return s
+		}
+		nullable := *s
+		nullable.Nullable = true
+		return &nullable
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := componentName(t)
+		if _, ok := g.schemas[name]; !ok {
+			// Заглушка до заполнения защищает от бесконечной рекурсии.
+			g.schemas[name] = &Schema{}
+			*g.schemas[name] = *g.structSchema(t)
+		}
+		return &Schema{Ref: refPrefix + name}
+	}
+	// interface{} и прочее — любое значение.
+	return &Schema{}
+}
+
+// structSchema описывает поля по тегам json, ограничения берутся из тегов validate.
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		name, _, _ := strings.Cut(tag, ",")
+		if tag == "-" {
+			continue
+		}
+		// Поля встроенной структуры, как и в encoding/json, поднимаются на уровень выше.
+		if f.Anonymous && name == "" && derefType(f.Type).Kind() == reflect.Struct {
+			embedded := g.structSchema(derefType(f.Type))
+			for k, v := range embedded.Properties {
+				s.Properties[k] = v
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		prop := g.schemaOf(f.Type)
+		for _, rule := range validate.Rules(f.Tag.Get("validate")) {
+			if rule.Name == "required" {
+				s.Required = append(s.Required, name)
+				continue
+			}
+			prop = constrain(prop, rule)
+		}
+		s.Properties[name] = prop
+	}
+	return s
+}
+
+// constrain переносит правило validate в ограничение схемы.
+func constrain(s *Schema, rule validate.Rule) *Schema {
+	if s.Ref != "" {
+		return s
+	}
+	c := *s
+	n, err := strconv.Atoi(rule.Param)
+	switch {
+	case rule.Name == "oneof":
+		c.Enum = strings.Fields(rule.Param)
+	case err != nil:
+		return s
+	case rule.Name == "max" && c.Type == "string":
+		c.MaxLength = &n
+	case rule.Name == "max" && c.Type == "array":
+		c.MaxItems = &n
+	case rule.Name == "min" && c.Type == "string":
+		c.MinLength = &n
+	case rule.Name == "min" && c.Type == "integer":
+		c.Minimum = &n
+	}
+	return &c
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// componentName делает имя схемы из имени типа: createPRRequest -> CreatePRRequest.
+func componentName(t reflect.Type) string {
+	r := []rune(t.Name())
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
@@ -0,0 +1,240 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const bearerScheme = "bearerAuth"
+
+// Param описывает строковый query-параметр операции.
+type Param struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// Operation описывает маршрут API. Request и значения Responses — образцы
+// значений (обычно нулевые структуры), по типам которых строятся схемы;
+// nil в Responses означает ответ без тела.
+type Operation struct {
+	Method      string
+	Path        string
+	Summary     string
+	Tag         string
+	Auth        bool
+	Deprecated  bool    // маршрут оставлен для старых клиентов
+	PathParams  []Param // параметры пути "{name}", всегда обязательные
+	Query       []Param
+	ContentType string // тип тела запроса, по умолчанию application/json
+	Request     any
+	Responses   map[int]any
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Document — сгенерированный OpenAPI-документ и схемы тел запросов для валидации.
+type Document struct {
+	OpenAPI    string                                `json:"openapi"`
+	Info       Info                                  `json:"info"`
+	Paths      map[string]map[string]operationObject `json:"paths"`
+	Components components                            `json:"components"`
+
+	requests  map[string]*Schema
+	responses map[string]map[string]*Schema // "METHOD /path" -> код или default -> схема тела, nil — без тела
+	raw       []byte
+}
+
+type components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]securityScheme `json:"securitySchemes"`
+}
+
+type securityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+type operationObject struct {
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+	Parameters  []parameter           `json:"parameters,omitempty"`
+	RequestBody *body                 `json:"requestBody,omitempty"`
+	Responses   map[string]response   `json:"responses"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type body struct {
+	Required bool                 `json:"required"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Description string               `json:"description"`
+	Content     map[string]mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Option func(*options)
+
+type options struct {
+	errorSample   any
+	problemSample any
+}
+
+// WithErrorResponse добавляет ко всем операциям ответ default с телом ошибки.
+func WithErrorResponse(sample any) Option {
+	return func(o *options) { o.errorSample = sample }
+}
+
+// WithProblemResponse добавляет к ответу default вариант application/problem+json
+// (RFC 7807); действует вместе с WithErrorResponse.
+func WithProblemResponse(sample any) Option {
+	return func(o *options) { o.problemSample = sample }
+}
+
+// New генерирует документ по описаниям операций.
+func New(info Info, ops []Operation, opts ...Option) (*Document, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	g := &generator{schemas: map[string]*Schema{}}
+	d := &Document{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   map[string]map[string]operationObject{},
+		Components: components{
+			Schemas: g.schemas,
+			SecuritySchemes: map[string]securityScheme{
+				bearerScheme: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+		requests:  map[string]*Schema{},
+		responses: map[string]map[string]*Schema{},
+	}
+
+	var errContent map[string]mediaType
+	if o.errorSample != nil {
+		errContent = map[string]mediaType{"application/json": {Schema: g.schemaOf(reflect.TypeOf(o.errorSample))}}
+		if o.problemSample != nil {
+			errContent["application/problem+json"] = mediaType{Schema: g.schemaOf(reflect.TypeOf(o.problemSample))}
+		}
+	}
+
+	for _, op := range ops {
+		obj := operationObject{
+			Summary:    op.Summary,
+			Deprecated: op.Deprecated,
+			Responses:  map[string]response{},
+		}
+		if op.Tag != "" {
+			obj.Tags = []string{op.Tag}
+		}
+		if op.Auth {
+			obj.Security = []map[string][]string{{bearerScheme: {}}}
+		}
+		for _, p := range op.PathParams {
+			obj.Parameters = append(obj.Parameters, parameter{
+				Name:        p.Name,
+				In:          "path",
+				Description: p.Description,
+				Required:    true,
+				Schema:      &Schema{Type: "string"},
+			})
+		}
+		for _, p := range op.Query {
+			obj.Parameters = append(obj.Parameters, parameter{
+				Name:        p.Name,
+				In:          "query",
+				Description: p.Description,
+				Required:    p.Required,
+				Schema:      &Schema{Type: "string"},
+			})
+		}
+		if op.Request != nil {
+			s := g.schemaOf(reflect.TypeOf(op.Request))
+			ct := op.ContentType
+			if ct == "" {
+				ct = "application/json"
+			}
+			obj.RequestBody = &body{Required: true, Content: map[string]mediaType{ct: {Schema: s}}}
+			if ct == "application/json" {
+				d.requests[op.Method+" "+op.Path] = s
+			}
+		}
+		responses := map[string]*Schema{}
+		for code, sample := range op.Responses {
+			resp := response{Description: http.StatusText(code)}
+			var s *Schema
+			if sample != nil {
+				s = g.schemaOf(reflect.TypeOf(sample))
+				resp.Content = map[string]mediaType{"application/json": {Schema: s}}
+			}
+			obj.Responses[strconv.Itoa(code)] = resp
+			responses[strconv.Itoa(code)] = s
+		}
+		if errContent != nil {
+			obj.Responses["default"] = response{Description: "Ошибка", Content: errContent}
+			responses["default"] = errContent["application/json"].Schema
+		}
+		d.responses[op.Method+" "+op.Path] = responses
+
+		if d.Paths[op.Path] == nil {
+			d.Paths[op.Path] = map[string]operationObject{}
+		}
+		d.Paths[op.Path][strings.ToLower(op.Method)] = obj
+	}
+
+	raw, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	d.raw = raw
+	return d, nil
+}
+
+// HasRequestSchema сообщает, проверяется ли тело запроса для маршрута.
+func (d *Document) HasRequestSchema(method, path string) bool {
+	_, ok := d.requests[method+" "+path]
+	return ok
+}
+
+// Routes возвращает описанные маршруты в виде "METHOD /path".
+func (d *Document) Routes() []string {
+	routes := []string{}
+	for path, ops := range d.Paths {
+		for method := range ops {
+			routes = append(routes, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(routes)
+	return routes
+}
+
+// ServeHTTP отдает документ в JSON.
+func (d *Document) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(d.raw)
+}
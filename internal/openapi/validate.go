@@ -0,0 +1,155 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FieldError — нарушение схемы в конкретном поле тела запроса.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Validate проверяет JSON-тело запроса по схеме операции. Возвращает nil,
+// если у операции нет тела или тело не является JSON — такие ошибки
+// разбирает сам обработчик.
+func (d *Document) Validate(method, path string, body []byte) []FieldError {
+	s, ok := d.requests[method+" "+path]
+	if !ok {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil
+	}
+
+	var errs []FieldError
+	d.validate(s, v, "", false, &errs)
+	return errs
+}
+
+// ValidateResponse проверяет ответ операции: код должен быть описан в
+// документе (ошибки — ответом default), а тело — соответствовать схеме этого
+// кода. В отличие от Validate поле, которого нет в схеме, тоже считается
+// ошибкой, чтобы переименование поля модели не проходило незаметно. path —
+// шаблон маршрута из документа, например "/pullRequest/{id}/timeline".
+func (d *Document) ValidateResponse(method, path string, status int, body []byte) []FieldError {
+	responses, ok := d.responses[method+" "+path]
+	if !ok {
+		return []FieldError{{Field: "route", Message: "маршрут не описан"}}
+	}
+	s, ok := responses[strconv.Itoa(status)]
+	if !ok && status >= 400 {
+		s, ok = responses["default"]
+	}
+	if !ok {
+		return []FieldError{{Field: "status", Message: fmt.Sprintf("код %d не описан", status)}}
+	}
+	if s == nil {
+		if len(bytes.TrimSpace(body)) > 0 {
+			return []FieldError{{Field: "body", Message: "тело не описано"}}
+		}
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return []FieldError{{Field: "body", Message: "ожидался JSON"}}
+	}
+
+	var errs []FieldError
+	d.validate(s, v, "", true, &errs)
+	return errs
+}
+
+// validate проверяет значение v по схеме s; strict запрещает поля объекта,
+// которых нет в схеме.
+func (d *Document) validate(s *Schema, v any, field string, strict bool, errs *[]FieldError) {
+	if s.Ref != "" {
+		s = d.Components.Schemas[strings.TrimPrefix(s.Ref, refPrefix)]
+	}
+	if v == nil || s.Type == "" {
+		// null равносилен отсутствию поля, обязательность проверяет родитель.
+		return
+	}
+
+	fail := func(msg string) {
+		name := field
+		if name == "" {
+			name = "body"
+		}
+		*errs = append(*errs, FieldError{Field: name, Message: msg})
+	}
+
+	switch s.Type {
+	case "string":
+		if _, ok := v.(string); !ok {
+			fail("ожидалась строка")
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			fail("ожидалось true или false")
+		}
+	case "integer":
+		n, ok := v.(json.Number)
+		if _, err := n.Int64(); !ok || err != nil {
+			fail("ожидалось целое число")
+		}
+	case "number":
+		if _, ok := v.(json.Number); !ok {
+			fail("ожидалось число")
+		}
+	case "array":
+		items, ok := v.([]any)
+		if !ok {
+			fail("ожидался массив")
+			return
+		}
+		for i, item := range items {
+			d.validate(s.Items, item, fmt.Sprintf("%s[%d]", field, i), strict, errs)
+		}
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			fail("ожидался объект")
+			return
+		}
+		for _, name := range s.Required {
+			if obj[name] == nil {
+				*errs = append(*errs, FieldError{Field: join(field, name), Message: "обязательное поле"})
+			}
+		}
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			switch p, ok := s.Properties[k]; {
+			case ok:
+				d.validate(p, obj[k], join(field, k), strict, errs)
+			case s.AdditionalProperties != nil:
+				d.validate(s.AdditionalProperties, obj[k], join(field, k), strict, errs)
+			case strict:
+				*errs = append(*errs, FieldError{Field: join(field, k), Message: "поле не описано в схеме"})
+			}
+		}
+	}
+}
+
+func join(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
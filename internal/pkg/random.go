@@ -1,26 +1,41 @@
 package pkg
 
 import (
-	"math/rand"
+	"context"
+	"math/rand/v2"
 	"sync"
-	"time"
 )
 
+// SharedRand — общий генератор math/rand/v2: безопасен для параллельного
+// использования и засевается криптографически при старте процесса.
+type SharedRand struct{}
+
+func (SharedRand) Intn(n int) int {
+	return rand.IntN(n)
+}
+
+func (SharedRand) Shuffle(n int, swap func(i, j int)) {
+	rand.Shuffle(n, swap)
+}
+
+// LockedRand — генератор с собственным состоянием под мьютексом.
 type LockedRand struct {
 	mu  sync.Mutex
 	rng *rand.Rand
 }
 
-func NewLockedRand() *LockedRand {
+// NewSeededRand возвращает генератор с фиксированным зерном: одинаковое зерно
+// дает одинаковую последовательность.
+func NewSeededRand(seed int64) *LockedRand {
 	return &LockedRand{
-		rng: rand.New(rand.NewSource(time.Now().UnixNano())),
+		rng: rand.New(rand.NewPCG(uint64(seed), 0)),
 	}
 }
 
 func (r *LockedRand) Intn(n int) int {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	return r.rng.Intn(n)
+	return r.rng.IntN(n)
 }
 
 func (r *LockedRand) Shuffle(n int, swap func(i, j int)) {
@@ -28,3 +43,16 @@ func (r *LockedRand) Shuffle(n int, swap func(i, j int)) {
 	defer r.mu.Unlock()
 	r.rng.Shuffle(n, swap)
 }
+
+type seedKey struct{}
+
+// WithSeed задает зерно случайного выбора для операций с ctx.
+func WithSeed(ctx context.Context, seed int64) context.Context {
+	return context.WithValue(ctx, seedKey{}, seed)
+}
+
+// SeedFromContext возвращает зерно, заданное WithSeed.
+func SeedFromContext(ctx context.Context) (int64, bool) {
+	seed, ok := ctx.Value(seedKey{}).(int64)
+	return seed, ok
+}
@@ -0,0 +1,99 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"prreviewer/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ApprovePR фиксирует одобрение PR ревьювером, если версия PR все еще равна version,
+// иначе возвращает ErrVersionConflict. Строка PR блокируется FOR SHARE, поэтому
+// параллельная замена ревьювера или merge дождется одобрения. Первое одобрение
+// фиксируется в pr_reviewers.first_action_at для SLA. Повторное одобрение не ошибка.
+func (r *Repository) ApprovePR(ctx context.Context, prID, userID string, version int) error {
+	var matched int
+	err := r.conn(ctx).QueryRow(ctx, `
+		WITH pr AS (
+			SELECT pull_request_id FROM pull_requests
+			WHERE pull_request_id=$1 AND version=$3
+			FOR SHARE
+		), ins AS (
+			INSERT INTO approvals(pull_request_id, user_id)
+			SELECT pull_request_id, $2 FROM pr
+			ON CONFLICT (pull_request_id, user_id) DO NOTHING
+		), acted AS (
+			UPDATE pr_reviewers SET first_action_at = NOW()
+			WHERE pull_request_id IN (SELECT pull_request_id FROM pr)
+				AND user_id = $2 AND first_action_at IS NULL
+		)
+		SELECT COUNT(*) FROM pr`,
+		prID, userID, version).Scan(&matched)
+	if err != nil {
+		return mapPgError(err)
+	}
+	if matched == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+func getApprovals(ctx context.Context, q querier, prID string) ([]string, error) {
+	rows, err := q.Query(ctx, sqlPRApprovals, prID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	approvals := []string{}
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, uid)
+	}
+	return approvals, rows.Err()
+}
+
+// SetTeamPolicy сохраняет политику целиком; незаданные настройки назначения
+// получают значения по умолчанию из миграции.
+func (r *Repository) SetTeamPolicy(ctx context.Context, p models.TeamPolicy) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO team_policies(team_name, require_approvals, required_approvals,
+			reviewer_count, assignment_strategy, cross_team_fallback, require_manager, mandatory_reviewer,
+			auto_reassign)
+		VALUES($1, $2, $3, COALESCE($4, 2), COALESCE(NULLIF($5, ''), 'random'),
+			COALESCE($6, false), COALESCE($7, false), NULLIF($8, ''), COALESCE($9, false))
+		ON CONFLICT(team_name) DO UPDATE
+		SET require_approvals=EXCLUDED.require_approvals,
+			required_approvals=EXCLUDED.required_approvals,
+			reviewer_count=EXCLUDED.reviewer_count,
+			assignment_strategy=EXCLUDED.assignment_strategy,
+			cross_team_fallback=EXCLUDED.cross_team_fallback,
+			require_manager=EXCLUDED.require_manager,
+			mandatory_reviewer=EXCLUDED.mandatory_reviewer,
+			auto_reassign=EXCLUDED.auto_reassign`,
+		p.TeamName, p.RequireApprovals, p.RequiredApprovals,
+		p.ReviewerCount, p.AssignmentStrategy, p.CrossTeamFallback, p.RequireManager, p.MandatoryReviewer,
+		p.AutoReassign)
+	return mapPgError(err)
+}
+
+func (r *Repository) GetTeamPolicy(ctx context.Context, teamName string) (*models.TeamPolicy, error) {
+	var p models.TeamPolicy
+	err := r.conn(ctx).QueryRow(ctx, `
+		SELECT team_name, require_approvals, required_approvals,
+			reviewer_count, assignment_strategy, cross_team_fallback, require_manager, mandatory_reviewer,
+			auto_reassign
+		FROM team_policies WHERE team_name=$1`,
+		teamName).Scan(&p.TeamName, &p.RequireApprovals, &p.RequiredApprovals,
+		&p.ReviewerCount, &p.AssignmentStrategy, &p.CrossTeamFallback, &p.RequireManager, &p.MandatoryReviewer,
+		&p.AutoReassign)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &p, err
+}
@@ -0,0 +1,127 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"prreviewer/internal/models"
+)
+
+// archiveStatements переносят PR из $1 в архив: ревьюверы — в
+// pull_request_reviewers_archive, одобрения — в массив approved_by.
+// История назначений остается в assignment_events.
+var archiveStatements = []string{
+	`INSERT INTO pull_requests_archive(pull_request_id, pull_request_name, author_id, status, created_at,
+		merged_at, closed_at, version, repository, changed_paths, required_tags, labels, priority,
+		url, branch, description, required_approvals, depends_on, approved_by)
+	SELECT p.pull_request_id, p.pull_request_name, p.author_id, p.status, p.created_at,
+		p.merged_at, p.closed_at, p.version, p.repository, p.changed_paths, p.required_tags, p.labels, p.priority,
+		p.url, p.branch, p.description, p.required_approvals, p.depends_on,
+		COALESCE((SELECT array_agg(a.user_id ORDER BY a.user_id) FROM approvals a
+			WHERE a.pull_request_id = p.pull_request_id), '{}')
+	FROM pull_requests p WHERE p.pull_request_id = ANY($1)`,
+	`INSERT INTO pull_request_reviewers_archive(pull_request_id, user_id, assigned_at, first_action_at)
+	SELECT pull_request_id, user_id, assigned_at, first_action_at FROM pr_reviewers WHERE pull_request_id = ANY($1)`,
+	"DELETE FROM approvals WHERE pull_request_id = ANY($1)",
+	"DELETE FROM pr_reviewers WHERE pull_request_id = ANY($1)",
+	"DELETE FROM pull_requests WHERE pull_request_id = ANY($1)",
+}
+
+// ArchiveMergedPRs переносит в архив до limit PR, смерженных раньше mergedBefore,
+// и возвращает их число. Строки, заблокированные другой транзакцией, пропускаются.
+func (r *Repository) ArchiveMergedPRs(ctx context.Context, mergedBefore time.Time, limit int) (int, error) {
+	var ids []string
+	err := r.WithTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `
+			SELECT pull_request_id FROM pull_requests
+			WHERE status = 'MERGED' AND merged_at < $1
+			ORDER BY merged_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED`,
+			mergedBefore, limit)
+		if err != nil {
+			return err
+		}
+		ids, err = pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		for _, sql := range archiveStatements {
+			if _, err := tx.Exec(ctx, sql, ids); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// GetArchivedPR возвращает PR из архива или ErrNotFound.
+func (r *Repository) GetArchivedPR(ctx context.Context, prID string) (*models.ArchivedPR, error) {
+	var pr *models.ArchivedPR
+	err := r.read(ctx, "GetArchivedPR", func(q querier) error {
+		var err error
+		pr, err = getArchivedPR(ctx, q, prID)
+		return err
+	})
+	return pr, err
+}
+
+func getArchivedPR(ctx context.Context, q querier, prID string) (*models.ArchivedPR, error) {
+	var pr models.ArchivedPR
+	var createdAt, mergedAt, closedAt *time.Time
+	var archivedAt time.Time
+
+	err := q.QueryRow(ctx, `
+		SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at, closed_at, version,
+			COALESCE(repository, ''), changed_paths, required_tags, labels, priority,
+			COALESCE(url, ''), COALESCE(branch, ''), COALESCE(description, ''), required_approvals,
+			depends_on, approved_by, archived_at
+		FROM pull_requests_archive WHERE pull_request_id=$1`,
+		prID).Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.Status, &createdAt, &mergedAt, &closedAt, &pr.Version,
+		&pr.Repository, &pr.ChangedPaths, &pr.RequiredTags, &pr.Labels, &pr.Priority,
+		&pr.URL, &pr.Branch, &pr.Description, &pr.RequiredApprovals, &pr.DependsOn, &pr.ApprovedBy, &archivedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	pr.CreatedAt, pr.MergedAt, pr.ClosedAt = formatTime(createdAt), formatTime(mergedAt), formatTime(closedAt)
+	pr.ArchivedAt = archivedAt.Format(time.RFC3339)
+
+	rows, err := q.Query(ctx,
+		"SELECT user_id FROM pull_request_reviewers_archive WHERE pull_request_id=$1 ORDER BY user_id",
+		prID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pr.AssignedReviewers = []string{}
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			return nil, err
+		}
+		pr.AssignedReviewers = append(pr.AssignedReviewers, uid)
+	}
+	return &pr, rows.Err()
+}
+
+// formatTime переводит необязательную метку времени в RFC 3339.
+func formatTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.Format(time.RFC3339)
+	return &s
+}
@@ -0,0 +1,89 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TestArchiveMergedPRs переносит в архив PR, смерженный раньше порога, вместе
+// с ревьюверами и одобрениями и не трогает более свежий. Нужна база с
+// примененными миграциями в TEST_DATABASE_URL (см. BenchmarkInsertReviewers);
+// тестовые строки удаляются по завершении.
+func TestArchiveMergedPRs(t *testing.T) {
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	db, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	cleanup := func() {
+		for _, sql := range []string{
+			"DELETE FROM pull_request_reviewers_archive WHERE pull_request_id LIKE 'archive-test-%'",
+			"DELETE FROM pull_requests_archive WHERE pull_request_id LIKE 'archive-test-%'",
+			"DELETE FROM approvals WHERE pull_request_id LIKE 'archive-test-%'",
+			"DELETE FROM pr_reviewers WHERE pull_request_id LIKE 'archive-test-%'",
+			"DELETE FROM pull_requests WHERE pull_request_id LIKE 'archive-test-%'",
+			"DELETE FROM users WHERE team_name = 'archive-test'",
+			"DELETE FROM teams WHERE team_name = 'archive-test'",
+		} {
+			_, _ = db.Exec(ctx, sql)
+		}
+	}
+	cleanup()
+	defer cleanup()
+
+	// Даты в прошлом веке не пересекаются с другими данными тестовой базы.
+	cutoff := time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)
+	for _, sql := range []string{
+		"INSERT INTO teams(team_name) VALUES('archive-test')",
+		`INSERT INTO users(user_id, username, team_name) VALUES
+			('archive-test-author', 'author', 'archive-test'),
+			('archive-test-a', 'a', 'archive-test'),
+			('archive-test-b', 'b', 'archive-test')`,
+		`INSERT INTO pull_requests(pull_request_id, pull_request_name, author_id, status, merged_at) VALUES
+			('archive-test-old', 'old', 'archive-test-author', 'MERGED', '2000-01-01T00:00:00Z'),
+			('archive-test-new', 'new', 'archive-test-author', 'MERGED', '2000-01-03T00:00:00Z')`,
+		`INSERT INTO pr_reviewers(pull_request_id, user_id) VALUES
+			('archive-test-old', 'archive-test-a'), ('archive-test-old', 'archive-test-b'),
+			('archive-test-new', 'archive-test-a')`,
+		"INSERT INTO approvals(pull_request_id, user_id) VALUES('archive-test-old', 'archive-test-a')",
+	} {
+		if _, err := db.Exec(ctx, sql); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := New(db)
+	if _, err := r.ArchiveMergedPRs(ctx, cutoff, 500); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.GetPR(ctx, "archive-test-old"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("PR старше порога должен уйти из pull_requests, получили %v", err)
+	}
+	if _, err := r.GetPR(ctx, "archive-test-new"); err != nil {
+		t.Errorf("PR новее порога должен остаться: %v", err)
+	}
+	pr, err := r.GetArchivedPR(ctx, "archive-test-old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(pr.AssignedReviewers, []string{"archive-test-a", "archive-test-b"}) ||
+		!slices.Equal(pr.ApprovedBy, []string{"archive-test-a"}) {
+		t.Errorf("в архиве ожидались ревьюверы и одобрения, получили %+v", pr)
+	}
+	if _, err := r.GetArchivedPR(ctx, "archive-test-new"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("PR новее порога не должен попасть в архив, получили %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"prreviewer/internal/models"
+)
+
+// InsertAuditEntry дописывает запись в журнал аудита. Команда запоминается и
+// по team_id, чтобы запись находилась по команде после ее переименования.
+func (r *Repository) InsertAuditEntry(ctx context.Context, e models.AuditEntry) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO audit_log(action, actor, team_name, team_id, user_id, pull_request_id, details)
+		VALUES($1, NULLIF($2, ''), NULLIF($3, ''), (SELECT team_id FROM teams WHERE team_name = $3),
+			NULLIF($4, ''), NULLIF($5, ''), $6)`,
+		e.Action, e.Actor, e.TeamName, e.UserID, e.PRID, e.Details)
+	return err
+}
+
+// ListAuditEntries возвращает страницу журнала от новых к старым и общее число
+// записей под фильтром. Фильтр по команде находит и записи, сделанные до ее
+// переименования; team_name в них остается прежним.
+func (r *Repository) ListAuditEntries(
+	ctx context.Context,
+	filter models.AuditFilter,
+	page models.Page,
+) ([]models.AuditEntry, int, error) {
+	const where = `
+		WHERE ($1 = '' OR user_id = $1)
+			AND ($2 = '' OR team_id = (SELECT team_id FROM teams WHERE team_name = $2)
+				OR team_id IS NULL AND team_name = $2)
+			AND ($3 = '' OR action = $3)
+			AND ($4::timestamptz IS NULL OR created_at >= $4)
+			AND ($5::timestamptz IS NULL OR created_at < $5)
+			AND ($6 = '' OR pull_request_id = $6)`
+	args := []any{filter.UserID, filter.TeamName, filter.Action, filter.From, filter.To, filter.PRID}
+
+	var total int
+	if err := r.conn(ctx).QueryRow(ctx, "SELECT COUNT(*) FROM audit_log"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT id, action, COALESCE(actor, ''), COALESCE(team_name, ''), COALESCE(user_id, ''),
+			COALESCE(pull_request_id, ''), details, created_at
+		FROM audit_log`+where+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $7 OFFSET $8`,
+		append(args, page.Limit, page.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var e models.AuditEntry
+		var createdAt time.Time
+		err := rows.Scan(&e.ID, &e.Action, &e.Actor, &e.TeamName, &e.UserID, &e.PRID, &e.Details, &createdAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		e.CreatedAt = createdAt.Format(time.RFC3339)
+		entries = append(entries, e)
+	}
+
+	return entries, total, rows.Err()
+}
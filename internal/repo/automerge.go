@@ -0,0 +1,32 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"prreviewer/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *Repository) SetTeamAutoMerge(ctx context.Context, cfg models.TeamAutoMerge) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO team_auto_merge(team_name, enabled, provider, repository, required_approvals)
+		VALUES($1, $2, $3, $4, $5)
+		ON CONFLICT(team_name) DO UPDATE
+		SET enabled=$2, provider=$3, repository=$4, required_approvals=$5`,
+		cfg.TeamName, cfg.Enabled, cfg.Provider, cfg.Repository, cfg.RequiredApprovals)
+	return mapPgError(err)
+}
+
+func (r *Repository) GetTeamAutoMerge(ctx context.Context, teamName string) (*models.TeamAutoMerge, error) {
+	var cfg models.TeamAutoMerge
+	err := r.conn(ctx).QueryRow(ctx, `
+		SELECT team_name, enabled, provider, repository, required_approvals
+		FROM team_auto_merge WHERE team_name=$1`,
+		teamName).Scan(&cfg.TeamName, &cfg.Enabled, &cfg.Provider, &cfg.Repository, &cfg.RequiredApprovals)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return &cfg, err
+}
@@ -0,0 +1,158 @@
+// Package cached оборачивает service.Repository кэшем для GetTeam и GetStats.
+// Изменения команд и пользователей через обертку сбрасывают оба кэша сразу, а
+// кэши других экземпляров сбрасывает Drop по сообщениям, которые рассылает
+// сам репозиторий (repo.WithInvalidation); изменения PR попадают в статистику
+// по истечении TTL.
+package cached
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"prreviewer/internal/cache"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+	"prreviewer/internal/service"
+)
+
+const (
+	teamPrefix  = "team:"
+	statsPrefix = "stats:"
+)
+
+// allPrefixes — все группы ключей, которые сбрасывают изменения команд и пользователей.
+var allPrefixes = []string{teamPrefix, statsPrefix}
+
+type Repository struct {
+	service.Repository
+
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+func New(r service.Repository, c cache.Cache, ttl time.Duration) *Repository {
+	return &Repository{Repository: r, cache: c, ttl: ttl}
+}
+
+func (r *Repository) GetTeam(ctx context.Context, name string) (*models.Team, error) {
+	return load(ctx, r, teamPrefix+name, func() (*models.Team, error) {
+		return r.Repository.GetTeam(ctx, name)
+	})
+}
+
+func (r *Repository) GetStats(ctx context.Context, filter models.StatsFilter, page models.Page) (*models.Stats, error) {
+	key := fmt.Sprintf("%s%d:%d:%s:%s", statsPrefix, page.Limit, page.Offset, unixKey(filter.From), unixKey(filter.To))
+	return load(ctx, r, key, func() (*models.Stats, error) {
+		return r.Repository.GetStats(ctx, filter, page)
+	})
+}
+
+// unixKey — часть ключа кэша для границы диапазона; пустая строка без границы.
+func unixKey(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func (r *Repository) CreateTeam(ctx context.Context, team models.Team) error {
+	defer r.invalidate(ctx)
+	return r.Repository.CreateTeam(ctx, team)
+}
+
+func (r *Repository) ImportTeams(ctx context.Context, teams []models.Team) ([]models.TeamImportResult, error) {
+	defer r.invalidate(ctx)
+	return r.Repository.ImportTeams(ctx, teams)
+}
+
+func (r *Repository) RenameTeam(ctx context.Context, oldName, newName string) error {
+	defer r.invalidate(ctx)
+	return r.Repository.RenameTeam(ctx, oldName, newName)
+}
+
+func (r *Repository) UpdateUserActiveStatus(ctx context.Context, uid string, active bool) error {
+	defer r.invalidate(ctx)
+	return r.Repository.UpdateUserActiveStatus(ctx, uid, active)
+}
+
+func (r *Repository) SetUserTags(ctx context.Context, uid string, tags []string) error {
+	defer r.invalidate(ctx)
+	return r.Repository.SetUserTags(ctx, uid, tags)
+}
+
+func (r *Repository) DeactivateTeamMembers(ctx context.Context, teamName string) ([]string, error) {
+	defer r.invalidate(ctx)
+	return r.Repository.DeactivateTeamMembers(ctx, teamName)
+}
+
+func (r *Repository) DeactivateTeamAndReassignPRs(
+	ctx context.Context,
+	teamName string,
+	rng interface{ Intn(int) int },
+) (*repo.DeactivationResult, error) {
+	defer r.invalidate(ctx)
+	return r.Repository.DeactivateTeamAndReassignPRs(ctx, teamName, rng)
+}
+
+func (r *Repository) DeleteUserAndReassignPRs(
+	ctx context.Context,
+	uid string,
+	rng interface{ Intn(int) int },
+) (*repo.UserDeletionResult, error) {
+	defer r.invalidate(ctx)
+	return r.Repository.DeleteUserAndReassignPRs(ctx, uid, rng)
+}
+
+// invalidate сбрасывает все команды: пользователь мог перейти из одной команды
+// в другую. Сброс после записи не дает закэшировать данные до изменения.
+func (r *Repository) invalidate(ctx context.Context) {
+	r.Drop(ctx, allPrefixes)
+}
+
+// Drop сбрасывает локальный кэш по префиксам, например по сообщению
+// repo.InvalidationBus о записи другого процесса; пустой список сбрасывает все.
+func (r *Repository) Drop(ctx context.Context, prefixes []string) {
+	if len(prefixes) == 0 {
+		prefixes = allPrefixes
+	}
+	for _, prefix := range prefixes {
+		if err := r.cache.DeletePrefix(ctx, prefix); err != nil {
+			logging.FromContext(ctx).Warn("cache invalidation failed", "prefix", prefix, "error", err)
+		}
+	}
+}
+
+// load возвращает значение из кэша или загружает и кэширует его. Сбой кэша
+// не ломает запрос: значение читается из репозитория. Чтение с
+// repo.ReadFromPrimary идет мимо кэша, а свежее значение заменяет закэшированное.
+func load[T any](ctx context.Context, r *Repository, key string, fetch func() (T, error)) (T, error) {
+	log := logging.FromContext(ctx)
+
+	if !repo.IsPrimaryRead(ctx) {
+		data, ok, err := r.cache.Get(ctx, key)
+		if err != nil {
+			log.Warn("cache read failed", "key", key, "error", err)
+		}
+		if ok {
+			var v T
+			if err := json.Unmarshal(data, &v); err == nil {
+				return v, nil
+			}
+		}
+	}
+
+	v, err := fetch()
+	if err != nil {
+		return v, err
+	}
+	if data, err := json.Marshal(v); err == nil {
+		if err := r.cache.Set(ctx, key, data, r.ttl); err != nil {
+			log.Warn("cache write failed", "key", key, "error", err)
+		}
+	}
+	return v, nil
+}
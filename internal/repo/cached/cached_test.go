@@ -0,0 +1,144 @@
+package cached_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"prreviewer/internal/cache"
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+	"prreviewer/internal/repo/cached"
+	"prreviewer/internal/repo/memory"
+)
+
+func TestTeamCacheInvalidatedOnUserChange(t *testing.T) {
+	ctx := context.Background()
+	base := memory.New()
+	r := cached.New(base, cache.NewMemory(), time.Minute)
+
+	err := r.CreateTeam(ctx, models.Team{TeamName: "backend", Members: []models.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.GetTeam(ctx, "backend"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Запись в обход обертки не видна, пока запись кэша жива.
+	if err := base.UpdateUserActiveStatus(ctx, "u1", false); err != nil {
+		t.Fatal(err)
+	}
+	team, err := r.GetTeam(ctx, "backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !team.Members[0].IsActive {
+		t.Fatalf("ожидался ответ из кэша")
+	}
+
+	if err := r.SetUserTags(ctx, "u1", []string{"go"}); err != nil {
+		t.Fatal(err)
+	}
+	team, err = r.GetTeam(ctx, "backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if team.Members[0].IsActive || len(team.Members[0].Tags) != 1 {
+		t.Errorf("после изменения пользователя кэш должен сброситься, получили %+v", team.Members[0])
+	}
+}
+
+func TestStatsCachedPerPage(t *testing.T) {
+	ctx := context.Background()
+	base := memory.New()
+	r := cached.New(base, cache.NewMemory(), time.Minute)
+
+	first, err := r.GetStats(ctx, models.StatsFilter{}, models.Page{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := base.CreateTeam(ctx, models.Team{TeamName: "backend"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cachedStats, _ := r.GetStats(ctx, models.StatsFilter{}, models.Page{Limit: 10})
+	otherPage, _ := r.GetStats(ctx, models.StatsFilter{}, models.Page{Limit: 5})
+	if cachedStats.TotalTeams != first.TotalTeams {
+		t.Errorf("та же страница должна прийти из кэша, получили %d команд", cachedStats.TotalTeams)
+	}
+	if otherPage.TotalTeams != 1 {
+		t.Errorf("другая страница кэшируется отдельно, ожидалась 1 команда, получили %d", otherPage.TotalTeams)
+	}
+}
+
+// TestDropFromPeerMessage — сообщение о записи другого процесса без префиксов
+// сбрасывает весь кэш.
+func TestDropFromPeerMessage(t *testing.T) {
+	ctx := context.Background()
+	base := memory.New()
+	r := cached.New(base, cache.NewMemory(), time.Minute)
+
+	err := r.CreateTeam(ctx, models.Team{TeamName: "backend", Members: []models.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.GetTeam(ctx, "backend"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Запись воркера идет в базу в обход обертки этого процесса.
+	if err := base.UpdateUserActiveStatus(ctx, "u1", false); err != nil {
+		t.Fatal(err)
+	}
+	r.Drop(ctx, nil)
+	team, err := r.GetTeam(ctx, "backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if team.Members[0].IsActive {
+		t.Errorf("сообщение о записи должно сбросить кэш, получили %+v", team.Members[0])
+	}
+}
+
+// TestPrimaryReadBypassesCache — чтение "записал — прочитал" не получает
+// устаревшую команду из кэша.
+func TestPrimaryReadBypassesCache(t *testing.T) {
+	ctx := context.Background()
+	base := memory.New()
+	r := cached.New(base, cache.NewMemory(), time.Minute)
+
+	err := r.CreateTeam(ctx, models.Team{TeamName: "backend", Members: []models.TeamMember{
+		{UserID: "u1", Username: "Alice", IsActive: true},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.GetTeam(ctx, "backend"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := base.UpdateUserActiveStatus(ctx, "u1", false); err != nil {
+		t.Fatal(err)
+	}
+	team, err := r.GetTeam(repo.ReadFromPrimary(ctx), "backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if team.Members[0].IsActive {
+		t.Errorf("чтение с основной БД не должно брать кэш, получили %+v", team.Members[0])
+	}
+
+	// Свежее значение заменяет закэшированное и для обычных чтений.
+	team, err = r.GetTeam(ctx, "backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if team.Members[0].IsActive {
+		t.Errorf("кэш должен обновиться после чтения с основной БД, получили %+v", team.Members[0])
+	}
+}
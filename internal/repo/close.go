@@ -0,0 +1,82 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"prreviewer/internal/models"
+)
+
+// ClosePR переводит открытый PR в CLOSED и снимает с него ревьюверов: назначения
+// переносятся в pr_reviewers_archive, в историю пишется событие RELEASED.
+func (r *Repository) ClosePR(ctx context.Context, prID string, expectedVersion *int) error {
+	return r.WithTx(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			UPDATE pull_requests SET status='CLOSED', closed_at=NOW(), version=version+1
+			WHERE pull_request_id=$1 AND status IN `+openStatuses+` AND ($2::int IS NULL OR version=$2)`,
+			prID, expectedVersion)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrVersionConflict
+		}
+
+		rows, err := tx.Query(ctx,
+			"DELETE FROM pr_reviewers WHERE pull_request_id=$1 RETURNING user_id, assigned_at, first_action_at",
+			prID)
+		if err != nil {
+			return err
+		}
+		var released []string
+		var times [][2]*time.Time // assigned_at и first_action_at снятых ревьюверов
+		for rows.Next() {
+			var uid string
+			var assignedAt, firstActionAt *time.Time
+			if err := rows.Scan(&uid, &assignedAt, &firstActionAt); err != nil {
+				rows.Close()
+				return err
+			}
+			released = append(released, uid)
+			times = append(times, [2]*time.Time{assignedAt, firstActionAt})
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for i, uid := range released {
+			_, err = tx.Exec(ctx, `
+				INSERT INTO pr_reviewers_archive(pull_request_id, user_id, reason, assigned_at, first_action_at)
+				VALUES($1, $2, 'pr closed', $3, $4)
+				ON CONFLICT (pull_request_id, user_id) DO NOTHING`,
+				prID, uid, times[i][0], times[i][1])
+			if err != nil {
+				return err
+			}
+
+			err = insertAssignmentEvent(ctx, tx, models.AssignmentEvent{
+				PRID:      prID,
+				EventType: models.EventReleased,
+				UserID:    uid,
+				Reason:    "pr closed",
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		err = insertOutbox(ctx, tx, models.DomainEvent{
+			Type:    models.DomainPRClosed,
+			PRID:    prID,
+			Details: map[string]any{"released_reviewers": released},
+		})
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,49 @@
+package repo
+
+import (
+	"context"
+
+	"prreviewer/internal/models"
+)
+
+// DBStats читает статистику таблиц и индексов основной БД: таблицы в порядке
+// убывания последовательно прочитанных строк, индексы по таблицам.
+func (r *Repository) DBStats(ctx context.Context) (*models.DBStats, error) {
+	stats := &models.DBStats{Tables: []models.TableStats{}, Indexes: []models.IndexStats{}}
+
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT relname, seq_scan, seq_tup_read, COALESCE(idx_scan, 0), n_live_tup, n_dead_tup
+		FROM pg_stat_user_tables
+		ORDER BY seq_tup_read DESC, relname`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t models.TableStats
+		if err := rows.Scan(&t.Table, &t.SeqScan, &t.SeqTupRead, &t.IdxScan, &t.LiveTuples, &t.DeadTuples); err != nil {
+			return nil, err
+		}
+		stats.Tables = append(stats.Tables, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.conn(ctx).Query(ctx, `
+		SELECT relname, indexrelname, idx_scan, idx_tup_read, pg_relation_size(indexrelid)
+		FROM pg_stat_user_indexes
+		ORDER BY relname, indexrelname`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var i models.IndexStats
+		if err := rows.Scan(&i.Table, &i.Index, &i.Scans, &i.TuplesRead, &i.SizeBytes); err != nil {
+			return nil, err
+		}
+		stats.Indexes = append(stats.Indexes, i)
+	}
+	return stats, rows.Err()
+}
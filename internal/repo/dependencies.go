@@ -0,0 +1,76 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+
+	"prreviewer/internal/models"
+)
+
+// blockedCond — открытые PR, среди зависимостей которых есть открытый PR.
+const blockedCond = `p.status IN ` + openStatuses + `
+	AND EXISTS (SELECT 1 FROM pull_requests d
+		WHERE d.pull_request_id = ANY(p.depends_on) AND d.status IN ` + openStatuses + `)`
+
+// GetOpenPRs возвращает те из prIDs, что еще открыты, по возрастанию id.
+// Смерженные, закрытые, архивные и неизвестные PR отбрасываются.
+func (r *Repository) GetOpenPRs(ctx context.Context, prIDs []string) ([]string, error) {
+	var ids []string
+	err := r.read(ctx, "GetOpenPRs", func(q querier) error {
+		rows, err := q.Query(ctx, `
+			SELECT pull_request_id FROM pull_requests
+			WHERE pull_request_id = ANY($1) AND status IN `+openStatuses+`
+			ORDER BY pull_request_id`,
+			prIDs)
+		if err != nil {
+			return err
+		}
+		ids, err = pgx.CollectRows(rows, pgx.RowTo[string])
+		return err
+	})
+	return ids, err
+}
+
+// ListBlockedPRs возвращает страницу открытых PR с открытыми зависимостями,
+// самые старые первыми, и их общее число.
+func (r *Repository) ListBlockedPRs(ctx context.Context, page models.Page) ([]models.BlockedPR, int, error) {
+	var (
+		prs   []models.BlockedPR
+		total int
+	)
+	err := r.read(ctx, "ListBlockedPRs", func(q querier) error {
+		if err := q.QueryRow(ctx, "SELECT COUNT(*) FROM pull_requests p WHERE "+blockedCond).Scan(&total); err != nil {
+			return err
+		}
+
+		rows, err := q.Query(ctx, `
+			SELECT p.pull_request_id, p.pull_request_name, p.author_id, p.status, COALESCE(p.url, ''),
+				ARRAY(SELECT d.pull_request_id FROM pull_requests d
+					WHERE d.pull_request_id = ANY(p.depends_on) AND d.status IN `+openStatuses+`
+					ORDER BY d.pull_request_id)
+			FROM pull_requests p
+			WHERE `+blockedCond+`
+			ORDER BY p.created_at, p.pull_request_id
+			LIMIT $1 OFFSET $2`,
+			page.Limit, page.Offset)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		prs = []models.BlockedPR{}
+		for rows.Next() {
+			var pr models.BlockedPR
+			if err := rows.Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.Status, &pr.URL, &pr.BlockedBy); err != nil {
+				return err
+			}
+			prs = append(prs, pr)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return prs, total, nil
+}
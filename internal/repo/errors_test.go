@@ -0,0 +1,57 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestMapPgError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"unique", &pgconn.PgError{Code: pgUniqueViolation}, ErrDuplicate},
+		{"foreign key", &pgconn.PgError{Code: pgForeignKeyViolation}, ErrForeignKey},
+		{"serialization", &pgconn.PgError{Code: pgSerializationFail}, ErrConflict},
+		{"deadlock", &pgconn.PgError{Code: pgDeadlockDetected}, ErrConflict},
+		{"lock timeout", &pgconn.PgError{Code: pgLockNotAvailable}, ErrConflict},
+		{"author check", &pgconn.PgError{Code: pgCheckViolation, ConstraintName: constraintReviewerNotAuthor}, ErrAuthorIsReviewer},
+		{"wrapped", fmt.Errorf("insert: %w", &pgconn.PgError{Code: pgUniqueViolation}), ErrDuplicate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := mapPgError(tt.err); !errors.Is(err, tt.want) {
+				t.Errorf("ожидалась %v, получили %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestMapPgErrorKeepsCause(t *testing.T) {
+	err := mapPgError(&pgconn.PgError{Code: pgSerializationFail})
+	if !isRetryableTxError(err) {
+		t.Errorf("конфликт транзакции должен оставаться повторяемым: %v", err)
+	}
+
+	other := &pgconn.PgError{Code: "42P01"}
+	if err := mapPgError(other); err != other {
+		t.Errorf("прочие ошибки должны возвращаться как есть, получили %v", err)
+	}
+	if err := mapPgError(nil); err != nil {
+		t.Errorf("nil должен остаться nil, получили %v", err)
+	}
+}
+
+func TestMapReviewerErrorWrapsGeneric(t *testing.T) {
+	err := mapReviewerError(&pgconn.PgError{Code: pgUniqueViolation})
+	if !errors.Is(err, ErrDuplicateReviewer) || !errors.Is(err, ErrDuplicate) {
+		t.Errorf("ожидалась ErrDuplicateReviewer и ErrDuplicate, получили %v", err)
+	}
+	if err := mapPgError(err); !errors.Is(err, ErrDuplicateReviewer) {
+		t.Errorf("повторный перевод не должен терять ErrDuplicateReviewer: %v", err)
+	}
+}
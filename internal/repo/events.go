@@ -0,0 +1,123 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"prreviewer/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ListAssignmentEvents возвращает историю назначений PR в порядке записи.
+func (r *Repository) ListAssignmentEvents(ctx context.Context, prID string) ([]models.AssignmentEvent, error) {
+	var events []models.AssignmentEvent
+	err := r.read(ctx, "ListAssignmentEvents", func(q querier) error {
+		rows, err := q.Query(ctx, `
+			SELECT id, pull_request_id, event_type, COALESCE(user_id, ''), COALESCE(new_user_id, ''),
+				COALESCE(reason, ''), created_at
+			FROM assignment_events
+			WHERE pull_request_id=$1
+			ORDER BY created_at, id`,
+			prID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		events = []models.AssignmentEvent{}
+		for rows.Next() {
+			var ev models.AssignmentEvent
+			var createdAt time.Time
+			err := rows.Scan(&ev.ID, &ev.PRID, &ev.EventType, &ev.UserID, &ev.NewUserID, &ev.Reason, &createdAt)
+			if err != nil {
+				return err
+			}
+			ev.CreatedAt = createdAt.Format(time.RFC3339)
+			events = append(events, ev)
+		}
+		return rows.Err()
+	})
+	return events, err
+}
+
+// ExportAssignmentEvents возвращает до limit событий назначения, записанных в
+// [from, to), в порядке (created_at, id) после курсора after (nil — с начала),
+// и курсор последнего из них; для пустой выборки курсор nil.
+func (r *Repository) ExportAssignmentEvents(
+	ctx context.Context,
+	from, to *time.Time,
+	after *models.EventCursor,
+	limit int,
+) ([]models.AssignmentEvent, *models.EventCursor, error) {
+	var afterAt *time.Time
+	var afterID int64
+	if after != nil {
+		afterAt, afterID = &after.CreatedAt, after.ID
+	}
+
+	events := []models.AssignmentEvent{}
+	var next *models.EventCursor
+	err := r.read(ctx, "ExportAssignmentEvents", func(q querier) error {
+		rows, err := q.Query(ctx, `
+			SELECT id, pull_request_id, event_type, COALESCE(user_id, ''), COALESCE(new_user_id, ''),
+				COALESCE(reason, ''), created_at
+			FROM assignment_events
+			WHERE ($1::timestamptz IS NULL OR created_at >= $1)
+				AND ($2::timestamptz IS NULL OR created_at < $2)
+				AND ($3::timestamptz IS NULL OR (created_at, id) > ($3, $4))
+			ORDER BY created_at, id
+			LIMIT $5`,
+			from, to, afterAt, afterID, limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		events, next = events[:0], nil
+		for rows.Next() {
+			var ev models.AssignmentEvent
+			var createdAt time.Time
+			err := rows.Scan(&ev.ID, &ev.PRID, &ev.EventType, &ev.UserID, &ev.NewUserID, &ev.Reason, &createdAt)
+			if err != nil {
+				return err
+			}
+			ev.CreatedAt = createdAt.Format(time.RFC3339)
+			events = append(events, ev)
+			next = &models.EventCursor{CreatedAt: createdAt, ID: ev.ID}
+		}
+		return rows.Err()
+	})
+	return events, next, err
+}
+
+func insertAssignmentEvent(ctx context.Context, tx pgx.Tx, ev models.AssignmentEvent) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO assignment_events(pull_request_id, event_type, user_id, new_user_id, reason)
+		VALUES($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''))`,
+		ev.PRID, ev.EventType, ev.UserID, ev.NewUserID, ev.Reason)
+	return err
+}
+
+// insertAssignmentEvents записывает события одной командой COPY.
+func insertAssignmentEvents(ctx context.Context, tx pgx.Tx, events []models.AssignmentEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	_, err := tx.CopyFrom(ctx,
+		pgx.Identifier{"assignment_events"},
+		[]string{"pull_request_id", "event_type", "user_id", "new_user_id", "reason"},
+		pgx.CopyFromSlice(len(events), func(i int) ([]any, error) {
+			ev := events[i]
+			return []any{ev.PRID, ev.EventType, nullIfEmpty(ev.UserID), nullIfEmpty(ev.NewUserID), nullIfEmpty(ev.Reason)}, nil
+		}))
+	return err
+}
+
+// nullIfEmpty заменяет пустую строку на NULL: в COPY нельзя использовать NULLIF.
+func nullIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,102 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+
+	"prreviewer/internal/models"
+)
+
+// SetUserIdentity привязывает пользователя к учетной записи во внешней системе
+// или отвязывает при пустом ExternalID. Учетная запись, уже привязанная к
+// другому пользователю, дает ErrDuplicate.
+func (r *Repository) SetUserIdentity(ctx context.Context, id models.UserIdentity) error {
+	return r.WithTx(ctx, func(tx pgx.Tx) error {
+		var exists bool
+		err := tx.QueryRow(ctx,
+			"SELECT EXISTS(SELECT 1 FROM users WHERE user_id=$1 AND deleted_at IS NULL)",
+			id.UserID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrNotFound
+		}
+
+		if id.ExternalID == "" {
+			_, err = tx.Exec(ctx,
+				"DELETE FROM user_identities WHERE user_id=$1 AND provider=$2",
+				id.UserID, id.Provider)
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO user_identities(user_id, provider, external_id)
+			VALUES($1, $2, $3)
+			ON CONFLICT(user_id, provider) DO UPDATE SET external_id=EXCLUDED.external_id`,
+			id.UserID, id.Provider, id.ExternalID)
+		return err
+	})
+}
+
+// GetUserIdentities возвращает учетные записи пользователя, упорядоченные по системе.
+func (r *Repository) GetUserIdentities(ctx context.Context, uid string) ([]models.UserIdentity, error) {
+	identities := []models.UserIdentity{}
+	err := r.read(ctx, "GetUserIdentities", func(q querier) error {
+		rows, err := q.Query(ctx,
+			"SELECT user_id, provider, external_id FROM user_identities WHERE user_id=$1 ORDER BY provider",
+			uid)
+		if err != nil {
+			return err
+		}
+		identities, err = pgx.CollectRows(rows, pgx.RowToStructByPos[models.UserIdentity])
+		return err
+	})
+	return identities, err
+}
+
+// GetUserByIdentity находит пользователя по учетной записи во внешней системе
+// или возвращает ErrNotFound.
+func (r *Repository) GetUserByIdentity(ctx context.Context, provider, externalID string) (*models.User, error) {
+	var u models.User
+	err := r.read(ctx, "GetUserByIdentity", func(q querier) error {
+		return q.QueryRow(ctx, `
+			SELECT user_id, username, team_name, is_active, review_weight, COALESCE(manager_id, ''),
+				COALESCE(email, ''), `+userTags+`
+			FROM users
+			WHERE deleted_at IS NULL AND user_id = (
+				SELECT i.user_id FROM user_identities i WHERE i.provider=$1 AND i.external_id=$2)`,
+			provider, externalID).Scan(&u.UserID, &u.Username, &u.TeamName, &u.IsActive, &u.ReviewWeight,
+			&u.ManagerID, &u.Email, &u.Tags)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// ExternalIDs реализует notify.IdentityLookup: учетные записи пользователей из
+// userIDs в системе provider; пользователи без привязки пропускаются.
+func (r *Repository) ExternalIDs(ctx context.Context, provider string, userIDs []string) (map[string]string, error) {
+	rows, err := r.conn(ctx).Query(ctx,
+		"SELECT user_id, external_id FROM user_identities WHERE provider=$1 AND user_id = ANY($2)",
+		provider, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[string]string, len(userIDs))
+	for rows.Next() {
+		var uid, externalID string
+		if err := rows.Scan(&uid, &externalID); err != nil {
+			return nil, err
+		}
+		ids[uid] = externalID
+	}
+	return ids, rows.Err()
+}
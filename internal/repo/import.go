@@ -0,0 +1,58 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+
+	"prreviewer/internal/models"
+)
+
+// ImportTeams создает или обновляет команды в одной транзакции. Каждая команда
+// пишется в своем savepoint: ошибка откатывает только ее и попадает в отчет.
+func (r *Repository) ImportTeams(ctx context.Context, teams []models.Team) ([]models.TeamImportResult, error) {
+	results := make([]models.TeamImportResult, 0, len(teams))
+	err := r.WithTx(ctx, func(tx pgx.Tx) error {
+		for _, team := range teams {
+			status, err := importTeam(ctx, tx, team)
+			res := models.TeamImportResult{TeamName: team.TeamName, Status: status}
+			if err != nil {
+				res.Status = models.ImportFailed
+				res.Reason = err.Error()
+			}
+			results = append(results, res)
+		}
+		return r.invalidateCaches(ctx, tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// importTeam пишет команду в savepoint транзакции tx.
+func importTeam(ctx context.Context, tx pgx.Tx, team models.Team) (string, error) {
+	status := models.ImportUpdated
+	err := inTx(ctx, tx, func(sp pgx.Tx) error {
+		tag, err := sp.Exec(ctx,
+			"INSERT INTO teams(team_name) VALUES($1) ON CONFLICT (team_name) DO NOTHING",
+			team.TeamName)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 1 {
+			status = models.ImportCreated
+		}
+
+		for _, m := range team.Members {
+			if err := upsertMember(ctx, sp, team.TeamName, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return status, nil
+}
@@ -0,0 +1,110 @@
+package repo
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// leaderRetryInterval — как часто резервный экземпляр пробует стать лидером.
+	leaderRetryInterval = 10 * time.Second
+	// leaderCheckInterval — как часто лидер проверяет соединение с блокировкой.
+	leaderCheckInterval = 5 * time.Second
+)
+
+// LeaderLock — выбор лидера на сессионной advisory-блокировке Postgres
+// (worker.Elector). Блокировку держит отдельное соединение лидера; при его
+// разрыве или падении процесса Postgres снимает ее сам, и лидером становится
+// другой экземпляр. За PgBouncer в режиме transaction сессионные блокировки
+// не работают.
+type LeaderLock struct {
+	db   *pgxpool.Pool
+	name string
+}
+
+// NewLeaderLock создает выбор лидера по блокировке с именем name: экземпляры
+// с одним name соперничают за одну блокировку.
+func NewLeaderLock(db *pgxpool.Pool, name string) *LeaderLock {
+	return &LeaderLock{db: db, name: name}
+}
+
+// Lead реализует worker.Elector: ждет блокировку, пока ctx не отменен.
+func (l *LeaderLock) Lead(ctx context.Context) (context.Context, func(), error) {
+	for {
+		conn, err := l.tryLock(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Warn("leader lock attempt failed", "lock", l.name, "error", err)
+		}
+		if conn != nil {
+			return l.hold(ctx, conn)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(leaderRetryInterval):
+		}
+	}
+}
+
+// tryLock возвращает соединение, захватившее блокировку, или nil, если ее
+// держит другой экземпляр. Соединение забирается из пула, чтобы блокировка
+// не досталась чужим запросам.
+func (l *LeaderLock) tryLock(ctx context.Context) (*pgx.Conn, error) {
+	pc, err := l.db.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var locked bool
+	err = pc.QueryRow(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", l.name).Scan(&locked)
+	if err != nil || !locked {
+		pc.Release()
+		return nil, err
+	}
+	return pc.Hijack(), nil
+}
+
+// hold проверяет соединение с блокировкой раз в leaderCheckInterval и отменяет
+// контекст лидера, как только соединение потеряно.
+func (l *LeaderLock) hold(ctx context.Context, conn *pgx.Conn) (context.Context, func(), error) {
+	leadCtx, cancel := context.WithCancel(ctx)
+	var mu sync.Mutex // соединение не используется конкурентно
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(leaderCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				mu.Lock()
+				err := conn.Ping(leadCtx)
+				mu.Unlock()
+				if err != nil {
+					slog.Error("leader lock connection lost", "lock", l.name, "error", err)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	resign := func() {
+		once.Do(func() {
+			close(done)
+			cancel()
+			mu.Lock()
+			defer mu.Unlock()
+			// Закрытие сессии снимает блокировку и без явного unlock.
+			_ = conn.Close(context.WithoutCancel(ctx))
+		})
+	}
+	return leadCtx, resign, nil
+}
@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+)
+
+// ArchiveMergedPRs переносит PR в архив по возрастанию времени merge, как
+// repo.ArchiveMergedPRs. История назначений остается.
+func (r *Repository) ArchiveMergedPRs(_ context.Context, mergedBefore time.Time, limit int) (int, error) {
+	r.rowMu.Lock()
+	defer r.rowMu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	type candidate struct {
+		pr       *pullRequest
+		mergedAt time.Time
+	}
+	var candidates []candidate
+	for _, pr := range r.sortedPRs() {
+		if pr.Status != models.StatusMerged {
+			continue
+		}
+		merged, err := time.Parse(time.RFC3339, *pr.MergedAt)
+		if err != nil {
+			return 0, err
+		}
+		if merged.Before(mergedBefore) {
+			candidates = append(candidates, candidate{pr: pr, mergedAt: merged})
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int { return a.mergedAt.Compare(b.mergedAt) })
+
+	archivedAt := r.now().Format(time.RFC3339)
+	candidates = candidates[:min(limit, len(candidates))]
+	for _, c := range candidates {
+		r.archived[c.pr.ID] = models.ArchivedPR{PR: c.pr.export(), ArchivedAt: archivedAt}
+		delete(r.prs, c.pr.ID)
+	}
+	return len(candidates), nil
+}
+
+func (r *Repository) GetArchivedPR(_ context.Context, prID string) (*models.ArchivedPR, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pr, ok := r.archived[prID]
+	if !ok {
+		return nil, repo.ErrNotFound
+	}
+	return &pr, nil
+}
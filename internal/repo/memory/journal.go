@@ -0,0 +1,206 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"prreviewer/internal/models"
+)
+
+// Записи журнала и outbox хранятся в JSON, как в jsonb-колонках: после чтения
+// Details содержат []any и float64, а не исходные типы.
+
+type auditRow struct {
+	entry     models.AuditEntry
+	teamID    int64 // как audit_log.team_id; 0 — команды не было
+	details   []byte
+	createdAt time.Time
+}
+
+// sameTeam повторяет фильтр repo.ListAuditEntries по команде: запись с team_id
+// относится к команде с этим id под любым именем.
+func sameTeam(row auditRow, teamID int64, name string) bool {
+	if row.teamID != 0 {
+		return row.teamID == teamID
+	}
+	return row.entry.TeamName == name
+}
+
+type outboxRow struct {
+	id        int64
+	payload   []byte
+	createdAt time.Time
+	attempts  int
+	published bool
+	lastError string
+}
+
+func (r *Repository) InsertAuditEntry(_ context.Context, e models.AuditEntry) error {
+	details, err := json.Marshal(e.Details)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextAuditID++
+	e.ID = r.nextAuditID
+	r.audit = append(r.audit, auditRow{entry: e, teamID: r.teams[e.TeamName], details: details, createdAt: r.now()})
+	return nil
+}
+
+func (r *Repository) ListAuditEntries(
+	_ context.Context,
+	filter models.AuditFilter,
+	page models.Page,
+) ([]models.AuditEntry, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matched []auditRow
+	teamID := r.teams[filter.TeamName]
+	for _, row := range r.audit {
+		e := row.entry
+		if (filter.UserID != "" && e.UserID != filter.UserID) ||
+			(filter.TeamName != "" && !sameTeam(row, teamID, filter.TeamName)) ||
+			(filter.PRID != "" && e.PRID != filter.PRID) ||
+			(filter.Action != "" && e.Action != filter.Action) ||
+			(filter.From != nil && row.createdAt.Before(*filter.From)) ||
+			(filter.To != nil && !row.createdAt.Before(*filter.To)) {
+			continue
+		}
+		matched = append(matched, row)
+	}
+	slices.SortFunc(matched, func(a, b auditRow) int {
+		if c := b.createdAt.Compare(a.createdAt); c != 0 {
+			return c
+		}
+		return int(b.entry.ID - a.entry.ID)
+	})
+
+	entries := []models.AuditEntry{}
+	for _, row := range paginate(matched, page) {
+		e := row.entry
+		e.Details = nil
+		if err := json.Unmarshal(row.details, &e.Details); err != nil {
+			return nil, 0, err
+		}
+		e.CreatedAt = row.createdAt.Format(time.RFC3339)
+		entries = append(entries, e)
+	}
+	return entries, len(matched), nil
+}
+
+// insertOutbox вызывается под r.mu вместе с изменением состояния.
+func (r *Repository) insertOutbox(e models.DomainEvent) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		panic("memory: marshal domain event: " + err.Error())
+	}
+	r.nextOutboxID++
+	r.outbox = append(r.outbox, outboxRow{id: r.nextOutboxID, payload: payload, createdAt: r.now()})
+}
+
+// insertAssignmentEvent вызывается под r.mu вместе с изменением ревьюверов.
+func (r *Repository) insertAssignmentEvent(ev models.AssignmentEvent) {
+	r.nextEventID++
+	ev.ID = r.nextEventID
+	ev.CreatedAt = r.now().Format(time.RFC3339)
+	r.events = append(r.events, ev)
+}
+
+func (r *Repository) ListAssignmentEvents(_ context.Context, prID string) ([]models.AssignmentEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := []models.AssignmentEvent{}
+	for _, ev := range r.events {
+		if ev.PRID == prID {
+			events = append(events, ev)
+		}
+	}
+	return events, nil
+}
+
+func (r *Repository) ExportAssignmentEvents(
+	_ context.Context,
+	from, to *time.Time,
+	after *models.EventCursor,
+	limit int,
+) ([]models.AssignmentEvent, *models.EventCursor, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := []models.AssignmentEvent{}
+	var next *models.EventCursor
+	for _, ev := range r.events {
+		if len(events) == limit {
+			break
+		}
+		createdAt, err := time.Parse(time.RFC3339, ev.CreatedAt)
+		if err != nil {
+			return nil, nil, err
+		}
+		if after != nil && (createdAt.Before(after.CreatedAt) || createdAt.Equal(after.CreatedAt) && ev.ID <= after.ID) {
+			continue
+		}
+		if from != nil && createdAt.Before(*from) || to != nil && !createdAt.Before(*to) {
+			continue
+		}
+		events = append(events, ev)
+		next = &models.EventCursor{CreatedAt: createdAt, ID: ev.ID}
+	}
+	return events, next, nil
+}
+
+func (r *Repository) PendingOutbox(_ context.Context, limit, maxAttempts int) ([]models.DomainEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := []models.DomainEvent{}
+	for _, row := range r.outbox {
+		if len(events) == limit {
+			break
+		}
+		if row.published || row.attempts >= maxAttempts {
+			continue
+		}
+		var e models.DomainEvent
+		if err := json.Unmarshal(row.payload, &e); err != nil {
+			return nil, err
+		}
+		e.ID, e.CreatedAt, e.Attempts = row.id, row.createdAt, row.attempts
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+func (r *Repository) MarkOutboxPublished(_ context.Context, ids []int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.outbox {
+		if slices.Contains(ids, r.outbox[i].id) {
+			r.outbox[i].published = true
+			r.outbox[i].attempts++
+			r.outbox[i].lastError = ""
+		}
+	}
+	return nil
+}
+
+func (r *Repository) MarkOutboxFailed(_ context.Context, id int64, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.outbox {
+		if r.outbox[i].id == id {
+			r.outbox[i].attempts++
+			r.outbox[i].lastError = reason
+		}
+	}
+	return nil
+}
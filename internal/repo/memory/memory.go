@@ -0,0 +1,1501 @@
+// Package memory — реализация service.Repository в памяти для unit-тестов
+// сервиса без Postgres. Повторяет семантику repo.Repository: мягкое удаление
+// пользователей, версии PR, отпуска, ошибки ограничений pr_reviewers и записи outbox.
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+)
+
+type user struct {
+	models.User
+	deleted      bool
+	snoozedUntil *time.Time
+}
+
+type pullRequest struct {
+	models.PR
+	createdAt  time.Time
+	remindedAt *time.Time
+	reviewers  []string
+	assignedAt map[string]time.Time // как pr_reviewers.assigned_at
+	actedAt    map[string]time.Time // как pr_reviewers.first_action_at
+	approvals  map[string]bool
+}
+
+// removedReviewer — строка pr_reviewers_archive.
+type removedReviewer struct {
+	reason        string
+	assignedAt    time.Time
+	firstActionAt time.Time // нулевое, если ревьювер не отреагировал
+	archivedAt    time.Time
+}
+
+type Repository struct {
+	mu sync.Mutex
+	// rowMu заменяет блокировки строк pull_requests: ReplaceReviewer держит его, пока
+	// план замены читает репозиторий, а остальные изменения PR ждут его, как UPDATE
+	// ждет SELECT ... FOR UPDATE. Берется раньше mu.
+	rowMu sync.Mutex
+	now   func() time.Time
+
+	teams        map[string]int64 // имя команды -> team_id
+	users        map[string]*user
+	prs          map[string]*pullRequest
+	archive      map[[2]string]removedReviewer // (pr, user)
+	lastAssigned map[string]time.Time
+	vacations    []models.Vacation
+	policies     map[string]models.TeamPolicy
+	autoMerge    map[string]models.TeamAutoMerge
+	rules        []models.OwnershipRule
+	optOut       map[string]bool
+	digests      map[string]models.DigestSubscription
+	identities   map[string]map[string]string // user_id -> provider -> external_id
+	audit        []auditRow
+	outbox       []outboxRow
+	events       []models.AssignmentEvent
+	archived     map[string]models.ArchivedPR
+
+	nextTeamID, nextVacationID, nextRuleID, nextAuditID, nextOutboxID, nextEventID int64
+}
+
+type Option func(*Repository)
+
+// WithClock подменяет источник текущего времени (по умолчанию time.Now).
+func WithClock(now func() time.Time) Option {
+	return func(r *Repository) { r.now = now }
+}
+
+func New(opts ...Option) *Repository {
+	r := &Repository{
+		now:          time.Now,
+		teams:        map[string]int64{},
+		users:        map[string]*user{},
+		prs:          map[string]*pullRequest{},
+		archive:      map[[2]string]removedReviewer{},
+		lastAssigned: map[string]time.Time{},
+		policies:     map[string]models.TeamPolicy{},
+		autoMerge:    map[string]models.TeamAutoMerge{},
+		optOut:       map[string]bool{},
+		digests:      map[string]models.DigestSubscription{},
+		identities:   map[string]map[string]string{},
+		archived:     map[string]models.ArchivedPR{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *Repository) TeamExists(_ context.Context, name string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.teams[name] != 0, nil
+}
+
+func (r *Repository) CreateTeam(_ context.Context, team models.Team) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.teams[team.TeamName] != 0 {
+		return fmt.Errorf("team %q already exists", team.TeamName)
+	}
+	r.addTeam(team.TeamName)
+	for _, m := range team.Members {
+		r.upsertMember(team.TeamName, m)
+	}
+	r.insertOutbox(models.DomainEvent{
+		Type:     models.DomainTeamCreated,
+		TeamName: team.TeamName,
+		Details:  map[string]any{"members": len(team.Members)},
+	})
+	return nil
+}
+
+// upsertMember повторяет repo.upsertMember: нулевой вес, пустые manager_id и email
+// и nil tags не меняют текущие значения, повторное добавление снимает удаление.
+func (r *Repository) upsertMember(teamName string, m models.TeamMember) {
+	u, ok := r.users[m.UserID]
+	if !ok {
+		u = &user{User: models.User{UserID: m.UserID, ReviewWeight: 1, Tags: []string{}}}
+		r.users[m.UserID] = u
+	}
+	u.Username, u.TeamName, u.IsActive, u.deleted = m.Username, teamName, m.IsActive, false
+	if m.ReviewWeight > 0 {
+		u.ReviewWeight = m.ReviewWeight
+	}
+	if m.ManagerID != "" {
+		u.ManagerID = m.ManagerID
+	}
+	if m.Email != "" {
+		u.Email = m.Email
+	}
+	if m.Tags != nil {
+		u.Tags = sortedCopy(m.Tags)
+	}
+}
+
+func (r *Repository) ImportTeams(_ context.Context, teams []models.Team) ([]models.TeamImportResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := make([]models.TeamImportResult, 0, len(teams))
+	for _, team := range teams {
+		status := models.ImportUpdated
+		if r.teams[team.TeamName] == 0 {
+			r.addTeam(team.TeamName)
+			status = models.ImportCreated
+		}
+		for _, m := range team.Members {
+			r.upsertMember(team.TeamName, m)
+		}
+		results = append(results, models.TeamImportResult{TeamName: team.TeamName, Status: status})
+	}
+	return results, nil
+}
+
+func (r *Repository) RenameTeam(_ context.Context, oldName, newName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.teams[oldName]
+	if id == 0 {
+		return repo.ErrNotFound
+	}
+	if r.teams[newName] != 0 {
+		return fmt.Errorf("%w: team %s", repo.ErrDuplicate, newName)
+	}
+	delete(r.teams, oldName)
+	r.teams[newName] = id
+	for _, u := range r.users {
+		if u.TeamName == oldName {
+			u.TeamName = newName
+		}
+	}
+	if p, ok := r.policies[oldName]; ok {
+		delete(r.policies, oldName)
+		p.TeamName = newName
+		r.policies[newName] = p
+	}
+	if cfg, ok := r.autoMerge[oldName]; ok {
+		delete(r.autoMerge, oldName)
+		cfg.TeamName = newName
+		r.autoMerge[newName] = cfg
+	}
+	for i := range r.rules {
+		if r.rules[i].TeamName == oldName {
+			r.rules[i].TeamName = newName
+		}
+	}
+	r.insertOutbox(models.DomainEvent{
+		Type:     models.DomainTeamRenamed,
+		TeamName: newName,
+		Details:  map[string]any{"old_team_name": oldName},
+	})
+	return nil
+}
+
+func (r *Repository) addTeam(name string) {
+	r.nextTeamID++
+	r.teams[name] = r.nextTeamID
+}
+
+func (r *Repository) GetTeam(_ context.Context, name string) (*models.Team, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.teams[name] == 0 {
+		return nil, repo.ErrNotFound
+	}
+	members := []models.TeamMember{}
+	for _, u := range r.sortedUsers() {
+		if u.TeamName != name || u.deleted {
+			continue
+		}
+		members = append(members, models.TeamMember{
+			UserID:       u.UserID,
+			Username:     u.Username,
+			IsActive:     u.IsActive,
+			ReviewWeight: u.ReviewWeight,
+			ManagerID:    u.ManagerID,
+			Email:        u.Email,
+			Tags:         slices.Clone(u.Tags),
+		})
+	}
+	return &models.Team{TeamName: name, Members: members}, nil
+}
+
+func (r *Repository) ListTeams(_ context.Context, namePrefix string, page models.Page) ([]models.TeamSummary, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.teams))
+	for name := range r.teams {
+		if strings.HasPrefix(name, namePrefix) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	teams := []models.TeamSummary{}
+	for _, name := range paginate(names, page) {
+		t := models.TeamSummary{TeamName: name}
+		for _, u := range r.users {
+			if u.TeamName == name && !u.deleted {
+				t.MemberCount++
+				if u.IsActive {
+					t.ActiveMembers++
+				}
+			}
+		}
+		for _, pr := range r.prs {
+			if a, ok := r.users[pr.AuthorID]; ok && a.TeamName == name && pr.Status.IsOpen() {
+				t.OpenPRs++
+			}
+		}
+		teams = append(teams, t)
+	}
+	return teams, len(names), nil
+}
+
+func (r *Repository) GetUser(_ context.Context, uid string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[uid]
+	if !ok || u.deleted {
+		return nil, repo.ErrNotFound
+	}
+	return u.copy(), nil
+}
+
+func (r *Repository) GetUserTeamName(_ context.Context, uid string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[uid]
+	if !ok {
+		return "", repo.ErrNotFound
+	}
+	return u.TeamName, nil
+}
+
+func (r *Repository) GetUserTeamNames(_ context.Context, uids []string) (map[string]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	teams := map[string]string{}
+	for _, uid := range uids {
+		if u, ok := r.users[uid]; ok {
+			teams[uid] = u.TeamName
+		}
+	}
+	return teams, nil
+}
+
+// GetUserProfile повторяет repo.userProfileQuery; архивные PR учитываются
+// только в одобрениях, так как время реакции в них не хранится.
+func (r *Repository) GetUserProfile(_ context.Context, uid string) (*models.UserProfile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[uid]
+	if !ok || u.deleted {
+		return nil, repo.ErrNotFound
+	}
+	now := r.now()
+	p := &models.UserProfile{User: *u.copy()}
+	if u.snoozedUntil != nil && u.snoozedUntil.After(now) {
+		until := *u.snoozedUntil
+		p.SnoozedUntil = &until
+	}
+
+	var responseSum float64
+	var responded int
+	respond := func(assigned, acted time.Time) {
+		if !assigned.IsZero() && !acted.IsZero() {
+			responseSum += acted.Sub(assigned).Seconds()
+			responded++
+		}
+	}
+	for _, pr := range r.prs {
+		if slices.Contains(pr.reviewers, uid) {
+			if pr.Status.IsOpen() {
+				p.OpenReviews++
+			}
+			respond(pr.assignedAt[uid], pr.actedAt[uid])
+		}
+		if pr.approvals[uid] {
+			p.ApprovalsGiven++
+		}
+	}
+	for key, removed := range r.archive {
+		if key[1] == uid {
+			respond(removed.assignedAt, removed.firstActionAt)
+		}
+	}
+	for _, pr := range r.archived {
+		if slices.Contains(pr.ApprovedBy, uid) {
+			p.ApprovalsGiven++
+		}
+	}
+	if responded > 0 {
+		avg := responseSum / float64(responded)
+		p.AvgResponseSeconds = &avg
+	}
+
+	for _, v := range r.vacations {
+		if v.UserID == uid && v.EndsAt.After(now) && (p.Vacation == nil || v.StartsAt.Before(p.Vacation.StartsAt)) {
+			vacation := v
+			p.Vacation = &vacation
+		}
+	}
+	p.OnVacation = p.Vacation != nil && !p.Vacation.StartsAt.After(now)
+	return p, nil
+}
+
+func (r *Repository) ListUsers(_ context.Context, filter models.UserFilter, page models.Page) ([]models.User, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	matched := []models.User{}
+	for _, u := range r.sortedUsers() {
+		if u.deleted ||
+			(filter.TeamName != "" && u.TeamName != filter.TeamName) ||
+			(filter.IsActive != nil && u.IsActive != *filter.IsActive) ||
+			!strings.Contains(strings.ToLower(u.Username), strings.ToLower(filter.Query)) {
+			continue
+		}
+		matched = append(matched, *u.copy())
+	}
+	return append([]models.User{}, paginate(matched, page)...), len(matched), nil
+}
+
+func (r *Repository) UpdateUserActiveStatus(_ context.Context, uid string, active bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[uid]
+	if !ok || u.deleted {
+		return repo.ErrNotFound
+	}
+	u.IsActive = active
+	return nil
+}
+
+func (r *Repository) SetUserTags(_ context.Context, uid string, tags []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[uid]
+	if !ok || u.deleted {
+		return repo.ErrNotFound
+	}
+	u.Tags = sortedCopy(tags)
+	return nil
+}
+
+func (r *Repository) DeactivateTeamMembers(_ context.Context, teamName string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.deactivateTeamUsers(teamName), nil
+}
+
+func (r *Repository) GetActiveTeamMembers(_ context.Context, teamName string, excludeIDs []string) ([]models.Candidate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.candidates(func(u *user) bool { return u.TeamName == teamName }, excludeIDs), nil
+}
+
+func (r *Repository) GetFallbackCandidates(_ context.Context, excludeTeam string, excludeIDs []string) ([]models.Candidate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.candidates(func(u *user) bool { return u.TeamName != excludeTeam }, excludeIDs), nil
+}
+
+func (r *Repository) GetOwnerCandidates(_ context.Context, userIDs, teamNames, excludeIDs []string) ([]models.Candidate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.candidates(func(u *user) bool {
+		return slices.Contains(userIDs, u.UserID) || slices.Contains(teamNames, u.TeamName)
+	}, excludeIDs), nil
+}
+
+// candidates повторяет repo.queryCandidates: активные пользователи не в отпуске
+// под условием match с нагрузкой и временем последнего назначения.
+func (r *Repository) candidates(match func(*user) bool, excludeIDs []string) []models.Candidate {
+	result := []models.Candidate{}
+	for _, u := range r.sortedUsers() {
+		if !match(u) || !u.IsActive || r.unavailable(u) || slices.Contains(excludeIDs, u.UserID) {
+			continue
+		}
+		c := models.Candidate{
+			UserID:         u.UserID,
+			TeamName:       u.TeamName,
+			Weight:         u.ReviewWeight,
+			Tags:           slices.Clone(u.Tags),
+			LastAssignedAt: r.lastAssigned[u.UserID],
+		}
+		for _, pr := range r.prs {
+			if pr.Status.IsOpen() && slices.Contains(pr.reviewers, u.UserID) {
+				c.OpenReviews += reviewLoad(pr)
+			}
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+// reviewLoad повторяет repo.openReviewLoad для одного PR.
+func reviewLoad(pr *pullRequest) int {
+	if pr.Priority == models.PriorityHigh {
+		return models.HighPriorityLoad
+	}
+	return 1
+}
+
+func (r *Repository) PRExists(_ context.Context, prID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.prs[prID]
+	_, archived := r.archived[prID]
+	return ok || archived, nil
+}
+
+func (r *Repository) GetExistingPRs(_ context.Context, prIDs []string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing := []string{}
+	for _, id := range prIDs {
+		_, ok := r.prs[id]
+		_, archived := r.archived[id]
+		if ok || archived {
+			existing = append(existing, id)
+		}
+	}
+	slices.Sort(existing)
+	return slices.Compact(existing), nil
+}
+
+func (r *Repository) CreatePR(_ context.Context, pr models.PR) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.prs[pr.ID]; ok {
+		return fmt.Errorf("pull request %q already exists", pr.ID)
+	}
+	if _, ok := r.users[pr.AuthorID]; !ok {
+		return fmt.Errorf("%w: author %s", repo.ErrUnknownReference, pr.AuthorID)
+	}
+
+	// Как lockActiveUsers: неактивные и неизвестные ревьюверы отбрасываются.
+	reviewers := []string{}
+	for _, uid := range pr.AssignedReviewers {
+		if u, ok := r.users[uid]; ok && u.IsActive {
+			reviewers = append(reviewers, uid)
+		}
+	}
+	for i, uid := range reviewers {
+		if err := checkReviewer(pr.AuthorID, reviewers[:i], uid); err != nil {
+			return err
+		}
+	}
+
+	r.prs[pr.ID] = &pullRequest{
+		PR: models.PR{
+			ID:                pr.ID,
+			Name:              pr.Name,
+			AuthorID:          pr.AuthorID,
+			Status:            models.StatusOpen,
+			Version:           1,
+			Repository:        pr.Repository,
+			ChangedPaths:      orEmpty(pr.ChangedPaths),
+			RequiredTags:      orEmpty(pr.RequiredTags),
+			Labels:            orEmpty(pr.Labels),
+			Priority:          cmp.Or(pr.Priority, models.PriorityNormal),
+			URL:               pr.URL,
+			Branch:            pr.Branch,
+			Description:       pr.Description,
+			RequiredApprovals: cloneInt(pr.RequiredApprovals),
+			DependsOn:         orEmpty(pr.DependsOn),
+		},
+		createdAt:  r.now(),
+		reviewers:  reviewers,
+		assignedAt: map[string]time.Time{},
+		actedAt:    map[string]time.Time{},
+		approvals:  map[string]bool{},
+	}
+	for _, uid := range reviewers {
+		r.recordAssignment(r.prs[pr.ID], uid)
+		r.insertAssignmentEvent(models.AssignmentEvent{PRID: pr.ID, EventType: models.EventAssigned, NewUserID: uid})
+	}
+
+	r.insertOutbox(models.DomainEvent{
+		Type:    models.DomainPRCreated,
+		PRID:    pr.ID,
+		UserID:  pr.AuthorID,
+		Details: map[string]any{"reviewers": reviewers},
+	})
+	if len(reviewers) > 0 {
+		r.insertOutbox(models.DomainEvent{
+			Type:       models.DomainReviewerAssigned,
+			PRID:       pr.ID,
+			Recipients: reviewers,
+		})
+	}
+	return nil
+}
+
+func (r *Repository) GetPR(_ context.Context, prID string) (*models.PR, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pr, ok := r.prs[prID]
+	if !ok {
+		return nil, repo.ErrNotFound
+	}
+	result := pr.export()
+	return &result, nil
+}
+
+// export возвращает копию PR в том виде, в каком ее читает repo.GetPR.
+func (pr *pullRequest) export() models.PR {
+	result := pr.PR
+	created := pr.createdAt.Format(time.RFC3339)
+	result.CreatedAt = &created
+	result.MergedAt = cloneString(pr.MergedAt)
+	result.ClosedAt = cloneString(pr.ClosedAt)
+	result.RequiredApprovals = cloneInt(pr.RequiredApprovals)
+	result.AssignedReviewers = sortedCopy(pr.reviewers)
+	result.ApprovedBy = sortedKeys(pr.approvals)
+	result.ChangedPaths = slices.Clone(pr.ChangedPaths)
+	result.RequiredTags = slices.Clone(pr.RequiredTags)
+	result.Labels = slices.Clone(pr.Labels)
+	result.DependsOn = slices.Clone(pr.DependsOn)
+	return result
+}
+
+func (r *Repository) GetUserReviews(
+	_ context.Context,
+	uid string,
+	filter models.ReviewFilter,
+	page models.Page,
+) ([]models.PRShort, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matched []*pullRequest
+	for _, pr := range r.prs {
+		author, ok := r.users[pr.AuthorID]
+		if !ok || !slices.Contains(pr.reviewers, uid) ||
+			(filter.Status != "" && pr.Status != filter.Status) ||
+			(filter.TeamName != "" && author.TeamName != filter.TeamName) ||
+			(filter.Label != "" && !slices.Contains(pr.Labels, filter.Label)) ||
+			(filter.Priority != "" && pr.Priority != filter.Priority) {
+			continue
+		}
+		matched = append(matched, pr)
+	}
+	slices.SortFunc(matched, func(a, b *pullRequest) int {
+		if c := b.createdAt.Compare(a.createdAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	prs := []models.PRShort{}
+	for _, pr := range paginate(matched, page) {
+		prs = append(prs, models.PRShort{
+			ID:       pr.ID,
+			Name:     pr.Name,
+			AuthorID: pr.AuthorID,
+			Status:   pr.Status,
+			Priority: pr.Priority,
+			Labels:   slices.Clone(pr.Labels),
+			URL:      pr.URL,
+		})
+	}
+	return prs, len(matched), nil
+}
+
+func (r *Repository) GetOpenPRs(_ context.Context, prIDs []string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.openPRs(prIDs), nil
+}
+
+// openPRs возвращает те из prIDs, что еще открыты, по возрастанию id.
+func (r *Repository) openPRs(prIDs []string) []string {
+	open := []string{}
+	for _, id := range prIDs {
+		if pr, ok := r.prs[id]; ok && pr.Status.IsOpen() {
+			open = append(open, id)
+		}
+	}
+	slices.Sort(open)
+	return slices.Compact(open)
+}
+
+// ListBlockedPRs возвращает открытые PR с открытыми зависимостями, как
+// repo.ListBlockedPRs.
+func (r *Repository) ListBlockedPRs(_ context.Context, page models.Page) ([]models.BlockedPR, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matched []*pullRequest
+	for _, pr := range r.prs {
+		if pr.Status.IsOpen() && len(r.openPRs(pr.DependsOn)) > 0 {
+			matched = append(matched, pr)
+		}
+	}
+	slices.SortFunc(matched, func(a, b *pullRequest) int {
+		if c := a.createdAt.Compare(b.createdAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	prs := []models.BlockedPR{}
+	for _, pr := range paginate(matched, page) {
+		prs = append(prs, models.BlockedPR{
+			ID:        pr.ID,
+			Name:      pr.Name,
+			AuthorID:  pr.AuthorID,
+			Status:    pr.Status,
+			URL:       pr.URL,
+			BlockedBy: r.openPRs(pr.DependsOn),
+		})
+	}
+	return prs, len(matched), nil
+}
+
+func (r *Repository) GetOpenPRsByReviewers(_ context.Context, reviewerIDs []string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	prIDs := []string{}
+	for _, pr := range r.sortedPRs() {
+		if !pr.Status.IsOpen() {
+			continue
+		}
+		for _, uid := range reviewerIDs {
+			if slices.Contains(pr.reviewers, uid) {
+				prIDs = append(prIDs, pr.ID)
+				break
+			}
+		}
+	}
+	return prIDs, nil
+}
+
+func (r *Repository) GetOpenPRsByTeam(_ context.Context, teamName string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var prs []*pullRequest
+	for _, pr := range r.sortedPRs() {
+		if a, ok := r.users[pr.AuthorID]; ok && a.TeamName == teamName && pr.Status.IsOpen() {
+			prs = append(prs, pr)
+		}
+	}
+	slices.SortStableFunc(prs, func(a, b *pullRequest) int { return a.createdAt.Compare(b.createdAt) })
+	prIDs := make([]string, 0, len(prs))
+	for _, pr := range prs {
+		prIDs = append(prIDs, pr.ID)
+	}
+	return prIDs, nil
+}
+
+func (r *Repository) MergePR(_ context.Context, prID string, expectedVersion *int) error {
+	r.rowMu.Lock()
+	defer r.rowMu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pr, ok := r.prs[prID]
+	if !ok {
+		return repo.ErrNotFound
+	}
+	if !pr.Status.IsOpen() || (expectedVersion != nil && *expectedVersion != pr.Version) {
+		if expectedVersion != nil {
+			return repo.ErrVersionConflict
+		}
+		return nil
+	}
+
+	merged := r.now().Format(time.RFC3339)
+	pr.Status, pr.MergedAt = models.StatusMerged, &merged
+	pr.Version++
+	r.insertOutbox(models.DomainEvent{
+		Type:       models.DomainPRMerged,
+		PRID:       prID,
+		UserID:     pr.AuthorID,
+		Recipients: []string{pr.AuthorID},
+	})
+	return nil
+}
+
+// ClosePR, как и repo.ClosePR, возвращает ErrVersionConflict для отсутствующего,
+// уже не открытого PR или несовпавшей версии.
+func (r *Repository) ClosePR(_ context.Context, prID string, expectedVersion *int) error {
+	r.rowMu.Lock()
+	defer r.rowMu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pr, ok := r.prs[prID]
+	if !ok || !pr.Status.IsOpen() || (expectedVersion != nil && *expectedVersion != pr.Version) {
+		return repo.ErrVersionConflict
+	}
+
+	closed := r.now().Format(time.RFC3339)
+	pr.Status, pr.ClosedAt = models.StatusClosed, &closed
+	pr.Version++
+
+	released := pr.reviewers
+	pr.reviewers = nil
+	for _, uid := range released {
+		if _, ok := r.archive[[2]string{prID, uid}]; !ok {
+			r.archive[[2]string{prID, uid}] = r.removeReviewer(pr, uid, "pr closed")
+		}
+		r.insertAssignmentEvent(models.AssignmentEvent{
+			PRID: prID, EventType: models.EventReleased, UserID: uid, Reason: "pr closed",
+		})
+	}
+
+	r.insertOutbox(models.DomainEvent{
+		Type:    models.DomainPRClosed,
+		PRID:    prID,
+		Details: map[string]any{"released_reviewers": released},
+	})
+	return nil
+}
+
+// SetPRStatus, как и repo.SetPRStatus, возвращает ErrVersionConflict для
+// отсутствующего, уже не открытого PR или несовпавшей версии.
+func (r *Repository) SetPRStatus(
+	_ context.Context,
+	prID string,
+	status models.PRStatus,
+	expectedVersion *int,
+) error {
+	r.rowMu.Lock()
+	defer r.rowMu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pr, ok := r.prs[prID]
+	if !ok || !pr.Status.IsOpen() || (expectedVersion != nil && *expectedVersion != pr.Version) {
+		return repo.ErrVersionConflict
+	}
+	pr.Status = status
+	pr.Version++
+
+	r.insertOutbox(models.DomainEvent{
+		Type:    models.DomainPRStatusChanged,
+		PRID:    prID,
+		Details: map[string]any{"status": status},
+	})
+	return nil
+}
+
+// ReplaceReviewer, как и repo.ReplaceReviewer, вызывает plan под блокировкой PR.
+// mu на время plan отпускается: план читает репозиторий.
+func (r *Repository) ReplaceReviewer(
+	ctx context.Context,
+	prID string,
+	plan func(ctx context.Context, pr *models.PR) (models.ReviewerChange, error),
+) error {
+	r.rowMu.Lock()
+	defer r.rowMu.Unlock()
+
+	r.mu.Lock()
+	pr, ok := r.prs[prID]
+	var current models.PR
+	if ok {
+		current = pr.export()
+	}
+	r.mu.Unlock()
+	if !ok {
+		return repo.ErrNotFound
+	}
+
+	change, err := plan(ctx, &current)
+	if err != nil {
+		return err
+	}
+	change.PRID = prID
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	remaining := slices.DeleteFunc(slices.Clone(pr.reviewers), func(uid string) bool {
+		return uid == change.OldReviewerID
+	})
+	if change.NewReviewerID != "" {
+		if err := r.checkNewReviewer(pr.AuthorID, remaining, change.NewReviewerID); err != nil {
+			return err
+		}
+	}
+	if change.EventType == models.EventDeclined {
+		removed := r.removeReviewer(pr, change.OldReviewerID, "declined")
+		if removed.firstActionAt.IsZero() {
+			removed.firstActionAt = r.now()
+		}
+		r.archive[[2]string{prID, change.OldReviewerID}] = removed
+	}
+	if change.EventType == models.EventUnassigned {
+		r.archive[[2]string{prID, change.OldReviewerID}] = r.removeReviewer(pr, change.OldReviewerID, "unassigned")
+	}
+	if change.NewReviewerID != "" {
+		remaining = append(remaining, change.NewReviewerID)
+		r.recordAssignment(pr, change.NewReviewerID)
+	}
+	pr.reviewers = remaining
+	pr.Version++
+	r.insertAssignmentEvent(models.AssignmentEvent{
+		PRID:      change.PRID,
+		EventType: change.EventType,
+		UserID:    change.OldReviewerID,
+		NewUserID: change.NewReviewerID,
+		Reason:    change.Reason,
+	})
+
+	if change.NewReviewerID != "" {
+		r.insertOutbox(models.DomainEvent{
+			Type:       models.DomainReviewerAssigned,
+			PRID:       change.PRID,
+			UserID:     change.NewReviewerID,
+			Recipients: []string{change.NewReviewerID},
+			Details: map[string]any{
+				"old_user_id": change.OldReviewerID,
+				"event_type":  change.EventType,
+				"reason":      change.Reason,
+			},
+		})
+	}
+	return nil
+}
+
+func (r *Repository) DeactivateTeamAndReassignPRs(
+	_ context.Context,
+	teamName string,
+	rng interface{ Intn(int) int },
+) (*repo.DeactivationResult, error) {
+	r.rowMu.Lock()
+	defer r.rowMu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshot := r.snapshot()
+	result, err := r.deactivateTeam(teamName, rng)
+	if err != nil {
+		r.restore(snapshot)
+		return nil, err
+	}
+	return result, nil
+}
+
+// PreviewTeamDeactivation выполняет деактивацию и возвращает состояние к снимку.
+func (r *Repository) PreviewTeamDeactivation(
+	_ context.Context,
+	teamName string,
+	rng interface{ Intn(int) int },
+) (*repo.DeactivationResult, error) {
+	r.rowMu.Lock()
+	defer r.rowMu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshot := r.snapshot()
+	defer r.restore(snapshot)
+	return r.deactivateTeam(teamName, rng)
+}
+
+func (r *Repository) deactivateTeam(teamName string, rng interface{ Intn(int) int }) (*repo.DeactivationResult, error) {
+	deactivated := r.deactivateTeamUsers(teamName)
+	if len(deactivated) == 0 {
+		return &repo.DeactivationResult{DeactivatedUsers: []string{}, Reassignments: []map[string]string{}}, nil
+	}
+
+	userTeams := make(map[string]string, len(deactivated))
+	for _, uid := range deactivated {
+		userTeams[uid] = teamName
+	}
+	reassignments, err := r.reassignReviewers(deactivated, userTeams, rng, "team deactivated")
+	if err != nil {
+		return nil, err
+	}
+
+	r.insertOutbox(models.DomainEvent{
+		Type:     models.DomainTeamDeactivated,
+		TeamName: teamName,
+		Details:  map[string]any{"deactivated_users": deactivated, "reassignments": len(reassignments)},
+	})
+	return &repo.DeactivationResult{DeactivatedUsers: deactivated, Reassignments: reassignments}, nil
+}
+
+func (r *Repository) DeleteUserAndReassignPRs(
+	_ context.Context,
+	uid string,
+	rng interface{ Intn(int) int },
+) (*repo.UserDeletionResult, error) {
+	r.rowMu.Lock()
+	defer r.rowMu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[uid]
+	if !ok || u.deleted {
+		return nil, repo.ErrNotFound
+	}
+	snapshot := r.snapshot()
+	u.IsActive, u.deleted = false, true
+
+	reassignments, err := r.reassignReviewers([]string{uid}, map[string]string{uid: u.TeamName}, rng, "user deleted")
+	if err != nil {
+		r.restore(snapshot)
+		return nil, err
+	}
+
+	var archived int64
+	for _, pr := range r.sortedPRs() {
+		if !slices.Contains(pr.reviewers, uid) {
+			continue
+		}
+		if _, ok := r.archive[[2]string{pr.ID, uid}]; !ok {
+			r.archive[[2]string{pr.ID, uid}] = r.removeReviewer(pr, uid, "user deleted")
+			archived++
+		}
+		pr.reviewers = slices.DeleteFunc(pr.reviewers, func(id string) bool { return id == uid })
+	}
+	delete(r.identities, uid)
+
+	r.insertOutbox(models.DomainEvent{
+		Type:     models.DomainUserDeleted,
+		TeamName: u.TeamName,
+		UserID:   uid,
+		Details:  map[string]any{"reassignments": len(reassignments)},
+	})
+	return &repo.UserDeletionResult{Reassignments: reassignments, ArchivedAssignments: archived}, nil
+}
+
+func (r *Repository) GetStats(_ context.Context, filter models.StatsFilter, page models.Page) (*models.Stats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := &models.Stats{
+		TotalTeams:        len(r.teams),
+		PRsByStatus:       models.StatusCounts(nil),
+		AssignmentsByUser: []models.UserAssignments{},
+		ReviewersByPR:     []models.PRReviewerCount{},
+	}
+	inRange := func(t time.Time) bool {
+		return (filter.From == nil || !t.Before(*filter.From)) && (filter.To == nil || t.Before(*filter.To))
+	}
+
+	var byUser []models.UserAssignments
+	for _, u := range r.sortedUsers() {
+		if u.deleted {
+			continue
+		}
+		ua := models.UserAssignments{UserID: u.UserID, Username: u.Username}
+		for _, pr := range r.prs {
+			if inRange(pr.createdAt) && slices.Contains(pr.reviewers, u.UserID) {
+				ua.Assignments++
+			}
+		}
+		byUser = append(byUser, ua)
+	}
+	slices.SortStableFunc(byUser, func(a, b models.UserAssignments) int { return b.Assignments - a.Assignments })
+
+	var byPR []models.PRReviewerCount
+	var mergeTimes, reviewTimes []float64
+	for _, pr := range r.sortedPRs() {
+		if pr.Status == models.StatusMerged {
+			merged, err := time.Parse(time.RFC3339, *pr.MergedAt)
+			if err != nil {
+				return nil, err
+			}
+			if inRange(merged) {
+				mergeTimes = append(mergeTimes, merged.Sub(pr.createdAt).Seconds())
+				for _, uid := range pr.reviewers {
+					reviewTimes = append(reviewTimes, merged.Sub(pr.assignedAt[uid]).Seconds())
+				}
+			}
+		}
+
+		if !inRange(pr.createdAt) {
+			continue
+		}
+		stats.TotalPRs++
+		stats.PRsByStatus[pr.Status]++
+		switch {
+		case pr.Status.IsOpen():
+			stats.OpenPRs++
+		case pr.Status == models.StatusMerged:
+			stats.MergedPRs++
+		case pr.Status == models.StatusClosed:
+			stats.ClosedPRs++
+		}
+		byPR = append(byPR, models.PRReviewerCount{PRID: pr.ID, PRName: pr.Name, ReviewerCount: len(pr.reviewers)})
+	}
+	slices.SortStableFunc(byPR, func(a, b models.PRReviewerCount) int { return b.ReviewerCount - a.ReviewerCount })
+
+	stats.TotalUsers = len(byUser)
+	stats.AssignmentsByUserTotal, stats.ReviewersByPRTotal = len(byUser), len(byPR)
+	stats.AssignmentsByUser = append(stats.AssignmentsByUser, paginate(byUser, page)...)
+	stats.ReviewersByPR = append(stats.ReviewersByPR, paginate(byPR, page)...)
+	stats.TimeToMerge = durationStats(mergeTimes)
+	stats.AssignmentToMerge = durationStats(reviewTimes)
+	return stats, nil
+}
+
+// durationStats считает среднее, медиану и p90 как AVG и percentile_cont в Postgres.
+func durationStats(seconds []float64) models.DurationStats {
+	if len(seconds) == 0 {
+		return models.DurationStats{}
+	}
+	sorted := slices.Sorted(slices.Values(seconds))
+	var sum float64
+	for _, s := range sorted {
+		sum += s
+	}
+	avg := sum / float64(len(sorted))
+	median, p90 := percentile(sorted, 0.5), percentile(sorted, 0.9)
+	return models.DurationStats{AvgSeconds: &avg, MedianSeconds: &median, P90Seconds: &p90}
+}
+
+// percentile — непрерывный перцентиль с линейной интерполяцией по отсортированным значениям.
+func percentile(sorted []float64, p float64) float64 {
+	pos := p * float64(len(sorted)-1)
+	lo := int(pos)
+	if lo+1 >= len(sorted) {
+		return sorted[lo]
+	}
+	return sorted[lo] + (pos-float64(lo))*(sorted[lo+1]-sorted[lo])
+}
+
+func (r *Repository) GetTeamStats(_ context.Context, teamName string) (*models.TeamStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.teams[teamName] == 0 {
+		return nil, repo.ErrNotFound
+	}
+
+	stats := &models.TeamStats{
+		TeamName:            teamName,
+		PRsByStatus:         models.StatusCounts(nil),
+		AssignmentsByMember: []models.TeamMemberAssignments{},
+	}
+	teamPRs := map[string]bool{}
+	var mergeSeconds float64
+	for _, pr := range r.sortedPRs() {
+		if r.users[pr.AuthorID].TeamName != teamName {
+			continue
+		}
+		teamPRs[pr.ID] = true
+		stats.PRsByStatus[pr.Status]++
+		switch {
+		case pr.Status.IsOpen():
+			stats.OpenPRs++
+		case pr.Status == models.StatusMerged:
+			stats.MergedPRs++
+			merged, err := time.Parse(time.RFC3339, *pr.MergedAt)
+			if err != nil {
+				return nil, err
+			}
+			mergeSeconds += merged.Sub(pr.createdAt).Seconds()
+		}
+	}
+	if stats.MergedPRs > 0 {
+		avg := mergeSeconds / float64(stats.MergedPRs)
+		stats.AvgTimeToMergeSeconds = &avg
+	}
+
+	for _, ev := range r.events {
+		if !teamPRs[ev.PRID] {
+			continue
+		}
+		switch ev.EventType {
+		case models.EventAssigned:
+			stats.Assignments++
+		case models.EventReassigned, models.EventDeclined:
+			stats.Reassignments++
+		}
+	}
+
+	for _, u := range r.sortedUsers() {
+		if u.TeamName != teamName || u.deleted {
+			continue
+		}
+		m := models.TeamMemberAssignments{UserID: u.UserID, Username: u.Username, IsActive: u.IsActive}
+		for _, pr := range r.prs {
+			if slices.Contains(pr.reviewers, u.UserID) {
+				m.Total++
+				if pr.Status.IsOpen() {
+					m.Open++
+				}
+			}
+		}
+		stats.AssignmentsByMember = append(stats.AssignmentsByMember, m)
+	}
+	slices.SortStableFunc(stats.AssignmentsByMember, func(a, b models.TeamMemberAssignments) int {
+		return b.Total - a.Total
+	})
+	return stats, nil
+}
+
+// GetSLAStats повторяет repo.slaQuery: текущие назначения и снятые ревьюверы
+// с известным временем назначения, без удаленных пользователей.
+func (r *Repository) GetSLAStats(_ context.Context, filter models.SLAFilter) (*models.SLAStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	type review struct {
+		uid                  string
+		assigned, acted, end time.Time
+	}
+	var reviews []review
+	for _, pr := range r.prs {
+		for _, uid := range pr.reviewers {
+			rv := review{uid: uid, assigned: pr.assignedAt[uid], acted: pr.actedAt[uid], end: r.now()}
+			if pr.MergedAt != nil {
+				merged, err := time.Parse(time.RFC3339, *pr.MergedAt)
+				if err != nil {
+					return nil, err
+				}
+				rv.end = merged
+			}
+			reviews = append(reviews, rv)
+		}
+	}
+	for key, removed := range r.archive {
+		if !removed.assignedAt.IsZero() {
+			reviews = append(reviews, review{
+				uid: key[1], assigned: removed.assignedAt, acted: removed.firstActionAt, end: removed.archivedAt,
+			})
+		}
+	}
+
+	teams, users := map[string]*slaCounter{}, map[string]*slaCounter{}
+	for _, rv := range reviews {
+		u := r.users[rv.uid]
+		if u == nil || u.deleted || (filter.TeamName != "" && u.TeamName != filter.TeamName) {
+			continue
+		}
+		if !rv.acted.IsZero() {
+			rv.end = rv.acted
+		}
+		if rv.acted.IsZero() && rv.end.Sub(rv.assigned) < filter.Window {
+			continue
+		}
+		for _, c := range []*slaCounter{
+			counter(teams, u.TeamName, models.SLACompliance{TeamName: u.TeamName}),
+			counter(users, u.UserID, models.SLACompliance{TeamName: u.TeamName, UserID: u.UserID, Username: u.Username}),
+		} {
+			c.add(rv.acted, rv.assigned, filter.Window)
+		}
+	}
+
+	return &models.SLAStats{Teams: sortedSLA(teams), Users: sortedSLA(users)}, nil
+}
+
+type slaCounter struct {
+	models.SLACompliance
+	responseSum float64
+}
+
+func counter(m map[string]*slaCounter, key string, c models.SLACompliance) *slaCounter {
+	if m[key] == nil {
+		m[key] = &slaCounter{SLACompliance: c}
+	}
+	return m[key]
+}
+
+func (c *slaCounter) add(acted, assigned time.Time, window time.Duration) {
+	c.Due++
+	if acted.IsZero() {
+		return
+	}
+	c.Responded++
+	response := acted.Sub(assigned)
+	if response <= window {
+		c.WithinSLA++
+	}
+	c.responseSum += response.Seconds()
+	avg := c.responseSum / float64(c.Responded)
+	c.AvgResponseSeconds = &avg
+}
+
+func sortedSLA(m map[string]*slaCounter) []models.SLACompliance {
+	result := []models.SLACompliance{}
+	for _, c := range m {
+		result = append(result, c.SLACompliance)
+	}
+	slices.SortFunc(result, func(a, b models.SLACompliance) int {
+		return cmp.Or(strings.Compare(a.TeamName, b.TeamName), strings.Compare(a.UserID, b.UserID))
+	})
+	return result
+}
+
+// Вспомогательные функции.
+
+func (r *Repository) deactivateTeamUsers(teamName string) []string {
+	deactivated := []string{}
+	for _, u := range r.sortedUsers() {
+		if u.TeamName == teamName && u.IsActive {
+			u.IsActive = false
+			deactivated = append(deactivated, u.UserID)
+		}
+	}
+	return deactivated
+}
+
+// reassignReviewers повторяет repo.reassignReviewers: каждого снятого ревьювера
+// открытого PR заменяет случайный активный коллега по команде, кроме автора,
+// снятых ревьюверов и уже выбранных замен этого PR. PR обходятся по возрастанию ID,
+// версия каждого PR увеличивается один раз.
+func (r *Repository) reassignReviewers(
+	removed []string,
+	userTeams map[string]string,
+	rng interface{ Intn(int) int },
+	reason string,
+) ([]map[string]string, error) {
+	reassignments := []map[string]string{}
+	for _, pr := range r.sortedPRs() {
+		if !pr.Status.IsOpen() {
+			continue
+		}
+		var affected []string
+		for _, uid := range pr.reviewers {
+			if slices.Contains(removed, uid) {
+				affected = append(affected, uid)
+			}
+		}
+
+		if len(affected) > 0 {
+			pr.Version++
+		}
+		var chosen []string
+		for _, oldReviewer := range affected {
+			var filtered []string
+			for _, u := range r.sortedUsers() {
+				if u.TeamName == userTeams[oldReviewer] && u.IsActive && !r.unavailable(u) &&
+					u.UserID != pr.AuthorID && !slices.Contains(affected, u.UserID) &&
+					!slices.Contains(chosen, u.UserID) {
+					filtered = append(filtered, u.UserID)
+				}
+			}
+
+			var newReviewer string
+			if len(filtered) > 0 {
+				newReviewer = filtered[rng.Intn(len(filtered))]
+				chosen = append(chosen, newReviewer)
+			}
+
+			pr.reviewers = slices.DeleteFunc(pr.reviewers, func(id string) bool { return id == oldReviewer })
+			if newReviewer != "" {
+				if err := r.checkNewReviewer(pr.AuthorID, pr.reviewers, newReviewer); err != nil {
+					return nil, err
+				}
+				pr.reviewers = append(pr.reviewers, newReviewer)
+				r.recordAssignment(pr, newReviewer)
+				r.insertOutbox(models.DomainEvent{
+					Type:       models.DomainReviewerAssigned,
+					PRID:       pr.ID,
+					UserID:     newReviewer,
+					Recipients: []string{newReviewer},
+					Details:    map[string]any{"old_user_id": oldReviewer, "reason": reason},
+				})
+			}
+
+			r.insertAssignmentEvent(models.AssignmentEvent{
+				PRID:      pr.ID,
+				EventType: models.EventReassigned,
+				UserID:    oldReviewer,
+				NewUserID: newReviewer,
+				Reason:    reason,
+			})
+			reassignments = append(reassignments, map[string]string{
+				"pr_id": pr.ID,
+				"old":   oldReviewer,
+				"new":   newReviewer,
+			})
+		}
+	}
+	return reassignments, nil
+}
+
+// checkNewReviewer проверяет ограничения pr_reviewers для нового ревьювера.
+func (r *Repository) checkNewReviewer(authorID string, current []string, uid string) error {
+	if _, ok := r.users[uid]; !ok {
+		return fmt.Errorf("%w: user %s", repo.ErrUnknownReference, uid)
+	}
+	return checkReviewer(authorID, current, uid)
+}
+
+func checkReviewer(authorID string, current []string, uid string) error {
+	if uid == authorID {
+		return fmt.Errorf("%w: %s", repo.ErrAuthorIsReviewer, uid)
+	}
+	if slices.Contains(current, uid) {
+		return fmt.Errorf("%w: %s", repo.ErrDuplicateReviewer, uid)
+	}
+	return nil
+}
+
+func (r *Repository) recordAssignment(pr *pullRequest, uid string) {
+	r.lastAssigned[uid] = r.now()
+	pr.assignedAt[uid] = r.now()
+	delete(pr.actedAt, uid)
+}
+
+// removeReviewer возвращает строку pr_reviewers_archive для снимаемого ревьювера.
+func (r *Repository) removeReviewer(pr *pullRequest, uid, reason string) removedReviewer {
+	return removedReviewer{
+		reason:        reason,
+		assignedAt:    pr.assignedAt[uid],
+		firstActionAt: pr.actedAt[uid],
+		archivedAt:    r.now(),
+	}
+}
+
+// unavailable сообщает, что пользователь в отпуске или приостановил назначения.
+func (r *Repository) unavailable(u *user) bool {
+	now := r.now()
+	if u.snoozedUntil != nil && u.snoozedUntil.After(now) {
+		return true
+	}
+	for _, v := range r.vacations {
+		if v.UserID == u.UserID && !v.StartsAt.After(now) && v.EndsAt.After(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Repository) sortedUsers() []*user {
+	users := make([]*user, 0, len(r.users))
+	for _, u := range r.users {
+		users = append(users, u)
+	}
+	slices.SortFunc(users, func(a, b *user) int { return strings.Compare(a.UserID, b.UserID) })
+	return users
+}
+
+func (r *Repository) sortedPRs() []*pullRequest {
+	prs := make([]*pullRequest, 0, len(r.prs))
+	for _, pr := range r.prs {
+		prs = append(prs, pr)
+	}
+	slices.SortFunc(prs, func(a, b *pullRequest) int { return strings.Compare(a.ID, b.ID) })
+	return prs
+}
+
+// state — копия изменяемого при переназначении состояния для отката "транзакции".
+type state struct {
+	users        map[string]user
+	prs          map[string]pullRequest
+	lastAssigned map[string]time.Time
+	outbox       int
+	events       int
+}
+
+func (r *Repository) snapshot() state {
+	s := state{
+		users:        make(map[string]user, len(r.users)),
+		prs:          make(map[string]pullRequest, len(r.prs)),
+		lastAssigned: maps.Clone(r.lastAssigned),
+		outbox:       len(r.outbox),
+		events:       len(r.events),
+	}
+	for id, u := range r.users {
+		s.users[id] = *u
+	}
+	for id, pr := range r.prs {
+		cp := *pr
+		cp.reviewers = slices.Clone(pr.reviewers)
+		cp.assignedAt = maps.Clone(pr.assignedAt)
+		cp.actedAt = maps.Clone(pr.actedAt)
+		s.prs[id] = cp
+	}
+	return s
+}
+
+func (r *Repository) restore(s state) {
+	for id, u := range s.users {
+		*r.users[id] = u
+	}
+	for id, pr := range s.prs {
+		*r.prs[id] = pr
+	}
+	r.lastAssigned = s.lastAssigned
+	r.outbox = r.outbox[:s.outbox]
+	r.events = r.events[:s.events]
+}
+
+func (u *user) copy() *models.User {
+	cp := u.User
+	cp.Tags = slices.Clone(u.Tags)
+	return &cp
+}
+
+func paginate[T any](items []T, page models.Page) []T {
+	if page.Offset >= len(items) {
+		return nil
+	}
+	items = items[page.Offset:]
+	if page.Limit > 0 && page.Limit < len(items) {
+		items = items[:page.Limit]
+	}
+	return items
+}
+
+func sortedCopy(s []string) []string {
+	cp := append([]string{}, s...)
+	slices.Sort(cp)
+	return cp
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func orEmpty(s []string) []string {
+	return append([]string{}, s...)
+}
+
+func cloneInt(n *int) *int {
+	if n == nil {
+		return nil
+	}
+	v := *n
+	return &v
+}
+
+func cloneString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	v := *s
+	return &v
+}
@@ -0,0 +1,458 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+)
+
+func (r *Repository) ApprovePR(_ context.Context, prID, userID string, version int) error {
+	r.rowMu.Lock()
+	defer r.rowMu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pr, ok := r.prs[prID]
+	if _, known := r.users[userID]; !ok || !known {
+		return fmt.Errorf("%w: approval %s/%s", repo.ErrUnknownReference, prID, userID)
+	}
+	if pr.Version != version {
+		return repo.ErrVersionConflict
+	}
+	pr.approvals[userID] = true
+	if _, acted := pr.actedAt[userID]; !acted && slices.Contains(pr.reviewers, userID) {
+		pr.actedAt[userID] = r.now()
+	}
+	return nil
+}
+
+// SetTeamPolicy, как и repo.SetTeamPolicy, подставляет значения по умолчанию
+// для незаданных настроек назначения.
+func (r *Repository) SetTeamPolicy(_ context.Context, p models.TeamPolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if p.ReviewerCount == nil {
+		p.ReviewerCount = ptr(models.DefaultReviewerCount)
+	}
+	if p.AssignmentStrategy == "" {
+		p.AssignmentStrategy = models.StrategyRandom
+	}
+	if p.CrossTeamFallback == nil {
+		p.CrossTeamFallback = ptr(false)
+	}
+	if p.RequireManager == nil {
+		p.RequireManager = ptr(false)
+	}
+	if p.AutoReassign == nil {
+		p.AutoReassign = ptr(false)
+	}
+	if p.MandatoryReviewer != nil && *p.MandatoryReviewer == "" {
+		p.MandatoryReviewer = nil
+	}
+	r.policies[p.TeamName] = clonePolicy(p)
+	return nil
+}
+
+func (r *Repository) GetTeamPolicy(_ context.Context, teamName string) (*models.TeamPolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.policies[teamName]
+	if !ok {
+		return nil, repo.ErrNotFound
+	}
+	p = clonePolicy(p)
+	return &p, nil
+}
+
+func (r *Repository) SetTeamAutoMerge(_ context.Context, cfg models.TeamAutoMerge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.autoMerge[cfg.TeamName] = cfg
+	return nil
+}
+
+func (r *Repository) GetTeamAutoMerge(_ context.Context, teamName string) (*models.TeamAutoMerge, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, ok := r.autoMerge[teamName]
+	if !ok {
+		return nil, repo.ErrNotFound
+	}
+	return &cfg, nil
+}
+
+func (r *Repository) SetOwnershipRules(_ context.Context, repository string, rules []models.OwnershipRule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rule := range rules {
+		if _, ok := r.users[rule.UserID]; rule.UserID != "" && !ok {
+			return fmt.Errorf("%w: user %s", repo.ErrUnknownReference, rule.UserID)
+		}
+		if rule.TeamName != "" && r.teams[rule.TeamName] == 0 {
+			return fmt.Errorf("%w: team %s", repo.ErrUnknownReference, rule.TeamName)
+		}
+	}
+
+	r.rules = slices.DeleteFunc(r.rules, func(rule models.OwnershipRule) bool {
+		return rule.Repository == repository
+	})
+	for _, rule := range rules {
+		r.nextRuleID++
+		rule.ID, rule.Repository = r.nextRuleID, repository
+		r.rules = append(r.rules, rule)
+	}
+	return nil
+}
+
+func (r *Repository) ListOwnershipRules(_ context.Context, repository string) ([]models.OwnershipRule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rules := []models.OwnershipRule{}
+	for _, rule := range r.rules {
+		if rule.Repository == repository {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func (r *Repository) SetNotificationPreferences(_ context.Context, p models.NotificationPreferences) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[p.UserID]; !ok {
+		return fmt.Errorf("%w: user %s", repo.ErrUnknownReference, p.UserID)
+	}
+	r.optOut[p.UserID] = p.EmailOptOut
+	sub := r.digests[p.UserID]
+	sub.UserID = p.UserID
+	if p.DigestTime != nil {
+		sub.DigestTime = *p.DigestTime
+	}
+	if p.Timezone != nil {
+		sub.Timezone = *p.Timezone
+	}
+	r.digests[p.UserID] = sub
+	return nil
+}
+
+func (r *Repository) ListDigestSubscriptions(context.Context) ([]models.DigestSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	subs := []models.DigestSubscription{}
+	for _, u := range r.sortedUsers() {
+		if sub, ok := r.digests[u.UserID]; ok && sub.DigestTime != "" && u.IsActive && !u.deleted {
+			subs = append(subs, sub)
+		}
+	}
+	return subs, nil
+}
+
+func (r *Repository) GetDigestSubscription(_ context.Context, uid string) (*models.DigestSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sub, ok := r.digests[uid]
+	if !ok {
+		sub.UserID = uid
+	}
+	return &sub, nil
+}
+
+func (r *Repository) MarkDigestSent(_ context.Context, uid string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if sub, ok := r.digests[uid]; ok {
+		sub.LastSentAt = &at
+		r.digests[uid] = sub
+	}
+	return nil
+}
+
+func (r *Repository) ListReviewAssignments(_ context.Context, uid string) ([]models.ReviewAssignment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	assignments := []models.ReviewAssignment{}
+	for _, pr := range r.prs {
+		if !pr.Status.IsOpen() || !slices.Contains(pr.reviewers, uid) || pr.approvals[uid] {
+			continue
+		}
+		a := models.ReviewAssignment{
+			PRID:       pr.ID,
+			PRName:     pr.Name,
+			AuthorID:   pr.AuthorID,
+			Priority:   cmp.Or(pr.Priority, models.PriorityNormal),
+			URL:        pr.URL,
+			AssignedAt: pr.assignedAt[uid],
+		}
+		if acted, ok := pr.actedAt[uid]; ok && !acted.IsZero() {
+			a.FirstActionAt = &acted
+		}
+		assignments = append(assignments, a)
+	}
+	slices.SortFunc(assignments, func(a, b models.ReviewAssignment) int {
+		if c := a.AssignedAt.Compare(b.AssignedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.PRID, b.PRID)
+	})
+	return assignments, nil
+}
+
+// EmailRecipients реализует notify.RecipientLookup.
+func (r *Repository) EmailRecipients(_ context.Context, userIDs []string) ([]models.Recipient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	recipients := []models.Recipient{}
+	for _, u := range r.sortedUsers() {
+		if slices.Contains(userIDs, u.UserID) && !u.deleted && u.Email != "" && !r.optOut[u.UserID] {
+			recipients = append(recipients, models.Recipient{UserID: u.UserID, Name: u.Username, Email: u.Email})
+		}
+	}
+	return recipients, nil
+}
+
+func (r *Repository) SetUserIdentity(_ context.Context, id models.UserIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if u, ok := r.users[id.UserID]; !ok || u.deleted {
+		return repo.ErrNotFound
+	}
+	if id.ExternalID == "" {
+		delete(r.identities[id.UserID], id.Provider)
+		return nil
+	}
+	for uid, ids := range r.identities {
+		if uid != id.UserID && ids[id.Provider] == id.ExternalID {
+			return fmt.Errorf("%w: %s %s", repo.ErrDuplicate, id.Provider, id.ExternalID)
+		}
+	}
+	if r.identities[id.UserID] == nil {
+		r.identities[id.UserID] = map[string]string{}
+	}
+	r.identities[id.UserID][id.Provider] = id.ExternalID
+	return nil
+}
+
+func (r *Repository) GetUserIdentities(_ context.Context, uid string) ([]models.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	identities := []models.UserIdentity{}
+	for _, provider := range slices.Sorted(maps.Keys(r.identities[uid])) {
+		identities = append(identities, models.UserIdentity{
+			UserID: uid, Provider: provider, ExternalID: r.identities[uid][provider],
+		})
+	}
+	return identities, nil
+}
+
+func (r *Repository) GetUserByIdentity(_ context.Context, provider, externalID string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for uid, ids := range r.identities {
+		if id, ok := ids[provider]; ok && id == externalID {
+			return r.users[uid].copy(), nil
+		}
+	}
+	return nil, repo.ErrNotFound
+}
+
+// ExternalIDs реализует notify.IdentityLookup.
+func (r *Repository) ExternalIDs(_ context.Context, provider string, userIDs []string) (map[string]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := map[string]string{}
+	for _, uid := range userIDs {
+		if id, ok := r.identities[uid][provider]; ok {
+			ids[uid] = id
+		}
+	}
+	return ids, nil
+}
+
+func (r *Repository) AddVacation(_ context.Context, v models.Vacation) (*models.Vacation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[v.UserID]; !ok {
+		return nil, fmt.Errorf("%w: user %s", repo.ErrUnknownReference, v.UserID)
+	}
+	r.nextVacationID++
+	v.ID = r.nextVacationID
+	r.vacations = append(r.vacations, v)
+	return &v, nil
+}
+
+func (r *Repository) SnoozeUser(_ context.Context, uid string, until *time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[uid]
+	if !ok || u.deleted {
+		return repo.ErrNotFound
+	}
+	u.snoozedUntil = until
+	return nil
+}
+
+func (r *Repository) ExpireVacations(_ context.Context) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	var expired []string
+	r.vacations = slices.DeleteFunc(r.vacations, func(v models.Vacation) bool {
+		if v.EndsAt.After(now) {
+			return false
+		}
+		expired = append(expired, v.UserID)
+		return true
+	})
+
+	users := []string{}
+	for _, uid := range expired {
+		hasMore := slices.ContainsFunc(r.vacations, func(v models.Vacation) bool { return v.UserID == uid })
+		if !hasMore && !slices.Contains(users, uid) {
+			users = append(users, uid)
+		}
+	}
+	slices.Sort(users)
+	return users, nil
+}
+
+func (r *Repository) ListStalePRs(
+	_ context.Context,
+	filter models.StaleFilter,
+	page models.Page,
+) ([]models.StalePR, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matched []*pullRequest
+	for _, pr := range r.prs {
+		approved := slices.ContainsFunc(pr.reviewers, func(uid string) bool { return pr.approvals[uid] })
+		createdBefore, remindedBefore := filter.CreatedBefore, filter.RemindedBefore
+		if pr.Priority == models.PriorityHigh {
+			createdBefore, remindedBefore = filter.HighCreatedBefore, filter.HighRemindedBefore
+		}
+		reminded := remindedBefore != nil && pr.remindedAt != nil && !pr.remindedAt.Before(*remindedBefore)
+		if pr.Status.IsOpen() && pr.createdAt.Before(createdBefore) && !approved && !reminded {
+			matched = append(matched, pr)
+		}
+	}
+	slices.SortFunc(matched, func(a, b *pullRequest) int {
+		if c := a.createdAt.Compare(b.createdAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	prs := []models.StalePR{}
+	for _, pr := range paginate(matched, page) {
+		s := models.StalePR{
+			ID:                pr.ID,
+			Name:              pr.Name,
+			AuthorID:          pr.AuthorID,
+			URL:               pr.URL,
+			AssignedReviewers: sortedCopy(pr.reviewers),
+			CreatedAt:         pr.createdAt,
+		}
+		if pr.remindedAt != nil {
+			s.RemindedAt = ptr(*pr.remindedAt)
+		}
+		prs = append(prs, s)
+	}
+	return prs, len(matched), nil
+}
+
+func (r *Repository) MarkReminded(_ context.Context, prIDs []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	for _, id := range prIDs {
+		if pr, ok := r.prs[id]; ok {
+			pr.remindedAt = ptr(now)
+		}
+	}
+	return nil
+}
+
+func (r *Repository) ListOverdueAssignments(
+	_ context.Context,
+	assignedBefore time.Time,
+	after *models.OverdueAssignment,
+	limit int,
+) ([]models.OverdueAssignment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var overdue []models.OverdueAssignment
+	for _, pr := range r.prs {
+		if !pr.Status.IsOpen() {
+			continue
+		}
+		for _, uid := range pr.reviewers {
+			u, ok := r.users[uid]
+			if !ok || !pr.actedAt[uid].IsZero() || !pr.assignedAt[uid].Before(assignedBefore) {
+				continue
+			}
+			if p, ok := r.policies[u.TeamName]; !ok || !*p.AutoReassign {
+				continue
+			}
+			overdue = append(overdue, models.OverdueAssignment{PRID: pr.ID, UserID: uid, AssignedAt: pr.assignedAt[uid]})
+		}
+	}
+	compare := func(a, b models.OverdueAssignment) int {
+		if c := a.AssignedAt.Compare(b.AssignedAt); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.PRID, b.PRID); c != 0 {
+			return c
+		}
+		return strings.Compare(a.UserID, b.UserID)
+	}
+	slices.SortFunc(overdue, compare)
+	if after != nil {
+		overdue = slices.DeleteFunc(overdue, func(a models.OverdueAssignment) bool { return compare(a, *after) <= 0 })
+	}
+	return overdue[:min(limit, len(overdue))], nil
+}
+
+func clonePolicy(p models.TeamPolicy) models.TeamPolicy {
+	if p.RequiredApprovals != nil {
+		p.RequiredApprovals = ptr(*p.RequiredApprovals)
+	}
+	p.ReviewerCount = ptr(*p.ReviewerCount)
+	p.CrossTeamFallback = ptr(*p.CrossTeamFallback)
+	p.RequireManager = ptr(*p.RequireManager)
+	p.AutoReassign = ptr(*p.AutoReassign)
+	if p.MandatoryReviewer != nil {
+		p.MandatoryReviewer = ptr(*p.MandatoryReviewer)
+	}
+	return p
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
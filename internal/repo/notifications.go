@@ -0,0 +1,135 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"prreviewer/internal/models"
+)
+
+// EmailRecipients возвращает адреса пользователей из userIDs, у которых задан
+// email и нет отказа от писем.
+func (r *Repository) EmailRecipients(ctx context.Context, userIDs []string) ([]models.Recipient, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT u.user_id, u.username, u.email
+		FROM users u
+		LEFT JOIN notification_preferences np ON np.user_id = u.user_id
+		WHERE u.user_id = ANY($1) AND u.deleted_at IS NULL
+			AND COALESCE(u.email, '') <> '' AND NOT COALESCE(np.email_opt_out, false)
+		ORDER BY u.user_id`,
+		userIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recipients := []models.Recipient{}
+	for rows.Next() {
+		var rcpt models.Recipient
+		if err := rows.Scan(&rcpt.UserID, &rcpt.Name, &rcpt.Email); err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, rcpt)
+	}
+	return recipients, rows.Err()
+}
+
+// SetNotificationPreferences сохраняет настройки уведомлений; расписание
+// дайджеста меняется, только если поле задано (не nil).
+func (r *Repository) SetNotificationPreferences(ctx context.Context, p models.NotificationPreferences) error {
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO notification_preferences(user_id, email_opt_out, digest_time, timezone)
+		VALUES($1, $2, NULLIF($3, '')::time, COALESCE($4, ''))
+		ON CONFLICT(user_id) DO UPDATE
+		SET email_opt_out=$2,
+			digest_time=CASE WHEN $3::text IS NULL THEN notification_preferences.digest_time
+				ELSE NULLIF($3, '')::time END,
+			timezone=COALESCE($4, notification_preferences.timezone),
+			updated_at=NOW()`,
+		p.UserID, p.EmailOptOut, p.DigestTime, p.Timezone)
+	return mapReviewerError(err)
+}
+
+const digestColumns = `np.user_id, COALESCE(left(np.digest_time::text, 5), ''), np.timezone, np.last_digest_at`
+
+// ListDigestSubscriptions возвращает настройки дайджеста активных пользователей,
+// у которых задано время отправки.
+func (r *Repository) ListDigestSubscriptions(ctx context.Context) ([]models.DigestSubscription, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT `+digestColumns+`
+		FROM notification_preferences np
+		JOIN users u ON u.user_id = np.user_id
+		WHERE np.digest_time IS NOT NULL AND u.is_active AND u.deleted_at IS NULL
+		ORDER BY np.user_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []models.DigestSubscription{}
+	for rows.Next() {
+		var sub models.DigestSubscription
+		if err := rows.Scan(&sub.UserID, &sub.DigestTime, &sub.Timezone, &sub.LastSentAt); err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// GetDigestSubscription возвращает настройки дайджеста пользователя; без
+// сохраненных настроек — пустые (дайджест не настроен).
+func (r *Repository) GetDigestSubscription(ctx context.Context, uid string) (*models.DigestSubscription, error) {
+	sub := models.DigestSubscription{UserID: uid}
+	err := r.conn(ctx).QueryRow(ctx, `
+		SELECT `+digestColumns+`
+		FROM notification_preferences np
+		WHERE np.user_id = $1`,
+		uid).Scan(&sub.UserID, &sub.DigestTime, &sub.Timezone, &sub.LastSentAt)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// MarkDigestSent запоминает время отправки дайджеста пользователю.
+func (r *Repository) MarkDigestSent(ctx context.Context, uid string, at time.Time) error {
+	_, err := r.db.Exec(ctx,
+		"UPDATE notification_preferences SET last_digest_at = $2 WHERE user_id = $1",
+		uid, at)
+	return err
+}
+
+// ListReviewAssignments возвращает открытые PR, где uid назначен ревьювером и
+// еще не одобрил их; давние назначения первыми.
+func (r *Repository) ListReviewAssignments(ctx context.Context, uid string) ([]models.ReviewAssignment, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT p.pull_request_id, p.pull_request_name, p.author_id, p.priority, COALESCE(p.url, ''),
+			r.assigned_at, r.first_action_at
+		FROM pr_reviewers r
+		JOIN pull_requests p ON p.pull_request_id = r.pull_request_id
+		WHERE r.user_id = $1 AND p.status IN `+openStatuses+`
+			AND NOT EXISTS (
+				SELECT 1 FROM approvals a
+				WHERE a.pull_request_id = r.pull_request_id AND a.user_id = r.user_id)
+		ORDER BY r.assigned_at, p.pull_request_id`,
+		uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assignments := []models.ReviewAssignment{}
+	for rows.Next() {
+		var a models.ReviewAssignment
+		if err := rows.Scan(&a.PRID, &a.PRName, &a.AuthorID, &a.Priority, &a.URL,
+			&a.AssignedAt, &a.FirstActionAt); err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, a)
+	}
+	return assignments, rows.Err()
+}
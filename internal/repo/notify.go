@@ -0,0 +1,117 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// cacheChannel — канал LISTEN/NOTIFY для сброса кэшей между экземплярами.
+const cacheChannel = "prreviewer_cache_invalidation"
+
+const (
+	listenRetryMin = time.Second
+	listenRetryMax = 30 * time.Second
+)
+
+// invalidation — сообщение о сбросе кэша в канале cacheChannel; пустой список
+// префиксов сбрасывает весь кэш.
+type invalidation struct {
+	Prefixes []string `json:"prefixes"`
+}
+
+// InvalidationBus рассылает сбросы кэша через NOTIFY и принимает их через
+// LISTEN на отдельном соединении. За PgBouncer в режиме transaction LISTEN не работает.
+type InvalidationBus struct {
+	db *pgxpool.Pool
+}
+
+func NewInvalidationBus(db *pgxpool.Pool) *InvalidationBus {
+	return &InvalidationBus{db: db}
+}
+
+// WithInvalidation включает рассылку сбросов кэша при изменении команд и
+// пользователей. NOTIFY отправляется в транзакции записи: Postgres доставляет
+// его при фиксации, а откат его отменяет. Так кэши сбрасываются при любой
+// записи репозитория, в том числе из фоновых задач воркера.
+func WithInvalidation(bus *InvalidationBus) Option {
+	return func(r *Repository) { r.bus = bus }
+}
+
+// invalidateCaches рассылает в транзакции tx сброс всех кэшей команд и статистики.
+func (r *Repository) invalidateCaches(ctx context.Context, tx pgx.Tx) error {
+	if r.bus == nil {
+		return nil
+	}
+	payload, err := json.Marshal(invalidation{})
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, "SELECT pg_notify($1, $2)", cacheChannel, string(payload))
+	return err
+}
+
+// Listen вызывает drop для каждого сброса, включая записи этого же процесса,
+// пока ctx не отменен.
+// Разорванное соединение переоткрывается с паузой до listenRetryMax; после
+// переподключения drop вызывается с пустым списком, потому что сбросы за
+// время разрыва потеряны.
+func (b *InvalidationBus) Listen(ctx context.Context, drop func(ctx context.Context, prefixes []string)) {
+	delay := listenRetryMin
+	for reconnected := false; ; reconnected = true {
+		err := b.listen(ctx, func() {
+			delay = listenRetryMin
+			if reconnected {
+				drop(ctx, nil)
+			}
+		}, drop)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Warn("cache invalidation listener disconnected", "error", err, "retry_in", delay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, listenRetryMax)
+	}
+}
+
+// listen держит одно соединение с LISTEN до первой ошибки; ready вызывается,
+// когда подписка активна.
+func (b *InvalidationBus) listen(
+	ctx context.Context,
+	ready func(),
+	drop func(ctx context.Context, prefixes []string),
+) error {
+	pc, err := b.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// Соединение забирается из пула: оно занято ожиданием уведомлений.
+	conn := pc.Hijack()
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	if _, err := conn.Exec(ctx, "LISTEN "+cacheChannel); err != nil {
+		return err
+	}
+	ready()
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var msg invalidation
+		if err := json.Unmarshal([]byte(n.Payload), &msg); err != nil {
+			slog.Warn("malformed cache invalidation", "payload", n.Payload, "error", err)
+			continue
+		}
+		drop(ctx, msg.Prefixes)
+	}
+}
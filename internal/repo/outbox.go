@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jackc/pgx/v5"
+
+	"prreviewer/internal/models"
+)
+
+// insertOutbox пишет доменное событие в той же транзакции, что и изменение состояния.
+func insertOutbox(ctx context.Context, tx pgx.Tx, e models.DomainEvent) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx,
+		"INSERT INTO outbox(event_type, payload) VALUES($1, $2)",
+		e.Type, payload)
+	return err
+}
+
+// insertOutboxBatch записывает события одной командой COPY.
+func insertOutboxBatch(ctx context.Context, tx pgx.Tx, events []models.DomainEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	rows := make([][]any, 0, len(events))
+	for _, e := range events {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, []any{e.Type, payload})
+	}
+	_, err := tx.CopyFrom(ctx, pgx.Identifier{"outbox"}, []string{"event_type", "payload"}, pgx.CopyFromRows(rows))
+	return err
+}
+
+// PendingOutbox возвращает до limit неопубликованных событий, сделавших меньше
+// maxAttempts попыток, в порядке записи.
+func (r *Repository) PendingOutbox(ctx context.Context, limit, maxAttempts int) ([]models.DomainEvent, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT id, payload, created_at, attempts FROM outbox
+		WHERE published_at IS NULL AND attempts < $2
+		ORDER BY id
+		LIMIT $1`,
+		limit, maxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.DomainEvent{}
+	for rows.Next() {
+		var e models.DomainEvent
+		var payload []byte
+		var id int64
+		var attempts int
+		if err := rows.Scan(&id, &payload, &e.CreatedAt, &attempts); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return nil, err
+		}
+		e.ID, e.Attempts = id, attempts
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func (r *Repository) MarkOutboxPublished(ctx context.Context, ids []int64) error {
+	_, err := r.db.Exec(ctx,
+		"UPDATE outbox SET published_at=NOW(), attempts=attempts+1, last_error=NULL WHERE id = ANY($1)",
+		ids)
+	return err
+}
+
+func (r *Repository) MarkOutboxFailed(ctx context.Context, id int64, reason string) error {
+	_, err := r.db.Exec(ctx,
+		"UPDATE outbox SET attempts=attempts+1, last_error=$2 WHERE id=$1",
+		id, reason)
+	return err
+}
@@ -0,0 +1,60 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+
+	"prreviewer/internal/models"
+)
+
+// SetOwnershipRules заменяет все правила владения репозитория.
+func (r *Repository) SetOwnershipRules(ctx context.Context, repository string, rules []models.OwnershipRule) error {
+	return r.WithTx(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "DELETE FROM ownership_rules WHERE repository=$1", repository); err != nil {
+			return err
+		}
+
+		for _, rule := range rules {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO ownership_rules(repository, pattern, user_id, team_name)
+				VALUES($1, $2, NULLIF($3, ''), NULLIF($4, ''))`,
+				repository, rule.Pattern, rule.UserID, rule.TeamName)
+			if err != nil {
+				return mapReviewerError(err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// ListOwnershipRules возвращает правила репозитория в порядке добавления.
+func (r *Repository) ListOwnershipRules(ctx context.Context, repository string) ([]models.OwnershipRule, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT id, repository, pattern, COALESCE(user_id, ''), COALESCE(team_name, '')
+		FROM ownership_rules WHERE repository=$1 ORDER BY id`,
+		repository)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []models.OwnershipRule{}
+	for rows.Next() {
+		var rule models.OwnershipRule
+		if err := rows.Scan(&rule.ID, &rule.Repository, &rule.Pattern, &rule.UserID, &rule.TeamName); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// GetOwnerCandidates возвращает активных пользователей из userIDs и участников teamNames.
+func (r *Repository) GetOwnerCandidates(
+	ctx context.Context,
+	userIDs, teamNames, excludeIDs []string,
+) ([]models.Candidate, error) {
+	return r.queryCandidates(ctx, candidatesQuery("(user_id = ANY($1) OR team_name = ANY($2))"), excludeIDs, userIDs, teamNames)
+}
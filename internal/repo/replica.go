@@ -0,0 +1,89 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"prreviewer/internal/logging"
+)
+
+// querier — общие методы пула и транзакции для чтения.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// WithReplica направляет чтения GetTeam, GetStats, GetUserReviews, GetPR и
+// ListAssignmentEvents в пул реплики; запись всегда идет в основной пул.
+func WithReplica(replica *pgxpool.Pool) Option {
+	return func(r *Repository) { r.replica = replica }
+}
+
+type primaryKey struct{}
+
+// ReadFromPrimary помечает контекст: чтения в нем идут в основной пул, даже если
+// задана реплика. Нужен сценариям "записал — прочитал", которым мешает задержка репликации.
+func ReadFromPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// IsPrimaryRead сообщает, что контекст помечен ReadFromPrimary. Обертки с
+// кэшем по нему читают в обход кэша.
+func IsPrimaryRead(ctx context.Context) bool {
+	return ctx.Value(primaryKey{}) != nil
+}
+
+type txKey struct{}
+
+// withTxQuerier помечает контекст транзакцией tx: чтения в нем идут через нее,
+// а не через отдельное соединение пула. Нужен колбэкам, которые репозиторий
+// вызывает внутри своей транзакции: иначе каждый такой вызов держит второе
+// соединение и при исчерпанном пуле транзакция ждет сама себя.
+func withTxQuerier(ctx context.Context, tx pgx.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// conn возвращает транзакцию из контекста, если она есть, иначе основной пул.
+func (r *Repository) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return r.db
+}
+
+// read выполняет fn на реплике, а если она недоступна — повторяет на основном пуле.
+// Внутри транзакции из контекста fn выполняется в ней.
+func (r *Repository) read(ctx context.Context, op string, fn func(q querier) error) error {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(tx)
+	}
+	if r.replica == nil || IsPrimaryRead(ctx) {
+		return fn(r.db)
+	}
+
+	err := fn(r.replica)
+	if err == nil || !replicaUnavailable(ctx, err) {
+		return err
+	}
+	logging.FromContext(ctx).Warn("read replica unavailable, falling back to primary", "op", op, "error", err)
+	return fn(r.db)
+}
+
+// replicaUnavailable отличает сбой соединения с репликой от ошибки самого запроса.
+// Ответ сервера считается сбоем только для классов 08 (connection exception)
+// и 57P (реплика останавливается или еще не принимает соединения).
+func replicaUnavailable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, ErrNotFound) || errors.Is(err, pgx.ErrNoRows) {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return strings.HasPrefix(pgErr.Code, "08") || strings.HasPrefix(pgErr.Code, "57P")
+	}
+	return true
+}
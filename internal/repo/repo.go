@@ -3,59 +3,168 @@ package repo
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
 	"time"
 
 	"prreviewer/internal/models"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-var ErrNotFound = errors.New("not found")
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrVersionConflict = errors.New("version conflict")
+
+	// Нарушения ограничений и конфликты транзакций, которые репозиторий
+	// возвращает вместо сырых ошибок pgx (см. mapPgError).
+	ErrDuplicate  = errors.New("duplicate key")
+	ErrForeignKey = errors.New("referenced row does not exist")
+	ErrConflict   = errors.New("transaction conflict with a concurrent change")
+
+	ErrDuplicateReviewer = fmt.Errorf("%w: reviewer already assigned", ErrDuplicate)
+	ErrAuthorIsReviewer  = errors.New("author cannot be a reviewer")
+	ErrUnknownReference  = fmt.Errorf("%w: user or pull request", ErrForeignKey)
+)
+
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgCheckViolation      = "23514"
+	pgSerializationFail   = "40001"
+	pgDeadlockDetected    = "40P01"
+	pgLockNotAvailable    = "55P03"
+
+	constraintReviewerNotAuthor = "pr_reviewers_not_author"
+)
+
+// openStatuses — открытые состояния PR (models.PRStatus.IsOpen) для условий
+// "status IN ...".
+const openStatuses = `('OPEN', 'IN_REVIEW', 'CHANGES_REQUESTED')`
+
+const defaultDeactivationRetries = 3
 
 type Repository struct {
-	db *pgxpool.Pool
+	db      *pgxpool.Pool
+	replica *pgxpool.Pool // nil — чтения идут в db
+
+	deactivationIsoLevel pgx.TxIsoLevel
+	deactivationRetries  int
+	bus                  *InvalidationBus // nil — сбросы кэша не рассылаются
 }
 
-func New(db *pgxpool.Pool) *Repository {
-	return &Repository{db: db}
+type Option func(*Repository)
+
+// WithDeactivationIsolation задает уровень изоляции транзакции деактивации команды.
+func WithDeactivationIsolation(level pgx.TxIsoLevel) Option {
+	return func(r *Repository) { r.deactivationIsoLevel = level }
+}
+
+// WithDeactivationRetries задает число попыток при serialization failure / deadlock.
+func WithDeactivationRetries(n int) Option {
+	return func(r *Repository) {
+		if n > 0 {
+			r.deactivationRetries = n
+		}
+	}
+}
+
+func New(db *pgxpool.Pool, opts ...Option) *Repository {
+	r := &Repository{
+		db:                   db,
+		deactivationIsoLevel: pgx.ReadCommitted,
+		deactivationRetries:  defaultDeactivationRetries,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// ParseIsoLevel переводит значение из конфигурации в уровень изоляции pgx.
+func ParseIsoLevel(s string) (pgx.TxIsoLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "read_committed", "read committed":
+		return pgx.ReadCommitted, nil
+	case "repeatable_read", "repeatable read":
+		return pgx.RepeatableRead, nil
+	case "serializable":
+		return pgx.Serializable, nil
+	default:
+		return "", fmt.Errorf("unknown isolation level %q", s)
+	}
 }
 
 func (r *Repository) TeamExists(ctx context.Context, name string) (bool, error) {
 	var exists bool
-	err := r.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM teams WHERE team_name=$1)", name).Scan(&exists)
+	err := r.conn(ctx).QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM teams WHERE team_name=$1)", name).Scan(&exists)
 	return exists, err
 }
 
 func (r *Repository) CreateTeam(ctx context.Context, team models.Team) error {
-	tx, err := r.db.Begin(ctx)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = tx.Rollback(ctx) }()
+	return r.WithTx(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "INSERT INTO teams(team_name) VALUES($1)", team.TeamName)
+		if err != nil {
+			return err
+		}
 
-	_, err = tx.Exec(ctx, "INSERT INTO teams(team_name) VALUES($1)", team.TeamName)
-	if err != nil {
-		return err
-	}
+		for _, m := range team.Members {
+			if err := upsertMember(ctx, tx, team.TeamName, m); err != nil {
+				return err
+			}
+		}
+		if err := r.invalidateCaches(ctx, tx); err != nil {
+			return err
+		}
 
-	for _, m := range team.Members {
-		_, err = tx.Exec(ctx, `
-			INSERT INTO users(user_id, username, team_name, is_active) 
-			VALUES($1, $2, $3, $4)
-			ON CONFLICT(user_id) DO UPDATE 
-			SET username=$2, team_name=$3, is_active=$4`,
-			m.UserID, m.Username, team.TeamName, m.IsActive)
+		err = insertOutbox(ctx, tx, models.DomainEvent{
+			Type:     models.DomainTeamCreated,
+			TeamName: team.TeamName,
+			Details:  map[string]any{"members": len(team.Members)},
+		})
 		if err != nil {
 			return err
 		}
-	}
 
-	return tx.Commit(ctx)
+		return nil
+	})
+}
+
+// upsertMember создает или обновляет участника команды. Нулевой review_weight,
+// пустые manager_id и email и nil tags не меняют текущие значения.
+func upsertMember(ctx context.Context, tx pgx.Tx, teamName string, m models.TeamMember) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO users(user_id, username, team_name, is_active, review_weight, manager_id, email)
+		VALUES($1, $2, $3, $4, GREATEST($5, 1), NULLIF($6, ''), NULLIF($7, ''))
+		ON CONFLICT(user_id) DO UPDATE
+		SET username=$2, team_name=$3, is_active=$4, deleted_at=NULL,
+			review_weight = CASE WHEN $5 > 0 THEN $5 ELSE users.review_weight END,
+			manager_id = COALESCE(NULLIF($6, ''), users.manager_id),
+			email = COALESCE(NULLIF($7, ''), users.email)`,
+		m.UserID, m.Username, teamName, m.IsActive, m.ReviewWeight, m.ManagerID, m.Email)
+	if err != nil || m.Tags == nil {
+		return err
+	}
+	return replaceUserTags(ctx, tx, m.UserID, m.Tags)
 }
 
 func (r *Repository) GetTeam(ctx context.Context, name string) (*models.Team, error) {
-	exists, err := r.TeamExists(ctx, name)
+	var team *models.Team
+	err := r.read(ctx, "GetTeam", func(q querier) error {
+		var err error
+		team, err = getTeam(ctx, q, name)
+		return err
+	})
+	return team, err
+}
+
+func getTeam(ctx context.Context, q querier, name string) (*models.Team, error) {
+	var exists bool
+	err := q.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM teams WHERE team_name=$1)", name).Scan(&exists)
 	if err != nil {
 		return nil, err
 	}
@@ -63,8 +172,10 @@ func (r *Repository) GetTeam(ctx context.Context, name string) (*models.Team, er
 		return nil, ErrNotFound
 	}
 
-	rows, err := r.db.Query(ctx,
-		"SELECT user_id, username, is_active FROM users WHERE team_name=$1 ORDER BY user_id",
+	rows, err := q.Query(ctx,
+		`SELECT user_id, username, is_active, review_weight, COALESCE(manager_id, ''), COALESCE(email, ''),
+			`+userTags+`
+		FROM users WHERE team_name=$1 AND deleted_at IS NULL ORDER BY user_id`,
 		name)
 	if err != nil {
 		return nil, err
@@ -74,7 +185,7 @@ func (r *Repository) GetTeam(ctx context.Context, name string) (*models.Team, er
 	members := []models.TeamMember{}
 	for rows.Next() {
 		var m models.TeamMember
-		if err := rows.Scan(&m.UserID, &m.Username, &m.IsActive); err != nil {
+		if err := rows.Scan(&m.UserID, &m.Username, &m.IsActive, &m.ReviewWeight, &m.ManagerID, &m.Email, &m.Tags); err != nil {
 			return nil, err
 		}
 		members = append(members, m)
@@ -85,30 +196,108 @@ func (r *Repository) GetTeam(ctx context.Context, name string) (*models.Team, er
 
 func (r *Repository) GetUser(ctx context.Context, uid string) (*models.User, error) {
 	var u models.User
-	err := r.db.QueryRow(ctx,
-		"SELECT user_id, username, team_name, is_active FROM users WHERE user_id=$1",
-		uid).Scan(&u.UserID, &u.Username, &u.TeamName, &u.IsActive)
+	err := r.conn(ctx).QueryRow(ctx,
+		`SELECT user_id, username, team_name, is_active, review_weight, COALESCE(manager_id, ''),
+			COALESCE(email, ''), `+userTags+`
+		FROM users WHERE user_id=$1 AND deleted_at IS NULL`,
+		uid).Scan(&u.UserID, &u.Username, &u.TeamName, &u.IsActive, &u.ReviewWeight, &u.ManagerID, &u.Email, &u.Tags)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrNotFound
 	}
 	return &u, err
 }
 
-func (r *Repository) UpdateUserActiveStatus(ctx context.Context, uid string, active bool) error {
-	tag, err := r.db.Exec(ctx, "UPDATE users SET is_active=$1 WHERE user_id=$2", active, uid)
-	if err != nil {
-		return err
-	}
-	if tag.RowsAffected() == 0 {
-		return ErrNotFound
+// GetUserTeamName возвращает команду пользователя, в том числе мягко
+// удаленного: его PR остаются под политикой команды.
+func (r *Repository) GetUserTeamName(ctx context.Context, uid string) (string, error) {
+	var team string
+	err := r.conn(ctx).QueryRow(ctx, "SELECT team_name FROM users WHERE user_id=$1", uid).Scan(&team)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrNotFound
 	}
-	return nil
+	return team, err
 }
 
-func (r *Repository) GetActiveTeamMembers(ctx context.Context, teamName string, excludeIDs []string) ([]string, error) {
-	rows, err := r.db.Query(ctx,
-		"SELECT user_id FROM users WHERE team_name=$1 AND is_active=true ORDER BY user_id",
-		teamName)
+// GetUserTeamNames возвращает команды тех из uids, кто заведен, в том числе
+// мягко удаленных; неизвестные пользователи в ответ не попадают.
+func (r *Repository) GetUserTeamNames(ctx context.Context, uids []string) (map[string]string, error) {
+	teams := map[string]string{}
+	err := r.read(ctx, "GetUserTeamNames", func(q querier) error {
+		rows, err := q.Query(ctx, "SELECT user_id, team_name FROM users WHERE user_id = ANY($1)", uids)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var uid, team string
+			if err := rows.Scan(&uid, &team); err != nil {
+				return err
+			}
+			teams[uid] = team
+		}
+		return rows.Err()
+	})
+	return teams, err
+}
+
+func (r *Repository) UpdateUserActiveStatus(ctx context.Context, uid string, active bool) error {
+	return r.WithTx(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, "UPDATE users SET is_active=$1 WHERE user_id=$2 AND deleted_at IS NULL", active, uid)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrNotFound
+		}
+		return r.invalidateCaches(ctx, tx)
+	})
+}
+
+func (r *Repository) GetActiveTeamMembers(
+	ctx context.Context,
+	teamName string,
+	excludeIDs []string,
+) ([]models.Candidate, error) {
+	return r.queryCandidates(ctx, sqlActiveTeamMembers, excludeIDs, teamName)
+}
+
+// GetFallbackCandidates возвращает активных пользователей всех команд, кроме excludeTeam.
+func (r *Repository) GetFallbackCandidates(
+	ctx context.Context,
+	excludeTeam string,
+	excludeIDs []string,
+) ([]models.Candidate, error) {
+	return r.queryCandidates(ctx, candidatesQuery("team_name <> $1"), excludeIDs, excludeTeam)
+}
+
+// openReviewLoad — нагрузка users.user_id: открытые PR, где он ревьювер, PR с
+// приоритетом high считаются за models.HighPriorityLoad.
+var openReviewLoad = `(SELECT COALESCE(SUM(CASE WHEN p.priority = 'high' THEN ` +
+	strconv.Itoa(models.HighPriorityLoad) + ` ELSE 1 END), 0)
+	FROM pr_reviewers pr
+	JOIN pull_requests p ON p.pull_request_id = pr.pull_request_id
+	WHERE pr.user_id = users.user_id AND p.status IN ` + openStatuses + `)`
+
+// candidatesQuery выбирает активных пользователей не в отпуске под условием cond
+// вместе с их текущей нагрузкой и временем последнего назначения.
+func candidatesQuery(cond string) string {
+	return `
+		SELECT user_id, team_name, review_weight, ` + userTags + `,
+			` + openReviewLoad + `,
+			(SELECT MAX(e.created_at) FROM assignment_events e WHERE e.new_user_id = users.user_id)
+		FROM users
+		WHERE ` + cond + ` AND is_active=true AND ` + notOnVacation + ` AND ` + notSnoozed + `
+		ORDER BY user_id`
+}
+
+// queryCandidates выполняет запрос candidatesQuery и отбрасывает excludeIDs.
+func (r *Repository) queryCandidates(
+	ctx context.Context,
+	query string,
+	excludeIDs []string,
+	args ...any,
+) ([]models.Candidate, error) {
+	rows, err := r.conn(ctx).Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -119,62 +308,115 @@ func (r *Repository) GetActiveTeamMembers(ctx context.Context, teamName string,
 		excludeMap[id] = true
 	}
 
-	result := []string{}
+	result := []models.Candidate{}
 	for rows.Next() {
-		var uid string
-		if err := rows.Scan(&uid); err != nil {
+		var c models.Candidate
+		var lastAssigned *time.Time
+		if err := rows.Scan(&c.UserID, &c.TeamName, &c.Weight, &c.Tags, &c.OpenReviews, &lastAssigned); err != nil {
 			return nil, err
 		}
-		if !excludeMap[uid] {
-			result = append(result, uid)
+		if lastAssigned != nil {
+			c.LastAssignedAt = *lastAssigned
+		}
+		if !excludeMap[c.UserID] {
+			result = append(result, c)
 		}
 	}
 
-	return result, nil
+	return result, rows.Err()
 }
 
 func (r *Repository) PRExists(ctx context.Context, prID string) (bool, error) {
 	var exists bool
-	err := r.db.QueryRow(ctx,
-		"SELECT EXISTS(SELECT 1 FROM pull_requests WHERE pull_request_id=$1)",
-		prID).Scan(&exists)
+	err := r.conn(ctx).QueryRow(ctx, sqlPRExists, prID).Scan(&exists)
 	return exists, err
 }
 
-func (r *Repository) CreatePR(ctx context.Context, pr models.PR) error {
-	tx, err := r.db.Begin(ctx)
-	if err != nil {
+// GetExistingPRs возвращает те из prIDs, что уже заведены, включая архивные,
+// по возрастанию id.
+func (r *Repository) GetExistingPRs(ctx context.Context, prIDs []string) ([]string, error) {
+	var ids []string
+	err := r.read(ctx, "GetExistingPRs", func(q querier) error {
+		rows, err := q.Query(ctx, `
+			SELECT pull_request_id FROM pull_requests WHERE pull_request_id = ANY($1)
+			UNION
+			SELECT pull_request_id FROM pull_requests_archive WHERE pull_request_id = ANY($1)
+			ORDER BY pull_request_id`,
+			prIDs)
+		if err != nil {
+			return err
+		}
+		ids, err = pgx.CollectRows(rows, pgx.RowTo[string])
 		return err
-	}
-	defer func() { _ = tx.Rollback(ctx) }()
+	})
+	return ids, err
+}
 
-	_, err = tx.Exec(ctx,
-		"INSERT INTO pull_requests(pull_request_id, pull_request_name, author_id, status) VALUES($1, $2, $3, 'OPEN')",
-		pr.ID, pr.Name, pr.AuthorID)
-	if err != nil {
-		return err
-	}
+func (r *Repository) CreatePR(ctx context.Context, pr models.PR) error {
+	return r.WithTx(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO pull_requests(pull_request_id, pull_request_name, author_id, status,
+				repository, changed_paths, required_tags, labels, priority, url, branch, description,
+				required_approvals, depends_on)
+			VALUES($1, $2, $3, 'OPEN', NULLIF($4, ''), COALESCE($5::text[], '{}'), COALESCE($6::text[], '{}'),
+				COALESCE($7::text[], '{}'), COALESCE(NULLIF($8, ''), 'normal'),
+				NULLIF($9, ''), NULLIF($10, ''), NULLIF($11, ''), $12, COALESCE($13::text[], '{}'))`,
+			pr.ID, pr.Name, pr.AuthorID, pr.Repository, pr.ChangedPaths, pr.RequiredTags, pr.Labels, pr.Priority,
+			pr.URL, pr.Branch, pr.Description, pr.RequiredApprovals, pr.DependsOn)
+		if err != nil {
+			return err
+		}
 
-	for _, reviewerID := range pr.AssignedReviewers {
-		_, err = tx.Exec(ctx,
-			"INSERT INTO pr_reviewers(pull_request_id, user_id) VALUES($1, $2)",
-			pr.ID, reviewerID)
+		reviewers, err := lockActiveUsers(ctx, tx, pr.AssignedReviewers)
 		if err != nil {
 			return err
 		}
-	}
 
-	return tx.Commit(ctx)
+		if err := insertReviewers(ctx, tx, pr.ID, reviewers); err != nil {
+			return err
+		}
+
+		err = insertOutbox(ctx, tx, models.DomainEvent{
+			Type:    models.DomainPRCreated,
+			PRID:    pr.ID,
+			UserID:  pr.AuthorID,
+			Details: map[string]any{"reviewers": reviewers},
+		})
+		if err != nil {
+			return err
+		}
+		if len(reviewers) > 0 {
+			err = insertOutbox(ctx, tx, models.DomainEvent{
+				Type:       models.DomainReviewerAssigned,
+				PRID:       pr.ID,
+				Recipients: reviewers,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 }
 
 func (r *Repository) GetPR(ctx context.Context, prID string) (*models.PR, error) {
+	var pr *models.PR
+	err := r.read(ctx, "GetPR", func(q querier) error {
+		var err error
+		pr, err = getPR(ctx, q, prID)
+		return err
+	})
+	return pr, err
+}
+
+func getPR(ctx context.Context, q querier, prID string) (*models.PR, error) {
 	var pr models.PR
-	var createdAt, mergedAt *time.Time
+	var createdAt, mergedAt, closedAt *time.Time
 
-	err := r.db.QueryRow(ctx, `
-		SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at 
-		FROM pull_requests WHERE pull_request_id=$1`,
-		prID).Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.Status, &createdAt, &mergedAt)
+	err := q.QueryRow(ctx, sqlGetPR, prID).Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.Status, &createdAt, &mergedAt, &closedAt, &pr.Version,
+		&pr.Repository, &pr.ChangedPaths, &pr.RequiredTags, &pr.Labels, &pr.Priority,
+		&pr.URL, &pr.Branch, &pr.Description, &pr.RequiredApprovals, &pr.DependsOn)
 
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ErrNotFound
@@ -183,18 +425,9 @@ func (r *Repository) GetPR(ctx context.Context, prID string) (*models.PR, error)
 		return nil, err
 	}
 
-	if createdAt != nil {
-		s := createdAt.Format(time.RFC3339)
-		pr.CreatedAt = &s
-	}
-	if mergedAt != nil {
-		s := mergedAt.Format(time.RFC3339)
-		pr.MergedAt = &s
-	}
+	pr.CreatedAt, pr.MergedAt, pr.ClosedAt = formatTime(createdAt), formatTime(mergedAt), formatTime(closedAt)
 
-	rows, err := r.db.Query(ctx,
-		"SELECT user_id FROM pr_reviewers WHERE pull_request_id=$1 ORDER BY user_id",
-		prID)
+	rows, err := q.Query(ctx, sqlPRReviewers, prID)
 	if err != nil {
 		return nil, err
 	}
@@ -209,96 +442,237 @@ func (r *Repository) GetPR(ctx context.Context, prID string) (*models.PR, error)
 		pr.AssignedReviewers = append(pr.AssignedReviewers, uid)
 	}
 
+	pr.ApprovedBy, err = getApprovals(ctx, q, prID)
+	if err != nil {
+		return nil, err
+	}
+
 	return &pr, nil
 }
 
-func (r *Repository) MergePR(ctx context.Context, prID string) error {
-	tag, err := r.db.Exec(ctx,
-		"UPDATE pull_requests SET status='MERGED', merged_at=NOW() WHERE pull_request_id=$1 AND status='OPEN'",
-		prID)
-	if err != nil {
-		return err
-	}
+func (r *Repository) MergePR(ctx context.Context, prID string, expectedVersion *int) error {
+	return r.WithTx(ctx, func(tx pgx.Tx) error {
+		var authorID string
+		err := tx.QueryRow(ctx, `
+			UPDATE pull_requests SET status='MERGED', merged_at=NOW(), version=version+1
+			WHERE pull_request_id=$1 AND status IN `+openStatuses+` AND ($2::int IS NULL OR version=$2)
+			RETURNING author_id`,
+			prID, expectedVersion).Scan(&authorID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			exists, _ := r.PRExists(ctx, prID)
+			if !exists {
+				return ErrNotFound
+			}
+			if expectedVersion != nil {
+				return ErrVersionConflict
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
 
-	if tag.RowsAffected() == 0 {
-		exists, _ := r.PRExists(ctx, prID)
-		if !exists {
-			return ErrNotFound
+		err = insertOutbox(ctx, tx, models.DomainEvent{
+			Type:       models.DomainPRMerged,
+			PRID:       prID,
+			UserID:     authorID,
+			Recipients: []string{authorID},
+		})
+		if err != nil {
+			return err
 		}
-	}
 
-	return nil
+		return nil
+	})
 }
 
-func (r *Repository) ReplaceReviewer(ctx context.Context, prID, oldReviewerID, newReviewerID string) error {
-	tx, err := r.db.Begin(ctx)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = tx.Rollback(ctx) }()
+// ReplaceReviewer блокирует строку PR (SELECT ... FOR UPDATE), передает plan текущее
+// состояние PR и в той же транзакции применяет возвращенную замену. Параллельные
+// изменения PR ждут окончания транзакции; ошибка plan откатывает ее без изменений.
+// Контекст plan несет транзакцию: чтения репозитория через него идут в ней и не
+// занимают второе соединение пула.
+func (r *Repository) ReplaceReviewer(
+	ctx context.Context,
+	prID string,
+	plan func(ctx context.Context, pr *models.PR) (models.ReviewerChange, error),
+) error {
+	return r.WithTx(ctx, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx,
+			"SELECT 1 FROM pull_requests WHERE pull_request_id=$1 FOR UPDATE",
+			prID).Scan(new(int))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
 
-	_, err = tx.Exec(ctx,
-		"DELETE FROM pr_reviewers WHERE pull_request_id=$1 AND user_id=$2",
-		prID, oldReviewerID)
-	if err != nil {
-		return err
-	}
+		pr, err := getPR(ctx, tx, prID)
+		if err != nil {
+			return err
+		}
+		change, err := plan(withTxQuerier(ctx, tx), pr)
+		if err != nil {
+			return err
+		}
+		change.PRID = prID
+
+		if err := bumpPRVersion(ctx, tx, prID, nil); err != nil {
+			return err
+		}
+
+		if change.EventType == models.EventDeclined || change.EventType == models.EventUnassigned {
+			// Снятое назначение сохраняется для SLA; отказ — реакция ревьювера.
+			declined := change.EventType == models.EventDeclined
+			reason := "unassigned"
+			if declined {
+				reason = "declined"
+			}
+			_, err = tx.Exec(ctx, `
+				INSERT INTO pr_reviewers_archive(pull_request_id, user_id, reason, assigned_at, first_action_at)
+				SELECT pull_request_id, user_id, $3, assigned_at,
+					CASE WHEN $4 THEN COALESCE(first_action_at, NOW()) ELSE first_action_at END
+				FROM pr_reviewers WHERE pull_request_id=$1 AND user_id=$2
+				ON CONFLICT (pull_request_id, user_id) DO UPDATE
+				SET reason=EXCLUDED.reason, archived_at=NOW(),
+					assigned_at=EXCLUDED.assigned_at, first_action_at=EXCLUDED.first_action_at`,
+				change.PRID, change.OldReviewerID, reason, declined)
+			if err != nil {
+				return err
+			}
+		}
 
-	if newReviewerID != "" {
 		_, err = tx.Exec(ctx,
-			"INSERT INTO pr_reviewers(pull_request_id, user_id) VALUES($1, $2)",
-			prID, newReviewerID)
+			"DELETE FROM pr_reviewers WHERE pull_request_id=$1 AND user_id=$2",
+			change.PRID, change.OldReviewerID)
 		if err != nil {
 			return err
 		}
-	}
 
-	return tx.Commit(ctx)
+		if change.NewReviewerID != "" {
+			_, err = tx.Exec(ctx,
+				"INSERT INTO pr_reviewers(pull_request_id, user_id) VALUES($1, $2)",
+				change.PRID, change.NewReviewerID)
+			if err != nil {
+				return mapReviewerError(err)
+			}
+		}
+
+		err = insertAssignmentEvent(ctx, tx, models.AssignmentEvent{
+			PRID:      change.PRID,
+			EventType: change.EventType,
+			UserID:    change.OldReviewerID,
+			NewUserID: change.NewReviewerID,
+			Reason:    change.Reason,
+		})
+		if err != nil {
+			return err
+		}
+
+		if change.NewReviewerID != "" {
+			err = insertOutbox(ctx, tx, models.DomainEvent{
+				Type:       models.DomainReviewerAssigned,
+				PRID:       change.PRID,
+				UserID:     change.NewReviewerID,
+				Recipients: []string{change.NewReviewerID},
+				Details: map[string]any{
+					"old_user_id": change.OldReviewerID,
+					"event_type":  change.EventType,
+					"reason":      change.Reason,
+				},
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *Repository) GetUserReviews(
+	ctx context.Context,
+	uid string,
+	filter models.ReviewFilter,
+	page models.Page,
+) ([]models.PRShort, int, error) {
+	var prs []models.PRShort
+	var total int
+	err := r.read(ctx, "GetUserReviews", func(q querier) error {
+		var err error
+		prs, total, err = getUserReviews(ctx, q, uid, filter, page)
+		return err
+	})
+	return prs, total, err
 }
 
-func (r *Repository) GetUserReviews(ctx context.Context, uid string) ([]models.PRShort, error) {
-	rows, err := r.db.Query(ctx, `
-		SELECT p.pull_request_id, p.pull_request_name, p.author_id, p.status 
+// reviewFilterCond применяет models.ReviewFilter из $2..$5; пустое значение — без фильтра.
+const reviewFilterCond = `($2 = '' OR p.status = $2)
+			AND ($3 = '' OR a.team_name = $3)
+			AND ($4 = '' OR $4 = ANY(p.labels))
+			AND ($5 = '' OR p.priority = $5)`
+
+func getUserReviews(
+	ctx context.Context,
+	q querier,
+	uid string,
+	filter models.ReviewFilter,
+	page models.Page,
+) ([]models.PRShort, int, error) {
+	var total int
+	err := q.QueryRow(ctx, `
+		SELECT COUNT(*)
+		FROM pull_requests p
+		JOIN pr_reviewers r ON p.pull_request_id = r.pull_request_id
+		JOIN users a ON a.user_id = p.author_id
+		WHERE r.user_id = $1
+			AND `+reviewFilterCond,
+		uid, filter.Status, filter.TeamName, filter.Label, filter.Priority).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := q.Query(ctx, `
+		SELECT p.pull_request_id, p.pull_request_name, p.author_id, p.status, p.priority, p.labels,
+			COALESCE(p.url, '')
 		FROM pull_requests p 
 		JOIN pr_reviewers r ON p.pull_request_id = r.pull_request_id 
+		JOIN users a ON a.user_id = p.author_id
 		WHERE r.user_id = $1
-		ORDER BY p.created_at DESC`,
-		uid)
+			AND `+reviewFilterCond+`
+		ORDER BY p.created_at DESC, p.pull_request_id
+		LIMIT $6 OFFSET $7`,
+		uid, filter.Status, filter.TeamName, filter.Label, filter.Priority, page.Limit, page.Offset)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
 	prs := []models.PRShort{}
 	for rows.Next() {
 		var pr models.PRShort
-		if err := rows.Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.Status); err != nil {
-			return nil, err
+		if err := rows.Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.Status, &pr.Priority, &pr.Labels, &pr.URL); err != nil {
+			return nil, 0, err
 		}
 		prs = append(prs, pr)
 	}
 
-	return prs, nil
+	return prs, total, nil
 }
 
 func (r *Repository) DeactivateTeamMembers(ctx context.Context, teamName string) ([]string, error) {
-	rows, err := r.db.Query(ctx,
-		"UPDATE users SET is_active=false WHERE team_name=$1 AND is_active=true RETURNING user_id",
-		teamName)
+	var deactivated []string
+	err := r.WithTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		deactivated, err = r.deactivateTeamUsers(ctx, tx, teamName)
+		if err != nil {
+			return err
+		}
+		return r.invalidateCaches(ctx, tx)
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	deactivated := []string{}
-	for rows.Next() {
-		var uid string
-		if err := rows.Scan(&uid); err != nil {
-			return nil, err
-		}
-		deactivated = append(deactivated, uid)
-	}
-
 	return deactivated, nil
 }
 
@@ -307,11 +681,11 @@ func (r *Repository) GetOpenPRsByReviewers(ctx context.Context, reviewerIDs []st
 		return []string{}, nil
 	}
 
-	rows, err := r.db.Query(ctx, `
+	rows, err := r.conn(ctx).Query(ctx, `
 		SELECT DISTINCT r.pull_request_id 
 		FROM pr_reviewers r
 		JOIN pull_requests p ON r.pull_request_id = p.pull_request_id
-		WHERE p.status = 'OPEN' AND r.user_id = ANY($1)`,
+		WHERE p.status IN `+openStatuses+` AND r.user_id = ANY($1)`,
 		reviewerIDs)
 	if err != nil {
 		return nil, err
@@ -340,112 +714,217 @@ func (r *Repository) DeactivateTeamAndReassignPRs(
 	teamName string,
 	rng interface{ Intn(int) int },
 ) (*DeactivationResult, error) {
-	tx, err := r.db.Begin(ctx)
-	if err != nil {
-		return nil, err
-	}
-	defer func() { _ = tx.Rollback(ctx) }()
-
-	deactivated, err := r.deactivateTeamUsers(ctx, tx, teamName)
-	if err != nil {
-		return nil, err
-	}
-
-	if len(deactivated) == 0 {
-		_ = tx.Commit(ctx)
-		return &DeactivationResult{DeactivatedUsers: []string{}, Reassignments: []map[string]string{}}, nil
-	}
+	var result *DeactivationResult
+	err := r.retryDeactivation(ctx, func() error {
+		var err error
+		result, err = r.deactivateTeamAndReassignPRs(ctx, teamName, rng, true)
+		return err
+	})
+	return result, err
+}
 
-	affectedPRs, err := r.getAffectedPRs(ctx, tx, deactivated)
-	if err != nil {
-		return nil, err
-	}
+// PreviewTeamDeactivation выполняет ту же транзакцию, что и
+// DeactivateTeamAndReassignPRs, но откатывает ее вместо фиксации.
+func (r *Repository) PreviewTeamDeactivation(
+	ctx context.Context,
+	teamName string,
+	rng interface{ Intn(int) int },
+) (*DeactivationResult, error) {
+	var result *DeactivationResult
+	err := r.retryDeactivation(ctx, func() error {
+		var err error
+		result, err = r.deactivateTeamAndReassignPRs(ctx, teamName, rng, false)
+		return err
+	})
+	return result, err
+}
 
-	activeCandidates, err := r.getActiveUsersByTeam(ctx, tx)
-	if err != nil {
-		return nil, err
+// retryDeactivation повторяет транзакцию деактивации/удаления при serialization
+// failure и deadlock, не более deactivationRetries раз и пока не отменен ctx.
+func (r *Repository) retryDeactivation(ctx context.Context, fn func() error) error {
+	var lastErr error
+	for attempt := 0; attempt < r.deactivationRetries; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if !isRetryableTxError(err) || ctx.Err() != nil {
+			return err
+		}
+		lastErr = err
 	}
+	return fmt.Errorf("deactivation retries exhausted: %w", lastErr)
+}
 
-	userTeams, err := r.getUserTeams(ctx, tx, deactivated)
-	if err != nil {
-		return nil, err
-	}
+// deactivateTeamAndReassignPRs деактивирует команду и переназначает ревью в одной
+// транзакции; при commit=false транзакция откатывается и результат — только прогноз.
+func (r *Repository) deactivateTeamAndReassignPRs(
+	ctx context.Context,
+	teamName string,
+	rng interface{ Intn(int) int },
+	commit bool,
+) (*DeactivationResult, error) {
+	var result *DeactivationResult
+	err := r.withTxOptions(ctx, pgx.TxOptions{IsoLevel: r.deactivationIsoLevel}, func(tx pgx.Tx) error {
+		deactivated, err := r.deactivateTeamUsers(ctx, tx, teamName)
+		if err != nil {
+			return err
+		}
+		if err := r.invalidateCaches(ctx, tx); err != nil {
+			return err
+		}
 
-	reassignments, err := r.reassignReviewers(ctx, tx, affectedPRs, userTeams, activeCandidates, rng)
-	if err != nil {
-		return nil, err
-	}
+		if len(deactivated) == 0 {
+			result = &DeactivationResult{DeactivatedUsers: []string{}, Reassignments: []map[string]string{}}
+			return nil
+		}
 
-	if err := tx.Commit(ctx); err != nil {
-		return nil, err
-	}
+		affectedPRs, err := r.getAffectedPRs(ctx, tx, deactivated)
+		if err != nil {
+			return err
+		}
 
-	return &DeactivationResult{
-		DeactivatedUsers: deactivated,
-		Reassignments:    reassignments,
-	}, nil
-}
+		activeCandidates, err := r.getActiveUsersByTeam(ctx, tx)
+		if err != nil {
+			return err
+		}
 
-func (r *Repository) GetStats(ctx context.Context) (*models.Stats, error) {
-	stats := &models.Stats{}
+		userTeams, err := r.getUserTeams(ctx, tx, deactivated)
+		if err != nil {
+			return err
+		}
 
-	queries := []struct {
-		sql    string
-		target *int
-	}{
-		{"SELECT COUNT(*) FROM teams", &stats.TotalTeams},
-		{"SELECT COUNT(*) FROM users", &stats.TotalUsers},
-		{"SELECT COUNT(*) FROM pull_requests", &stats.TotalPRs},
-		{"SELECT COUNT(*) FROM pull_requests WHERE status='OPEN'", &stats.OpenPRs},
-		{"SELECT COUNT(*) FROM pull_requests WHERE status='MERGED'", &stats.MergedPRs},
-	}
+		reassignments, err := r.reassignReviewers(
+			ctx, tx, affectedPRs, userTeams, activeCandidates, rng, "team deactivated",
+		)
+		if err != nil {
+			return err
+		}
 
-	for _, q := range queries {
-		if err := r.db.QueryRow(ctx, q.sql).Scan(q.target); err != nil {
-			return nil, err
+		err = insertOutbox(ctx, tx, models.DomainEvent{
+			Type:     models.DomainTeamDeactivated,
+			TeamName: teamName,
+			Details:  map[string]any{"deactivated_users": deactivated, "reassignments": len(reassignments)},
+		})
+		if err != nil {
+			return err
 		}
-	}
 
-	rows, err := r.db.Query(ctx, `
-		SELECT u.user_id, u.username, COUNT(r.pull_request_id) 
-		FROM users u 
-		LEFT JOIN pr_reviewers r ON u.user_id = r.user_id
-		GROUP BY u.user_id 
-		ORDER BY COUNT(r.pull_request_id) DESC, u.user_id`)
+		result = &DeactivationResult{
+			DeactivatedUsers: deactivated,
+			Reassignments:    reassignments,
+		}
+		if !commit {
+			return errRollback
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return result, nil
+}
 
-	stats.AssignmentsByUser = []models.UserAssignments{}
-	for rows.Next() {
-		var ua models.UserAssignments
-		if err := rows.Scan(&ua.UserID, &ua.Username, &ua.Assignments); err != nil {
-			return nil, err
-		}
-		stats.AssignmentsByUser = append(stats.AssignmentsByUser, ua)
-	}
+func (r *Repository) GetStats(ctx context.Context, filter models.StatsFilter, page models.Page) (*models.Stats, error) {
+	var stats *models.Stats
+	err := r.read(ctx, "GetStats", func(q querier) error {
+		var err error
+		stats, err = getStats(ctx, q, filter, page)
+		return err
+	})
+	return stats, err
+}
 
-	rows2, err := r.db.Query(ctx, `
-		SELECT p.pull_request_id, p.pull_request_name, COUNT(r.user_id) 
-		FROM pull_requests p 
-		LEFT JOIN pr_reviewers r ON p.pull_request_id = r.pull_request_id
-		GROUP BY p.pull_request_id 
-		ORDER BY COUNT(r.user_id) DESC, p.pull_request_id`)
+// statsQuery собирает статистику одним запросом: счетчики, страницы обоих
+// рейтингов и распределения времени до merge считаются в CTE, рейтинги
+// возвращаются JSON-массивами. $3/$4 — необязательные границы [from, to):
+// PR отбираются по created_at, длительности до merge — по merged_at.
+const statsQuery = `
+	WITH range_prs AS (
+		SELECT pull_request_id, pull_request_name, status
+		FROM pull_requests
+		WHERE ($3::timestamptz IS NULL OR created_at >= $3)
+			AND ($4::timestamptz IS NULL OR created_at < $4)
+	), by_user AS (
+		SELECT u.user_id, u.username, COUNT(p.pull_request_id) AS n
+		FROM users u
+		LEFT JOIN pr_reviewers r ON r.user_id = u.user_id
+		LEFT JOIN range_prs p ON p.pull_request_id = r.pull_request_id
+		WHERE u.deleted_at IS NULL
+		GROUP BY u.user_id
+		ORDER BY n DESC, u.user_id
+		LIMIT $1 OFFSET $2
+	), reviewer_counts AS (
+		SELECT pull_request_id, COUNT(*) AS n FROM pr_reviewers GROUP BY pull_request_id
+	), by_pr AS (
+		SELECT p.pull_request_id, p.pull_request_name, COALESCE(c.n, 0) AS n
+		FROM range_prs p
+		LEFT JOIN reviewer_counts c ON c.pull_request_id = p.pull_request_id
+		ORDER BY n DESC, p.pull_request_id
+		LIMIT $1 OFFSET $2
+	), pr_counts AS (
+		SELECT COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE status IN ` + openStatuses + `) AS open,
+			COUNT(*) FILTER (WHERE status = 'MERGED') AS merged,
+			COUNT(*) FILTER (WHERE status = 'CLOSED') AS closed
+		FROM range_prs
+	), status_counts AS (
+		SELECT status, COUNT(*) AS n FROM range_prs GROUP BY status
+	), merged_prs AS (
+		SELECT pull_request_id, created_at, merged_at
+		FROM pull_requests
+		WHERE status = 'MERGED' AND merged_at IS NOT NULL
+			AND ($3::timestamptz IS NULL OR merged_at >= $3)
+			AND ($4::timestamptz IS NULL OR merged_at < $4)
+	), merge_times AS (
+		SELECT EXTRACT(EPOCH FROM merged_at - created_at)::float8 AS s FROM merged_prs
+	), review_times AS (
+		SELECT EXTRACT(EPOCH FROM p.merged_at - r.assigned_at)::float8 AS s
+		FROM pr_reviewers r
+		JOIN merged_prs p ON p.pull_request_id = r.pull_request_id
+	), durations AS (
+		SELECT
+			(SELECT AVG(s) FROM merge_times) AS merge_avg,
+			(SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY s) FROM merge_times) AS merge_median,
+			(SELECT percentile_cont(0.9) WITHIN GROUP (ORDER BY s) FROM merge_times) AS merge_p90,
+			(SELECT AVG(s) FROM review_times) AS review_avg,
+			(SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY s) FROM review_times) AS review_median,
+			(SELECT percentile_cont(0.9) WITHIN GROUP (ORDER BY s) FROM review_times) AS review_p90
+	)
+	SELECT
+		(SELECT COUNT(*) FROM teams),
+		(SELECT COUNT(*) FROM users WHERE deleted_at IS NULL),
+		pc.total, pc.open, pc.merged, pc.closed,
+		COALESCE((SELECT json_object_agg(status, n) FROM status_counts), '{}'),
+		d.merge_avg, d.merge_median, d.merge_p90, d.review_avg, d.review_median, d.review_p90,
+		COALESCE((
+			SELECT json_agg(json_build_object(
+				'user_id', user_id, 'username', username, 'total_assignments', n) ORDER BY n DESC, user_id)
+			FROM by_user), '[]'),
+		COALESCE((
+			SELECT json_agg(json_build_object(
+				'pull_request_id', pull_request_id, 'pull_request_name', pull_request_name,
+				'reviewer_count', n) ORDER BY n DESC, pull_request_id)
+			FROM by_pr), '[]')
+	FROM pr_counts pc, durations d`
+
+func getStats(ctx context.Context, q querier, filter models.StatsFilter, page models.Page) (*models.Stats, error) {
+	stats := &models.Stats{}
+	var byStatus map[models.PRStatus]int
+	err := q.QueryRow(ctx, statsQuery, page.Limit, page.Offset, filter.From, filter.To).Scan(
+		&stats.TotalTeams, &stats.TotalUsers,
+		&stats.TotalPRs, &stats.OpenPRs, &stats.MergedPRs, &stats.ClosedPRs, &byStatus,
+		&stats.TimeToMerge.AvgSeconds, &stats.TimeToMerge.MedianSeconds, &stats.TimeToMerge.P90Seconds,
+		&stats.AssignmentToMerge.AvgSeconds, &stats.AssignmentToMerge.MedianSeconds, &stats.AssignmentToMerge.P90Seconds,
+		&stats.AssignmentsByUser, &stats.ReviewersByPR)
 	if err != nil {
 		return nil, err
 	}
-	defer rows2.Close()
-
-	stats.ReviewersByPR = []models.PRReviewerCount{}
-	for rows2.Next() {
-		var prc models.PRReviewerCount
-		if err := rows2.Scan(&prc.PRID, &prc.PRName, &prc.ReviewerCount); err != nil {
-			return nil, err
-		}
-		stats.ReviewersByPR = append(stats.ReviewersByPR, prc)
-	}
+	stats.PRsByStatus = models.StatusCounts(byStatus)
 
+	// Списки содержат по строке на каждого пользователя и PR.
+	stats.AssignmentsByUserTotal = stats.TotalUsers
+	stats.ReviewersByPRTotal = stats.TotalPRs
 	return stats, nil
 }
 
@@ -470,37 +949,40 @@ func (r *Repository) deactivateTeamUsers(ctx context.Context, tx pgx.Tx, teamNam
 	return deactivated, nil
 }
 
-func (r *Repository) getAffectedPRs(ctx context.Context, tx pgx.Tx, deactivated []string) (map[string]*prData, error) {
+// getAffectedPRs возвращает открытые PR со снятыми ревьюверами по возрастанию ID.
+func (r *Repository) getAffectedPRs(ctx context.Context, tx pgx.Tx, deactivated []string) ([]*prData, error) {
 	rows, err := tx.Query(ctx, `
 		SELECT DISTINCT p.pull_request_id, p.author_id, r.user_id as reviewer
 		FROM pull_requests p
 		JOIN pr_reviewers r ON p.pull_request_id = r.pull_request_id
-		WHERE p.status = 'OPEN' AND r.user_id = ANY($1)
-		ORDER BY p.pull_request_id`,
+		WHERE p.status IN `+openStatuses+` AND r.user_id = ANY($1)
+		ORDER BY p.pull_request_id, r.user_id`,
 		deactivated)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	affectedPRs := make(map[string]*prData)
+	affectedPRs := []*prData{}
 	for rows.Next() {
 		var prID, authorID, reviewer string
 		if err := rows.Scan(&prID, &authorID, &reviewer); err != nil {
 			return nil, err
 		}
 
-		if affectedPRs[prID] == nil {
-			affectedPRs[prID] = &prData{prID: prID, authorID: authorID}
+		if n := len(affectedPRs); n == 0 || affectedPRs[n-1].prID != prID {
+			affectedPRs = append(affectedPRs, &prData{prID: prID, authorID: authorID})
 		}
-		affectedPRs[prID].reviewers = append(affectedPRs[prID].reviewers, reviewer)
+		pr := affectedPRs[len(affectedPRs)-1]
+		pr.reviewers = append(pr.reviewers, reviewer)
 	}
-	return affectedPRs, nil
+	return affectedPRs, rows.Err()
 }
 
 func (r *Repository) getActiveUsersByTeam(ctx context.Context, tx pgx.Tx) (map[string][]string, error) {
 	rows, err := tx.Query(ctx,
-		"SELECT user_id, team_name FROM users WHERE is_active=true ORDER BY user_id")
+		"SELECT user_id, team_name FROM users WHERE is_active=true AND "+notOnVacation+" AND "+notSnoozed+
+			" ORDER BY user_id")
 	if err != nil {
 		return nil, err
 	}
@@ -518,41 +1000,58 @@ func (r *Repository) getActiveUsersByTeam(ctx context.Context, tx pgx.Tx) (map[s
 }
 
 func (r *Repository) getUserTeams(ctx context.Context, tx pgx.Tx, deactivated []string) (map[string]string, error) {
-	userTeams := make(map[string]string)
-	for _, uid := range deactivated {
-		var team string
-		err := tx.QueryRow(ctx, "SELECT team_name FROM users WHERE user_id=$1", uid).Scan(&team)
-		if err != nil {
+	rows, err := tx.Query(ctx, "SELECT user_id, team_name FROM users WHERE user_id = ANY($1)", deactivated)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	userTeams := make(map[string]string, len(deactivated))
+	for rows.Next() {
+		var uid, team string
+		if err := rows.Scan(&uid, &team); err != nil {
 			return nil, err
 		}
 		userTeams[uid] = team
 	}
-	return userTeams, nil
+	return userTeams, rows.Err()
 }
 
+// reassignReviewers заменяет снятых ревьюверов открытых PR случайными активными
+// коллегами по команде. Замены выбираются в памяти, а в базу пишутся пачкой:
+// одно обновление версий, одно удаление и CopyFrom для ревьюверов, событий и outbox.
 func (r *Repository) reassignReviewers(
 	ctx context.Context,
 	tx pgx.Tx,
-	affectedPRs map[string]*prData,
+	affectedPRs []*prData,
 	userTeams map[string]string,
 	activeCandidates map[string][]string,
 	rng interface{ Intn(int) int },
+	reason string,
 ) ([]map[string]string, error) {
 	reassignments := []map[string]string{}
+	if len(affectedPRs) == 0 {
+		return reassignments, nil
+	}
 
+	var (
+		prIDs                  []string
+		removedPRs, removedIDs []string
+		added                  [][]any
+		events                 []models.AssignmentEvent
+		outbox                 []models.DomainEvent
+	)
 	for _, pr := range affectedPRs {
-		for _, oldReviewer := range pr.reviewers {
-			team := userTeams[oldReviewer]
-			candidates := activeCandidates[team]
+		prIDs = append(prIDs, pr.prID)
 
-			exclude := make(map[string]bool)
-			exclude[pr.authorID] = true
-			for _, rev := range pr.reviewers {
-				exclude[rev] = true
-			}
+		exclude := map[string]bool{pr.authorID: true}
+		for _, rev := range pr.reviewers {
+			exclude[rev] = true
+		}
 
+		for _, oldReviewer := range pr.reviewers {
 			filtered := []string{}
-			for _, c := range candidates {
+			for _, c := range activeCandidates[userTeams[oldReviewer]] {
 				if !exclude[c] {
 					filtered = append(filtered, c)
 				}
@@ -561,24 +1060,27 @@ func (r *Repository) reassignReviewers(
 			var newReviewer string
 			if len(filtered) > 0 {
 				newReviewer = filtered[rng.Intn(len(filtered))]
+				// Два снятых ревьювера одного PR не должны получить одну замену.
+				exclude[newReviewer] = true
+				added = append(added, []any{pr.prID, newReviewer})
+				outbox = append(outbox, models.DomainEvent{
+					Type:       models.DomainReviewerAssigned,
+					PRID:       pr.prID,
+					UserID:     newReviewer,
+					Recipients: []string{newReviewer},
+					Details:    map[string]any{"old_user_id": oldReviewer, "reason": reason},
+				})
 			}
 
-			_, err := tx.Exec(ctx,
-				"DELETE FROM pr_reviewers WHERE pull_request_id=$1 AND user_id=$2",
-				pr.prID, oldReviewer)
-			if err != nil {
-				return nil, err
-			}
-
-			if newReviewer != "" {
-				_, err = tx.Exec(ctx,
-					"INSERT INTO pr_reviewers(pull_request_id, user_id) VALUES($1, $2)",
-					pr.prID, newReviewer)
-				if err != nil {
-					return nil, err
-				}
-			}
-
+			removedPRs = append(removedPRs, pr.prID)
+			removedIDs = append(removedIDs, oldReviewer)
+			events = append(events, models.AssignmentEvent{
+				PRID:      pr.prID,
+				EventType: models.EventReassigned,
+				UserID:    oldReviewer,
+				NewUserID: newReviewer,
+				Reason:    reason,
+			})
 			reassignments = append(reassignments, map[string]string{
 				"pr_id": pr.prID,
 				"old":   oldReviewer,
@@ -586,9 +1088,198 @@ func (r *Repository) reassignReviewers(
 			})
 		}
 	}
+
+	_, err := tx.Exec(ctx,
+		"UPDATE pull_requests SET version=version+1 WHERE pull_request_id = ANY($1)",
+		prIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM pr_reviewers r
+		USING unnest($1::text[], $2::text[]) AS d(pull_request_id, user_id)
+		WHERE r.pull_request_id = d.pull_request_id AND r.user_id = d.user_id`,
+		removedPRs, removedIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(added) > 0 {
+		_, err = tx.CopyFrom(ctx,
+			pgx.Identifier{"pr_reviewers"},
+			[]string{"pull_request_id", "user_id"},
+			pgx.CopyFromRows(added))
+		if err != nil {
+			return nil, mapReviewerError(err)
+		}
+	}
+
+	if err := insertAssignmentEvents(ctx, tx, events); err != nil {
+		return nil, err
+	}
+	if err := insertOutboxBatch(ctx, tx, outbox); err != nil {
+		return nil, err
+	}
 	return reassignments, nil
 }
 
+// insertReviewers назначает ревьюверов PR одним INSERT и записывает события
+// назначения одной командой COPY вместо пары запросов на каждого ревьювера.
+func insertReviewers(ctx context.Context, tx pgx.Tx, prID string, reviewers []string) error {
+	if len(reviewers) == 0 {
+		return nil
+	}
+	_, err := tx.Exec(ctx,
+		"INSERT INTO pr_reviewers(pull_request_id, user_id) SELECT $1, unnest($2::text[])",
+		prID, reviewers)
+	if err != nil {
+		return mapReviewerError(err)
+	}
+
+	events := make([]models.AssignmentEvent, len(reviewers))
+	for i, reviewerID := range reviewers {
+		events[i] = models.AssignmentEvent{PRID: prID, EventType: models.EventAssigned, NewUserID: reviewerID}
+	}
+	return insertAssignmentEvents(ctx, tx, events)
+}
+
+// lockActiveUsers блокирует строки пользователей (FOR SHARE) и возвращает только
+// тех, кто все еще активен. Конкурентная деактивация команды держит блокировку
+// на этих строках, поэтому PR не получит только что деактивированного ревьювера.
+func lockActiveUsers(ctx context.Context, tx pgx.Tx, userIDs []string) ([]string, error) {
+	if len(userIDs) == 0 {
+		return []string{}, nil
+	}
+
+	rows, err := tx.Query(ctx,
+		"SELECT user_id FROM users WHERE user_id = ANY($1) AND is_active=true ORDER BY user_id FOR SHARE",
+		userIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	active := make(map[string]bool, len(userIDs))
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			return nil, err
+		}
+		active[uid] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0, len(userIDs))
+	for _, uid := range userIDs {
+		if active[uid] {
+			result = append(result, uid)
+		}
+	}
+	return result, nil
+}
+
+// bumpPRVersion увеличивает версию PR. Если expectedVersion задан и не совпадает
+// с текущей версией, возвращает ErrVersionConflict.
+func bumpPRVersion(ctx context.Context, tx pgx.Tx, prID string, expectedVersion *int) error {
+	tag, err := tx.Exec(ctx, `
+		UPDATE pull_requests SET version=version+1
+		WHERE pull_request_id=$1 AND ($2::int IS NULL OR version=$2)`,
+		prID, expectedVersion)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// mapReviewerError переводит нарушения ограничений pr_reviewers в ошибки репозитория.
+func mapReviewerError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch {
+	case pgErr.Code == pgUniqueViolation:
+		return fmt.Errorf("%w: %s", ErrDuplicateReviewer, pgErr.Detail)
+	case pgErr.Code == pgForeignKeyViolation:
+		return fmt.Errorf("%w: %s", ErrUnknownReference, pgErr.Detail)
+	default:
+		return mapPgError(err)
+	}
+}
+
+// mapPgError переводит ошибку Postgres в ErrDuplicate, ErrForeignKey, ErrConflict
+// или ErrAuthorIsReviewer. У конфликта транзакции исходная ошибка остается
+// в цепочке, чтобы ее видели isRetryableTxError и IsTransient. Уже переведенные
+// и прочие ошибки возвращаются как есть.
+func mapPgError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || isMapped(err) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case pgUniqueViolation:
+		return fmt.Errorf("%w: %s", ErrDuplicate, pgErr.Detail)
+	case pgForeignKeyViolation:
+		return fmt.Errorf("%w: %s", ErrForeignKey, pgErr.Detail)
+	case pgCheckViolation:
+		if pgErr.ConstraintName == constraintReviewerNotAuthor {
+			return fmt.Errorf("%w: %s", ErrAuthorIsReviewer, pgErr.Message)
+		}
+		return err
+	case pgSerializationFail, pgDeadlockDetected, pgLockNotAvailable:
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	default:
+		return err
+	}
+}
+
+func isMapped(err error) bool {
+	return errors.Is(err, ErrDuplicate) || errors.Is(err, ErrForeignKey) ||
+		errors.Is(err, ErrConflict) || errors.Is(err, ErrAuthorIsReviewer)
+}
+
+// isRetryableTxError сообщает, что транзакцию можно безопасно повторить:
+// serialization_failure (40001) или deadlock_detected (40P01).
+func isRetryableTxError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == pgSerializationFail || pgErr.Code == pgDeadlockDetected
+}
+
+// IsTransient сообщает, что операцию можно повторить целиком: транзакция откатилась
+// из-за serialization failure или deadlock, соединение с сервером оборвалось (класс 08),
+// сервер останавливается или еще не принимает соединения (57P01–57P03), либо запрос
+// не успел уйти на сервер.
+func IsTransient(err error) bool {
+	if isRetryableTxError(err) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "57P01", "57P02", "57P03":
+			return true
+		}
+		return strings.HasPrefix(pgErr.Code, "08")
+	}
+	var safe interface{ SafeToRetry() bool }
+	if errors.As(err, &safe) && safe.SafeToRetry() {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
 type prData struct {
 	prID      string
 	authorID  string
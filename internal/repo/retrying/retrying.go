@@ -0,0 +1,561 @@
+// Package retrying оборачивает service.Repository повторами при временных сбоях БД:
+// serialization failure, deadlock, обрыв соединения и перезапуск сервера
+// (см. repo.IsTransient). Пауза между попытками растет экспоненциально и выбирается
+// случайно в пределах шага, чтобы экземпляры сервиса не повторяли запросы разом.
+package retrying
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+	"prreviewer/internal/service"
+)
+
+// Policy задает число попыток и границы паузы между ними.
+type Policy struct {
+	// Attempts — число попыток вместе с первой; 1 выключает повторы.
+	Attempts  int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// DefaultPolicy — три попытки с паузой до 50 мс, затем до 100 мс.
+var DefaultPolicy = Policy{Attempts: 3, BaseDelay: 50 * time.Millisecond, MaxDelay: time.Second}
+
+type Repository struct {
+	service.Repository
+
+	policy Policy
+}
+
+func New(r service.Repository, p Policy) *Repository {
+	return &Repository{Repository: r, policy: p}
+}
+
+// retry выполняет fn и повторяет ее, пока ошибка временная и попытки не исчерпаны.
+// Отмена контекста прерывает ожидание и возвращает последнюю ошибку; после
+// отмены операция не повторяется.
+func (r *Repository) retry(ctx context.Context, op string, fn func() error) error {
+	err := fn()
+	for attempt := 1; attempt < r.policy.Attempts && err != nil && ctx.Err() == nil && repo.IsTransient(err); attempt++ {
+		delay := r.delay(attempt)
+		logging.FromContext(ctx).Warn("transient database error, retrying",
+			"op", op, "attempt", attempt, "delay", delay, "error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		err = fn()
+	}
+	return err
+}
+
+// delay — случайная пауза перед повтором attempt: от нуля до BaseDelay·2^(attempt-1),
+// но не больше MaxDelay.
+func (r *Repository) delay(attempt int) time.Duration {
+	ceiling := r.policy.BaseDelay << (attempt - 1)
+	if ceiling <= 0 || ceiling > r.policy.MaxDelay {
+		ceiling = r.policy.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling)
+}
+
+func get[T any](ctx context.Context, r *Repository, op string, fn func() (T, error)) (T, error) {
+	var v T
+	err := r.retry(ctx, op, func() error {
+		var err error
+		v, err = fn()
+		return err
+	})
+	return v, err
+}
+
+func list[T any](ctx context.Context, r *Repository, op string, fn func() (T, int, error)) (T, int, error) {
+	var v T
+	var total int
+	err := r.retry(ctx, op, func() error {
+		var err error
+		v, total, err = fn()
+		return err
+	})
+	return v, total, err
+}
+
+func (r *Repository) AddVacation(ctx context.Context, v models.Vacation) (*models.Vacation, error) {
+	return get(ctx, r, "AddVacation", func() (*models.Vacation, error) {
+		return r.Repository.AddVacation(ctx, v)
+	})
+}
+
+func (r *Repository) ApprovePR(ctx context.Context, prID, userID string, version int) error {
+	return r.retry(ctx, "ApprovePR", func() error {
+		return r.Repository.ApprovePR(ctx, prID, userID, version)
+	})
+}
+
+func (r *Repository) ArchiveMergedPRs(ctx context.Context, mergedBefore time.Time, limit int) (int, error) {
+	return get(ctx, r, "ArchiveMergedPRs", func() (int, error) {
+		return r.Repository.ArchiveMergedPRs(ctx, mergedBefore, limit)
+	})
+}
+
+func (r *Repository) ClosePR(ctx context.Context, prID string, expectedVersion *int) error {
+	return r.retry(ctx, "ClosePR", func() error {
+		return r.Repository.ClosePR(ctx, prID, expectedVersion)
+	})
+}
+
+func (r *Repository) CreatePR(ctx context.Context, pr models.PR) error {
+	return r.retry(ctx, "CreatePR", func() error {
+		return r.Repository.CreatePR(ctx, pr)
+	})
+}
+
+func (r *Repository) CreateTeam(ctx context.Context, team models.Team) error {
+	return r.retry(ctx, "CreateTeam", func() error {
+		return r.Repository.CreateTeam(ctx, team)
+	})
+}
+
+// DeactivateTeamAndReassignPRs повторяется и при сбоях соединения; serialization
+// failure и deadlock транзакция деактивации уже повторяет сама (DEACTIVATION_RETRIES).
+func (r *Repository) DeactivateTeamAndReassignPRs(
+	ctx context.Context,
+	teamName string,
+	rng interface{ Intn(int) int },
+) (*repo.DeactivationResult, error) {
+	return get(ctx, r, "DeactivateTeamAndReassignPRs", func() (*repo.DeactivationResult, error) {
+		return r.Repository.DeactivateTeamAndReassignPRs(ctx, teamName, rng)
+	})
+}
+
+func (r *Repository) DeactivateTeamMembers(ctx context.Context, teamName string) ([]string, error) {
+	return get(ctx, r, "DeactivateTeamMembers", func() ([]string, error) {
+		return r.Repository.DeactivateTeamMembers(ctx, teamName)
+	})
+}
+
+func (r *Repository) DeleteUserAndReassignPRs(
+	ctx context.Context,
+	uid string,
+	rng interface{ Intn(int) int },
+) (*repo.UserDeletionResult, error) {
+	return get(ctx, r, "DeleteUserAndReassignPRs", func() (*repo.UserDeletionResult, error) {
+		return r.Repository.DeleteUserAndReassignPRs(ctx, uid, rng)
+	})
+}
+
+func (r *Repository) ExpireVacations(ctx context.Context) ([]string, error) {
+	return get(ctx, r, "ExpireVacations", func() ([]string, error) {
+		return r.Repository.ExpireVacations(ctx)
+	})
+}
+
+func (r *Repository) ExportAssignmentEvents(
+	ctx context.Context,
+	from, to *time.Time,
+	after *models.EventCursor,
+	limit int,
+) ([]models.AssignmentEvent, *models.EventCursor, error) {
+	var events []models.AssignmentEvent
+	var next *models.EventCursor
+	err := r.retry(ctx, "ExportAssignmentEvents", func() error {
+		var err error
+		events, next, err = r.Repository.ExportAssignmentEvents(ctx, from, to, after, limit)
+		return err
+	})
+	return events, next, err
+}
+
+func (r *Repository) GetActiveTeamMembers(
+	ctx context.Context,
+	teamName string,
+	excludeIDs []string,
+) ([]models.Candidate, error) {
+	return get(ctx, r, "GetActiveTeamMembers", func() ([]models.Candidate, error) {
+		return r.Repository.GetActiveTeamMembers(ctx, teamName, excludeIDs)
+	})
+}
+
+func (r *Repository) GetArchivedPR(ctx context.Context, prID string) (*models.ArchivedPR, error) {
+	return get(ctx, r, "GetArchivedPR", func() (*models.ArchivedPR, error) {
+		return r.Repository.GetArchivedPR(ctx, prID)
+	})
+}
+
+func (r *Repository) GetDigestSubscription(ctx context.Context, uid string) (*models.DigestSubscription, error) {
+	return get(ctx, r, "GetDigestSubscription", func() (*models.DigestSubscription, error) {
+		return r.Repository.GetDigestSubscription(ctx, uid)
+	})
+}
+
+func (r *Repository) GetExistingPRs(ctx context.Context, prIDs []string) ([]string, error) {
+	return get(ctx, r, "GetExistingPRs", func() ([]string, error) {
+		return r.Repository.GetExistingPRs(ctx, prIDs)
+	})
+}
+
+func (r *Repository) GetFallbackCandidates(
+	ctx context.Context,
+	excludeTeam string,
+	excludeIDs []string,
+) ([]models.Candidate, error) {
+	return get(ctx, r, "GetFallbackCandidates", func() ([]models.Candidate, error) {
+		return r.Repository.GetFallbackCandidates(ctx, excludeTeam, excludeIDs)
+	})
+}
+
+func (r *Repository) GetOpenPRs(ctx context.Context, prIDs []string) ([]string, error) {
+	return get(ctx, r, "GetOpenPRs", func() ([]string, error) {
+		return r.Repository.GetOpenPRs(ctx, prIDs)
+	})
+}
+
+func (r *Repository) GetOpenPRsByReviewers(ctx context.Context, reviewerIDs []string) ([]string, error) {
+	return get(ctx, r, "GetOpenPRsByReviewers", func() ([]string, error) {
+		return r.Repository.GetOpenPRsByReviewers(ctx, reviewerIDs)
+	})
+}
+
+func (r *Repository) GetOpenPRsByTeam(ctx context.Context, teamName string) ([]string, error) {
+	return get(ctx, r, "GetOpenPRsByTeam", func() ([]string, error) {
+		return r.Repository.GetOpenPRsByTeam(ctx, teamName)
+	})
+}
+
+func (r *Repository) GetOwnerCandidates(
+	ctx context.Context,
+	userIDs, teamNames, excludeIDs []string,
+) ([]models.Candidate, error) {
+	return get(ctx, r, "GetOwnerCandidates", func() ([]models.Candidate, error) {
+		return r.Repository.GetOwnerCandidates(ctx, userIDs, teamNames, excludeIDs)
+	})
+}
+
+func (r *Repository) GetPR(ctx context.Context, prID string) (*models.PR, error) {
+	return get(ctx, r, "GetPR", func() (*models.PR, error) {
+		return r.Repository.GetPR(ctx, prID)
+	})
+}
+
+func (r *Repository) GetSLAStats(ctx context.Context, filter models.SLAFilter) (*models.SLAStats, error) {
+	return get(ctx, r, "GetSLAStats", func() (*models.SLAStats, error) {
+		return r.Repository.GetSLAStats(ctx, filter)
+	})
+}
+
+func (r *Repository) GetStats(ctx context.Context, filter models.StatsFilter, page models.Page) (*models.Stats, error) {
+	return get(ctx, r, "GetStats", func() (*models.Stats, error) {
+		return r.Repository.GetStats(ctx, filter, page)
+	})
+}
+
+func (r *Repository) GetTeam(ctx context.Context, name string) (*models.Team, error) {
+	return get(ctx, r, "GetTeam", func() (*models.Team, error) {
+		return r.Repository.GetTeam(ctx, name)
+	})
+}
+
+func (r *Repository) GetTeamAutoMerge(ctx context.Context, teamName string) (*models.TeamAutoMerge, error) {
+	return get(ctx, r, "GetTeamAutoMerge", func() (*models.TeamAutoMerge, error) {
+		return r.Repository.GetTeamAutoMerge(ctx, teamName)
+	})
+}
+
+func (r *Repository) GetTeamPolicy(ctx context.Context, teamName string) (*models.TeamPolicy, error) {
+	return get(ctx, r, "GetTeamPolicy", func() (*models.TeamPolicy, error) {
+		return r.Repository.GetTeamPolicy(ctx, teamName)
+	})
+}
+
+func (r *Repository) GetTeamStats(ctx context.Context, teamName string) (*models.TeamStats, error) {
+	return get(ctx, r, "GetTeamStats", func() (*models.TeamStats, error) {
+		return r.Repository.GetTeamStats(ctx, teamName)
+	})
+}
+
+func (r *Repository) GetUser(ctx context.Context, uid string) (*models.User, error) {
+	return get(ctx, r, "GetUser", func() (*models.User, error) {
+		return r.Repository.GetUser(ctx, uid)
+	})
+}
+
+func (r *Repository) GetUserByIdentity(ctx context.Context, provider, externalID string) (*models.User, error) {
+	return get(ctx, r, "GetUserByIdentity", func() (*models.User, error) {
+		return r.Repository.GetUserByIdentity(ctx, provider, externalID)
+	})
+}
+
+func (r *Repository) GetUserIdentities(ctx context.Context, uid string) ([]models.UserIdentity, error) {
+	return get(ctx, r, "GetUserIdentities", func() ([]models.UserIdentity, error) {
+		return r.Repository.GetUserIdentities(ctx, uid)
+	})
+}
+
+func (r *Repository) GetUserProfile(ctx context.Context, uid string) (*models.UserProfile, error) {
+	return get(ctx, r, "GetUserProfile", func() (*models.UserProfile, error) {
+		return r.Repository.GetUserProfile(ctx, uid)
+	})
+}
+
+func (r *Repository) GetUserTeamName(ctx context.Context, uid string) (string, error) {
+	return get(ctx, r, "GetUserTeamName", func() (string, error) {
+		return r.Repository.GetUserTeamName(ctx, uid)
+	})
+}
+
+func (r *Repository) GetUserTeamNames(ctx context.Context, uids []string) (map[string]string, error) {
+	return get(ctx, r, "GetUserTeamNames", func() (map[string]string, error) {
+		return r.Repository.GetUserTeamNames(ctx, uids)
+	})
+}
+
+func (r *Repository) GetUserReviews(
+	ctx context.Context,
+	uid string,
+	filter models.ReviewFilter,
+	page models.Page,
+) ([]models.PRShort, int, error) {
+	return list(ctx, r, "GetUserReviews", func() ([]models.PRShort, int, error) {
+		return r.Repository.GetUserReviews(ctx, uid, filter, page)
+	})
+}
+
+func (r *Repository) ImportTeams(ctx context.Context, teams []models.Team) ([]models.TeamImportResult, error) {
+	return get(ctx, r, "ImportTeams", func() ([]models.TeamImportResult, error) {
+		return r.Repository.ImportTeams(ctx, teams)
+	})
+}
+
+func (r *Repository) InsertAuditEntry(ctx context.Context, e models.AuditEntry) error {
+	return r.retry(ctx, "InsertAuditEntry", func() error {
+		return r.Repository.InsertAuditEntry(ctx, e)
+	})
+}
+
+func (r *Repository) ListAssignmentEvents(ctx context.Context, prID string) ([]models.AssignmentEvent, error) {
+	return get(ctx, r, "ListAssignmentEvents", func() ([]models.AssignmentEvent, error) {
+		return r.Repository.ListAssignmentEvents(ctx, prID)
+	})
+}
+
+func (r *Repository) ListAuditEntries(
+	ctx context.Context,
+	filter models.AuditFilter,
+	page models.Page,
+) ([]models.AuditEntry, int, error) {
+	return list(ctx, r, "ListAuditEntries", func() ([]models.AuditEntry, int, error) {
+		return r.Repository.ListAuditEntries(ctx, filter, page)
+	})
+}
+
+func (r *Repository) ListBlockedPRs(ctx context.Context, page models.Page) ([]models.BlockedPR, int, error) {
+	return list(ctx, r, "ListBlockedPRs", func() ([]models.BlockedPR, int, error) {
+		return r.Repository.ListBlockedPRs(ctx, page)
+	})
+}
+
+func (r *Repository) ListDigestSubscriptions(ctx context.Context) ([]models.DigestSubscription, error) {
+	return get(ctx, r, "ListDigestSubscriptions", func() ([]models.DigestSubscription, error) {
+		return r.Repository.ListDigestSubscriptions(ctx)
+	})
+}
+
+func (r *Repository) ListOwnershipRules(ctx context.Context, repository string) ([]models.OwnershipRule, error) {
+	return get(ctx, r, "ListOwnershipRules", func() ([]models.OwnershipRule, error) {
+		return r.Repository.ListOwnershipRules(ctx, repository)
+	})
+}
+
+func (r *Repository) ListOverdueAssignments(
+	ctx context.Context,
+	assignedBefore time.Time,
+	after *models.OverdueAssignment,
+	limit int,
+) ([]models.OverdueAssignment, error) {
+	return get(ctx, r, "ListOverdueAssignments", func() ([]models.OverdueAssignment, error) {
+		return r.Repository.ListOverdueAssignments(ctx, assignedBefore, after, limit)
+	})
+}
+
+func (r *Repository) ListReviewAssignments(ctx context.Context, uid string) ([]models.ReviewAssignment, error) {
+	return get(ctx, r, "ListReviewAssignments", func() ([]models.ReviewAssignment, error) {
+		return r.Repository.ListReviewAssignments(ctx, uid)
+	})
+}
+
+func (r *Repository) ListStalePRs(
+	ctx context.Context,
+	filter models.StaleFilter,
+	page models.Page,
+) ([]models.StalePR, int, error) {
+	return list(ctx, r, "ListStalePRs", func() ([]models.StalePR, int, error) {
+		return r.Repository.ListStalePRs(ctx, filter, page)
+	})
+}
+
+func (r *Repository) ListTeams(
+	ctx context.Context,
+	namePrefix string,
+	page models.Page,
+) ([]models.TeamSummary, int, error) {
+	return list(ctx, r, "ListTeams", func() ([]models.TeamSummary, int, error) {
+		return r.Repository.ListTeams(ctx, namePrefix, page)
+	})
+}
+
+func (r *Repository) ListUsers(
+	ctx context.Context,
+	filter models.UserFilter,
+	page models.Page,
+) ([]models.User, int, error) {
+	return list(ctx, r, "ListUsers", func() ([]models.User, int, error) {
+		return r.Repository.ListUsers(ctx, filter, page)
+	})
+}
+
+func (r *Repository) MarkDigestSent(ctx context.Context, uid string, at time.Time) error {
+	return r.retry(ctx, "MarkDigestSent", func() error {
+		return r.Repository.MarkDigestSent(ctx, uid, at)
+	})
+}
+
+func (r *Repository) MarkOutboxFailed(ctx context.Context, id int64, reason string) error {
+	return r.retry(ctx, "MarkOutboxFailed", func() error {
+		return r.Repository.MarkOutboxFailed(ctx, id, reason)
+	})
+}
+
+func (r *Repository) MarkOutboxPublished(ctx context.Context, ids []int64) error {
+	return r.retry(ctx, "MarkOutboxPublished", func() error {
+		return r.Repository.MarkOutboxPublished(ctx, ids)
+	})
+}
+
+func (r *Repository) MarkReminded(ctx context.Context, prIDs []string) error {
+	return r.retry(ctx, "MarkReminded", func() error {
+		return r.Repository.MarkReminded(ctx, prIDs)
+	})
+}
+
+func (r *Repository) MergePR(ctx context.Context, prID string, expectedVersion *int) error {
+	return r.retry(ctx, "MergePR", func() error {
+		return r.Repository.MergePR(ctx, prID, expectedVersion)
+	})
+}
+
+func (r *Repository) PendingOutbox(ctx context.Context, limit, maxAttempts int) ([]models.DomainEvent, error) {
+	return get(ctx, r, "PendingOutbox", func() ([]models.DomainEvent, error) {
+		return r.Repository.PendingOutbox(ctx, limit, maxAttempts)
+	})
+}
+
+func (r *Repository) PreviewTeamDeactivation(
+	ctx context.Context,
+	teamName string,
+	rng interface{ Intn(int) int },
+) (*repo.DeactivationResult, error) {
+	return get(ctx, r, "PreviewTeamDeactivation", func() (*repo.DeactivationResult, error) {
+		return r.Repository.PreviewTeamDeactivation(ctx, teamName, rng)
+	})
+}
+
+func (r *Repository) PRExists(ctx context.Context, prID string) (bool, error) {
+	return get(ctx, r, "PRExists", func() (bool, error) {
+		return r.Repository.PRExists(ctx, prID)
+	})
+}
+
+func (r *Repository) RenameTeam(ctx context.Context, oldName, newName string) error {
+	return r.retry(ctx, "RenameTeam", func() error {
+		return r.Repository.RenameTeam(ctx, oldName, newName)
+	})
+}
+
+// ReplaceReviewer при повторе заново вызывает plan: транзакция откатилась, и замена
+// выбирается по состоянию PR в новой транзакции.
+func (r *Repository) ReplaceReviewer(
+	ctx context.Context,
+	prID string,
+	plan func(ctx context.Context, pr *models.PR) (models.ReviewerChange, error),
+) error {
+	return r.retry(ctx, "ReplaceReviewer", func() error {
+		return r.Repository.ReplaceReviewer(ctx, prID, plan)
+	})
+}
+
+func (r *Repository) SetNotificationPreferences(ctx context.Context, p models.NotificationPreferences) error {
+	return r.retry(ctx, "SetNotificationPreferences", func() error {
+		return r.Repository.SetNotificationPreferences(ctx, p)
+	})
+}
+
+func (r *Repository) SetOwnershipRules(ctx context.Context, repository string, rules []models.OwnershipRule) error {
+	return r.retry(ctx, "SetOwnershipRules", func() error {
+		return r.Repository.SetOwnershipRules(ctx, repository, rules)
+	})
+}
+
+func (r *Repository) SetPRStatus(
+	ctx context.Context,
+	prID string,
+	status models.PRStatus,
+	expectedVersion *int,
+) error {
+	return r.retry(ctx, "SetPRStatus", func() error {
+		return r.Repository.SetPRStatus(ctx, prID, status, expectedVersion)
+	})
+}
+
+func (r *Repository) SetTeamAutoMerge(ctx context.Context, cfg models.TeamAutoMerge) error {
+	return r.retry(ctx, "SetTeamAutoMerge", func() error {
+		return r.Repository.SetTeamAutoMerge(ctx, cfg)
+	})
+}
+
+func (r *Repository) SetTeamPolicy(ctx context.Context, p models.TeamPolicy) error {
+	return r.retry(ctx, "SetTeamPolicy", func() error {
+		return r.Repository.SetTeamPolicy(ctx, p)
+	})
+}
+
+func (r *Repository) SetUserIdentity(ctx context.Context, id models.UserIdentity) error {
+	return r.retry(ctx, "SetUserIdentity", func() error {
+		return r.Repository.SetUserIdentity(ctx, id)
+	})
+}
+
+func (r *Repository) SetUserTags(ctx context.Context, uid string, tags []string) error {
+	return r.retry(ctx, "SetUserTags", func() error {
+		return r.Repository.SetUserTags(ctx, uid, tags)
+	})
+}
+
+func (r *Repository) SnoozeUser(ctx context.Context, uid string, until *time.Time) error {
+	return r.retry(ctx, "SnoozeUser", func() error {
+		return r.Repository.SnoozeUser(ctx, uid, until)
+	})
+}
+
+func (r *Repository) TeamExists(ctx context.Context, name string) (bool, error) {
+	return get(ctx, r, "TeamExists", func() (bool, error) {
+		return r.Repository.TeamExists(ctx, name)
+	})
+}
+
+func (r *Repository) UpdateUserActiveStatus(ctx context.Context, uid string, active bool) error {
+	return r.retry(ctx, "UpdateUserActiveStatus", func() error {
+		return r.Repository.UpdateUserActiveStatus(ctx, uid, active)
+	})
+}
@@ -0,0 +1,129 @@
+package retrying_test
+
+import (
+	"context"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+	"prreviewer/internal/repo/memory"
+	"prreviewer/internal/repo/retrying"
+	"prreviewer/internal/service"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// flakyRepo возвращает errs по очереди на GetPR, затем обращается к memory.Repository.
+type flakyRepo struct {
+	*memory.Repository
+	errs  []error
+	calls int
+}
+
+func (r *flakyRepo) GetPR(ctx context.Context, prID string) (*models.PR, error) {
+	r.calls++
+	if len(r.errs) > 0 {
+		err := r.errs[0]
+		r.errs = r.errs[1:]
+		return nil, err
+	}
+	return r.Repository.GetPR(ctx, prID)
+}
+
+var fastPolicy = retrying.Policy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+
+func TestRetryTransientErrors(t *testing.T) {
+	dial := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   error
+	}{
+		{"serialization failure", []error{&pgconn.PgError{Code: "40001"}}, 2, nil},
+		{"deadlock", []error{&pgconn.PgError{Code: "40P01"}}, 2, nil},
+		{"connection failure", []error{&pgconn.PgError{Code: "08006"}, &pgconn.PgError{Code: "08001"}}, 3, nil},
+		{"admin shutdown", []error{&pgconn.PgError{Code: "57P01"}}, 2, nil},
+		{"dial", []error{dial}, 2, nil},
+		{"attempts exhausted", []error{dial, dial, dial, dial}, 3, dial},
+		{"not found", []error{repo.ErrNotFound}, 1, repo.ErrNotFound},
+		{"unique violation", []error{&pgconn.PgError{Code: "23505"}}, 1, &pgconn.PgError{Code: "23505"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			base := &flakyRepo{Repository: memory.New(), errs: tt.errs}
+			seed(t, base.Repository)
+			r := retrying.New(base, fastPolicy)
+
+			_, err := r.GetPR(ctx, "pr1")
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("ожидался успех после повторов, получили %v", err)
+			}
+			if tt.wantErr != nil && (err == nil || err.Error() != tt.wantErr.Error()) {
+				t.Errorf("ожидалась ошибка %v, получили %v", tt.wantErr, err)
+			}
+			if base.calls != tt.wantCalls {
+				t.Errorf("ожидалось %d вызовов, получили %d", tt.wantCalls, base.calls)
+			}
+		})
+	}
+}
+
+func TestRetryStopsOnContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	base := &flakyRepo{Repository: memory.New(), errs: []error{&pgconn.PgError{Code: "40001"}}}
+	r := retrying.New(base, retrying.Policy{Attempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour})
+
+	if _, err := r.GetPR(ctx, "pr1"); err == nil {
+		t.Fatal("ожидалась ошибка при отмененном контексте")
+	}
+	if base.calls != 1 {
+		t.Errorf("после отмены контекста повторов быть не должно, вызовов: %d", base.calls)
+	}
+}
+
+func seed(t *testing.T, r *memory.Repository) {
+	t.Helper()
+	ctx := context.Background()
+	err := r.CreateTeam(ctx, models.Team{TeamName: "backend", Members: []models.TeamMember{
+		{UserID: "author", Username: "Author", IsActive: true},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.CreatePR(ctx, models.PR{ID: "pr1", Name: "pr1", AuthorID: "author"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestWrapsEveryMethod проверяет, что Repository объявляет каждый метод
+// service.Repository сам: метод, доступный только через встроенный интерфейс,
+// молча обходит повторы.
+func TestWrapsEveryMethod(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "retrying.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	declared := map[string]bool{}
+	for _, d := range f.Decls {
+		if fn, ok := d.(*ast.FuncDecl); ok && fn.Recv != nil {
+			declared[fn.Name.Name] = true
+		}
+	}
+
+	iface := reflect.TypeOf((*service.Repository)(nil)).Elem()
+	for i := range iface.NumMethod() {
+		if name := iface.Method(i).Name; !declared[name] {
+			t.Errorf("метод %s не обернут повторами", name)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"context"
+
+	"prreviewer/internal/models"
+)
+
+// slaQuery считает SLA реакции по командам (user_id IS NULL) и пользователям.
+// Назначение учитывается, если ревьювер отреагировал или окно $1 (в секундах)
+// истекло: для текущих назначений — к моменту merge или сейчас, для снятых —
+// к моменту снятия. Назначения удаленных пользователей не учитываются.
+const slaQuery = `
+	WITH reviews AS (
+		SELECT r.user_id, r.assigned_at, r.first_action_at,
+			COALESCE(r.first_action_at, p.merged_at, NOW()) AS ended_at
+		FROM pr_reviewers r
+		JOIN pull_requests p ON p.pull_request_id = r.pull_request_id
+		UNION ALL
+		SELECT user_id, assigned_at, first_action_at, COALESCE(first_action_at, archived_at)
+		FROM pr_reviewers_archive
+		WHERE assigned_at IS NOT NULL
+	), due AS (
+		SELECT u.user_id, u.username, u.team_name,
+			EXTRACT(EPOCH FROM v.first_action_at - v.assigned_at)::float8 AS response
+		FROM reviews v
+		JOIN users u ON u.user_id = v.user_id
+		WHERE u.deleted_at IS NULL AND ($2 = '' OR u.team_name = $2)
+			AND (v.first_action_at IS NOT NULL
+				OR EXTRACT(EPOCH FROM v.ended_at - v.assigned_at)::float8 >= $1::float8)
+	)
+	SELECT team_name, user_id, username,
+		COUNT(*), COUNT(response), COUNT(*) FILTER (WHERE response <= $1::float8), AVG(response)
+	FROM due
+	GROUP BY GROUPING SETS ((team_name), (team_name, user_id, username))
+	ORDER BY team_name, user_id NULLS FIRST`
+
+// GetSLAStats возвращает сырые счетчики SLA по командам и пользователям;
+// проценты и общий итог считает сервис.
+func (r *Repository) GetSLAStats(ctx context.Context, filter models.SLAFilter) (*models.SLAStats, error) {
+	stats := &models.SLAStats{Teams: []models.SLACompliance{}, Users: []models.SLACompliance{}}
+	err := r.read(ctx, "GetSLAStats", func(q querier) error {
+		stats.Teams, stats.Users = stats.Teams[:0], stats.Users[:0]
+		rows, err := q.Query(ctx, slaQuery, filter.Window.Seconds(), filter.TeamName)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var c models.SLACompliance
+			var userID, username *string
+			err := rows.Scan(&c.TeamName, &userID, &username,
+				&c.Due, &c.Responded, &c.WithinSLA, &c.AvgResponseSeconds)
+			if err != nil {
+				return err
+			}
+			if userID == nil {
+				stats.Teams = append(stats.Teams, c)
+				continue
+			}
+			c.UserID, c.Username = *userID, *username
+			stats.Users = append(stats.Users, c)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
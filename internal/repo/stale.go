@@ -0,0 +1,122 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"prreviewer/internal/models"
+)
+
+// staleCond — открытые PR старше $1 без одобрений от текущих ревьюверов,
+// о которых не напоминали после $2 (NULL — без учета напоминаний). Для PR с
+// приоритетом high пороги — $3 и $4.
+const staleCond = `p.status IN ` + openStatuses + `
+	AND p.created_at < CASE WHEN p.priority = 'high' THEN $3::timestamptz ELSE $1::timestamptz END
+	AND COALESCE(p.reminded_at < CASE WHEN p.priority = 'high' THEN $4::timestamptz ELSE $2::timestamptz END, true)
+	AND NOT EXISTS (
+		SELECT 1 FROM approvals a
+		JOIN pr_reviewers r ON r.pull_request_id = a.pull_request_id AND r.user_id = a.user_id
+		WHERE a.pull_request_id = p.pull_request_id)`
+
+// ListStalePRs возвращает страницу зависших PR, самые старые первыми, и их общее число.
+func (r *Repository) ListStalePRs(
+	ctx context.Context,
+	filter models.StaleFilter,
+	page models.Page,
+) ([]models.StalePR, int, error) {
+	var total int
+	err := r.conn(ctx).QueryRow(ctx,
+		"SELECT COUNT(*) FROM pull_requests p WHERE "+staleCond,
+		filter.CreatedBefore, filter.RemindedBefore, filter.HighCreatedBefore, filter.HighRemindedBefore).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT p.pull_request_id, p.pull_request_name, p.author_id, COALESCE(p.url, ''), p.created_at, p.reminded_at,
+			ARRAY(SELECT r.user_id FROM pr_reviewers r
+				WHERE r.pull_request_id = p.pull_request_id ORDER BY r.user_id)
+		FROM pull_requests p
+		WHERE `+staleCond+`
+		ORDER BY p.created_at, p.pull_request_id
+		LIMIT $5 OFFSET $6`,
+		filter.CreatedBefore, filter.RemindedBefore, filter.HighCreatedBefore, filter.HighRemindedBefore,
+		page.Limit, page.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	prs := []models.StalePR{}
+	for rows.Next() {
+		var pr models.StalePR
+		var remindedAt *time.Time
+		err := rows.Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.URL, &pr.CreatedAt, &remindedAt, &pr.AssignedReviewers)
+		if err != nil {
+			return nil, 0, err
+		}
+		pr.RemindedAt = remindedAt
+		prs = append(prs, pr)
+	}
+
+	return prs, total, rows.Err()
+}
+
+// MarkReminded фиксирует время отправки напоминания по PR.
+func (r *Repository) MarkReminded(ctx context.Context, prIDs []string) error {
+	_, err := r.db.Exec(ctx,
+		"UPDATE pull_requests SET reminded_at = NOW() WHERE pull_request_id = ANY($1)",
+		prIDs)
+	return err
+}
+
+// ListOverdueAssignments возвращает до limit назначений открытых PR без реакции
+// ревьювера, сделанных раньше assignedBefore, в командах ревьюверов с включенной
+// политикой auto_reassign; самые старые первыми. Непустой after продолжает
+// выборку со следующего за ним назначения.
+func (r *Repository) ListOverdueAssignments(
+	ctx context.Context,
+	assignedBefore time.Time,
+	after *models.OverdueAssignment,
+	limit int,
+) ([]models.OverdueAssignment, error) {
+	var afterAt *time.Time
+	var afterPR, afterUser string
+	if after != nil {
+		afterAt, afterPR, afterUser = &after.AssignedAt, after.PRID, after.UserID
+	}
+
+	var overdue []models.OverdueAssignment
+	err := r.read(ctx, "ListOverdueAssignments", func(q querier) error {
+		overdue = overdue[:0]
+		rows, err := q.Query(ctx, `
+			SELECT r.pull_request_id, r.user_id, r.assigned_at
+			FROM pr_reviewers r
+			JOIN pull_requests p ON p.pull_request_id = r.pull_request_id
+			JOIN users u ON u.user_id = r.user_id
+			JOIN team_policies tp ON tp.team_name = u.team_name
+			WHERE p.status IN `+openStatuses+` AND tp.auto_reassign
+				AND r.first_action_at IS NULL AND r.assigned_at < $1
+				AND ($3::timestamptz IS NULL OR (r.assigned_at, r.pull_request_id, r.user_id) > ($3, $4, $5))
+			ORDER BY r.assigned_at, r.pull_request_id, r.user_id
+			LIMIT $2`,
+			assignedBefore, limit, afterAt, afterPR, afterUser)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var a models.OverdueAssignment
+			if err := rows.Scan(&a.PRID, &a.UserID, &a.AssignedAt); err != nil {
+				return err
+			}
+			overdue = append(overdue, a)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return overdue, nil
+}
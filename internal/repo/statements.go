@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Горячие запросы: проверка PR при создании, выбор кандидатов при каждом
+// назначении и чтение PR. PrepareStatements готовит их на каждом соединении
+// под именем, равным тексту запроса, и pgx выполняет их по готовому оператору
+// в любом QueryExecMode, в том числе simple_protocol.
+const (
+	sqlPRExists = `SELECT EXISTS(SELECT 1 FROM pull_requests WHERE pull_request_id=$1)
+			OR EXISTS(SELECT 1 FROM pull_requests_archive WHERE pull_request_id=$1)`
+
+	sqlGetPR = `
+		SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at, closed_at, version,
+			COALESCE(repository, ''), changed_paths, required_tags, labels, priority,
+			COALESCE(url, ''), COALESCE(branch, ''), COALESCE(description, ''), required_approvals, depends_on
+		FROM pull_requests WHERE pull_request_id=$1`
+
+	sqlPRReviewers = "SELECT user_id FROM pr_reviewers WHERE pull_request_id=$1 ORDER BY user_id"
+
+	sqlPRApprovals = "SELECT user_id FROM approvals WHERE pull_request_id=$1 ORDER BY user_id"
+)
+
+var sqlActiveTeamMembers = candidatesQuery("team_name = $1")
+
+var hotStatements = []string{sqlPRExists, sqlGetPR, sqlPRReviewers, sqlPRApprovals, sqlActiveTeamMembers}
+
+// PrepareStatements готовит горячие запросы на новом соединении; ставится
+// в pgxpool.Config.AfterConnect. За PgBouncer в режиме transaction
+// подготовленные операторы не переживают смену соединения, поэтому там его
+// не подключают. Каждый запрос готовится независимо: ошибка одного не мешает
+// подготовить остальные, а все ошибки возвращаются вместе.
+func PrepareStatements(ctx context.Context, conn *pgx.Conn) error {
+	var errs []error
+	for i, sql := range hotStatements {
+		if _, err := conn.Prepare(ctx, sql, sql); err != nil {
+			errs = append(errs, fmt.Errorf("prepare hot statement %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ParseQueryExecMode переводит значение из конфигурации в режим выполнения
+// запросов pgx; пустое значение — режим pgx по умолчанию (cache_statement).
+func ParseQueryExecMode(s string) (pgx.QueryExecMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "cache_statement":
+		return pgx.QueryExecModeCacheStatement, nil
+	case "cache_describe":
+		return pgx.QueryExecModeCacheDescribe, nil
+	case "describe_exec":
+		return pgx.QueryExecModeDescribeExec, nil
+	case "exec":
+		return pgx.QueryExecModeExec, nil
+	case "simple_protocol":
+		return pgx.QueryExecModeSimpleProtocol, nil
+	default:
+		return 0, fmt.Errorf("unknown query exec mode %q", s)
+	}
+}
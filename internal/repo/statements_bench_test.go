@@ -0,0 +1,94 @@
+package repo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// BenchmarkHotQueries выполняет горячие запросы (PRExists, GetActiveTeamMembers,
+// GetPR) параллельно, как под нагрузкой loadtest, в каждом режиме выполнения
+// pgx и с подготовленными операторами. Нужна база из TEST_DATABASE_URL
+// (см. BenchmarkInsertReviewers); тестовые строки удаляются по завершении.
+func BenchmarkHotQueries(b *testing.B) {
+	dbURL := benchDatabaseURL(b)
+	ctx := context.Background()
+
+	setup, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer setup.Close()
+	cleanup := func() {
+		_, _ = setup.Exec(ctx, "DELETE FROM pull_requests WHERE pull_request_id='bench-hot-pr'")
+		_, _ = setup.Exec(ctx, "DELETE FROM users WHERE team_name='bench-hot'")
+		_, _ = setup.Exec(ctx, "DELETE FROM teams WHERE team_name='bench-hot'")
+	}
+	cleanup()
+	defer cleanup()
+	if _, err := setup.Exec(ctx, "INSERT INTO teams(team_name) VALUES('bench-hot')"); err != nil {
+		b.Fatal(err)
+	}
+	_, err = setup.Exec(ctx, `
+		INSERT INTO users(user_id, username, team_name)
+		SELECT 'bench-hot-' || i, 'bench-hot-' || i, 'bench-hot' FROM generate_series(1, 8) AS i`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	_, err = setup.Exec(ctx, `
+		INSERT INTO pull_requests(pull_request_id, pull_request_name, author_id, status)
+		VALUES('bench-hot-pr', 'bench', 'bench-hot-1', 'OPEN')`)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	modes := []struct {
+		name    string
+		mode    pgx.QueryExecMode
+		prepare bool
+	}{
+		{"simple_protocol", pgx.QueryExecModeSimpleProtocol, false},
+		{"exec", pgx.QueryExecModeExec, false},
+		{"describe_exec", pgx.QueryExecModeDescribeExec, false},
+		{"cache_describe", pgx.QueryExecModeCacheDescribe, false},
+		{"cache_statement", pgx.QueryExecModeCacheStatement, false},
+		{"prepared", pgx.QueryExecModeSimpleProtocol, true},
+	}
+	for _, m := range modes {
+		b.Run(m.name, func(b *testing.B) {
+			cfg, err := pgxpool.ParseConfig(dbURL)
+			if err != nil {
+				b.Fatal(err)
+			}
+			cfg.ConnConfig.DefaultQueryExecMode = m.mode
+			if m.prepare {
+				cfg.AfterConnect = PrepareStatements
+			}
+			db, err := pgxpool.NewWithConfig(ctx, cfg)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer db.Close()
+			r := New(db)
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := r.PRExists(ctx, "bench-hot-pr"); err != nil {
+						b.Error(err)
+						return
+					}
+					if _, err := r.GetActiveTeamMembers(ctx, "bench-hot", []string{"bench-hot-1"}); err != nil {
+						b.Error(err)
+						return
+					}
+					if _, err := r.GetPR(ctx, "bench-hot-pr"); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
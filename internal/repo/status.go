@@ -0,0 +1,38 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+
+	"prreviewer/internal/models"
+)
+
+// SetPRStatus переводит открытый PR в другое открытое состояние. Для
+// отсутствующего, смерженного или закрытого PR и несовпавшей версии —
+// ErrVersionConflict.
+func (r *Repository) SetPRStatus(
+	ctx context.Context,
+	prID string,
+	status models.PRStatus,
+	expectedVersion *int,
+) error {
+	return r.WithTx(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			UPDATE pull_requests SET status=$2, version=version+1
+			WHERE pull_request_id=$1 AND status IN `+openStatuses+` AND ($3::int IS NULL OR version=$3)`,
+			prID, status, expectedVersion)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrVersionConflict
+		}
+
+		return insertOutbox(ctx, tx, models.DomainEvent{
+			Type:    models.DomainPRStatusChanged,
+			PRID:    prID,
+			Details: map[string]any{"status": status},
+		})
+	})
+}
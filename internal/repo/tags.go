@@ -0,0 +1,41 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// userTags — выражение для выборок из users: отсортированные теги пользователя.
+const userTags = `ARRAY(SELECT t.tag FROM user_tags t WHERE t.user_id = users.user_id ORDER BY t.tag)`
+
+// SetUserTags заменяет теги пользователя.
+func (r *Repository) SetUserTags(ctx context.Context, uid string, tags []string) error {
+	return r.WithTx(ctx, func(tx pgx.Tx) error {
+		var exists bool
+		err := tx.QueryRow(ctx,
+			"SELECT EXISTS(SELECT 1 FROM users WHERE user_id=$1 AND deleted_at IS NULL)",
+			uid).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrNotFound
+		}
+
+		if err := replaceUserTags(ctx, tx, uid, tags); err != nil {
+			return err
+		}
+		return r.invalidateCaches(ctx, tx)
+	})
+}
+
+func replaceUserTags(ctx context.Context, tx pgx.Tx, uid string, tags []string) error {
+	if _, err := tx.Exec(ctx, "DELETE FROM user_tags WHERE user_id=$1", uid); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx,
+		"INSERT INTO user_tags(user_id, tag) SELECT $1, unnest($2::text[])",
+		uid, tags)
+	return err
+}
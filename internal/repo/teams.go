@@ -0,0 +1,112 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+
+	"prreviewer/internal/models"
+)
+
+// ListTeams возвращает страницу команд со счетчиками участников и открытых PR
+// их авторов, а также общее число команд под фильтром namePrefix.
+func (r *Repository) ListTeams(
+	ctx context.Context,
+	namePrefix string,
+	page models.Page,
+) ([]models.TeamSummary, int, error) {
+	var total int
+	err := r.conn(ctx).QueryRow(ctx,
+		"SELECT COUNT(*) FROM teams WHERE starts_with(team_name, $1)",
+		namePrefix).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT t.team_name,
+			(SELECT COUNT(*) FROM users u
+				WHERE u.team_name = t.team_name AND u.deleted_at IS NULL),
+			(SELECT COUNT(*) FROM users u
+				WHERE u.team_name = t.team_name AND u.deleted_at IS NULL AND u.is_active),
+			(SELECT COUNT(*) FROM pull_requests p
+				JOIN users a ON a.user_id = p.author_id
+				WHERE a.team_name = t.team_name AND p.status IN `+openStatuses+`)
+		FROM teams t
+		WHERE starts_with(t.team_name, $1)
+		ORDER BY t.team_name
+		LIMIT $2 OFFSET $3`,
+		namePrefix, page.Limit, page.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	teams := []models.TeamSummary{}
+	for rows.Next() {
+		var t models.TeamSummary
+		if err := rows.Scan(&t.TeamName, &t.MemberCount, &t.ActiveMembers, &t.OpenPRs); err != nil {
+			return nil, 0, err
+		}
+		teams = append(teams, t)
+	}
+
+	return teams, total, rows.Err()
+}
+
+// GetOpenPRsByTeam возвращает открытые PR авторов команды teamName, самые
+// старые первыми.
+func (r *Repository) GetOpenPRsByTeam(ctx context.Context, teamName string) ([]string, error) {
+	prIDs := []string{}
+	err := r.read(ctx, "GetOpenPRsByTeam", func(q querier) error {
+		prIDs = prIDs[:0]
+		rows, err := q.Query(ctx, `
+			SELECT p.pull_request_id
+			FROM pull_requests p
+			JOIN users a ON a.user_id = p.author_id
+			WHERE a.team_name = $1 AND p.status IN `+openStatuses+`
+			ORDER BY p.created_at, p.pull_request_id`,
+			teamName)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var prID string
+			if err := rows.Scan(&prID); err != nil {
+				return err
+			}
+			prIDs = append(prIDs, prID)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return prIDs, nil
+}
+
+// RenameTeam меняет имя команды; ссылки на команду обновляются каскадно, а
+// записи аудита остаются связаны с ней через team_id. Для неизвестной команды —
+// ErrNotFound, для занятого имени — ErrDuplicate.
+func (r *Repository) RenameTeam(ctx context.Context, oldName, newName string) error {
+	return r.WithTx(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, "UPDATE teams SET team_name=$2 WHERE team_name=$1", oldName, newName)
+		if err != nil {
+			return mapPgError(err)
+		}
+		if tag.RowsAffected() == 0 {
+			return ErrNotFound
+		}
+		if err := r.invalidateCaches(ctx, tx); err != nil {
+			return err
+		}
+
+		return insertOutbox(ctx, tx, models.DomainEvent{
+			Type:     models.DomainTeamRenamed,
+			TeamName: newName,
+			Details:  map[string]any{"old_team_name": oldName},
+		})
+	})
+}
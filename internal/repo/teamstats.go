@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"context"
+
+	"prreviewer/internal/models"
+)
+
+// teamStatsQuery считает статистику команды одним запросом. Замены — события
+// REASSIGNED и DECLINED на PR команды, первичные назначения — ASSIGNED.
+const teamStatsQuery = `
+	WITH team_prs AS (
+		SELECT p.pull_request_id, p.status, p.created_at, p.merged_at
+		FROM pull_requests p
+		JOIN users u ON u.user_id = p.author_id
+		WHERE u.team_name = $1
+	), pr_counts AS (
+		SELECT COUNT(*) FILTER (WHERE status IN ` + openStatuses + `) AS open,
+			COUNT(*) FILTER (WHERE status = 'MERGED') AS merged,
+			AVG(EXTRACT(EPOCH FROM merged_at - created_at)::float8)
+				FILTER (WHERE status = 'MERGED' AND merged_at IS NOT NULL) AS avg_merge
+		FROM team_prs
+	), status_counts AS (
+		SELECT status, COUNT(*) AS n FROM team_prs GROUP BY status
+	), event_counts AS (
+		SELECT COUNT(*) FILTER (WHERE e.event_type = 'ASSIGNED') AS assigned,
+			COUNT(*) FILTER (WHERE e.event_type IN ('REASSIGNED', 'DECLINED')) AS reassigned
+		FROM assignment_events e
+		JOIN team_prs t ON t.pull_request_id = e.pull_request_id
+	), members AS (
+		SELECT u.user_id, u.username, u.is_active,
+			COUNT(r.pull_request_id) AS total,
+			COUNT(r.pull_request_id) FILTER (WHERE p.status IN ` + openStatuses + `) AS open
+		FROM users u
+		LEFT JOIN pr_reviewers r ON r.user_id = u.user_id
+		LEFT JOIN pull_requests p ON p.pull_request_id = r.pull_request_id
+		WHERE u.team_name = $1 AND u.deleted_at IS NULL
+		GROUP BY u.user_id
+	)
+	SELECT
+		EXISTS(SELECT 1 FROM teams WHERE team_name = $1),
+		pc.open, pc.merged, pc.avg_merge, ec.assigned, ec.reassigned,
+		COALESCE((SELECT json_object_agg(status, n) FROM status_counts), '{}'),
+		COALESCE((
+			SELECT json_agg(json_build_object(
+				'user_id', user_id, 'username', username, 'is_active', is_active,
+				'total_assignments', total, 'open_assignments', open) ORDER BY total DESC, user_id)
+			FROM members), '[]')
+	FROM pr_counts pc, event_counts ec`
+
+// GetTeamStats возвращает нагрузку на ревью в команде; ReassignmentRate
+// считает сервис. Для неизвестной команды — ErrNotFound.
+func (r *Repository) GetTeamStats(ctx context.Context, teamName string) (*models.TeamStats, error) {
+	stats := &models.TeamStats{TeamName: teamName}
+	err := r.read(ctx, "GetTeamStats", func(q querier) error {
+		var exists bool
+		var byStatus map[models.PRStatus]int
+		err := q.QueryRow(ctx, teamStatsQuery, teamName).Scan(
+			&exists, &stats.OpenPRs, &stats.MergedPRs, &stats.AvgTimeToMergeSeconds,
+			&stats.Assignments, &stats.Reassignments, &byStatus, &stats.AssignmentsByMember)
+		if err == nil && !exists {
+			return ErrNotFound
+		}
+		stats.PRsByStatus = models.StatusCounts(byStatus)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
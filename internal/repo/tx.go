@@ -0,0 +1,86 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"prreviewer/internal/logging"
+)
+
+// ErrTxPanic — функция транзакции запаниковала; транзакция откачена.
+var ErrTxPanic = errors.New("panic in transaction")
+
+// errRollback возвращается функцией транзакции, чтобы откатить ее без ошибки
+// для вызывающего, например при прогнозе деактивации.
+var errRollback = errors.New("rollback requested")
+
+// rollbackTimeout — сколько ждать ROLLBACK, когда контекст операции уже отменен.
+const rollbackTimeout = 5 * time.Second
+
+// txBeginner — пул соединений или транзакция; Begin у транзакции создает savepoint.
+type txBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// WithTx выполняет fn в транзакции основного пула: фиксирует ее, если fn вернула
+// nil, и откатывает при ошибке или панике. Вложенные шаги, которые можно
+// откатить по отдельности, выполняются через inTx(ctx, tx, ...) в savepoint.
+func (r *Repository) WithTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	return inTx(ctx, r.db, fn)
+}
+
+// withTxOptions — WithTx с уровнем изоляции из opts.
+func (r *Repository) withTxOptions(ctx context.Context, opts pgx.TxOptions, fn func(tx pgx.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	return runTx(ctx, tx, fn)
+}
+
+// inTx начинает транзакцию на db (savepoint, если db — транзакция) и выполняет в ней fn.
+func inTx(ctx context.Context, db txBeginner, fn func(tx pgx.Tx) error) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	return runTx(ctx, tx, fn)
+}
+
+// runTx выполняет fn и фиксирует tx. Ошибка fn, errRollback или паника откатывают
+// tx; паника возвращается как ErrTxPanic, а ее стек пишется в лог. Ошибки
+// Postgres переводятся mapPgError.
+func runTx(ctx context.Context, tx pgx.Tx, fn func(tx pgx.Tx) error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			logging.FromContext(ctx).Error("panic in transaction", "panic", p, "stack", string(debug.Stack()))
+			err = fmt.Errorf("%w: %v", ErrTxPanic, p)
+		}
+		if err != nil {
+			rollback(ctx, tx)
+		}
+		if errors.Is(err, errRollback) {
+			err = nil
+		}
+		err = mapPgError(err)
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// rollback откатывает незафиксированную транзакцию. ROLLBACK отправляется без
+// отмены ctx: с истекшим контекстом pgx не откатывает транзакцию, а закрывает
+// соединение. После Commit вызов ничего не делает.
+func rollback(ctx context.Context, tx pgx.Tx) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+	_ = tx.Rollback(ctx)
+}
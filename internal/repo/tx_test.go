@@ -0,0 +1,124 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// fakeTx запоминает фиксацию и откат; Begin создает вложенную транзакцию (savepoint).
+type fakeTx struct {
+	pgx.Tx
+
+	committed  bool
+	rolledBack bool
+	children   []*fakeTx
+}
+
+func (t *fakeTx) Begin(context.Context) (pgx.Tx, error) {
+	child := &fakeTx{}
+	t.children = append(t.children, child)
+	return child, nil
+}
+
+func (t *fakeTx) Commit(context.Context) error {
+	t.committed = true
+	return nil
+}
+
+func (t *fakeTx) Rollback(context.Context) error {
+	if t.committed {
+		return pgx.ErrTxClosed
+	}
+	t.rolledBack = true
+	return nil
+}
+
+func TestInTxCommitsOnSuccess(t *testing.T) {
+	db := &fakeTx{}
+	if err := inTx(context.Background(), db, func(pgx.Tx) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	tx := db.children[0]
+	if !tx.committed || tx.rolledBack {
+		t.Errorf("ожидалась фиксация без отката: committed=%v rolledBack=%v", tx.committed, tx.rolledBack)
+	}
+}
+
+func TestInTxRollsBackOnError(t *testing.T) {
+	db := &fakeTx{}
+	want := errors.New("boom")
+	err := inTx(context.Background(), db, func(pgx.Tx) error { return want })
+	if !errors.Is(err, want) {
+		t.Fatalf("ожидалась ошибка fn, получили %v", err)
+	}
+	if tx := db.children[0]; tx.committed || !tx.rolledBack {
+		t.Errorf("ожидался откат без фиксации")
+	}
+}
+
+func TestInTxRecoversPanicInSavepoint(t *testing.T) {
+	db := &fakeTx{}
+	var savepointErr error
+	err := inTx(context.Background(), db, func(tx pgx.Tx) error {
+		savepointErr = inTx(context.Background(), tx, func(pgx.Tx) error {
+			panic("nil map")
+		})
+		return savepointErr
+	})
+	if !errors.Is(savepointErr, ErrTxPanic) || !errors.Is(err, ErrTxPanic) {
+		t.Fatalf("паника должна вернуться как ErrTxPanic, получили %v", err)
+	}
+	tx := db.children[0]
+	if !tx.rolledBack || !tx.children[0].rolledBack {
+		t.Errorf("savepoint и транзакция должны быть откачены")
+	}
+}
+
+func TestInTxSavepointFailureKeepsOuterTx(t *testing.T) {
+	db := &fakeTx{}
+	err := inTx(context.Background(), db, func(tx pgx.Tx) error {
+		_ = inTx(context.Background(), tx, func(pgx.Tx) error { return errors.New("duplicate") })
+		return inTx(context.Background(), tx, func(pgx.Tx) error { return nil })
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := db.children[0]
+	if !tx.committed || !tx.children[0].rolledBack || !tx.children[1].committed {
+		t.Errorf("откатиться должен только неудачный savepoint")
+	}
+}
+
+func TestInTxErrRollbackReturnsNil(t *testing.T) {
+	db := &fakeTx{}
+	if err := inTx(context.Background(), db, func(pgx.Tx) error { return errRollback }); err != nil {
+		t.Fatalf("errRollback не должен возвращаться вызывающему, получили %v", err)
+	}
+	if tx := db.children[0]; tx.committed || !tx.rolledBack {
+		t.Errorf("при errRollback транзакция должна быть откачена")
+	}
+}
+
+func TestReadUsesTxFromContext(t *testing.T) {
+	r := &Repository{}
+	tx := &fakeTx{}
+	ctx := withTxQuerier(context.Background(), tx)
+
+	var got querier
+	err := r.read(ctx, "test", func(q querier) error {
+		got = q
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != tx {
+		t.Errorf("чтение внутри транзакции должно идти через нее, получили %T", got)
+	}
+	if r.conn(ctx) != tx {
+		t.Errorf("conn должен вернуть транзакцию из контекста")
+	}
+}
@@ -0,0 +1,217 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+
+	"prreviewer/internal/models"
+)
+
+type UserDeletionResult struct {
+	Reassignments       []map[string]string
+	ArchivedAssignments int64
+}
+
+// DeleteUserAndReassignPRs мягко удаляет пользователя (deleted_at), отвязывает
+// его учетные записи во внешних системах, переназначает его открытые ревью на
+// активных коллег по команде и переносит оставшиеся назначения (на закрытых PR)
+// в pr_reviewers_archive.
+func (r *Repository) DeleteUserAndReassignPRs(
+	ctx context.Context,
+	uid string,
+	rng interface{ Intn(int) int },
+) (*UserDeletionResult, error) {
+	var result *UserDeletionResult
+	err := r.retryDeactivation(ctx, func() error {
+		var err error
+		result, err = r.deleteUserAndReassignPRs(ctx, uid, rng)
+		return err
+	})
+	return result, err
+}
+
+func (r *Repository) deleteUserAndReassignPRs(
+	ctx context.Context,
+	uid string,
+	rng interface{ Intn(int) int },
+) (*UserDeletionResult, error) {
+	var result *UserDeletionResult
+	err := r.withTxOptions(ctx, pgx.TxOptions{IsoLevel: r.deactivationIsoLevel}, func(tx pgx.Tx) error {
+		var team string
+		err := tx.QueryRow(ctx, `
+			UPDATE users SET is_active=false, deleted_at=NOW()
+			WHERE user_id=$1 AND deleted_at IS NULL
+			RETURNING team_name`,
+			uid).Scan(&team)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		// Учетные записи освобождаются для других пользователей.
+		if _, err := tx.Exec(ctx, "DELETE FROM user_identities WHERE user_id=$1", uid); err != nil {
+			return err
+		}
+		if err := r.invalidateCaches(ctx, tx); err != nil {
+			return err
+		}
+
+		affectedPRs, err := r.getAffectedPRs(ctx, tx, []string{uid})
+		if err != nil {
+			return err
+		}
+
+		activeCandidates, err := r.getActiveUsersByTeam(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		reassignments, err := r.reassignReviewers(
+			ctx, tx, affectedPRs, map[string]string{uid: team}, activeCandidates, rng, "user deleted",
+		)
+		if err != nil {
+			return err
+		}
+
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO pr_reviewers_archive(pull_request_id, user_id, reason, assigned_at, first_action_at)
+			SELECT pull_request_id, user_id, 'user deleted', assigned_at, first_action_at
+			FROM pr_reviewers WHERE user_id=$1
+			ON CONFLICT (pull_request_id, user_id) DO NOTHING`,
+			uid)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, "DELETE FROM pr_reviewers WHERE user_id=$1", uid); err != nil {
+			return err
+		}
+
+		err = insertOutbox(ctx, tx, models.DomainEvent{
+			Type:     models.DomainUserDeleted,
+			TeamName: team,
+			UserID:   uid,
+			Details:  map[string]any{"reassignments": len(reassignments)},
+		})
+		if err != nil {
+			return err
+		}
+
+		result = &UserDeletionResult{
+			Reassignments:       reassignments,
+			ArchivedAssignments: tag.RowsAffected(),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListUsers возвращает страницу неудаленных пользователей под фильтром и их общее число.
+func (r *Repository) ListUsers(
+	ctx context.Context,
+	filter models.UserFilter,
+	page models.Page,
+) ([]models.User, int, error) {
+	const where = `
+		WHERE deleted_at IS NULL
+			AND ($1 = '' OR team_name = $1)
+			AND ($2::boolean IS NULL OR is_active = $2)
+			AND ($3 = '' OR strpos(lower(username), lower($3)) > 0)`
+
+	var total int
+	err := r.conn(ctx).QueryRow(ctx, "SELECT COUNT(*) FROM users"+where,
+		filter.TeamName, filter.IsActive, filter.Query).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.conn(ctx).Query(ctx, `
+		SELECT user_id, username, team_name, is_active, review_weight, COALESCE(manager_id, ''),
+			COALESCE(email, ''), `+userTags+`
+		FROM users`+where+`
+		ORDER BY user_id
+		LIMIT $4 OFFSET $5`,
+		filter.TeamName, filter.IsActive, filter.Query, page.Limit, page.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var u models.User
+		if err := rows.Scan(&u.UserID, &u.Username, &u.TeamName, &u.IsActive, &u.ReviewWeight, &u.ManagerID, &u.Email, &u.Tags); err != nil {
+			return nil, 0, err
+		}
+		users = append(users, u)
+	}
+
+	return users, total, rows.Err()
+}
+
+// userProfileQuery считает нагрузку пользователя $1: открытые ревью, одобрения
+// (в том числе на PR в архиве) и среднее время реакции по текущим, снятым и
+// архивным назначениям.
+const userProfileQuery = `
+	SELECT user_id, username, team_name, is_active, review_weight, COALESCE(manager_id, ''),
+		COALESCE(email, ''), ` + userTags + `,
+		CASE WHEN snoozed_until > NOW() THEN snoozed_until END,
+		(SELECT COUNT(*) FROM pr_reviewers r
+			JOIN pull_requests p ON p.pull_request_id = r.pull_request_id
+			WHERE r.user_id = $1 AND p.status IN ` + openStatuses + `),
+		(SELECT COUNT(*) FROM approvals WHERE user_id = $1)
+			+ (SELECT COUNT(*) FROM pull_requests_archive WHERE $1 = ANY(approved_by)),
+		(SELECT AVG(EXTRACT(EPOCH FROM first_action_at - assigned_at)::float8) FROM (
+			SELECT assigned_at, first_action_at FROM pr_reviewers WHERE user_id = $1
+			UNION ALL
+			SELECT assigned_at, first_action_at FROM pr_reviewers_archive WHERE user_id = $1
+			UNION ALL
+			SELECT assigned_at, first_action_at FROM pull_request_reviewers_archive WHERE user_id = $1
+		) t WHERE first_action_at IS NOT NULL AND assigned_at IS NOT NULL)
+	FROM users WHERE user_id = $1 AND deleted_at IS NULL`
+
+// GetUserProfile возвращает пользователя с нагрузкой, текущим или ближайшим
+// отпуском и действующей паузой. Для неизвестного или удаленного пользователя —
+// ErrNotFound.
+func (r *Repository) GetUserProfile(ctx context.Context, uid string) (*models.UserProfile, error) {
+	var p *models.UserProfile
+	err := r.read(ctx, "GetUserProfile", func(q querier) error {
+		p = &models.UserProfile{}
+		err := q.QueryRow(ctx, userProfileQuery, uid).Scan(
+			&p.UserID, &p.Username, &p.TeamName, &p.IsActive, &p.ReviewWeight, &p.ManagerID, &p.Email, &p.Tags,
+			&p.SnoozedUntil, &p.OpenReviews, &p.ApprovalsGiven, &p.AvgResponseSeconds)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var v models.Vacation
+		err = q.QueryRow(ctx, `
+			SELECT id, user_id, starts_at, ends_at, COALESCE(reason, ''), starts_at <= NOW()
+			FROM user_unavailability
+			WHERE user_id = $1 AND ends_at > NOW()
+			ORDER BY starts_at
+			LIMIT 1`,
+			uid).Scan(&v.ID, &v.UserID, &v.StartsAt, &v.EndsAt, &v.Reason, &p.OnVacation)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		p.Vacation = &v
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
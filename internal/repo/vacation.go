@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"prreviewer/internal/models"
+)
+
+// notOnVacation — условие для выборки кандидатов из users: окно отпуска не покрывает текущий момент.
+const notOnVacation = `NOT EXISTS (
+	SELECT 1 FROM user_unavailability v
+	WHERE v.user_id = users.user_id AND v.starts_at <= NOW() AND v.ends_at > NOW())`
+
+// notSnoozed — условие для выборки кандидатов из users: пользователь не приостановил
+// новые назначения или пауза уже истекла.
+const notSnoozed = `(users.snoozed_until IS NULL OR users.snoozed_until <= NOW())`
+
+func (r *Repository) AddVacation(ctx context.Context, v models.Vacation) (*models.Vacation, error) {
+	err := r.conn(ctx).QueryRow(ctx, `
+		INSERT INTO user_unavailability(user_id, starts_at, ends_at, reason)
+		VALUES($1, $2, $3, NULLIF($4, ''))
+		RETURNING id`,
+		v.UserID, v.StartsAt, v.EndsAt, v.Reason).Scan(&v.ID)
+	if err != nil {
+		return nil, mapPgError(err)
+	}
+	return &v, nil
+}
+
+// ExpireVacations удаляет закончившиеся окна и возвращает пользователей, у которых
+// больше не осталось текущих или будущих отпусков.
+func (r *Repository) ExpireVacations(ctx context.Context) ([]string, error) {
+	rows, err := r.conn(ctx).Query(ctx, `
+		WITH expired AS (
+			DELETE FROM user_unavailability WHERE ends_at <= NOW() RETURNING user_id
+		)
+		SELECT DISTINCT e.user_id FROM expired e
+		WHERE NOT EXISTS (
+			SELECT 1 FROM user_unavailability v WHERE v.user_id = e.user_id AND v.ends_at > NOW())
+		ORDER BY e.user_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []string{}
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			return nil, err
+		}
+		users = append(users, uid)
+	}
+	return users, rows.Err()
+}
+
+// SnoozeUser задает момент, до которого пользователь не получает новых назначений;
+// nil снимает паузу.
+func (r *Repository) SnoozeUser(ctx context.Context, uid string, until *time.Time) error {
+	tag, err := r.db.Exec(ctx,
+		"UPDATE users SET snoozed_until=$2 WHERE user_id=$1 AND deleted_at IS NULL",
+		uid, until)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+)
+
+var (
+	ErrNotApproved   = errors.New("pull request does not have enough approvals")
+	ErrInvalidPolicy = errors.New("invalid team policy")
+)
+
+func (s *Service) ApprovePullRequest(ctx context.Context, prID, userID string) (*models.PR, error) {
+	ctx, cancel := s.writeContext(ctx)
+	defer cancel()
+
+	ctx = repo.ReadFromPrimary(ctx)
+	pr, err := s.repo.GetPR(ctx, prID)
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, ErrPRNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if pr.Status == models.StatusMerged {
+		return nil, ErrPRMerged
+	}
+	if pr.Status == models.StatusClosed {
+		return nil, ErrPRClosed
+	}
+	if !contains(pr.AssignedReviewers, userID) {
+		return nil, ErrNotAssigned
+	}
+
+	if err := s.repo.ApprovePR(ctx, prID, userID, pr.Version); err != nil {
+		if errors.Is(err, repo.ErrVersionConflict) {
+			return nil, ErrConflictRetry
+		}
+		return nil, err
+	}
+	s.recordAudit(ctx, models.AuditEntry{Action: models.AuditPRApproved, UserID: userID, PRID: prID})
+
+	pr, err = s.repo.GetPR(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+
+	merged, err := s.autoMergeIfApproved(ctx, pr, len(approvedByReviewers(pr)))
+	if err != nil {
+		// Одобрение уже сохранено; сбой внешней VCS не должен его откатывать.
+		logging.FromContext(ctx).Error("auto-merge failed", "pr_id", prID, "error", err)
+	}
+	if merged {
+		return s.repo.GetPR(ctx, prID)
+	}
+	return pr, nil
+}
+
+func (s *Service) SetTeamPolicy(ctx context.Context, p models.TeamPolicy) (*models.TeamPolicy, error) {
+	ctx, cancel := s.writeContext(ctx)
+	defer cancel()
+
+	if p.RequiredApprovals != nil && *p.RequiredApprovals <= 0 {
+		return nil, fmt.Errorf("%w: required_approvals must be positive", ErrInvalidPolicy)
+	}
+	if p.ReviewerCount != nil && *p.ReviewerCount < 0 {
+		return nil, fmt.Errorf("%w: reviewer_count must not be negative", ErrInvalidPolicy)
+	}
+	if p.AssignmentStrategy != "" && !validStrategy(p.AssignmentStrategy) {
+		return nil, fmt.Errorf("%w: unknown assignment_strategy %q", ErrInvalidPolicy, p.AssignmentStrategy)
+	}
+
+	exists, err := s.repo.TeamExists(ctx, p.TeamName)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrTeamNotFound
+	}
+	if m := p.MandatoryReviewer; m != nil && *m != "" {
+		if err := s.checkTeamMember(ctx, p.TeamName, *m); err != nil {
+			return nil, err
+		}
+	}
+
+	current, err := s.teamPolicy(ctx, p.TeamName)
+	if err != nil {
+		return nil, err
+	}
+	mergeAssignmentSettings(&p, current)
+
+	if err := s.repo.SetTeamPolicy(ctx, p); err != nil {
+		return nil, err
+	}
+
+	details := map[string]any{
+		"require_approvals":   p.RequireApprovals,
+		"reviewer_count":      *p.ReviewerCount,
+		"assignment_strategy": p.AssignmentStrategy,
+		"cross_team_fallback": *p.CrossTeamFallback,
+		"require_manager":     *p.RequireManager,
+		"auto_reassign":       *p.AutoReassign,
+	}
+	if p.RequiredApprovals != nil {
+		details["required_approvals"] = *p.RequiredApprovals
+	}
+	if p.MandatoryReviewer != nil && *p.MandatoryReviewer == "" {
+		p.MandatoryReviewer = nil
+	}
+	if p.MandatoryReviewer != nil {
+		details["mandatory_reviewer"] = *p.MandatoryReviewer
+	}
+	s.recordAudit(ctx, models.AuditEntry{
+		Action:   models.AuditTeamPolicyChanged,
+		TeamName: p.TeamName,
+		Details:  details,
+	})
+	return &p, nil
+}
+
+// checkTeamMember проверяет, что обязательный ревьювер состоит в команде.
+func (s *Service) checkTeamMember(ctx context.Context, teamName, uid string) error {
+	u, err := s.repo.GetUser(ctx, uid)
+	if errors.Is(err, repo.ErrNotFound) {
+		return fmt.Errorf("%w: mandatory_reviewer %q not found", ErrInvalidPolicy, uid)
+	}
+	if err != nil {
+		return err
+	}
+	if u.TeamName != teamName {
+		return fmt.Errorf("%w: mandatory_reviewer %q is not a member of team %q", ErrInvalidPolicy, uid, teamName)
+	}
+	return nil
+}
+
+// NotApprovedError — ErrNotApproved с числом одобрений и ревьюверами, которые
+// еще не одобрили PR.
+type NotApprovedError struct {
+	Approved int
+	Required int
+	Missing  []string
+}
+
+func (e *NotApprovedError) Error() string {
+	return fmt.Sprintf("%s: %d of %d", ErrNotApproved, e.Approved, e.Required)
+}
+
+func (e *NotApprovedError) Unwrap() error { return ErrNotApproved }
+
+// checkApprovals проверяет порог одобрений PR перед merge.
+func (s *Service) checkApprovals(ctx context.Context, pr *models.PR) error {
+	status, err := s.approvalStatus(ctx, pr)
+	if err != nil {
+		return err
+	}
+	if !status.Satisfied {
+		approved := approvedByReviewers(pr)
+		missing := []string{}
+		for _, uid := range pr.AssignedReviewers {
+			if !contains(approved, uid) {
+				missing = append(missing, uid)
+			}
+		}
+		return &NotApprovedError{Approved: status.Approved, Required: status.RequiredCount, Missing: missing}
+	}
+	return nil
+}
+
+// approvalStatus считает одобрения PR по большему из порогов: политики команды
+// автора и собственного required_approvals PR. Порог PR только повышает
+// требование политики и не больше числа назначенных ревьюверов, чтобы PR
+// оставалось кому одобрить. Команда без политики одобрений сама одобрений
+// не требует.
+func (s *Service) approvalStatus(ctx context.Context, pr *models.PR) (models.ApprovalStatus, error) {
+	status := models.ApprovalStatus{Approved: len(approvedByReviewers(pr)), Satisfied: true}
+	required, err := s.policyApprovals(ctx, pr)
+	if err != nil {
+		return status, err
+	}
+	if pr.RequiredApprovals != nil {
+		required = max(required, min(*pr.RequiredApprovals, len(pr.AssignedReviewers)))
+	}
+	if required <= 0 {
+		return status, nil
+	}
+
+	status.Required = true
+	status.RequiredCount = required
+	status.Satisfied = status.Approved >= status.RequiredCount
+	return status, nil
+}
+
+// policyApprovals возвращает порог одобрений политики команды автора PR; PR
+// удаленного автора остается под политикой его бывшей команды. 0 —
+// политика одобрений не требует.
+func (s *Service) policyApprovals(ctx context.Context, pr *models.PR) (int, error) {
+	teamName, err := s.repo.GetUserTeamName(ctx, pr.AuthorID)
+	if errors.Is(err, repo.ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	policy, err := s.repo.GetTeamPolicy(ctx, teamName)
+	if errors.Is(err, repo.ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if !policy.RequireApprovals {
+		return 0, nil
+	}
+	if policy.RequiredApprovals != nil {
+		return *policy.RequiredApprovals, nil
+	}
+	return len(pr.AssignedReviewers), nil
+}
+
+// approvedByReviewers возвращает одобрения только от текущих ревьюверов:
+// одобрение снятого с PR ревьювера не засчитывается.
+func approvedByReviewers(pr *models.PR) []string {
+	result := make([]string, 0, len(pr.ApprovedBy))
+	for _, uid := range pr.ApprovedBy {
+		if contains(pr.AssignedReviewers, uid) {
+			result = append(result, uid)
+		}
+	}
+	return result
+}
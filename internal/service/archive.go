@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+)
+
+// DefaultArchiveAfter — через сколько после merge PR переносится в архив.
+const DefaultArchiveAfter = 90 * 24 * time.Hour
+
+// archiveBatch — сколько PR переносится одной транзакцией.
+const archiveBatch = 500
+
+// WithArchiveAfter задает, через сколько после merge PR переносится в архив.
+func WithArchiveAfter(d time.Duration) Option {
+	return func(s *Service) { s.archiveAfter = d }
+}
+
+// ArchiveMergedPRs переносит в архив PR, смерженные раньше порога, пачками по
+// archiveBatch, пока не останется ни одного, и возвращает их число.
+func (s *Service) ArchiveMergedPRs(ctx context.Context) (int, error) {
+	mergedBefore := s.now().Add(-s.archiveAfter)
+	total := 0
+	for {
+		n, err := s.repo.ArchiveMergedPRs(ctx, mergedBefore, archiveBatch)
+		total += n
+		if err != nil || n < archiveBatch {
+			return total, err
+		}
+	}
+}
+
+// GetArchivedPR возвращает PR из архива.
+func (s *Service) GetArchivedPR(ctx context.Context, prID string) (*models.ArchivedPR, error) {
+	ctx, cancel := s.readContext(ctx)
+	defer cancel()
+
+	pr, err := s.repo.GetArchivedPR(ctx, prID)
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, ErrPRNotFound
+	}
+	return pr, err
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+)
+
+// forceAssignReason — причина назначения администратором, если он ее не указал.
+const forceAssignReason = "assigned by admin"
+
+var (
+	ErrAlreadyAssigned    = errors.New("user is already assigned to this PR")
+	ErrIneligibleReviewer = errors.New("user cannot review this PR")
+)
+
+// AssignReviewer добавляет к ревьюверам PR выбранного администратором участника
+// команды автора в обход стратегии назначения. Участник должен быть активен и не
+// быть автором; отпуск и пауза назначений не учитываются.
+func (s *Service) AssignReviewer(
+	ctx context.Context,
+	prID, userID, reason string,
+	expectedVersion *int,
+) (*models.PR, error) {
+	ctx, cancel := s.writeContext(ctx)
+	defer cancel()
+
+	ctx = repo.ReadFromPrimary(ctx)
+	user, err := s.repo.GetUser(ctx, userID)
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, fmt.Errorf("%w: user %q is inactive", ErrIneligibleReviewer, userID)
+	}
+
+	reason = cmp.Or(reason, forceAssignReason)
+	pr, _, err := s.replaceReviewer(ctx, prID, func(ctx context.Context, pr *models.PR) (models.ReviewerChange, error) {
+		switch {
+		case pr.Status == models.StatusMerged:
+			return models.ReviewerChange{}, ErrPRMerged
+		case pr.Status == models.StatusClosed:
+			return models.ReviewerChange{}, ErrPRClosed
+		case expectedVersion != nil && *expectedVersion != pr.Version:
+			return models.ReviewerChange{}, ErrVersionConflict
+		case contains(pr.AssignedReviewers, userID):
+			return models.ReviewerChange{}, ErrAlreadyAssigned
+		}
+		if err := ensureNotAuthor(pr.AuthorID, userID); err != nil {
+			return models.ReviewerChange{}, err
+		}
+
+		author, err := s.repo.GetUser(ctx, pr.AuthorID)
+		if err != nil && !errors.Is(err, repo.ErrNotFound) {
+			return models.ReviewerChange{}, err
+		}
+		if err != nil || author.TeamName != user.TeamName {
+			return models.ReviewerChange{}, fmt.Errorf("%w: user %q is not a member of the author's team",
+				ErrIneligibleReviewer, userID)
+		}
+
+		return models.ReviewerChange{
+			PRID:          prID,
+			NewReviewerID: userID,
+			EventType:     models.EventAssigned,
+			Reason:        reason,
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	pr.AssignmentReasons = map[string]string{userID: reason}
+	return pr, nil
+}
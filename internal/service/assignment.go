@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+)
+
+// defaultPolicy — настройки назначения для команды без сохраненной политики.
+func defaultPolicy(teamName string) *models.TeamPolicy {
+	count := models.DefaultReviewerCount
+	fallback, manager, autoReassign := false, false, false
+	return &models.TeamPolicy{
+		TeamName:           teamName,
+		ReviewerCount:      &count,
+		AssignmentStrategy: models.StrategyRandom,
+		CrossTeamFallback:  &fallback,
+		RequireManager:     &manager,
+		AutoReassign:       &autoReassign,
+	}
+}
+
+// teamPolicy возвращает политику команды или политику по умолчанию.
+func (s *Service) teamPolicy(ctx context.Context, teamName string) (*models.TeamPolicy, error) {
+	p, err := s.repo.GetTeamPolicy(ctx, teamName)
+	if errors.Is(err, repo.ErrNotFound) {
+		return defaultPolicy(teamName), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	mergeAssignmentSettings(p, defaultPolicy(teamName))
+	return p, nil
+}
+
+// mergeAssignmentSettings заполняет незаданные настройки назначения из current.
+func mergeAssignmentSettings(p, current *models.TeamPolicy) {
+	if p.ReviewerCount == nil {
+		p.ReviewerCount = current.ReviewerCount
+	}
+	if p.AssignmentStrategy == "" {
+		p.AssignmentStrategy = current.AssignmentStrategy
+	}
+	if p.CrossTeamFallback == nil {
+		p.CrossTeamFallback = current.CrossTeamFallback
+	}
+	if p.RequireManager == nil {
+		p.RequireManager = current.RequireManager
+	}
+	if p.MandatoryReviewer == nil {
+		p.MandatoryReviewer = current.MandatoryReviewer
+	}
+	if p.AutoReassign == nil {
+		p.AutoReassign = current.AutoReassign
+	}
+}
+
+func validStrategy(strategy string) bool {
+	switch strategy {
+	case models.StrategyRandom, models.StrategyRoundRobin, models.StrategyLeastLoaded:
+		return true
+	}
+	return false
+}
+
+// assignReviewers выбирает ревьюверов нового PR по политике команды автора:
+// сначала менеджер автора (если требуется) и обязательный ревьювер команды
+// (сверх reviewer_count), затем владельцы измененных файлов,
+// затем участники команды по стратегии и, если разрешено, участники других команд.
+// На каждом шаге кандидаты с required_tags PR выбираются раньше остальных.
+// Уже назначенные ревьюверы PR (при доборе) занимают места в reviewer_count,
+// кроме обязательного ревьювера. Возвращает только новых ревьюверов вместе
+// с причиной выбора каждого из них.
+func (s *Service) assignReviewers(
+	ctx context.Context,
+	author *models.User,
+	pr models.PR,
+) ([]string, map[string]string, error) {
+	policy, err := s.teamPolicy(ctx, author.TeamName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rng := s.random(ctx)
+	strategy := policy.AssignmentStrategy
+	count := *policy.ReviewerCount
+	for _, id := range pr.AssignedReviewers {
+		if m := policy.MandatoryReviewer; m == nil || *m != id {
+			count--
+		}
+	}
+	count = max(count, 0)
+	reviewers := make([]string, 0, count)
+	reasons := make(map[string]string, count)
+	exclude := append([]string{author.UserID}, pr.AssignedReviewers...)
+	// Менеджер назначается сверх нулевого reviewer_count только при создании PR:
+	// добор в укомплектованный PR его не добавляет.
+	full := len(pr.AssignedReviewers) > 0 && count == 0
+
+	if *policy.RequireManager && !full && author.ManagerID != "" && author.ManagerID != author.UserID &&
+		!slices.Contains(exclude, author.ManagerID) {
+		available, err := s.managerAvailable(ctx, author.ManagerID, exclude)
+		if err != nil {
+			return nil, nil, err
+		}
+		if available {
+			reviewers = append(reviewers, author.ManagerID)
+			reasons[author.ManagerID] = "manager of the author"
+			exclude = append(exclude, author.ManagerID)
+			count = max(count, 1)
+		}
+	}
+
+	if m := policy.MandatoryReviewer; m != nil && *m != "" && !slices.Contains(exclude, *m) {
+		available, err := s.repo.GetActiveTeamMembers(ctx, author.TeamName, exclude)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Неактивный, ушедший из команды или находящийся в отпуске обязательный
+		// ревьювер пропускается.
+		if slices.ContainsFunc(available, func(c models.Candidate) bool { return c.UserID == *m }) {
+			reviewers = append(reviewers, *m)
+			reasons[*m] = "mandatory reviewer of team " + author.TeamName
+			exclude = append(exclude, *m)
+			count++
+		}
+	}
+
+	owners, ownership, err := s.ownerCandidates(ctx, pr.Repository, pr.ChangedPaths, exclude)
+	if err != nil {
+		return nil, nil, err
+	}
+	picked := s.pickTagged(rng, strategy, owners, count-len(reviewers), pr.RequiredTags)
+	explain(reasons, picked, owners, pr.RequiredTags, func(c models.Candidate) string {
+		return ownership[c.UserID]
+	})
+	reviewers = append(reviewers, picked...)
+
+	candidates, err := s.repo.GetActiveTeamMembers(ctx, author.TeamName, append(exclude, reviewers...))
+	if err != nil {
+		return nil, nil, err
+	}
+	picked = s.pickTagged(rng, strategy, candidates, count-len(reviewers), pr.RequiredTags)
+	explain(reasons, picked, candidates, pr.RequiredTags, func(c models.Candidate) string {
+		return strategyReason(strategy) + " in team " + c.TeamName
+	})
+	reviewers = append(reviewers, picked...)
+
+	if len(reviewers) < count && *policy.CrossTeamFallback {
+		others, err := s.repo.GetFallbackCandidates(ctx, author.TeamName, append(exclude, reviewers...))
+		if err != nil {
+			return nil, nil, err
+		}
+		picked = s.pickTagged(rng, strategy, others, count-len(reviewers), pr.RequiredTags)
+		explain(reasons, picked, others, pr.RequiredTags, func(c models.Candidate) string {
+			return strategyReason(strategy) + " in team " + c.TeamName + " (cross-team fallback)"
+		})
+		reviewers = append(reviewers, picked...)
+	}
+
+	return reviewers, reasons, nil
+}
+
+// managerAvailable сообщает, может ли менеджер managerID ревьюить: он ищется
+// среди кандидатов своей команды, как обязательный ревьювер, поэтому
+// неактивный, удаленный, находящийся в отпуске или на паузе менеджер
+// пропускается.
+func (s *Service) managerAvailable(ctx context.Context, managerID string, exclude []string) (bool, error) {
+	manager, err := s.repo.GetUser(ctx, managerID)
+	if errors.Is(err, repo.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	available, err := s.repo.GetActiveTeamMembers(ctx, manager.TeamName, exclude)
+	if err != nil {
+		return false, err
+	}
+	return slices.ContainsFunc(available, func(c models.Candidate) bool { return c.UserID == managerID }), nil
+}
+
+// explain записывает в reasons причину выбора каждого из picked: reason
+// кандидата и, если он подошел по required_tags, отметку об этом.
+func explain(
+	reasons map[string]string,
+	picked []string,
+	candidates []models.Candidate,
+	tags []string,
+	reason func(c models.Candidate) string,
+) {
+	for _, c := range candidates {
+		if !slices.Contains(picked, c.UserID) {
+			continue
+		}
+		r := reason(c)
+		if matched := matchedTags(c, tags); len(matched) > 0 {
+			r += ", has required tags " + strings.Join(matched, ",")
+		}
+		reasons[c.UserID] = r
+	}
+}
+
+// strategyReason описывает стратегию выбора для assignment_reasons.
+func strategyReason(strategy string) string {
+	switch strategy {
+	case models.StrategyLeastLoaded:
+		return "least-loaded"
+	case models.StrategyRoundRobin:
+		return "round-robin"
+	default:
+		return "weighted random pick"
+	}
+}
+
+// pickTagged выбирает до n кандидатов, сначала среди подходящих по тегам,
+// затем из общего пула.
+func (s *Service) pickTagged(rng Randomizer, strategy string, candidates []models.Candidate, n int, tags []string) []string {
+	if len(tags) == 0 {
+		return s.pickReviewers(rng, strategy, candidates, n)
+	}
+	tagged, rest := splitByTags(candidates, tags)
+	picked := s.pickReviewers(rng, strategy, tagged, n)
+	return append(picked, s.pickReviewers(rng, strategy, rest, n-len(picked))...)
+}
+
+// pickReviewers выбирает до n разных кандидатов по стратегии.
+func (s *Service) pickReviewers(rng Randomizer, strategy string, candidates []models.Candidate, n int) []string {
+	switch strategy {
+	case models.StrategyLeastLoaded:
+		return s.pickOrdered(rng, candidates, n, func(a, b models.Candidate) int {
+			return a.OpenReviews - b.OpenReviews
+		})
+	case models.StrategyRoundRobin:
+		return s.pickOrdered(rng, candidates, n, func(a, b models.Candidate) int {
+			return a.LastAssignedAt.Compare(b.LastAssignedAt)
+		})
+	default:
+		return s.pickRandomReviewers(rng, candidates, n)
+	}
+}
+
+// pickOrdered на каждом шаге берет лучших по cmp кандидатов и выбирает среди
+// них одного с учетом review_weight.
+func (s *Service) pickOrdered(rng Randomizer, candidates []models.Candidate, n int, cmp func(a, b models.Candidate) int) []string {
+	pool := slices.Clone(candidates)
+	slices.SortStableFunc(pool, cmp)
+
+	picked := make([]string, 0, min(n, len(pool)))
+	for len(picked) < n && len(pool) > 0 {
+		best := 1
+		for best < len(pool) && cmp(pool[0], pool[best]) == 0 {
+			best++
+		}
+		i := weightedIndex(rng, pool[:best])
+		picked = append(picked, pool[i].UserID)
+		pool = slices.Delete(pool, i, i+1)
+	}
+	return picked
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"prreviewer/internal/auth"
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+)
+
+var ErrInvalidAuditFilter = errors.New("invalid audit filter")
+
+// ListAudit возвращает страницу журнала аудита.
+func (s *Service) ListAudit(
+	ctx context.Context,
+	filter models.AuditFilter,
+	page models.Page,
+) ([]models.AuditEntry, int, error) {
+	ctx, cancel := s.readContext(ctx)
+	defer cancel()
+
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, 0, fmt.Errorf("%w: from must be before to", ErrInvalidAuditFilter)
+	}
+	return s.repo.ListAuditEntries(ctx, filter, page)
+}
+
+// recordAudit дописывает событие в журнал от имени владельца токена запроса.
+// Изменение к этому моменту уже зафиксировано, поэтому сбой записи только логируется.
+func (s *Service) recordAudit(ctx context.Context, e models.AuditEntry) {
+	if c, ok := auth.FromContext(ctx); ok {
+		e.Actor = c.Subject
+	}
+	if err := s.repo.InsertAuditEntry(ctx, e); err != nil {
+		logging.FromContext(ctx).Error("failed to write audit entry", "action", e.Action, "error", err)
+	}
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+	"prreviewer/internal/vcs"
+)
+
+var ErrInvalidAutoMerge = errors.New("invalid auto-merge configuration")
+
+func (s *Service) SetTeamAutoMerge(ctx context.Context, cfg models.TeamAutoMerge) (*models.TeamAutoMerge, error) {
+	ctx, cancel := s.writeContext(ctx)
+	defer cancel()
+
+	if cfg.Enabled {
+		if _, ok := s.mergers[cfg.Provider]; !ok {
+			return nil, fmt.Errorf("%w: provider %q is not configured", ErrInvalidAutoMerge, cfg.Provider)
+		}
+		if cfg.Repository == "" {
+			return nil, fmt.Errorf("%w: repository is required", ErrInvalidAutoMerge)
+		}
+		if cfg.RequiredApprovals <= 0 {
+			return nil, fmt.Errorf("%w: required_approvals must be positive", ErrInvalidAutoMerge)
+		}
+	}
+
+	exists, err := s.repo.TeamExists(ctx, cfg.TeamName)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrTeamNotFound
+	}
+	if !cfg.Enabled {
+		// Выключить auto-merge можно всегда, даже без токена провайдера;
+		// незаданные поля остаются от текущей настройки.
+		if cfg, err = s.disabledAutoMerge(ctx, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.SetTeamAutoMerge(ctx, cfg); err != nil {
+		return nil, err
+	}
+
+	s.recordAudit(ctx, models.AuditEntry{
+		Action:   models.AuditAutoMergeChanged,
+		TeamName: cfg.TeamName,
+		Details: map[string]any{
+			"enabled":            cfg.Enabled,
+			"provider":           cfg.Provider,
+			"repository":         cfg.Repository,
+			"required_approvals": cfg.RequiredApprovals,
+		},
+	})
+	return &cfg, nil
+}
+
+// disabledAutoMerge дополняет выключающую настройку полями текущей.
+func (s *Service) disabledAutoMerge(ctx context.Context, cfg models.TeamAutoMerge) (models.TeamAutoMerge, error) {
+	current, err := s.repo.GetTeamAutoMerge(ctx, cfg.TeamName)
+	if err != nil && !errors.Is(err, repo.ErrNotFound) {
+		return cfg, err
+	}
+	if current != nil {
+		cfg.Provider = cmp.Or(cfg.Provider, current.Provider)
+		cfg.Repository = cmp.Or(cfg.Repository, current.Repository)
+		if cfg.RequiredApprovals <= 0 {
+			cfg.RequiredApprovals = current.RequiredApprovals
+		}
+	}
+	cfg.RequiredApprovals = max(cfg.RequiredApprovals, 1)
+	return cfg, nil
+}
+
+// autoMergeIfApproved мержит PR во внешней VCS и локально, если у команды автора
+// включен auto-merge, набрано нужное число одобрений и нет открытых зависимостей.
+func (s *Service) autoMergeIfApproved(ctx context.Context, pr *models.PR, approvals int) (bool, error) {
+	if !pr.Status.IsOpen() {
+		return false, nil
+	}
+
+	author, err := s.repo.GetUser(ctx, pr.AuthorID)
+	if err != nil {
+		return false, err
+	}
+
+	cfg, err := s.repo.GetTeamAutoMerge(ctx, author.TeamName)
+	if errors.Is(err, repo.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !cfg.Enabled || approvals < cfg.RequiredApprovals {
+		return false, nil
+	}
+
+	if err := s.checkApprovals(ctx, pr); err != nil {
+		if errors.Is(err, ErrNotApproved) {
+			return false, nil
+		}
+		return false, err
+	}
+	if err := s.checkDependencies(ctx, pr); err != nil {
+		if errors.Is(err, ErrBlocked) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	merger, ok := s.mergers[cfg.Provider]
+	if !ok {
+		return false, fmt.Errorf("%w: provider %q is not configured", ErrInvalidAutoMerge, cfg.Provider)
+	}
+
+	// Без ссылки на PR провайдера merge не вызывается: по одному id можно
+	// смержить чужой PR с тем же номером.
+	number, err := vcs.PRNumber(cfg.Provider, cfg.Repository, pr.URL)
+	if err != nil {
+		return false, err
+	}
+
+	if err := merger.Merge(ctx, cfg.Repository, number); err != nil {
+		return false, fmt.Errorf("auto-merge %s: %w", pr.ID, err)
+	}
+	logging.FromContext(ctx).Info("PR merged in VCS",
+		"pr_id", pr.ID,
+		"provider", cfg.Provider,
+		"repository", cfg.Repository,
+	)
+
+	if _, err := s.MergePullRequest(ctx, pr.ID, nil, false); err != nil {
+		return false, err
+	}
+	return true, nil
+}
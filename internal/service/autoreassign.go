@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+)
+
+// DefaultAutoReassignAfter — сколько назначение может оставаться без реакции
+// ревьювера, прежде чем задача auto_reassign заменит его.
+const DefaultAutoReassignAfter = 48 * time.Hour
+
+// autoReassignBatch ограничивает число замен за один запуск задачи и размер
+// страницы просроченных назначений.
+const autoReassignBatch = 100
+
+// errResponded — ревьювер одобрил PR между выборкой просроченных назначений и заменой.
+var errResponded = errors.New("reviewer already responded")
+
+// WithAutoReassignAfter задает, сколько назначение может оставаться без реакции ревьювера.
+func WithAutoReassignAfter(d time.Duration) Option {
+	return func(s *Service) { s.reassignAfter = d }
+}
+
+// ReassignOverdueReviews заменяет ревьюверов, не отреагировавших на назначение
+// дольше порога, в командах с включенной политикой auto_reassign, и возвращает
+// число замен. Назначение без кандидатов на замену остается до следующего
+// запуска, а выборка идет дальше по страницам: такие назначения не занимают
+// место тех, что заменить можно.
+func (s *Service) ReassignOverdueReviews(ctx context.Context) (int, error) {
+	ctx = repo.ReadFromPrimary(ctx)
+	assignedBefore := s.now().Add(-s.reassignAfter)
+	reassigned := 0
+	var after *models.OverdueAssignment
+	for reassigned < autoReassignBatch {
+		overdue, err := s.repo.ListOverdueAssignments(ctx, assignedBefore, after, autoReassignBatch)
+		if err != nil {
+			return reassigned, err
+		}
+		n, err := s.reassignOverdue(ctx, overdue, autoReassignBatch-reassigned)
+		reassigned += n
+		if err != nil || len(overdue) < autoReassignBatch {
+			return reassigned, err
+		}
+		after = &overdue[len(overdue)-1]
+	}
+	return reassigned, nil
+}
+
+// reassignOverdue заменяет ревьюверов страницы просроченных назначений, пока
+// замен меньше limit, и возвращает их число.
+func (s *Service) reassignOverdue(ctx context.Context, overdue []models.OverdueAssignment, limit int) (int, error) {
+	logger := logging.FromContext(ctx)
+	reason := fmt.Sprintf("no response within %s", s.reassignAfter)
+	reassigned := 0
+	for _, a := range overdue {
+		if reassigned == limit {
+			break
+		}
+		_, newReviewerID, err := s.replaceReviewer(ctx, a.PRID, func(ctx context.Context, pr *models.PR) (models.ReviewerChange, error) {
+			if err := checkReassignable(pr, a.UserID, nil); err != nil {
+				return models.ReviewerChange{}, err
+			}
+			if contains(pr.ApprovedBy, a.UserID) {
+				return models.ReviewerChange{}, errResponded
+			}
+
+			newReviewer, _, err := s.pickReplacement(ctx, pr, a.UserID)
+			if err != nil {
+				return models.ReviewerChange{}, err
+			}
+			if newReviewer == "" {
+				return models.ReviewerChange{}, ErrNoCandidate
+			}
+
+			return models.ReviewerChange{
+				PRID:          a.PRID,
+				OldReviewerID: a.UserID,
+				NewReviewerID: newReviewer,
+				EventType:     models.EventReassigned,
+				Reason:        reason,
+			}, nil
+		})
+		switch {
+		case errors.Is(err, ErrNoCandidate):
+			logger.Warn("no candidate to replace overdue reviewer", "pr_id", a.PRID, "user_id", a.UserID)
+			continue
+		case errors.Is(err, errResponded), errors.Is(err, ErrNotAssigned), errors.Is(err, ErrPRNotFound),
+			errors.Is(err, ErrPRMerged), errors.Is(err, ErrPRClosed):
+			// PR изменился после выборки: назначение уже не просрочено.
+			continue
+		case err != nil:
+			return reassigned, err
+		}
+
+		logger.Info("overdue reviewer reassigned",
+			"pr_id", a.PRID, "user_id", a.UserID, "new_user_id", newReviewerID, "assigned_at", a.AssignedAt)
+		reassigned++
+	}
+	return reassigned, nil
+}
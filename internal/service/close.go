@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+)
+
+// ClosePullRequest закрывает PR без merge и освобождает ревьюверов.
+// Повторное закрытие идемпотентно, закрыть смерженный PR нельзя.
+func (s *Service) ClosePullRequest(ctx context.Context, prID string, expectedVersion *int) (*models.PR, error) {
+	ctx, cancel := s.writeContext(ctx)
+	defer cancel()
+
+	ctx = repo.ReadFromPrimary(ctx)
+	pr, err := s.repo.GetPR(ctx, prID)
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, ErrPRNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch pr.Status {
+	case models.StatusClosed:
+		return pr, nil
+	case models.StatusMerged:
+		return nil, ErrPRMerged
+	}
+
+	if expectedVersion != nil && *expectedVersion != pr.Version {
+		return nil, ErrVersionConflict
+	}
+
+	if err := s.repo.ClosePR(ctx, prID, &pr.Version); err != nil {
+		if errors.Is(err, repo.ErrVersionConflict) {
+			return nil, staleWriteErr(expectedVersion)
+		}
+		return nil, err
+	}
+
+	s.recordAudit(ctx, models.AuditEntry{
+		Action:  models.AuditPRClosed,
+		UserID:  pr.AuthorID,
+		PRID:    prID,
+		Details: map[string]any{"released_reviewers": pr.AssignedReviewers},
+	})
+	return s.repo.GetPR(ctx, prID)
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+)
+
+// DeclineReview снимает ревьювера с PR по его инициативе и автоматически
+// подбирает замену. Если кандидатов нет, ревьювер снимается без замены.
+func (s *Service) DeclineReview(ctx context.Context, prID, userID, reason string) (*models.PR, string, error) {
+	ctx, cancel := s.writeContext(ctx)
+	defer cancel()
+
+	ctx = repo.ReadFromPrimary(ctx)
+	var assignmentReason string
+	pr, newReviewerID, err := s.replaceReviewer(ctx, prID, func(ctx context.Context, pr *models.PR) (models.ReviewerChange, error) {
+		if err := checkReassignable(pr, userID, nil); err != nil {
+			return models.ReviewerChange{}, err
+		}
+
+		newReviewer, why, err := s.pickReplacement(ctx, pr, userID)
+		if err != nil {
+			return models.ReviewerChange{}, err
+		}
+
+		assignmentReason = why
+		return models.ReviewerChange{
+			PRID:          prID,
+			OldReviewerID: userID,
+			NewReviewerID: newReviewer,
+			EventType:     models.EventDeclined,
+			Reason:        reason,
+		}, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if newReviewerID != "" {
+		pr.AssignmentReasons = map[string]string{newReviewerID: assignmentReason}
+	}
+	return pr, newReviewerID, nil
+}
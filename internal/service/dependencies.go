@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"prreviewer/internal/models"
+)
+
+var (
+	ErrBlocked           = errors.New("pull request is blocked by open dependencies")
+	ErrInvalidDependency = errors.New("invalid pull request dependency")
+)
+
+// MaxDependencies ограничивает число зависимостей одного PR.
+const MaxDependencies = 50
+
+// BlockedError — ErrBlocked со списком открытых зависимостей PR.
+type BlockedError struct {
+	BlockedBy []string
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrBlocked, strings.Join(e.BlockedBy, ", "))
+}
+
+func (e *BlockedError) Unwrap() error { return ErrBlocked }
+
+// normalizeDependencies убирает повторы и проверяет, что каждая зависимость —
+// существующий PR (в том числе архивный), отличный от самого prID.
+func (s *Service) normalizeDependencies(ctx context.Context, prID string, deps []string) ([]string, error) {
+	result := make([]string, 0, len(deps))
+	for _, dep := range deps {
+		dep = strings.TrimSpace(dep)
+		if dep == "" || dep == prID {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidDependency, dep)
+		}
+		result = append(result, dep)
+	}
+	slices.Sort(result)
+	result = slices.Compact(result)
+	if len(result) > MaxDependencies {
+		return nil, fmt.Errorf("%w: more than %d dependencies", ErrInvalidDependency, MaxDependencies)
+	}
+
+	for _, dep := range result {
+		exists, err := s.repo.PRExists(ctx, dep)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("%w: PR %q not found", ErrInvalidDependency, dep)
+		}
+	}
+	return result, nil
+}
+
+// checkDependencies возвращает BlockedError, если среди зависимостей PR есть
+// открытые; смерженные и закрытые зависимости merge не мешают.
+func (s *Service) checkDependencies(ctx context.Context, pr *models.PR) error {
+	if len(pr.DependsOn) == 0 {
+		return nil
+	}
+	open, err := s.repo.GetOpenPRs(ctx, pr.DependsOn)
+	if err != nil {
+		return err
+	}
+	if len(open) > 0 {
+		return &BlockedError{BlockedBy: open}
+	}
+	return nil
+}
+
+// ListBlockedPRs возвращает страницу открытых PR, ожидающих открытых
+// зависимостей, и их общее число.
+func (s *Service) ListBlockedPRs(ctx context.Context, page models.Page) ([]models.BlockedPR, int, error) {
+	ctx, cancel := s.readContext(ctx)
+	defer cancel()
+
+	return s.repo.ListBlockedPRs(ctx, page)
+}
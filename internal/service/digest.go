@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/notify"
+	"prreviewer/internal/repo"
+)
+
+// digestPeriod — период дайджеста: новые назначения без прошлой сводки берутся
+// за него, в «истекает SLA» попадают сроки до следующей сводки.
+const digestPeriod = 24 * time.Hour
+
+// digestTimeLayout — формат времени дайджеста в настройках уведомлений.
+const digestTimeLayout = "15:04"
+
+var (
+	ErrInvalidDigestTime = errors.New("invalid digest time")
+	ErrInvalidTimezone   = errors.New("invalid timezone")
+)
+
+// validateDigest проверяет заданные время и часовой пояс дайджеста из
+// настроек уведомлений.
+func validateDigest(p models.NotificationPreferences) error {
+	if p.DigestTime != nil && *p.DigestTime != "" {
+		_, err := time.Parse(digestTimeLayout, *p.DigestTime)
+		if err != nil || len(*p.DigestTime) != len(digestTimeLayout) {
+			return fmt.Errorf("%w: expected HH:MM, got %q", ErrInvalidDigestTime, *p.DigestTime)
+		}
+	}
+	if p.Timezone != nil {
+		if _, err := digestLocation(*p.Timezone); err != nil {
+			return fmt.Errorf("%w: %q", ErrInvalidTimezone, *p.Timezone)
+		}
+	}
+	return nil
+}
+
+// digestLocation возвращает часовой пояс дайджеста; пустой — UTC.
+func digestLocation(tz string) (*time.Location, error) {
+	if tz == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(tz)
+}
+
+// digestSlot возвращает последний наступивший к now момент отправки дайджеста
+// по местному времени пользователя.
+func digestSlot(sub models.DigestSubscription, now time.Time) (time.Time, error) {
+	clock, err := time.Parse(digestTimeLayout, sub.DigestTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := digestLocation(sub.Timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	local := now.In(loc)
+	slot := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, loc)
+	if slot.After(now) {
+		slot = slot.AddDate(0, 0, -1)
+	}
+	return slot, nil
+}
+
+// digestDue сообщает, что за последний наступивший момент отправки дайджест
+// пользователю еще не уходил.
+func digestDue(sub models.DigestSubscription, slot time.Time) bool {
+	return sub.LastSentAt == nil || sub.LastSentAt.Before(slot)
+}
+
+// DigestPreview собирает дайджест пользователя на текущий момент, не отправляя
+// его: то, что уйдет при ближайшей отправке, если назначения не изменятся.
+func (s *Service) DigestPreview(ctx context.Context, uid string) (*models.Digest, error) {
+	ctx, cancel := s.readContext(ctx)
+	defer cancel()
+
+	if _, err := s.repo.GetUser(ctx, uid); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	sub, err := s.repo.GetDigestSubscription(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	digest, err := s.buildDigest(ctx, *sub, now)
+	if err != nil {
+		return nil, err
+	}
+	if sub.DigestTime != "" {
+		slot, err := digestSlot(*sub, now)
+		if err != nil {
+			return nil, err
+		}
+		if !digestDue(*sub, slot) {
+			slot = slot.AddDate(0, 0, 1)
+		}
+		digest.NextDeliveryAt = &slot
+	}
+	return digest, nil
+}
+
+// SendDigests отправляет дайджест пользователям, у которых наступило заданное
+// время отправки, а сегодняшний дайджест еще не уходил. Пустой дайджест (нет
+// ревью в работе) не отправляется, но отмечается, чтобы не собирать его повторно.
+func (s *Service) SendDigests(ctx context.Context) (int, error) {
+	subs, err := s.repo.ListDigestSubscriptions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := s.now()
+	sent := 0
+	for _, sub := range subs {
+		logger := logging.FromContext(ctx).With("user_id", sub.UserID)
+		slot, err := digestSlot(sub, now)
+		if err != nil {
+			logger.Warn("invalid digest schedule", "digest_time", sub.DigestTime, "timezone", sub.Timezone, "error", err)
+			continue
+		}
+		if !digestDue(sub, slot) {
+			continue
+		}
+
+		digest, err := s.buildDigest(ctx, sub, now)
+		if err != nil {
+			return sent, err
+		}
+		if !digest.Empty() {
+			err := s.notifier.Notify(ctx, notify.Event{
+				Type:       notify.EventDigest,
+				UserID:     sub.UserID,
+				Recipients: []string{sub.UserID},
+				Message:    digestMessage(digest, slot.Location()),
+				CreatedAt:  now,
+				Details:    map[string]any{"digest": digest},
+			})
+			if err != nil {
+				logger.Error("failed to send digest", "error", err)
+				continue
+			}
+			sent++
+		}
+		if err := s.repo.MarkDigestSent(ctx, sub.UserID, now); err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// buildDigest собирает дайджест sub.UserID на момент now. Новые назначения —
+// с прошлой отправки, а без нее — за digestPeriod.
+func (s *Service) buildDigest(ctx context.Context, sub models.DigestSubscription, now time.Time) (*models.Digest, error) {
+	assignments, err := s.repo.ListReviewAssignments(ctx, sub.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := &models.Digest{
+		UserID:         sub.UserID,
+		GeneratedAt:    now,
+		Since:          now.Add(-digestPeriod),
+		OpenReviews:    []models.DigestItem{},
+		NewlyAssigned:  []models.DigestItem{},
+		SLAApproaching: []models.DigestItem{},
+	}
+	if sub.LastSentAt != nil {
+		digest.Since = *sub.LastSentAt
+	}
+	horizon := now.Add(digestPeriod)
+	for _, a := range assignments {
+		item := models.DigestItem{
+			PRID:       a.PRID,
+			PRName:     a.PRName,
+			AuthorID:   a.AuthorID,
+			Priority:   a.Priority,
+			URL:        a.URL,
+			AssignedAt: a.AssignedAt,
+		}
+		if a.FirstActionAt == nil {
+			// Срок реакции — порог auto_reassign: после него ревьювера заменят.
+			due := a.AssignedAt.Add(s.reassignAfter)
+			item.SLADueAt = &due
+		}
+
+		digest.OpenReviews = append(digest.OpenReviews, item)
+		if a.AssignedAt.After(digest.Since) {
+			digest.NewlyAssigned = append(digest.NewlyAssigned, item)
+		}
+		if item.SLADueAt != nil && item.SLADueAt.Before(horizon) {
+			digest.SLAApproaching = append(digest.SLAApproaching, item)
+		}
+	}
+	return digest, nil
+}
+
+// digestMessage формирует текст дайджеста для канала уведомлений; сроки SLA —
+// по местному времени пользователя.
+func digestMessage(d *models.Digest, loc *time.Location) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Ревью в работе: %d, новых назначений: %d, скоро истекает SLA: %d",
+		len(d.OpenReviews), len(d.NewlyAssigned), len(d.SLAApproaching))
+
+	section := func(title string, items []models.DigestItem, line func(models.DigestItem) string) {
+		if len(items) == 0 {
+			return
+		}
+		b.WriteString("\n\n" + title + ":")
+		for _, item := range items {
+			b.WriteString("\n- " + line(item))
+		}
+	}
+	pr := func(item models.DigestItem) string {
+		s := fmt.Sprintf("«%s» (%s) автора %s", item.PRName, item.PRID, item.AuthorID)
+		if item.URL != "" {
+			s += " " + item.URL
+		}
+		return s
+	}
+	section("Истекает SLA", d.SLAApproaching, func(item models.DigestItem) string {
+		verb := "до"
+		if item.SLADueAt.Before(d.GeneratedAt) {
+			verb = "просрочено с"
+		}
+		return pr(item) + ", " + verb + " " + item.SLADueAt.In(loc).Format("02.01 15:04 MST")
+	})
+	section("Новые назначения", d.NewlyAssigned, pr)
+	section("Ревью в работе", d.OpenReviews, pr)
+	return b.String()
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+)
+
+var (
+	ErrUserExists             = errors.New("user already exists")
+	ErrTeamRequired           = errors.New("team is required for a new user")
+	ErrDirectoryNotConfigured = errors.New("directory sync is not configured")
+	ErrDirectoryGroupEmpty    = errors.New("directory group is empty")
+)
+
+// Directory — внешний каталог (LDAP/AD), группы которого задают состав команд.
+type Directory interface {
+	// TeamMembers возвращает команды с участниками их групп.
+	TeamMembers(ctx context.Context) ([]models.Team, error)
+}
+
+// WithDirectory включает синхронизацию команд с каталогом.
+func WithDirectory(d Directory) Option {
+	return func(s *Service) { s.directory = d }
+}
+
+// reasonUserDeactivated — причина замен ревьюверов отключенного пользователя.
+const reasonUserDeactivated = "user deactivated"
+
+// CreateDirectoryUser заводит пользователя из каталога (SCIM) в команде
+// teamName; команда создается, если ее нет. Удаленного пользователя с тем же
+// user_id восстанавливает.
+func (s *Service) CreateDirectoryUser(ctx context.Context, teamName string, m models.TeamMember) (*models.User, error) {
+	ctx, cancel := s.bulkContext(ctx)
+	defer cancel()
+
+	ctx = repo.ReadFromPrimary(ctx)
+	if _, err := s.repo.GetUser(ctx, m.UserID); err == nil {
+		return nil, ErrUserExists
+	} else if !errors.Is(err, repo.ErrNotFound) {
+		return nil, err
+	}
+	if teamName == "" {
+		return nil, ErrTeamRequired
+	}
+	return s.syncDirectoryUser(ctx, nil, teamName, m)
+}
+
+// UpdateDirectoryUser заменяет данные пользователя данными каталога; пустой
+// teamName оставляет текущую команду. Отключение активного пользователя
+// переназначает его открытые ревью на коллег; ревью, для которых не нашлось
+// кандидата, остаются за ним.
+func (s *Service) UpdateDirectoryUser(ctx context.Context, teamName string, m models.TeamMember) (*models.User, error) {
+	ctx, cancel := s.bulkContext(ctx)
+	defer cancel()
+
+	ctx = repo.ReadFromPrimary(ctx)
+	current, err := s.repo.GetUser(ctx, m.UserID)
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if teamName == "" {
+		teamName = current.TeamName
+	}
+	return s.syncDirectoryUser(ctx, current, teamName, m)
+}
+
+func (s *Service) syncDirectoryUser(
+	ctx context.Context,
+	current *models.User,
+	teamName string,
+	m models.TeamMember,
+) (*models.User, error) {
+	members := []models.TeamMember{m}
+	if err := normalizeMembers(members); err != nil {
+		return nil, err
+	}
+	results, err := s.repo.ImportTeams(ctx, []models.Team{{TeamName: teamName, Members: members}})
+	if err != nil {
+		return nil, err
+	}
+	if results[0].Status == models.ImportFailed {
+		return nil, fmt.Errorf("запись пользователя: %s", results[0].Reason)
+	}
+	s.recordAudit(ctx, models.AuditEntry{
+		Action:   models.AuditUserProvisioned,
+		TeamName: teamName,
+		UserID:   m.UserID,
+		Details:  map[string]any{"created": current == nil, "is_active": m.IsActive},
+	})
+
+	if current != nil && current.IsActive && !m.IsActive {
+		if _, err := s.reassignOpenReviews(ctx, m.UserID); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.GetUser(ctx, m.UserID)
+}
+
+// reassignOpenReviews заменяет отключенного ревьювера на открытых PR
+// случайным активным коллегой, как /pullRequest/reassign.
+func (s *Service) reassignOpenReviews(ctx context.Context, uid string) (int, error) {
+	prIDs, err := s.repo.GetOpenPRsByReviewers(ctx, []string{uid})
+	if err != nil {
+		return 0, err
+	}
+
+	logger := logging.FromContext(ctx)
+	reassigned := 0
+	for _, prID := range prIDs {
+		_, newReviewerID, err := s.replaceReviewer(ctx, prID, func(ctx context.Context, pr *models.PR) (models.ReviewerChange, error) {
+			if err := checkReassignable(pr, uid, nil); err != nil {
+				return models.ReviewerChange{}, err
+			}
+			newReviewer, _, err := s.pickReplacement(ctx, pr, uid)
+			if err != nil {
+				return models.ReviewerChange{}, err
+			}
+			if newReviewer == "" {
+				return models.ReviewerChange{}, ErrNoCandidate
+			}
+			return models.ReviewerChange{
+				PRID:          prID,
+				OldReviewerID: uid,
+				NewReviewerID: newReviewer,
+				EventType:     models.EventReassigned,
+				Reason:        reasonUserDeactivated,
+			}, nil
+		})
+		switch {
+		case errors.Is(err, ErrNoCandidate):
+			logger.Warn("no candidate to replace deactivated reviewer", "pr_id", prID, "user_id", uid)
+			continue
+		case errors.Is(err, ErrNotAssigned), errors.Is(err, ErrPRNotFound),
+			errors.Is(err, ErrPRMerged), errors.Is(err, ErrPRClosed):
+			continue
+		case err != nil:
+			return reassigned, err
+		}
+
+		logger.Info("deactivated reviewer reassigned", "pr_id", prID, "user_id", uid, "new_user_id", newReviewerID)
+		reassigned++
+	}
+	return reassigned, nil
+}
+
+// SyncDirectory приводит команды каталога к составу их групп: заводит новых
+// пользователей, переводит их между командами, включает вернувшихся в группу
+// и отключает выбывших, переназначая их открытые ревью. Пользователь из
+// нескольких групп попадает в команду первой из них. С dryRun только
+// возвращает отчет. Если группа команды с активными участниками пришла из
+// каталога пустой, синхронизация без force прерывается с ErrDirectoryGroupEmpty:
+// пустой ответ чаще означает сбой каталога или опечатку в DN, чем роспуск команды.
+func (s *Service) SyncDirectory(ctx context.Context, dryRun, force bool) (*models.DirectorySyncReport, error) {
+	if s.directory == nil {
+		return nil, ErrDirectoryNotConfigured
+	}
+	ctx, cancel := s.bulkContext(ctx)
+	defer cancel()
+
+	teams, err := s.directory.TeamMembers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("чтение каталога: %w", err)
+	}
+
+	ctx = repo.ReadFromPrimary(ctx)
+	logger := logging.FromContext(ctx)
+	report := &models.DirectorySyncReport{DryRun: dryRun, Changes: []models.DirectorySyncChange{}}
+	listed := make(map[string]bool)
+	writes := make([]models.Team, 0, len(teams))
+	for _, team := range teams {
+		var members []models.TeamMember
+		for _, m := range team.Members {
+			if listed[m.UserID] {
+				continue
+			}
+			listed[m.UserID] = true
+			if err := normalizeMembers([]models.TeamMember{m}); err != nil {
+				logger.Warn("directory user skipped", "user_id", m.UserID, "error", err)
+				continue
+			}
+			action, err := s.directoryAction(ctx, team.TeamName, m)
+			if err != nil {
+				return nil, err
+			}
+			if action == "" {
+				continue
+			}
+			report.Changes = append(report.Changes,
+				models.DirectorySyncChange{TeamName: team.TeamName, UserID: m.UserID, Action: action})
+			members = append(members, m)
+		}
+		if len(members) > 0 {
+			writes = append(writes, models.Team{TeamName: team.TeamName, Members: members})
+		}
+	}
+
+	groupSize := make(map[string]int, len(teams))
+	for _, team := range teams {
+		groupSize[team.TeamName] += len(team.Members)
+	}
+	var (
+		leavers     []models.DirectorySyncChange
+		emptyGroups []string
+	)
+	seenTeams := make(map[string]bool, len(teams))
+	for _, team := range teams {
+		if seenTeams[team.TeamName] {
+			continue
+		}
+		seenTeams[team.TeamName] = true
+		current, err := s.repo.GetTeam(ctx, team.TeamName)
+		if errors.Is(err, repo.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		active := 0
+		for _, m := range current.Members {
+			if m.IsActive && !listed[m.UserID] {
+				leavers = append(leavers, models.DirectorySyncChange{
+					TeamName: team.TeamName, UserID: m.UserID, Action: models.DirectoryUserDeactivated,
+				})
+			}
+			if m.IsActive {
+				active++
+			}
+		}
+		if groupSize[team.TeamName] == 0 && active > 0 {
+			emptyGroups = append(emptyGroups, team.TeamName)
+		}
+	}
+	report.Changes = append(report.Changes, leavers...)
+	if dryRun || len(report.Changes) == 0 {
+		return report, nil
+	}
+	if len(emptyGroups) > 0 && !force {
+		logger.Warn("directory sync aborted: groups came back empty", "teams", emptyGroups)
+		return nil, fmt.Errorf("%w: teams %s", ErrDirectoryGroupEmpty, strings.Join(emptyGroups, ", "))
+	}
+
+	if len(writes) > 0 {
+		results, err := s.repo.ImportTeams(ctx, writes)
+		if err != nil {
+			return nil, err
+		}
+		for _, res := range results {
+			if res.Status == models.ImportFailed {
+				return nil, fmt.Errorf("запись команды %s: %s", res.TeamName, res.Reason)
+			}
+		}
+	}
+	for _, c := range leavers {
+		if err := s.repo.UpdateUserActiveStatus(ctx, c.UserID, false); err != nil {
+			return nil, err
+		}
+		n, err := s.reassignOpenReviews(ctx, c.UserID)
+		report.ReassignedReviews += n
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, c := range report.Changes {
+		s.recordAudit(ctx, models.AuditEntry{
+			Action:   models.AuditDirectorySynced,
+			TeamName: c.TeamName,
+			UserID:   c.UserID,
+			Details:  map[string]any{"action": c.Action},
+		})
+	}
+	return report, nil
+}
+
+// directoryAction определяет, как синхронизация изменит пользователя m
+// команды teamName; пустая строка — без изменений.
+func (s *Service) directoryAction(ctx context.Context, teamName string, m models.TeamMember) (string, error) {
+	user, err := s.repo.GetUser(ctx, m.UserID)
+	switch {
+	case errors.Is(err, repo.ErrNotFound):
+		return models.DirectoryUserCreated, nil
+	case err != nil:
+		return "", err
+	case user.TeamName != teamName:
+		return models.DirectoryUserMoved, nil
+	case !user.IsActive:
+		return models.DirectoryUserActivated, nil
+	case user.Username != m.Username, m.Email != "" && user.Email != m.Email:
+		return models.DirectoryUserUpdated, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"prreviewer/internal/models"
+)
+
+// exportBatchSize — число событий, читаемых выгрузкой за один запрос к БД.
+const exportBatchSize = 1000
+
+var ErrInvalidExportRange = errors.New("invalid export range")
+
+// ExportAssignmentEvents передает write все события назначения из [from, to)
+// пачками в порядке записи. Следующая пачка читается только после возврата
+// write, поэтому медленный получатель не копит события в памяти сервиса.
+// Каждая пачка читается в своем сроке чтения, вся выгрузка ограничена только ctx.
+func (s *Service) ExportAssignmentEvents(
+	ctx context.Context,
+	from, to *time.Time,
+	write func([]models.AssignmentEvent) error,
+) error {
+	if from != nil && to != nil && !from.Before(*to) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidExportRange)
+	}
+
+	var after *models.EventCursor
+	for {
+		batch, next, err := s.exportBatch(ctx, from, to, after)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := write(batch); err != nil {
+			return err
+		}
+		if len(batch) < exportBatchSize {
+			return nil
+		}
+		after = next
+	}
+}
+
+func (s *Service) exportBatch(
+	ctx context.Context,
+	from, to *time.Time,
+	after *models.EventCursor,
+) ([]models.AssignmentEvent, *models.EventCursor, error) {
+	ctx, cancel := s.readContext(ctx)
+	defer cancel()
+	return s.repo.ExportAssignmentEvents(ctx, from, to, after, exportBatchSize)
+}
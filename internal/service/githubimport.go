@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"unicode/utf8"
+
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+	"prreviewer/internal/vcs"
+)
+
+// MaxGitHubImportPRs ограничивает число PR одного импорта из GitHub.
+const MaxGitHubImportPRs = 500
+
+// githubImportWorkers — сколько PR импорта заводится одновременно.
+const githubImportWorkers = 8
+
+var ErrInvalidGitHubImport = errors.New("invalid GitHub import")
+
+var githubRepository = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+
+// WithGitHubURL задает адрес GitHub API для импорта PR (GitHub Enterprise);
+// по умолчанию https://api.github.com.
+func WithGitHubURL(u string) Option {
+	return func(s *Service) { s.githubURL = u }
+}
+
+// ImportGitHubPRs заводит открытые PR репозитория owner/repo из GitHub с
+// токеном token и назначает им ревьюверов, как /pullRequest/create. PR
+// получает id "owner/repo#номер"; автор ищется по привязанному логину GitHub,
+// затем по user_id, равному логину. Уже заведенные PR, PR неизвестных авторов
+// и (без includeDrafts) черновики пропускаются, поэтому импорт можно повторять.
+// Заведенные PR отсеиваются одним запросом, а новые создаются параллельно,
+// не больше githubImportWorkers одновременно.
+func (s *Service) ImportGitHubPRs(
+	ctx context.Context,
+	repository, token string,
+	includeDrafts bool,
+) ([]models.PRImportResult, error) {
+	if !githubRepository.MatchString(repository) {
+		return nil, fmt.Errorf("%w: repository must be owner/repo, got %q", ErrInvalidGitHubImport, repository)
+	}
+
+	listCtx, cancel := s.bulkContext(ctx)
+	prs, err := vcs.NewGitHub(s.githubURL, token).OpenPullRequests(listCtx, repository, MaxGitHubImportPRs)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("чтение PR из GitHub: %w", err)
+	}
+
+	results := make([]models.PRImportResult, len(prs))
+	ids := make([]string, len(prs))
+	for i, pr := range prs {
+		results[i] = models.PRImportResult{PRID: fmt.Sprintf("%s#%d", repository, pr.Number), Number: pr.Number}
+		ids[i] = results[i].PRID
+	}
+	existing, err := s.existingPRs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	authors := map[string]string{}
+	var pending []int
+	for i, pr := range prs {
+		res := &results[i]
+		if existing[res.PRID] {
+			res.Status, res.Reason = models.ImportSkipped, "already exists"
+			continue
+		}
+		if pr.Draft && !includeDrafts {
+			res.Status, res.Reason = models.ImportSkipped, "draft"
+			continue
+		}
+		authorID, ok := authors[pr.Author]
+		if !ok {
+			if authorID, err = s.githubAuthor(ctx, pr.Author); err != nil {
+				return nil, err
+			}
+			authors[pr.Author] = authorID
+		}
+		if authorID == "" {
+			res.Status, res.Reason = models.ImportSkipped, fmt.Sprintf("author %q not found", pr.Author)
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, githubImportWorkers)
+	for _, i := range pending {
+		if ctx.Err() != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			pr := prs[i]
+			s.importGitHubPR(ctx, &results[i], models.PR{
+				ID:          results[i].PRID,
+				Name:        truncate(pr.Title, 255),
+				AuthorID:    authors[pr.Author],
+				Repository:  repository,
+				Labels:      pr.Labels,
+				URL:         pr.URL,
+				Branch:      truncate(pr.Branch, 255),
+				Description: pr.Description,
+			})
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	created := 0
+	for _, res := range results {
+		if res.Status == models.ImportCreated {
+			created++
+		}
+	}
+	s.recordAudit(ctx, models.AuditEntry{
+		Action:  models.AuditGitHubImported,
+		Details: map[string]any{"repository": repository, "open_prs": len(prs), "created": created},
+	})
+	return results, nil
+}
+
+// existingPRs возвращает множество уже заведенных PR из ids.
+func (s *Service) existingPRs(ctx context.Context, ids []string) (map[string]bool, error) {
+	ctx, cancel := s.readContext(ctx)
+	defer cancel()
+
+	found, err := s.repo.GetExistingPRs(repo.ReadFromPrimary(ctx), ids)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool, len(found))
+	for _, id := range found {
+		existing[id] = true
+	}
+	return existing, nil
+}
+
+// importGitHubPR заводит PR импорта и записывает исход в res. PR, заведенный
+// между проверкой и созданием, считается пропущенным.
+func (s *Service) importGitHubPR(ctx context.Context, res *models.PRImportResult, pr models.PR) {
+	out, err := s.CreatePullRequest(ctx, pr)
+	switch {
+	case errors.Is(err, ErrPRExists):
+		res.Status, res.Reason = models.ImportSkipped, "already exists"
+	case err != nil:
+		logging.FromContext(ctx).Warn("github PR import failed", "pull_request_id", res.PRID, "error", err)
+		res.Status, res.Reason = models.ImportFailed, err.Error()
+	default:
+		res.Status, res.AssignedReviewers = models.ImportCreated, out.AssignedReviewers
+	}
+}
+
+// githubAuthor возвращает user_id автора PR с логином login или пустую строку.
+func (s *Service) githubAuthor(ctx context.Context, login string) (string, error) {
+	ctx, cancel := s.readContext(ctx)
+	defer cancel()
+
+	externalID, _ := normalizeExternalID(models.IdentityGitHub, login)
+	if externalID == "" {
+		return "", nil
+	}
+	user, err := s.repo.GetUserByIdentity(ctx, models.IdentityGitHub, externalID)
+	if err == nil {
+		return user.UserID, nil
+	}
+	if !errors.Is(err, repo.ErrNotFound) {
+		return "", err
+	}
+	user, err = s.repo.GetUser(ctx, login)
+	if errors.Is(err, repo.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return user.UserID, nil
+}
+
+// truncate обрезает строку до n символов под размер колонки.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
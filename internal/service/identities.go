@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+)
+
+var (
+	ErrUnknownIdentityProvider = errors.New("unknown identity provider")
+	ErrIdentityTaken           = errors.New("identity is linked to another user")
+)
+
+// SetUserIdentity привязывает пользователя к учетной записи GitHub, GitLab или
+// Slack (пустой ExternalID отвязывает) и возвращает все его учетные записи.
+func (s *Service) SetUserIdentity(ctx context.Context, id models.UserIdentity) ([]models.UserIdentity, error) {
+	ctx, cancel := s.writeContext(ctx)
+	defer cancel()
+
+	externalID, err := normalizeExternalID(id.Provider, id.ExternalID)
+	if err != nil {
+		return nil, err
+	}
+	id.ExternalID = externalID
+
+	user, err := s.repo.GetUser(ctx, id.UserID)
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.SetUserIdentity(ctx, id)
+	switch {
+	case errors.Is(err, repo.ErrNotFound):
+		return nil, ErrUserNotFound
+	case errors.Is(err, repo.ErrDuplicate):
+		return nil, fmt.Errorf("%w: %s %s", ErrIdentityTaken, id.Provider, id.ExternalID)
+	case err != nil:
+		return nil, err
+	}
+
+	s.recordAudit(ctx, models.AuditEntry{
+		Action:   models.AuditUserIdentityChanged,
+		TeamName: user.TeamName,
+		UserID:   id.UserID,
+		Details:  map[string]any{"provider": id.Provider, "external_id": id.ExternalID},
+	})
+	return s.repo.GetUserIdentities(repo.ReadFromPrimary(ctx), id.UserID)
+}
+
+// GetUserIdentities возвращает учетные записи пользователя во внешних системах.
+func (s *Service) GetUserIdentities(ctx context.Context, uid string) ([]models.UserIdentity, error) {
+	ctx, cancel := s.readContext(ctx)
+	defer cancel()
+
+	if _, err := s.repo.GetUser(ctx, uid); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return s.repo.GetUserIdentities(ctx, uid)
+}
+
+// GetUserByIdentity находит пользователя по логину GitHub/GitLab или member ID
+// в Slack, например автора PR из webhook внешней системы.
+func (s *Service) GetUserByIdentity(ctx context.Context, provider, externalID string) (*models.User, error) {
+	ctx, cancel := s.readContext(ctx)
+	defer cancel()
+
+	externalID, err := normalizeExternalID(provider, externalID)
+	if err != nil {
+		return nil, err
+	}
+	if externalID == "" {
+		return nil, ErrUserNotFound
+	}
+
+	user, err := s.repo.GetUserByIdentity(ctx, provider, externalID)
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// normalizeExternalID убирает пробелы и ведущий @; логины GitHub и GitLab не
+// зависят от регистра и хранятся в нижнем, member ID в Slack — как есть.
+func normalizeExternalID(provider, externalID string) (string, error) {
+	externalID = strings.TrimPrefix(strings.TrimSpace(externalID), "@")
+	switch provider {
+	case models.IdentityGitHub, models.IdentityGitLab:
+		return strings.ToLower(externalID), nil
+	case models.IdentitySlack:
+		return externalID, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownIdentityProvider, provider)
+}
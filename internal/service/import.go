@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"prreviewer/internal/models"
+)
+
+// MaxImportTeams ограничивает размер одного импорта.
+const MaxImportTeams = 1000
+
+var ErrInvalidImport = errors.New("invalid team import")
+
+// ImportTeams создает или обновляет команды пачкой. Некорректные элементы
+// отклоняются до записи, остальные пишутся одной транзакцией; отчет идет
+// в порядке входного списка.
+func (s *Service) ImportTeams(ctx context.Context, teams []models.Team) ([]models.TeamImportResult, error) {
+	ctx, cancel := s.bulkContext(ctx)
+	defer cancel()
+
+	if len(teams) == 0 {
+		return nil, fmt.Errorf("%w: no teams", ErrInvalidImport)
+	}
+	if len(teams) > MaxImportTeams {
+		return nil, fmt.Errorf("%w: at most %d teams per import", ErrInvalidImport, MaxImportTeams)
+	}
+
+	results := make([]models.TeamImportResult, len(teams))
+	valid := make([]models.Team, 0, len(teams))
+	validIdx := make([]int, 0, len(teams))
+	seenTeams := make(map[string]bool, len(teams))
+	seenUsers := make(map[string]string)
+
+	for i, team := range teams {
+		results[i] = models.TeamImportResult{TeamName: team.TeamName}
+		if reason := validateImportTeam(team, seenTeams, seenUsers); reason != "" {
+			results[i].Status = models.ImportFailed
+			results[i].Reason = reason
+			continue
+		}
+		valid = append(valid, team)
+		validIdx = append(validIdx, i)
+	}
+
+	if len(valid) == 0 {
+		return results, nil
+	}
+
+	written, err := s.repo.ImportTeams(ctx, valid)
+	if err != nil {
+		return nil, fmt.Errorf("импорт команд: %w", err)
+	}
+	for j, res := range written {
+		results[validIdx[j]] = res
+		if res.Status != models.ImportFailed {
+			s.recordAudit(ctx, models.AuditEntry{
+				Action:   models.AuditTeamImported,
+				TeamName: res.TeamName,
+				Details:  map[string]any{"status": res.Status, "members": len(valid[j].Members)},
+			})
+		}
+	}
+	return results, nil
+}
+
+func validateImportTeam(team models.Team, seenTeams map[string]bool, seenUsers map[string]string) string {
+	if team.TeamName == "" {
+		return "team_name is required"
+	}
+	if seenTeams[team.TeamName] {
+		return "duplicate team in import"
+	}
+	seenTeams[team.TeamName] = true
+
+	for i, m := range team.Members {
+		if m.UserID == "" {
+			return "member user_id is required"
+		}
+		if m.ReviewWeight < 0 {
+			return fmt.Sprintf("user %s: %v", m.UserID, ErrInvalidWeight)
+		}
+		tags, err := normalizeTags(m.Tags)
+		if err != nil {
+			return fmt.Sprintf("user %s: %v", m.UserID, err)
+		}
+		team.Members[i].Tags = tags
+		if !validEmail(m.Email) {
+			return fmt.Sprintf("user %s: %v", m.UserID, ErrInvalidEmail)
+		}
+		if other, ok := seenUsers[m.UserID]; ok {
+			return fmt.Sprintf("user %s is already listed in team %s", m.UserID, other)
+		}
+	}
+	for _, m := range team.Members {
+		seenUsers[m.UserID] = team.TeamName
+	}
+	return ""
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"prreviewer/internal/models"
+)
+
+var (
+	ErrInvalidLabel    = errors.New("invalid label")
+	ErrInvalidPriority = errors.New("unknown pull request priority")
+	ErrInvalidURL      = errors.New("invalid pull request url")
+)
+
+// MaxLabelLength ограничивает длину метки PR.
+const MaxLabelLength = 64
+
+// normalizeLabels приводит метки к нижнему регистру, убирает пробелы и повторы.
+func normalizeLabels(labels []string) ([]string, error) {
+	result := make([]string, 0, len(labels))
+	for _, label := range labels {
+		label = strings.ToLower(strings.TrimSpace(label))
+		if label == "" || len(label) > MaxLabelLength {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidLabel, label)
+		}
+		result = append(result, label)
+	}
+	slices.Sort(result)
+	return slices.Compact(result), nil
+}
+
+// normalizePriority возвращает приоритет PR; пустой означает normal.
+func normalizePriority(priority string) (string, error) {
+	switch priority {
+	case "":
+		return models.PriorityNormal, nil
+	case models.PriorityLow, models.PriorityNormal, models.PriorityHigh:
+		return priority, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidPriority, priority)
+}
+
+// validatePRURL проверяет, что ссылка на PR — абсолютный http(s)-адрес;
+// пустая ссылка допустима.
+func validatePRURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %q", ErrInvalidURL, raw)
+	}
+	return nil
+}
+
+// staleFilter строит фильтр зависших PR на момент now: PR с приоритетом high
+// считается зависшим и получает повторное напоминание вдвое раньше остальных.
+func staleFilter(now time.Time, staleAfter time.Duration, remind bool) models.StaleFilter {
+	filter := models.StaleFilter{
+		CreatedBefore:     now.Add(-staleAfter),
+		HighCreatedBefore: now.Add(-staleAfter / 2),
+	}
+	if remind {
+		filter.RemindedBefore = &filter.CreatedBefore
+		filter.HighRemindedBefore = &filter.HighCreatedBefore
+	}
+	return filter
+}
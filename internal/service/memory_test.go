@@ -0,0 +1,2106 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"prreviewer/internal/models"
+	"prreviewer/internal/notify"
+	"prreviewer/internal/pkg"
+	"prreviewer/internal/repo"
+	"prreviewer/internal/repo/memory"
+	"prreviewer/internal/service"
+	"prreviewer/internal/vcs"
+)
+
+var _ service.Repository = (*memory.Repository)(nil)
+
+// clock — управляемое время для memory.Repository.
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+// newMemoryService создает сервис над memory.Repository с командами teams.
+func newMemoryService(t *testing.T, teams ...models.Team) (*service.Service, *memory.Repository, *clock) {
+	t.Helper()
+	// Сервис проверяет окна отпусков по реальному времени.
+	clk := &clock{t: time.Now().Truncate(time.Second)}
+	r := memory.New(memory.WithClock(clk.now))
+	svc := service.New(r, service.WithRandomizer(firstRand{}), service.WithClock(clk.now))
+	for _, team := range teams {
+		if err := svc.CreateTeam(context.Background(), team); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return svc, r, clk
+}
+
+func team(name string, members ...string) models.Team {
+	t := models.Team{TeamName: name}
+	for _, uid := range members {
+		t.Members = append(t.Members, models.TeamMember{UserID: uid, Username: uid, IsActive: true})
+	}
+	return t
+}
+
+func setPolicy(t *testing.T, svc *service.Service, p models.TeamPolicy) {
+	t.Helper()
+	if _, err := svc.SetTeamPolicy(context.Background(), p); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func createPR(t *testing.T, svc *service.Service, id, author string) *models.PR {
+	t.Helper()
+	pr, err := svc.CreatePullRequest(context.Background(), models.PR{ID: id, Name: id, AuthorID: author})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pr
+}
+
+func TestMemoryCreatePullRequestSkipsUnavailableMembers(t *testing.T) {
+	svc, _, clk := newMemoryService(t, team("backend", "author", "a", "b", "c", "d"))
+	ctx := context.Background()
+
+	if _, err := svc.SetUserActive(ctx, "a", false); err != nil {
+		t.Fatal(err)
+	}
+	_, err := svc.SetVacation(ctx, models.Vacation{UserID: "b", StartsAt: clk.now(), EndsAt: clk.now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pr := createPR(t, svc, "pr1", "author")
+	if want := []string{"c", "d"}; !slices.Equal(pr.AssignedReviewers, want) {
+		t.Errorf("ожидались ревьюверы %v, получили %v", want, pr.AssignedReviewers)
+	}
+	if pr.Status != "OPEN" || pr.Version != 1 {
+		t.Errorf("новый PR должен быть OPEN с версией 1, получили %s/%d", pr.Status, pr.Version)
+	}
+
+	clk.advance(2 * time.Hour)
+	pr = createPR(t, svc, "pr2", "author")
+	if !slices.Contains(pr.AssignedReviewers, "b") {
+		t.Errorf("после отпуска b снова должен назначаться, получили %v", pr.AssignedReviewers)
+	}
+}
+
+func TestMemorySnoozeKeepsReviewsAndSkipsNewOnes(t *testing.T) {
+	svc, r, clk := newMemoryService(t, team("backend", "author", "a", "b", "c"))
+	ctx := context.Background()
+	created := createPR(t, svc, "pr1", "author") // a, b
+
+	until, err := svc.SnoozeUser(ctx, "a", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if until == nil || until.Before(time.Now()) {
+		t.Errorf("ожидался момент окончания паузы в будущем, получили %v", until)
+	}
+	if _, err := svc.SnoozeUser(ctx, "a", 8*24*time.Hour); !errors.Is(err, service.ErrInvalidSnooze) {
+		t.Errorf("пауза дольше недели недопустима, получили %v", err)
+	}
+	if _, err := svc.SnoozeUser(ctx, "ghost", time.Hour); !errors.Is(err, service.ErrUserNotFound) {
+		t.Errorf("ожидалась ErrUserNotFound, получили %v", err)
+	}
+
+	pr, err := r.GetPR(ctx, "pr1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(pr.AssignedReviewers, created.AssignedReviewers) {
+		t.Errorf("пауза не снимает текущие ревью: было %v, стало %v", created.AssignedReviewers, pr.AssignedReviewers)
+	}
+	user, err := r.GetUser(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.IsActive {
+		t.Error("пауза не должна деактивировать пользователя")
+	}
+
+	pr = createPR(t, svc, "pr2", "author")
+	if want := []string{"b", "c"}; !slices.Equal(pr.AssignedReviewers, want) {
+		t.Errorf("a на паузе не должен назначаться: ожидались %v, получили %v", want, pr.AssignedReviewers)
+	}
+
+	clk.advance(2 * time.Hour)
+	pr = createPR(t, svc, "pr3", "author")
+	if !slices.Contains(pr.AssignedReviewers, "a") {
+		t.Errorf("после окончания паузы a снова должен назначаться, получили %v", pr.AssignedReviewers)
+	}
+}
+
+func TestMemoryAssignmentStrategies(t *testing.T) {
+	tests := []struct {
+		strategy string
+		want     []string
+	}{
+		// Без нагрузки least_loaded выбирает первого по user_id, затем менее загруженных.
+		{models.StrategyLeastLoaded, []string{"a", "b", "c", "a"}},
+		// round_robin идет по давности последнего назначения.
+		{models.StrategyRoundRobin, []string{"a", "b", "c", "a"}},
+		// random с firstRand всегда берет первого кандидата.
+		{models.StrategyRandom, []string{"a", "a", "a", "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			svc, _, clk := newMemoryService(t, team("backend", "author", "a", "b", "c"))
+			count := 1
+			setPolicy(t, svc, models.TeamPolicy{TeamName: "backend", ReviewerCount: &count, AssignmentStrategy: tt.strategy})
+
+			var got []string
+			for _, id := range []string{"pr1", "pr2", "pr3", "pr4"} {
+				clk.advance(time.Minute)
+				pr := createPR(t, svc, id, "author")
+				got = append(got, pr.AssignedReviewers...)
+				if id == "pr3" && tt.strategy == models.StrategyLeastLoaded {
+					// Освобождаем a, чтобы он снова стал наименее загруженным.
+					if _, err := svc.MergePullRequest(context.Background(), "pr1", nil, false); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ожидались назначения %v, получили %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMemoryCrossTeamFallback(t *testing.T) {
+	svc, _, _ := newMemoryService(t, team("backend", "author", "a"), team("platform", "p1", "p2"))
+	fallback := true
+	setPolicy(t, svc, models.TeamPolicy{TeamName: "backend", CrossTeamFallback: &fallback})
+
+	pr := createPR(t, svc, "pr1", "author")
+	if want := []string{"a", "p1"}; !slices.Equal(pr.AssignedReviewers, want) {
+		t.Errorf("ожидались ревьюверы %v, получили %v", want, pr.AssignedReviewers)
+	}
+}
+
+func TestMemoryManagerSkippedWhenUnavailable(t *testing.T) {
+	backend := team("backend", "author", "a", "b")
+	backend.Members[0].ManagerID = "boss"
+	svc, _, clk := newMemoryService(t, team("management", "boss"), backend)
+	ctx := context.Background()
+	count, manager := 1, true
+	setPolicy(t, svc, models.TeamPolicy{TeamName: "backend", ReviewerCount: &count, RequireManager: &manager})
+
+	if pr := createPR(t, svc, "pr1", "author"); !slices.Contains(pr.AssignedReviewers, "boss") {
+		t.Fatalf("менеджер автора должен назначаться, получили %v", pr.AssignedReviewers)
+	}
+
+	if _, err := svc.SnoozeUser(ctx, "boss", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if pr := createPR(t, svc, "pr2", "author"); slices.Contains(pr.AssignedReviewers, "boss") {
+		t.Errorf("менеджер на паузе не должен назначаться, получили %v", pr.AssignedReviewers)
+	}
+
+	clk.advance(2 * time.Hour)
+	_, err := svc.SetVacation(ctx, models.Vacation{UserID: "boss", StartsAt: clk.now(), EndsAt: clk.now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr := createPR(t, svc, "pr3", "author"); slices.Contains(pr.AssignedReviewers, "boss") {
+		t.Errorf("менеджер в отпуске не должен назначаться, получили %v", pr.AssignedReviewers)
+	}
+}
+
+func TestMemoryMandatoryReviewer(t *testing.T) {
+	svc, _, clk := newMemoryService(t, team("backend", "author", "a", "b", "lead"), team("platform", "p1"))
+	ctx := context.Background()
+	count, lead := 1, "lead"
+	setPolicy(t, svc, models.TeamPolicy{TeamName: "backend", ReviewerCount: &count, MandatoryReviewer: &lead})
+
+	pr := createPR(t, svc, "pr1", "author")
+	if len(pr.AssignedReviewers) != 2 || !slices.Contains(pr.AssignedReviewers, "lead") {
+		t.Errorf("lead должен назначаться сверх reviewer_count, получили %v", pr.AssignedReviewers)
+	}
+
+	_, err := svc.SetVacation(ctx, models.Vacation{UserID: "lead", StartsAt: clk.now(), EndsAt: clk.now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr = createPR(t, svc, "pr2", "author")
+	if len(pr.AssignedReviewers) != 1 || slices.Contains(pr.AssignedReviewers, "lead") {
+		t.Errorf("lead в отпуске не должен назначаться, получили %v", pr.AssignedReviewers)
+	}
+
+	pr = createPR(t, svc, "pr3", "lead")
+	if slices.Contains(pr.AssignedReviewers, "lead") {
+		t.Errorf("автор не назначается ревьювером своего PR, получили %v", pr.AssignedReviewers)
+	}
+
+	outsider := "p1"
+	_, err = svc.SetTeamPolicy(ctx, models.TeamPolicy{TeamName: "backend", MandatoryReviewer: &outsider})
+	if !errors.Is(err, service.ErrInvalidPolicy) {
+		t.Errorf("участник другой команды не может быть обязательным ревьювером, получили %v", err)
+	}
+
+	none := ""
+	p, err := svc.SetTeamPolicy(ctx, models.TeamPolicy{TeamName: "backend", MandatoryReviewer: &none})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.MandatoryReviewer != nil || *p.ReviewerCount != 1 {
+		t.Errorf("пустая строка снимает обязательного ревьювера, остальное сохраняется: %+v", p)
+	}
+}
+
+func TestMemoryPriorityAndLabels(t *testing.T) {
+	svc, _, clk := newMemoryService(t, team("backend", "author", "a", "b", "c"))
+	ctx := context.Background()
+	count := 1
+	setPolicy(t, svc, models.TeamPolicy{TeamName: "backend", ReviewerCount: &count, AssignmentStrategy: models.StrategyLeastLoaded})
+
+	// PR старше половины порога зависания, но моложе самого порога.
+	clk.t = time.Now().Add(-service.DefaultStaleAfter * 3 / 4).Truncate(time.Second)
+	hot, err := svc.CreatePullRequest(ctx, models.PR{
+		ID: "hot", Name: "hot", AuthorID: "author", Priority: models.PriorityHigh, Labels: []string{" Security", "security"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(hot.Labels, []string{"security"}) || hot.Priority != models.PriorityHigh {
+		t.Errorf("метки нормализуются, приоритет сохраняется: %+v", hot)
+	}
+	var got []string
+	for _, id := range []string{"pr2", "pr3", "pr4"} {
+		got = append(got, createPR(t, svc, id, "author").AssignedReviewers...)
+	}
+	// a ревьюит PR с приоритетом high, он весит как два обычных.
+	if want := []string{"b", "c", "b"}; !slices.Equal(got, want) {
+		t.Errorf("ожидались назначения %v, получили %v", want, got)
+	}
+
+	stale, _, err := svc.ListStalePRs(ctx, 0, models.Page{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 1 || stale[0].ID != "hot" {
+		t.Errorf("зависшим должен считаться только PR с приоритетом high, получили %+v", stale)
+	}
+
+	reviews, total, err := svc.GetUserReviews(ctx, hot.AssignedReviewers[0],
+		models.ReviewFilter{Label: "SECURITY", Priority: models.PriorityHigh}, models.Page{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || reviews[0].ID != "hot" {
+		t.Errorf("ожидался PR hot по метке и приоритету, получили %d: %+v", total, reviews)
+	}
+	_, total, err = svc.GetUserReviews(ctx, "b", models.ReviewFilter{Label: "security"}, models.Page{Limit: 10})
+	if err != nil || total != 0 {
+		t.Errorf("у b нет PR с меткой security, получили %d, %v", total, err)
+	}
+
+	_, _, err = svc.GetUserReviews(ctx, "b", models.ReviewFilter{Priority: "urgent"}, models.Page{Limit: 10})
+	if !errors.Is(err, service.ErrInvalidPriority) {
+		t.Errorf("ожидалась ErrInvalidPriority в фильтре, получили %v", err)
+	}
+	_, err = svc.CreatePullRequest(ctx, models.PR{ID: "bad", Name: "bad", AuthorID: "author", Priority: "urgent"})
+	if !errors.Is(err, service.ErrInvalidPriority) {
+		t.Errorf("ожидалась ErrInvalidPriority при создании, получили %v", err)
+	}
+}
+
+func TestMemoryPRMetadata(t *testing.T) {
+	svc, _, _ := newMemoryService(t, team("backend", "author", "a"))
+	ctx := context.Background()
+
+	_, err := svc.CreatePullRequest(ctx, models.PR{
+		ID: "pr1", Name: "pr1", AuthorID: "author",
+		URL: "https://github.com/acme/api/pull/1", Branch: "feature/x", Description: "Новая ручка",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, err := svc.GetPullRequest(ctx, "pr1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.URL != "https://github.com/acme/api/pull/1" || pr.Branch != "feature/x" || pr.Description != "Новая ручка" {
+		t.Errorf("метаданные PR должны сохраниться: %+v", pr)
+	}
+	reviews, _, err := svc.GetUserReviews(ctx, "a", models.ReviewFilter{}, models.Page{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reviews) != 1 || reviews[0].URL != pr.URL {
+		t.Errorf("в списке ревью должна быть ссылка на PR: %+v", reviews)
+	}
+
+	for _, bad := range []string{"github.com/acme/api/pull/2", "ftp://example.com/pr", "https://"} {
+		_, err = svc.CreatePullRequest(ctx, models.PR{ID: "pr2", Name: "pr2", AuthorID: "author", URL: bad})
+		if !errors.Is(err, service.ErrInvalidURL) {
+			t.Errorf("%q: ожидалась ErrInvalidURL, получили %v", bad, err)
+		}
+	}
+}
+
+func TestMemoryReassignReviewer(t *testing.T) {
+	svc, r, _ := newMemoryService(t, team("backend", "author", "a", "b", "c"))
+	ctx := context.Background()
+	pr := createPR(t, svc, "pr1", "author") // a, b
+
+	stale := pr.Version
+	pr, newReviewer, err := svc.ReassignReviewer(ctx, "pr1", "a", "a в отпуске", &stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newReviewer != "c" || !slices.Equal(pr.AssignedReviewers, []string{"b", "c"}) {
+		t.Errorf("a должен смениться на c, получили %s и %v", newReviewer, pr.AssignedReviewers)
+	}
+	if pr.Version != stale+1 {
+		t.Errorf("версия должна вырасти до %d, получили %d", stale+1, pr.Version)
+	}
+	details, err := svc.GetPullRequest(ctx, "pr1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := details.History[len(details.History)-1]; last.EventType != models.EventReassigned || last.Reason != "a в отпуске" {
+		t.Errorf("причина замены должна попасть в историю, получили %+v", last)
+	}
+	events, err := r.PendingOutbox(ctx, 100, service.MaxOutboxAttempts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := events[len(events)-1]; last.UserID != "c" || last.Details["reason"] != "a в отпуске" {
+		t.Errorf("причина замены должна попасть в уведомление новому ревьюверу, получили %+v", last)
+	}
+
+	if _, _, err := svc.ReassignReviewer(ctx, "pr1", "b", "", &stale); !errors.Is(err, service.ErrVersionConflict) {
+		t.Errorf("устаревшая версия: ожидалась ErrVersionConflict, получили %v", err)
+	}
+	if _, _, err := svc.ReassignReviewer(ctx, "pr1", "a", "", nil); !errors.Is(err, service.ErrNotAssigned) {
+		t.Errorf("снятый ревьювер: ожидалась ErrNotAssigned, получили %v", err)
+	}
+	if _, err := svc.SetUserActive(ctx, "a", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := svc.ReassignReviewer(ctx, "pr1", "b", "", nil); !errors.Is(err, service.ErrNoCandidate) {
+		t.Errorf("в команде не осталось кандидатов: ожидалась ErrNoCandidate, получили %v", err)
+	}
+}
+
+func TestMemoryAssignmentReasons(t *testing.T) {
+	svc, _, _ := newMemoryService(t, team("backend", "author", "a", "b", "o"), team("frontend", "f"))
+	ctx := context.Background()
+	setPolicy(t, svc, models.TeamPolicy{TeamName: "backend", AssignmentStrategy: models.StrategyLeastLoaded})
+	if _, err := svc.SetOwnershipRules(ctx, "repo", []models.OwnershipRule{
+		{Pattern: "util/*", UserID: "o"},
+		{Pattern: "web/", TeamName: "frontend"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	pr, err := svc.CreatePullRequest(ctx, models.PR{
+		ID: "pr1", Name: "pr1", AuthorID: "author",
+		Repository: "repo", ChangedPaths: []string{"util/strings.go", "web/app.js"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"f": "codeowner match web/ via team frontend",
+		"o": "codeowner match util/*",
+	}
+	if !reflect.DeepEqual(pr.AssignmentReasons, want) {
+		t.Errorf("ожидались причины %v, получили %v", want, pr.AssignmentReasons)
+	}
+
+	pr = createPR(t, svc, "pr2", "author")
+	for _, uid := range pr.AssignedReviewers {
+		if got := pr.AssignmentReasons[uid]; got != "least-loaded in team backend" {
+			t.Errorf("%s: ожидалась причина least-loaded, получили %q", uid, got)
+		}
+	}
+
+	pr, newReviewer, err := svc.ReassignReviewer(ctx, "pr2", pr.AssignedReviewers[0], "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pr.AssignmentReasons) != 1 || !strings.HasPrefix(pr.AssignmentReasons[newReviewer], "weighted random pick in team backend, replacing ") {
+		t.Errorf("ожидалась причина замены для %s, получили %v", newReviewer, pr.AssignmentReasons)
+	}
+}
+
+// TestMemoryPRTimelineLongAudit — таймлайн читает журнал аудита PR целиком,
+// даже если в нем больше записей, чем помещается на страницу.
+func TestMemoryPRTimelineLongAudit(t *testing.T) {
+	svc, _, clk := newMemoryService(t, team("backend", "author", "a", "b", "c"))
+	ctx := context.Background()
+	createPR(t, svc, "pr1", "author") // a, b
+
+	clk.advance(time.Minute)
+	if _, err := svc.ApprovePullRequest(ctx, "pr1", "a"); err != nil {
+		t.Fatal(err)
+	}
+	old := "b"
+	for range models.MaxPageLimit {
+		clk.advance(time.Second)
+		_, replacedBy, err := svc.ReassignReviewer(ctx, "pr1", old, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		old = replacedBy
+	}
+
+	timeline, err := svc.GetPRTimeline(ctx, "pr1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	approved := 0
+	for _, e := range timeline.Timeline {
+		if e.Type == models.TimelineApproved {
+			approved++
+		}
+	}
+	if approved != 1 {
+		t.Errorf("одобрение из начала журнала должно попасть в таймлайн, получили %d", approved)
+	}
+}
+
+func TestMemoryPRTimeline(t *testing.T) {
+	svc, _, clk := newMemoryService(t, team("backend", "author", "a", "b", "c"))
+	ctx := context.Background()
+	createPR(t, svc, "pr1", "author") // a, b
+
+	clk.advance(time.Minute)
+	if _, _, err := svc.ReassignReviewer(ctx, "pr1", "a", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	clk.advance(time.Minute)
+	if _, err := svc.ApprovePullRequest(ctx, "pr1", "b"); err != nil {
+		t.Fatal(err)
+	}
+	clk.advance(time.Minute)
+	if _, err := svc.MergePullRequest(ctx, "pr1", nil, false); err != nil {
+		t.Fatal(err)
+	}
+
+	timeline, err := svc.GetPRTimeline(ctx, "pr1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for _, e := range timeline.Timeline {
+		types = append(types, e.Type)
+	}
+	want := []string{
+		models.TimelineCreated, models.EventAssigned, models.EventAssigned,
+		models.EventReassigned, models.TimelineApproved, models.TimelineMerged,
+	}
+	if !slices.Equal(types, want) {
+		t.Fatalf("ожидались шаги %v, получили %v", want, types)
+	}
+	if e := timeline.Timeline[3]; e.UserID != "a" || e.NewUserID != "c" {
+		t.Errorf("замена a на c: получили %+v", e)
+	}
+	if e := timeline.Timeline[4]; e.Actor != "b" {
+		t.Errorf("одобрение должно быть от b, получили %+v", e)
+	}
+	if timeline.Status != "MERGED" {
+		t.Errorf("ожидался статус MERGED, получили %s", timeline.Status)
+	}
+
+	if _, err := svc.GetPRTimeline(ctx, "missing"); !errors.Is(err, service.ErrPRNotFound) {
+		t.Errorf("ожидалась ErrPRNotFound, получили %v", err)
+	}
+}
+
+func TestMemoryDeclineReviewWithoutReplacement(t *testing.T) {
+	svc, r, _ := newMemoryService(t, team("backend", "author", "a", "b"))
+	ctx := context.Background()
+	createPR(t, svc, "pr1", "author") // a, b
+
+	pr, newReviewer, err := svc.DeclineReview(ctx, "pr1", "a", "занят")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newReviewer != "" || !slices.Equal(pr.AssignedReviewers, []string{"b"}) {
+		t.Errorf("a должен сняться без замены, получили %q и %v", newReviewer, pr.AssignedReviewers)
+	}
+
+	events, err := r.PendingOutbox(ctx, 100, service.MaxOutboxAttempts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	want := []string{models.DomainTeamCreated, models.DomainPRCreated, models.DomainReviewerAssigned}
+	if !slices.Equal(types, want) {
+		t.Errorf("отказ без замены не должен писать событий назначения, получили %v", types)
+	}
+}
+
+func TestMemoryDeactivateTeamReleasesReviewers(t *testing.T) {
+	svc, _, _ := newMemoryService(t, team("backend", "author", "a"), team("platform", "p1", "p2"))
+	ctx := context.Background()
+	fallback := true
+	setPolicy(t, svc, models.TeamPolicy{TeamName: "backend", CrossTeamFallback: &fallback})
+	createPR(t, svc, "pr1", "author") // a, p1
+
+	deactivated, reassignments, err := svc.DeactivateTeam(ctx, "platform")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(deactivated, []string{"p1", "p2"}) {
+		t.Errorf("ожидалась деактивация p1 и p2, получили %v", deactivated)
+	}
+	if len(reassignments) != 1 || reassignments[0]["old"] != "p1" || reassignments[0]["new"] != "" {
+		t.Errorf("p1 должен сняться без замены: в команде нет активных, получили %v", reassignments)
+	}
+
+	pr, err := svc.MergePullRequest(ctx, "pr1", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(pr.AssignedReviewers, []string{"a"}) {
+		t.Errorf("на PR должен остаться только a, получили %v", pr.AssignedReviewers)
+	}
+}
+
+func TestMemoryPreviewTeamDeactivationChangesNothing(t *testing.T) {
+	svc, r, _ := newMemoryService(t, team("backend", "author", "a", "b", "c"))
+	ctx := context.Background()
+	created := createPR(t, svc, "pr1", "author") // a, b
+	outboxBefore, err := r.PendingOutbox(ctx, 100, service.MaxOutboxAttempts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deactivated, preview, err := svc.PreviewTeamDeactivation(ctx, "backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(deactivated, []string{"a", "author", "b", "c"}) {
+		t.Errorf("в прогнозе должны быть все участники, получили %v", deactivated)
+	}
+	if len(preview) != 2 {
+		t.Errorf("ожидались 2 переназначения в прогнозе, получили %v", preview)
+	}
+
+	pr, err := r.GetPR(ctx, "pr1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.Version != created.Version || !slices.Equal(pr.AssignedReviewers, created.AssignedReviewers) {
+		t.Errorf("прогноз не должен менять PR: было %+v, стало %+v", created, pr)
+	}
+	user, err := r.GetUser(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.IsActive {
+		t.Error("прогноз не должен деактивировать пользователей")
+	}
+	outboxAfter, err := r.PendingOutbox(ctx, 100, service.MaxOutboxAttempts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outboxAfter) != len(outboxBefore) {
+		t.Errorf("прогноз не должен писать события: было %d, стало %d", len(outboxBefore), len(outboxAfter))
+	}
+
+	_, actual, err := svc.DeactivateTeam(ctx, "backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, preview) {
+		t.Errorf("при том же выборе прогноз должен совпасть с деактивацией: %v и %v", preview, actual)
+	}
+}
+
+func TestMemoryDeleteUserReassignsAndArchives(t *testing.T) {
+	svc, _, _ := newMemoryService(t, team("backend", "author", "bob", "carol"))
+	ctx := context.Background()
+	count := 1
+	setPolicy(t, svc, models.TeamPolicy{TeamName: "backend", ReviewerCount: &count})
+
+	createPR(t, svc, "pr1", "author") // bob
+	if _, err := svc.MergePullRequest(ctx, "pr1", nil, false); err != nil {
+		t.Fatal(err)
+	}
+	createPR(t, svc, "pr2", "author") // bob
+
+	result, err := svc.DeleteUser(ctx, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Reassignments) != 1 || result.Reassignments[0]["new"] != "carol" {
+		t.Errorf("открытое ревью bob должно перейти к carol, получили %v", result.Reassignments)
+	}
+	if result.ArchivedAssignments != 1 {
+		t.Errorf("назначение на смерженном PR должно уйти в архив, получили %d", result.ArchivedAssignments)
+	}
+
+	if _, err := svc.DeleteUser(ctx, "bob"); !errors.Is(err, service.ErrUserNotFound) {
+		t.Errorf("повторное удаление: ожидалась ErrUserNotFound, получили %v", err)
+	}
+	team, err := svc.GetTeam(ctx, "backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(team.Members) != 2 {
+		t.Errorf("удаленный пользователь не должен быть в команде, получили %v", team.Members)
+	}
+}
+
+func TestMemoryCloseAndMergeRules(t *testing.T) {
+	svc, _, _ := newMemoryService(t, team("backend", "author", "a", "b"))
+	ctx := context.Background()
+	required := 1
+	setPolicy(t, svc, models.TeamPolicy{TeamName: "backend", RequireApprovals: true, RequiredApprovals: &required})
+
+	createPR(t, svc, "pr1", "author")
+	if _, err := svc.MergePullRequest(ctx, "pr1", nil, false); !errors.Is(err, service.ErrNotApproved) {
+		t.Errorf("без одобрений: ожидалась ErrNotApproved, получили %v", err)
+	}
+	if _, err := svc.ApprovePullRequest(ctx, "pr1", "a"); err != nil {
+		t.Fatal(err)
+	}
+	pr, err := svc.MergePullRequest(ctx, "pr1", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.Status != "MERGED" || pr.MergedAt == nil {
+		t.Errorf("PR должен быть смержен, получили %+v", pr)
+	}
+	if _, err := svc.ClosePullRequest(ctx, "pr1", nil); !errors.Is(err, service.ErrPRMerged) {
+		t.Errorf("закрытие смерженного: ожидалась ErrPRMerged, получили %v", err)
+	}
+
+	createPR(t, svc, "pr2", "author")
+	pr, err = svc.ClosePullRequest(ctx, "pr2", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.Status != "CLOSED" || len(pr.AssignedReviewers) != 0 {
+		t.Errorf("закрытый PR должен освободить ревьюверов, получили %+v", pr)
+	}
+	if _, _, err := svc.ReassignReviewer(ctx, "pr2", "a", "", nil); !errors.Is(err, service.ErrPRClosed) {
+		t.Errorf("переназначение на закрытом PR: ожидалась ErrPRClosed, получили %v", err)
+	}
+}
+
+// TestMemoryDeletedAuthorKeepsTeamPolicy — удаление автора не снимает с его PR
+// порог одобрений команды.
+func TestMemoryDeletedAuthorKeepsTeamPolicy(t *testing.T) {
+	svc, _, _ := newMemoryService(t, team("backend", "author", "a", "b"))
+	ctx := context.Background()
+	required := 1
+	setPolicy(t, svc, models.TeamPolicy{TeamName: "backend", RequireApprovals: true, RequiredApprovals: &required})
+
+	createPR(t, svc, "pr1", "author")
+	if _, err := svc.DeleteUser(ctx, "author"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.MergePullRequest(ctx, "pr1", nil, false); !errors.Is(err, service.ErrNotApproved) {
+		t.Errorf("PR удаленного автора без одобрений: ожидалась ErrNotApproved, получили %v", err)
+	}
+}
+
+func TestMemoryPRRequiredApprovals(t *testing.T) {
+	svc, _, _ := newMemoryService(t, team("backend", "author", "a", "b"))
+	ctx := context.Background()
+	one, two, five, none := 1, 2, 5, 0
+	setPolicy(t, svc, models.TeamPolicy{TeamName: "backend", RequireApprovals: true, RequiredApprovals: &one})
+
+	if _, err := svc.CreatePullRequest(ctx, models.PR{ID: "pr1", Name: "pr1", AuthorID: "author", RequiredApprovals: &two}); err != nil {
+		t.Fatal(err)
+	}
+	details, err := svc.GetPullRequest(ctx, "pr1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (models.ApprovalStatus{Required: true, RequiredCount: 2}); details.Approval != want {
+		t.Errorf("порог PR повышает политику команды: ожидалось %+v, получили %+v", want, details.Approval)
+	}
+
+	_, err = svc.MergePullRequest(ctx, "pr1", nil, false)
+	var notApproved *service.NotApprovedError
+	if !errors.As(err, &notApproved) || !errors.Is(err, service.ErrNotApproved) {
+		t.Fatalf("ожидалась NotApprovedError, получили %v", err)
+	}
+	if !slices.Equal(notApproved.Missing, []string{"a", "b"}) {
+		t.Errorf("ожидались недостающие одобрения a и b, получили %v", notApproved.Missing)
+	}
+	pr, err := svc.MergePullRequest(ctx, "pr1", nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.Status != models.StatusMerged {
+		t.Errorf("force должен смержить PR без одобрений, получили %+v", pr)
+	}
+	entries, _, err := svc.ListAudit(ctx, models.AuditFilter{PRID: "pr1", Action: models.AuditPRMerged}, models.Page{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Details["forced"] != true {
+		t.Errorf("принудительный merge должен попасть в аудит с forced, получили %+v", entries)
+	}
+
+	if _, err := svc.CreatePullRequest(ctx, models.PR{ID: "pr2", Name: "pr2", AuthorID: "author", RequiredApprovals: &none}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.MergePullRequest(ctx, "pr2", nil, false); !errors.Is(err, service.ErrNotApproved) {
+		t.Errorf("порог 0 не снимает требование политики команды, получили %v", err)
+	}
+	if _, err := svc.ApprovePullRequest(ctx, "pr2", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.MergePullRequest(ctx, "pr2", nil, false); err != nil {
+		t.Errorf("одного одобрения по политике достаточно: %v", err)
+	}
+
+	if _, err := svc.CreatePullRequest(ctx, models.PR{ID: "pr3", Name: "pr3", AuthorID: "author", RequiredApprovals: &five}); err != nil {
+		t.Fatal(err)
+	}
+	details, err = svc.GetPullRequest(ctx, "pr3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if details.Approval.RequiredCount != 2 {
+		t.Errorf("порог PR ограничен числом ревьюверов (2), получили %+v", details.Approval)
+	}
+}
+
+func TestMemoryPRStatusTransitions(t *testing.T) {
+	svc, _, _ := newMemoryService(t, team("backend", "author", "a", "b", "c"))
+	ctx := context.Background()
+
+	pr1 := createPR(t, svc, "pr1", "author")
+	pr, err := svc.SetPullRequestStatus(ctx, "pr1", models.StatusInReview, &pr1.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.Status != models.StatusInReview || pr.Version != pr1.Version+1 {
+		t.Errorf("ожидался IN_REVIEW со следующей версией, получили %+v", pr)
+	}
+	if _, err := svc.SetPullRequestStatus(ctx, "pr1", models.StatusChangesRequested, &pr1.Version); !errors.Is(err, service.ErrVersionConflict) {
+		t.Errorf("устаревшая версия: ожидалась ErrVersionConflict, получили %v", err)
+	}
+	if _, err := svc.SetPullRequestStatus(ctx, "pr1", models.StatusMerged, nil); !errors.Is(err, service.ErrInvalidStatus) {
+		t.Errorf("MERGED задается только merge: ожидалась ErrInvalidStatus, получили %v", err)
+	}
+	if _, err := svc.SetPullRequestStatus(ctx, "pr1", models.StatusChangesRequested, nil); err != nil {
+		t.Fatal(err)
+	}
+	_, newReviewer, err := svc.ReassignReviewer(ctx, "pr1", pr1.AssignedReviewers[0], "", nil)
+	if err != nil {
+		t.Fatalf("CHANGES_REQUESTED — открытое состояние, замена должна пройти: %v", err)
+	}
+
+	createPR(t, svc, "pr2", "author")
+	createPR(t, svc, "pr3", "author")
+	if _, err := svc.SetPullRequestStatus(ctx, "pr2", models.StatusInReview, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.MergePullRequest(ctx, "pr2", nil, false); err != nil {
+		t.Fatalf("merge из IN_REVIEW: %v", err)
+	}
+	if _, err := svc.SetPullRequestStatus(ctx, "pr2", models.StatusOpen, nil); !errors.Is(err, service.ErrPRMerged) {
+		t.Errorf("смерженный PR: ожидалась ErrPRMerged, получили %v", err)
+	}
+
+	stats, err := svc.GetStats(ctx, models.StatsFilter{}, models.Page{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[models.PRStatus]int{
+		models.StatusOpen: 1, models.StatusInReview: 0, models.StatusChangesRequested: 1,
+		models.StatusMerged: 1, models.StatusClosed: 0,
+	}
+	if !maps.Equal(stats.PRsByStatus, want) || stats.OpenPRs != 2 {
+		t.Errorf("ожидались счетчики %v и 2 открытых PR, получили %v и %d", want, stats.PRsByStatus, stats.OpenPRs)
+	}
+
+	reviews, _, err := svc.GetUserReviews(ctx, newReviewer, models.ReviewFilter{Status: models.StatusChangesRequested},
+		models.Page{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reviews) != 1 || reviews[0].ID != "pr1" {
+		t.Errorf("фильтр по CHANGES_REQUESTED: ожидался pr1, получили %+v", reviews)
+	}
+}
+
+func TestMemoryGetPullRequest(t *testing.T) {
+	svc, _, _ := newMemoryService(t, team("backend", "author", "a", "b", "c"))
+	ctx := context.Background()
+	setPolicy(t, svc, models.TeamPolicy{TeamName: "backend", RequireApprovals: true})
+
+	createPR(t, svc, "pr1", "author") // a, b
+	if _, _, err := svc.ReassignReviewer(ctx, "pr1", "a", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.ApprovePullRequest(ctx, "pr1", "b"); err != nil {
+		t.Fatal(err)
+	}
+
+	pr, err := svc.GetPullRequest(ctx, "pr1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(pr.AssignedReviewers, []string{"b", "c"}) || pr.CreatedAt == nil {
+		t.Errorf("ожидались ревьюверы [b c] и время создания, получили %+v", pr.PR)
+	}
+	want := models.ApprovalStatus{Required: true, RequiredCount: 2, Approved: 1}
+	if pr.Approval != want {
+		t.Errorf("ожидался статус одобрений %+v, получили %+v", want, pr.Approval)
+	}
+
+	var history []string
+	for _, ev := range pr.History {
+		history = append(history, ev.EventType+":"+ev.UserID+">"+ev.NewUserID)
+	}
+	if want := []string{"ASSIGNED:>a", "ASSIGNED:>b", "REASSIGNED:a>c"}; !slices.Equal(history, want) {
+		t.Errorf("ожидалась история %v, получили %v", want, history)
+	}
+
+	if _, err := svc.GetPullRequest(ctx, "missing"); !errors.Is(err, service.ErrPRNotFound) {
+		t.Errorf("неизвестный PR: ожидалась ErrPRNotFound, получили %v", err)
+	}
+}
+
+func TestMemoryGetTeamStats(t *testing.T) {
+	svc, _, clk := newMemoryService(t, team("backend", "author", "a", "b", "c"), team("frontend", "f1", "f2"))
+	ctx := context.Background()
+
+	createPR(t, svc, "pr1", "author")
+	pr2 := createPR(t, svc, "pr2", "author")
+	createPR(t, svc, "pr3", "f1")
+	clk.advance(time.Hour)
+	if _, err := svc.MergePullRequest(ctx, "pr1", nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := svc.ReassignReviewer(ctx, "pr2", pr2.AssignedReviewers[0], "", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := svc.GetTeamStats(ctx, "backend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.OpenPRs != 1 || stats.MergedPRs != 1 {
+		t.Errorf("ожидались 1 открытый и 1 смерженный PR, получили %d и %d", stats.OpenPRs, stats.MergedPRs)
+	}
+	if stats.AvgTimeToMergeSeconds == nil || *stats.AvgTimeToMergeSeconds != time.Hour.Seconds() {
+		t.Errorf("среднее время до merge должно быть 3600 секунд, получили %v", stats.AvgTimeToMergeSeconds)
+	}
+	if stats.Assignments != 4 || stats.Reassignments != 1 || stats.ReassignmentRate != 0.25 {
+		t.Errorf("ожидались 4 назначения, 1 замена и доля 0.25, получили %d, %d, %v",
+			stats.Assignments, stats.Reassignments, stats.ReassignmentRate)
+	}
+
+	var total, open int
+	for _, m := range stats.AssignmentsByMember {
+		total += m.Total
+		open += m.Open
+	}
+	if len(stats.AssignmentsByMember) != 4 || total != 4 || open != 2 {
+		t.Errorf("ожидались 4 участника с 4 назначениями, 2 из них на открытых PR, получили %+v",
+			stats.AssignmentsByMember)
+	}
+	for i := 1; i < len(stats.AssignmentsByMember); i++ {
+		if stats.AssignmentsByMember[i-1].Total < stats.AssignmentsByMember[i].Total {
+			t.Errorf("самые загруженные участники должны идти первыми: %+v", stats.AssignmentsByMember)
+		}
+	}
+
+	if _, err := svc.GetTeamStats(ctx, "missing"); !errors.Is(err, service.ErrTeamNotFound) {
+		t.Errorf("неизвестная команда: ожидалась ErrTeamNotFound, получили %v", err)
+	}
+}
+
+func TestMemorySLAStats(t *testing.T) {
+	svc, _, clk := newMemoryService(t, team("backend", "author", "a", "b"), team("platform", "p1"))
+	ctx := context.Background()
+	createPR(t, svc, "pr1", "author") // a, b
+
+	clk.advance(2 * time.Hour)
+	if _, err := svc.ApprovePullRequest(ctx, "pr1", "a"); err != nil {
+		t.Fatal(err)
+	}
+	clk.advance(30 * time.Hour) // b так и не отреагировал на pr1
+	createPR(t, svc, "pr2", "author")
+	if _, _, err := svc.DeclineReview(ctx, "pr2", "b", "занят"); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := svc.GetSLAStats(ctx, models.SLAFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.WindowSeconds != 24*3600 {
+		t.Errorf("ожидалось окно по умолчанию 24h, получили %d", stats.WindowSeconds)
+	}
+	// pr2 у a еще в пределах окна и не учитывается.
+	want := map[string][3]int{"a": {1, 1, 1}, "b": {2, 1, 1}}
+	if len(stats.Users) != len(want) {
+		t.Fatalf("ожидались пользователи a и b, получили %+v", stats.Users)
+	}
+	for _, u := range stats.Users {
+		if got := [3]int{u.Due, u.Responded, u.WithinSLA}; got != want[u.UserID] {
+			t.Errorf("%s: ожидались due/responded/within %v, получили %v", u.UserID, want[u.UserID], got)
+		}
+	}
+	if len(stats.Teams) != 1 || stats.Teams[0].TeamName != "backend" || stats.Teams[0].Due != 3 {
+		t.Fatalf("ожидалась одна команда backend с тремя назначениями, получили %+v", stats.Teams)
+	}
+	if p := stats.Overall.CompliancePercent; p == nil || math.Abs(*p-200.0/3) > 1e-9 {
+		t.Errorf("ожидалось соблюдение 66.7%%, получили %v", p)
+	}
+	if avg := stats.Overall.AvgResponseSeconds; avg == nil || *avg != 3600 {
+		t.Errorf("ожидалось среднее время реакции 3600s, получили %v", avg)
+	}
+
+	stats, err = svc.GetSLAStats(ctx, models.SLAFilter{TeamName: "platform", Window: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Users) != 0 || stats.Overall.CompliancePercent != nil {
+		t.Errorf("у platform нет назначений, получили %+v", stats)
+	}
+	if _, err := svc.GetSLAStats(ctx, models.SLAFilter{TeamName: "missing"}); !errors.Is(err, service.ErrTeamNotFound) {
+		t.Errorf("ожидалась ErrTeamNotFound, получили %v", err)
+	}
+}
+
+func TestMemoryStatsMergeDurations(t *testing.T) {
+	svc, _, clk := newMemoryService(t, team("backend", "author", "a", "b", "c"))
+	ctx := context.Background()
+
+	stats, err := svc.GetStats(ctx, models.StatsFilter{}, models.Page{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.TimeToMerge.AvgSeconds != nil || stats.AssignmentToMerge.P90Seconds != nil {
+		t.Errorf("без смерженных PR длительности должны быть пустыми, получили %+v", stats.TimeToMerge)
+	}
+
+	pr1 := createPR(t, svc, "pr1", "author")
+	createPR(t, svc, "pr2", "author")
+	clk.advance(time.Hour)
+	if _, _, err := svc.ReassignReviewer(ctx, "pr1", pr1.AssignedReviewers[0], "", nil); err != nil {
+		t.Fatal(err)
+	}
+	clk.advance(time.Hour)
+	if _, err := svc.MergePullRequest(ctx, "pr1", nil, false); err != nil {
+		t.Fatal(err)
+	}
+	clk.advance(4 * time.Hour)
+	if _, err := svc.MergePullRequest(ctx, "pr2", nil, false); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err = svc.GetStats(ctx, models.StatsFilter{}, models.Page{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		got  *float64
+		want float64
+	}{
+		// PR смержены через 2ч и 6ч.
+		{"time_to_merge.avg", stats.TimeToMerge.AvgSeconds, 14400},
+		{"time_to_merge.median", stats.TimeToMerge.MedianSeconds, 14400},
+		{"time_to_merge.p90", stats.TimeToMerge.P90Seconds, 20160},
+		// Ревьюверы: 1ч (замена в pr1), 2ч (pr1), 6ч и 6ч (pr2).
+		{"assignment_to_merge.avg", stats.AssignmentToMerge.AvgSeconds, 13500},
+		{"assignment_to_merge.median", stats.AssignmentToMerge.MedianSeconds, 14400},
+		{"assignment_to_merge.p90", stats.AssignmentToMerge.P90Seconds, 21600},
+	}
+	for _, tt := range tests {
+		if tt.got == nil || math.Abs(*tt.got-tt.want) > 1e-6 {
+			t.Errorf("%s: ожидалось %v, получили %v", tt.name, tt.want, tt.got)
+		}
+	}
+}
+
+func TestMemoryStatsTimeRange(t *testing.T) {
+	svc, _, clk := newMemoryService(t, team("backend", "author", "a", "b"))
+	ctx := context.Background()
+
+	createPR(t, svc, "pr1", "author")
+	clk.advance(24 * time.Hour)
+	createPR(t, svc, "pr2", "author")
+	if _, err := svc.MergePullRequest(ctx, "pr1", nil, false); err != nil {
+		t.Fatal(err)
+	}
+
+	from := clk.now().Add(-time.Hour)
+	stats, err := svc.GetStats(ctx, models.StatsFilter{From: &from}, models.Page{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.TotalPRs != 1 || stats.OpenPRs != 1 || stats.MergedPRs != 0 {
+		t.Errorf("в периоде создан только открытый pr2, получили %d/%d/%d",
+			stats.TotalPRs, stats.OpenPRs, stats.MergedPRs)
+	}
+	if len(stats.ReviewersByPR) != 1 || stats.ReviewersByPR[0].PRID != "pr2" {
+		t.Errorf("в рейтинге PR должен остаться только pr2, получили %+v", stats.ReviewersByPR)
+	}
+	for _, ua := range stats.AssignmentsByUser {
+		if ua.Assignments > 1 {
+			t.Errorf("назначения на pr1 вне периода не должны учитываться: %+v", ua)
+		}
+	}
+	// pr1 создан до периода, но смержен в нем.
+	if stats.TimeToMerge.AvgSeconds == nil || *stats.TimeToMerge.AvgSeconds != (24*time.Hour).Seconds() {
+		t.Errorf("время до merge pr1 должно учитываться по merged_at, получили %v", stats.TimeToMerge.AvgSeconds)
+	}
+
+	_, err = svc.GetStats(ctx, models.StatsFilter{From: &from, To: &from}, models.Page{Limit: 10})
+	if !errors.Is(err, service.ErrInvalidStatsRange) {
+		t.Errorf("пустой период: ожидалась ErrInvalidStatsRange, получили %v", err)
+	}
+}
+
+func TestMemoryArchiveMergedPRs(t *testing.T) {
+	_, r, clk := newMemoryService(t, team("backend", "author", "a", "b", "c"))
+	svc := service.New(r, service.WithRandomizer(firstRand{}), service.WithArchiveAfter(24*time.Hour),
+		service.WithClock(clk.now))
+	ctx := context.Background()
+
+	createPR(t, svc, "pr1", "author") // a, b
+	if _, err := svc.ApprovePullRequest(ctx, "pr1", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.MergePullRequest(ctx, "pr1", nil, false); err != nil {
+		t.Fatal(err)
+	}
+	clk.advance(24*time.Hour - time.Second)
+	createPR(t, svc, "pr2", "author")
+	if _, err := svc.MergePullRequest(ctx, "pr2", nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := svc.ArchiveMergedPRs(ctx); err != nil || n != 0 {
+		t.Fatalf("до порога ничего не переносится, получили %d, %v", n, err)
+	}
+
+	clk.advance(2 * time.Second)
+	n, err := svc.ArchiveMergedPRs(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("ожидался перенос одного PR, получили %d, %v", n, err)
+	}
+
+	if _, err := svc.GetPullRequest(ctx, "pr1"); !errors.Is(err, service.ErrPRNotFound) {
+		t.Errorf("архивный PR: ожидалась ErrPRNotFound, получили %v", err)
+	}
+	if _, err := svc.GetPullRequest(ctx, "pr2"); err != nil {
+		t.Errorf("свежий смерженный PR не должен переноситься: %v", err)
+	}
+
+	pr, err := svc.GetArchivedPR(ctx, "pr1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.Status != "MERGED" || !slices.Equal(pr.AssignedReviewers, []string{"a", "b"}) ||
+		!slices.Equal(pr.ApprovedBy, []string{"a"}) || pr.ArchivedAt == "" {
+		t.Errorf("в архиве ожидался смерженный PR с ревьюверами и одобрениями, получили %+v", pr)
+	}
+	if _, err := svc.GetArchivedPR(ctx, "pr2"); !errors.Is(err, service.ErrPRNotFound) {
+		t.Errorf("PR не из архива: ожидалась ErrPRNotFound, получили %v", err)
+	}
+
+	_, err = svc.CreatePullRequest(ctx, models.PR{ID: "pr1", Name: "pr1", AuthorID: "author"})
+	if !errors.Is(err, service.ErrPRExists) {
+		t.Errorf("ID из архива: ожидалась ErrPRExists, получили %v", err)
+	}
+}
+
+// racingRepo выполняет race перед записью merge, имитируя параллельный запрос
+// между проверками сервиса и записью в репозиторий.
+type racingRepo struct {
+	*memory.Repository
+	race func()
+}
+
+func (r racingRepo) MergePR(ctx context.Context, prID string, expectedVersion *int) error {
+	if r.race != nil {
+		r.race()
+	}
+	return r.Repository.MergePR(ctx, prID, expectedVersion)
+}
+
+func TestMemoryStaleWriteConflict(t *testing.T) {
+	other, r, _ := newMemoryService(t, team("backend", "author", "a", "b", "c"))
+	ctx := context.Background()
+	pr := createPR(t, other, "pr1", "author")
+
+	racing := racingRepo{Repository: r}
+	racing.race = func() {
+		racing.race = nil
+		if _, _, err := other.ReassignReviewer(ctx, "pr1", pr.AssignedReviewers[0], "", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	svc := service.New(&racing, service.WithRandomizer(firstRand{}))
+
+	if _, err := svc.MergePullRequest(ctx, "pr1", nil, false); !errors.Is(err, service.ErrConflictRetry) {
+		t.Fatalf("merge после параллельной замены: ожидалась ErrConflictRetry, получили %v", err)
+	}
+	merged, err := svc.MergePullRequest(ctx, "pr1", nil, false)
+	if err != nil || merged.Status != "MERGED" {
+		t.Fatalf("повторный merge должен пройти, получили %+v, %v", merged, err)
+	}
+
+	if err := r.ApprovePR(ctx, "pr1", "c", pr.Version); !errors.Is(err, repo.ErrVersionConflict) {
+		t.Errorf("одобрение по устаревшей версии: ожидалась ErrVersionConflict, получили %v", err)
+	}
+}
+
+func TestMemoryConcurrentReassignSameReviewer(t *testing.T) {
+	svc, _, _ := newMemoryService(t, team("backend", "author", "a", "b", "c", "d", "e", "f"))
+	ctx := context.Background()
+	pr := createPR(t, svc, "pr1", "author")
+	old := pr.AssignedReviewers[0]
+
+	const workers = 8
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, errs[i] = svc.ReassignReviewer(ctx, "pr1", old, "", nil)
+		}()
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, service.ErrNotAssigned):
+			t.Errorf("ожидалась ErrNotAssigned для опоздавших замен, получили %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("ровно одна замена должна пройти, прошло %d", succeeded)
+	}
+
+	got, err := svc.GetPullRequest(ctx, "pr1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.AssignedReviewers) != 2 || slices.Contains(got.AssignedReviewers, old) || got.Version != pr.Version+1 {
+		t.Errorf("ожидались два ревьювера без %s и одно увеличение версии, получили %+v", old, got.PR)
+	}
+	reassigned := 0
+	for _, ev := range got.History {
+		if ev.EventType == models.EventReassigned {
+			reassigned++
+		}
+	}
+	if reassigned != 1 {
+		t.Errorf("в истории должно быть одно событие REASSIGNED, получили %d", reassigned)
+	}
+}
+
+func TestMemoryAssignmentSeedIsDeterministic(t *testing.T) {
+	members := []string{"author", "a", "b", "c", "d", "e", "f", "g", "h"}
+	assign := func(seed int64) []string {
+		r := memory.New()
+		svc := service.New(r)
+		if err := svc.CreateTeam(context.Background(), team("backend", members...)); err != nil {
+			t.Fatal(err)
+		}
+		ctx := pkg.WithSeed(context.Background(), seed)
+		pr, err := svc.CreatePullRequest(ctx, models.PR{ID: "pr1", Name: "pr1", AuthorID: "author"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pr.AssignedReviewers
+	}
+
+	want := assign(42)
+	for i := 0; i < 5; i++ {
+		if got := assign(42); !slices.Equal(got, want) {
+			t.Fatalf("одинаковое зерно должно давать одинаковых ревьюверов: %v и %v", want, got)
+		}
+	}
+}
+
+func TestMemoryReassignOverdueReviews(t *testing.T) {
+	svc, _, clk := newMemoryService(t,
+		team("backend", "author", "a", "b", "c"), team("frontend", "fauthor", "f1", "f2", "f3"))
+	ctx := context.Background()
+	enabled := true
+	setPolicy(t, svc, models.TeamPolicy{TeamName: "backend", AutoReassign: &enabled})
+
+	clk.t = time.Now().Add(-service.DefaultAutoReassignAfter - time.Hour).Truncate(time.Second)
+	if pr := createPR(t, svc, "pr1", "author"); !slices.Equal(pr.AssignedReviewers, []string{"a", "b"}) {
+		t.Fatalf("ожидались ревьюверы [a b], получили %v", pr.AssignedReviewers)
+	}
+	createPR(t, svc, "pr2", "fauthor")
+	if _, err := svc.ApprovePullRequest(ctx, "pr1", "a"); err != nil {
+		t.Fatal(err)
+	}
+	clk.t = time.Now().Truncate(time.Second)
+
+	n, err := svc.ReassignOverdueReviews(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("ожидалась одна замена: a отреагировал, у frontend замена выключена; получили %d", n)
+	}
+
+	pr, err := svc.GetPullRequest(ctx, "pr1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(pr.AssignedReviewers, []string{"a", "c"}) {
+		t.Errorf("b должен быть заменен на c, получили %v", pr.AssignedReviewers)
+	}
+	last := pr.History[len(pr.History)-1]
+	if last.EventType != models.EventReassigned || last.UserID != "b" || last.Reason != "no response within 48h0m0s" {
+		t.Errorf("замена должна попасть в историю с причиной, получили %+v", last)
+	}
+
+	other, err := svc.GetPullRequest(ctx, "pr2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(other.AssignedReviewers, []string{"f1", "f2"}) {
+		t.Errorf("команда без auto_reassign не затрагивается, получили %v", other.AssignedReviewers)
+	}
+
+	if n, err := svc.ReassignOverdueReviews(ctx); err != nil || n != 0 {
+		t.Errorf("новое назначение еще не просрочено: получили %d, %v", n, err)
+	}
+}
+
+// TestMemoryReassignOverdueReviewsSkipsStuck — назначения без кандидатов на
+// замену не мешают заменить более новые просроченные назначения.
+func TestMemoryReassignOverdueReviewsSkipsStuck(t *testing.T) {
+	svc, _, clk := newMemoryService(t,
+		team("backend", "author", "a", "b"), team("frontend", "fauthor", "f1", "f2", "f3"))
+	enabled := true
+	setPolicy(t, svc, models.TeamPolicy{TeamName: "backend", AutoReassign: &enabled})
+	setPolicy(t, svc, models.TeamPolicy{TeamName: "frontend", AutoReassign: &enabled})
+
+	start := clk.now()
+	// В backend заменить некем: больше страницы застрявших назначений.
+	for i := range 60 {
+		createPR(t, svc, fmt.Sprintf("stuck%d", i), "author")
+	}
+	clk.advance(time.Minute)
+	createPR(t, svc, "fresh", "fauthor")
+	clk.t = start.Add(service.DefaultAutoReassignAfter + time.Hour)
+
+	n, err := svc.ReassignOverdueReviews(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("ожидались две замены в frontend, получили %d", n)
+	}
+}
+
+func TestMemoryAssignReviewer(t *testing.T) {
+	svc, _, _ := newMemoryService(t, team("backend", "author", "a", "b", "c", "idle"), team("frontend", "f1"))
+	ctx := context.Background()
+	if _, err := svc.SetUserActive(ctx, "idle", false); err != nil {
+		t.Fatal(err)
+	}
+	createPR(t, svc, "pr1", "author")
+
+	pr, err := svc.AssignReviewer(ctx, "pr1", "c", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(pr.AssignedReviewers, []string{"a", "b", "c"}) || pr.AssignmentReasons["c"] != "assigned by admin" {
+		t.Errorf("c должен добавиться к ревьюверам с причиной, получили %v %v", pr.AssignedReviewers, pr.AssignmentReasons)
+	}
+
+	for _, tc := range []struct {
+		user string
+		want error
+	}{
+		{"c", service.ErrAlreadyAssigned},
+		{"author", service.ErrAuthorIsReviewer},
+		{"idle", service.ErrIneligibleReviewer},
+		{"f1", service.ErrIneligibleReviewer},
+		{"missing", service.ErrUserNotFound},
+	} {
+		if _, err := svc.AssignReviewer(ctx, "pr1", tc.user, "", nil); !errors.Is(err, tc.want) {
+			t.Errorf("%s: ожидалась %v, получили %v", tc.user, tc.want, err)
+		}
+	}
+
+	details, err := svc.GetPullRequest(ctx, "pr1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := details.History[len(details.History)-1]
+	if last.EventType != models.EventAssigned || last.NewUserID != "c" || last.Reason != "assigned by admin" {
+		t.Errorf("назначение должно попасть в историю, получили %+v", last)
+	}
+
+	createPR(t, svc, "pr2", "author")
+	pr, err = svc.AssignReviewer(ctx, "pr2", "c", "security review", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.AssignmentReasons["c"] != "security review" {
+		t.Errorf("в ответе должна остаться причина из запроса, получили %v", pr.AssignmentReasons)
+	}
+}
+
+func TestMemoryUnassignReviewer(t *testing.T) {
+	svc, _, _ := newMemoryService(t, team("backend", "author", "a", "b"))
+	ctx := context.Background()
+	createPR(t, svc, "pr1", "author")
+
+	pr, err := svc.UnassignReviewer(ctx, "pr1", "a", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(pr.AssignedReviewers, []string{"b"}) {
+		t.Errorf("a должен быть снят без замены, получили %v", pr.AssignedReviewers)
+	}
+	if _, err := svc.UnassignReviewer(ctx, "pr1", "a", "", nil); !errors.Is(err, service.ErrNotAssigned) {
+		t.Errorf("повторное снятие: ожидалась ErrNotAssigned, получили %v", err)
+	}
+	// Последнего ревьювера тоже можно снять.
+	if pr, err := svc.UnassignReviewer(ctx, "pr1", "b", "left the project", nil); err != nil || len(pr.AssignedReviewers) != 0 {
+		t.Fatalf("ожидался PR без ревьюверов, получили %v, %v", pr, err)
+	}
+
+	details, err := svc.GetPullRequest(ctx, "pr1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := details.History[len(details.History)-1]
+	if last.EventType != models.EventUnassigned || last.UserID != "b" || last.Reason != "left the project" {
+		t.Errorf("снятие должно попасть в историю с причиной, получили %+v", last)
+	}
+
+	entries, _, err := svc.ListAudit(ctx, models.AuditFilter{PRID: "pr1", Action: models.AuditReviewerUnassigned}, models.Page{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("ожидались две записи аудита о снятии, получили %+v", entries)
+	}
+}
+
+func TestMemoryTopUpReviewers(t *testing.T) {
+	svc, _, _ := newMemoryService(t, team("backend", "author", "a", "b", "c"))
+	ctx := context.Background()
+	createPR(t, svc, "pr1", "author")
+
+	if _, err := svc.UnassignReviewer(ctx, "pr1", "a", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	pr, added, err := svc.TopUpReviewers(ctx, "pr1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 1 || len(pr.AssignedReviewers) != 2 || !slices.Contains(pr.AssignedReviewers, added[0]) {
+		t.Fatalf("ожидался добор одного ревьювера до двух, получили %v (добавлены %v)", pr.AssignedReviewers, added)
+	}
+	if pr.AssignmentReasons[added[0]] == "" {
+		t.Errorf("для добавленного ревьювера нет причины выбора: %v", pr.AssignmentReasons)
+	}
+
+	pr, added, err = svc.TopUpReviewers(ctx, "pr1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 0 || len(pr.AssignedReviewers) != 2 {
+		t.Errorf("укомплектованный PR не должен меняться, получили %v (добавлены %v)", pr.AssignedReviewers, added)
+	}
+
+	if _, _, err := svc.TopUpReviewers(ctx, "missing"); !errors.Is(err, service.ErrPRNotFound) {
+		t.Errorf("ожидалась ErrPRNotFound, получили %v", err)
+	}
+	if _, err := svc.MergePullRequest(ctx, "pr1", nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := svc.TopUpReviewers(ctx, "pr1"); !errors.Is(err, service.ErrPRMerged) {
+		t.Errorf("добор в смерженном PR: ожидалась ErrPRMerged, получили %v", err)
+	}
+}
+
+func TestMemoryTopUpSkipsManagerWhenFull(t *testing.T) {
+	backend := team("backend", "author", "a", "b")
+	backend.Members[0].ManagerID = "boss"
+	svc, _, _ := newMemoryService(t, team("management", "boss"), backend)
+	ctx := context.Background()
+	count, manager := 1, false
+	setPolicy(t, svc, models.TeamPolicy{TeamName: "backend", ReviewerCount: &count, RequireManager: &manager})
+	createPR(t, svc, "pr1", "author")
+
+	manager = true
+	setPolicy(t, svc, models.TeamPolicy{TeamName: "backend", ReviewerCount: &count, RequireManager: &manager})
+	pr, added, err := svc.TopUpReviewers(ctx, "pr1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 0 || len(pr.AssignedReviewers) != count {
+		t.Errorf("добор не должен превышать reviewer_count ради менеджера, получили %v (добавлены %v)", pr.AssignedReviewers, added)
+	}
+}
+
+func TestMemoryAddTeamMembersRebalances(t *testing.T) {
+	svc, _, clk := newMemoryService(t, team("backend", "author", "a"))
+	ctx := context.Background()
+	createPR(t, svc, "pr1", "author")
+	clk.advance(time.Minute)
+	createPR(t, svc, "pr2", "author")
+
+	added := team("backend", "b", "d", "c")
+	added.Members[2].IsActive = false
+	created, assignments, err := svc.AddTeamMembers(ctx, added)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.RebalanceAssignment{{PRID: "pr1", UserID: "b"}, {PRID: "pr2", UserID: "d"}}
+	if created || !slices.Equal(assignments, want) {
+		t.Fatalf("ожидалось распределение новичков %v, получили %v (created=%v)", want, assignments, created)
+	}
+	pr, err := svc.GetPullRequest(ctx, "pr1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(pr.AssignedReviewers, []string{"a", "b"}) {
+		t.Errorf("pr1 должен быть укомплектован новичком, получили %v", pr.AssignedReviewers)
+	}
+
+	// Повторная загрузка тех же участников ничего не назначает.
+	if _, assignments, err := svc.AddTeamMembers(ctx, added); err != nil || len(assignments) != 0 {
+		t.Errorf("ожидалось отсутствие назначений, получили %v, %v", assignments, err)
+	}
+
+	created, assignments, err = svc.AddTeamMembers(ctx, team("frontend", "f1"))
+	if err != nil || !created || len(assignments) != 0 {
+		t.Errorf("новая команда должна создаваться без назначений, получили %v, %v, %v", created, assignments, err)
+	}
+}
+
+func TestMemoryGetUserProfile(t *testing.T) {
+	svc, _, clk := newMemoryService(t, team("backend", "author", "a", "b"))
+	ctx := context.Background()
+	createPR(t, svc, "pr1", "author")
+	createPR(t, svc, "pr2", "author")
+	clk.advance(time.Hour)
+	if _, err := svc.ApprovePullRequest(ctx, "pr1", "a"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := svc.SetVacation(ctx, models.Vacation{UserID: "a", StartsAt: clk.now(), EndsAt: clk.now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := svc.GetUserProfile(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.TeamName != "backend" || p.OpenReviews != 2 || p.ApprovalsGiven != 1 {
+		t.Errorf("неверная нагрузка: %+v", p)
+	}
+	if p.AvgResponseSeconds == nil || *p.AvgResponseSeconds != 3600 {
+		t.Errorf("среднее время реакции: ожидалось 3600, получили %v", p.AvgResponseSeconds)
+	}
+	if !p.OnVacation || p.Vacation == nil {
+		t.Errorf("пользователь должен быть в отпуске: %+v", p)
+	}
+
+	p, err = svc.GetUserProfile(ctx, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.AvgResponseSeconds != nil || p.OnVacation || p.Vacation != nil {
+		t.Errorf("b не реагировал и не в отпуске, получили %+v", p)
+	}
+
+	if _, err := svc.GetUserProfile(ctx, "missing"); !errors.Is(err, service.ErrUserNotFound) {
+		t.Errorf("ожидалась ErrUserNotFound, получили %v", err)
+	}
+}
+
+func TestMemoryRenameTeam(t *testing.T) {
+	svc, _, _ := newMemoryService(t, team("backend", "author", "a", "b"), team("frontend", "f1"))
+	ctx := context.Background()
+	count := 1
+	setPolicy(t, svc, models.TeamPolicy{TeamName: "backend", ReviewerCount: &count})
+
+	if err := svc.RenameTeam(ctx, "backend", "platform"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.GetTeam(ctx, "backend"); !errors.Is(err, service.ErrTeamNotFound) {
+		t.Errorf("старое имя: ожидалась ErrTeamNotFound, получили %v", err)
+	}
+	got, err := svc.GetTeam(ctx, "platform")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Members) != 3 {
+		t.Errorf("участники должны перейти к новому имени, получили %+v", got.Members)
+	}
+	if pr := createPR(t, svc, "pr1", "author"); len(pr.AssignedReviewers) != 1 {
+		t.Errorf("политика команды должна сохраниться, получили ревьюверов %v", pr.AssignedReviewers)
+	}
+
+	entries, _, err := svc.ListAudit(ctx, models.AuditFilter{TeamName: "platform"}, models.Page{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := make([]string, 0, len(entries))
+	for _, e := range entries {
+		actions = append(actions, e.Action)
+	}
+	for _, want := range []string{models.AuditTeamCreated, models.AuditTeamRenamed} {
+		if !slices.Contains(actions, want) {
+			t.Errorf("история команды до переименования потеряна: нет %s в %v", want, actions)
+		}
+	}
+
+	if err := svc.RenameTeam(ctx, "platform", "frontend"); !errors.Is(err, service.ErrTeamExists) {
+		t.Errorf("занятое имя: ожидалась ErrTeamExists, получили %v", err)
+	}
+	if err := svc.RenameTeam(ctx, "missing", "other"); !errors.Is(err, service.ErrTeamNotFound) {
+		t.Errorf("ожидалась ErrTeamNotFound, получили %v", err)
+	}
+}
+
+func TestMemoryUserIdentities(t *testing.T) {
+	svc, _, _ := newMemoryService(t, team("backend", "alice", "bob"))
+	ctx := context.Background()
+
+	ids, err := svc.SetUserIdentity(ctx, models.UserIdentity{UserID: "alice", Provider: models.IdentityGitHub, ExternalID: " @Alice-Dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.SetUserIdentity(ctx, models.UserIdentity{UserID: "alice", Provider: models.IdentitySlack, ExternalID: "U0ALICE"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0].ExternalID != "alice-dev" {
+		t.Errorf("логин GitHub хранится в нижнем регистре без @: %+v", ids)
+	}
+
+	user, err := svc.GetUserByIdentity(ctx, models.IdentityGitHub, "ALICE-dev")
+	if err != nil || user.UserID != "alice" {
+		t.Errorf("ожидался alice по логину GitHub, получили %+v, %v", user, err)
+	}
+	_, err = svc.SetUserIdentity(ctx, models.UserIdentity{UserID: "bob", Provider: models.IdentityGitHub, ExternalID: "alice-dev"})
+	if !errors.Is(err, service.ErrIdentityTaken) {
+		t.Errorf("ожидалась ErrIdentityTaken, получили %v", err)
+	}
+	_, err = svc.GetUserByIdentity(ctx, "bitbucket", "alice")
+	if !errors.Is(err, service.ErrUnknownIdentityProvider) {
+		t.Errorf("ожидалась ErrUnknownIdentityProvider, получили %v", err)
+	}
+
+	ids, err = svc.SetUserIdentity(ctx, models.UserIdentity{UserID: "alice", Provider: models.IdentityGitHub})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0].Provider != models.IdentitySlack {
+		t.Errorf("пустой external_id отвязывает GitHub, Slack остается: %+v", ids)
+	}
+	if _, err := svc.GetUserByIdentity(ctx, models.IdentityGitHub, "alice-dev"); !errors.Is(err, service.ErrUserNotFound) {
+		t.Errorf("отвязанный логин не находит пользователя, получили %v", err)
+	}
+
+	if _, err := svc.DeleteUser(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.SetUserIdentity(ctx, models.UserIdentity{UserID: "bob", Provider: models.IdentitySlack, ExternalID: "U0ALICE"}); err != nil {
+		t.Errorf("учетная запись удаленного пользователя освобождается: %v", err)
+	}
+}
+
+func TestMemoryDirectoryUserDeactivationReassigns(t *testing.T) {
+	svc, _, _ := newMemoryService(t, team("backend", "author", "bob"))
+	ctx := context.Background()
+	count := 1
+	setPolicy(t, svc, models.TeamPolicy{TeamName: "backend", ReviewerCount: &count})
+
+	user, err := svc.CreateDirectoryUser(ctx, "backend", models.TeamMember{UserID: "carol", Username: "Carol", IsActive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.TeamName != "backend" || !user.IsActive {
+		t.Errorf("ожидался активный carol в backend, получили %+v", user)
+	}
+	if _, err := svc.CreateDirectoryUser(ctx, "backend", models.TeamMember{UserID: "carol", IsActive: true}); !errors.Is(err, service.ErrUserExists) {
+		t.Errorf("ожидалась ErrUserExists, получили %v", err)
+	}
+	if _, err := svc.CreateDirectoryUser(ctx, "", models.TeamMember{UserID: "dave", IsActive: true}); !errors.Is(err, service.ErrTeamRequired) {
+		t.Errorf("ожидалась ErrTeamRequired, получили %v", err)
+	}
+
+	pr := createPR(t, svc, "pr1", "author")
+	if len(pr.AssignedReviewers) != 1 {
+		t.Fatalf("ожидался один ревьювер, получили %v", pr.AssignedReviewers)
+	}
+	leaver := pr.AssignedReviewers[0]
+
+	user, err = svc.UpdateDirectoryUser(ctx, "", models.TeamMember{UserID: leaver, IsActive: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.IsActive || user.TeamName != "backend" {
+		t.Errorf("пустая команда оставляет текущую, пользователь отключен: %+v", user)
+	}
+	details, err := svc.GetPullRequest(ctx, "pr1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(details.AssignedReviewers) != 1 || details.AssignedReviewers[0] == leaver {
+		t.Errorf("ревью отключенного %s должно перейти к коллеге, получили %v", leaver, details.AssignedReviewers)
+	}
+
+	if _, err := svc.UpdateDirectoryUser(ctx, "", models.TeamMember{UserID: "ghost"}); !errors.Is(err, service.ErrUserNotFound) {
+		t.Errorf("ожидалась ErrUserNotFound, получили %v", err)
+	}
+}
+
+type fakeDirectory []models.Team
+
+func (d fakeDirectory) TeamMembers(context.Context) ([]models.Team, error) { return d, nil }
+
+func TestMemorySyncDirectory(t *testing.T) {
+	_, r, _ := newMemoryService(t, team("backend", "author", "bob", "carol"), team("frontend", "erin"))
+	dir := fakeDirectory{
+		team("backend", "author", "carol", "dave", "erin"),
+		team("platform", "dave"),
+	}
+	svc := service.New(r, service.WithRandomizer(firstRand{}), service.WithDirectory(dir))
+	ctx := context.Background()
+	count := 1
+	setPolicy(t, svc, models.TeamPolicy{TeamName: "backend", ReviewerCount: &count})
+	createPR(t, svc, "pr1", "author") // bob
+
+	want := []models.DirectorySyncChange{
+		{TeamName: "backend", UserID: "dave", Action: models.DirectoryUserCreated},
+		{TeamName: "backend", UserID: "erin", Action: models.DirectoryUserMoved},
+		{TeamName: "backend", UserID: "bob", Action: models.DirectoryUserDeactivated},
+	}
+	report, err := svc.SyncDirectory(ctx, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(report.Changes, want) {
+		t.Errorf("ожидалось %+v, получили %+v", want, report.Changes)
+	}
+	if bob, _ := r.GetUser(ctx, "bob"); bob == nil || !bob.IsActive {
+		t.Errorf("dry_run ничего не меняет, получили %+v", bob)
+	}
+
+	report, err = svc.SyncDirectory(ctx, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(report.Changes, want) || report.ReassignedReviews != 1 {
+		t.Errorf("ожидалось %+v и одно переназначение, получили %+v", want, report)
+	}
+	if bob, _ := r.GetUser(ctx, "bob"); bob == nil || bob.IsActive {
+		t.Errorf("выбывший из группы bob отключается, получили %+v", bob)
+	}
+	if erin, _ := r.GetUser(ctx, "erin"); erin == nil || erin.TeamName != "backend" {
+		t.Errorf("erin переходит в backend, получили %+v", erin)
+	}
+	details, err := svc.GetPullRequest(ctx, "pr1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(details.AssignedReviewers, "bob") {
+		t.Errorf("ревью bob переназначено, получили %v", details.AssignedReviewers)
+	}
+
+	report, err = svc.SyncDirectory(ctx, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Changes) != 0 {
+		t.Errorf("повторная синхронизация ничего не меняет, получили %+v", report.Changes)
+	}
+
+	plain := service.New(r)
+	if _, err := plain.SyncDirectory(ctx, true, false); !errors.Is(err, service.ErrDirectoryNotConfigured) {
+		t.Errorf("ожидалась ErrDirectoryNotConfigured, получили %v", err)
+	}
+}
+
+func TestMemorySyncDirectoryEmptyGroup(t *testing.T) {
+	_, r, _ := newMemoryService(t, team("backend", "author", "bob"), team("frontend", "erin"))
+	dir := fakeDirectory{
+		team("backend", "author", "bob"),
+		{TeamName: "frontend"},
+	}
+	svc := service.New(r, service.WithRandomizer(firstRand{}), service.WithDirectory(dir))
+	ctx := context.Background()
+
+	if _, err := svc.SyncDirectory(ctx, false, false); !errors.Is(err, service.ErrDirectoryGroupEmpty) {
+		t.Fatalf("ожидалась ErrDirectoryGroupEmpty, получили %v", err)
+	}
+	if erin, _ := r.GetUser(ctx, "erin"); erin == nil || !erin.IsActive {
+		t.Errorf("без force команда пустой группы не отключается, получили %+v", erin)
+	}
+
+	report, err := svc.SyncDirectory(ctx, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.DirectorySyncChange{{TeamName: "frontend", UserID: "erin", Action: models.DirectoryUserDeactivated}}
+	if !reflect.DeepEqual(report.Changes, want) {
+		t.Errorf("ожидалось %+v, получили %+v", want, report.Changes)
+	}
+	if erin, _ := r.GetUser(ctx, "erin"); erin == nil || erin.IsActive {
+		t.Errorf("с force участник пустой группы отключается, получили %+v", erin)
+	}
+}
+
+func TestMemoryExportAssignmentEvents(t *testing.T) {
+	svc, _, clk := newMemoryService(t, team("backend", "author", "bob", "carol"))
+	ctx := context.Background()
+	count := 1
+	setPolicy(t, svc, models.TeamPolicy{TeamName: "backend", ReviewerCount: &count})
+
+	createPR(t, svc, "pr1", "author")
+	clk.advance(time.Hour)
+	boundary := clk.now()
+	createPR(t, svc, "pr2", "author")
+
+	var all []models.AssignmentEvent
+	err := svc.ExportAssignmentEvents(ctx, nil, nil, func(events []models.AssignmentEvent) error {
+		all = append(all, events...)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[0].PRID != "pr1" || all[1].PRID != "pr2" {
+		t.Fatalf("ожидались события pr1 и pr2 в порядке записи, получили %+v", all)
+	}
+
+	var recent []models.AssignmentEvent
+	err = svc.ExportAssignmentEvents(ctx, &boundary, nil, func(events []models.AssignmentEvent) error {
+		recent = append(recent, events...)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recent) != 1 || recent[0].PRID != "pr2" {
+		t.Errorf("from отсекает ранние события, получили %+v", recent)
+	}
+
+	stop := errors.New("client gone")
+	if err := svc.ExportAssignmentEvents(ctx, nil, nil, func([]models.AssignmentEvent) error { return stop }); !errors.Is(err, stop) {
+		t.Errorf("ошибка записи прерывает выгрузку, получили %v", err)
+	}
+	if err := svc.ExportAssignmentEvents(ctx, &boundary, &boundary, nil); !errors.Is(err, service.ErrInvalidExportRange) {
+		t.Errorf("ожидалась ErrInvalidExportRange, получили %v", err)
+	}
+}
+
+func TestMemoryImportGitHubPRs(t *testing.T) {
+	gh := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/acme/api/pulls" || r.Header.Get("Authorization") != "Bearer gh-token" {
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`[
+			{"number": 1, "title": "Fix login", "html_url": "https://github.com/acme/api/pull/1",
+			 "user": {"login": "Alice-GH"}, "head": {"ref": "fix-login"}, "labels": [{"name": "Bug"}]},
+			{"number": 2, "title": "Add cache", "user": {"login": "bob"}, "head": {"ref": "cache"}},
+			{"number": 3, "title": "WIP", "draft": true, "user": {"login": "bob"}},
+			{"number": 4, "title": "Typo", "user": {"login": "stranger"}}
+		]`))
+	}))
+	defer gh.Close()
+
+	_, r, _ := newMemoryService(t, team("backend", "alice", "bob", "carol"))
+	svc := service.New(r, service.WithRandomizer(firstRand{}), service.WithGitHubURL(gh.URL))
+	ctx := context.Background()
+	if _, err := svc.SetUserIdentity(ctx, models.UserIdentity{UserID: "alice", Provider: models.IdentityGitHub, ExternalID: "alice-gh"}); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := svc.ImportGitHubPRs(ctx, "acme/api", "gh-token", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := make([]string, len(results))
+	for i, res := range results {
+		statuses[i] = res.Status
+	}
+	want := []string{models.ImportCreated, models.ImportCreated, models.ImportSkipped, models.ImportSkipped}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Fatalf("ожидались статусы %v, получили %+v", want, results)
+	}
+	if results[0].PRID != "acme/api#1" || len(results[0].AssignedReviewers) == 0 {
+		t.Errorf("PR получает id owner/repo#номер и ревьюверов, получили %+v", results[0])
+	}
+
+	pr, err := svc.GetPullRequest(ctx, "acme/api#1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.AuthorID != "alice" || pr.Repository != "acme/api" || pr.Branch != "fix-login" || !slices.Equal(pr.Labels, []string{"bug"}) {
+		t.Errorf("автор по логину GitHub и метаданные PR, получили %+v", pr.PR)
+	}
+
+	results, err = svc.ImportGitHubPRs(ctx, "acme/api", "gh-token", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Status != models.ImportSkipped || results[0].Reason != "already exists" ||
+		results[2].Status != models.ImportCreated {
+		t.Errorf("повторный импорт пропускает заведенные PR и с include_drafts берет черновик, получили %+v", results)
+	}
+
+	if _, err := svc.ImportGitHubPRs(ctx, "acme/api", "wrong", false); !errors.Is(err, vcs.ErrRequestFailed) {
+		t.Errorf("ожидалась vcs.ErrRequestFailed, получили %v", err)
+	}
+	if _, err := svc.ImportGitHubPRs(ctx, "acme", "gh-token", false); !errors.Is(err, service.ErrInvalidGitHubImport) {
+		t.Errorf("ожидалась ErrInvalidGitHubImport, получили %v", err)
+	}
+}
+
+func TestMemoryPRDependencies(t *testing.T) {
+	svc, _, _ := newMemoryService(t, team("backend", "author", "a", "b"))
+	ctx := context.Background()
+
+	createPR(t, svc, "base", "author")
+	createPR(t, svc, "other", "author")
+	for _, deps := range [][]string{{"pr1"}, {"missing"}, {""}} {
+		_, err := svc.CreatePullRequest(ctx, models.PR{ID: "pr1", Name: "pr1", AuthorID: "author", DependsOn: deps})
+		if !errors.Is(err, service.ErrInvalidDependency) {
+			t.Errorf("%q: ожидалась ErrInvalidDependency, получили %v", deps, err)
+		}
+	}
+	pr, err := svc.CreatePullRequest(ctx, models.PR{
+		ID: "pr1", Name: "pr1", AuthorID: "author", DependsOn: []string{"other", "base", "other"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(pr.DependsOn, []string{"base", "other"}) {
+		t.Errorf("ожидались зависимости без повторов по порядку, получили %v", pr.DependsOn)
+	}
+
+	_, err = svc.MergePullRequest(ctx, "pr1", nil, true)
+	var blocked *service.BlockedError
+	if !errors.As(err, &blocked) || !errors.Is(err, service.ErrBlocked) {
+		t.Fatalf("ожидалась BlockedError даже с force, получили %v", err)
+	}
+	if !slices.Equal(blocked.BlockedBy, []string{"base", "other"}) {
+		t.Errorf("ожидались блокирующие base и other, получили %v", blocked.BlockedBy)
+	}
+
+	if _, err := svc.MergePullRequest(ctx, "base", nil, false); err != nil {
+		t.Fatal(err)
+	}
+	prs, total, err := svc.ListBlockedPRs(ctx, models.Page{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(prs) != 1 || prs[0].ID != "pr1" || !slices.Equal(prs[0].BlockedBy, []string{"other"}) {
+		t.Errorf("ожидался pr1, заблокированный other, получили %d %+v", total, prs)
+	}
+
+	if _, err := svc.ClosePullRequest(ctx, "other", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, total, _ := svc.ListBlockedPRs(ctx, models.Page{Limit: 10}); total != 0 {
+		t.Errorf("закрытая зависимость не блокирует, получили %d", total)
+	}
+	if _, err := svc.MergePullRequest(ctx, "pr1", nil, false); err != nil {
+		t.Errorf("merge после закрытия зависимостей: %v", err)
+	}
+}
+
+func TestMemoryDigest(t *testing.T) {
+	_, r, clk := newMemoryService(t, team("backend", "author", "a", "b", "c"))
+	notifier := &recordingNotifier{}
+	// Срок реакции в дайджесте — порог auto_reassign.
+	svc := service.New(r, service.WithRandomizer(firstRand{}), service.WithNotifier(notifier),
+		service.WithAutoReassignAfter(24*time.Hour), service.WithClock(clk.now))
+	ctx := context.Background()
+
+	// Середина дня по Москве: слот дайджеста в 00:00 уже наступил и не
+	// сдвигается на следующий день во время теста.
+	now := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+	clk.t = now.Add(-30 * time.Hour)
+	createPR(t, svc, "pr1", "author")
+	clk.t = now.Add(-time.Hour)
+	createPR(t, svc, "pr2", "author")
+	createPR(t, svc, "pr3", "author")
+	if _, err := svc.ApprovePullRequest(ctx, "pr3", "a"); err != nil {
+		t.Fatal(err)
+	}
+	clk.t = now
+
+	digestTime, timezone := "00:00", "Europe/Moscow"
+	for _, uid := range []string{"a", "c"} {
+		prefs := models.NotificationPreferences{UserID: uid, DigestTime: &digestTime, Timezone: &timezone}
+		if _, err := svc.SetNotificationPreferences(ctx, prefs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Настройки без полей дайджеста не сбрасывают его расписание.
+	prefs, err := svc.SetNotificationPreferences(ctx, models.NotificationPreferences{UserID: "c", EmailOptOut: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *prefs.DigestTime != digestTime || *prefs.Timezone != timezone {
+		t.Errorf("расписание дайджеста сохраняется, получили %q %q", *prefs.DigestTime, *prefs.Timezone)
+	}
+
+	preview, err := svc.DigestPreview(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := func(items []models.DigestItem) []string {
+		var ids []string
+		for _, item := range items {
+			ids = append(ids, item.PRID)
+		}
+		return ids
+	}
+	if got := ids(preview.OpenReviews); !slices.Equal(got, []string{"pr1", "pr2"}) {
+		t.Errorf("в работе pr1 и pr2 (pr3 одобрен), получили %v", got)
+	}
+	if got := ids(preview.NewlyAssigned); !slices.Equal(got, []string{"pr2"}) {
+		t.Errorf("новое назначение — только pr2, получили %v", got)
+	}
+	if got := ids(preview.SLAApproaching); !slices.Equal(got, []string{"pr1", "pr2"}) {
+		t.Errorf("SLA истекает у pr1 (просрочен) и pr2, получили %v", got)
+	}
+	if preview.NextDeliveryAt == nil || preview.NextDeliveryAt.After(now) {
+		t.Errorf("дайджест еще не отправлялся, отправка должна быть уже наступившей: %v", preview.NextDeliveryAt)
+	}
+
+	n, err := svc.SendDigests(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || len(notifier.events) != 1 {
+		t.Fatalf("дайджест только для a (у c нет ревью, у b нет настройки), получили %d: %+v", n, notifier.events)
+	}
+	if ev := notifier.events[0]; ev.Type != notify.EventDigest || !slices.Equal(ev.Recipients, []string{"a"}) ||
+		!strings.HasPrefix(ev.Message, "Ревью в работе: 2, новых назначений: 1, скоро истекает SLA: 2") {
+		t.Errorf("неожиданное событие дайджеста: %+v", ev)
+	}
+
+	if n, err := svc.SendDigests(ctx); err != nil || n != 0 {
+		t.Errorf("сегодняшний дайджест уже отправлен: получили %d, %v", n, err)
+	}
+	preview, err = svc.DigestPreview(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.NextDeliveryAt == nil || !preview.NextDeliveryAt.After(now) {
+		t.Errorf("следующая отправка должна быть завтра: %v", preview.NextDeliveryAt)
+	}
+	if len(preview.NewlyAssigned) != 0 {
+		t.Errorf("после отправки новых назначений нет, получили %v", ids(preview.NewlyAssigned))
+	}
+
+	if _, err := svc.DigestPreview(ctx, "ghost"); !errors.Is(err, service.ErrUserNotFound) {
+		t.Errorf("ожидалась ErrUserNotFound, получили %v", err)
+	}
+	for _, tc := range []struct {
+		digestTime, timezone string
+		want                 error
+	}{
+		{"25:00", "", service.ErrInvalidDigestTime},
+		{"9:00", "", service.ErrInvalidDigestTime},
+		{"09:00", "Mars/X", service.ErrInvalidTimezone},
+	} {
+		prefs := models.NotificationPreferences{UserID: "a", DigestTime: &tc.digestTime, Timezone: &tc.timezone}
+		if _, err := svc.SetNotificationPreferences(ctx, prefs); !errors.Is(err, tc.want) {
+			t.Errorf("%s %s: ожидалась %v, получили %v", tc.digestTime, tc.timezone, tc.want, err)
+		}
+	}
+}
+
+// recordingMerger запоминает вызовы merge API.
+type recordingMerger struct{ merged []int }
+
+func (m *recordingMerger) Merge(_ context.Context, _ string, number int) error {
+	m.merged = append(m.merged, number)
+	return nil
+}
+
+func TestMemoryAutoMergeRequiresUpstreamURL(t *testing.T) {
+	_, r, _ := newMemoryService(t, team("backend", "author", "a", "b"))
+	merger := &recordingMerger{}
+	svc := service.New(r, service.WithRandomizer(firstRand{}), service.WithMerger(vcs.ProviderGitHub, merger))
+	ctx := context.Background()
+
+	cfg := models.TeamAutoMerge{TeamName: "backend", Enabled: true, Provider: vcs.ProviderGitHub, Repository: "acme/api", RequiredApprovals: 1}
+	if _, err := svc.SetTeamAutoMerge(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	// Завершающие цифры id больше не считаются номером PR у провайдера.
+	createPR(t, svc, "pr-1001", "author")
+	if pr, err := svc.ApprovePullRequest(ctx, "pr-1001", "a"); err != nil || pr.Status == models.StatusMerged {
+		t.Fatalf("PR без ссылки не должен мержиться: %+v, %v", pr, err)
+	}
+
+	withURL := models.PR{ID: "pr2", Name: "pr2", AuthorID: "author", URL: "https://github.com/acme/api/pull/17"}
+	if _, err := svc.CreatePullRequest(ctx, withURL); err != nil {
+		t.Fatal(err)
+	}
+	pr, err := svc.ApprovePullRequest(ctx, "pr2", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.Status != models.StatusMerged || !slices.Equal(merger.merged, []int{17}) {
+		t.Errorf("ожидался merge PR 17 по ссылке, получили %s, вызовы %v", pr.Status, merger.merged)
+	}
+}
+
+func TestMemoryDisableAutoMergeWithoutProvider(t *testing.T) {
+	_, r, _ := newMemoryService(t, team("backend", "author", "a"))
+	ctx := context.Background()
+	cfg := models.TeamAutoMerge{TeamName: "backend", Enabled: true, Provider: vcs.ProviderGitHub, Repository: "acme/api", RequiredApprovals: 2}
+	withToken := service.New(r, service.WithMerger(vcs.ProviderGitHub, &recordingMerger{}))
+	if _, err := withToken.SetTeamAutoMerge(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	// Токен провайдера убрали из конфигурации: выключение все равно разрешено.
+	withoutToken := service.New(r)
+	got, err := withoutToken.SetTeamAutoMerge(ctx, models.TeamAutoMerge{TeamName: "backend"})
+	if err != nil {
+		t.Fatalf("выключение auto-merge без провайдера: %v", err)
+	}
+	if got.Enabled || got.Repository != "acme/api" || got.RequiredApprovals != 2 {
+		t.Errorf("выключение должно сохранить остальные поля, получили %+v", got)
+	}
+	if _, err := withoutToken.SetTeamAutoMerge(ctx, cfg); !errors.Is(err, service.ErrInvalidAutoMerge) {
+		t.Errorf("включение без провайдера: ожидалась ErrInvalidAutoMerge, получили %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+)
+
+// SetNotificationPreferences сохраняет настройки уведомлений пользователя.
+// Не переданные digest_time и timezone остаются прежними.
+func (s *Service) SetNotificationPreferences(
+	ctx context.Context,
+	p models.NotificationPreferences,
+) (*models.NotificationPreferences, error) {
+	ctx, cancel := s.writeContext(ctx)
+	defer cancel()
+
+	if err := validateDigest(p); err != nil {
+		return nil, err
+	}
+	user, err := s.repo.GetUser(ctx, p.UserID)
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SetNotificationPreferences(ctx, p); err != nil {
+		return nil, err
+	}
+	// Ответ показывает сохраненное расписание, в том числе не переданные поля.
+	sub, err := s.repo.GetDigestSubscription(repo.ReadFromPrimary(ctx), p.UserID)
+	if err != nil {
+		return nil, err
+	}
+	p.DigestTime, p.Timezone = &sub.DigestTime, &sub.Timezone
+
+	s.recordAudit(ctx, models.AuditEntry{
+		Action:   models.AuditNotificationsSet,
+		TeamName: user.TeamName,
+		UserID:   p.UserID,
+		Details: map[string]any{
+			"email_opt_out": p.EmailOptOut,
+			"digest_time":   p.DigestTime,
+			"timezone":      p.Timezone,
+		},
+	})
+	return &p, nil
+}
+
+// validEmail допускает пустой адрес (не менять текущий) или один адрес без имени.
+func validEmail(email string) bool {
+	if email == "" {
+		return true
+	}
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
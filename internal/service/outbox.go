@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/notify"
+	"prreviewer/internal/repo"
+)
+
+const (
+	// outboxBatch ограничивает число событий, публикуемых за один проход релея.
+	outboxBatch = 100
+	// MaxOutboxAttempts — после стольких неудачных попыток событие больше не публикуется.
+	MaxOutboxAttempts = 10
+)
+
+// RelayOutbox публикует неотправленные доменные события в канал уведомлений
+// и возвращает число доставленных. Доставка at-least-once: событие, упавшее
+// после отправки, но до отметки, уйдет повторно.
+func (s *Service) RelayOutbox(ctx context.Context) (int, error) {
+	events, err := s.repo.PendingOutbox(ctx, outboxBatch, MaxOutboxAttempts)
+	if err != nil {
+		return 0, err
+	}
+
+	published := make([]int64, 0, len(events))
+	for _, e := range events {
+		ev, err := s.outboxEvent(ctx, e)
+		if err == nil {
+			err = s.notifier.Notify(ctx, ev)
+		}
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to publish outbox event",
+				"id", e.ID, "type", e.Type, "attempts", e.Attempts+1, "error", err)
+			if err := s.repo.MarkOutboxFailed(ctx, e.ID, err.Error()); err != nil {
+				return len(published), err
+			}
+			continue
+		}
+		published = append(published, e.ID)
+	}
+
+	if len(published) == 0 {
+		return 0, nil
+	}
+	if err := s.repo.MarkOutboxPublished(ctx, published); err != nil {
+		return 0, err
+	}
+	return len(published), nil
+}
+
+// outboxEvent дополняет доменное событие текущими данными PR для канала уведомлений.
+func (s *Service) outboxEvent(ctx context.Context, e models.DomainEvent) (notify.Event, error) {
+	ev := notify.Event{
+		Type:       e.Type,
+		PRID:       e.PRID,
+		TeamName:   e.TeamName,
+		UserID:     e.UserID,
+		Recipients: e.Recipients,
+		Details:    e.Details,
+		CreatedAt:  e.CreatedAt,
+	}
+	if e.PRID == "" {
+		return ev, nil
+	}
+
+	pr, err := s.repo.GetPR(ctx, e.PRID)
+	if errors.Is(err, repo.ErrNotFound) {
+		return ev, nil
+	}
+	if err != nil {
+		return ev, err
+	}
+	ev.PRName = pr.Name
+	ev.PRURL = pr.URL
+	ev.AuthorID = pr.AuthorID
+	ev.Reviewers = pr.AssignedReviewers
+	if reason, ok := e.Details["reason"].(string); ok {
+		ev.Message = reason
+	}
+	return ev, nil
+}
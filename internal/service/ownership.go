@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+)
+
+var ErrInvalidOwnershipRule = errors.New("invalid ownership rule")
+
+// MaxOwnershipRules ограничивает число правил одного репозитория.
+const MaxOwnershipRules = 1000
+
+// SetOwnershipRules заменяет правила владения репозитория. Как и в CODEOWNERS,
+// для каждого файла действует последнее подходящее правило.
+func (s *Service) SetOwnershipRules(
+	ctx context.Context,
+	repository string,
+	rules []models.OwnershipRule,
+) ([]models.OwnershipRule, error) {
+	ctx, cancel := s.writeContext(ctx)
+	defer cancel()
+
+	if repository == "" {
+		return nil, fmt.Errorf("%w: repository is required", ErrInvalidOwnershipRule)
+	}
+	if len(rules) > MaxOwnershipRules {
+		return nil, fmt.Errorf("%w: at most %d rules per repository", ErrInvalidOwnershipRule, MaxOwnershipRules)
+	}
+	for i, rule := range rules {
+		if err := validateOwnershipRule(rule); err != nil {
+			return nil, fmt.Errorf("%w: rule %d: %s", ErrInvalidOwnershipRule, i, err)
+		}
+	}
+
+	err := s.repo.SetOwnershipRules(ctx, repository, rules)
+	if errors.Is(err, repo.ErrUnknownReference) {
+		return nil, fmt.Errorf("%w: unknown user or team", ErrInvalidOwnershipRule)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordAudit(ctx, models.AuditEntry{
+		Action:  models.AuditOwnershipChanged,
+		Details: map[string]any{"repository": repository, "rules": len(rules)},
+	})
+	return s.repo.ListOwnershipRules(ctx, repository)
+}
+
+func (s *Service) ListOwnershipRules(ctx context.Context, repository string) ([]models.OwnershipRule, error) {
+	ctx, cancel := s.readContext(ctx)
+	defer cancel()
+
+	return s.repo.ListOwnershipRules(ctx, repository)
+}
+
+func validateOwnershipRule(rule models.OwnershipRule) error {
+	if strings.TrimSpace(rule.Pattern) == "" {
+		return errors.New("pattern is required")
+	}
+	for _, seg := range strings.Split(strings.Trim(rule.Pattern, "/"), "/") {
+		if _, err := path.Match(seg, ""); err != nil {
+			return fmt.Errorf("bad pattern %q", rule.Pattern)
+		}
+	}
+	if (rule.UserID == "") == (rule.TeamName == "") {
+		return errors.New("exactly one of user_id and team_name is required")
+	}
+	return nil
+}
+
+// ownerCandidates возвращает доступных владельцев измененных файлов и для
+// каждого — шаблон правила, по которому он стал владельцем.
+func (s *Service) ownerCandidates(
+	ctx context.Context,
+	repository string,
+	changedPaths, exclude []string,
+) ([]models.Candidate, map[string]string, error) {
+	if repository == "" || len(changedPaths) == 0 {
+		return nil, nil, nil
+	}
+
+	rules, err := s.repo.ListOwnershipRules(ctx, repository)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var userIDs, teamNames []string
+	userPatterns, teamPatterns := map[string]string{}, map[string]string{}
+	for _, p := range changedPaths {
+		for _, rule := range lastMatchingRules(rules, p) {
+			if rule.UserID != "" {
+				userIDs = append(userIDs, rule.UserID)
+				if _, ok := userPatterns[rule.UserID]; !ok {
+					userPatterns[rule.UserID] = rule.Pattern
+				}
+			} else {
+				teamNames = append(teamNames, rule.TeamName)
+				if _, ok := teamPatterns[rule.TeamName]; !ok {
+					teamPatterns[rule.TeamName] = rule.Pattern
+				}
+			}
+		}
+	}
+	if len(userIDs) == 0 && len(teamNames) == 0 {
+		return nil, nil, nil
+	}
+
+	owners, err := s.repo.GetOwnerCandidates(ctx, userIDs, teamNames, exclude)
+	if err != nil {
+		return nil, nil, err
+	}
+	reasons := make(map[string]string, len(owners))
+	for _, c := range owners {
+		if pattern, ok := userPatterns[c.UserID]; ok {
+			reasons[c.UserID] = "codeowner match " + pattern
+		} else {
+			reasons[c.UserID] = "codeowner match " + teamPatterns[c.TeamName] + " via team " + c.TeamName
+		}
+	}
+	return owners, reasons, nil
+}
+
+// lastMatchingRules возвращает все правила с последним шаблоном, подходящим под файл.
+func lastMatchingRules(rules []models.OwnershipRule, file string) []models.OwnershipRule {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if !matchOwnership(rules[i].Pattern, file) {
+			continue
+		}
+		var matched []models.OwnershipRule
+		for _, rule := range rules {
+			if rule.Pattern == rules[i].Pattern {
+				matched = append(matched, rule)
+			}
+		}
+		return matched
+	}
+	return nil
+}
+
+// matchOwnership сопоставляет путь файла с шаблоном в духе CODEOWNERS:
+// шаблон без "/" ищется на любой глубине, "**" соответствует любому числу
+// каталогов, шаблон каталога покрывает все файлы внутри него.
+func matchOwnership(pattern, file string) bool {
+	segs := strings.Split(strings.Trim(file, "/"), "/")
+	trimmed := strings.Trim(pattern, "/")
+	pat := strings.Split(trimmed, "/")
+
+	if !strings.HasPrefix(pattern, "/") && !strings.Contains(trimmed, "/") {
+		for i := range segs {
+			if matchSegments(pat, segs[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	return matchSegments(pat, segs)
+}
+
+func matchSegments(pat, segs []string) bool {
+	if len(pat) == 0 {
+		return true
+	}
+	if pat[0] == "**" {
+		for i := 0; i <= len(segs); i++ {
+			if matchSegments(pat[1:], segs[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segs) == 0 {
+		return false
+	}
+	ok, _ := path.Match(pat[0], segs[0])
+	return ok && matchSegments(pat[1:], segs[1:])
+}
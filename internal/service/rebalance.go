@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+)
+
+// errRebalanceDone — PR укомплектован или подходящих новичков для него нет.
+var errRebalanceDone = errors.New("nothing to rebalance")
+
+// AddTeamMembers создает команду или добавляет участников в существующую и
+// назначает новых активных участников ревьюверами на открытые PR команды, где
+// ревьюверов меньше reviewer_count. Возвращает, была ли команда создана, и
+// сделанные назначения. Новички распределяются поровну, самые старые PR первыми.
+func (s *Service) AddTeamMembers(ctx context.Context, team models.Team) (bool, []models.RebalanceAssignment, error) {
+	ctx, cancel := s.bulkContext(ctx)
+	defer cancel()
+
+	if err := normalizeMembers(team.Members); err != nil {
+		return false, nil, err
+	}
+
+	ctx = repo.ReadFromPrimary(ctx)
+	wasActive := map[string]bool{}
+	current, err := s.repo.GetTeam(ctx, team.TeamName)
+	if err != nil && !errors.Is(err, repo.ErrNotFound) {
+		return false, nil, err
+	}
+	if current != nil {
+		for _, m := range current.Members {
+			wasActive[m.UserID] = m.IsActive
+		}
+	}
+
+	results, err := s.repo.ImportTeams(ctx, []models.Team{team})
+	if err != nil {
+		return false, nil, err
+	}
+	if results[0].Status == models.ImportFailed {
+		return false, nil, fmt.Errorf("запись участников: %s", results[0].Reason)
+	}
+	created := results[0].Status == models.ImportCreated
+
+	var newcomers []string
+	for _, m := range team.Members {
+		if m.IsActive && !wasActive[m.UserID] {
+			newcomers = append(newcomers, m.UserID)
+		}
+	}
+	entry := models.AuditEntry{
+		Action:   models.AuditTeamUpdated,
+		TeamName: team.TeamName,
+		Details:  map[string]any{"members": len(team.Members), "newcomers": newcomers},
+	}
+	if created {
+		entry.Action = models.AuditTeamCreated
+		entry.Details = map[string]any{"members": len(team.Members)}
+	}
+	s.recordAudit(ctx, entry)
+
+	assignments := []models.RebalanceAssignment{}
+	if len(newcomers) == 0 {
+		return created, assignments, nil
+	}
+	policy, err := s.teamPolicy(ctx, team.TeamName)
+	if err != nil {
+		return created, nil, err
+	}
+	prIDs, err := s.repo.GetOpenPRsByTeam(ctx, team.TeamName)
+	if err != nil {
+		return created, nil, err
+	}
+
+	load := make(map[string]int, len(newcomers))
+	reason := "new member of team " + team.TeamName
+	for _, prID := range prIDs {
+		for {
+			_, userID, err := s.replaceReviewer(ctx, prID, func(ctx context.Context, pr *models.PR) (models.ReviewerChange, error) {
+				if !pr.Status.IsOpen() {
+					return models.ReviewerChange{}, errRebalanceDone
+				}
+				missing := *policy.ReviewerCount
+				for _, id := range pr.AssignedReviewers {
+					if m := policy.MandatoryReviewer; m == nil || *m != id {
+						missing--
+					}
+				}
+				if missing <= 0 {
+					return models.ReviewerChange{}, errRebalanceDone
+				}
+
+				exclude := append([]string{pr.AuthorID}, pr.AssignedReviewers...)
+				available, err := s.repo.GetActiveTeamMembers(ctx, team.TeamName, exclude)
+				if err != nil {
+					return models.ReviewerChange{}, err
+				}
+				picked := pickNewcomer(newcomers, available, load)
+				if picked == "" {
+					return models.ReviewerChange{}, errRebalanceDone
+				}
+				if err := ensureNotAuthor(pr.AuthorID, picked); err != nil {
+					return models.ReviewerChange{}, err
+				}
+				return models.ReviewerChange{
+					PRID:          prID,
+					NewReviewerID: picked,
+					EventType:     models.EventAssigned,
+					Reason:        reason,
+				}, nil
+			})
+			if errors.Is(err, errRebalanceDone) || errors.Is(err, ErrPRNotFound) {
+				break
+			}
+			if err != nil {
+				return created, assignments, fmt.Errorf("PR %s: %w", prID, err)
+			}
+			load[userID]++
+			assignments = append(assignments, models.RebalanceAssignment{PRID: prID, UserID: userID})
+		}
+	}
+	return created, assignments, nil
+}
+
+// pickNewcomer выбирает из доступных кандидатов новичка с наименьшим числом
+// назначений в текущей перебалансировке; при равенстве — первого в запросе.
+func pickNewcomer(newcomers []string, available []models.Candidate, load map[string]int) string {
+	picked := ""
+	for _, id := range newcomers {
+		if !slices.ContainsFunc(available, func(c models.Candidate) bool { return c.UserID == id }) {
+			continue
+		}
+		if picked == "" || load[id] < load[picked] {
+			picked = id
+		}
+	}
+	return picked
+}
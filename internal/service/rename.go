@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+)
+
+// RenameTeam переименовывает команду. Участники, политики и правила владения
+// переходят к новому имени, история в журнале аудита остается доступна по нему.
+func (s *Service) RenameTeam(ctx context.Context, oldName, newName string) error {
+	ctx, cancel := s.writeContext(ctx)
+	defer cancel()
+
+	if oldName == newName {
+		exists, err := s.repo.TeamExists(ctx, oldName)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrTeamNotFound
+		}
+		return nil
+	}
+
+	err := s.repo.RenameTeam(ctx, oldName, newName)
+	if errors.Is(err, repo.ErrNotFound) {
+		return ErrTeamNotFound
+	}
+	if errors.Is(err, repo.ErrDuplicate) {
+		return ErrTeamExists
+	}
+	if err != nil {
+		return err
+	}
+
+	s.recordAudit(ctx, models.AuditEntry{
+		Action:   models.AuditTeamRenamed,
+		TeamName: newName,
+		Details:  map[string]any{"old_team_name": oldName},
+	})
+	return nil
+}
@@ -4,24 +4,47 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"prreviewer/internal/models"
+	"prreviewer/internal/notify"
+	"prreviewer/internal/pkg"
 	"prreviewer/internal/repo"
+	"prreviewer/internal/vcs"
 )
 
 var (
-	ErrTeamExists     = errors.New("team already exists")
-	ErrTeamNotFound   = errors.New("team not found")
-	ErrUserNotFound   = errors.New("user not found")
-	ErrAuthorNotFound = errors.New("author not found")
-	ErrPRExists       = errors.New("pull request already exists")
-	ErrPRNotFound     = errors.New("pull request not found")
-	ErrPRMerged       = errors.New("cannot modify merged PR")
-	ErrNotAssigned    = errors.New("reviewer is not assigned to this PR")
-	ErrNoCandidate    = errors.New("no suitable replacement found")
+	ErrTeamExists        = errors.New("team already exists")
+	ErrTeamNotFound      = errors.New("team not found")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrAuthorNotFound    = errors.New("author not found")
+	ErrPRExists          = errors.New("pull request already exists")
+	ErrPRNotFound        = errors.New("pull request not found")
+	ErrPRMerged          = errors.New("cannot modify merged PR")
+	ErrPRClosed          = errors.New("cannot modify closed PR")
+	ErrNotAssigned       = errors.New("reviewer is not assigned to this PR")
+	ErrNoCandidate       = errors.New("no suitable replacement found")
+	ErrVersionConflict   = errors.New("pull request was modified concurrently")
+	ErrConflictRetry     = errors.New("pull request changed during the operation, retry")
+	ErrReviewerConflict  = errors.New("reviewer assignment violates integrity constraints")
+	ErrAuthorIsReviewer  = errors.New("author cannot be assigned as reviewer")
+	ErrInvalidStatus     = errors.New("unknown pull request status")
+	ErrInvalidWeight     = errors.New("review_weight must not be negative")
+	ErrInvalidEmail      = errors.New("invalid email")
+	ErrInvalidStatsRange = errors.New("invalid stats range")
+
+	// Нарушения ограничений и конфликты транзакций из репозитория.
+	ErrDuplicate  = repo.ErrDuplicate
+	ErrForeignKey = repo.ErrForeignKey
+	ErrConflict   = repo.ErrConflict
 )
 
 type Repository interface {
+	AddVacation(ctx context.Context, v models.Vacation) (*models.Vacation, error)
+	ApprovePR(ctx context.Context, prID, userID string, version int) error
+	ArchiveMergedPRs(ctx context.Context, mergedBefore time.Time, limit int) (int, error)
+	ClosePR(ctx context.Context, prID string, expectedVersion *int) error
 	CreatePR(ctx context.Context, pr models.PR) error
 	CreateTeam(ctx context.Context, team models.Team) error
 	DeactivateTeamAndReassignPRs(
@@ -30,35 +53,166 @@ type Repository interface {
 		rng interface{ Intn(int) int },
 	) (*repo.DeactivationResult, error)
 	DeactivateTeamMembers(ctx context.Context, teamName string) ([]string, error)
-	GetActiveTeamMembers(ctx context.Context, teamName string, excludeIDs []string) ([]string, error)
+	DeleteUserAndReassignPRs(
+		ctx context.Context,
+		uid string,
+		rng interface{ Intn(int) int },
+	) (*repo.UserDeletionResult, error)
+	ExpireVacations(ctx context.Context) ([]string, error)
+	ExportAssignmentEvents(
+		ctx context.Context,
+		from, to *time.Time,
+		after *models.EventCursor,
+		limit int,
+	) ([]models.AssignmentEvent, *models.EventCursor, error)
+	GetActiveTeamMembers(ctx context.Context, teamName string, excludeIDs []string) ([]models.Candidate, error)
+	GetArchivedPR(ctx context.Context, prID string) (*models.ArchivedPR, error)
+	GetDigestSubscription(ctx context.Context, uid string) (*models.DigestSubscription, error)
+	GetExistingPRs(ctx context.Context, prIDs []string) ([]string, error)
+	GetFallbackCandidates(ctx context.Context, excludeTeam string, excludeIDs []string) ([]models.Candidate, error)
+	GetOpenPRs(ctx context.Context, prIDs []string) ([]string, error)
 	GetOpenPRsByReviewers(ctx context.Context, reviewerIDs []string) ([]string, error)
+	GetOpenPRsByTeam(ctx context.Context, teamName string) ([]string, error)
+	GetOwnerCandidates(ctx context.Context, userIDs, teamNames, excludeIDs []string) ([]models.Candidate, error)
 	GetPR(ctx context.Context, prID string) (*models.PR, error)
-	GetStats(ctx context.Context) (*models.Stats, error)
+	GetSLAStats(ctx context.Context, filter models.SLAFilter) (*models.SLAStats, error)
+	GetStats(ctx context.Context, filter models.StatsFilter, page models.Page) (*models.Stats, error)
 	GetTeam(ctx context.Context, name string) (*models.Team, error)
+	GetTeamAutoMerge(ctx context.Context, teamName string) (*models.TeamAutoMerge, error)
+	GetTeamPolicy(ctx context.Context, teamName string) (*models.TeamPolicy, error)
+	GetTeamStats(ctx context.Context, teamName string) (*models.TeamStats, error)
 	GetUser(ctx context.Context, uid string) (*models.User, error)
-	GetUserReviews(ctx context.Context, uid string) ([]models.PRShort, error)
-	MergePR(ctx context.Context, prID string) error
+	GetUserByIdentity(ctx context.Context, provider, externalID string) (*models.User, error)
+	GetUserIdentities(ctx context.Context, uid string) ([]models.UserIdentity, error)
+	GetUserProfile(ctx context.Context, uid string) (*models.UserProfile, error)
+	GetUserTeamName(ctx context.Context, uid string) (string, error)
+	GetUserTeamNames(ctx context.Context, uids []string) (map[string]string, error)
+	GetUserReviews(
+		ctx context.Context,
+		uid string,
+		filter models.ReviewFilter,
+		page models.Page,
+	) ([]models.PRShort, int, error)
+	ImportTeams(ctx context.Context, teams []models.Team) ([]models.TeamImportResult, error)
+	InsertAuditEntry(ctx context.Context, e models.AuditEntry) error
+	ListAssignmentEvents(ctx context.Context, prID string) ([]models.AssignmentEvent, error)
+	ListAuditEntries(ctx context.Context, filter models.AuditFilter, page models.Page) ([]models.AuditEntry, int, error)
+	ListBlockedPRs(ctx context.Context, page models.Page) ([]models.BlockedPR, int, error)
+	ListDigestSubscriptions(ctx context.Context) ([]models.DigestSubscription, error)
+	ListOwnershipRules(ctx context.Context, repository string) ([]models.OwnershipRule, error)
+	ListOverdueAssignments(
+		ctx context.Context,
+		assignedBefore time.Time,
+		after *models.OverdueAssignment,
+		limit int,
+	) ([]models.OverdueAssignment, error)
+	ListReviewAssignments(ctx context.Context, uid string) ([]models.ReviewAssignment, error)
+	ListStalePRs(ctx context.Context, filter models.StaleFilter, page models.Page) ([]models.StalePR, int, error)
+	ListTeams(ctx context.Context, namePrefix string, page models.Page) ([]models.TeamSummary, int, error)
+	ListUsers(ctx context.Context, filter models.UserFilter, page models.Page) ([]models.User, int, error)
+	MarkDigestSent(ctx context.Context, uid string, at time.Time) error
+	MarkOutboxFailed(ctx context.Context, id int64, reason string) error
+	MarkOutboxPublished(ctx context.Context, ids []int64) error
+	MarkReminded(ctx context.Context, prIDs []string) error
+	MergePR(ctx context.Context, prID string, expectedVersion *int) error
+	PendingOutbox(ctx context.Context, limit, maxAttempts int) ([]models.DomainEvent, error)
+	PreviewTeamDeactivation(
+		ctx context.Context,
+		teamName string,
+		rng interface{ Intn(int) int },
+	) (*repo.DeactivationResult, error)
 	PRExists(ctx context.Context, prID string) (bool, error)
-	ReplaceReviewer(ctx context.Context, prID string, oldReviewerID string, newReviewerID string) error
+	RenameTeam(ctx context.Context, oldName, newName string) error
+	ReplaceReviewer(
+		ctx context.Context,
+		prID string,
+		plan func(ctx context.Context, pr *models.PR) (models.ReviewerChange, error),
+	) error
+	SetNotificationPreferences(ctx context.Context, p models.NotificationPreferences) error
+	SetOwnershipRules(ctx context.Context, repository string, rules []models.OwnershipRule) error
+	SetPRStatus(ctx context.Context, prID string, status models.PRStatus, expectedVersion *int) error
+	SetTeamAutoMerge(ctx context.Context, cfg models.TeamAutoMerge) error
+	SetTeamPolicy(ctx context.Context, p models.TeamPolicy) error
+	SetUserIdentity(ctx context.Context, id models.UserIdentity) error
+	SetUserTags(ctx context.Context, uid string, tags []string) error
+	SnoozeUser(ctx context.Context, uid string, until *time.Time) error
 	TeamExists(ctx context.Context, name string) (bool, error)
 	UpdateUserActiveStatus(ctx context.Context, uid string, active bool) error
 }
 
+// Randomizer — источник случайности для выбора ревьюверов.
 type Randomizer interface {
 	Intn(n int) int
-	Shuffle(n int, swap func(i, j int))
 }
 
 type Service struct {
-	repo Repository
-	rng  Randomizer
+	repo          Repository
+	rng           Randomizer
+	mergers       map[string]vcs.Merger
+	notifier      notify.Notifier
+	staleAfter    time.Duration
+	archiveAfter  time.Duration
+	reassignAfter time.Duration
+	timeouts      Timeouts
+	directory     Directory
+	githubURL     string
+	now           func() time.Time
+}
+
+type Option func(*Service)
+
+// WithRandomizer задает источник случайности выбора ревьюверов; по умолчанию
+// pkg.SharedRand.
+func WithRandomizer(rng Randomizer) Option {
+	return func(s *Service) { s.rng = rng }
 }
 
-func New(r Repository, rng Randomizer) *Service {
-	return &Service{repo: r, rng: rng}
+// WithMerger регистрирует клиента VCS для auto-merge (provider: github, gitlab).
+func WithMerger(provider string, m vcs.Merger) Option {
+	return func(s *Service) { s.mergers[provider] = m }
+}
+
+// WithClock задает источник текущего времени для порогов фоновых задач; по
+// умолчанию time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(s *Service) { s.now = now }
+}
+
+func New(r Repository, opts ...Option) *Service {
+	s := &Service{
+		repo:          r,
+		rng:           pkg.SharedRand{},
+		mergers:       map[string]vcs.Merger{},
+		notifier:      notify.Log{},
+		staleAfter:    DefaultStaleAfter,
+		archiveAfter:  DefaultArchiveAfter,
+		reassignAfter: DefaultAutoReassignAfter,
+		timeouts:      DefaultTimeouts,
+		now:           time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// random возвращает источник случайности операции: генератор с зерном из
+// pkg.WithSeed, если оно задано, иначе общий генератор сервиса.
+func (s *Service) random(ctx context.Context) Randomizer {
+	if seed, ok := pkg.SeedFromContext(ctx); ok {
+		return pkg.NewSeededRand(seed)
+	}
+	return s.rng
 }
 
 func (s *Service) CreateTeam(ctx context.Context, team models.Team) error {
+	ctx, cancel := s.writeContext(ctx)
+	defer cancel()
+
+	if err := normalizeMembers(team.Members); err != nil {
+		return err
+	}
+
 	exists, err := s.repo.TeamExists(ctx, team.TeamName)
 	if err != nil {
 		return fmt.Errorf("проверка существования команды: %w", err)
@@ -66,10 +220,45 @@ func (s *Service) CreateTeam(ctx context.Context, team models.Team) error {
 	if exists {
 		return ErrTeamExists
 	}
-	return s.repo.CreateTeam(ctx, team)
+	// Параллельный запрос мог создать команду после проверки.
+	err = s.repo.CreateTeam(ctx, team)
+	if errors.Is(err, repo.ErrDuplicate) {
+		return ErrTeamExists
+	}
+	if err != nil {
+		return err
+	}
+
+	s.recordAudit(ctx, models.AuditEntry{
+		Action:   models.AuditTeamCreated,
+		TeamName: team.TeamName,
+		Details:  map[string]any{"members": len(team.Members)},
+	})
+	return nil
+}
+
+// normalizeMembers проверяет участников команды и приводит их теги к каноническому виду.
+func normalizeMembers(members []models.TeamMember) error {
+	for i, m := range members {
+		if m.ReviewWeight < 0 {
+			return fmt.Errorf("%w: %s", ErrInvalidWeight, m.UserID)
+		}
+		tags, err := normalizeTags(m.Tags)
+		if err != nil {
+			return fmt.Errorf("user %s: %w", m.UserID, err)
+		}
+		members[i].Tags = tags
+		if !validEmail(m.Email) {
+			return fmt.Errorf("%w: %s", ErrInvalidEmail, m.UserID)
+		}
+	}
+	return nil
 }
 
 func (s *Service) GetTeam(ctx context.Context, teamName string) (*models.Team, error) {
+	ctx, cancel := s.readContext(ctx)
+	defer cancel()
+
 	team, err := s.repo.GetTeam(ctx, teamName)
 	if errors.Is(err, repo.ErrNotFound) {
 		return nil, ErrTeamNotFound
@@ -77,7 +266,21 @@ func (s *Service) GetTeam(ctx context.Context, teamName string) (*models.Team, e
 	return team, err
 }
 
+func (s *Service) ListTeams(
+	ctx context.Context,
+	namePrefix string,
+	page models.Page,
+) ([]models.TeamSummary, int, error) {
+	ctx, cancel := s.readContext(ctx)
+	defer cancel()
+
+	return s.repo.ListTeams(ctx, namePrefix, page)
+}
+
 func (s *Service) SetUserActive(ctx context.Context, uid string, active bool) (*models.User, error) {
+	ctx, cancel := s.writeContext(ctx)
+	defer cancel()
+
 	err := s.repo.UpdateUserActiveStatus(ctx, uid, active)
 	if errors.Is(err, repo.ErrNotFound) {
 		return nil, ErrUserNotFound
@@ -85,10 +288,44 @@ func (s *Service) SetUserActive(ctx context.Context, uid string, active bool) (*
 	if err != nil {
 		return nil, err
 	}
-	return s.repo.GetUser(ctx, uid)
+
+	user, err := s.repo.GetUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	action := models.AuditUserDeactivated
+	if active {
+		action = models.AuditUserActivated
+	}
+	s.recordAudit(ctx, models.AuditEntry{Action: action, TeamName: user.TeamName, UserID: uid})
+	return user, nil
 }
 
-func (s *Service) CreatePullRequest(ctx context.Context, prID, prName, authorID string) (*models.PR, error) {
+// CreatePullRequest создает PR из ID, Name, AuthorID и необязательных Repository,
+// ChangedPaths, RequiredTags, RequiredApprovals и метаданных (URL, Branch,
+// Description) запроса и назначает ревьюверов.
+func (s *Service) CreatePullRequest(ctx context.Context, req models.PR) (*models.PR, error) {
+	ctx, cancel := s.writeContext(ctx)
+	defer cancel()
+
+	ctx = repo.ReadFromPrimary(ctx)
+	prID, authorID := req.ID, req.AuthorID
+	requiredTags, err := normalizeTags(req.RequiredTags)
+	if err != nil {
+		return nil, err
+	}
+	req.RequiredTags = requiredTags
+	if req.Labels, err = normalizeLabels(req.Labels); err != nil {
+		return nil, err
+	}
+	if req.Priority, err = normalizePriority(req.Priority); err != nil {
+		return nil, err
+	}
+	if err := validatePRURL(req.URL); err != nil {
+		return nil, err
+	}
+
 	exists, err := s.repo.PRExists(ctx, prID)
 	if err != nil {
 		return nil, err
@@ -96,6 +333,9 @@ func (s *Service) CreatePullRequest(ctx context.Context, prID, prName, authorID
 	if exists {
 		return nil, ErrPRExists
 	}
+	if req.DependsOn, err = s.normalizeDependencies(ctx, prID, req.DependsOn); err != nil {
+		return nil, err
+	}
 
 	author, err := s.repo.GetUser(ctx, authorID)
 	if errors.Is(err, repo.ErrNotFound) {
@@ -105,31 +345,62 @@ func (s *Service) CreatePullRequest(ctx context.Context, prID, prName, authorID
 		return nil, err
 	}
 
-	candidates, err := s.repo.GetActiveTeamMembers(ctx, author.TeamName, []string{authorID})
+	req.AssignedReviewers = nil
+	reviewers, reasons, err := s.assignReviewers(ctx, author, req)
 	if err != nil {
 		return nil, fmt.Errorf("поиск кандидатов: %w", err)
 	}
-
-	candidatesCount := 2
-	reviewers := s.pickRandomReviewers(candidates, candidatesCount)
+	if err := ensureNotAuthor(authorID, reviewers...); err != nil {
+		return nil, err
+	}
 
 	pr := models.PR{
 		ID:                prID,
-		Name:              prName,
+		Name:              req.Name,
 		AuthorID:          authorID,
-		Status:            "OPEN",
+		Status:            models.StatusOpen,
 		AssignedReviewers: reviewers,
+		Repository:        req.Repository,
+		ChangedPaths:      req.ChangedPaths,
+		RequiredTags:      req.RequiredTags,
+		Labels:            req.Labels,
+		Priority:          req.Priority,
+		URL:               req.URL,
+		Branch:            req.Branch,
+		Description:       req.Description,
+		RequiredApprovals: req.RequiredApprovals,
+		DependsOn:         req.DependsOn,
 	}
 
-	if err := s.repo.CreatePR(ctx, pr); err != nil {
-		return nil, err
+	err = s.repo.CreatePR(ctx, pr)
+	if errors.Is(err, repo.ErrDuplicate) && !errors.Is(err, repo.ErrDuplicateReviewer) {
+		return nil, ErrPRExists
+	}
+	if err != nil {
+		return nil, mapReviewerErr(err)
 	}
 
-	return s.repo.GetPR(ctx, prID)
+	s.recordAudit(ctx, models.AuditEntry{
+		Action:   models.AuditPRCreated,
+		TeamName: author.TeamName,
+		UserID:   authorID,
+		PRID:     prID,
+		Details:  map[string]any{"reviewers": reviewers},
+	})
+	created, err := s.repo.GetPR(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+	created.AssignmentReasons = reasons
+	return created, nil
 }
 
-func (s *Service) MergePullRequest(ctx context.Context, prID string) (*models.PR, error) {
-	currentPR, err := s.repo.GetPR(ctx, prID)
+// GetPullRequest возвращает PR со статусом одобрений и историей назначений.
+func (s *Service) GetPullRequest(ctx context.Context, prID string) (*models.PRDetails, error) {
+	ctx, cancel := s.readContext(ctx)
+	defer cancel()
+
+	pr, err := s.repo.GetPR(ctx, prID)
 	if errors.Is(err, repo.ErrNotFound) {
 		return nil, ErrPRNotFound
 	}
@@ -137,77 +408,179 @@ func (s *Service) MergePullRequest(ctx context.Context, prID string) (*models.PR
 		return nil, err
 	}
 
-	if currentPR.Status == "MERGED" {
-		return currentPR, nil
+	approval, err := s.approvalStatus(ctx, pr)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.repo.MergePR(ctx, prID); err != nil {
+	history, err := s.repo.ListAssignmentEvents(ctx, prID)
+	if err != nil {
 		return nil, err
 	}
-	return s.repo.GetPR(ctx, prID)
+	return &models.PRDetails{PR: *pr, Approval: approval, History: history}, nil
 }
 
-func (s *Service) ReassignReviewer(ctx context.Context, prID, oldReviewerID string) (*models.PR, string, error) {
-	pr, err := s.repo.GetPR(ctx, prID)
+// MergePullRequest сливает PR, набравший нужное число одобрений, когда все его
+// зависимости смержены или закрыты. С force порог одобрений не проверяется
+// (зависимости — проверяются); право на это проверяет вызывающий.
+func (s *Service) MergePullRequest(
+	ctx context.Context,
+	prID string,
+	expectedVersion *int,
+	force bool,
+) (*models.PR, error) {
+	ctx, cancel := s.writeContext(ctx)
+	defer cancel()
+
+	ctx = repo.ReadFromPrimary(ctx)
+	currentPR, err := s.repo.GetPR(ctx, prID)
 	if errors.Is(err, repo.ErrNotFound) {
-		return nil, "", ErrPRNotFound
+		return nil, ErrPRNotFound
 	}
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
-	if pr.Status == "MERGED" {
-		return nil, "", ErrPRMerged
+	if currentPR.Status == models.StatusMerged {
+		return currentPR, nil
 	}
 
-	if !contains(pr.AssignedReviewers, oldReviewerID) {
-		return nil, "", ErrNotAssigned
+	if currentPR.Status == models.StatusClosed {
+		return nil, ErrPRClosed
 	}
 
-	oldReviewer, err := s.repo.GetUser(ctx, oldReviewerID)
-	if errors.Is(err, repo.ErrNotFound) {
-		return nil, "", ErrUserNotFound
+	if expectedVersion != nil && *expectedVersion != currentPR.Version {
+		return nil, ErrVersionConflict
 	}
 
-	excludeList := make([]string, 0, len(pr.AssignedReviewers)+1)
-	excludeList = append(excludeList, pr.AssignedReviewers...)
-	excludeList = append(excludeList, pr.AuthorID)
+	if err := s.checkDependencies(ctx, currentPR); err != nil {
+		return nil, err
+	}
 
-	candidates, err := s.repo.GetActiveTeamMembers(ctx, oldReviewer.TeamName, excludeList)
-	if err != nil {
-		return nil, "", err
+	var notApproved *NotApprovedError
+	if err := s.checkApprovals(ctx, currentPR); err != nil && !(force && errors.As(err, &notApproved)) {
+		return nil, err
 	}
 
-	if len(candidates) == 0 {
-		return nil, "", ErrNoCandidate
+	if err := s.repo.MergePR(ctx, prID, &currentPR.Version); err != nil {
+		if errors.Is(err, repo.ErrVersionConflict) {
+			return nil, staleWriteErr(expectedVersion)
+		}
+		return nil, err
 	}
 
-	newReviewer := candidates[s.rng.Intn(len(candidates))]
+	entry := models.AuditEntry{Action: models.AuditPRMerged, UserID: currentPR.AuthorID, PRID: prID}
+	if notApproved != nil {
+		entry.Details = map[string]any{"forced": true, "missing_approvers": notApproved.Missing}
+	}
+	s.recordAudit(ctx, entry)
+	return s.repo.GetPR(ctx, prID)
+}
+
+// ReassignReviewer заменяет ревьювера случайным с учетом весов активным
+// участником его команды (см. pickReplacement); стратегия политики команды
+// здесь не применяется. Причина reason попадает в историю назначений и в
+// уведомление новому ревьюверу.
+func (s *Service) ReassignReviewer(
+	ctx context.Context,
+	prID, oldReviewerID, reason string,
+	expectedVersion *int,
+) (*models.PR, string, error) {
+	ctx, cancel := s.writeContext(ctx)
+	defer cancel()
+
+	ctx = repo.ReadFromPrimary(ctx)
+	var assignmentReason string
+	pr, newReviewerID, err := s.replaceReviewer(ctx, prID, func(ctx context.Context, pr *models.PR) (models.ReviewerChange, error) {
+		if err := checkReassignable(pr, oldReviewerID, expectedVersion); err != nil {
+			return models.ReviewerChange{}, err
+		}
+
+		newReviewer, why, err := s.pickReplacement(ctx, pr, oldReviewerID)
+		if err != nil {
+			return models.ReviewerChange{}, err
+		}
+		if newReviewer == "" {
+			return models.ReviewerChange{}, ErrNoCandidate
+		}
 
-	if err := s.repo.ReplaceReviewer(ctx, prID, oldReviewerID, newReviewer); err != nil {
+		assignmentReason = why
+		return models.ReviewerChange{
+			PRID:          prID,
+			OldReviewerID: oldReviewerID,
+			NewReviewerID: newReviewer,
+			EventType:     models.EventReassigned,
+			Reason:        reason,
+		}, nil
+	})
+	if err != nil {
 		return nil, "", err
 	}
-
-	updatedPR, err := s.repo.GetPR(ctx, prID)
-	return updatedPR, newReviewer, err
+	pr.AssignmentReasons = map[string]string{newReviewerID: assignmentReason}
+	return pr, newReviewerID, nil
 }
 
-func (s *Service) GetUserReviews(ctx context.Context, uid string) (string, []models.PRShort, error) {
-	prs, err := s.repo.GetUserReviews(ctx, uid)
+func (s *Service) GetUserReviews(
+	ctx context.Context,
+	uid string,
+	filter models.ReviewFilter,
+	page models.Page,
+) ([]models.PRShort, int, error) {
+	ctx, cancel := s.readContext(ctx)
+	defer cancel()
+
+	if filter.Status != "" && !filter.Status.Valid() {
+		return nil, 0, ErrInvalidStatus
+	}
+	if filter.Priority != "" {
+		if _, err := normalizePriority(filter.Priority); err != nil {
+			return nil, 0, err
+		}
+	}
+	filter.Label = strings.ToLower(strings.TrimSpace(filter.Label))
+
+	prs, total, err := s.repo.GetUserReviews(ctx, uid, filter, page)
 	if err != nil {
-		return uid, nil, err
+		return nil, 0, err
 	}
 	if prs == nil {
 		prs = []models.PRShort{}
 	}
-	return uid, prs, nil
+	return prs, total, nil
 }
 
-func (s *Service) GetStats(ctx context.Context) (*models.Stats, error) {
-	return s.repo.GetStats(ctx)
+func (s *Service) GetStats(ctx context.Context, filter models.StatsFilter, page models.Page) (*models.Stats, error) {
+	ctx, cancel := s.readContext(ctx)
+	defer cancel()
+
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidStatsRange)
+	}
+	return s.repo.GetStats(ctx, filter, page)
+}
+
+// GetTeamStats возвращает нагрузку на ревью в команде.
+func (s *Service) GetTeamStats(ctx context.Context, teamName string) (*models.TeamStats, error) {
+	ctx, cancel := s.readContext(ctx)
+	defer cancel()
+
+	stats, err := s.repo.GetTeamStats(ctx, teamName)
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, ErrTeamNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stats.Assignments > 0 {
+		stats.ReassignmentRate = float64(stats.Reassignments) / float64(stats.Assignments)
+	}
+	return stats, nil
 }
 
 func (s *Service) DeactivateTeam(ctx context.Context, teamName string) ([]string, []map[string]string, error) {
+	ctx, cancel := s.bulkContext(ctx)
+	defer cancel()
+
 	exists, err := s.repo.TeamExists(ctx, teamName)
 	if err != nil {
 		return nil, nil, err
@@ -216,28 +589,216 @@ func (s *Service) DeactivateTeam(ctx context.Context, teamName string) ([]string
 		return nil, nil, ErrTeamNotFound
 	}
 
-	result, err := s.repo.DeactivateTeamAndReassignPRs(ctx, teamName, s.rng)
+	result, err := s.repo.DeactivateTeamAndReassignPRs(ctx, teamName, s.random(ctx))
+	if err != nil {
+		return nil, nil, mapReviewerErr(err)
+	}
+
+	s.recordAudit(ctx, models.AuditEntry{
+		Action:   models.AuditTeamDeactivated,
+		TeamName: teamName,
+		Details: map[string]any{
+			"deactivated_users": result.DeactivatedUsers,
+			"reassignments":     len(result.Reassignments),
+		},
+	})
+
+	return result.DeactivatedUsers, result.Reassignments, nil
+}
+
+// PreviewTeamDeactivation возвращает пользователей, которые были бы деактивированы,
+// и переназначения, ничего не сохраняя. Замены выбираются случайно, поэтому при
+// реальной деактивации новые ревьюверы могут отличаться.
+func (s *Service) PreviewTeamDeactivation(ctx context.Context, teamName string) ([]string, []map[string]string, error) {
+	ctx, cancel := s.bulkContext(ctx)
+	defer cancel()
+
+	exists, err := s.repo.TeamExists(ctx, teamName)
 	if err != nil {
 		return nil, nil, err
 	}
+	if !exists {
+		return nil, nil, ErrTeamNotFound
+	}
 
+	result, err := s.repo.PreviewTeamDeactivation(ctx, teamName, s.random(ctx))
+	if err != nil {
+		return nil, nil, mapReviewerErr(err)
+	}
 	return result.DeactivatedUsers, result.Reassignments, nil
 }
 
 // Вспомогательные функции.
-func (s *Service) pickRandomReviewers(candidates []string, n int) []string {
-	if len(candidates) <= n {
-		return candidates
+
+// pickRandomReviewers выбирает до n разных кандидатов с вероятностью,
+// пропорциональной review_weight.
+func (s *Service) pickRandomReviewers(rng Randomizer, candidates []models.Candidate, n int) []string {
+	pool := make([]models.Candidate, len(candidates))
+	copy(pool, candidates)
+
+	picked := make([]string, 0, min(n, len(pool)))
+	for len(picked) < n && len(pool) > 0 {
+		i := weightedIndex(rng, pool)
+		picked = append(picked, pool[i].UserID)
+		pool = append(pool[:i], pool[i+1:]...)
+	}
+	return picked
+}
+
+// weightedIndex возвращает индекс кандидата с вероятностью weight/sum(weight).
+// Вес меньше 1 считается равным 1.
+func weightedIndex(rng Randomizer, candidates []models.Candidate) int {
+	total := 0
+	for _, c := range candidates {
+		total += max(c.Weight, 1)
+	}
+
+	x := rng.Intn(total)
+	for i, c := range candidates {
+		x -= max(c.Weight, 1)
+		if x < 0 {
+			return i
+		}
+	}
+	return len(candidates) - 1
+}
+
+// checkReassignable проверяет, что ревьювера на PR можно заменить.
+func checkReassignable(pr *models.PR, reviewerID string, expectedVersion *int) error {
+	if pr.Status == models.StatusMerged {
+		return ErrPRMerged
+	}
+
+	if pr.Status == models.StatusClosed {
+		return ErrPRClosed
+	}
+
+	if expectedVersion != nil && *expectedVersion != pr.Version {
+		return ErrVersionConflict
+	}
+
+	if !contains(pr.AssignedReviewers, reviewerID) {
+		return ErrNotAssigned
+	}
+	return nil
+}
+
+// pickReplacement выбирает случайного активного участника команды старого ревьювера,
+// исключая автора и текущих ревьюверов, и возвращает его вместе с причиной выбора.
+// Пустая строка — кандидатов нет.
+func (s *Service) pickReplacement(ctx context.Context, pr *models.PR, oldReviewerID string) (string, string, error) {
+	oldReviewer, err := s.repo.GetUser(ctx, oldReviewerID)
+	if errors.Is(err, repo.ErrNotFound) {
+		return "", "", ErrUserNotFound
+	}
+	if err != nil {
+		return "", "", err
 	}
-	shuffled := make([]string, len(candidates))
-	copy(shuffled, candidates)
 
-	s.rng.Shuffle(len(shuffled), func(i, j int) {
-		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	excludeList := make([]string, 0, len(pr.AssignedReviewers)+1)
+	excludeList = append(excludeList, pr.AssignedReviewers...)
+	excludeList = append(excludeList, pr.AuthorID)
+
+	candidates, err := s.repo.GetActiveTeamMembers(ctx, oldReviewer.TeamName, excludeList)
+	if err != nil {
+		return "", "", err
+	}
+
+	if len(candidates) == 0 {
+		return "", "", nil
+	}
+
+	newReviewer := candidates[weightedIndex(s.random(ctx), candidates)].UserID
+	if err := ensureNotAuthor(pr.AuthorID, newReviewer); err != nil {
+		return "", "", err
+	}
+	reason := strategyReason(models.StrategyRandom) + " in team " + oldReviewer.TeamName + ", replacing " + oldReviewerID
+	return newReviewer, reason, nil
+}
+
+// replaceReviewer применяет замену ревьювера (или добавление, если OldReviewerID
+// пуст) и возвращает обновленный PR. Репозиторий блокирует PR на всю операцию:
+// plan проверяет его актуальное состояние и выбирает замену, поэтому два
+// параллельных запроса не заменят одного ревьювера дважды. Читать репозиторий
+// plan должен через переданный ему ctx — он несет транзакцию блокировки.
+func (s *Service) replaceReviewer(
+	ctx context.Context,
+	prID string,
+	plan func(ctx context.Context, pr *models.PR) (models.ReviewerChange, error),
+) (*models.PR, string, error) {
+	var change models.ReviewerChange
+	err := s.repo.ReplaceReviewer(ctx, prID, func(ctx context.Context, pr *models.PR) (models.ReviewerChange, error) {
+		var err error
+		change, err = plan(ctx, pr)
+		return change, err
 	})
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, "", ErrPRNotFound
+	}
+	if err != nil {
+		return nil, "", mapReviewerErr(err)
+	}
 
-	return shuffled[:n]
+	entry := models.AuditEntry{
+		Action:  models.AuditReviewerReassigned,
+		UserID:  change.OldReviewerID,
+		PRID:    change.PRID,
+		Details: map[string]any{"new_user_id": change.NewReviewerID, "reason": change.Reason},
+	}
+	switch change.EventType {
+	case models.EventDeclined:
+		entry.Action = models.AuditReviewDeclined
+	case models.EventUnassigned:
+		entry.Action = models.AuditReviewerUnassigned
+		entry.Details = map[string]any{"reason": change.Reason}
+	case models.EventAssigned:
+		entry.Action = models.AuditReviewerAssigned
+		entry.UserID = change.NewReviewerID
+		entry.Details = map[string]any{"reason": change.Reason}
+	}
+	s.recordAudit(ctx, entry)
+
+	updatedPR, err := s.repo.GetPR(ctx, change.PRID)
+	if err != nil {
+		return nil, "", err
+	}
+	return updatedPR, change.NewReviewerID, nil
 }
+
+// staleWriteErr — ошибка для записи, не прошедшей сравнение версий в репозитории.
+// Если клиент передал expected_version, его версия устарела. Иначе PR изменил
+// параллельный запрос между проверками сервиса и записью, и операцию можно повторить.
+func staleWriteErr(expectedVersion *int) error {
+	if expectedVersion != nil {
+		return ErrVersionConflict
+	}
+	return ErrConflictRetry
+}
+
+// ensureNotAuthor — единая проверка правила "автор не может быть ревьювером".
+// Все пути назначения ревьюверов обязаны вызывать ее перед записью в репозиторий.
+func ensureNotAuthor(authorID string, reviewerIDs ...string) error {
+	for _, id := range reviewerIDs {
+		if id == authorID {
+			return fmt.Errorf("%w: %s", ErrAuthorIsReviewer, id)
+		}
+	}
+	return nil
+}
+
+// mapReviewerErr оборачивает нарушения ограничений pr_reviewers в ErrReviewerConflict,
+// сохраняя исходную причину в тексте ошибки.
+func mapReviewerErr(err error) error {
+	if errors.Is(err, repo.ErrAuthorIsReviewer) {
+		return fmt.Errorf("%w: %w", ErrAuthorIsReviewer, err)
+	}
+	if errors.Is(err, repo.ErrDuplicateReviewer) ||
+		errors.Is(err, repo.ErrUnknownReference) {
+		return fmt.Errorf("%w: %w", ErrReviewerConflict, err)
+	}
+	return err
+}
+
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
 		if s == item {
@@ -0,0 +1,499 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"prreviewer/internal/models"
+	"prreviewer/internal/notify"
+	"prreviewer/internal/repo"
+	"prreviewer/internal/service"
+)
+
+// stubRepo реализует только методы, нужные тестам; остальные паникуют через nil-интерфейс.
+type stubRepo struct {
+	service.Repository
+
+	users      map[string]*models.User
+	prs        map[string]*models.PR
+	candidates []models.Candidate
+	// teamCandidates переопределяет candidates для отдельных команд.
+	teamCandidates map[string][]models.Candidate
+	fallback       []models.Candidate
+	policy         *models.TeamPolicy
+	rules          []models.OwnershipRule
+	ownerIDs       []string
+	stale          []models.StalePR
+	reminded       []string
+	outbox         []models.DomainEvent
+	published      []int64
+	failed         map[int64]string
+	createErr      error
+	deactErr       error
+	created        *models.PR
+	imported       []models.Team
+	audit          []models.AuditEntry
+}
+
+func (r *stubRepo) PRExists(_ context.Context, prID string) (bool, error) {
+	_, ok := r.prs[prID]
+	return ok, nil
+}
+
+func (r *stubRepo) GetUser(_ context.Context, uid string) (*models.User, error) {
+	u, ok := r.users[uid]
+	if !ok {
+		return nil, repo.ErrNotFound
+	}
+	return u, nil
+}
+
+func (r *stubRepo) GetActiveTeamMembers(_ context.Context, team string, _ []string) ([]models.Candidate, error) {
+	if c, ok := r.teamCandidates[team]; ok {
+		return c, nil
+	}
+	return r.candidates, nil
+}
+
+func (r *stubRepo) GetFallbackCandidates(context.Context, string, []string) ([]models.Candidate, error) {
+	return r.fallback, nil
+}
+
+func (r *stubRepo) GetTeamPolicy(context.Context, string) (*models.TeamPolicy, error) {
+	if r.policy == nil {
+		return nil, repo.ErrNotFound
+	}
+	return r.policy, nil
+}
+
+func (r *stubRepo) ListOwnershipRules(context.Context, string) ([]models.OwnershipRule, error) {
+	return r.rules, nil
+}
+
+func (r *stubRepo) GetOwnerCandidates(_ context.Context, userIDs, _, _ []string) ([]models.Candidate, error) {
+	r.ownerIDs = userIDs
+	result := make([]models.Candidate, 0, len(userIDs))
+	for _, id := range userIDs {
+		result = append(result, models.Candidate{UserID: id})
+	}
+	return result, nil
+}
+
+func (r *stubRepo) ListStalePRs(context.Context, models.StaleFilter, models.Page) ([]models.StalePR, int, error) {
+	return r.stale, len(r.stale), nil
+}
+
+func (r *stubRepo) MarkReminded(_ context.Context, prIDs []string) error {
+	r.reminded = append(r.reminded, prIDs...)
+	return nil
+}
+
+func (r *stubRepo) PendingOutbox(context.Context, int, int) ([]models.DomainEvent, error) {
+	return r.outbox, nil
+}
+
+func (r *stubRepo) MarkOutboxPublished(_ context.Context, ids []int64) error {
+	r.published = append(r.published, ids...)
+	return nil
+}
+
+func (r *stubRepo) MarkOutboxFailed(_ context.Context, id int64, reason string) error {
+	if r.failed == nil {
+		r.failed = map[int64]string{}
+	}
+	r.failed[id] = reason
+	return nil
+}
+
+func (r *stubRepo) CreatePR(_ context.Context, pr models.PR) error {
+	if r.createErr != nil {
+		return r.createErr
+	}
+	r.created = &pr
+	r.prs[pr.ID] = &pr
+	return nil
+}
+
+func (r *stubRepo) GetPR(_ context.Context, prID string) (*models.PR, error) {
+	pr, ok := r.prs[prID]
+	if !ok {
+		return nil, repo.ErrNotFound
+	}
+	return pr, nil
+}
+
+func (r *stubRepo) ReplaceReviewer(
+	ctx context.Context,
+	prID string,
+	plan func(ctx context.Context, pr *models.PR) (models.ReviewerChange, error),
+) error {
+	pr, ok := r.prs[prID]
+	if !ok {
+		return repo.ErrNotFound
+	}
+	_, err := plan(ctx, pr)
+	return err
+}
+
+func (r *stubRepo) TeamExists(context.Context, string) (bool, error) {
+	return true, nil
+}
+
+func (r *stubRepo) DeactivateTeamAndReassignPRs(
+	context.Context,
+	string,
+	interface{ Intn(int) int },
+) (*repo.DeactivationResult, error) {
+	if r.deactErr != nil {
+		return nil, r.deactErr
+	}
+	return &repo.DeactivationResult{}, nil
+}
+
+func (r *stubRepo) ImportTeams(_ context.Context, teams []models.Team) ([]models.TeamImportResult, error) {
+	r.imported = teams
+	res := make([]models.TeamImportResult, len(teams))
+	for i, t := range teams {
+		res[i] = models.TeamImportResult{TeamName: t.TeamName, Status: models.ImportCreated}
+	}
+	return res, nil
+}
+
+func (r *stubRepo) InsertAuditEntry(_ context.Context, e models.AuditEntry) error {
+	r.audit = append(r.audit, e)
+	return nil
+}
+
+// recordingNotifier запоминает события и отклоняет PR из failFor.
+type recordingNotifier struct {
+	events  []notify.Event
+	failFor string
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, e notify.Event) error {
+	if n.failFor != "" && e.PRID == n.failFor {
+		return errors.New("delivery failed")
+	}
+	n.events = append(n.events, e)
+	return nil
+}
+
+type firstRand struct{}
+
+func (firstRand) Intn(int) int { return 0 }
+
+// fixedRand всегда возвращает v.
+type fixedRand struct{ v int }
+
+func (r fixedRand) Intn(int) int { return r.v }
+
+func newStubRepo() *stubRepo {
+	return &stubRepo{
+		users: map[string]*models.User{
+			"author": {UserID: "author", TeamName: "t", IsActive: true},
+			"rev1":   {UserID: "rev1", TeamName: "t", IsActive: true},
+		},
+		prs: map[string]*models.PR{},
+	}
+}
+
+func TestCreatePullRequestRejectsAuthorAsReviewer(t *testing.T) {
+	r := newStubRepo()
+	r.candidates = []models.Candidate{{UserID: "author"}}
+	svc := service.New(r, service.WithRandomizer(firstRand{}))
+
+	_, err := svc.CreatePullRequest(context.Background(), models.PR{ID: "pr1", Name: "PR", AuthorID: "author"})
+	if !errors.Is(err, service.ErrAuthorIsReviewer) {
+		t.Fatalf("ожидалась ErrAuthorIsReviewer, получили %v", err)
+	}
+	if r.created != nil {
+		t.Errorf("PR не должен быть создан")
+	}
+}
+
+func TestCreatePullRequestMapsDBAuthorViolation(t *testing.T) {
+	r := newStubRepo()
+	r.candidates = []models.Candidate{{UserID: "rev1"}}
+	r.createErr = repo.ErrAuthorIsReviewer
+	svc := service.New(r, service.WithRandomizer(firstRand{}))
+
+	_, err := svc.CreatePullRequest(context.Background(), models.PR{ID: "pr1", Name: "PR", AuthorID: "author"})
+	if !errors.Is(err, service.ErrAuthorIsReviewer) {
+		t.Fatalf("ожидалась ErrAuthorIsReviewer, получили %v", err)
+	}
+}
+
+func TestReassignReviewerRejectsAuthorAsReviewer(t *testing.T) {
+	r := newStubRepo()
+	r.prs["pr1"] = &models.PR{ID: "pr1", AuthorID: "author", Status: "OPEN", AssignedReviewers: []string{"rev1"}}
+	r.candidates = []models.Candidate{{UserID: "author"}}
+	svc := service.New(r, service.WithRandomizer(firstRand{}))
+
+	_, _, err := svc.ReassignReviewer(context.Background(), "pr1", "rev1", "", nil)
+	if !errors.Is(err, service.ErrAuthorIsReviewer) {
+		t.Fatalf("ожидалась ErrAuthorIsReviewer, получили %v", err)
+	}
+}
+
+func TestDeactivateTeamMapsDBAuthorViolation(t *testing.T) {
+	r := newStubRepo()
+	r.deactErr = repo.ErrAuthorIsReviewer
+	svc := service.New(r, service.WithRandomizer(firstRand{}))
+
+	_, _, err := svc.DeactivateTeam(context.Background(), "t")
+	if !errors.Is(err, service.ErrAuthorIsReviewer) {
+		t.Fatalf("ожидалась ErrAuthorIsReviewer, получили %v", err)
+	}
+}
+
+func TestImportTeamsReportsInvalidItems(t *testing.T) {
+	r := newStubRepo()
+	svc := service.New(r, service.WithRandomizer(firstRand{}))
+
+	results, err := svc.ImportTeams(context.Background(), []models.Team{
+		{TeamName: "a", Members: []models.TeamMember{{UserID: "u1"}}},
+		{TeamName: ""},
+		{TeamName: "b", Members: []models.TeamMember{{UserID: "u1"}}},
+		{TeamName: "a"},
+		{TeamName: "c", Members: []models.TeamMember{{UserID: "u2"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		models.ImportCreated,
+		models.ImportFailed,
+		models.ImportFailed,
+		models.ImportFailed,
+		models.ImportCreated,
+	}
+	for i, res := range results {
+		if res.Status != want[i] {
+			t.Errorf("элемент %d: ожидался статус %s, получили %+v", i, want[i], res)
+		}
+	}
+	if len(r.imported) != 2 || r.imported[0].TeamName != "a" || r.imported[1].TeamName != "c" {
+		t.Errorf("в репозиторий должны попасть только a и c, получили %+v", r.imported)
+	}
+	if len(r.audit) != 2 || r.audit[0].Action != models.AuditTeamImported {
+		t.Errorf("ожидались 2 записи аудита team.imported, получили %+v", r.audit)
+	}
+}
+
+func TestReassignReviewerWeightedByReviewWeight(t *testing.T) {
+	// Вес junior=1, senior=3: из 4 равновероятных значений Intn senior получает 3.
+	picks := map[string]int{}
+	for v := 0; v < 4; v++ {
+		r := newStubRepo()
+		r.prs["pr1"] = &models.PR{ID: "pr1", AuthorID: "author", Status: "OPEN", AssignedReviewers: []string{"rev1"}}
+		r.candidates = []models.Candidate{{UserID: "junior", Weight: 1}, {UserID: "senior", Weight: 3}}
+		svc := service.New(r, service.WithRandomizer(fixedRand{v: v}))
+
+		_, newReviewer, err := svc.ReassignReviewer(context.Background(), "pr1", "rev1", "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		picks[newReviewer]++
+	}
+
+	if picks["junior"] != 1 || picks["senior"] != 3 {
+		t.Errorf("ожидалось junior=1, senior=3, получили %v", picks)
+	}
+}
+
+func TestCreatePullRequestAppliesTeamPolicy(t *testing.T) {
+	count, fallback, manager := 3, true, true
+	r := newStubRepo()
+	r.users["author"].ManagerID = "boss"
+	r.users["boss"] = &models.User{UserID: "boss", TeamName: "other", IsActive: true}
+	r.policy = &models.TeamPolicy{
+		ReviewerCount:      &count,
+		AssignmentStrategy: models.StrategyLeastLoaded,
+		CrossTeamFallback:  &fallback,
+		RequireManager:     &manager,
+	}
+	r.candidates = []models.Candidate{
+		{UserID: "busy", OpenReviews: 5},
+		{UserID: "free", OpenReviews: 0},
+	}
+	r.teamCandidates = map[string][]models.Candidate{"other": {{UserID: "boss"}}}
+	r.fallback = []models.Candidate{{UserID: "outsider"}}
+	svc := service.New(r, service.WithRandomizer(firstRand{}))
+
+	if _, err := svc.CreatePullRequest(context.Background(), models.PR{ID: "pr1", Name: "PR", AuthorID: "author"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"boss", "free", "busy"}
+	got := r.created.AssignedReviewers
+	if len(got) != len(want) {
+		t.Fatalf("ожидались ревьюверы %v, получили %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("ожидались ревьюверы %v, получили %v", want, got)
+		}
+	}
+}
+
+func TestSetTeamPolicyRejectsUnknownStrategy(t *testing.T) {
+	svc := service.New(newStubRepo(), service.WithRandomizer(firstRand{}))
+
+	_, err := svc.SetTeamPolicy(context.Background(), models.TeamPolicy{TeamName: "t", AssignmentStrategy: "lottery"})
+	if !errors.Is(err, service.ErrInvalidPolicy) {
+		t.Fatalf("ожидалась ErrInvalidPolicy, получили %v", err)
+	}
+}
+
+func TestCreatePullRequestPrefersCodeOwners(t *testing.T) {
+	r := newStubRepo()
+	r.rules = []models.OwnershipRule{
+		{Pattern: "*", UserID: "generic"},
+		{Pattern: "*.md", UserID: "writer"},
+		{Pattern: "internal/repo/", UserID: "dba"},
+		{Pattern: "/docs/**/*.png", UserID: "designer"},
+	}
+	r.candidates = []models.Candidate{{UserID: "rev1"}}
+	svc := service.New(r, service.WithRandomizer(firstRand{}))
+
+	_, err := svc.CreatePullRequest(context.Background(), models.PR{
+		ID:           "pr1",
+		Name:         "PR",
+		AuthorID:     "author",
+		Repository:   "acme/api",
+		ChangedPaths: []string{"internal/repo/repo.go", "docs/img/a/b.png"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantOwners := []string{"dba", "designer"}
+	if len(r.ownerIDs) != len(wantOwners) || r.ownerIDs[0] != wantOwners[0] || r.ownerIDs[1] != wantOwners[1] {
+		t.Errorf("ожидались владельцы %v, получили %v", wantOwners, r.ownerIDs)
+	}
+	got := r.created.AssignedReviewers
+	if len(got) != 2 || got[0] != "dba" || got[1] != "designer" {
+		t.Errorf("владельцы должны назначаться в первую очередь, получили %v", got)
+	}
+}
+
+func TestCreatePullRequestPrefersTaggedReviewers(t *testing.T) {
+	r := newStubRepo()
+	r.candidates = []models.Candidate{
+		{UserID: "backend", Tags: []string{"go"}},
+		{UserID: "dba", Tags: []string{"db", "go"}},
+		{UserID: "designer", Tags: []string{"frontend"}},
+	}
+	svc := service.New(r, service.WithRandomizer(fixedRand{v: 1}))
+
+	_, err := svc.CreatePullRequest(context.Background(), models.PR{
+		ID: "pr1", Name: "PR", AuthorID: "author", RequiredTags: []string{" DB "},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := r.created.AssignedReviewers
+	if len(got) != 2 || got[0] != "dba" {
+		t.Errorf("первым должен быть назначен dba с тегом db, получили %v", got)
+	}
+	if len(r.created.RequiredTags) != 1 || r.created.RequiredTags[0] != "db" {
+		t.Errorf("теги должны нормализоваться, получили %v", r.created.RequiredTags)
+	}
+}
+
+func TestRemindStalePRsMarksOnlyDelivered(t *testing.T) {
+	r := newStubRepo()
+	created := time.Now().Add(-72 * time.Hour)
+	r.stale = []models.StalePR{
+		{ID: "pr1", AssignedReviewers: []string{"rev1"}, CreatedAt: created},
+		{ID: "pr2", AssignedReviewers: []string{"rev1"}, CreatedAt: created},
+	}
+	notifier := &recordingNotifier{failFor: "pr2"}
+	svc := service.New(r, service.WithRandomizer(firstRand{}), service.WithNotifier(notifier))
+
+	n, err := svc.RemindStalePRs(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 1 || len(notifier.events) != 1 || notifier.events[0].Type != notify.EventStalePR {
+		t.Errorf("ожидалось одно напоминание pr.stale, получили %d: %+v", n, notifier.events)
+	}
+	if len(r.reminded) != 1 || r.reminded[0] != "pr1" {
+		t.Errorf("отмечен должен быть только pr1, получили %v", r.reminded)
+	}
+}
+
+func TestRelayOutboxMarksFailedEventsForRetry(t *testing.T) {
+	r := newStubRepo()
+	r.prs["pr1"] = &models.PR{ID: "pr1", Name: "Fix", AuthorID: "author"}
+	r.outbox = []models.DomainEvent{
+		{ID: 1, Type: models.DomainReviewerAssigned, PRID: "pr1", Recipients: []string{"rev1"}},
+		{ID: 2, Type: models.DomainPRMerged, PRID: "pr2"},
+		{ID: 3, Type: models.DomainTeamCreated, TeamName: "backend"},
+	}
+	notifier := &recordingNotifier{failFor: "pr2"}
+	svc := service.New(r, service.WithRandomizer(firstRand{}), service.WithNotifier(notifier))
+
+	n, err := svc.RelayOutbox(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 2 || len(r.published) != 2 || r.published[0] != 1 || r.published[1] != 3 {
+		t.Errorf("опубликованы должны быть события 1 и 3, получили %d: %v", n, r.published)
+	}
+	if _, ok := r.failed[2]; !ok || len(r.failed) != 1 {
+		t.Errorf("событие 2 должно остаться на повтор, получили %v", r.failed)
+	}
+	if ev := notifier.events[0]; ev.PRName != "Fix" || ev.AuthorID != "author" {
+		t.Errorf("событие должно быть дополнено данными PR, получили %+v", ev)
+	}
+}
+
+// slowRepo ждет отмены контекста в чтении команды и деактивации, как запрос
+// к перегруженной БД.
+type slowRepo struct{ *stubRepo }
+
+func (slowRepo) GetTeam(ctx context.Context, _ string) (*models.Team, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (slowRepo) DeactivateTeamAndReassignPRs(
+	ctx context.Context,
+	_ string,
+	_ interface{ Intn(int) int },
+) (*repo.DeactivationResult, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestOperationTimeoutsByClass(t *testing.T) {
+	svc := service.New(slowRepo{newStubRepo()}, service.WithRandomizer(firstRand{}), service.WithTimeouts(service.Timeouts{
+		Read: 10 * time.Millisecond,
+		Bulk: 100 * time.Millisecond,
+	}))
+
+	start := time.Now()
+	_, err := svc.GetTeam(context.Background(), "t")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("чтение: ожидался DeadlineExceeded, получили %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= 100*time.Millisecond {
+		t.Errorf("чтение должно прерываться по сроку Read, прошло %s", elapsed)
+	}
+
+	start = time.Now()
+	_, _, err = svc.DeactivateTeam(context.Background(), "t")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("деактивация: ожидался DeadlineExceeded, получили %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("деактивация должна получить срок Bulk, прервана через %s", elapsed)
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"prreviewer/internal/models"
+)
+
+// DefaultSLAWindow — время, за которое ревьювер должен одобрить PR или отказаться от ревью.
+const DefaultSLAWindow = 24 * time.Hour
+
+var ErrInvalidSLAWindow = errors.New("invalid SLA window")
+
+// GetSLAStats возвращает соблюдение SLA реакции на ревью по командам и
+// пользователям; нулевое окно — DefaultSLAWindow.
+func (s *Service) GetSLAStats(ctx context.Context, filter models.SLAFilter) (*models.SLAStats, error) {
+	ctx, cancel := s.readContext(ctx)
+	defer cancel()
+
+	if filter.Window < 0 {
+		return nil, fmt.Errorf("%w: must not be negative", ErrInvalidSLAWindow)
+	}
+	if filter.Window == 0 {
+		filter.Window = DefaultSLAWindow
+	}
+	if filter.TeamName != "" {
+		exists, err := s.repo.TeamExists(ctx, filter.TeamName)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrTeamNotFound
+		}
+	}
+
+	stats, err := s.repo.GetSLAStats(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	stats.WindowSeconds = int64(filter.Window / time.Second)
+
+	var responseSum float64
+	for i := range stats.Teams {
+		team := &stats.Teams[i]
+		team.CompliancePercent = compliancePercent(team.WithinSLA, team.Due)
+		stats.Overall.Due += team.Due
+		stats.Overall.Responded += team.Responded
+		stats.Overall.WithinSLA += team.WithinSLA
+		if team.AvgResponseSeconds != nil {
+			responseSum += *team.AvgResponseSeconds * float64(team.Responded)
+		}
+	}
+	for i := range stats.Users {
+		stats.Users[i].CompliancePercent = compliancePercent(stats.Users[i].WithinSLA, stats.Users[i].Due)
+	}
+	stats.Overall.TeamName = filter.TeamName
+	stats.Overall.CompliancePercent = compliancePercent(stats.Overall.WithinSLA, stats.Overall.Due)
+	if stats.Overall.Responded > 0 {
+		avg := responseSum / float64(stats.Overall.Responded)
+		stats.Overall.AvgResponseSeconds = &avg
+	}
+	return stats, nil
+}
+
+func compliancePercent(within, due int) *float64 {
+	if due == 0 {
+		return nil
+	}
+	p := float64(within) * 100 / float64(due)
+	return &p
+}
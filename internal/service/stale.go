@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"prreviewer/internal/logging"
+	"prreviewer/internal/models"
+	"prreviewer/internal/notify"
+)
+
+// DefaultStaleAfter — возраст PR без одобрений, после которого он считается зависшим.
+const DefaultStaleAfter = 48 * time.Hour
+
+// staleReminderBatch ограничивает число напоминаний за один запуск задачи.
+const staleReminderBatch = 100
+
+var ErrInvalidStaleAge = errors.New("invalid stale age")
+
+// WithNotifier задает канал уведомлений; по умолчанию события пишутся в лог.
+func WithNotifier(n notify.Notifier) Option {
+	return func(s *Service) { s.notifier = n }
+}
+
+// WithStaleAfter задает порог, после которого открытый PR без одобрений считается зависшим.
+func WithStaleAfter(d time.Duration) Option {
+	return func(s *Service) { s.staleAfter = d }
+}
+
+// ListStalePRs возвращает открытые PR без одобрений старше olderThan (PR с
+// приоритетом high — старше половины olderThan); нулевой olderThan — порог сервиса.
+func (s *Service) ListStalePRs(
+	ctx context.Context,
+	olderThan time.Duration,
+	page models.Page,
+) ([]models.StalePR, int, error) {
+	ctx, cancel := s.readContext(ctx)
+	defer cancel()
+
+	if olderThan < 0 {
+		return nil, 0, fmt.Errorf("%w: must not be negative", ErrInvalidStaleAge)
+	}
+	if olderThan == 0 {
+		olderThan = s.staleAfter
+	}
+	return s.repo.ListStalePRs(ctx, staleFilter(time.Now(), olderThan, false), page)
+}
+
+// RemindStalePRs отправляет напоминания по зависшим PR. Повторное напоминание
+// по тому же PR уходит не раньше, чем через порог зависания (для high — половину).
+func (s *Service) RemindStalePRs(ctx context.Context) (int, error) {
+	now := time.Now()
+	prs, _, err := s.repo.ListStalePRs(ctx,
+		staleFilter(now, s.staleAfter, true),
+		models.Page{Limit: staleReminderBatch})
+	if err != nil {
+		return 0, err
+	}
+
+	reminded := make([]string, 0, len(prs))
+	for _, pr := range prs {
+		err := s.notifier.Notify(ctx, notify.Event{
+			Type:       notify.EventStalePR,
+			PRID:       pr.ID,
+			PRName:     pr.Name,
+			PRURL:      pr.URL,
+			AuthorID:   pr.AuthorID,
+			Reviewers:  pr.AssignedReviewers,
+			Recipients: pr.AssignedReviewers,
+			Message:    fmt.Sprintf("PR ждет ревью %s", now.Sub(pr.CreatedAt).Truncate(time.Hour)),
+			CreatedAt:  now,
+		})
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to send stale PR reminder", "pr_id", pr.ID, "error", err)
+			continue
+		}
+		reminded = append(reminded, pr.ID)
+	}
+
+	if len(reminded) == 0 {
+		return 0, nil
+	}
+	if err := s.repo.MarkReminded(ctx, reminded); err != nil {
+		return 0, err
+	}
+	return len(reminded), nil
+}
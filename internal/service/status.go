@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+)
+
+// SetPullRequestStatus переводит открытый PR между открытыми состояниями
+// (OPEN, IN_REVIEW, CHANGES_REQUESTED). В MERGED и CLOSED PR переводят merge и
+// закрытие. Повтор текущего состояния идемпотентен.
+func (s *Service) SetPullRequestStatus(
+	ctx context.Context,
+	prID string,
+	status models.PRStatus,
+	expectedVersion *int,
+) (*models.PR, error) {
+	if !status.IsOpen() {
+		return nil, ErrInvalidStatus
+	}
+
+	ctx, cancel := s.writeContext(ctx)
+	defer cancel()
+
+	ctx = repo.ReadFromPrimary(ctx)
+	pr, err := s.repo.GetPR(ctx, prID)
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, ErrPRNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case pr.Status == models.StatusMerged:
+		return nil, ErrPRMerged
+	case pr.Status == models.StatusClosed:
+		return nil, ErrPRClosed
+	case pr.Status == status:
+		return pr, nil
+	}
+
+	if expectedVersion != nil && *expectedVersion != pr.Version {
+		return nil, ErrVersionConflict
+	}
+
+	if err := s.repo.SetPRStatus(ctx, prID, status, &pr.Version); err != nil {
+		if errors.Is(err, repo.ErrVersionConflict) {
+			return nil, staleWriteErr(expectedVersion)
+		}
+		return nil, err
+	}
+
+	s.recordAudit(ctx, models.AuditEntry{
+		Action:  models.AuditPRStatusChanged,
+		UserID:  pr.AuthorID,
+		PRID:    prID,
+		Details: map[string]any{"from": pr.Status, "to": status},
+	})
+	return s.repo.GetPR(ctx, prID)
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+)
+
+var ErrInvalidTag = errors.New("invalid tag")
+
+// MaxTagLength совпадает с размером колонки user_tags.tag.
+const MaxTagLength = 64
+
+// SetUserTags заменяет теги навыков пользователя.
+func (s *Service) SetUserTags(ctx context.Context, uid string, tags []string) (*models.User, error) {
+	ctx, cancel := s.writeContext(ctx)
+	defer cancel()
+
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	if tags == nil {
+		tags = []string{}
+	}
+
+	err = s.repo.SetUserTags(ctx, uid, tags)
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.GetUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	s.recordAudit(ctx, models.AuditEntry{
+		Action:   models.AuditUserTagsChanged,
+		TeamName: user.TeamName,
+		UserID:   uid,
+		Details:  map[string]any{"tags": tags},
+	})
+	return user, nil
+}
+
+// normalizeTags приводит теги к нижнему регистру, убирает пробелы и повторы.
+// nil остается nil: при записи участника это означает «не менять теги».
+func normalizeTags(tags []string) ([]string, error) {
+	if tags == nil {
+		return nil, nil
+	}
+
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || len(tag) > MaxTagLength {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidTag, tag)
+		}
+		result = append(result, tag)
+	}
+	slices.Sort(result)
+	return slices.Compact(result), nil
+}
+
+// splitByTags делит кандидатов на тех, у кого есть хотя бы один из тегов, и остальных.
+func splitByTags(candidates []models.Candidate, tags []string) (tagged, rest []models.Candidate) {
+	for _, c := range candidates {
+		if len(matchedTags(c, tags)) > 0 {
+			tagged = append(tagged, c)
+		} else {
+			rest = append(rest, c)
+		}
+	}
+	return tagged, rest
+}
+
+// matchedTags возвращает теги кандидата из tags.
+func matchedTags(c models.Candidate, tags []string) []string {
+	var matched []string
+	for _, t := range c.Tags {
+		if slices.Contains(tags, t) {
+			matched = append(matched, t)
+		}
+	}
+	return matched
+}
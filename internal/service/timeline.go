@@ -0,0 +1,158 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+)
+
+// timelineRank упорядочивает шаги с одинаковым временем: в одной транзакции PR
+// создается раньше назначений, а снятие ревьюверов следует за закрытием.
+var timelineRank = map[string]int{
+	models.TimelineCreated:  0,
+	models.EventAssigned:    1,
+	models.EventReassigned:  2,
+	models.EventDeclined:    2,
+	models.EventUnassigned:  2,
+	models.TimelineApproved: 2,
+	models.TimelineMerged:   3,
+	models.TimelineClosed:   3,
+	models.EventReleased:    4,
+}
+
+// GetPRTimeline восстанавливает жизненный цикл PR, в том числе архивного:
+// создание, назначения и замены ревьюверов из assignment_events, одобрения
+// и исполнителей действий из журнала аудита, merge и закрытие по времени из PR.
+func (s *Service) GetPRTimeline(ctx context.Context, prID string) (*models.PRTimeline, error) {
+	ctx, cancel := s.readContext(ctx)
+	defer cancel()
+
+	result := &models.PRTimeline{PRID: prID}
+	pr, err := s.repo.GetPR(ctx, prID)
+	if errors.Is(err, repo.ErrNotFound) {
+		archived, archErr := s.repo.GetArchivedPR(ctx, prID)
+		if errors.Is(archErr, repo.ErrNotFound) {
+			return nil, ErrPRNotFound
+		}
+		if archErr != nil {
+			return nil, archErr
+		}
+		pr, err, result.Archived = &archived.PR, nil, true
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.Status = pr.Status
+
+	events, err := s.repo.ListAssignmentEvents(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+	audit, err := s.prAuditEntries(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Журнал отдается от новых к старым. Исполнитель действия ищется по
+	// действию и затронутым ревьюверам; повтор берет самую раннюю запись.
+	slices.Reverse(audit)
+	actors := map[string]string{}
+	var entries []models.TimelineEntry
+	approved := map[string]bool{}
+	for _, e := range audit {
+		newUserID, _ := e.Details["new_user_id"].(string)
+		key := actorKey(e.Action, e.UserID, newUserID)
+		if _, ok := actors[key]; !ok {
+			actors[key] = e.Actor
+		}
+		if e.Action == models.AuditPRApproved && !approved[e.UserID] {
+			approved[e.UserID] = true
+			entries = append(entries, models.TimelineEntry{
+				Type: models.TimelineApproved, Actor: e.UserID, UserID: e.UserID, At: e.CreatedAt,
+			})
+		}
+	}
+
+	if pr.CreatedAt != nil {
+		entries = append(entries, models.TimelineEntry{
+			Type:   models.TimelineCreated,
+			Actor:  cmp.Or(actors[actorKey(models.AuditPRCreated, pr.AuthorID, "")], pr.AuthorID),
+			UserID: pr.AuthorID,
+			At:     *pr.CreatedAt,
+		})
+	}
+	for _, ev := range events {
+		entry := models.TimelineEntry{
+			Type:      ev.EventType,
+			UserID:    ev.UserID,
+			NewUserID: ev.NewUserID,
+			Reason:    ev.Reason,
+			At:        ev.CreatedAt,
+		}
+		switch ev.EventType {
+		case models.EventDeclined:
+			entry.Actor = ev.UserID
+		case models.EventReassigned:
+			entry.Actor = actors[actorKey(models.AuditReviewerReassigned, ev.UserID, ev.NewUserID)]
+		case models.EventUnassigned:
+			entry.Actor = actors[actorKey(models.AuditReviewerUnassigned, ev.UserID, "")]
+		case models.EventAssigned:
+			// Исполнитель есть только у назначений через /pullRequest/assign.
+			entry.Actor = actors[actorKey(models.AuditReviewerAssigned, ev.NewUserID, "")]
+		}
+		entries = append(entries, entry)
+	}
+	if pr.MergedAt != nil {
+		entries = append(entries, models.TimelineEntry{
+			Type: models.TimelineMerged, Actor: actors[actorKey(models.AuditPRMerged, pr.AuthorID, "")], At: *pr.MergedAt,
+		})
+	}
+	if pr.ClosedAt != nil {
+		entries = append(entries, models.TimelineEntry{
+			Type: models.TimelineClosed, Actor: actors[actorKey(models.AuditPRClosed, pr.AuthorID, "")], At: *pr.ClosedAt,
+		})
+	}
+
+	slices.SortStableFunc(entries, func(a, b models.TimelineEntry) int {
+		if c := parseRFC3339(a.At).Compare(parseRFC3339(b.At)); c != 0 {
+			return c
+		}
+		return timelineRank[a.Type] - timelineRank[b.Type]
+	})
+	result.Timeline = entries
+	if result.Timeline == nil {
+		result.Timeline = []models.TimelineEntry{}
+	}
+	return result, nil
+}
+
+// prAuditEntries читает весь журнал аудита PR страницами по MaxPageLimit, от
+// новых записей к старым.
+func (s *Service) prAuditEntries(ctx context.Context, prID string) ([]models.AuditEntry, error) {
+	var all []models.AuditEntry
+	for {
+		page, total, err := s.repo.ListAuditEntries(ctx, models.AuditFilter{PRID: prID},
+			models.Page{Limit: models.MaxPageLimit, Offset: len(all)})
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) == 0 || len(all) >= total {
+			return all, nil
+		}
+	}
+}
+
+func actorKey(action, userID, newUserID string) string {
+	return action + "|" + userID + "|" + newUserID
+}
+
+func parseRFC3339(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// Timeouts — предельное время операций сервиса по классам; срок отсчитывается
+// от начала операции и не продлевает дедлайн запроса. 0 — без своего срока.
+type Timeouts struct {
+	Read  time.Duration // чтение: команды, PR, ревью, статистика
+	Write time.Duration // изменение одного PR, пользователя или настроек команды
+	// Bulk — деактивация команды, удаление пользователя и импорт, которые
+	// переназначают ревью по многим PR в одной транзакции.
+	Bulk time.Duration
+}
+
+// DefaultTimeouts — сроки операций по умолчанию.
+var DefaultTimeouts = Timeouts{Read: 2 * time.Second, Write: 5 * time.Second, Bulk: 10 * time.Second}
+
+// Max возвращает самый долгий из сроков.
+func (t Timeouts) Max() time.Duration {
+	return max(t.Read, t.Write, t.Bulk)
+}
+
+// WithTimeouts задает сроки операций сервиса.
+func WithTimeouts(t Timeouts) Option {
+	return func(s *Service) { s.timeouts = t }
+}
+
+func (s *Service) readContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, s.timeouts.Read)
+}
+
+func (s *Service) writeContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, s.timeouts.Write)
+}
+
+func (s *Service) bulkContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, s.timeouts.Bulk)
+}
+
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
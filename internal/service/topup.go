@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+)
+
+// topUpReason — причина в истории назначений для ревьювера, добавленного добором.
+const topUpReason = "top-up to reviewer_count"
+
+// errTopUpDone — PR укомплектован или подходящих кандидатов больше нет.
+var errTopUpDone = errors.New("nothing to top up")
+
+// TopUpReviewers добирает ревьюверов открытого PR до reviewer_count политики
+// команды автора тем же алгоритмом, что и при создании PR, и возвращает PR с
+// причинами выбора добавленных ревьюверов. Ревьюверы добавляются по одному под
+// блокировкой PR, поэтому параллельные доборы не превысят reviewer_count.
+// Укомплектованный PR возвращается без изменений.
+func (s *Service) TopUpReviewers(ctx context.Context, prID string) (*models.PR, []string, error) {
+	ctx, cancel := s.writeContext(ctx)
+	defer cancel()
+
+	ctx = repo.ReadFromPrimary(ctx)
+	var pr *models.PR
+	added := []string{}
+	reasons := map[string]string{}
+	for {
+		var reason string
+		updated, newReviewerID, err := s.replaceReviewer(ctx, prID, func(ctx context.Context, pr *models.PR) (models.ReviewerChange, error) {
+			switch pr.Status {
+			case models.StatusMerged:
+				return models.ReviewerChange{}, ErrPRMerged
+			case models.StatusClosed:
+				return models.ReviewerChange{}, ErrPRClosed
+			}
+
+			author, err := s.repo.GetUser(ctx, pr.AuthorID)
+			if errors.Is(err, repo.ErrNotFound) {
+				return models.ReviewerChange{}, ErrAuthorNotFound
+			}
+			if err != nil {
+				return models.ReviewerChange{}, err
+			}
+			picked, why, err := s.assignReviewers(ctx, author, *pr)
+			if err != nil {
+				return models.ReviewerChange{}, err
+			}
+			if len(picked) == 0 {
+				return models.ReviewerChange{}, errTopUpDone
+			}
+			if err := ensureNotAuthor(pr.AuthorID, picked[0]); err != nil {
+				return models.ReviewerChange{}, err
+			}
+
+			reason = why[picked[0]]
+			return models.ReviewerChange{
+				PRID:          prID,
+				NewReviewerID: picked[0],
+				EventType:     models.EventAssigned,
+				Reason:        topUpReason,
+			}, nil
+		})
+		if errors.Is(err, errTopUpDone) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		pr = updated
+		added = append(added, newReviewerID)
+		reasons[newReviewerID] = reason
+	}
+
+	if pr == nil {
+		var err error
+		pr, err = s.repo.GetPR(ctx, prID)
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, nil, ErrPRNotFound
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(reasons) > 0 {
+		pr.AssignmentReasons = reasons
+	}
+	return pr, added, nil
+}
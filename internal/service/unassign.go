@@ -0,0 +1,37 @@
+package service
+
+import (
+	"cmp"
+	"context"
+
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+)
+
+// unassignReason — причина снятия ревьювера, если она не указана.
+const unassignReason = "unassigned by admin"
+
+// UnassignReviewer снимает ревьювера с открытого PR без замены. В отличие от
+// ReassignReviewer кандидат не нужен: у PR может не остаться ревьюверов.
+func (s *Service) UnassignReviewer(
+	ctx context.Context,
+	prID, userID, reason string,
+	expectedVersion *int,
+) (*models.PR, error) {
+	ctx, cancel := s.writeContext(ctx)
+	defer cancel()
+
+	ctx = repo.ReadFromPrimary(ctx)
+	pr, _, err := s.replaceReviewer(ctx, prID, func(ctx context.Context, pr *models.PR) (models.ReviewerChange, error) {
+		if err := checkReassignable(pr, userID, expectedVersion); err != nil {
+			return models.ReviewerChange{}, err
+		}
+		return models.ReviewerChange{
+			PRID:          prID,
+			OldReviewerID: userID,
+			EventType:     models.EventUnassigned,
+			Reason:        cmp.Or(reason, unassignReason),
+		}, nil
+	})
+	return pr, err
+}
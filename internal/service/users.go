@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+)
+
+// DeleteUser удаляет пользователя, переназначая его открытые ревью на коллег по команде.
+func (s *Service) DeleteUser(ctx context.Context, uid string) (*repo.UserDeletionResult, error) {
+	ctx, cancel := s.bulkContext(ctx)
+	defer cancel()
+
+	result, err := s.repo.DeleteUserAndReassignPRs(ctx, uid, s.random(ctx))
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, mapReviewerErr(err)
+	}
+
+	s.recordAudit(ctx, models.AuditEntry{
+		Action: models.AuditUserDeleted,
+		UserID: uid,
+		Details: map[string]any{
+			"reassignments":        len(result.Reassignments),
+			"archived_assignments": result.ArchivedAssignments,
+		},
+	})
+	return result, nil
+}
+
+// ListUsers ищет пользователей по команде, активности и подстроке имени.
+func (s *Service) ListUsers(
+	ctx context.Context,
+	filter models.UserFilter,
+	page models.Page,
+) ([]models.User, int, error) {
+	ctx, cancel := s.readContext(ctx)
+	defer cancel()
+
+	return s.repo.ListUsers(ctx, filter, page)
+}
+
+// UserTeams возвращает текущие команды заведенных пользователей из uids;
+// по ним проверяются права на изменение пользователя.
+func (s *Service) UserTeams(ctx context.Context, uids []string) (map[string]string, error) {
+	ctx, cancel := s.readContext(ctx)
+	defer cancel()
+
+	return s.repo.GetUserTeamNames(repo.ReadFromPrimary(ctx), uids)
+}
+
+// GetUserProfile возвращает пользователя с текущей нагрузкой на ревью и
+// доступностью.
+func (s *Service) GetUserProfile(ctx context.Context, uid string) (*models.UserProfile, error) {
+	ctx, cancel := s.readContext(ctx)
+	defer cancel()
+
+	p, err := s.repo.GetUserProfile(ctx, uid)
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	return p, err
+}
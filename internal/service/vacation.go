@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"prreviewer/internal/models"
+	"prreviewer/internal/repo"
+)
+
+var (
+	ErrInvalidVacation = errors.New("invalid vacation window")
+	ErrInvalidSnooze   = errors.New("invalid snooze duration")
+)
+
+// MaxSnooze — предел паузы назначений; для более долгого отсутствия есть отпуск.
+const MaxSnooze = 7 * 24 * time.Hour
+
+// SetVacation добавляет окно отсутствия; пока оно покрывает текущий момент,
+// пользователь не попадает в кандидаты на ревью.
+func (s *Service) SetVacation(ctx context.Context, v models.Vacation) (*models.Vacation, error) {
+	ctx, cancel := s.writeContext(ctx)
+	defer cancel()
+
+	if !v.EndsAt.After(v.StartsAt) {
+		return nil, fmt.Errorf("%w: end must be after start", ErrInvalidVacation)
+	}
+	if !v.EndsAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: window is already over", ErrInvalidVacation)
+	}
+
+	user, err := s.repo.GetUser(ctx, v.UserID)
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := s.repo.AddVacation(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordAudit(ctx, models.AuditEntry{
+		Action:   models.AuditVacationSet,
+		TeamName: user.TeamName,
+		UserID:   v.UserID,
+		Details: map[string]any{
+			"starts_at": v.StartsAt.Format(time.RFC3339),
+			"ends_at":   v.EndsAt.Format(time.RFC3339),
+		},
+	})
+	return saved, nil
+}
+
+// ExpireVacations удаляет закончившиеся отпуска и возвращает пользователей,
+// снова доступных для ревью. Вызывается фоновой задачей воркера.
+func (s *Service) ExpireVacations(ctx context.Context) ([]string, error) {
+	users, err := s.repo.ExpireVacations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, uid := range users {
+		s.recordAudit(ctx, models.AuditEntry{Action: models.AuditVacationEnded, UserID: uid})
+	}
+	return users, nil
+}
+
+// SnoozeUser приостанавливает новые назначения пользователю на d: он остается
+// активным и сохраняет текущие ревью. d == 0 снимает паузу. Возвращает момент
+// окончания паузы или nil.
+func (s *Service) SnoozeUser(ctx context.Context, uid string, d time.Duration) (*time.Time, error) {
+	ctx, cancel := s.writeContext(ctx)
+	defer cancel()
+
+	if d < 0 || d > MaxSnooze {
+		return nil, fmt.Errorf("%w: must be between 0 and %s", ErrInvalidSnooze, MaxSnooze)
+	}
+	var until *time.Time
+	if d > 0 {
+		t := time.Now().Add(d).Truncate(time.Second)
+		until = &t
+	}
+
+	user, err := s.repo.GetUser(ctx, uid)
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SnoozeUser(ctx, uid, until); err != nil {
+		if errors.Is(err, repo.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	details := map[string]any{}
+	if until != nil {
+		details["snoozed_until"] = until.Format(time.RFC3339)
+	}
+	s.recordAudit(ctx, models.AuditEntry{
+		Action:   models.AuditUserSnoozed,
+		TeamName: user.TeamName,
+		UserID:   uid,
+		Details:  details,
+	})
+	return until, nil
+}
@@ -0,0 +1,195 @@
+"use strict";
+
+// Дашборд строится только из публичных JSON-ручек сервиса.
+
+const SLA_WARN_PERCENT = 90;
+const SLA_BAD_PERCENT = 70;
+const API_PREFIX = "/api/v1";
+
+async function api(path, params = {}) {
+  const query = new URLSearchParams(params).toString();
+  const url = API_PREFIX + path;
+  const resp = await fetch(query ? `${url}?${query}` : url, {headers: {Accept: "application/json"}});
+  const body = await resp.json().catch(() => null);
+  if (!resp.ok) {
+    const message = body && body.error ? `${body.error.code}: ${body.error.message}` : resp.statusText;
+    throw new Error(`${path}: ${message}`);
+  }
+  return body;
+}
+
+function el(tag, attrs = {}, ...children) {
+  const node = document.createElement(tag);
+  for (const [name, value] of Object.entries(attrs)) {
+    if (name === "onclick") {
+      node.addEventListener("click", value);
+    } else {
+      node.setAttribute(name, value);
+    }
+  }
+  for (const child of children) {
+    node.append(child === null || child === undefined ? "" : child);
+  }
+  return node;
+}
+
+function showError(err) {
+  const box = document.getElementById("error");
+  box.textContent = err.message;
+  box.hidden = false;
+}
+
+function duration(seconds) {
+  if (seconds === null || seconds === undefined) {
+    return "—";
+  }
+  if (seconds < 3600) {
+    return `${Math.round(seconds / 60)} мин`;
+  }
+  if (seconds < 86400) {
+    return `${(seconds / 3600).toFixed(1)} ч`;
+  }
+  return `${(seconds / 86400).toFixed(1)} д`;
+}
+
+function percent(value) {
+  return value === null || value === undefined ? "—" : `${value.toFixed(0)}%`;
+}
+
+function cards(container, items) {
+  container.replaceChildren(...items.map(([label, value]) =>
+    el("div", {class: "card"}, el("div", {class: "value"}, value), el("div", {class: "label"}, label))));
+}
+
+// barChart рисует горизонтальные столбцы rows — {name, value, label, className, onclick};
+// max — значение полного столбца, по умолчанию наибольшее из rows.
+function barChart(container, rows, max) {
+  if (rows.length === 0) {
+    container.replaceChildren(el("p", {class: "empty"}, "Нет данных"));
+    return;
+  }
+  const top = max || Math.max(1, ...rows.map((r) => r.value));
+  container.replaceChildren(...rows.map((r) => {
+    const bar = el("div", {class: `bar ${r.className || ""}`});
+    bar.style.width = `${Math.min(100, (r.value / top) * 100)}%`;
+    const attrs = r.onclick ? {class: "row link", onclick: r.onclick} : {class: "row"};
+    return el("div", attrs,
+      el("span", {class: "name", title: r.name}, r.name),
+      el("div", {class: "track"}, bar),
+      el("span", {class: "value"}, r.label === undefined ? String(r.value) : r.label));
+  }));
+}
+
+function slaRows(items, name) {
+  return items.map((c) => ({
+    name: name(c),
+    value: c.compliance_percent || 0,
+    label: `${percent(c.compliance_percent)} (${c.within_sla}/${c.due_reviews})`,
+    className: c.compliance_percent === null ? "" :
+      c.compliance_percent < SLA_BAD_PERCENT ? "bad" :
+      c.compliance_percent < SLA_WARN_PERCENT ? "warn" : "",
+  }));
+}
+
+async function loadOverview() {
+  const stats = await api("/stats", {limit: 20});
+  cards(document.getElementById("overview"), [
+    ["Команды", stats.total_teams],
+    ["Пользователи", stats.total_users],
+    ["Открытые PR", stats.open_prs],
+    ["Смерженные PR", stats.merged_prs],
+    ["Закрытые PR", stats.closed_prs],
+    ["До merge (медиана)", duration(stats.time_to_merge.median_seconds)],
+    ["До merge (p90)", duration(stats.time_to_merge.p90_seconds)],
+  ]);
+  barChart(document.getElementById("assignments"), stats.assignments_by_user.map((u) => ({
+    name: u.username || u.user_id,
+    value: u.total_assignments,
+    onclick: () => loadReviewer(u.user_id, u.username).catch(showError),
+  })));
+}
+
+async function loadSLA() {
+  const sla = await api("/stats/sla");
+  barChart(document.getElementById("sla"), slaRows(sla.teams, (c) => c.team_name), 100);
+}
+
+async function loadTeams() {
+  const list = await api("/team/list", {limit: 1000});
+  const tbody = document.querySelector("#teams tbody");
+  tbody.replaceChildren(...list.teams.map((t) =>
+    el("tr", {class: "link", onclick: () => loadTeam(t.team_name).catch(showError)},
+      el("td", {}, t.team_name),
+      el("td", {}, String(t.member_count)),
+      el("td", {}, String(t.active_members)),
+      el("td", {}, String(t.open_prs)))));
+}
+
+async function loadTeam(teamName) {
+  const [stats, sla] = await Promise.all([
+    api("/stats/team", {team_name: teamName}),
+    api("/stats/sla", {team_name: teamName}),
+  ]);
+  document.getElementById("team-name").textContent = teamName;
+  cards(document.getElementById("team-summary"), [
+    ["Открытые PR", stats.open_prs],
+    ["Смерженные PR", stats.merged_prs],
+    ["Среднее до merge", duration(stats.avg_time_to_merge_seconds)],
+    ["Доля замен", percent(stats.reassignment_rate * 100)],
+    ["SLA", percent(sla.overall.compliance_percent)],
+  ]);
+  barChart(document.getElementById("team-load"), stats.assignments_by_member.map((m) => ({
+    name: (m.username || m.user_id) + (m.is_active ? "" : " (неактивен)"),
+    value: m.open_assignments,
+    onclick: () => loadReviewer(m.user_id, m.username).catch(showError),
+  })));
+  barChart(document.getElementById("team-sla"), slaRows(sla.users, (c) => c.username || c.user_id), 100);
+  const section = document.getElementById("team");
+  section.hidden = false;
+  section.scrollIntoView({behavior: "smooth"});
+}
+
+function availability(user) {
+  if (user.on_vacation) {
+    return `в отпуске до ${user.vacation.ends_at.slice(0, 10)}`;
+  }
+  if (user.snoozed_until) {
+    return `пауза до ${user.snoozed_until.slice(0, 16).replace("T", " ")}`;
+  }
+  return user.is_active ? "доступен" : "неактивен";
+}
+
+// OPEN_STATUSES — незавершенные состояния PR: ревью в них считается открытым.
+const OPEN_STATUSES = ["OPEN", "IN_REVIEW", "CHANGES_REQUESTED"];
+
+async function loadReviewer(userID, username) {
+  // Смерженные и закрытые PR отсекает сервер: по запросу на каждое открытое состояние.
+  const [profile, ...reviews] = await Promise.all([
+    api("/users/get", {user_id: userID}),
+    ...OPEN_STATUSES.map((status) => api("/users/getReview", {user_id: userID, status})),
+  ]);
+  const open = reviews.flatMap((r) => r.pull_requests);
+  document.getElementById("reviewer-name").textContent = username || userID;
+  cards(document.getElementById("reviewer-summary"), [
+    ["Открытые ревью", profile.user.open_reviews],
+    ["Одобрения", profile.user.approvals_given],
+    ["Средняя реакция", duration(profile.user.avg_response_seconds)],
+    ["Статус", availability(profile.user)],
+  ]);
+  const tbody = document.querySelector("#reviews tbody");
+  tbody.replaceChildren(...open.map((pr) =>
+    el("tr", {},
+      el("td", {}, pr.url ? el("a", {href: pr.url, target: "_blank", rel: "noopener"}, pr.pull_request_id) : pr.pull_request_id),
+      el("td", {}, pr.pull_request_name),
+      el("td", {}, pr.author_id),
+      el("td", {}, pr.priority || "normal"),
+      el("td", {}, (pr.labels || []).join(", ")))));
+  if (open.length === 0) {
+    tbody.replaceChildren(el("tr", {}, el("td", {colspan: "5", class: "empty"}, "Открытых ревью нет")));
+  }
+  const section = document.getElementById("reviewer");
+  section.hidden = false;
+  section.scrollIntoView({behavior: "smooth"});
+}
+
+Promise.all([loadOverview(), loadTeams(), loadSLA()]).catch(showError);
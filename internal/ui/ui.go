@@ -0,0 +1,23 @@
+// Package ui — встроенная в бинарник страница-дашборд для тимлидов: команды,
+// открытые ревью по ревьюверам и графики статистики. Данные страница берет из
+// существующих JSON-ручек, своего API у нее нет.
+package ui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var static embed.FS
+
+// Handler отдает статику дашборда; prefix — путь, под которым смонтирован
+// обработчик, с завершающим "/".
+func Handler(prefix string) http.Handler {
+	root, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err) // каталог встроен при сборке
+	}
+	return http.StripPrefix(prefix, http.FileServerFS(root))
+}
@@ -0,0 +1,45 @@
+package ui_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"prreviewer/internal/ui"
+)
+
+func TestHandlerServesEmbeddedAssets(t *testing.T) {
+	h := ui.Handler("/ui/")
+	tests := []struct {
+		path        string
+		contentType string
+		contains    string
+	}{
+		{"/ui/", "text/html", `<script src="app.js">`},
+		{"/ui/app.js", "javascript", `api("/stats"`},
+		{"/ui/style.css", "text/css", ".chart"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: ожидался статус 200, получили %d", tt.path, rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.Contains(ct, tt.contentType) {
+			t.Errorf("%s: ожидался Content-Type %s, получили %q", tt.path, tt.contentType, ct)
+		}
+		if !strings.Contains(rec.Body.String(), tt.contains) {
+			t.Errorf("%s: в ответе нет %q", tt.path, tt.contains)
+		}
+	}
+}
+
+func TestHandlerUnknownAsset(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ui.Handler("/ui/").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ui/missing.js", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("ожидался статус 404, получили %d", rec.Code)
+	}
+}
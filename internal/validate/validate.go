@@ -0,0 +1,222 @@
+// Package validate проверяет структуры запросов по тегам validate.
+//
+// Поддерживаемые правила (через запятую):
+//
+//	required   строка не пустая (без учета пробелов), срез и map не пустые, указатель не nil;
+//	           для bool означает только обязательность поля в схеме OpenAPI
+//	max=N      длина строки в символах или число элементов среза не больше N
+//	min=N      для строк и срезов — длина не меньше N, для чисел — значение не меньше N
+//	oneof=a b  строка — одно из перечисленных значений (пустая строка допускается)
+//
+// Вложенные структуры и срезы структур проверяются рекурсивно. Теги типа
+// запроса проверяет Check: неизвестное правило или некорректный параметр
+// должны находиться при старте, а не на первом запросе.
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Errors — нарушения по путям JSON-полей ("members[0].user_id").
+type Errors map[string]string
+
+// Rule — разобранное правило тега validate.
+type Rule struct {
+	Name  string
+	Param string
+}
+
+// Rules разбирает тег validate поля.
+func Rules(tag string) []Rule {
+	if tag == "" {
+		return nil
+	}
+	var rules []Rule
+	for _, part := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(part), "=")
+		rules = append(rules, Rule{Name: name, Param: param})
+	}
+	return rules
+}
+
+// Check проверяет теги validate типа v (структуры или указателя на нее) и
+// вложенных в него типов: правила известны, параметры max и min — числа.
+func Check(v any) error {
+	return checkType(reflect.TypeOf(v), map[reflect.Type]bool{})
+}
+
+func checkType(t reflect.Type, seen map[reflect.Type]bool) error {
+	for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || seen[t] {
+		return nil
+	}
+	seen[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() && !f.Anonymous {
+			continue
+		}
+		for _, r := range Rules(f.Tag.Get("validate")) {
+			if err := r.valid(); err != nil {
+				return fmt.Errorf("validate: %s.%s: %w", t.Name(), f.Name, err)
+			}
+		}
+		if err := checkType(f.Type, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// valid сообщает, что правило известно и его параметр корректен.
+func (r Rule) valid() error {
+	switch r.Name {
+	case "required", "oneof":
+		return nil
+	case "max", "min":
+		if _, err := strconv.Atoi(r.Param); err != nil {
+			return fmt.Errorf("invalid %s=%q", r.Name, r.Param)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown rule %q", r.Name)
+}
+
+// Struct проверяет v (структуру или указатель на нее) и возвращает nil, если нарушений нет.
+func Struct(v any) Errors {
+	errs := Errors{}
+	walk(reflect.ValueOf(v), "", errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func walk(v reflect.Value, path string, errs Errors) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if f.Anonymous && name == "" {
+				walk(v.Field(i), path, errs)
+				continue
+			}
+			if !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			field := join(path, name)
+			if msg := check(v.Field(i), Rules(f.Tag.Get("validate"))); msg != "" {
+				errs[field] = msg
+				continue
+			}
+			walk(v.Field(i), field, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walk(v.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	}
+}
+
+// check возвращает сообщение о первом нарушенном правиле.
+func check(v reflect.Value, rules []Rule) string {
+	for _, r := range rules {
+		switch r.Name {
+		case "required":
+			if empty(v) {
+				return "обязательное поле"
+			}
+		case "max", "min":
+			n, err := strconv.Atoi(r.Param)
+			if err != nil {
+				panic(fmt.Sprintf("validate: invalid %s=%q", r.Name, r.Param))
+			}
+			if msg := bound(v, r.Name, n); msg != "" {
+				return msg
+			}
+		case "oneof":
+			allowed := strings.Fields(r.Param)
+			if s := v.String(); v.Kind() == reflect.String && s != "" && !slices.Contains(allowed, s) {
+				return "допустимые значения: " + strings.Join(allowed, ", ")
+			}
+		default:
+			panic("validate: unknown rule " + r.Name)
+		}
+	}
+	return ""
+}
+
+func empty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	case reflect.Bool:
+		return false
+	}
+	return v.IsZero()
+}
+
+func bound(v reflect.Value, rule string, n int) string {
+	var size int
+	unit := "символов"
+	switch v.Kind() {
+	case reflect.String:
+		size = utf8.RuneCountInString(v.String())
+	case reflect.Slice, reflect.Array, reflect.Map:
+		size, unit = v.Len(), "элементов"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size, unit = int(v.Int()), ""
+	case reflect.Pointer:
+		if v.IsNil() {
+			return ""
+		}
+		return bound(v.Elem(), rule, n)
+	default:
+		return ""
+	}
+
+	switch {
+	case rule == "max" && size > n && unit == "":
+		return fmt.Sprintf("не больше %d", n)
+	case rule == "max" && size > n:
+		return fmt.Sprintf("не больше %d %s", n, unit)
+	case rule == "min" && size < n && unit == "":
+		return fmt.Sprintf("не меньше %d", n)
+	case rule == "min" && size < n:
+		return fmt.Sprintf("не меньше %d %s", n, unit)
+	}
+	return ""
+}
+
+func join(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
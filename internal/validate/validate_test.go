@@ -0,0 +1,70 @@
+package validate_test
+
+import (
+	"strings"
+	"testing"
+
+	"prreviewer/internal/validate"
+)
+
+type member struct {
+	UserID string `json:"user_id" validate:"required,max=5"`
+	Active bool   `json:"is_active" validate:"required"`
+}
+
+type request struct {
+	TeamName string   `json:"team_name" validate:"required,max=10"`
+	Strategy string   `json:"strategy,omitempty" validate:"oneof=random least_loaded"`
+	Count    *int     `json:"count" validate:"min=1"`
+	Members  []member `json:"members" validate:"max=2"`
+}
+
+func TestStruct(t *testing.T) {
+	zero, three := 0, 3
+	tests := []struct {
+		name   string
+		req    request
+		fields []string
+	}{
+		{"корректный запрос", request{TeamName: "backend", Count: &three, Members: []member{{UserID: "u1"}}}, nil},
+		{"пустая и пробельная строка", request{TeamName: "  "}, []string{"team_name"}},
+		{"слишком длинная строка", request{TeamName: strings.Repeat("я", 11)}, []string{"team_name"}},
+		{"значение вне списка", request{TeamName: "t", Strategy: "magic"}, []string{"strategy"}},
+		{"число меньше минимума", request{TeamName: "t", Count: &zero}, []string{"count"}},
+		{"вложенные элементы", request{TeamName: "t", Members: []member{{UserID: "u1"}, {UserID: "toolong"}}}, []string{"members[1].user_id"}},
+		{"слишком много элементов", request{TeamName: "t", Members: make([]member, 3)}, []string{"members"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validate.Struct(&tt.req)
+			if len(errs) != len(tt.fields) {
+				t.Fatalf("ожидались ошибки в %v, получили %v", tt.fields, errs)
+			}
+			for _, f := range tt.fields {
+				if _, ok := errs[f]; !ok {
+					t.Errorf("ожидалась ошибка в поле %s, получили %v", f, errs)
+				}
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if err := validate.Check(request{}); err != nil {
+		t.Errorf("корректные теги: неожиданная ошибка %v", err)
+	}
+
+	type badRule struct {
+		Name string `json:"name" validate:"requred"`
+	}
+	type badParam struct {
+		Items []struct {
+			Name string `json:"name" validate:"max=ten"`
+		} `json:"items"`
+	}
+	for _, v := range []any{badRule{}, &badParam{}} {
+		if err := validate.Check(v); err == nil {
+			t.Errorf("%T: ожидалась ошибка тега", v)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package vcs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+
+	defaultGitHubURL = "https://api.github.com"
+	defaultGitLabURL = "https://gitlab.com/api/v4"
+	requestTimeout   = 10 * time.Second
+	githubPageSize   = 100
+)
+
+var (
+	ErrNoPRNumber    = errors.New("cannot determine upstream PR number")
+	ErrMergeFailed   = errors.New("vcs merge failed")
+	ErrRequestFailed = errors.New("vcs request failed")
+)
+
+// Merger мержит PR во внешней системе контроля версий.
+type Merger interface {
+	Merge(ctx context.Context, repository string, number int) error
+}
+
+// PullRequest — открытый PR во внешней системе.
+type PullRequest struct {
+	Number      int
+	Title       string
+	Author      string
+	URL         string
+	Branch      string
+	Description string
+	Labels      []string
+	Draft       bool
+}
+
+// PRNumber извлекает номер PR во внешней системе из его ссылки prURL и
+// проверяет, что ссылка ведет в repository провайдера: для GitHub —
+// .../{owner}/{repo}/pull/{number}, для GitLab —
+// .../{project}/-/merge_requests/{iid}. Номер не угадывается по id PR: без
+// ссылки или с чужой ссылкой возвращается ErrNoPRNumber.
+func PRNumber(provider, repository, prURL string) (int, error) {
+	if prURL == "" {
+		return 0, fmt.Errorf("%w: PR has no upstream url", ErrNoPRNumber)
+	}
+	u, err := url.Parse(prURL)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrNoPRNumber, err)
+	}
+
+	marker := "/pull/"
+	if provider == ProviderGitLab {
+		marker = "/-/merge_requests/"
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	i := strings.LastIndex(path, marker)
+	if i < 0 || !strings.EqualFold(strings.Trim(path[:i], "/"), strings.Trim(repository, "/")) {
+		return 0, fmt.Errorf("%w: %s is not a %s PR of %s", ErrNoPRNumber, prURL, provider, repository)
+	}
+	number, err := strconv.Atoi(path[i+len(marker):])
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("%w: %s", ErrNoPRNumber, prURL)
+	}
+	return number, nil
+}
+
+type GitHub struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+func NewGitHub(baseURL, token string) *GitHub {
+	if baseURL == "" {
+		baseURL = defaultGitHubURL
+	}
+	return &GitHub{baseURL: baseURL, token: token, client: &http.Client{Timeout: requestTimeout}}
+}
+
+// Merge вызывает PUT /repos/{owner}/{repo}/pulls/{number}/merge.
+func (g *GitHub) Merge(ctx context.Context, repository string, number int) error {
+	endpoint := fmt.Sprintf("%s/repos/%s/pulls/%d/merge", g.baseURL, repository, number)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, bytes.NewBufferString("{}"))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+g.token)
+	req.Header.Set("Content-Type", "application/json")
+	return do(g.client, req)
+}
+
+// OpenPullRequests постранично читает GET /repos/{owner}/{repo}/pulls открытых
+// PR от старых к новым, пока не наберет limit.
+func (g *GitHub) OpenPullRequests(ctx context.Context, repository string, limit int) ([]PullRequest, error) {
+	var prs []PullRequest
+	for page := 1; len(prs) < limit; page++ {
+		endpoint := fmt.Sprintf("%s/repos/%s/pulls?state=open&sort=created&direction=asc&per_page=%d&page=%d",
+			g.baseURL, repository, githubPageSize, page)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("Authorization", "Bearer "+g.token)
+
+		var batch []struct {
+			Number  int    `json:"number"`
+			Title   string `json:"title"`
+			HTMLURL string `json:"html_url"`
+			Body    string `json:"body"`
+			Draft   bool   `json:"draft"`
+			User    struct {
+				Login string `json:"login"`
+			} `json:"user"`
+			Head struct {
+				Ref string `json:"ref"`
+			} `json:"head"`
+			Labels []struct {
+				Name string `json:"name"`
+			} `json:"labels"`
+		}
+		if err := getJSON(g.client, req, &batch); err != nil {
+			return nil, err
+		}
+		for _, b := range batch {
+			pr := PullRequest{
+				Number:      b.Number,
+				Title:       b.Title,
+				Author:      b.User.Login,
+				URL:         b.HTMLURL,
+				Branch:      b.Head.Ref,
+				Description: b.Body,
+				Draft:       b.Draft,
+			}
+			for _, l := range b.Labels {
+				pr.Labels = append(pr.Labels, l.Name)
+			}
+			prs = append(prs, pr)
+		}
+		if len(batch) < githubPageSize {
+			break
+		}
+	}
+	if len(prs) > limit {
+		prs = prs[:limit]
+	}
+	return prs, nil
+}
+
+type GitLab struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+func NewGitLab(baseURL, token string) *GitLab {
+	if baseURL == "" {
+		baseURL = defaultGitLabURL
+	}
+	return &GitLab{baseURL: baseURL, token: token, client: &http.Client{Timeout: requestTimeout}}
+}
+
+// Merge вызывает PUT /projects/{id}/merge_requests/{iid}/merge.
+func (g *GitLab) Merge(ctx context.Context, repository string, number int) error {
+	endpoint := fmt.Sprintf("%s/projects/%s/merge_requests/%d/merge",
+		g.baseURL, url.PathEscape(repository), number)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("PRIVATE-TOKEN", g.token)
+	return do(g.client, req)
+}
+
+func do(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	var body struct {
+		Message string `json:"message"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	return fmt.Errorf("%w: %s %s: %d %s", ErrMergeFailed, req.Method, req.URL.Path, resp.StatusCode, body.Message)
+}
+
+// getJSON выполняет запрос и разбирает успешный ответ в v.
+func getJSON(client *http.Client, req *http.Request, v any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		var body struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		return fmt.Errorf("%w: %s %s: %d %s", ErrRequestFailed, req.Method, req.URL.Path, resp.StatusCode, body.Message)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
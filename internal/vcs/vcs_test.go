@@ -0,0 +1,39 @@
+package vcs_test
+
+import (
+	"errors"
+	"testing"
+
+	"prreviewer/internal/vcs"
+)
+
+func TestPRNumber(t *testing.T) {
+	tests := []struct {
+		provider, repository, url string
+		want                      int
+	}{
+		{vcs.ProviderGitHub, "acme/api", "https://github.com/acme/api/pull/42", 42},
+		{vcs.ProviderGitHub, "Acme/API", "https://ghe.example.com/acme/api/pull/7/", 7},
+		{vcs.ProviderGitLab, "group/sub/api", "https://gitlab.com/group/sub/api/-/merge_requests/15", 15},
+	}
+	for _, tt := range tests {
+		got, err := vcs.PRNumber(tt.provider, tt.repository, tt.url)
+		if err != nil || got != tt.want {
+			t.Errorf("PRNumber(%q, %q, %q) = %d, %v; ожидалось %d", tt.provider, tt.repository, tt.url, got, err, tt.want)
+		}
+	}
+}
+
+func TestPRNumberRejectsMissingOrForeignURL(t *testing.T) {
+	for _, tt := range []struct{ provider, repository, url string }{
+		{vcs.ProviderGitHub, "acme/api", ""},
+		{vcs.ProviderGitHub, "acme/api", "https://github.com/acme/other/pull/42"},
+		{vcs.ProviderGitHub, "acme/api", "https://github.com/acme/api/issues/42"},
+		{vcs.ProviderGitHub, "acme/api", "https://github.com/acme/api/pull/abc"},
+		{vcs.ProviderGitLab, "group/api", "https://github.com/group/api/pull/42"},
+	} {
+		if _, err := vcs.PRNumber(tt.provider, tt.repository, tt.url); !errors.Is(err, vcs.ErrNoPRNumber) {
+			t.Errorf("PRNumber(%q, %q, %q): ожидалась ErrNoPRNumber, получили %v", tt.provider, tt.repository, tt.url, err)
+		}
+	}
+}
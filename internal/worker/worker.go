@@ -0,0 +1,161 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"prreviewer/internal/logging"
+)
+
+// ErrUnknownJob — задача с таким именем не зарегистрирована.
+var ErrUnknownJob = errors.New("unknown job")
+
+// JobFunc — одна итерация фоновой задачи.
+type JobFunc func(ctx context.Context) error
+
+type job struct {
+	name     string
+	interval time.Duration
+	fn       JobFunc
+}
+
+// Ticker — источник тиков задачи.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// NewTickerFunc создает Ticker с периодом d.
+type NewTickerFunc func(d time.Duration) Ticker
+
+type timeTicker struct{ *time.Ticker }
+
+func (t timeTicker) C() <-chan time.Time { return t.Ticker.C }
+
+func newTimeTicker(d time.Duration) Ticker {
+	return timeTicker{time.NewTicker(d)}
+}
+
+// Elector выбирает среди экземпляров воркера единственный, который выполняет
+// задачи по расписанию.
+type Elector interface {
+	// Lead блокируется до получения лидерства или отмены ctx. Возвращенный
+	// контекст отменяется при потере лидерства; resign отказывается от него.
+	Lead(ctx context.Context) (leadCtx context.Context, resign func(), err error)
+}
+
+// Runner периодически запускает зарегистрированные фоновые задачи.
+type Runner struct {
+	jobs      []job
+	newTicker NewTickerFunc
+	elector   Elector
+}
+
+type Option func(*Runner)
+
+// WithTicker подменяет источник тиков (например, в тестах).
+func WithTicker(f NewTickerFunc) Option {
+	return func(r *Runner) { r.newTicker = f }
+}
+
+// WithElector запускает задачи по расписанию только на экземпляре-лидере.
+func WithElector(e Elector) Option {
+	return func(r *Runner) { r.elector = e }
+}
+
+func New(opts ...Option) *Runner {
+	r := &Runner{newTicker: newTimeTicker}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Add регистрирует задачу, выполняемую раз в interval.
+func (r *Runner) Add(name string, interval time.Duration, fn JobFunc) {
+	r.jobs = append(r.jobs, job{name: name, interval: interval, fn: fn})
+}
+
+// Len возвращает число зарегистрированных задач.
+func (r *Runner) Len() int {
+	return len(r.jobs)
+}
+
+// RunOnce синхронно выполняет одну итерацию задачи name вне расписания.
+func (r *Runner) RunOnce(ctx context.Context, name string) error {
+	for _, j := range r.jobs {
+		if j.name != name {
+			continue
+		}
+		ctx, logger := logging.With(ctx, "job", j.name)
+		logger.Info("job triggered manually")
+		if err := j.fn(ctx); err != nil {
+			logger.Error("job failed", "error", err)
+			return err
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownJob, name)
+}
+
+// Run блокируется до отмены ctx, выполняя каждую задачу в своей горутине.
+// С Elector задачи выполняются, только пока экземпляр остается лидером;
+// потерявший лидерство экземпляр снова ждет его.
+func (r *Runner) Run(ctx context.Context) {
+	if r.elector == nil {
+		r.runJobs(ctx)
+		return
+	}
+	for {
+		leadCtx, resign, err := r.elector.Lead(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				logging.FromContext(ctx).Error("leader election failed", "error", err)
+			}
+			return
+		}
+		logging.FromContext(ctx).Info("worker became leader")
+		r.runJobs(leadCtx)
+		resign()
+		if ctx.Err() != nil {
+			return
+		}
+		logging.FromContext(ctx).Warn("worker lost leadership")
+	}
+}
+
+// runJobs выполняет задачи по расписанию до отмены ctx.
+func (r *Runner) runJobs(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, j := range r.jobs {
+		wg.Add(1)
+		go func(j job) {
+			defer wg.Done()
+			r.loop(ctx, j)
+		}(j)
+	}
+	<-ctx.Done()
+	wg.Wait()
+}
+
+func (r *Runner) loop(ctx context.Context, j job) {
+	ticker := r.newTicker(j.interval)
+	defer ticker.Stop()
+
+	ctx, logger := logging.With(ctx, "job", j.name)
+	logger.Info("job started", "interval", j.interval.String())
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("job stopped")
+			return
+		case <-ticker.C():
+			if err := j.fn(ctx); err != nil {
+				logger.Error("job failed", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"prreviewer/internal/worker"
+)
+
+// manualTicker отдает тики только по команде теста.
+type manualTicker struct {
+	ch chan time.Time
+}
+
+func (t *manualTicker) C() <-chan time.Time { return t.ch }
+func (t *manualTicker) Stop()               {}
+
+func TestRunnerRunsJobOnEachTick(t *testing.T) {
+	ticker := &manualTicker{ch: make(chan time.Time)}
+	runner := worker.New(worker.WithTicker(func(time.Duration) worker.Ticker { return ticker }))
+
+	calls := make(chan struct{}, 3)
+	runner.Add("test", time.Hour, func(context.Context) error {
+		calls <- struct{}{}
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		runner.Run(ctx)
+		close(done)
+	}()
+
+	for i := 0; i < 3; i++ {
+		ticker.ch <- time.Now()
+		<-calls
+	}
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run не завершился после отмены контекста")
+	}
+}
+
+func TestRunOnce(t *testing.T) {
+	runner := worker.New()
+	calls := 0
+	runner.Add("test", time.Hour, func(context.Context) error {
+		calls++
+		return nil
+	})
+
+	if err := runner.RunOnce(context.Background(), "test"); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("задача должна выполниться один раз, выполнилась %d", calls)
+	}
+	if err := runner.RunOnce(context.Background(), "missing"); !errors.Is(err, worker.ErrUnknownJob) {
+		t.Errorf("ожидалась ErrUnknownJob, получили %v", err)
+	}
+}
+
+// fakeElector выдает лидерство по команде теста: каждое значение из grants —
+// канал, закрытие которого означает потерю лидерства.
+type fakeElector struct {
+	grants chan chan struct{}
+}
+
+func (e *fakeElector) Lead(ctx context.Context) (context.Context, func(), error) {
+	select {
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	case lost := <-e.grants:
+		leadCtx, cancel := context.WithCancel(ctx)
+		go func() {
+			select {
+			case <-lost:
+				cancel()
+			case <-leadCtx.Done():
+			}
+		}()
+		return leadCtx, cancel, nil
+	}
+}
+
+func TestRunnerRunsJobsOnlyWhileLeader(t *testing.T) {
+	ticker := &manualTicker{ch: make(chan time.Time)}
+	elector := &fakeElector{grants: make(chan chan struct{})}
+	runner := worker.New(
+		worker.WithTicker(func(time.Duration) worker.Ticker { return ticker }),
+		worker.WithElector(elector),
+	)
+
+	calls := make(chan struct{}, 1)
+	runner.Add("test", time.Hour, func(context.Context) error {
+		calls <- struct{}{}
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		runner.Run(ctx)
+		close(done)
+	}()
+
+	tick := func() bool {
+		select {
+		case ticker.ch <- time.Now():
+			<-calls
+			return true
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}
+
+	if tick() {
+		t.Fatal("задача выполнилась до получения лидерства")
+	}
+
+	lost := make(chan struct{})
+	elector.grants <- lost
+	if !tick() {
+		t.Fatal("лидер не выполнил задачу")
+	}
+
+	close(lost)
+	// Run снова ждет лидерства, значит задачи уже остановлены.
+	elector.grants <- make(chan struct{})
+	if !tick() {
+		t.Fatal("задача не выполнилась после повторного получения лидерства")
+	}
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run не завершился после отмены контекста")
+	}
+}
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS trg_pr_reviewers_not_author ON pr_reviewers;
+DROP FUNCTION IF EXISTS check_reviewer_not_author();
\ No newline at end of file
@@ -0,0 +1,16 @@
+CREATE OR REPLACE FUNCTION check_reviewer_not_author() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM pull_requests
+        WHERE pull_request_id = NEW.pull_request_id AND author_id = NEW.user_id
+    ) THEN
+        RAISE EXCEPTION 'author % cannot review own pull request %', NEW.user_id, NEW.pull_request_id
+            USING ERRCODE = 'check_violation', CONSTRAINT = 'pr_reviewers_not_author';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_pr_reviewers_not_author
+    BEFORE INSERT OR UPDATE ON pr_reviewers
+    FOR EACH ROW EXECUTE FUNCTION check_reviewer_not_author();
\ No newline at end of file
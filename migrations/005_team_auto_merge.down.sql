@@ -0,0 +1 @@
+DROP TABLE IF EXISTS team_auto_merge;
\ No newline at end of file
@@ -0,0 +1,7 @@
+CREATE TABLE team_auto_merge (
+    team_name VARCHAR(255) PRIMARY KEY REFERENCES teams(team_name),
+    enabled BOOLEAN NOT NULL DEFAULT false,
+    provider VARCHAR(20) NOT NULL,
+    repository VARCHAR(255) NOT NULL,
+    required_approvals INTEGER NOT NULL DEFAULT 1 CHECK (required_approvals > 0)
+);
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS team_policies;
+DROP TABLE IF EXISTS approvals;
\ No newline at end of file
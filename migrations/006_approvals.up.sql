@@ -0,0 +1,12 @@
+CREATE TABLE approvals (
+    pull_request_id VARCHAR(255) NOT NULL REFERENCES pull_requests(pull_request_id),
+    user_id VARCHAR(255) NOT NULL REFERENCES users(user_id),
+    approved_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (pull_request_id, user_id)
+);
+
+CREATE TABLE team_policies (
+    team_name VARCHAR(255) PRIMARY KEY REFERENCES teams(team_name),
+    require_approvals BOOLEAN NOT NULL DEFAULT false,
+    required_approvals INTEGER CHECK (required_approvals > 0)
+);
\ No newline at end of file
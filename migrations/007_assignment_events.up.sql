@@ -0,0 +1,11 @@
+CREATE TABLE assignment_events (
+    id BIGSERIAL PRIMARY KEY,
+    pull_request_id VARCHAR(255) NOT NULL REFERENCES pull_requests(pull_request_id),
+    event_type VARCHAR(32) NOT NULL,
+    user_id VARCHAR(255) REFERENCES users(user_id),
+    new_user_id VARCHAR(255) REFERENCES users(user_id),
+    reason TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_assignment_events_pr ON assignment_events(pull_request_id, created_at);
\ No newline at end of file
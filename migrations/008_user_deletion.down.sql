@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS pr_reviewers_archive;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
\ No newline at end of file
@@ -0,0 +1,9 @@
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE TABLE pr_reviewers_archive (
+    pull_request_id VARCHAR(255) NOT NULL REFERENCES pull_requests(pull_request_id),
+    user_id VARCHAR(255) NOT NULL REFERENCES users(user_id),
+    archived_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    reason TEXT,
+    PRIMARY KEY (pull_request_id, user_id)
+);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN IF EXISTS review_weight;
\ No newline at end of file
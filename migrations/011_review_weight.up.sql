@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN review_weight INTEGER NOT NULL DEFAULT 1 CHECK (review_weight > 0);
\ No newline at end of file
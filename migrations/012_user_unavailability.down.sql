@@ -0,0 +1 @@
+DROP TABLE IF EXISTS user_unavailability;
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER TABLE users DROP COLUMN IF EXISTS manager_id;
+
+ALTER TABLE team_policies
+    DROP COLUMN IF EXISTS require_manager,
+    DROP COLUMN IF EXISTS cross_team_fallback,
+    DROP COLUMN IF EXISTS assignment_strategy,
+    DROP COLUMN IF EXISTS reviewer_count;
\ No newline at end of file
@@ -0,0 +1,8 @@
+ALTER TABLE team_policies
+    ADD COLUMN reviewer_count INTEGER NOT NULL DEFAULT 2 CHECK (reviewer_count >= 0),
+    ADD COLUMN assignment_strategy VARCHAR(32) NOT NULL DEFAULT 'random'
+        CHECK (assignment_strategy IN ('random', 'round_robin', 'least_loaded')),
+    ADD COLUMN cross_team_fallback BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN require_manager BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE users ADD COLUMN manager_id VARCHAR(255);
\ No newline at end of file
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS ownership_rules;
+
+ALTER TABLE pull_requests
+    DROP COLUMN IF EXISTS changed_paths,
+    DROP COLUMN IF EXISTS repository;
\ No newline at end of file
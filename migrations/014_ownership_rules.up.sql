@@ -0,0 +1,15 @@
+ALTER TABLE pull_requests
+    ADD COLUMN repository VARCHAR(255),
+    ADD COLUMN changed_paths TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE TABLE ownership_rules (
+    id BIGSERIAL PRIMARY KEY,
+    repository VARCHAR(255) NOT NULL,
+    pattern VARCHAR(500) NOT NULL,
+    user_id VARCHAR(255) REFERENCES users(user_id),
+    team_name VARCHAR(255) REFERENCES teams(team_name),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK ((user_id IS NULL) <> (team_name IS NULL))
+);
+
+CREATE INDEX idx_ownership_rules_repository ON ownership_rules(repository, id);
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS required_tags;
+
+DROP TABLE IF EXISTS user_tags;
\ No newline at end of file
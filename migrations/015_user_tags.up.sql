@@ -0,0 +1,9 @@
+CREATE TABLE user_tags (
+    user_id VARCHAR(255) NOT NULL REFERENCES users(user_id),
+    tag VARCHAR(64) NOT NULL,
+    PRIMARY KEY (user_id, tag)
+);
+
+CREATE INDEX idx_user_tags_tag ON user_tags(tag);
+
+ALTER TABLE pull_requests ADD COLUMN required_tags TEXT[] NOT NULL DEFAULT '{}';
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_pull_requests_open_created;
+
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS reminded_at;
\ No newline at end of file
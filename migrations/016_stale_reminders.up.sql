@@ -0,0 +1,3 @@
+ALTER TABLE pull_requests ADD COLUMN reminded_at TIMESTAMPTZ;
+
+CREATE INDEX idx_pull_requests_open_created ON pull_requests(created_at) WHERE status = 'OPEN';
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS notification_preferences;
+
+ALTER TABLE users DROP COLUMN IF EXISTS email;
\ No newline at end of file
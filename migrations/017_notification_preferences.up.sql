@@ -0,0 +1,7 @@
+ALTER TABLE users ADD COLUMN email VARCHAR(320);
+
+CREATE TABLE notification_preferences (
+    user_id VARCHAR(255) PRIMARY KEY REFERENCES users(user_id),
+    email_opt_out BOOLEAN NOT NULL DEFAULT false,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
\ No newline at end of file
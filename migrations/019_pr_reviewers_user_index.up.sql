@@ -0,0 +1 @@
+CREATE INDEX idx_pr_reviewers_user ON pr_reviewers(user_id);
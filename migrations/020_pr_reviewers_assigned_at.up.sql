@@ -0,0 +1,11 @@
+ALTER TABLE pr_reviewers ADD COLUMN assigned_at TIMESTAMPTZ;
+
+-- Текущие назначения берут время из истории, а без нее — время создания PR.
+UPDATE pr_reviewers r SET assigned_at = COALESCE(
+    (SELECT MAX(e.created_at) FROM assignment_events e
+     WHERE e.pull_request_id = r.pull_request_id AND e.new_user_id = r.user_id),
+    (SELECT p.created_at FROM pull_requests p WHERE p.pull_request_id = r.pull_request_id),
+    NOW());
+
+ALTER TABLE pr_reviewers ALTER COLUMN assigned_at SET DEFAULT NOW();
+ALTER TABLE pr_reviewers ALTER COLUMN assigned_at SET NOT NULL;
@@ -0,0 +1,3 @@
+CREATE INDEX idx_pull_requests_created ON pull_requests(created_at);
+
+CREATE INDEX idx_pull_requests_merged ON pull_requests(merged_at) WHERE status = 'MERGED';
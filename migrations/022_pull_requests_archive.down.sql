@@ -0,0 +1,19 @@
+INSERT INTO pull_requests(pull_request_id, pull_request_name, author_id, status, created_at, merged_at,
+    closed_at, version, repository, changed_paths, required_tags)
+SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at,
+    closed_at, version, repository, changed_paths, required_tags
+FROM pull_requests_archive;
+
+INSERT INTO pr_reviewers(pull_request_id, user_id, assigned_at)
+SELECT pull_request_id, user_id, assigned_at FROM pull_request_reviewers_archive;
+
+INSERT INTO approvals(pull_request_id, user_id)
+SELECT pull_request_id, unnest(approved_by) FROM pull_requests_archive;
+
+ALTER TABLE pr_reviewers_archive ADD CONSTRAINT pr_reviewers_archive_pull_request_id_fkey
+    FOREIGN KEY (pull_request_id) REFERENCES pull_requests(pull_request_id);
+ALTER TABLE assignment_events ADD CONSTRAINT assignment_events_pull_request_id_fkey
+    FOREIGN KEY (pull_request_id) REFERENCES pull_requests(pull_request_id);
+
+DROP TABLE IF EXISTS pull_request_reviewers_archive;
+DROP TABLE IF EXISTS pull_requests_archive;
@@ -0,0 +1,26 @@
+CREATE TABLE pull_requests_archive (
+    pull_request_id VARCHAR(255) PRIMARY KEY,
+    pull_request_name VARCHAR(255) NOT NULL,
+    author_id VARCHAR(255) NOT NULL REFERENCES users(user_id),
+    status VARCHAR(20) NOT NULL,
+    created_at TIMESTAMPTZ,
+    merged_at TIMESTAMPTZ,
+    closed_at TIMESTAMPTZ,
+    version INTEGER NOT NULL,
+    repository VARCHAR(255),
+    changed_paths TEXT[] NOT NULL DEFAULT '{}',
+    required_tags TEXT[] NOT NULL DEFAULT '{}',
+    approved_by TEXT[] NOT NULL DEFAULT '{}',
+    archived_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE pull_request_reviewers_archive (
+    pull_request_id VARCHAR(255) NOT NULL REFERENCES pull_requests_archive(pull_request_id),
+    user_id VARCHAR(255) NOT NULL REFERENCES users(user_id),
+    assigned_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (pull_request_id, user_id)
+);
+
+-- История назначений и снятые ревьюверы остаются после переноса PR в архив.
+ALTER TABLE assignment_events DROP CONSTRAINT assignment_events_pull_request_id_fkey;
+ALTER TABLE pr_reviewers_archive DROP CONSTRAINT pr_reviewers_archive_pull_request_id_fkey;
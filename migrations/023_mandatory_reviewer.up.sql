@@ -0,0 +1,2 @@
+ALTER TABLE team_policies
+    ADD COLUMN mandatory_reviewer VARCHAR(255) REFERENCES users(user_id) ON DELETE SET NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_pull_requests_labels;
+ALTER TABLE pull_requests_archive DROP COLUMN IF EXISTS priority, DROP COLUMN IF EXISTS labels;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS priority, DROP COLUMN IF EXISTS labels;
@@ -0,0 +1,10 @@
+ALTER TABLE pull_requests
+    ADD COLUMN labels TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN priority VARCHAR(16) NOT NULL DEFAULT 'normal'
+        CHECK (priority IN ('low', 'normal', 'high'));
+
+ALTER TABLE pull_requests_archive
+    ADD COLUMN labels TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN priority VARCHAR(16) NOT NULL DEFAULT 'normal';
+
+CREATE INDEX idx_pull_requests_labels ON pull_requests USING GIN (labels);
@@ -0,0 +1,3 @@
+ALTER TABLE pull_request_reviewers_archive DROP COLUMN IF EXISTS first_action_at;
+ALTER TABLE pr_reviewers_archive DROP COLUMN IF EXISTS first_action_at, DROP COLUMN IF EXISTS assigned_at;
+ALTER TABLE pr_reviewers DROP COLUMN IF EXISTS first_action_at;
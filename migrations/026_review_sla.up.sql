@@ -0,0 +1,13 @@
+ALTER TABLE pr_reviewers ADD COLUMN first_action_at TIMESTAMPTZ;
+
+-- Одобрения, сделанные до миграции, считаются первым действием ревьювера.
+UPDATE pr_reviewers r SET first_action_at = a.approved_at
+FROM approvals a
+WHERE a.pull_request_id = r.pull_request_id AND a.user_id = r.user_id;
+
+-- Снятые ревьюверы сохраняют время назначения и реакции для SLA.
+ALTER TABLE pr_reviewers_archive
+    ADD COLUMN assigned_at TIMESTAMPTZ,
+    ADD COLUMN first_action_at TIMESTAMPTZ;
+
+ALTER TABLE pull_request_reviewers_archive ADD COLUMN first_action_at TIMESTAMPTZ;
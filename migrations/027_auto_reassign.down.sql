@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_pr_reviewers_pending;
+ALTER TABLE team_policies DROP COLUMN IF EXISTS auto_reassign;
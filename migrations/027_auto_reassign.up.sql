@@ -0,0 +1,4 @@
+ALTER TABLE team_policies ADD COLUMN auto_reassign BOOLEAN NOT NULL DEFAULT false;
+
+-- Задача auto_reassign ищет назначения без реакции ревьювера.
+CREATE INDEX idx_pr_reviewers_pending ON pr_reviewers(assigned_at) WHERE first_action_at IS NULL;
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_audit_log_team_id;
+ALTER TABLE audit_log DROP COLUMN IF EXISTS team_id;
+
+ALTER TABLE users DROP CONSTRAINT users_team_name_fkey,
+    ADD CONSTRAINT users_team_name_fkey FOREIGN KEY (team_name) REFERENCES teams(team_name);
+ALTER TABLE team_auto_merge DROP CONSTRAINT team_auto_merge_team_name_fkey,
+    ADD CONSTRAINT team_auto_merge_team_name_fkey FOREIGN KEY (team_name) REFERENCES teams(team_name);
+ALTER TABLE team_policies DROP CONSTRAINT team_policies_team_name_fkey,
+    ADD CONSTRAINT team_policies_team_name_fkey FOREIGN KEY (team_name) REFERENCES teams(team_name);
+ALTER TABLE ownership_rules DROP CONSTRAINT ownership_rules_team_name_fkey,
+    ADD CONSTRAINT ownership_rules_team_name_fkey FOREIGN KEY (team_name) REFERENCES teams(team_name);
+
+ALTER TABLE teams DROP COLUMN IF EXISTS team_id;
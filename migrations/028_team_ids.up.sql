@@ -0,0 +1,25 @@
+-- Суррогатный ключ команды. Имя остается естественным ключом для ссылок и
+-- меняется каскадно при переименовании, а журнал аудита, который нельзя
+-- переписать, связывается с командой по team_id.
+ALTER TABLE teams ADD COLUMN team_id BIGINT GENERATED ALWAYS AS IDENTITY UNIQUE;
+
+ALTER TABLE users DROP CONSTRAINT users_team_name_fkey,
+    ADD CONSTRAINT users_team_name_fkey FOREIGN KEY (team_name)
+        REFERENCES teams(team_name) ON UPDATE CASCADE;
+ALTER TABLE team_auto_merge DROP CONSTRAINT team_auto_merge_team_name_fkey,
+    ADD CONSTRAINT team_auto_merge_team_name_fkey FOREIGN KEY (team_name)
+        REFERENCES teams(team_name) ON UPDATE CASCADE;
+ALTER TABLE team_policies DROP CONSTRAINT team_policies_team_name_fkey,
+    ADD CONSTRAINT team_policies_team_name_fkey FOREIGN KEY (team_name)
+        REFERENCES teams(team_name) ON UPDATE CASCADE;
+ALTER TABLE ownership_rules DROP CONSTRAINT ownership_rules_team_name_fkey,
+    ADD CONSTRAINT ownership_rules_team_name_fkey FOREIGN KEY (team_name)
+        REFERENCES teams(team_name) ON UPDATE CASCADE;
+
+ALTER TABLE audit_log ADD COLUMN team_id BIGINT REFERENCES teams(team_id);
+
+ALTER TABLE audit_log DISABLE TRIGGER trg_audit_log_append_only;
+UPDATE audit_log a SET team_id = t.team_id FROM teams t WHERE t.team_name = a.team_name;
+ALTER TABLE audit_log ENABLE TRIGGER trg_audit_log_append_only;
+
+CREATE INDEX idx_audit_log_team_id ON audit_log(team_id, created_at);
@@ -0,0 +1,4 @@
+ALTER TABLE pull_requests_archive
+    DROP COLUMN IF EXISTS description, DROP COLUMN IF EXISTS branch, DROP COLUMN IF EXISTS url;
+ALTER TABLE pull_requests
+    DROP COLUMN IF EXISTS description, DROP COLUMN IF EXISTS branch, DROP COLUMN IF EXISTS url;
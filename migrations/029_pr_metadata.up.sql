@@ -0,0 +1,9 @@
+ALTER TABLE pull_requests
+    ADD COLUMN url VARCHAR(2048),
+    ADD COLUMN branch VARCHAR(255),
+    ADD COLUMN description TEXT;
+
+ALTER TABLE pull_requests_archive
+    ADD COLUMN url VARCHAR(2048),
+    ADD COLUMN branch VARCHAR(255),
+    ADD COLUMN description TEXT;
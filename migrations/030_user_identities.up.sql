@@ -0,0 +1,7 @@
+CREATE TABLE user_identities (
+    user_id VARCHAR(255) NOT NULL REFERENCES users(user_id),
+    provider VARCHAR(16) NOT NULL CHECK (provider IN ('github', 'gitlab', 'slack')),
+    external_id VARCHAR(255) NOT NULL,
+    PRIMARY KEY (user_id, provider),
+    UNIQUE (provider, external_id)
+);
@@ -0,0 +1,2 @@
+-- Выгрузка /export/assignments читает события по диапазону времени в порядке (created_at, id).
+CREATE INDEX idx_assignment_events_created ON assignment_events(created_at, id);
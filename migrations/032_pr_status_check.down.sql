@@ -0,0 +1,7 @@
+UPDATE pull_requests SET status = 'OPEN' WHERE status IN ('IN_REVIEW', 'CHANGES_REQUESTED');
+
+DROP INDEX IF EXISTS idx_pull_requests_open_created;
+CREATE INDEX idx_pull_requests_open_created ON pull_requests(created_at) WHERE status = 'OPEN';
+
+ALTER TABLE pull_requests_archive DROP CONSTRAINT IF EXISTS pull_requests_archive_status_check;
+ALTER TABLE pull_requests DROP CONSTRAINT IF EXISTS pull_requests_status_check;
@@ -0,0 +1,11 @@
+-- Состояния PR (models.PRStatus): OPEN, IN_REVIEW и CHANGES_REQUESTED открыты, MERGED и CLOSED конечные.
+ALTER TABLE pull_requests ADD CONSTRAINT pull_requests_status_check
+    CHECK (status IN ('OPEN', 'IN_REVIEW', 'CHANGES_REQUESTED', 'MERGED', 'CLOSED'));
+
+ALTER TABLE pull_requests_archive ADD CONSTRAINT pull_requests_archive_status_check
+    CHECK (status IN ('OPEN', 'IN_REVIEW', 'CHANGES_REQUESTED', 'MERGED', 'CLOSED'));
+
+-- Напоминания о зависших PR ищут среди всех открытых состояний.
+DROP INDEX IF EXISTS idx_pull_requests_open_created;
+CREATE INDEX idx_pull_requests_open_created ON pull_requests(created_at)
+    WHERE status IN ('OPEN', 'IN_REVIEW', 'CHANGES_REQUESTED');
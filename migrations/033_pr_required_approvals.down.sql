@@ -0,0 +1,2 @@
+ALTER TABLE pull_requests_archive DROP COLUMN IF EXISTS required_approvals;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS required_approvals;
@@ -0,0 +1,3 @@
+-- Порог одобрений для merge конкретного PR; NULL — по политике команды автора.
+ALTER TABLE pull_requests ADD COLUMN required_approvals INTEGER CHECK (required_approvals >= 0);
+ALTER TABLE pull_requests_archive ADD COLUMN required_approvals INTEGER;
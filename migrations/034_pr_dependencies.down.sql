@@ -0,0 +1,2 @@
+ALTER TABLE pull_requests_archive DROP COLUMN IF EXISTS depends_on;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS depends_on;
@@ -0,0 +1,4 @@
+-- PR, которые должны быть смержены или закрыты до merge этого PR. Внешнего
+-- ключа нет: зависимость может уйти в архив.
+ALTER TABLE pull_requests ADD COLUMN depends_on TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE pull_requests_archive ADD COLUMN depends_on TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_assignment_events_new_user;
+CREATE INDEX IF NOT EXISTS idx_users_team ON users(team_name);
+DROP INDEX IF EXISTS idx_users_team_active;
+DROP INDEX IF EXISTS idx_pull_requests_status_created;
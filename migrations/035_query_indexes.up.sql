@@ -0,0 +1,13 @@
+-- Индексы под частые запросы. pr_reviewers(user_id) уже есть (019).
+
+-- Фильтр по статусу с сортировкой по времени создания: зависимости PR,
+-- списки ревью с фильтром status, статистика.
+CREATE INDEX idx_pull_requests_status_created ON pull_requests(status, created_at);
+
+-- Кандидаты в ревьюверы и деактивация команды: team_name = $1 AND is_active.
+-- Покрывает и запросы по одной team_name, поэтому idx_users_team не нужен.
+CREATE INDEX idx_users_team_active ON users(team_name, is_active);
+DROP INDEX IF EXISTS idx_users_team;
+
+-- Время последнего назначения кандидата: MAX(created_at) по new_user_id.
+CREATE INDEX idx_assignment_events_new_user ON assignment_events(new_user_id, created_at);
@@ -0,0 +1,4 @@
+ALTER TABLE notification_preferences
+    DROP COLUMN IF EXISTS last_digest_at,
+    DROP COLUMN IF EXISTS timezone,
+    DROP COLUMN IF EXISTS digest_time;
@@ -0,0 +1,4 @@
+ALTER TABLE notification_preferences
+    ADD COLUMN digest_time TIME,
+    ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN last_digest_at TIMESTAMPTZ;
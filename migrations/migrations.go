@@ -0,0 +1,35 @@
+// Package migrations встраивает SQL-миграции в бинарник, чтобы сервис не зависел
+// от расположения каталога на диске.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// FS содержит файлы NNN_name.up.sql и NNN_name.down.sql для golang-migrate.
+//
+//go:embed *.sql
+var FS embed.FS
+
+// Latest возвращает номер последней встроенной миграции.
+func Latest() (uint, error) {
+	entries, err := fs.ReadDir(FS, ".")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint
+	for _, e := range entries {
+		num, _, ok := strings.Cut(e.Name(), "_")
+		if !ok || !strings.HasSuffix(e.Name(), ".up.sql") {
+			continue
+		}
+		if v, err := strconv.ParseUint(num, 10, 64); err == nil {
+			latest = max(latest, uint(v))
+		}
+	}
+	return latest, nil
+}